@@ -0,0 +1,406 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archive formats supported by the --compress-format flag
+const (
+	archiveFormatZip    = "zip"
+	archiveFormatTarGz  = "tar.gz"
+	archiveFormatTarZst = "tar.zst"
+)
+
+// validateCompressFormat checks the archive format and that the level can be parsed for it.
+func validateCompressFormat(format, level string) error {
+	switch format {
+	case archiveFormatZip, archiveFormatTarGz:
+		if _, err := parseDeflateLevel(level); err != nil {
+			return err
+		}
+	case archiveFormatTarZst:
+		if _, err := parseZstdLevel(level); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid compress-format '%s': must be one of %s, %s, %s",
+			format, archiveFormatZip, archiveFormatTarGz, archiveFormatTarZst)
+	}
+	return nil
+}
+
+// archiveExtension returns the file extension (including the leading dot) for an archive format.
+func archiveExtension(format string) string {
+	return "." + format
+}
+
+// detectArchiveFormat returns the archive format for a path based on its extension,
+// or an empty string if the path is not a recognised archive.
+func detectArchiveFormat(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		return archiveFormatZip
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		return archiveFormatTarGz
+	case strings.HasSuffix(path, ".tar.zst"):
+		return archiveFormatTarZst
+	}
+	return ""
+}
+
+// trimArchiveExtension strips a recognised archive extension from a file name.
+func trimArchiveExtension(name string) string {
+	for _, ext := range []string{".zip", ".tar.gz", ".tgz", ".tar.zst"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext)
+		}
+	}
+	return name
+}
+
+// fileExtension returns the full archive extension of name (e.g. ".tar.gz"),
+// falling back to filepath.Ext for anything else.
+func fileExtension(name string) string {
+	if trimmed := trimArchiveExtension(name); trimmed != name {
+		return name[len(trimmed):]
+	}
+	return filepath.Ext(name)
+}
+
+// gzipFileExt is added to the name of data files written with --compress-sql-files.
+const gzipFileExt = ".gz"
+
+// readDataFile reads a data file, decompressing it when its name ends in .gz.
+func readDataFile(path string) ([]byte, error) {
+	if !strings.HasSuffix(path, gzipFileExt) {
+		return os.ReadFile(path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	defer gz.Close()
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return data, nil
+}
+
+// parseDeflateLevel parses a gzip/deflate level (-1 to 9). An empty level selects the default.
+func parseDeflateLevel(level string) (int, error) {
+	if level == "" {
+		return flate.DefaultCompression, nil
+	}
+	n, err := strconv.Atoi(level)
+	if err != nil || n < flate.HuffmanOnly || n > flate.BestCompression {
+		return 0, fmt.Errorf("invalid compress-level '%s': must be an integer between %d and %d",
+			level, flate.HuffmanOnly, flate.BestCompression)
+	}
+	return n, nil
+}
+
+// parseZstdLevel parses a zstd level, either by name (fastest, default, better, best)
+// or as a numeric zstd level. An empty level selects the default.
+func parseZstdLevel(level string) (zstd.EncoderLevel, error) {
+	if level == "" {
+		return zstd.SpeedDefault, nil
+	}
+	if n, err := strconv.Atoi(level); err == nil {
+		return zstd.EncoderLevelFromZstd(n), nil
+	}
+	if ok, l := zstd.EncoderLevelFromString(level); ok {
+		return l, nil
+	}
+	return 0, fmt.Errorf("invalid compress-level '%s' for %s: use a number or one of fastest, default, better, best",
+		level, archiveFormatTarZst)
+}
+
+// createArchive packs the export directory into archiveFileName using the given format and level.
+func createArchive(exportPath, archiveFileName, format, level string) error {
+	switch format {
+	case archiveFormatZip:
+		n, err := parseDeflateLevel(level)
+		if err != nil {
+			return err
+		}
+		return createZipArchive(exportPath, archiveFileName, n)
+	case archiveFormatTarGz:
+		n, err := parseDeflateLevel(level)
+		if err != nil {
+			return err
+		}
+		return createTarArchive(exportPath, archiveFileName, func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, n)
+		})
+	case archiveFormatTarZst:
+		l, err := parseZstdLevel(level)
+		if err != nil {
+			return err
+		}
+		return createTarArchive(exportPath, archiveFileName, func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(l))
+		})
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+// extractArchive unpacks an archive into destPath, picking the format from the file extension.
+func extractArchive(archivePath, destPath string) error {
+	switch detectArchiveFormat(archivePath) {
+	case archiveFormatZip:
+		return unzipFile(archivePath, destPath)
+	case archiveFormatTarGz:
+		return extractTarArchive(archivePath, destPath, func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		})
+	case archiveFormatTarZst:
+		return extractTarArchive(archivePath, destPath, func(r io.Reader) (io.ReadCloser, error) {
+			dec, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return dec.IOReadCloser(), nil
+		})
+	default:
+		return fmt.Errorf("unsupported archive format: %s", archivePath)
+	}
+}
+
+// createZipArchive creates a zip file containing the contents of the export directory.
+func createZipArchive(exportPath string, zipFileName string, level int) error {
+	zipFile, err := os.Create(zipFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create zip file %s: %v", zipFileName, err)
+	}
+	defer zipFile.Close() // Ensure file is closed even on error during walk
+
+	zipWriter := zip.NewWriter(zipFile)
+	defer zipWriter.Close() // Ensure writer is closed
+	zipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+
+	// Walk through the export directory and add files to zip
+	err = filepath.Walk(exportPath, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr // Propagate walk error
+		}
+
+		// Skip the root export directory itself
+		if path == exportPath {
+			return nil
+		}
+
+		// Skip directories (zip writer handles directory entries implicitly)
+		if info.IsDir() {
+			return nil
+		}
+
+		// Create a new file header
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return fmt.Errorf("failed to create zip header for %s: %v", path, err)
+		}
+
+		// Set the name in the archive relative to the export directory's parent
+		// This ensures the timestamped directory is the root inside the zip
+		relPath, err := filepath.Rel(filepath.Dir(exportPath), path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %v", path, err)
+		}
+		header.Name = relPath
+		header.Method = zip.Deflate // Use compression
+
+		// Create writer for this file within zip
+		writer, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to create zip entry for %s: %v", path, err)
+		}
+
+		// Open the file to be zipped
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file %s for zipping: %v", path, err)
+		}
+		defer file.Close()
+
+		// Copy the file content into the zip
+		_, err = io.Copy(writer, file)
+		if err != nil {
+			return fmt.Errorf("failed to write file %s to zip: %v", path, err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		// Attempt to remove partially created zip file on error
+		zipWriter.Close()
+		zipFile.Close()
+		os.Remove(zipFileName)
+		return fmt.Errorf("failed during zip archive creation: %v", err)
+	}
+
+	// Explicitly close writer and file before returning success
+	if err := zipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finalize zip writer: %v", err)
+	}
+	if err := zipFile.Close(); err != nil {
+		return fmt.Errorf("failed to close zip file handle: %v", err)
+	}
+
+	fmt.Printf("Successfully created zip archive: %s\n", zipFileName)
+	return nil
+}
+
+// createTarArchive creates a compressed tar file containing the contents of the export directory.
+// newCompressor wraps the output file with the chosen compression stream.
+func createTarArchive(exportPath, archiveFileName string, newCompressor func(io.Writer) (io.WriteCloser, error)) error {
+	archiveFile, err := os.Create(archiveFileName)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file %s: %v", archiveFileName, err)
+	}
+	defer archiveFile.Close()
+
+	compressor, err := newCompressor(archiveFile)
+	if err != nil {
+		os.Remove(archiveFileName)
+		return fmt.Errorf("failed to create compressor for %s: %v", archiveFileName, err)
+	}
+	tarWriter := tar.NewWriter(compressor)
+
+	err = filepath.Walk(exportPath, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if path == exportPath || info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return fmt.Errorf("failed to create tar header for %s: %v", path, err)
+		}
+
+		// Keep the timestamped directory as the root inside the archive, as with zip
+		relPath, err := filepath.Rel(filepath.Dir(exportPath), path)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %s: %v", path, err)
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write tar header for %s: %v", path, err)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open file %s for archiving: %v", path, err)
+		}
+		defer file.Close()
+
+		if _, err := io.Copy(tarWriter, file); err != nil {
+			return fmt.Errorf("failed to write file %s to archive: %v", path, err)
+		}
+		return nil
+	})
+
+	if err != nil {
+		tarWriter.Close()
+		compressor.Close()
+		archiveFile.Close()
+		os.Remove(archiveFileName)
+		return fmt.Errorf("failed during archive creation: %v", err)
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to finalize tar writer: %v", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("failed to finalize compressor: %v", err)
+	}
+	if err := archiveFile.Close(); err != nil {
+		return fmt.Errorf("failed to close archive file handle: %v", err)
+	}
+
+	fmt.Printf("Successfully created archive: %s\n", archiveFileName)
+	return nil
+}
+
+// extractTarArchive extracts a compressed tar file into destPath.
+// newDecompressor wraps the input file with the matching decompression stream.
+func extractTarArchive(archivePath, destPath string, newDecompressor func(io.Reader) (io.ReadCloser, error)) error {
+	fmt.Printf("Opening archive: %s\n", archivePath)
+	archiveFile, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer archiveFile.Close()
+
+	decompressor, err := newDecompressor(archiveFile)
+	if err != nil {
+		return fmt.Errorf("failed to read archive %s: %v", archivePath, err)
+	}
+	defer decompressor.Close()
+
+	tarReader := tar.NewReader(decompressor)
+	cleanDest := filepath.Clean(destPath) + string(os.PathSeparator)
+
+	extractedCount := 0
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive entry: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// Reject entries that would escape the destination directory
+		path := filepath.Join(destPath, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, cleanDest) {
+			return fmt.Errorf("invalid archive entry path: %s", header.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %v", err)
+		}
+
+		outFile, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create file: %v", err)
+		}
+		written, err := io.Copy(outFile, tarReader)
+		outFile.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write file: %v", err)
+		}
+
+		extractedCount++
+		fmt.Printf("Extracted: %s (%d bytes)\n", header.Name, written)
+	}
+
+	fmt.Printf("Successfully extracted %d files\n", extractedCount)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSampleExport creates an export directory with a metadata file and a
+// data file of roughly rows INSERT statements, similar to a real export.
+func writeSampleExport(t testing.TB, dir string, rows int) string {
+	exportPath := filepath.Join(dir, "mydb_20240101_120000")
+	require.NoError(t, os.MkdirAll(exportPath, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(exportPath, "0_metadata.json"), []byte(`{"database_name":"mydb"}`), 0644))
+
+	var sb strings.Builder
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&sb, "INSERT INTO `users` (`id`, `name`, `email`) VALUES (%d, 'user_%d', 'user_%d@example.com');\n", i, i, i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(exportPath, "1_users.sql"), []byte(sb.String()), 0644))
+	return exportPath
+}
+
+func TestCreateAndExtractArchive(t *testing.T) {
+	for _, format := range []string{archiveFormatZip, archiveFormatTarGz, archiveFormatTarZst} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			exportPath := writeSampleExport(t, dir, 100)
+			archivePath := exportPath + archiveExtension(format)
+
+			require.NoError(t, createArchive(exportPath, archivePath, format, ""))
+			assert.Equal(t, format, detectArchiveFormat(archivePath))
+
+			destPath := filepath.Join(dir, "extracted")
+			require.NoError(t, extractArchive(archivePath, destPath))
+
+			original, err := os.ReadFile(filepath.Join(exportPath, "1_users.sql"))
+			require.NoError(t, err)
+			extracted, err := os.ReadFile(filepath.Join(destPath, filepath.Base(exportPath), "1_users.sql"))
+			require.NoError(t, err)
+			assert.Equal(t, original, extracted)
+		})
+	}
+}
+
+func TestValidateCompressFormat(t *testing.T) {
+	assert.NoError(t, validateCompressFormat(archiveFormatZip, ""))
+	assert.NoError(t, validateCompressFormat(archiveFormatTarGz, "9"))
+	assert.NoError(t, validateCompressFormat(archiveFormatTarZst, "best"))
+	assert.NoError(t, validateCompressFormat(archiveFormatTarZst, "19"))
+	assert.Error(t, validateCompressFormat("rar", ""))
+	assert.Error(t, validateCompressFormat(archiveFormatTarGz, "best"))
+	assert.Error(t, validateCompressFormat(archiveFormatZip, "12"))
+}
+
+func TestFileExtension(t *testing.T) {
+	assert.Equal(t, ".tar.gz", fileExtension("mydb_20240101_120000.tar.gz"))
+	assert.Equal(t, ".tar.zst", fileExtension("mydb_20240101_120000.tar.zst"))
+	assert.Equal(t, ".zip", fileExtension("mydb_20240101_120000.zip"))
+	assert.Equal(t, ".json", fileExtension("backup.json"))
+}
+
+// BenchmarkCreateArchive compares speed and output size of each archive format.
+// Run with: go test ./cmd/syncdb -run '^$' -bench CreateArchive
+func BenchmarkCreateArchive(b *testing.B) {
+	dir := b.TempDir()
+	exportPath := writeSampleExport(b, dir, 50000)
+
+	for _, format := range []string{archiveFormatZip, archiveFormatTarGz, archiveFormatTarZst} {
+		b.Run(format, func(b *testing.B) {
+			archivePath := exportPath + archiveExtension(format)
+			for i := 0; i < b.N; i++ {
+				if err := createArchive(exportPath, archivePath, format, ""); err != nil {
+					b.Fatal(err)
+				}
+			}
+			info, err := os.Stat(archivePath)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(info.Size()), "bytes/archive")
+		})
+	}
+}
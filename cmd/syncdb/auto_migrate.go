@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// columnMigrator rewrites INSERT statements from a data file to match the
+// columns of the live table for --auto-migrate: columns that were dropped from
+// the table are left out, and columns added since the export are filled with
+// their default.
+type columnMigrator struct {
+	driver   string
+	table    string
+	columns  []string          // Columns of the live table
+	defaults map[string]string // Column defaults of the live table
+	warned   bool              // Whether the column differences were already reported
+}
+
+func newColumnMigrator(driver string, schema *db.SchemaInfo) *columnMigrator {
+	return &columnMigrator{
+		driver:   driver,
+		table:    schema.Name,
+		columns:  schema.Columns,
+		defaults: schema.ColumnDefaults,
+	}
+}
+
+// migrate rewrites a data chunk for the live table. Statements that already
+// match the live columns, and statements that are not INSERT ... VALUES, are
+// returned unchanged.
+func (m *columnMigrator) migrate(chunk string) (string, error) {
+	stmt, ok, err := parseInsertStatement(chunk)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse data for %s: %v", m.table, err)
+	}
+	if !ok {
+		return chunk, nil
+	}
+
+	live := make(map[string]bool, len(m.columns))
+	for _, col := range m.columns {
+		live[col] = true
+	}
+	exported := make(map[string]bool, len(stmt.columns))
+	var keep []int
+	var dropped []string
+	for i, col := range stmt.columns {
+		exported[col] = true
+		if live[col] {
+			keep = append(keep, i)
+		} else {
+			dropped = append(dropped, col)
+		}
+	}
+	var added, addedValues []string
+	for _, col := range m.columns {
+		if exported[col] {
+			continue
+		}
+		added = append(added, col)
+		// DEFAULT evaluates the column default on the server, which covers
+		// expressions such as CURRENT_TIMESTAMP or sequences
+		if _, ok := m.defaults[col]; ok {
+			addedValues = append(addedValues, "DEFAULT")
+		} else {
+			addedValues = append(addedValues, "NULL")
+		}
+	}
+	if len(dropped) == 0 && len(added) == 0 {
+		return chunk, nil
+	}
+	if len(keep) == 0 {
+		return "", fmt.Errorf("none of the exported columns of table %s exist in the target table", m.table)
+	}
+
+	if !m.warned {
+		for _, col := range dropped {
+			fmt.Printf("Warning: column '%s' no longer exists in table '%s' and will be skipped\n", col, m.table)
+		}
+		for _, col := range added {
+			fmt.Printf("Column '%s' of table '%s' is missing from the export and will use its default\n", col, m.table)
+		}
+		m.warned = true
+	}
+
+	columns := make([]string, 0, len(keep)+len(added))
+	for _, i := range keep {
+		columns = append(columns, stmt.columns[i])
+	}
+	columns = append(columns, added...)
+	escapedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		escapedColumns[i] = db.EscapeIdentifier(m.driver, col)
+	}
+	stmt.head = fmt.Sprintf("%s(%s) VALUES", stmt.head[:strings.IndexByte(stmt.head, '(')], strings.Join(escapedColumns, ", "))
+
+	for r, values := range stmt.values {
+		rowValues := make([]string, 0, len(columns))
+		for _, i := range keep {
+			rowValues = append(rowValues, values[i])
+		}
+		rowValues = append(rowValues, addedValues...)
+		stmt.rows[r] = "(" + strings.Join(rowValues, ", ") + ")"
+	}
+	stmt.tail = dropUpsertAssignments(stmt.tail, dropped, columns[0], m.driver)
+	return stmt.String(), nil
+}
+
+// dropUpsertAssignments removes the assignments to dropped columns from the
+// conflict clause of an upsert statement. When no assignment is left, MySQL
+// keeps the existing row with a no-op assignment to firstColumn and PostgreSQL
+// uses DO NOTHING.
+func dropUpsertAssignments(tail string, dropped []string, firstColumn, driver string) string {
+	if len(dropped) == 0 {
+		return tail
+	}
+	marker := "ON DUPLICATE KEY UPDATE "
+	idx := strings.Index(tail, marker)
+	if idx < 0 {
+		marker = "DO UPDATE SET "
+		if idx = strings.Index(tail, marker); idx < 0 {
+			return tail
+		}
+	}
+
+	droppedSet := make(map[string]bool, len(dropped))
+	for _, col := range dropped {
+		droppedSet[col] = true
+	}
+	var assignments []string
+	for _, assignment := range strings.Split(tail[idx+len(marker):], ",") {
+		col, _, _ := strings.Cut(assignment, "=")
+		if !droppedSet[strings.Trim(strings.TrimSpace(col), "`\"")] {
+			assignments = append(assignments, assignment)
+		}
+	}
+	if len(assignments) > 0 {
+		return tail[:idx+len(marker)] + strings.Join(assignments, ",")
+	}
+	if driver == db.DriverPostgres {
+		return tail[:idx] + "DO NOTHING"
+	}
+	col := db.EscapeIdentifier(driver, firstColumn)
+	return tail[:idx+len(marker)] + col + "=" + col
+}
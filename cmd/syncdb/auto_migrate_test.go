@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnMigrator(t *testing.T) {
+	schema := &db.SchemaInfo{
+		Name:           "users",
+		Columns:        []string{"id", "name", "created_at", "nickname"},
+		ColumnDefaults: map[string]string{"created_at": "CURRENT_TIMESTAMP"},
+	}
+
+	t.Run("Added and dropped columns", func(t *testing.T) {
+		m := newColumnMigrator(db.DriverMySQL, schema)
+		migrated, err := m.migrate("INSERT INTO `users` (`id`, `name`, `legacy`) VALUES\n(1, 'alice', 'x'),\n(2, 'bob', NULL);")
+		require.NoError(t, err)
+		assert.Equal(t, "INSERT INTO `users` (`id`, `name`, `created_at`, `nickname`) VALUES\n"+
+			"(1, 'alice', DEFAULT, NULL),\n(2, 'bob', DEFAULT, NULL);", migrated)
+	})
+
+	t.Run("Matching columns are unchanged", func(t *testing.T) {
+		m := newColumnMigrator(db.DriverMySQL, schema)
+		chunk := "INSERT INTO `users` (`nickname`, `id`, `name`, `created_at`) VALUES\n(NULL, 1, 'alice', NOW());"
+		migrated, err := m.migrate(chunk)
+		require.NoError(t, err)
+		assert.Equal(t, chunk, migrated)
+	})
+
+	t.Run("Upsert clause drops removed columns", func(t *testing.T) {
+		m := newColumnMigrator(db.DriverPostgres, schema)
+		migrated, err := m.migrate(`INSERT INTO "users" ("id", "name", "created_at", "nickname", "legacy") VALUES` +
+			"\n(1, 'alice', NOW(), NULL, 'x')\n" + `ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name","legacy"=EXCLUDED."legacy";`)
+		require.NoError(t, err)
+		assert.Equal(t, `INSERT INTO "users" ("id", "name", "created_at", "nickname") VALUES`+
+			"\n(1, 'alice', NOW(), NULL)\n"+`ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name";`, migrated)
+	})
+
+	t.Run("No exported column exists", func(t *testing.T) {
+		m := newColumnMigrator(db.DriverMySQL, schema)
+		_, err := m.migrate("INSERT INTO `users` (`legacy`) VALUES\n('x');")
+		assert.Error(t, err)
+	})
+}
+
+func TestDropUpsertAssignments(t *testing.T) {
+	assert.Equal(t, "ON DUPLICATE KEY UPDATE `id`=`id`",
+		dropUpsertAssignments("ON DUPLICATE KEY UPDATE `legacy`=VALUES(`legacy`)", []string{"legacy"}, "id", db.DriverMySQL))
+	assert.Equal(t, `ON CONFLICT ("id") DO NOTHING`,
+		dropUpsertAssignments(`ON CONFLICT ("id") DO UPDATE SET "legacy"=EXCLUDED."legacy"`, []string{"legacy"}, "id", db.DriverPostgres))
+	assert.Equal(t, "ON CONFLICT DO NOTHING",
+		dropUpsertAssignments("ON CONFLICT DO NOTHING", []string{"legacy"}, "id", db.DriverPostgres))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// resolveBinaryFormat validates --binary-format. base64 is the same as
+// --base64-blobs; both only encode binary columns.
+func resolveBinaryFormat(cmdArgs *CommonArgs) error {
+	switch cmdArgs.BinaryFormat {
+	case "":
+	case db.BinaryFormatBase64:
+		cmdArgs.Base64Blobs = true
+	case db.BinaryFormatHex:
+		if cmdArgs.Base64Blobs {
+			return fmt.Errorf("--binary-format hex cannot be combined with --base64-blobs or --base64")
+		}
+	default:
+		return fmt.Errorf("invalid binary format %q (valid values: base64, hex)", cmdArgs.BinaryFormat)
+	}
+	return nil
+}
+
+// hexLiteral renders hex encoded binary data as a literal of the driver:
+// X'...' for MySQL and a bytea hex escape E'\\x...' for PostgreSQL.
+func hexLiteral(hexValue, driver string) string {
+	if driver == db.DriverPostgres {
+		return `E'\\x` + hexValue + "'"
+	}
+	return "X'" + hexValue + "'"
+}
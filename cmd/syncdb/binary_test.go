@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHexLiteralRoundTrip(t *testing.T) {
+	blob := []byte{0x00, 0x27, 0x5c, 0xde, 0xad, 0xbe, 0xef, 0xff}
+	encoded := hex.EncodeToString(blob)
+
+	testCases := []struct {
+		driver   string
+		expected string
+		prefix   string
+	}{
+		{db.DriverMySQL, "X'00275cdeadbeefff'", "X'"},
+		{db.DriverPostgres, `E'\\x00275cdeadbeefff'`, `E'\\x`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.driver, func(t *testing.T) {
+			literal := hexLiteral(encoded, tc.driver)
+			assert.Equal(t, tc.expected, literal)
+
+			// The literal survives parsing of the data file as one value
+			stmt, ok, err := parseInsertStatement("INSERT INTO files (id, data) VALUES\n(1, " + literal + ");")
+			require.NoError(t, err)
+			require.True(t, ok)
+			value := stmt.values[0][1]
+			decoded, err := hex.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, tc.prefix), "'"))
+			require.NoError(t, err)
+			assert.Equal(t, blob, decoded)
+		})
+	}
+}
+
+func TestResolveBinaryFormat(t *testing.T) {
+	cmdArgs := &CommonArgs{BinaryFormat: "base64"}
+	require.NoError(t, resolveBinaryFormat(cmdArgs))
+	assert.True(t, cmdArgs.Base64Blobs)
+	assert.False(t, cmdArgs.Base64Strings)
+
+	require.NoError(t, resolveBinaryFormat(&CommonArgs{BinaryFormat: "hex"}))
+	require.NoError(t, resolveBinaryFormat(&CommonArgs{}))
+	require.NoError(t, resolveBinaryFormat(&CommonArgs{BinaryFormat: "hex", Base64Strings: true}))
+	assert.Error(t, resolveBinaryFormat(&CommonArgs{BinaryFormat: "hex", Base64Blobs: true}))
+	assert.Error(t, resolveBinaryFormat(&CommonArgs{BinaryFormat: "octal"}))
+}
+
+func TestBase64Scope(t *testing.T) {
+	blob := "bin'ary"
+	encodedBlob := "'" + base64.StdEncoding.EncodeToString([]byte(blob)) + "'"
+	encodedText := "'" + base64.StdEncoding.EncodeToString([]byte("it's")) + "'"
+
+	testCases := []struct {
+		name         string
+		args         CommonArgs
+		expectedBlob string
+		expectedText string
+	}{
+		{"no encoding", CommonArgs{}, "'bin''ary'", "'it''s'"},
+		{"blobs only", CommonArgs{Base64Blobs: true}, encodedBlob, "'it''s'"},
+		{"strings only", CommonArgs{Base64Strings: true}, "'bin''ary'", encodedText},
+		{"blobs and strings", CommonArgs{Base64Blobs: true, Base64Strings: true}, encodedBlob, encodedText},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, driver := range []string{db.DriverMySQL, db.DriverPostgres} {
+				blobType, textType := "blob", "text"
+				if driver == db.DriverPostgres {
+					blobType = "bytea"
+				}
+				value, err := formatColumnValue(blob, blobType, driver, &tc.args)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedBlob, value, driver)
+
+				value, err = formatColumnValue("it's", textType, driver, &tc.args)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expectedText, value, driver)
+			}
+		})
+	}
+
+	// Empty binary values still follow --empty-string-as-null
+	value, err := formatColumnValue("", "varbinary", db.DriverMySQL, &CommonArgs{Base64Blobs: true, EmptyStringAsNull: true})
+	require.NoError(t, err)
+	assert.Equal(t, "NULL", value)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// defaultBlobThreshold is the default --blob-threshold.
+const defaultBlobThreshold = "1MB"
+
+// blobSidecarExt is the extension of the files binary values are exported to.
+const blobSidecarExt = ".bin"
+
+// blobSidecarName returns the name of the file holding a binary value exported
+// with --blob-threshold: {index}_{table}_{column}_{row}.bin, where row is the
+// position of the row in the table's export, counting from 1.
+func blobSidecarName(tableIndex int, table, column string, row int) string {
+	return fmt.Sprintf("%d_%s_%s_%d%s", tableIndex, table, column, row, blobSidecarExt)
+}
+
+// isBlobSidecarFile reports whether fileName is a BLOB sidecar file.
+func isBlobSidecarFile(fileName string) bool {
+	return strings.HasSuffix(fileName, blobSidecarExt)
+}
+
+// countBlobSidecars returns the number of BLOB sidecar files in files.
+func countBlobSidecars(files []string) int {
+	count := 0
+	for _, file := range files {
+		if isBlobSidecarFile(file) {
+			count++
+		}
+	}
+	return count
+}
+
+// writeBlobSidecar writes a binary value larger than threshold bytes to its
+// sidecar file in exportPath and returns the file's path and the placeholder
+// to write in the INSERT statement instead of the value (see
+// db.BlobPlaceholder). value is the exported string of the column, hex
+// encoded with --binary-format hex. It returns an empty path when the value
+// stays in the statement.
+func writeBlobSidecar(exportPath string, tableIndex int, table, column string, row int, value string, hexEncoded bool, threshold int64) (string, string, error) {
+	if threshold <= 0 {
+		return "", "", nil
+	}
+	data := []byte(value)
+	if hexEncoded {
+		if int64(len(value)/2) <= threshold {
+			return "", "", nil
+		}
+		var err error
+		if data, err = hex.DecodeString(value); err != nil {
+			return "", "", fmt.Errorf("invalid hex value: %v", err)
+		}
+	}
+	if int64(len(data)) <= threshold {
+		return "", "", nil
+	}
+	name := blobSidecarName(tableIndex, table, column, row)
+	path := filepath.Join(exportPath, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to write BLOB file %s: %v", path, err)
+	}
+	return path, db.BlobPlaceholder(name), nil
+}
+
+// removeBlobSidecars removes the BLOB sidecar files of a table.
+func removeBlobSidecars(exportPath string, tableIndex int, table string) {
+	files, _ := filepath.Glob(filepath.Join(exportPath, fmt.Sprintf("%d_%s_*%s", tableIndex, table, blobSidecarExt)))
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
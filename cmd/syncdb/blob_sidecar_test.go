@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBlobSidecar(t *testing.T) {
+	dir := t.TempDir()
+	large := strings.Repeat("\x00\xff", 8)
+
+	// Values up to the threshold stay in the statement
+	file, placeholder, err := writeBlobSidecar(dir, 3, "files", "data", 1, large, false, 16)
+	require.NoError(t, err)
+	assert.Empty(t, file)
+	assert.Empty(t, placeholder)
+
+	file, placeholder, err = writeBlobSidecar(dir, 3, "files", "data", 2, large, false, 15)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "3_files_data_2.bin"), file)
+	assert.Equal(t, "LOAD_BLOB_FILE('3_files_data_2.bin')", placeholder)
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, []byte(large), data)
+
+	// Hex encoded values are compared and written decoded
+	file, _, err = writeBlobSidecar(dir, 3, "files", "data", 3, "00ff00ff", true, 3)
+	require.NoError(t, err)
+	data, err = os.ReadFile(file)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0xff, 0x00, 0xff}, data)
+	file, _, err = writeBlobSidecar(dir, 3, "files", "data", 4, "00ff00ff", true, 4)
+	require.NoError(t, err)
+	assert.Empty(t, file)
+
+	// A threshold of 0 disables sidecar files
+	file, _, err = writeBlobSidecar(dir, 3, "files", "data", 5, large, false, 0)
+	require.NoError(t, err)
+	assert.Empty(t, file)
+
+	assert.Equal(t, 2, countBlobSidecars([]string{"3_files.sql", filepath.Join(dir, "3_files_data_2.bin"), "3_files_data_3.bin"}))
+	removeBlobSidecars(dir, 3, "files")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/catalog"
+	"github.com/spf13/cobra"
+)
+
+func newCatalogCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catalog",
+		Short: "Show the last successful export of each database",
+		Long: `Every successful export is recorded in catalog.json in the syncdb directory
+($SYNCDB_PATH or the user configuration directory). Entries are keyed by
+driver:host:port/database; commands taking a database also accept the full key.
+Examples:
+  syncdb catalog list
+  syncdb import --path $(syncdb catalog get mydb) --database mydb_copy
+  syncdb catalog clear mydb`,
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the last export of each database",
+		Args:  cobra.NoArgs,
+		RunE:  runCatalogList,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get <database>",
+		Short: "Print the path of the last export of a database",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runCatalogGet,
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear <database>",
+		Short: "Remove the catalog entry of a database",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runCatalogClear,
+	})
+	return cmd
+}
+
+func runCatalogList(cmd *cobra.Command, args []string) error {
+	c, err := catalog.Open(os.Getenv("SYNCDB_PATH"))
+	if err != nil {
+		return err
+	}
+	entries, err := c.Entries()
+	if err != nil {
+		return err
+	}
+	printCatalogEntries(cmd.OutOrStdout(), entries)
+	return nil
+}
+
+func runCatalogGet(cmd *cobra.Command, args []string) error {
+	entry, err := findCatalogEntry(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), entry.Path)
+	return nil
+}
+
+func runCatalogClear(cmd *cobra.Command, args []string) error {
+	entry, err := findCatalogEntry(args[0])
+	if err != nil {
+		return err
+	}
+	c, err := catalog.Open(os.Getenv("SYNCDB_PATH"))
+	if err != nil {
+		return err
+	}
+	if err := c.Remove(entry.Key()); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed catalog entry '%s'\n", entry.Key())
+	return nil
+}
+
+// findCatalogEntry returns the single catalog entry matching a database name or
+// catalog key. A database name exported from several servers is ambiguous.
+func findCatalogEntry(name string) (catalog.Entry, error) {
+	c, err := catalog.Open(os.Getenv("SYNCDB_PATH"))
+	if err != nil {
+		return catalog.Entry{}, err
+	}
+	matches, err := c.Find(name)
+	if err != nil {
+		return catalog.Entry{}, err
+	}
+	switch len(matches) {
+	case 0:
+		return catalog.Entry{}, fmt.Errorf("no export of '%s' found in the catalog", name)
+	case 1:
+		return matches[0], nil
+	}
+	keys := make([]string, len(matches))
+	for i, entry := range matches {
+		keys[i] = entry.Key()
+	}
+	return catalog.Entry{}, fmt.Errorf("'%s' matches several catalog entries, use one of: %s", name, strings.Join(keys, ", "))
+}
+
+// printCatalogEntries writes the catalog as a table.
+func printCatalogEntries(w io.Writer, entries []catalog.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No exports recorded in the catalog.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATABASE\tEXPORTED AT\tTABLES\tRECORDS\tFORMAT\tSCHEMA VERSION\tSTORAGE\tPATH")
+	for _, e := range entries {
+		version := e.SchemaVersion
+		if version == "" {
+			version = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\t%s\n", e.Key(), e.ExportedAt.Local().Format("2006-01-02 15:04:05"),
+			e.Tables, e.Records, e.Format, version, e.Storage, e.Path)
+	}
+	tw.Flush()
+}
+
+// recordExportInCatalog stores a successful export as the latest export of its
+// database. Local exports are recorded by absolute path; uploaded exports by
+// their key or object name in the bucket.
+func recordExportInCatalog(cmdArgs *CommonArgs, uploadPath string, stats []ExportStats) error {
+	exportPath := filepath.Join(cmdArgs.Path, filepath.Base(uploadPath))
+	if cmdArgs.Storage == "local" {
+		var err error
+		if exportPath, err = filepath.Abs(uploadPath); err != nil {
+			return err
+		}
+	} else {
+		exportPath = filepath.ToSlash(exportPath)
+	}
+
+	entry := catalog.Entry{
+		Driver:        cmdArgs.Driver,
+		Host:          cmdArgs.Host,
+		Port:          cmdArgs.Port,
+		Database:      cmdArgs.Database,
+		Storage:       cmdArgs.Storage,
+		Path:          exportPath,
+		ExportedAt:    time.Now().UTC(),
+		Tables:        len(stats),
+		Format:        cmdArgs.Format,
+		SchemaVersion: cmdArgs.SchemaVersion,
+	}
+	for _, s := range stats {
+		entry.Records += int64(s.RecordsExported)
+	}
+
+	c, err := catalog.Open(os.Getenv("SYNCDB_PATH"))
+	if err != nil {
+		return err
+	}
+	return c.Put(entry)
+}
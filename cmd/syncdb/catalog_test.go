@@ -0,0 +1,41 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/catalog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordExportInCatalog(t *testing.T) {
+	syncDBPath := t.TempDir()
+	t.Setenv("SYNCDB_PATH", syncDBPath)
+	exportDir := t.TempDir()
+
+	cmdArgs := &CommonArgs{Driver: "mysql", Host: "localhost", Port: 3306, Database: "shop", Storage: "local", Format: "sql", SchemaVersion: "1.4.0"}
+	stats := []ExportStats{{TableName: "users", RecordsExported: 3}, {TableName: "orders", RecordsExported: 4}}
+	require.NoError(t, recordExportInCatalog(cmdArgs, filepath.Join(exportDir, "shop_20240101_120000.zip"), stats))
+
+	cmdArgs = &CommonArgs{Driver: "postgres", Host: "db", Port: 5432, Database: "crm", Storage: "s3", Path: "backups", Format: "sql"}
+	require.NoError(t, recordExportInCatalog(cmdArgs, filepath.Join(exportDir, "crm_20240101_120000.zip"), nil))
+
+	entries, err := catalog.New(filepath.Join(syncDBPath, catalog.FileName)).Entries()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "mysql:localhost:3306/shop", entries[0].Key())
+	assert.Equal(t, filepath.Join(exportDir, "shop_20240101_120000.zip"), entries[0].Path)
+	assert.Equal(t, 2, entries[0].Tables)
+	assert.Equal(t, int64(7), entries[0].Records)
+	assert.Equal(t, "1.4.0", entries[0].SchemaVersion)
+	assert.Empty(t, entries[1].SchemaVersion)
+	assert.Equal(t, "backups/crm_20240101_120000.zip", entries[1].Path)
+
+	output := executeRoot(t, "catalog", "get", "shop")
+	assert.Equal(t, filepath.Join(exportDir, "shop_20240101_120000.zip")+"\n", output)
+
+	executeRoot(t, "catalog", "clear", "postgres:db:5432/crm")
+	_, err = findCatalogEntry("crm")
+	assert.Error(t, err)
+}
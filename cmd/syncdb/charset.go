@@ -0,0 +1,58 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// tableCharsetRegex matches the default character set in MySQL table options,
+// e.g. "DEFAULT CHARSET=utf8mb4", capturing its name
+var tableCharsetRegex = regexp.MustCompile(`(?i)\b(?:CHARSET|CHARACTER\s+SET)\s*=?\s*(\w+)`)
+
+// tableCollateRegex matches the default collation in MySQL table options
+var tableCollateRegex = regexp.MustCompile(`(?i)\bCOLLATE\s*=?\s*\w+`)
+
+// withTableCharset adds --charset and --collation to the table options of a
+// MySQL CREATE TABLE statement when it does not set them already, so the
+// table does not silently take the server defaults. Column level clauses are
+// left alone. A collation is only added when it belongs to the table's
+// character set.
+func withTableCharset(stmt, charset, collation string) string {
+	if charset == "" && collation == "" {
+		return stmt
+	}
+	body := strings.TrimRight(strings.TrimSpace(stmt), ";")
+	// The table options follow the parenthesis closing the definitions; SHOW
+	// CREATE TABLE puts it at the start of the last line
+	end := strings.LastIndex(body, "\n)")
+	if end >= 0 {
+		end++
+	} else if end = strings.LastIndex(body, ")"); end < 0 {
+		return stmt
+	}
+	definitions, options := body[:end+1], body[end+1:]
+
+	tableCharset := ""
+	if m := tableCharsetRegex.FindStringSubmatch(options); m != nil {
+		tableCharset = m[1]
+	} else if charset != "" {
+		options += " DEFAULT CHARSET=" + charset
+		tableCharset = charset
+	}
+	if collation != "" && !tableCollateRegex.MatchString(options) &&
+		(tableCharset == "" || strings.HasPrefix(strings.ToLower(collation), strings.ToLower(tableCharset)+"_")) {
+		options += " COLLATE=" + collation
+	}
+	return definitions + options + ";"
+}
+
+// databaseOptions returns the options of a database recreated with --drop.
+func databaseOptions(cmdArgs *CommonArgs) db.DatabaseOptions {
+	return db.DatabaseOptions{
+		Charset:   cmdArgs.Charset,
+		Collation: cmdArgs.Collation,
+		Encoding:  cmdArgs.Encoding,
+	}
+}
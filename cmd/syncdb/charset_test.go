@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTableCharset(t *testing.T) {
+	createTable := "CREATE TABLE `users` (\n  `id` int NOT NULL,\n  `name` varchar(50) COLLATE latin1_swedish_ci\n)"
+	testCases := []struct {
+		name      string
+		stmt      string
+		charset   string
+		collation string
+		expected  string
+	}{
+		{"nothing requested", createTable + " ENGINE=InnoDB;", "", "", createTable + " ENGINE=InnoDB;"},
+		{"both injected", createTable + " ENGINE=InnoDB;", "utf8mb4", "utf8mb4_unicode_ci",
+			createTable + " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;"},
+		{"collation added to existing charset", createTable + " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;", "latin1", "utf8mb4_unicode_ci",
+			createTable + " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;"},
+		{"existing collation kept", createTable + " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;", "utf8mb4", "utf8mb4_unicode_ci",
+			createTable + " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;"},
+		{"collation of another charset skipped", createTable + " ENGINE=InnoDB DEFAULT CHARSET=latin1;", "", "utf8mb4_unicode_ci",
+			createTable + " ENGINE=InnoDB DEFAULT CHARSET=latin1;"},
+		{"charset only", "CREATE TABLE t (id int);", "utf8mb4", "", "CREATE TABLE t (id int) DEFAULT CHARSET=utf8mb4;"},
+		{"collation only", "CREATE TABLE t (id int)", "", "utf8mb4_bin", "CREATE TABLE t (id int) COLLATE=utf8mb4_bin;"},
+		{"CHARACTER SET option", "CREATE TABLE t (id int) CHARACTER SET = latin1;", "utf8mb4", "", "CREATE TABLE t (id int) CHARACTER SET = latin1;"},
+		{"not a table definition", "CREATE TABLE t LIKE s;", "utf8mb4", "", "CREATE TABLE t LIKE s;"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, withTableCharset(tc.stmt, tc.charset, tc.collation))
+		})
+	}
+}
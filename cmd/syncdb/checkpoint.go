@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// checkpointFileName is the default --checkpoint-file, in the export directory.
+const checkpointFileName = ".syncdb_checkpoint.json"
+
+// exportCheckpoint records the tables whose data an export has written, so
+// that an interrupted export run again with --resume skips them. The stats of
+// the completed tables are kept so the resumed export's metadata still lists
+// their data files.
+type exportCheckpoint struct {
+	CompletedTables []string      `json:"completed_tables"`
+	Stats           []ExportStats `json:"stats,omitempty"`
+}
+
+// exportCheckpointPath returns the checkpoint file of an export: --checkpoint-file
+// if set, otherwise .syncdb_checkpoint.json in the export directory.
+func exportCheckpointPath(exportPath string, cmdArgs *CommonArgs) string {
+	if cmdArgs.CheckpointFile != "" {
+		return cmdArgs.CheckpointFile
+	}
+	return filepath.Join(exportPath, checkpointFileName)
+}
+
+// loadExportCheckpoint reads a checkpoint file. A missing file is an empty
+// checkpoint.
+func loadExportCheckpoint(path string) (*exportCheckpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &exportCheckpoint{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file %s: %v", path, err)
+	}
+	var checkpoint exportCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file %s: %v", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// completed reports whether the data of table is in the checkpoint.
+func (c *exportCheckpoint) completed(table string) bool {
+	return slices.Contains(c.CompletedTables, table)
+}
+
+// add records a table whose data has been exported.
+func (c *exportCheckpoint) add(stats ExportStats) {
+	if c.completed(stats.TableName) {
+		return
+	}
+	c.CompletedTables = append(c.CompletedTables, stats.TableName)
+	c.Stats = append(c.Stats, stats)
+}
+
+// save replaces the checkpoint file.
+func (c *exportCheckpoint) save(path string) error {
+	return writeCheckpointFile(path, c)
+}
+
+// writeCheckpointFile replaces a checkpoint file atomically with checkpoint
+// encoded as JSON, so a run interrupted while saving never leaves a partial
+// checkpoint behind.
+func writeCheckpointFile(path string, checkpoint any) error {
+	data, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write checkpoint file %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint file %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint file %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint file %s: %v", path, err)
+	}
+	return nil
+}
+
+// importCheckpointFileName is the checkpoint file of an import, in the
+// directory of the export being imported.
+const importCheckpointFileName = ".syncdb_import_checkpoint.json"
+
+// importCheckpointPath returns the checkpoint file of an import of path, an
+// export directory or archive. An archive is extracted to a temporary
+// directory removed after each run, so its checkpoint is kept next to it, as
+// {archive}.syncdb_import_checkpoint.json.
+func importCheckpointPath(path string) string {
+	if detectArchiveFormat(path) != "" {
+		return path + importCheckpointFileName
+	}
+	return filepath.Join(path, importCheckpointFileName)
+}
+
+// importCheckpoint records the progress of an import, so that an interrupted
+// import run again with --resume skips the tables it completed and continues
+// partly imported tables after their last committed chunk. Workers import
+// tables concurrently, so its methods lock it. A nil checkpoint records nothing.
+type importCheckpoint struct {
+	mu   sync.Mutex
+	path string
+
+	Tables          []string       `json:"tables"` // Tables whose data the import imports
+	CompletedTables []string       `json:"completed_tables"`
+	Chunks          map[string]int `json:"chunks,omitempty"` // Chunks committed of each partly imported table, counted across its data files
+}
+
+// loadImportCheckpoint reads the import checkpoint file at path. A missing
+// file is an empty checkpoint.
+func loadImportCheckpoint(path string) (*importCheckpoint, error) {
+	checkpoint := &importCheckpoint{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint file %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint file %s: %v", path, err)
+	}
+	return checkpoint, nil
+}
+
+// completed reports whether the data of table has been imported.
+func (c *importCheckpoint) completed(table string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Contains(c.CompletedTables, table)
+}
+
+// startChunk returns the 0-based chunk to continue a partly imported table at.
+func (c *importCheckpoint) startChunk(table string) int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.Chunks[table]
+}
+
+// chunkDone records that the first chunks chunks of table are committed.
+func (c *importCheckpoint) chunkDone(table string, chunks int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Chunks == nil {
+		c.Chunks = make(map[string]int)
+	}
+	c.Chunks[table] = chunks
+	c.save()
+}
+
+// tableDone records that the data of table is imported.
+func (c *importCheckpoint) tableDone(table string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !slices.Contains(c.CompletedTables, table) {
+		c.CompletedTables = append(c.CompletedTables, table)
+	}
+	delete(c.Chunks, table)
+	c.save()
+}
+
+// save writes the checkpoint file, with c locked. A checkpoint that cannot be
+// saved does not fail the import, it only cannot be resumed.
+func (c *importCheckpoint) save() {
+	if err := writeCheckpointFile(c.path, c); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// remove deletes the checkpoint file once the import is complete.
+func (c *importCheckpoint) remove() {
+	if c == nil {
+		return
+	}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove checkpoint file %s: %v\n", c.path, err)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	path := exportCheckpointPath(dir, &CommonArgs{})
+	assert.Equal(t, filepath.Join(dir, ".syncdb_checkpoint.json"), path)
+	assert.Equal(t, "/tmp/export.checkpoint", exportCheckpointPath(dir, &CommonArgs{CheckpointFile: "/tmp/export.checkpoint"}))
+
+	// A missing file is an empty checkpoint
+	checkpoint, err := loadExportCheckpoint(path)
+	require.NoError(t, err)
+	assert.False(t, checkpoint.completed("users"))
+
+	checkpoint.add(ExportStats{TableName: "users", RecordsExported: 10})
+	checkpoint.add(ExportStats{TableName: "orders", RecordsExported: 20})
+	checkpoint.add(ExportStats{TableName: "users", RecordsExported: 10})
+	require.NoError(t, checkpoint.save(path))
+
+	loaded, err := loadExportCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders"}, loaded.CompletedTables)
+	assert.Len(t, loaded.Stats, 2)
+	assert.True(t, loaded.completed("orders"))
+
+	// Only the checkpoint itself is left, no temporary file
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ".syncdb_checkpoint.json", entries[0].Name())
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err = loadExportCheckpoint(path)
+	assert.ErrorContains(t, err, "failed to parse checkpoint file")
+}
+
+func TestWriteDataFilesResume(t *testing.T) {
+	dir := t.TempDir()
+	checkpoint := &exportCheckpoint{}
+	checkpoint.add(ExportStats{TableName: "users", RecordsExported: 10})
+	checkpoint.add(ExportStats{TableName: "orders", RecordsExported: 20})
+	path := filepath.Join(dir, checkpointFileName)
+	require.NoError(t, checkpoint.save(path))
+
+	// Every table is in the checkpoint, so no connection is opened
+	conn := &db.Connection{Config: db.ConnectionConfig{Driver: db.DriverMySQL}}
+	cmdArgs := &CommonArgs{Resume: true, Workers: 2}
+	records, stats, err := writeDataFiles(conn, dir, cmdArgs, []string{"users", "orders"}, map[string]bool{}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, 30, records)
+	assert.Equal(t, checkpoint.Stats, stats)
+
+	// The completed export removes its checkpoint
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestImportSourceDataResume(t *testing.T) {
+	dir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	insert := func(table string, id int) string {
+		return fmt.Sprintf("INSERT INTO %s (id) VALUES (%d);", table, id)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"),
+		[]byte(insert("users", 1)+separator+insert("users", 2)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2_orders.sql"),
+		[]byte(insert("orders", 1)+separator+insert("orders", 2)+separator+insert("orders", 3)), 0644))
+	metadata := &ExportData{}
+	metadata.Metadata.Tables = []string{"users", "orders"}
+	src := &importSource{path: dir, metadata: metadata, dataTables: []string{"users", "orders"}}
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}
+	expectChunk := func(table string, id int) *sqlmock.ExpectedExec {
+		mock.ExpectBegin()
+		return mock.ExpectExec(insert(table, id))
+	}
+
+	// The first import stops at the second chunk of orders
+	expectChunk("users", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChunk("users", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChunk("orders", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChunk("orders", 2).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+	chdirTemp(t) // The failing chunk is saved in the working directory
+	cmdArgs := &CommonArgs{TxScope: txScopeChunk}
+	assert.ErrorContains(t, importSourceData(conn, cmdArgs, src, &ImportResult{}), "connection reset")
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	checkpoint, err := loadImportCheckpoint(filepath.Join(dir, importCheckpointFileName))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders"}, checkpoint.Tables)
+	assert.Equal(t, []string{"users"}, checkpoint.CompletedTables)
+	assert.Equal(t, map[string]int{"orders": 1}, checkpoint.Chunks)
+
+	var status bytes.Buffer
+	require.NoError(t, printImportStatus(&status, checkpoint))
+	assert.Equal(t, "Imported 1 of 2 tables (50.0%)\n\n"+
+		"TABLE   STATUS\n"+
+		"users   imported\n"+
+		"orders  in progress (1 chunks imported)\n", status.String())
+
+	// With --resume only the rest of orders is imported
+	expectChunk("orders", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChunk("orders", 3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	cmdArgs.Resume = true
+	result := &ImportResult{}
+	require.NoError(t, importSourceData(conn, cmdArgs, src, result))
+	require.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(2), result.RowsImported)
+
+	checkpoint, err = loadImportCheckpoint(filepath.Join(dir, importCheckpointFileName))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders"}, checkpoint.CompletedTables)
+	assert.Empty(t, checkpoint.Chunks)
+
+	// The completed import removes the checkpoint
+	src.checkpoint.remove()
+	assert.NoFileExists(t, filepath.Join(dir, importCheckpointFileName))
+}
+
+func TestImportArchiveResume(t *testing.T) {
+	exportDir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	insert := func(id int) string {
+		return fmt.Sprintf("INSERT INTO users (id) VALUES (%d);", id)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "0_metadata.json"),
+		[]byte(`{"metadata": {"database_name": "shop", "tables": ["users"], "include_data": true}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(exportDir, "1_users.sql"),
+		[]byte(insert(1)+separator+insert(2)+separator+insert(3)), 0644))
+	archivePath := filepath.Join(t.TempDir(), "shop_20240101_120000.zip")
+	require.NoError(t, createArchive(exportDir, archivePath, archiveFormatZip, ""))
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}
+	expectChunk := func(id int) *sqlmock.ExpectedExec {
+		mock.ExpectBegin()
+		return mock.ExpectExec(insert(id))
+	}
+	// importArchive imports the archive as import does, extracting it to a
+	// temporary directory removed afterwards
+	importArchive := func(cmdArgs *CommonArgs) error {
+		src, cleanup, err := openImportSource(newImportCommand(), cmdArgs, db.DriverPostgres)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		require.NoError(t, err)
+		src.dataTables = []string{"users"}
+		return importSourceData(conn, cmdArgs, src, &ImportResult{})
+	}
+
+	// The first import stops at the second chunk, and the checkpoint is kept
+	// next to the archive
+	expectChunk(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChunk(2).WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+	chdirTemp(t) // The failing chunk is saved in the working directory
+	cmdArgs := &CommonArgs{Path: archivePath, TxScope: txScopeChunk}
+	assert.ErrorContains(t, importArchive(cmdArgs), "connection reset")
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	checkpointPath := archivePath + importCheckpointFileName
+	assert.Equal(t, checkpointPath, importCheckpointPath(archivePath))
+	checkpoint, err := loadImportCheckpoint(checkpointPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"users": 1}, checkpoint.Chunks)
+
+	// With --resume the new extraction continues after the committed chunk
+	expectChunk(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChunk(3).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	cmdArgs = &CommonArgs{Path: archivePath, TxScope: txScopeChunk, Resume: true}
+	require.NoError(t, importArchive(cmdArgs))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	checkpoint, err = loadImportCheckpoint(checkpointPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users"}, checkpoint.CompletedTables)
+
+	// Remote exports are downloaded again on each run and cannot be resumed
+	_, _, err = openImportSource(newImportCommand(), &CommonArgs{Path: "shop.zip", Storage: "s3", Resume: true}, db.DriverPostgres)
+	assert.ErrorContains(t, err, "--resume cannot be used with --storage s3")
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checksumManifestName is the file listing the SHA-256 of every other file in
+// an export, in the format of sha256sum so it can also be checked with
+// `sha256sum -c`.
+const checksumManifestName = "0_checksums.sha256"
+
+// rowChecksumPrefix starts the comment line written before each row with --row-checksum.
+const rowChecksumPrefix = "-- CRC:"
+
+// writeChecksumManifest hashes every file in the export directory and writes
+// the manifest. It must run after all other export files are written.
+func writeChecksumManifest(exportPath string) error {
+	entries, err := os.ReadDir(exportPath)
+	if err != nil {
+		return fmt.Errorf("failed to read export directory: %v", err)
+	}
+
+	var lines []string
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == checksumManifestName {
+			continue
+		}
+		sum, err := fileSHA256(filepath.Join(exportPath, entry.Name()))
+		if err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("%s  %s\n", sum, entry.Name()))
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][66:] < lines[j][66:] })
+
+	manifestFile := filepath.Join(exportPath, checksumManifestName)
+	if err := os.WriteFile(manifestFile, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return fmt.Errorf("failed to write checksum manifest %s: %v", manifestFile, err)
+	}
+	return nil
+}
+
+// verifyChecksumManifest checks every file listed in the export's manifest and
+// returns an error naming the first file that is missing or does not match.
+func verifyChecksumManifest(importPath string) error {
+	manifestFile := filepath.Join(importPath, checksumManifestName)
+	manifest, err := os.Open(manifestFile)
+	if err != nil {
+		return fmt.Errorf("failed to read checksum manifest: %v", err)
+	}
+	defer manifest.Close()
+
+	verified := 0
+	scanner := bufio.NewScanner(manifest)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		expected, fileName, ok := strings.Cut(line, "  ")
+		if !ok {
+			return fmt.Errorf("invalid line in checksum manifest: %q", line)
+		}
+		sum, err := fileSHA256(filepath.Join(importPath, fileName))
+		if err != nil {
+			return err
+		}
+		if sum != expected {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", fileName, expected, sum)
+		}
+		verified++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read checksum manifest: %v", err)
+	}
+	fmt.Printf("Verified checksums of %d files\n", verified)
+	return nil
+}
+
+// fileSHA256 returns the hex encoded SHA-256 of a file, streaming its content.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s for checksum: %v", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read %s for checksum: %v", path, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// rowChecksum returns the CRC32 of a row's value tuple as 8 hex digits.
+func rowChecksum(row string) string {
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(row)))
+}
+
+// withRowChecksum prefixes a value tuple with its checksum comment line.
+func withRowChecksum(row string) string {
+	return rowChecksumPrefix + rowChecksum(row) + "\n" + row
+}
+
+// verifyRowChecksums recomputes the checksum of every row in an INSERT chunk
+// and compares it with the comment written at export. Statements that are not
+// INSERT ... VALUES have no rows and pass.
+func verifyRowChecksums(chunk string) error {
+	stmt, ok, err := parseInsertStatement(chunk)
+	if err != nil {
+		return fmt.Errorf("failed to parse data for checksum verification: %v", err)
+	}
+	if !ok {
+		return nil
+	}
+	for i, row := range stmt.rows {
+		expected := stmt.checksums[i]
+		if expected == "" {
+			return fmt.Errorf("row %d has no checksum (was the export made with --row-checksum?)", i+1)
+		}
+		if actual := rowChecksum(row); actual != expected {
+			return fmt.Errorf("row checksum mismatch for row %d: expected %s, got %s: %s", i+1, expected, actual, row)
+		}
+	}
+	return nil
+}
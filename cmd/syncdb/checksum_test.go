@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecksumManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0_metadata.json"), []byte(`{"database":"app"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"), []byte("INSERT INTO users (id) VALUES\n(1);"), 0644))
+	require.NoError(t, writeChecksumManifest(dir))
+
+	manifest, err := os.ReadFile(filepath.Join(dir, checksumManifestName))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(manifest)), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasSuffix(lines[0], "  0_metadata.json"))
+	assert.True(t, strings.HasSuffix(lines[1], "  1_users.sql"))
+
+	require.NoError(t, verifyChecksumManifest(dir))
+
+	t.Run("Corrupted file fails verification", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"), []byte("INSERT INTO users (id) VALUES\n(2);"), 0644))
+		err := verifyChecksumManifest(dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch for 1_users.sql")
+	})
+
+	t.Run("Missing manifest fails verification", func(t *testing.T) {
+		assert.Error(t, verifyChecksumManifest(t.TempDir()))
+	})
+}
+
+func TestVerifyRowChecksums(t *testing.T) {
+	rows := []string{withRowChecksum("(1, 'alice')"), withRowChecksum("(2, 'bob')")}
+	stmt, err := buildInsertStatement(db.DriverMySQL, insertModeInsert, "users", []string{"id", "name"}, nil, rows)
+	require.NoError(t, err)
+	assert.Equal(t, 2, countInsertRows(stmt))
+	require.NoError(t, verifyRowChecksums(stmt))
+
+	t.Run("Corrupted row fails verification", func(t *testing.T) {
+		err := verifyRowChecksums(strings.Replace(stmt, "'bob'", "'bib'", 1))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "row checksum mismatch for row 2")
+	})
+
+	t.Run("Rows without checksum fail verification", func(t *testing.T) {
+		err := verifyRowChecksums("INSERT INTO `users` (`id`) VALUES\n(1);")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "row 1 has no checksum")
+	})
+
+	t.Run("Statements without rows pass", func(t *testing.T) {
+		assert.NoError(t, verifyRowChecksums("SET FOREIGN_KEY_CHECKS=0;"))
+	})
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"gopkg.in/yaml.v3"
+)
+
+// CoercionRule rewrites the values of matching columns while importing with
+// --type-coerce, for data exported from another kind of database.
+type CoercionRule struct {
+	ColumnPattern string              // "table.column" glob, e.g. "users.*" (empty matches every column)
+	SourceType    string              // Type of the exported column, e.g. mysql_tinyint1 (descriptive only)
+	TargetType    string              // Type of the target column, e.g. pg_bool (empty matches every type)
+	Transform     func(string) string // Rewrites one SQL literal of the column
+}
+
+// coercionRuleConfig is a rule as written in the --type-coerce YAML file
+type coercionRuleConfig struct {
+	Preset        string `yaml:"preset"`
+	ColumnPattern string `yaml:"column_pattern"`
+	SourceType    string `yaml:"source_type"`
+	TargetType    string `yaml:"target_type"`
+	Transform     string `yaml:"transform"`
+}
+
+// coercionPresets are the built-in rules, usable as --type-coerce <name> or as
+// "preset: <name>" entries of a rules file.
+var coercionPresets = map[string][]coercionRuleConfig{
+	"mysql-to-postgres": {
+		{SourceType: "mysql_tinyint1", TargetType: "pg_bool", Transform: "0->false,1->true"},
+		// MySQL zero dates have no PostgreSQL equivalent
+		{SourceType: "mysql_datetime", TargetType: "pg_timestamp", Transform: "0000-00-00 00:00:00->NULL,0000-00-00->NULL"},
+		{SourceType: "mysql_datetime", TargetType: "pg_timestamptz", Transform: "0000-00-00 00:00:00->NULL,0000-00-00->NULL"},
+		{SourceType: "mysql_date", TargetType: "pg_date", Transform: "0000-00-00->NULL"},
+	},
+	"postgres-to-mysql": {
+		{SourceType: "pg_bool", TargetType: "mysql_tinyint", Transform: "true->1,false->0,t->1,f->0"},
+	},
+}
+
+// coercionTypeAliases maps type names to the names reported for target
+// columns: DATA_TYPE on MySQL and udt_name on PostgreSQL.
+var coercionTypeAliases = map[string]string{
+	"boolean":                     "bool",
+	"tinyint1":                    "tinyint",
+	"tinyint(1)":                  "tinyint",
+	"integer":                     "int4",
+	"bigint":                      "int8",
+	"smallint":                    "int2",
+	"timestamp without time zone": "timestamp",
+	"timestamp with time zone":    "timestamptz",
+}
+
+// normalizeCoercionType returns the target column type a rule type refers to,
+// without its pg_ or mysql_ prefix.
+func normalizeCoercionType(typeName string) string {
+	typeName = strings.ToLower(strings.TrimSpace(typeName))
+	typeName = strings.TrimPrefix(strings.TrimPrefix(typeName, "pg_"), "mysql_")
+	if alias, ok := coercionTypeAliases[typeName]; ok {
+		return alias
+	}
+	return typeName
+}
+
+// loadCoercionRules returns the rules of a built-in preset, or reads them from
+// a YAML file holding a list of rules and presets.
+func loadCoercionRules(value string) ([]CoercionRule, error) {
+	if preset, ok := coercionPresets[value]; ok {
+		return buildCoercionRules(preset)
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type coercion file: %v (built-in presets: %s)", err, strings.Join(coercionPresetNames(), ", "))
+	}
+	var configs []coercionRuleConfig
+	if err := yaml.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse type coercion file %s: %v", value, err)
+	}
+	rules, err := buildCoercionRules(configs)
+	if err != nil {
+		return nil, fmt.Errorf("type coercion file %s: %v", value, err)
+	}
+	return rules, nil
+}
+
+// coercionPresetNames returns the names of the built-in presets, sorted.
+func coercionPresetNames() []string {
+	names := make([]string, 0, len(coercionPresets))
+	for name := range coercionPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildCoercionRules turns rule configurations into rules, expanding presets.
+func buildCoercionRules(configs []coercionRuleConfig) ([]CoercionRule, error) {
+	var rules []CoercionRule
+	for i, cfg := range configs {
+		if cfg.Preset != "" {
+			preset, ok := coercionPresets[cfg.Preset]
+			if !ok {
+				return nil, fmt.Errorf("rule %d: unknown preset %q (valid presets: %s)", i+1, cfg.Preset, strings.Join(coercionPresetNames(), ", "))
+			}
+			presetRules, err := buildCoercionRules(preset)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, presetRules...)
+			continue
+		}
+		if cfg.ColumnPattern == "" && cfg.TargetType == "" {
+			return nil, fmt.Errorf("rule %d: set column_pattern or target_type", i+1)
+		}
+		if _, err := path.Match(cfg.ColumnPattern, ""); err != nil {
+			return nil, fmt.Errorf("rule %d: invalid column_pattern %q: %v", i+1, cfg.ColumnPattern, err)
+		}
+		transform, err := parseValueMapping(cfg.Transform)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %v", i+1, err)
+		}
+		rules = append(rules, CoercionRule{
+			ColumnPattern: cfg.ColumnPattern,
+			SourceType:    cfg.SourceType,
+			TargetType:    cfg.TargetType,
+			Transform:     transform,
+		})
+	}
+	return rules, nil
+}
+
+// parseValueMapping parses a transform such as "0->false,1->true" into a
+// function replacing each listed value with its SQL replacement. Values are
+// compared case-insensitively and without their single quotes, so 1 also
+// matches '1'. Other values, and NULL, are kept.
+func parseValueMapping(transform string) (func(string) string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(transform, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(pair, "->")
+		if !ok {
+			return nil, fmt.Errorf("invalid transform %q: expected value->replacement pairs separated by commas", transform)
+		}
+		mapping[strings.ToLower(unquoteLiteral(strings.TrimSpace(from)))] = strings.TrimSpace(to)
+	}
+	if len(mapping) == 0 {
+		return nil, fmt.Errorf("empty transform")
+	}
+	return func(literal string) string {
+		if strings.EqualFold(literal, "NULL") {
+			return literal
+		}
+		if replacement, ok := mapping[strings.ToLower(unquoteLiteral(literal))]; ok {
+			return replacement
+		}
+		return literal
+	}, nil
+}
+
+// unquoteLiteral removes the single quotes around a string literal.
+func unquoteLiteral(literal string) string {
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		return literal[1 : len(literal)-1]
+	}
+	return literal
+}
+
+// typeCoercer applies the coercion rules matching the columns of one target table.
+type typeCoercer struct {
+	table      string
+	transforms map[string][]func(string) string // Column name to the transforms of its matching rules
+}
+
+// newTypeCoercer returns the coercer of a target table, or nil when no rule
+// matches any of its columns.
+func newTypeCoercer(rules []CoercionRule, schema *db.SchemaInfo) *typeCoercer {
+	transforms := make(map[string][]func(string) string)
+	for _, col := range schema.Columns {
+		colType := normalizeCoercionType(schema.ColumnTypes[col])
+		for _, rule := range rules {
+			if rule.TargetType != "" && normalizeCoercionType(rule.TargetType) != colType {
+				continue
+			}
+			if rule.ColumnPattern != "" {
+				if ok, _ := path.Match(rule.ColumnPattern, schema.Name+"."+col); !ok {
+					continue
+				}
+			}
+			transforms[col] = append(transforms[col], rule.Transform)
+		}
+	}
+	if len(transforms) == 0 {
+		return nil
+	}
+	return &typeCoercer{table: schema.Name, transforms: transforms}
+}
+
+// coerce rewrites the values of an INSERT statement for the target column
+// types. Other statements are returned unchanged.
+func (c *typeCoercer) coerce(chunk string) (string, error) {
+	stmt, ok, err := parseInsertStatement(chunk)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse data for %s: %v", c.table, err)
+	}
+	if !ok {
+		return chunk, nil
+	}
+
+	changed := false
+	for r, values := range stmt.values {
+		rowChanged := false
+		for i, col := range stmt.columns {
+			for _, transform := range c.transforms[col] {
+				if coerced := transform(values[i]); coerced != values[i] {
+					values[i] = coerced
+					rowChanged = true
+				}
+			}
+		}
+		if rowChanged {
+			stmt.rows[r] = "(" + strings.Join(values, ", ") + ")"
+			changed = true
+		}
+	}
+	if !changed {
+		return chunk, nil
+	}
+	return stmt.String(), nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseValueMapping(t *testing.T) {
+	transform, err := parseValueMapping("0->false, 1->true")
+	require.NoError(t, err)
+	assert.Equal(t, "false", transform("0"))
+	assert.Equal(t, "true", transform("'1'"))
+	assert.Equal(t, "2", transform("2"))
+	assert.Equal(t, "NULL", transform("NULL"))
+
+	_, err = parseValueMapping("0=false")
+	assert.Error(t, err)
+	_, err = parseValueMapping("")
+	assert.Error(t, err)
+}
+
+func TestLoadCoercionRules(t *testing.T) {
+	rules, err := loadCoercionRules("mysql-to-postgres")
+	require.NoError(t, err)
+	assert.NotEmpty(t, rules)
+
+	file := filepath.Join(t.TempDir(), "coerce.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+- preset: postgres-to-mysql
+- column_pattern: "users.status"
+  source_type: mysql_enum
+  transform: "'A'->'active','I'->'inactive'"
+`), 0644))
+	rules, err = loadCoercionRules(file)
+	require.NoError(t, err)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "mysql_tinyint", rules[0].TargetType)
+	assert.Equal(t, "users.status", rules[1].ColumnPattern)
+	assert.Equal(t, "'active'", rules[1].Transform("'A'"))
+
+	require.NoError(t, os.WriteFile(file, []byte(`- source_type: mysql_tinyint1`), 0644))
+	_, err = loadCoercionRules(file)
+	assert.ErrorContains(t, err, "set column_pattern or target_type")
+
+	require.NoError(t, os.WriteFile(file, []byte(`- preset: oracle-to-postgres`), 0644))
+	_, err = loadCoercionRules(file)
+	assert.ErrorContains(t, err, "unknown preset")
+
+	_, err = loadCoercionRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "mysql-to-postgres, postgres-to-mysql")
+}
+
+func TestTypeCoercer(t *testing.T) {
+	rules, err := loadCoercionRules("mysql-to-postgres")
+	require.NoError(t, err)
+	schema := &db.SchemaInfo{
+		Name:        "users",
+		Columns:     []string{"id", "active", "created_at", "name"},
+		ColumnTypes: map[string]string{"id": "int4", "active": "bool", "created_at": "timestamp", "name": "varchar"},
+	}
+	coercer := newTypeCoercer(rules, schema)
+	require.NotNil(t, coercer)
+
+	chunk := `INSERT INTO "users" ("id", "active", "created_at", "name") VALUES
+(1, 1, '2024-01-01 10:00:00', '1'),
+(2, 0, '0000-00-00 00:00:00', NULL);`
+	coerced, err := coercer.coerce(chunk)
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "users" ("id", "active", "created_at", "name") VALUES
+(1, true, '2024-01-01 10:00:00', '1'),
+(2, false, NULL, NULL);`, coerced)
+
+	// Statements without coerced values and other statements are unchanged
+	chunk = `INSERT INTO "users" ("id", "active") VALUES
+(3, true);`
+	coerced, err = coercer.coerce(chunk)
+	require.NoError(t, err)
+	assert.Equal(t, chunk, coerced)
+	coerced, err = coercer.coerce("SET session_replication_role = 'replica';")
+	require.NoError(t, err)
+	assert.Equal(t, "SET session_replication_role = 'replica';", coerced)
+
+	// Rules restricted by column pattern skip other tables
+	rules = []CoercionRule{{ColumnPattern: "orders.*", Transform: func(v string) string { return "0" }}}
+	assert.Nil(t, newTypeCoercer(rules, schema))
+}
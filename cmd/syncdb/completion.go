@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/spf13/cobra"
+)
+
+// Values suggested for flags with a fixed set of choices
+var (
+	completionDrivers      = []string{"mysql", "postgres"}
+	completionStorageTypes = []string{"local", "s3", "gdrive", "gcs"}
+)
+
+func newCompletionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate shell completion scripts",
+		Long: `Generate a completion script for syncdb for the given shell.
+Examples:
+  # Bash (current session)
+  source <(syncdb completion bash)
+  # Bash (permanent, Linux)
+  syncdb completion bash > /etc/bash_completion.d/syncdb
+  # Zsh
+  syncdb completion zsh > "${fpath[1]}/_syncdb"
+  # Fish
+  syncdb completion fish > ~/.config/fish/completions/syncdb.fish
+  # PowerShell
+  syncdb completion powershell | Out-String | Invoke-Expression`,
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE:                  runCompletion,
+	}
+	return cmd
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	switch args[0] {
+	case "bash":
+		return cmd.Root().GenBashCompletion(out)
+	case "zsh":
+		return cmd.Root().GenZshCompletion(out)
+	case "fish":
+		return cmd.Root().GenFishCompletion(out, true)
+	case "powershell":
+		return cmd.Root().GenPowerShellCompletion(out)
+	}
+	return fmt.Errorf("unsupported shell: %s", args[0])
+}
+
+// registerFlagCompletions adds dynamic completion for the --profile, --driver
+// and --storage flags on cmd and all of its subcommands that define them.
+func registerFlagCompletions(cmd *cobra.Command) {
+	completions := map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+		"profile": completeProfileNames,
+		"driver":  cobra.FixedCompletions(completionDrivers, cobra.ShellCompDirectiveNoFileComp),
+		"storage": cobra.FixedCompletions(completionStorageTypes, cobra.ShellCompDirectiveNoFileComp),
+	}
+	for flagName, completion := range completions {
+		if cmd.Flags().Lookup(flagName) != nil {
+			cmd.RegisterFlagCompletionFunc(flagName, completion)
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		registerFlagCompletions(sub)
+	}
+}
+
+// completeProfileNames suggests the names of saved profiles.
+func completeProfileNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	profileDir, err := profile.GetProfileDir(os.Getenv("SYNCDB_PATH"))
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	files, err := os.ReadDir(profileDir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".yaml")
+		if !file.IsDir() && ok && strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfileArg suggests profile names for the first positional argument
+// of profile subcommands that operate on an existing profile.
+func completeProfileArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeProfileNames(cmd, args, toComplete)
+}
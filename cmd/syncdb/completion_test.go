@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// executeRoot runs the root command with args and returns its standard output.
+func executeRoot(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs(args)
+	t.Cleanup(func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	})
+	require.NoError(t, rootCmd.Execute())
+	return out.String()
+}
+
+// completionValues returns the suggestions of a hidden __complete invocation,
+// without the trailing directive line.
+func completionValues(output string) []string {
+	var values []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if !strings.HasPrefix(line, ":") {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+func TestCompletionCommand(t *testing.T) {
+	output := executeRoot(t, "completion", "bash")
+	assert.Contains(t, output, "__start_syncdb")
+	assert.Contains(t, output, "_syncdb_export")
+	assert.Contains(t, output, "_syncdb_profile_show")
+
+	for _, shell := range []string{"zsh", "fish", "powershell"} {
+		t.Run(shell, func(t *testing.T) {
+			assert.NotEmpty(t, executeRoot(t, "completion", shell))
+		})
+	}
+}
+
+func TestFlagCompletion(t *testing.T) {
+	assert.Equal(t, completionDrivers, completionValues(executeRoot(t, "__complete", "export", "--driver", "")))
+	assert.Equal(t, completionStorageTypes, completionValues(executeRoot(t, "__complete", "import", "--storage", "")))
+
+	baseTmpDir, cleanup := setupTestProfileDir(t)
+	t.Cleanup(cleanup)
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+	profileDir := filepath.Join(baseTmpDir, "profiles")
+	createDummyCmdProfile(t, profileDir, "staging", "database: staging_db\n")
+	createDummyCmdProfile(t, profileDir, "dev", "database: dev_db\n")
+
+	assert.Equal(t, []string{"dev", "staging"}, completionValues(executeRoot(t, "__complete", "export", "--profile", "")))
+	assert.Equal(t, []string{"staging"}, completionValues(executeRoot(t, "__complete", "profile", "show", "st")))
+	assert.Empty(t, completionValues(executeRoot(t, "__complete", "profile", "copy", "dev", "")))
+}
@@ -2,10 +2,19 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hoangnguyenba/syncdb/pkg/config"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/filter"
+	"github.com/hoangnguyenba/syncdb/pkg/notify"
 	"github.com/hoangnguyenba/syncdb/pkg/profile" // Import the profile package
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // Helper function to determine the final string value based on priority
@@ -133,6 +142,180 @@ func getStringSliceFlagWithConfigFallback(cmd *cobra.Command, flagName string, c
 	return ret
 }
 
+// resolveDurationValue determines a duration with priority Flag > Profile > Default.
+// Profile values use time.ParseDuration syntax (e.g. "30s").
+func resolveDurationValue(cmd *cobra.Command, flagName string, profileValue string, defaultValue time.Duration) (time.Duration, error) {
+	if cmd.Flags().Changed(flagName) {
+		return cmd.Flags().GetDuration(flagName)
+	}
+	if profileValue == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(profileValue)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q in profile: %v", flagName, profileValue, err)
+	}
+	return d, nil
+}
+
+// maxWorkers is the largest accepted --workers.
+const maxWorkers = 64
+
+// validateWorkers checks that a number of workers is between 1 and maxWorkers.
+func validateWorkers(workers int) error {
+	if workers < 1 || workers > maxWorkers {
+		return fmt.Errorf("workers must be between 1 and %d, got %d", maxWorkers, workers)
+	}
+	return nil
+}
+
+// exportWorkersEnv is the deprecated environment variable that sets the
+// number of export workers.
+const exportWorkersEnv = "SYNCDB_EXPORT_WORKERS"
+
+// defaultExportWorkers returns the default --workers of export: half the CPU
+// cores, at least 1 as before --workers existed, and at most maxWorkers.
+func defaultExportWorkers() int {
+	return min(max(runtime.NumCPU()/2, 1), maxWorkers)
+}
+
+// warnMixedTablePatterns warns about table patterns mixing glob and SQL LIKE
+// wildcards, which are matched as glob patterns (see db.TablePatternMatch).
+func warnMixedTablePatterns(patternLists ...[]string) {
+	for _, patterns := range patternLists {
+		for _, pattern := range patterns {
+			if db.MixedTablePattern(pattern) {
+				fmt.Printf("Warning: table pattern %q mixes glob (*, ?) and SQL (%%, _) wildcards; it is matched as a glob pattern, with %% and _ taken literally\n", pattern)
+			}
+		}
+	}
+}
+
+// loadConditionsFile reads a YAML file mapping table names to WHERE conditions.
+func loadConditionsFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read conditions file: %v", err)
+	}
+	var conditions map[string]string
+	if err := yaml.Unmarshal(data, &conditions); err != nil {
+		return nil, fmt.Errorf("failed to parse conditions file %s: %v", path, err)
+	}
+	return conditions, nil
+}
+
+// mergeConditions combines per-table WHERE conditions. Entries from the
+// conditions file override the profile's, and a non-empty globalCondition is
+// stored under db.AllTablesConditionKey as the fallback for all other tables.
+func mergeConditions(profileConditions, fileConditions map[string]string, globalCondition string) map[string]string {
+	if len(profileConditions) == 0 && len(fileConditions) == 0 && globalCondition == "" {
+		return nil
+	}
+	merged := make(map[string]string, len(profileConditions)+len(fileConditions)+1)
+	for table, condition := range profileConditions {
+		merged[table] = condition
+	}
+	for table, condition := range fileConditions {
+		merged[table] = condition
+	}
+	if globalCondition != "" {
+		merged[db.AllTablesConditionKey] = globalCondition
+	}
+	return merged
+}
+
+// applyFilterSpec merges the rules of --filter-file into cmdArgs. They replace
+// the table lists and per-table conditions of the config and profile, but not
+// those of flags passed explicitly: --tables, --exclude-table,
+// --exclude-table-schema, --exclude-table-data, --conditions-file and, for
+// the db.AllTablesConditionKey entry, --condition.
+func applyFilterSpec(cmd *cobra.Command, cmdArgs *CommonArgs, spec *filter.FilterSpec) {
+	lists := []struct {
+		flag  string
+		rules []string
+		value *[]string
+	}{
+		{"tables", spec.Include, &cmdArgs.Tables},
+		{"exclude-table", spec.Exclude, &cmdArgs.ExcludeTable},
+		{"exclude-table-schema", spec.ExcludeSchema, &cmdArgs.ExcludeTableSchema},
+		{"exclude-table-data", spec.ExcludeData, &cmdArgs.ExcludeTableData},
+	}
+	for _, list := range lists {
+		if len(list.rules) > 0 && !cmd.Flags().Changed(list.flag) {
+			*list.value = list.rules
+		}
+	}
+	warnMixedTablePatterns(spec.Include, spec.Exclude, spec.ExcludeSchema, spec.ExcludeData)
+
+	for table, condition := range spec.Conditions {
+		explicitFlag := "conditions-file"
+		if table == db.AllTablesConditionKey {
+			explicitFlag = "condition"
+		}
+		if _, ok := cmdArgs.Conditions[table]; ok && cmd.Flags().Changed(explicitFlag) {
+			continue
+		}
+		if cmdArgs.Conditions == nil {
+			cmdArgs.Conditions = make(map[string]string, len(spec.Conditions))
+		}
+		cmdArgs.Conditions[table] = condition
+	}
+}
+
+// parseExcludeColumns parses the --exclude-columns syntax "table:col1,col2;table2:col3"
+// into a map of table name to excluded columns.
+func parseExcludeColumns(value string) (map[string][]string, error) {
+	excludeColumns := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		table, columnList, ok := strings.Cut(entry, ":")
+		table = strings.TrimSpace(table)
+		if !ok || table == "" {
+			return nil, fmt.Errorf("invalid exclude-columns entry %q: expected table:col1,col2", entry)
+		}
+		for _, col := range strings.Split(columnList, ",") {
+			if col = strings.TrimSpace(col); col != "" {
+				excludeColumns[table] = append(excludeColumns[table], col)
+			}
+		}
+		if len(excludeColumns[table]) == 0 {
+			return nil, fmt.Errorf("invalid exclude-columns entry %q: no columns given", entry)
+		}
+	}
+	if len(excludeColumns) == 0 {
+		return nil, nil
+	}
+	return excludeColumns, nil
+}
+
+// parseOrderBy parses the --order-by syntax "table:col1 ASC,col2 DESC;table2:col3"
+// into a map of table name to sort specification.
+func parseOrderBy(value string) (map[string]string, error) {
+	orderBy := make(map[string]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		table, spec, ok := strings.Cut(entry, ":")
+		table = strings.TrimSpace(table)
+		if !ok || table == "" {
+			return nil, fmt.Errorf("invalid order-by entry %q: expected table:col1 ASC,col2 DESC", entry)
+		}
+		if _, err := db.ParseOrderBy(spec); err != nil {
+			return nil, fmt.Errorf("invalid order-by entry %q: %v", entry, err)
+		}
+		orderBy[table] = strings.TrimSpace(spec)
+	}
+	if len(orderBy) == 0 {
+		return nil, nil
+	}
+	return orderBy, nil
+}
+
 // populateCommonArgsFromFlagsAndConfig fills a CommonArgs struct by reading flags, environment variables (via cfg),
 // and profile settings, respecting the priority: Flag > Env Var > Profile > Default.
 // It now returns an error if profile loading fails.
@@ -158,12 +341,53 @@ func populateCommonArgsFromFlagsAndConfig(cmd *cobra.Command, cfg config.CommonC
 	profilePassword := ""
 	profileDatabase := ""
 	profileDriver := ""
+	profileDSN := ""
+	profilePgSchema := ""
 	var profileTables []string
 	var profileIncludeSchema *bool
 	var profileIncludeData *bool
 	var profileExcludeTable []string
 	var profileExcludeTableSchema []string
 	var profileExcludeTableData []string
+	profileInsertMode := ""
+	profileTableOrder := ""
+	profileSchemaConflict := ""
+	profileNullToken := ""
+	profileQueryTimeout := ""
+	profileConnectRetryCount := 0
+	profileConnectRetryDelay := ""
+	profileDeadlockRetryCount := 0
+	profileDeadlockRetryDelay := ""
+	profileWorkers := 0
+	profileCompressFormat := ""
+	profileCompressLevel := ""
+	profilePreExportSQL := ""
+	profilePostExportSQL := ""
+	profilePreImportSQL := ""
+	profilePostImportSQL := ""
+	profileTempDir := ""
+	profileCharset := ""
+	profileCollation := ""
+	profileEncoding := ""
+	profileStorage := ""
+	profileS3Bucket := ""
+	profileS3Region := ""
+	profileS3StorageClass := ""
+	profileS3Tags := ""
+	profileS3KMSKeyID := ""
+	profileSchemaVersion := ""
+	profileFilterFile := ""
+	profileGdriveCredentials := ""
+	profileGdriveFolder := ""
+	profileCondition := ""
+	var profileConditions map[string]string
+	var profileExcludeColumns map[string][]string
+	var profileOrderBy map[string]string
+	var profileSkipExisting *bool
+	var profileANSIQuotes *bool
+	profileQuoteIdentifiers := ""
+	profileWebhookURL := ""
+	var profileWebhookHeaders []string
 
 	if loadedProfile != nil {
 		profileHost = loadedProfile.Host
@@ -172,12 +396,56 @@ func populateCommonArgsFromFlagsAndConfig(cmd *cobra.Command, cfg config.CommonC
 		profilePassword = loadedProfile.Password
 		profileDatabase = loadedProfile.Database
 		profileDriver = loadedProfile.Driver
+		profileDSN = loadedProfile.DSN
+		profilePgSchema = loadedProfile.PgSchema
 		profileTables = loadedProfile.Tables
 		profileIncludeSchema = loadedProfile.IncludeSchema
 		profileIncludeData = loadedProfile.IncludeData
 		profileExcludeTable = loadedProfile.ExcludeTable
 		profileExcludeTableSchema = loadedProfile.ExcludeTableSchema
 		profileExcludeTableData = loadedProfile.ExcludeTableData
+		profileInsertMode = loadedProfile.InsertMode
+		if profileInsertMode == "" {
+			profileInsertMode = loadedProfile.InsertStrategy
+		}
+		profileTableOrder = loadedProfile.TableOrder
+		profileSchemaConflict = loadedProfile.SchemaConflict
+		profileNullToken = loadedProfile.NullToken
+		profileQueryTimeout = loadedProfile.QueryTimeout
+		profileConnectRetryCount = loadedProfile.ConnectRetryCount
+		profileConnectRetryDelay = loadedProfile.ConnectRetryDelay
+		profileDeadlockRetryCount = loadedProfile.DeadlockRetryCount
+		profileDeadlockRetryDelay = loadedProfile.DeadlockRetryDelay
+		profileWorkers = loadedProfile.Workers
+		profileCompressFormat = loadedProfile.CompressFormat
+		profileCompressLevel = loadedProfile.CompressLevel
+		profilePreExportSQL = loadedProfile.PreExportSQL
+		profilePostExportSQL = loadedProfile.PostExportSQL
+		profilePreImportSQL = loadedProfile.PreImportSQL
+		profilePostImportSQL = loadedProfile.PostImportSQL
+		profileTempDir = loadedProfile.TempDir
+		profileCharset = loadedProfile.Charset
+		profileCollation = loadedProfile.Collation
+		profileEncoding = loadedProfile.Encoding
+		profileStorage = loadedProfile.Storage
+		profileS3Bucket = loadedProfile.S3Bucket
+		profileS3Region = loadedProfile.S3Region
+		profileS3StorageClass = loadedProfile.S3StorageClass
+		profileS3Tags = loadedProfile.S3Tags
+		profileS3KMSKeyID = loadedProfile.S3KMSKeyID
+		profileSchemaVersion = loadedProfile.SchemaVersion
+		profileFilterFile = loadedProfile.FilterFile
+		profileGdriveCredentials = loadedProfile.GdriveCredentials
+		profileGdriveFolder = loadedProfile.GdriveFolder
+		profileCondition = loadedProfile.Condition
+		profileConditions = loadedProfile.Conditions
+		profileExcludeColumns = loadedProfile.ExcludeColumns
+		profileOrderBy = loadedProfile.OrderBy
+		profileSkipExisting = loadedProfile.SkipExisting
+		profileANSIQuotes = loadedProfile.ANSIQuotes
+		profileQuoteIdentifiers = loadedProfile.QuoteIdentifiers
+		profileWebhookURL = loadedProfile.WebhookURL
+		profileWebhookHeaders = loadedProfile.WebhookHeaders
 	}
 
 	// Database connection
@@ -187,16 +455,44 @@ func populateCommonArgsFromFlagsAndConfig(cmd *cobra.Command, cfg config.CommonC
 	args.Password = resolveStringValue(cmd, "password", cfg.Password, profilePassword, "") // Handle password securely later if needed
 	args.Database = resolveStringValue(cmd, "database", cfg.Database, profileDatabase, "") // Database is required, validation happens later
 	args.Driver = resolveStringValue(cmd, "driver", cfg.Driver, profileDriver, "mysql")    // Assuming mysql is default
+	args.PgSchema = resolveStringValue(cmd, "pg-schema", "", profilePgSchema, db.SchemaPostgres) // Part of profile, no env var
+	// A DSN (part of profile, no env var) replaces the settings above, except
+	// those given with their own flag
+	if dsn := resolveStringValue(cmd, "dsn", "", profileDSN, ""); dsn != "" {
+		if err := applyDSN(cmd, &args, dsn); err != nil {
+			return args, err
+		}
+	}
 
 	// Table selection
 	args.Tables = resolveStringSliceValue(cmd, "tables", cfg.Tables, profileTables)
 
-	// Path and Storage (Storage related flags are NOT part of profile)
-	args.Path = resolveStringValue(cmd, "path", "", "", "")                                               // Not in profile
-	args.Storage = resolveStringValue(cmd, "storage", cfg.Storage, "", "local")                           // Not in profile
-	args.S3Bucket = resolveStringValue(cmd, "s3-bucket", cfg.S3Bucket, "", "")                            // Not in profile
-	args.S3Region = resolveStringValue(cmd, "s3-region", cfg.S3Region, "", "")                            // Not in profile
+	// Path and Storage (the storage type, S3 and Google Drive settings are part of profile)
+	if flag := cmd.Flags().Lookup("path"); flag != nil && flag.Value.Type() == "stringSlice" {
+		// Import reads one or more exports
+		args.Paths, _ = cmd.Flags().GetStringSlice("path")
+		if len(args.Paths) > 0 {
+			args.Path = args.Paths[0]
+		}
+	} else {
+		args.Path = resolveStringValue(cmd, "path", "", "", "") // Not in profile
+	}
+	args.Storage = resolveStringValue(cmd, "storage", cfg.Storage, profileStorage, "local")
+	args.S3Bucket = resolveStringValue(cmd, "s3-bucket", cfg.S3Bucket, profileS3Bucket, "")
+	args.S3Region = resolveStringValue(cmd, "s3-region", cfg.S3Region, profileS3Region, "")
+	args.GdriveCredentials = resolveStringValue(cmd, "gdrive-credentials", "", profileGdriveCredentials, "") // Part of profile, no env var
+	args.GdriveFolder = resolveStringValue(cmd, "gdrive-folder", "", profileGdriveFolder, "")                // Part of profile, no env var
+	args.GCSBucket = resolveStringValue(cmd, "gcs-bucket", cfg.GCSBucket, "", "")                         // Not in profile
+	args.GCSProject = resolveStringValue(cmd, "gcs-project", cfg.GCSProject, "", "")                      // Not in profile
+	args.GCSCredentials = resolveStringValue(cmd, "gcs-credentials", cfg.GCSCredentials, "", "")          // Not in profile
+
 
+	// S3 multipart settings are command-time flags, not stored in profile
+	args.S3MultipartThreshold, _ = cmd.Flags().GetInt("s3-multipart-threshold")
+	args.S3PartSize, _ = cmd.Flags().GetInt("s3-part-size")
+	args.S3StorageClass = resolveStringValue(cmd, "s3-storage-class", "", profileS3StorageClass, "")
+	args.S3Tags = resolveStringValue(cmd, "s3-tags", "", profileS3Tags, "")
+	args.S3KMSKeyID = resolveStringValue(cmd, "s3-kms-key-id", "", profileS3KMSKeyID, "")
 
 	// Format/Encoding (Format is NOT part of profile)
 	args.Format = resolveStringValue(cmd, "format", cfg.Format, "", "sql") // Not in profile
@@ -218,6 +514,7 @@ func populateCommonArgsFromFlagsAndConfig(cmd *cobra.Command, cfg config.CommonC
 	args.ExcludeTable = resolveStringSliceValue(cmd, "exclude-table", cfg.ExcludeTable, profileExcludeTable)
 	args.ExcludeTableSchema = resolveStringSliceValue(cmd, "exclude-table-schema", cfg.ExcludeTableSchema, profileExcludeTableSchema)
 	args.ExcludeTableData = resolveStringSliceValue(cmd, "exclude-table-data", cfg.ExcludeTableData, profileExcludeTableData)
+	warnMixedTablePatterns(args.Tables, args.ExcludeTable, args.ExcludeTableSchema, args.ExcludeTableData)
 
 	// Zip is a command-time flag, not stored in profile
 	args.Zip, _ = cmd.Flags().GetBool("zip")
@@ -225,12 +522,172 @@ func populateCommonArgsFromFlagsAndConfig(cmd *cobra.Command, cfg config.CommonC
 	args.DisableForeignKeyCheck, _ = cmd.Flags().GetBool("disable-foreign-key-check")
 	args.Drop, _ = cmd.Flags().GetBool("drop")
 	args.Truncate, _ = cmd.Flags().GetBool("truncate")
-	// Note: The 'Condition' field from the profile (loadedProfile.Condition) is not directly mapped to CommonArgs.
-	// We leave it for specific handling in export.go
+	args.SkipExisting = resolveBoolValueProfile(cmd, "skip-existing", profileSkipExisting, false)
+	args.ANSIQuotes = resolveBoolValueProfile(cmd, "ansi-quotes", profileANSIQuotes, false)
+	args.QuoteIdentifiers = resolveStringValue(cmd, "quote-identifiers", "", profileQuoteIdentifiers, db.QuoteStyleAuto)
+	// Schema version (part of profile, no env var)
+	args.SchemaVersion = resolveStringValue(cmd, "schema-version", "", profileSchemaVersion, "")
+	// Filter file (part of profile, no env var), parsed by loadAndValidateArgs
+	args.FilterFile = resolveStringValue(cmd, "filter-file", "", profileFilterFile, "")
+	args.TxIsolation, _ = cmd.Flags().GetString("tx-isolation")
+	args.TxSize, _ = cmd.Flags().GetInt("import-tx-size")
+	if args.TxSize < 0 {
+		return args, fmt.Errorf("--import-tx-size must not be negative, got %d", args.TxSize)
+	}
+	// WHERE conditions (part of profile, no env var): --conditions-file entries override
+	// the profile's per-table conditions, and --condition is the fallback for other tables
+	args.Condition = resolveStringValue(cmd, "condition", "", profileCondition, "")
+	var fileConditions map[string]string
+	if conditionsFile, _ := cmd.Flags().GetString("conditions-file"); conditionsFile != "" {
+		if fileConditions, err = loadConditionsFile(conditionsFile); err != nil {
+			return args, err
+		}
+	}
+	args.Conditions = mergeConditions(profileConditions, fileConditions, args.Condition)
+	// Excluded columns (part of profile, no env var): the flag replaces the profile's map
+	args.ExcludeColumns = profileExcludeColumns
+	if cmd.Flags().Changed("exclude-columns") {
+		excludeColumns, _ := cmd.Flags().GetString("exclude-columns")
+		if args.ExcludeColumns, err = parseExcludeColumns(excludeColumns); err != nil {
+			return args, err
+		}
+	}
+	// Row ordering (part of profile, no env var): the flag replaces the profile's map
+	args.OrderBy = profileOrderBy
+	if cmd.Flags().Changed("order-by") {
+		orderBy, _ := cmd.Flags().GetString("order-by")
+		if args.OrderBy, err = parseOrderBy(orderBy); err != nil {
+			return args, err
+		}
+	}
 
 	// FileName: only from flag, not from config/profile
 	args.FileName, _ = cmd.Flags().GetString("file-name")
 	args.QuerySeparator = getStringFlagWithConfigFallback(cmd, "query-separator", "\n--SYNCDB_QUERY_SEPARATOR--\n")
+	// Insert mode (part of profile, no env var)
+	args.InsertMode = resolveStringValue(cmd, "insert-mode", "", profileInsertMode, insertModeInsert)
+	// Table order of exports (part of profile, no env var)
+	args.TableOrder = resolveStringValue(cmd, "table-order", "", profileTableOrder, tableOrderDependency)
+	// NULL handling (null token is part of profile, no env var)
+	args.NullToken = resolveStringValue(cmd, "null-token", "", profileNullToken, defaultNullToken)
+	args.EmptyStringAsNull, _ = cmd.Flags().GetBool("empty-string-as-null")
+	args.DisableKeys, _ = cmd.Flags().GetBool("disable-keys")
+	args.DisableUniqueChecks, _ = cmd.Flags().GetBool("disable-unique-checks")
+	args.DisableFKCheckOnExport, _ = cmd.Flags().GetBool("disable-fk-check-on-export")
+	// Export query timeout (part of profile, no env var)
+	if args.QueryTimeout, err = resolveDurationValue(cmd, "query-timeout", profileQueryTimeout, 0); err != nil {
+		return args, err
+	}
+	// Connection retry (count and delay are part of profile, no env var)
+	args.ConnectRetryCount = resolveIntValue(cmd, "connect-retry-count", 0, profileConnectRetryCount, 0)
+	if args.ConnectRetryDelay, err = resolveDurationValue(cmd, "connect-retry-delay", profileConnectRetryDelay, db.DefaultConnectRetryDelay); err != nil {
+		return args, err
+	}
+	args.ConnectRetryMaxDelay, _ = cmd.Flags().GetDuration("connect-retry-max-delay")
+	// Deadlock retry for imports (part of profile, no env var)
+	args.DeadlockRetryCount = resolveIntValue(cmd, "deadlock-retry-count", 0, profileDeadlockRetryCount, db.DefaultDeadlockRetryCount)
+	if args.DeadlockRetryDelay, err = resolveDurationValue(cmd, "deadlock-retry-delay", profileDeadlockRetryDelay, db.DefaultDeadlockRetryDelay); err != nil {
+		return args, err
+	}
+	// Archive compression (part of profile, no env var)
+	args.CompressFormat = resolveStringValue(cmd, "compress-format", "", profileCompressFormat, archiveFormatZip)
+	args.CompressLevel = resolveStringValue(cmd, "compress-level", "", profileCompressLevel, "")
+	// Export hooks (part of profile, no env var; *-file flags are handled in loadAndValidateArgs)
+	args.PreExportSQL = resolveStringValue(cmd, "pre-export-sql", "", profilePreExportSQL, "")
+	args.PostExportSQL = resolveStringValue(cmd, "post-export-sql", "", profilePostExportSQL, "")
+	// Webhook notification (URL and headers are part of profile, no env var)
+	args.WebhookURL = resolveStringValue(cmd, "webhook-url", "", profileWebhookURL, "")
+	args.WebhookMethod, _ = cmd.Flags().GetString("webhook-method")
+	webhookHeaders := profileWebhookHeaders
+	if cmd.Flags().Changed("webhook-header") {
+		webhookHeaders, _ = cmd.Flags().GetStringArray("webhook-header")
+	}
+	if args.WebhookHeaders, err = notify.ParseHeaders(webhookHeaders); err != nil {
+		return args, err
+	}
+	args.WebhookTimeout, _ = cmd.Flags().GetDuration("webhook-timeout")
+	// Import hooks (part of profile, no env var; *-file flags are handled in runImport)
+	args.PreImportSQL = resolveStringValue(cmd, "pre-import-sql", "", profilePreImportSQL, "")
+	args.PostImportSQL = resolveStringValue(cmd, "post-import-sql", "", profilePostImportSQL, "")
+	args.PostImportOnError, _ = cmd.Flags().GetBool("post-import-on-error")
+	// Import temp directory (part of profile, no env var)
+	args.TempDir = resolveStringValue(cmd, "temp-dir", "", profileTempDir, "")
+	// Character set of imported tables (part of profile, no env var)
+	args.Charset = resolveStringValue(cmd, "charset", "", profileCharset, "")
+	args.Collation = resolveStringValue(cmd, "collation", "", profileCollation, "")
+	args.Encoding = resolveStringValue(cmd, "encoding", "", profileEncoding, "")
+	args.KeepTemp, _ = cmd.Flags().GetBool("keep-temp")
+	args.ContinueOnError, _ = cmd.Flags().GetBool("continue-on-error")
+	args.AutoMigrate, _ = cmd.Flags().GetBool("auto-migrate")
+	if typeCoerce, _ := cmd.Flags().GetString("type-coerce"); typeCoerce != "" {
+		if args.TypeCoercions, err = loadCoercionRules(typeCoerce); err != nil {
+			return args, err
+		}
+	}
+	args.S3AutoLatest, _ = cmd.Flags().GetBool("s3-auto-latest")
+	args.GdriveIndex, _ = cmd.Flags().GetInt("gdrive-index")
+	if gdriveAutoLatest, _ := cmd.Flags().GetBool("gdrive-auto-latest"); gdriveAutoLatest {
+		if args.GdriveIndex > 1 {
+			return args, fmt.Errorf("--gdrive-auto-latest cannot be combined with --gdrive-index")
+		}
+		args.GdriveIndex = 1
+	}
+	if args.GdriveIndex < 0 {
+		return args, fmt.Errorf("gdrive-index must not be negative, got %d", args.GdriveIndex)
+	}
+	args.VerifyChecksums, _ = cmd.Flags().GetBool("verify-checksums")
+	args.RequireSchemaVersion, _ = cmd.Flags().GetBool("require-schema-version")
+	if args.RequireSchemaVersion && args.SchemaVersion == "" {
+		return args, fmt.Errorf("--require-schema-version needs the expected version in --schema-version or the profile's schema_version")
+	}
+	args.VerifyRowChecksum, _ = cmd.Flags().GetBool("verify-row-checksums")
+	args.DeferIndexes, _ = cmd.Flags().GetBool("defer-indexes")
+	// Workers: Flag > Env (SYNCDB_EXPORT_WORKERS, SYNCDB_IMPORT_WORKERS) > Profile > the flag's default
+	if workersFlag := cmd.Flags().Lookup("workers"); workersFlag != nil {
+		defaultWorkers, _ := strconv.Atoi(workersFlag.DefValue)
+		args.Workers = resolveIntValue(cmd, "workers", cfg.Workers, profileWorkers, defaultWorkers)
+		if err := validateWorkers(args.Workers); err != nil {
+			return args, err
+		}
+		if strings.HasPrefix(cmd.Name(), "export") && os.Getenv(exportWorkersEnv) != "" &&
+			!cmd.Flags().Changed("workers") && cfg.Workers != 0 && cfg.Workers != defaultWorkers {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s is deprecated, use --workers or the profile's workers instead\n", exportWorkersEnv)
+		}
+	}
+	if cmd.Flags().Lookup("on-duplicate") != nil {
+		args.OnDuplicate, _ = cmd.Flags().GetString("on-duplicate")
+		if args.OnDuplicate != onDuplicateSkip && args.OnDuplicate != onDuplicateOverwrite {
+			return args, fmt.Errorf("invalid --on-duplicate %q (valid values: skip, overwrite)", args.OnDuplicate)
+		}
+	}
+	args.MergeSchema, _ = cmd.Flags().GetBool("merge-schema")
+	args.SchemaConflict = resolveStringValue(cmd, "schema-conflict", "", profileSchemaConflict, schemaConflictError)
+	if err := validateSchemaConflict(args.SchemaConflict); err != nil {
+		return args, err
+	}
+	args.SchemaOnly, _ = cmd.Flags().GetBool("schema-only")
+	if args.SchemaOnly {
+		if cmd.Flags().Changed("include-data") && args.IncludeData {
+			return args, fmt.Errorf("--schema-only cannot be combined with --include-data")
+		}
+		if args.Truncate {
+			return args, fmt.Errorf("--schema-only cannot be combined with --truncate")
+		}
+		args.IncludeSchema, args.IncludeData = true, false
+	}
+	if cmd.Flags().Lookup("pk-min") != nil {
+		args.PKMin, _ = cmd.Flags().GetString("pk-min")
+		args.PKMax, _ = cmd.Flags().GetString("pk-max")
+		if err := (pkRange{min: args.PKMin, max: args.PKMax}).validate(); err != nil {
+			return args, err
+		}
+	}
+	if cmd.Flags().Lookup("import-tx-scope") != nil {
+		args.TxScope, _ = cmd.Flags().GetString("import-tx-scope")
+		if err := validateTxScope(&args); err != nil {
+			return args, err
+		}
+	}
 	return args, nil
 }
 
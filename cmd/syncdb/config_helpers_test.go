@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/hoangnguyenba/syncdb/pkg/config"
+	"github.com/hoangnguyenba/syncdb/pkg/filter"
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -134,9 +137,9 @@ host: profile_host
 port: 1234
 driver: postgres
 tables: [prof_t1, prof_t2]
-exclude-table: [prof_ex1]
-profile-include-schema: false # Note: uses profile-specific flag name
-profile-include-data: false   # Note: uses profile-specific flag name
+exclude_table: [prof_ex1]
+include_schema: false
+include_data: false
 `
 		createDummyCmdProfile(t, profileDir, profileName, profileContent)
 
@@ -221,8 +224,8 @@ database: profile_db
 host: profile_host
 port: 1111
 tables: [prof_t1]
-exclude-table: [prof_ex1]
-profile-include-schema: true # Profile says true
+exclude_table: [prof_ex1]
+include_schema: true # Profile says true
 `
 		createDummyCmdProfile(t, profileDir, profileName, profileContent)
 
@@ -350,3 +353,306 @@ includedata: true
 	assert.Equal(t, "./export", args.Path)   // Flag value
 	assert.Equal(t, "postgres", args.Driver) // Profile value
 }
+
+func TestMergeConditions(t *testing.T) {
+	assert.Nil(t, mergeConditions(nil, nil, ""))
+
+	merged := mergeConditions(
+		map[string]string{"orders": "profile_orders", "users": "profile_users"},
+		map[string]string{"orders": "file_orders"},
+		"global",
+	)
+	assert.Equal(t, map[string]string{
+		"orders": "file_orders",
+		"users":  "profile_users",
+		"*":      "global",
+	}, merged)
+}
+
+func TestApplyFilterSpec(t *testing.T) {
+	spec := &filter.FilterSpec{
+		Include:     []string{"orders", "users"},
+		Exclude:     []string{"temp_*"},
+		ExcludeData: []string{"config_table"},
+		Conditions:  map[string]string{"orders": "status = 'active'", "users": "spec_users", "*": "spec_global"},
+	}
+
+	t.Run("Spec replaces profile values", func(t *testing.T) {
+		cmd := newExportCommand()
+		cmdArgs := &CommonArgs{
+			ExcludeTable: []string{"profile_excluded"},
+			Conditions:   map[string]string{"orders": "profile_orders"},
+		}
+		applyFilterSpec(cmd, cmdArgs, spec)
+		assert.Equal(t, []string{"orders", "users"}, cmdArgs.Tables)
+		assert.Equal(t, []string{"temp_*"}, cmdArgs.ExcludeTable)
+		assert.Empty(t, cmdArgs.ExcludeTableSchema)
+		assert.Equal(t, []string{"config_table"}, cmdArgs.ExcludeTableData)
+		assert.Equal(t, spec.Conditions, cmdArgs.Conditions)
+	})
+
+	t.Run("Explicit flags take precedence", func(t *testing.T) {
+		cmd := newExportCommand()
+		require.NoError(t, cmd.Flags().Set("tables", "orders"))
+		require.NoError(t, cmd.Flags().Set("exclude-table-data", "logs"))
+		require.NoError(t, cmd.Flags().Set("conditions-file", "conditions.yaml"))
+		require.NoError(t, cmd.Flags().Set("condition", "flag_global"))
+		cmdArgs := &CommonArgs{
+			Tables:           []string{"orders"},
+			ExcludeTableData: []string{"logs"},
+			Conditions:       map[string]string{"orders": "file_orders", "*": "flag_global"},
+		}
+		applyFilterSpec(cmd, cmdArgs, spec)
+		assert.Equal(t, []string{"orders"}, cmdArgs.Tables)
+		assert.Equal(t, []string{"temp_*"}, cmdArgs.ExcludeTable)
+		assert.Equal(t, []string{"logs"}, cmdArgs.ExcludeTableData)
+		assert.Equal(t, map[string]string{
+			"orders": "file_orders",
+			"users":  "spec_users",
+			"*":      "flag_global",
+		}, cmdArgs.Conditions)
+	})
+}
+
+func TestParseExcludeColumns(t *testing.T) {
+	excludeColumns, err := parseExcludeColumns("users:password_hash, api_token; sessions:token;")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"users":    {"password_hash", "api_token"},
+		"sessions": {"token"},
+	}, excludeColumns)
+
+	excludeColumns, err = parseExcludeColumns("")
+	require.NoError(t, err)
+	assert.Nil(t, excludeColumns)
+
+	_, err = parseExcludeColumns("users")
+	assert.Error(t, err)
+	_, err = parseExcludeColumns(":token")
+	assert.Error(t, err)
+	_, err = parseExcludeColumns("users:")
+	assert.Error(t, err)
+}
+
+func TestParseOrderBy(t *testing.T) {
+	orderBy, err := parseOrderBy("users:created_at DESC, id; sessions:token;")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"users":    "created_at DESC, id",
+		"sessions": "token",
+	}, orderBy)
+
+	orderBy, err = parseOrderBy("")
+	require.NoError(t, err)
+	assert.Nil(t, orderBy)
+
+	_, err = parseOrderBy("users")
+	assert.Error(t, err)
+	_, err = parseOrderBy("users:")
+	assert.Error(t, err)
+	_, err = parseOrderBy("users:id UP")
+	assert.Error(t, err)
+}
+
+func TestPopulateConditions(t *testing.T) {
+	baseTmpDir, cleanupProfileDir := setupTestProfileDir(t)
+	defer cleanupProfileDir()
+	originalSyncDBPath := os.Getenv("SYNCDB_PATH")
+	os.Setenv("SYNCDB_PATH", baseTmpDir)
+	defer os.Setenv("SYNCDB_PATH", originalSyncDBPath)
+
+	createDummyCmdProfile(t, filepath.Join(baseTmpDir, "profiles"), "conditions", `
+database: profile_db
+condition: deleted_at IS NULL
+conditions:
+  orders: status = 'open'
+  users: active = 1
+`)
+	conditionsFile := filepath.Join(baseTmpDir, "conditions.yaml")
+	require.NoError(t, os.WriteFile(conditionsFile, []byte("orders: status = 'closed'\n"), 0644))
+
+	newCmd := func() *cobra.Command {
+		cmd := setupTestCmd()
+		cmd.Flags().String("condition", "", "WHERE condition")
+		cmd.Flags().String("conditions-file", "", "Conditions file")
+		return cmd
+	}
+
+	t.Run("Profile conditions", func(t *testing.T) {
+		args, err := populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{}, "conditions")
+		require.NoError(t, err)
+		assert.Equal(t, "status = 'open'", args.Conditions["orders"])
+		assert.Equal(t, "active = 1", args.Conditions["users"])
+		assert.Equal(t, "deleted_at IS NULL", args.Conditions["*"])
+	})
+
+	t.Run("Flag and file override profile", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("condition", "id > 0"))
+		require.NoError(t, cmd.Flags().Set("conditions-file", conditionsFile))
+		args, err := populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{}, "conditions")
+		require.NoError(t, err)
+		assert.Equal(t, "status = 'closed'", args.Conditions["orders"])
+		assert.Equal(t, "active = 1", args.Conditions["users"])
+		assert.Equal(t, "id > 0", args.Conditions["*"])
+	})
+
+	t.Run("Missing conditions file", func(t *testing.T) {
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("conditions-file", filepath.Join(baseTmpDir, "missing.yaml")))
+		_, err := populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{}, "")
+		assert.Error(t, err)
+	})
+}
+
+func TestPopulateFilterFile(t *testing.T) {
+	baseTmpDir, cleanupProfileDir := setupTestProfileDir(t)
+	defer cleanupProfileDir()
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+
+	createDummyCmdProfile(t, filepath.Join(baseTmpDir, "profiles"), "filtered", `
+database: profile_db
+filter_file: /etc/syncdb/filter.yaml
+`)
+	newCmd := func() *cobra.Command {
+		cmd := setupTestCmd()
+		cmd.Flags().String("filter-file", "", "Filter file")
+		return cmd
+	}
+
+	args, err := populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{}, "filtered")
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/syncdb/filter.yaml", args.FilterFile)
+
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("filter-file", "./filter.yaml"))
+	args, err = populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{}, "filtered")
+	require.NoError(t, err)
+	assert.Equal(t, "./filter.yaml", args.FilterFile)
+}
+
+func TestPopulateStorageFromProfile(t *testing.T) {
+	baseTmpDir, cleanupProfileDir := setupTestProfileDir(t)
+	defer cleanupProfileDir()
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+
+	createDummyCmdProfile(t, filepath.Join(baseTmpDir, "profiles"), "backups", `
+database: profile_db
+storage: s3
+s3_bucket: profile-bucket
+s3_region: eu-west-1
+s3_storage_class: STANDARD_IA
+s3_tags: Environment=prod,CostCenter=db-backups
+s3_kms_key_id: alias/backups
+gdrive_credentials: /etc/syncdb/creds.json
+gdrive_folder: folder-id
+`)
+	newCmd := func() *cobra.Command {
+		cmd := setupTestCmd()
+		cmd.Flags().String("gdrive-credentials", "", "Google Drive credentials")
+		cmd.Flags().String("gdrive-folder", "", "Google Drive folder")
+		return cmd
+	}
+
+	args, err := populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{}, "backups")
+	require.NoError(t, err)
+	assert.Equal(t, "s3", args.Storage)
+	assert.Equal(t, "profile-bucket", args.S3Bucket)
+	assert.Equal(t, "eu-west-1", args.S3Region)
+	assert.Equal(t, "STANDARD_IA", args.S3StorageClass)
+	assert.Equal(t, "Environment=prod,CostCenter=db-backups", args.S3Tags)
+	assert.Equal(t, "alias/backups", args.S3KMSKeyID)
+	assert.Equal(t, "/etc/syncdb/creds.json", args.GdriveCredentials)
+	assert.Equal(t, "folder-id", args.GdriveFolder)
+
+	// Flag > Env > Profile
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("s3-bucket", "flag-bucket"))
+	require.NoError(t, cmd.Flags().Set("gdrive-folder", "flag-folder"))
+	args, err = populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{Storage: "gdrive", S3Region: "us-east-1"}, "backups")
+	require.NoError(t, err)
+	assert.Equal(t, "gdrive", args.Storage)
+	assert.Equal(t, "flag-bucket", args.S3Bucket)
+	assert.Equal(t, "us-east-1", args.S3Region)
+	assert.Equal(t, "flag-folder", args.GdriveFolder)
+}
+
+func TestPopulateWorkers(t *testing.T) {
+	baseTmpDir, cleanupProfileDir := setupTestProfileDir(t)
+	defer cleanupProfileDir()
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+
+	createDummyCmdProfile(t, filepath.Join(baseTmpDir, "profiles"), "parallel", "database: profile_db\nworkers: 6\n")
+	newCmd := func() *cobra.Command {
+		cmd := setupTestCmd()
+		cmd.Flags().Int("workers", 2, "Number of tables exported in parallel")
+		return cmd
+	}
+
+	// The flag's default without env var or profile
+	args, err := populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, args.Workers)
+
+	// Profile > Default
+	args, err = populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{}, "parallel")
+	require.NoError(t, err)
+	assert.Equal(t, 6, args.Workers)
+
+	// Env > Profile
+	args, err = populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{Workers: 4}, "parallel")
+	require.NoError(t, err)
+	assert.Equal(t, 4, args.Workers)
+
+	// Flag > Env
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("workers", "8"))
+	args, err = populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{Workers: 4}, "parallel")
+	require.NoError(t, err)
+	assert.Equal(t, 8, args.Workers)
+
+	for _, workers := range []string{"0", "65"} {
+		cmd := newCmd()
+		require.NoError(t, cmd.Flags().Set("workers", workers))
+		_, err = populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{}, "")
+		assert.ErrorContains(t, err, "workers must be between 1 and 64")
+	}
+	_, err = populateCommonArgsFromFlagsAndConfig(newCmd(), config.CommonConfig{Workers: 100}, "")
+	assert.Error(t, err)
+
+	// Setting the export workers through the environment is deprecated
+	t.Setenv("SYNCDB_EXPORT_WORKERS", "4")
+	var stderr bytes.Buffer
+	cmd = newCmd()
+	cmd.Use = "export"
+	cmd.SetErr(&stderr)
+	args, err = populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{Workers: 4}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 4, args.Workers)
+	assert.Equal(t, "Warning: SYNCDB_EXPORT_WORKERS is deprecated, use --workers or the profile's workers instead\n", stderr.String())
+
+	// No warning when the flag overrides it
+	stderr.Reset()
+	require.NoError(t, cmd.Flags().Set("workers", "8"))
+	_, err = populateCommonArgsFromFlagsAndConfig(cmd, config.CommonConfig{Workers: 4}, "")
+	require.NoError(t, err)
+	assert.Empty(t, stderr.String())
+}
+
+func TestWorldReadableProfile(t *testing.T) {
+	baseTmpDir, cleanupProfileDir := setupTestProfileDir(t)
+	defer cleanupProfileDir()
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+	profilePath := createDummyCmdProfile(t, filepath.Join(baseTmpDir, "profiles"), "prod", "database: app\npassword: secret\n")
+
+	assert.True(t, profileHasCredentials(&profile.ProfileConfig{Password: "secret"}))
+	assert.True(t, profileHasCredentials(&profile.ProfileConfig{DSN: "mysql://app:secret@db/app"}))
+	assert.True(t, profileHasCredentials(&profile.ProfileConfig{GdriveCredentials: "/etc/creds.json"}))
+	assert.False(t, profileHasCredentials(&profile.ProfileConfig{DSN: "mysql://app@db/app", S3Bucket: "backups"}))
+
+	require.NoError(t, os.Chmod(profilePath, 0644))
+	assert.True(t, isWorldReadable(profilePath))
+	require.NoError(t, os.Chmod(profilePath, 0640))
+	assert.False(t, isWorldReadable(profilePath))
+	assert.False(t, isWorldReadable(filepath.Join(baseTmpDir, "missing.yaml")))
+}
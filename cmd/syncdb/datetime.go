@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultDateTimeFormat is the layout used for time values unless --datetime-format is set.
+const defaultDateTimeFormat = "2006-01-02 15:04:05"
+
+// dateTimeInputLayouts are the layouts tried when reading a timestamp column
+// value: time values round-tripped through JSON are RFC 3339, and MySQL
+// returns DATETIME/TIMESTAMP columns as text.
+var dateTimeInputLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+}
+
+// loadDateTimeArgs reads the export flags controlling how time values are written.
+func loadDateTimeArgs(cmd *cobra.Command, cmdArgs *CommonArgs) error {
+	cmdArgs.DateTimeFormat, _ = cmd.Flags().GetString("datetime-format")
+	cmdArgs.DateTimeUTC, _ = cmd.Flags().GetBool("datetime-utc")
+	// Import shares the argument loading but has none of these flags
+	if zeroTimeAsNull, err := cmd.Flags().GetBool("zero-time-as-null"); err == nil {
+		cmdArgs.KeepZeroTime = !zeroTimeAsNull
+	}
+
+	timezone, _ := cmd.Flags().GetString("datetime-timezone")
+	if timezone == "" {
+		return nil
+	}
+	if cmdArgs.DateTimeUTC {
+		return fmt.Errorf("--datetime-utc and --datetime-timezone cannot be used together")
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return fmt.Errorf("invalid datetime-timezone %q: %v", timezone, err)
+	}
+	cmdArgs.DateTimeLocation = location
+	return nil
+}
+
+// customDateTime reports whether any datetime option changes how time values
+// are written. Timestamp columns are only reformatted in that case, so exports
+// without these options keep the values exactly as the database returned them.
+func (c *CommonArgs) customDateTime() bool {
+	return c.DateTimeFormat != "" || c.DateTimeUTC || c.DateTimeLocation != nil
+}
+
+// formatDateTime renders a time value with the configured zone and layout.
+func formatDateTime(t time.Time, cmdArgs *CommonArgs) string {
+	if cmdArgs.DateTimeUTC {
+		t = t.UTC()
+	} else if cmdArgs.DateTimeLocation != nil {
+		t = t.In(cmdArgs.DateTimeLocation)
+	}
+	layout := cmdArgs.DateTimeFormat
+	if layout == "" {
+		layout = defaultDateTimeFormat
+	}
+	return t.Format(layout)
+}
+
+// isDateTimeType reports whether a column type from DataOperation.ColumnTypes
+// holds a date and time.
+func isDateTimeType(columnType string) bool {
+	switch columnType {
+	case "timestamp", "timestamptz", "datetime":
+		return true
+	}
+	return false
+}
+
+// parseDateTime reads a timestamp column value. Values without a zone offset
+// are taken as UTC.
+func parseDateTime(s string) (time.Time, bool) {
+	for _, layout := range dateTimeInputLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
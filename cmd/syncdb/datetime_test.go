@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDateTimeValues(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// TIMESTAMPTZ values reach the SQL writer as RFC 3339 text
+	timestamptz := "2024-03-01T10:20:30.123456+02:00"
+	// MySQL returns DATETIME(6) columns as text without a zone
+	datetime6 := "2024-03-01 10:20:30.123456"
+
+	testCases := []struct {
+		name       string
+		args       CommonArgs
+		driver     string
+		columnType string
+		value      interface{}
+		expected   string
+	}{
+		{"Default keeps TIMESTAMPTZ text", CommonArgs{}, db.DriverPostgres, "timestamptz", timestamptz, "'" + timestamptz + "'"},
+		{"TIMESTAMPTZ in UTC", CommonArgs{DateTimeUTC: true}, db.DriverPostgres, "timestamptz", timestamptz, "'2024-03-01 08:20:30'"},
+		{"TIMESTAMPTZ with microseconds and offset", CommonArgs{DateTimeFormat: "2006-01-02 15:04:05.000000-07:00"},
+			db.DriverPostgres, "timestamptz", timestamptz, "'2024-03-01 10:20:30.123456+02:00'"},
+		{"TIMESTAMPTZ in time zone", CommonArgs{DateTimeLocation: berlin}, db.DriverPostgres, "timestamptz", timestamptz, "'2024-03-01 09:20:30'"},
+		{"DATETIME(6) with microseconds", CommonArgs{DateTimeFormat: "2006-01-02 15:04:05.000000"},
+			db.DriverMySQL, "datetime", datetime6, "'2024-03-01 10:20:30.123456'"},
+		{"DATETIME(6) truncated by default layout", CommonArgs{DateTimeUTC: true}, db.DriverMySQL, "datetime", datetime6, "'2024-03-01 10:20:30'"},
+		{"Other columns are not parsed", CommonArgs{DateTimeUTC: true}, db.DriverMySQL, "varchar", datetime6, "'" + datetime6 + "'"},
+		{"Time value in UTC", CommonArgs{DateTimeUTC: true}, db.DriverMySQL, "",
+			time.Date(2024, 3, 1, 10, 20, 30, 0, berlin), "'2024-03-01 09:20:30'"},
+		{"Zero time as NULL", CommonArgs{}, db.DriverMySQL, "", time.Time{}, "NULL"},
+		{"Zero time kept", CommonArgs{KeepZeroTime: true}, db.DriverMySQL, "", time.Time{}, "'0001-01-01 00:00:00'"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := formatColumnValue(tc.value, tc.columnType, tc.driver, &tc.args)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+}
+
+func TestLoadDateTimeArgs(t *testing.T) {
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := newExportCommand()
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	var cmdArgs CommonArgs
+	require.NoError(t, loadDateTimeArgs(newCmd("--datetime-timezone", "Asia/Tokyo"), &cmdArgs))
+	assert.Equal(t, "Asia/Tokyo", cmdArgs.DateTimeLocation.String())
+	assert.False(t, cmdArgs.KeepZeroTime)
+
+	cmdArgs = CommonArgs{}
+	require.NoError(t, loadDateTimeArgs(newCmd("--zero-time-as-null=false"), &cmdArgs))
+	assert.True(t, cmdArgs.KeepZeroTime)
+
+	assert.Error(t, loadDateTimeArgs(newCmd("--datetime-timezone", "Mars/Olympus"), &CommonArgs{}))
+	assert.Error(t, loadDateTimeArgs(newCmd("--datetime-utc", "--datetime-timezone", "UTC"), &CommonArgs{}))
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/config"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// doctorCheck is a single prerequisite check run by 'syncdb doctor'.
+type doctorCheck struct {
+	Name string
+	Run  func() error
+}
+
+func newDoctorCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that prerequisites for export and import are configured",
+		Long: `Check local configuration before running an export or import: database
+driver support, storage credentials and writable directories. No database
+connection is made.
+Examples:
+  syncdb doctor --driver postgres --path ./backups
+  syncdb doctor --storage s3 --s3-bucket my-bucket --s3-region us-west-2
+  syncdb doctor --storage gdrive --gdrive-credentials ./credentials.json`,
+		Args:         cobra.NoArgs,
+		RunE:         runDoctor,
+		SilenceUsage: true, // Failed checks are not usage errors
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("driver", "D", "", "Database driver to check (mysql, postgres)")
+	flags.StringP("path", "o", "", "Export directory to check for write access (default: current directory)")
+	flags.String("temp-dir", "", "Temp directory to check for write access (default: system temp directory)")
+	flags.StringP("storage", "s", "", "Storage type whose credentials should be checked (local, s3, gdrive, gcs)")
+	flags.String("s3-bucket", "", "S3 bucket name")
+	flags.String("s3-region", "", "S3 region")
+	flags.String("gdrive-credentials", "", "Google Drive service account credentials file path")
+	flags.String("gdrive-folder", "", "Google Drive folder ID")
+	flags.String("gcs-bucket", "", "Google Cloud Storage bucket name")
+	flags.String("gcs-credentials", "", "Google Cloud service account credentials file path")
+
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	checks := buildDoctorChecks(cmd)
+	if failed := runDoctorChecks(os.Stdout, checks); failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+// buildDoctorChecks assembles the checks for the configured driver and storage.
+// Values fall back to the environment configuration like export and import do.
+func buildDoctorChecks(cmd *cobra.Command) []doctorCheck {
+	var cfg config.CommonConfig
+	if exportConfig != nil {
+		cfg = exportConfig.Export.CommonConfig
+	}
+	driver := resolveStringValue(cmd, "driver", cfg.Driver, "", "mysql")
+	exportPath := resolveStringValue(cmd, "path", cfg.Path, "", ".")
+	tempDir, _ := cmd.Flags().GetString("temp-dir")
+	if tempDir == "" {
+		tempDir = os.TempDir()
+	}
+	storageType := resolveStringValue(cmd, "storage", cfg.Storage, "", "local")
+
+	checks := []doctorCheck{
+		{Name: fmt.Sprintf("Database driver (%s)", driver), Run: func() error { return checkDriver(driver) }},
+	}
+	if driver == "sqlite" || driver == "sqlite3" {
+		checks = append(checks, doctorCheck{Name: "CGO enabled (required for SQLite)", Run: checkCGO})
+	}
+	checks = append(checks,
+		doctorCheck{Name: fmt.Sprintf("Export directory writable (%s)", exportPath), Run: func() error { return checkWritableDir(exportPath) }},
+		doctorCheck{Name: fmt.Sprintf("Temp directory writable (%s)", tempDir), Run: func() error { return checkWritableDir(tempDir) }},
+	)
+
+	switch storageType {
+	case "s3":
+		bucket := resolveStringValue(cmd, "s3-bucket", cfg.S3Bucket, "", "")
+		region := resolveStringValue(cmd, "s3-region", cfg.S3Region, "", "")
+		checks = append(checks,
+			doctorCheck{Name: "S3 bucket and region", Run: func() error {
+				return requireSettings(map[string]string{"s3-bucket": bucket, "s3-region": region})
+			}},
+			doctorCheck{Name: "AWS credentials", Run: checkAWSCredentials},
+		)
+	case "gdrive":
+		credentials, _ := cmd.Flags().GetString("gdrive-credentials")
+		folder, _ := cmd.Flags().GetString("gdrive-folder")
+		checks = append(checks,
+			doctorCheck{Name: "Google Drive folder", Run: func() error {
+				return requireSettings(map[string]string{"gdrive-folder": folder})
+			}},
+			doctorCheck{Name: "Google Drive credentials file", Run: func() error { return checkReadableFile("gdrive-credentials", credentials) }},
+		)
+	case "gcs":
+		bucket := resolveStringValue(cmd, "gcs-bucket", cfg.GCSBucket, "", "")
+		credentials := resolveStringValue(cmd, "gcs-credentials", cfg.GCSCredentials, "", "")
+		checks = append(checks,
+			doctorCheck{Name: "GCS bucket", Run: func() error {
+				return requireSettings(map[string]string{"gcs-bucket": bucket})
+			}},
+			doctorCheck{Name: "Google Cloud credentials", Run: func() error { return checkGCSCredentials(credentials) }},
+		)
+	case "local":
+	default:
+		checks = append(checks, doctorCheck{Name: "Storage type", Run: func() error {
+			return fmt.Errorf("unsupported storage type: %s", storageType)
+		}})
+	}
+	return checks
+}
+
+// runDoctorChecks runs every check, printing one result line per check, and
+// returns the number of failed checks.
+func runDoctorChecks(w io.Writer, checks []doctorCheck) int {
+	failed := 0
+	for _, check := range checks {
+		if err := check.Run(); err != nil {
+			fmt.Fprintf(w, "%-45s ✗ FAIL: %v\n", check.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "%-45s ✓ OK\n", check.Name)
+	}
+	return failed
+}
+
+// checkDriver verifies that the database driver is compiled into the binary.
+// sql.Open does not connect, so no database needs to be reachable.
+func checkDriver(driver string) error {
+	conn, err := sql.Open(driver, "")
+	if err != nil {
+		return fmt.Errorf("driver not available in this build: %v", err)
+	}
+	conn.Close()
+	if driver != db.DriverMySQL && driver != db.DriverPostgres {
+		return fmt.Errorf("driver %s is compiled in but not supported by syncdb", driver)
+	}
+	return nil
+}
+
+// checkCGO verifies that the binary was built with CGO, which SQLite drivers require.
+func checkCGO() error {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fmt.Errorf("build information is not available")
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "CGO_ENABLED" && setting.Value == "1" {
+			return nil
+		}
+	}
+	return fmt.Errorf("binary was built without CGO")
+}
+
+// checkWritableDir verifies that dir, or the parent it would be created in, is writable.
+func checkWritableDir(dir string) error {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return validateTempDir(dir)
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("no existing parent directory")
+		}
+		dir = parent
+	}
+}
+
+// checkAWSCredentials verifies that AWS credentials are available from the
+// environment or the shared credentials file.
+func checkAWSCredentials() error {
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "" {
+		return nil
+	}
+	credentialsFile := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if credentialsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+		}
+		credentialsFile = filepath.Join(home, ".aws", "credentials")
+	}
+	if _, err := os.Stat(credentialsFile); err != nil {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set and no shared credentials file was found")
+	}
+	return nil
+}
+
+// checkGCSCredentials verifies the credentials file if given, or that Application
+// Default Credentials are configured through GOOGLE_APPLICATION_CREDENTIALS.
+func checkGCSCredentials(credentialsFile string) error {
+	if credentialsFile != "" {
+		return checkReadableFile("gcs-credentials", credentialsFile)
+	}
+	if adc := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); adc != "" {
+		return checkReadableFile("GOOGLE_APPLICATION_CREDENTIALS", adc)
+	}
+	// Credentials may still come from the metadata server or gcloud, which needs a network call to verify
+	fmt.Fprintln(os.Stderr, "Note: no GCS credentials file configured, relying on Application Default Credentials")
+	return nil
+}
+
+// checkReadableFile verifies that the file named by setting exists and can be read.
+func checkReadableFile(setting, path string) error {
+	if path == "" {
+		return fmt.Errorf("%s is not set", setting)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %v", path, err)
+	}
+	return f.Close()
+}
+
+// requireSettings returns an error listing the settings that are empty.
+func requireSettings(settings map[string]string) error {
+	var missing []string
+	for name, value := range settings {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("%s not set", strings.Join(missing, ", "))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunDoctorChecks(t *testing.T) {
+	var buf bytes.Buffer
+	failed := runDoctorChecks(&buf, []doctorCheck{
+		{Name: "passing", Run: func() error { return nil }},
+		{Name: "failing", Run: func() error { return errors.New("missing thing") }},
+	})
+	assert.Equal(t, 1, failed)
+	assert.Contains(t, buf.String(), "✓ OK")
+	assert.Contains(t, buf.String(), "✗ FAIL: missing thing")
+}
+
+func TestDoctorChecks(t *testing.T) {
+	assert.NoError(t, checkDriver("mysql"))
+	assert.NoError(t, checkDriver("postgres"))
+	assert.Error(t, checkDriver("sqlite3"))
+
+	dir := t.TempDir()
+	assert.NoError(t, checkWritableDir(dir))
+	assert.NoError(t, checkWritableDir(filepath.Join(dir, "not", "created", "yet")))
+
+	file := filepath.Join(dir, "credentials.json")
+	require.NoError(t, os.WriteFile(file, []byte("{}"), 0600))
+	assert.NoError(t, checkReadableFile("gdrive-credentials", file))
+	assert.Error(t, checkReadableFile("gdrive-credentials", filepath.Join(dir, "missing.json")))
+	assert.Error(t, checkReadableFile("gdrive-credentials", ""))
+
+	assert.NoError(t, requireSettings(map[string]string{"s3-bucket": "b", "s3-region": "r"}))
+	assert.EqualError(t, requireSettings(map[string]string{"s3-bucket": "", "s3-region": ""}), "s3-bucket, s3-region not set")
+}
@@ -1,46 +1,112 @@
 package main
 
 import (
-	"archive/zip"
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/hoangnguyenba/syncdb/pkg/config"
 	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/filter"
+	"github.com/hoangnguyenba/syncdb/pkg/lock"
 	"github.com/hoangnguyenba/syncdb/pkg/profile"
 	"github.com/hoangnguyenba/syncdb/pkg/storage"
 )
 
 type ExportData struct {
-	Metadata struct {
-		ExportedAt   time.Time `json:"exported_at"`
-		DatabaseName string    `json:"database_name"`
-		Tables       []string  `json:"tables"`
-		Schema       bool      `json:"include_schema"`
-		ViewData     bool      `json:"include_view_data"`
-		IncludeData  bool      `json:"include_data"`
-		Base64       bool      `json:"base64"`
-	} `json:"metadata"`
-	Schema map[string]string                   `json:"schema,omitempty"`
-	Data   map[string][]map[string]interface{} `json:"data"` // Keep this for now, might remove if not needed later
+	Metadata ExportMetadata                      `json:"metadata"`
+	Schema   map[string]string                   `json:"schema,omitempty"`
+	Data     map[string][]map[string]interface{} `json:"data"` // Keep this for now, might remove if not needed later
+}
+
+// ExportMetadata is the content of an export's metadata file, written as JSON
+// or, with --metadata-format yaml, as YAML.
+type ExportMetadata struct {
+	ExportedAt   time.Time `json:"exported_at" yaml:"exported_at"`
+	DatabaseName string    `json:"database_name" yaml:"database_name"`
+	Tables       []string  `json:"tables" yaml:"tables"`
+	Schema       bool      `json:"include_schema" yaml:"include_schema"`
+	ViewData     bool      `json:"include_view_data" yaml:"include_view_data"`
+	IncludeData  bool      `json:"include_data" yaml:"include_data"`
+	Base64       bool      `json:"base64" yaml:"base64"` // String values are base64 encoded (--base64-strings)
+	// Binary values are base64 encoded (--base64-blobs)
+	Base64Blobs bool `json:"base64_blobs,omitempty" yaml:"base64_blobs,omitempty"`
+	// Columns left out of the data files with --exclude-columns
+	ExcludedColumns map[string][]string `json:"excluded_columns,omitempty" yaml:"excluded_columns,omitempty"`
+	// Fraction of rows exported with --sample-rate (0 means all rows)
+	SampleRate float64 `json:"sample_rate,omitempty" yaml:"sample_rate,omitempty"`
+	// Format of the schema and data files (sql, json, csv or parquet)
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+	// Statement used in the data files (see --insert-mode)
+	InsertMode string `json:"insert_mode,omitempty" yaml:"insert_mode,omitempty"`
+	// Number of data files per table written with --chunk-size or --max-file-size
+	ChunkCounts map[string]int `json:"chunk_counts,omitempty" yaml:"chunk_counts,omitempty"`
+	// Format of each table's data files (sql, json, csv or parquet)
+	DataFormats map[string]string `json:"data_formats,omitempty" yaml:"data_formats,omitempty"`
+	// Tables left out by --min-rows or --max-rows
+	ExcludedByRowCount []string `json:"excluded_by_row_count,omitempty" yaml:"excluded_by_row_count,omitempty"`
+	// Entries of Tables that are views, exported with --include-view-data
+	Views []string `json:"views,omitempty" yaml:"views,omitempty"`
+	// Version of the exported schema, set with --schema-version
+	SchemaVersion string `json:"schema_version,omitempty" yaml:"schema_version,omitempty"`
+	// Exported tables each table references through its foreign keys
+	TableRelationships map[string][]string `json:"table_relationships,omitempty" yaml:"table_relationships,omitempty"`
+	// Binary values larger than --blob-threshold are in .bin sidecar files
+	HasBlobSidecars bool `json:"has_blob_sidecars,omitempty" yaml:"has_blob_sidecars,omitempty"`
+	// Statement terminator and comment style of the SQL files, set with
+	// --stmt-terminator and --comment-style (empty for the defaults)
+	StmtTerminator string `json:"stmt_terminator,omitempty" yaml:"stmt_terminator,omitempty"`
+	CommentStyle   string `json:"comment_style,omitempty" yaml:"comment_style,omitempty"`
+	// Page of rows of each table exported with --page-size and --page-number
+	PageNumber int `json:"page_number,omitempty" yaml:"page_number,omitempty"`
+	PageSize   int `json:"page_size,omitempty" yaml:"page_size,omitempty"`
+	// Filter spec read from --filter-file
+	FilterSpec *filter.FilterSpec `json:"filter_spec,omitempty" yaml:"filter_spec,omitempty"`
 }
 
 var (
 	exportConfig *config.Config
 )
 
+// defaultWriteBufferSize is the default --write-buffer-size in MB
+const defaultWriteBufferSize = 4
+
+// exportLockFileName is the default lock file created in the export path
+const exportLockFileName = ".syncdb.lock"
+
+// Insert modes supported by the --insert-mode flag
+const (
+	insertModeInsert       = "insert"
+	insertModeInsertIgnore = "insert-ignore"
+	insertModeReplace      = "replace"
+	insertModeUpsert       = "upsert"
+)
+
+// Table orders supported by the --table-order flag
+const (
+	tableOrderDependency   = "dependency"
+	tableOrderAlpha        = "alpha"
+	tableOrderReverseAlpha = "reverse-alpha"
+	tableOrderManual       = "manual"
+)
+
+// defaultNullToken is written for NULL values unless --null-token is set.
+const defaultNullToken = "NULL"
+
 func init() {
 	var err error
 	exportConfig, err = config.LoadConfig()
@@ -56,16 +122,93 @@ func newExportCommand() *cobra.Command {
 		Long:  `Export database data to a file.`,
 		RunE:  runExport, // Use the named function
 	}
+	addExportFlags(cmd)
+
+	return cmd
+}
 
+// addExportFlags adds the flags of the export command to cmd, which are also
+// the flags of export-pages.
+func addExportFlags(cmd *cobra.Command) {
 	// Add shared flags
 	AddSharedFlags(cmd, false) // Pass false for export command
 
 	// Add export-specific flags
 	flags := cmd.Flags()
-	flags.Int("batch-size", 500, "Number of records to process in a batch")
+	flags.Int("batch-size", 500, "Number of rows per INSERT statement")
+	flags.Int("workers", defaultExportWorkers(), "Number of tables exported in parallel, each on its own connection (1-64, half the CPU cores by default); overrides SYNCDB_EXPORT_WORKERS and the profile's workers")
+	flags.String("max-insert-bytes", "0", "Maximum size of an INSERT statement, e.g. 1MB to stay below MySQL's max_allowed_packet; a batch is split into several statements when needed (0 means no limit)")
 	flags.Int("limit", 0, "Maximum number of records to export per table (0 means no limit)")
-
-	return cmd
+	flags.Int("offset", 0, "Number of records to skip in each table before exporting, for exporting a table page by page with --limit (requires --order-by-pk)")
+	flags.Int64("min-rows", 0, "Only export tables with at least this many rows (0 means no minimum)")
+	flags.Int64("max-rows", 0, "Only export tables with at most this many rows (0 means no maximum)")
+	flags.Int("write-buffer-size", defaultWriteBufferSize, "Size in MB of the buffer used to write each data file")
+	flags.String("max-file-size", "", "Maximum size of a data file, e.g. 100MB; larger tables are split into {index}_{table}_partN.sql files, or chunkN with --chunk-size (empty means no limit)")
+	flags.String("blob-threshold", defaultBlobThreshold, "Size above which a binary value is written to a {index}_{table}_{column}_{row}.bin file and loaded from it on import, e.g. 512KB (0 means never)")
+	flags.Int("chunk-size", 0, "Number of INSERT statements per data file; larger tables are split into {index}_{table}_chunkN.sql files (0 means one file per table)")
+	flags.String("datetime-format", "", "Go time layout for date/time values (default: 2006-01-02 15:04:05)")
+	flags.Bool("datetime-utc", false, "Convert date/time values to UTC before formatting")
+	flags.String("datetime-timezone", "", "Convert date/time values to an IANA time zone (e.g. Europe/Berlin) before formatting")
+	flags.Bool("zero-time-as-null", true, "Write zero date/time values as NULL")
+	flags.String("binary-format", "", "Encoding of binary columns: base64 (same as --base64-blobs) or hex")
+	flags.Bool("base64-blobs", false, "Encode the values of binary columns (BLOB, BINARY, VARBINARY, bytea) in base64")
+	flags.Bool("base64-strings", false, "Encode the values of all other string columns in base64")
+	flags.Bool("follow-fk", false, "With --tables, also export the tables the selected tables reference through foreign keys, recursively")
+	flags.Int("fk-depth", 0, "Maximum number of foreign key levels followed by --follow-fk (0 means no limit)")
+	flags.Bool("fk-schema-only", false, "Export only the schema, not the data, of the tables added by --follow-fk")
+	flags.Bool("defer-indexes", false, "Write secondary indexes to 0_indexes.sql instead of the CREATE TABLE statements, so they are built after the data import")
+	flags.String("sql-header", defaultSQLHeader, "Comment written at the top of 0_schema.sql and each data file; placeholders: {date}, {driver}, {host}, {port}, {database} (empty disables it)")
+	flags.String("mysql-set-names", "", "Character set of SET NAMES statements written at the top of the SQL files, e.g. utf8mb4 (MySQL only)")
+	flags.String("schema-version", "", "Schema version recorded in the export metadata, e.g. a migration number, commit SHA or semantic version")
+	flags.Bool("ansi-quotes", false, "Quote identifiers with double quotes instead of backticks in the SQL files, for servers running with the ANSI_QUOTES SQL mode (MySQL only)")
+	flags.String("quote-identifiers", db.QuoteStyleAuto, "Quoting of table and column names in the SQL files, for tools expecting other quotes: auto (driver-specific), backtick, double-quote or none")
+	flags.String("stmt-terminator", stmtTerminatorSemicolon, "Statement terminator of the SQL files, for tools other than syncdb: semicolon, go (GO lines, MSSQL) or slash (/ lines, Oracle)")
+	flags.String("comment-style", commentStyleDashDash, "Comment style of the SQL files, for tools other than syncdb: dash-dash (--), hash (#) or c-style (/* */)")
+	flags.Bool("normalize-json", false, "Rewrite string values holding JSON objects or arrays with sorted keys and without extra whitespace, for stable diffs between exports")
+	flags.Bool("row-checksum", false, "Write a CRC32 comment before each row of the data files, checked on import with --verify-row-checksums")
+	flags.Float64("sample-rate", 0, "Fraction of rows to export per table, between 0.0 and 1.0 (0 = all rows)")
+	flags.Int64("sample-seed", 0, "Seed for repeatable sampling with --sample-rate (0 = different sample each run)")
+	flags.String("insert-mode", "", "SQL insert mode for data files (insert, insert-ignore, replace, upsert); also accepted as --insert-strategy")
+	flags.SetNormalizeFunc(insertModeFlagAlias)
+	flags.String("table-order", "", "Order of exported tables: dependency (default), alpha, reverse-alpha or manual (the order of --tables, then the remaining tables in dependency order)")
+	flags.String("compress-format", "", "Archive format when creating an archive (zip, tar.gz, tar.zst)")
+	flags.Bool("compress-sql-files", false, "Compress each SQL data file with gzip as it is written ({index}_{table}.sql.gz), at the --compress-level (-1 to 9)")
+	flags.Int("keep-last", 0, "Keep only the N most recent exports of this database after a successful export (0 = keep all)")
+	flags.Bool("prune-dry-run", false, "Show which old exports --keep-last would delete without deleting them")
+	flags.String("lock-file", "", "Lock file that prevents concurrent exports to the same path (default: {path}/.syncdb.lock)")
+	flags.Duration("lock-timeout", 0, "How long to wait for the lock held by another export (0 = fail immediately)")
+	flags.String("checkpoint-file", "", "File recording the tables whose data has been exported, removed when the export completes (default: {export-path}/"+checkpointFileName+")")
+	flags.Bool("resume", false, "Skip the tables recorded in the checkpoint file by an interrupted export; use with --path set to the export directory")
+	flags.Bool("no-lock", false, "Do not lock the export path (e.g. on NFS mounts where file locks are unreliable)")
+	flags.String("pre-export-sql", "", "SQL to run before the export starts")
+	flags.String("post-export-sql", "", "SQL to run after all export files are written (runs even if the export fails)")
+	flags.String("pre-export-sql-file", "", "File containing SQL to run before the export starts")
+	flags.String("post-export-sql-file", "", "File containing SQL to run after all export files are written")
+	flags.String("compress-level", "", "Compression level (zip/tar.gz: -1 to 9; tar.zst: fastest, default, better, best or a zstd level)")
+	flags.String("condition", "", "WHERE condition applied to tables without a per-table condition")
+	flags.Duration("query-timeout", 0, "Maximum duration of each table export query, e.g. 30s (0 = no limit)")
+	flags.String("metadata-format", metadataFormatJSON, "Format of the metadata file: json (0_metadata.json) or yaml (0_metadata.yaml, with a comment on each field)")
+	flags.Int("export-retry-count", db.DefaultExportRetryCount, "Number of times a table is exported again after a transient database error, such as a lost connection (0 = no retry)")
+	flags.Duration("export-retry-delay", db.DefaultExportRetryDelay, "Delay before exporting a table again after a transient database error")
+	flags.String("null-token", "", "Token written for NULL values in data files (default: NULL)")
+	flags.Bool("empty-string-as-null", false, "Write empty string values as the null token")
+	flags.String("conditions-file", "", "YAML file mapping table names to WHERE conditions")
+	flags.String("filter-file", "", "YAML filter spec with include, exclude, exclude_schema, exclude_data and conditions; flags passed explicitly take precedence")
+	flags.String("exclude-columns", "", "Columns to leave out of data files (table:col1,col2;table2:col3)")
+	flags.String("order-by", "", "Sort the rows of data files (table:col1 ASC,col2 DESC;table2:col3)")
+	flags.Bool("order-by-pk", false, "Sort the rows of tables without an --order-by entry by their primary key")
+	flags.Bool("incremental", false, "Only export rows that are new or changed since the previous export, using the row hashes stored with it")
+	flags.Bool("include-stats", false, "Write per-column statistics (NULL count, min/max/avg of numbers, max length and distinct count of strings) to 0_column_stats.json")
+	flags.Int("float-precision", -1, "Digits after the decimal point of FLOAT/DOUBLE values (-1 = shortest exact representation)")
+	flags.Bool("decimal-strip-trailing-zeros", false, "Remove trailing zeros after the decimal point of DECIMAL values and rounded floats (1.23000 becomes 1.23)")
+	flags.String("uuid-format", uuidFormatString, "Output of UUID columns (CHAR id, uuid or guid columns holding UUIDs): string, hex or binary (UNHEX(), MySQL only)")
+	flags.Bool("deterministic", false, "Make data files reproducible and diff-friendly (implies --order-by-pk)")
+	flags.Bool("disable-keys", false, "Wrap each data file with DISABLE KEYS / ENABLE KEYS (PostgreSQL: session_replication_role) to speed up import")
+	flags.Bool("disable-unique-checks", false, "With --disable-keys, also disable UNIQUE_CHECKS for InnoDB tables")
+	flags.Bool("include-generated", false, "Export the values of generated (computed) columns, which are left out by default since the database computes them; such data files cannot be imported into tables where the columns are generated")
+	flags.Bool("disable-fk-check-on-export", false, "Wrap each data file with SET FOREIGN_KEY_CHECKS=0 / 1 (PostgreSQL: session_replication_role) so it imports without foreign key checks")
+	flags.Int("page-size", 0, "Rows per page of a paginated export: only the rows of --page-number are exported from each table, ordered by primary key")
+	flags.Int("page-number", 0, "Page of rows to export with --page-size, starting at 1; the export is named {database}_{timestamp}_page{N}")
 }
 
 // loadAndValidateArgs loads configuration, merges flags, validates required fields,
@@ -85,14 +228,200 @@ func loadAndValidateArgs(cmd *cobra.Command) (*CommonArgs, int, *db.Connection,
 	}
 
 	// Get export-specific flags/config
-	batchSize := getIntFlagWithConfigFallback(cmd, "batch-size", exportConfig.Export.BatchSize)
+	rowsPerInsert := getIntFlagWithConfigFallback(cmd, "batch-size", exportConfig.Export.BatchSize)
 	cmdArgs.RecordLimit, _ = cmd.Flags().GetInt("limit") // Default is 0 (no limit)
+	cmdArgs.RecordOffset, _ = cmd.Flags().GetInt("offset")
+	if cmdArgs.RecordLimit < 0 || cmdArgs.RecordOffset < 0 {
+		return nil, 0, nil, fmt.Errorf("limit and offset must not be negative")
+	}
+	cmdArgs.MinRows, _ = cmd.Flags().GetInt64("min-rows")
+	cmdArgs.MaxRows, _ = cmd.Flags().GetInt64("max-rows")
+	if cmdArgs.MinRows < 0 || cmdArgs.MaxRows < 0 {
+		return nil, 0, nil, fmt.Errorf("min-rows and max-rows must not be negative")
+	}
+	if cmdArgs.MaxRows > 0 && cmdArgs.MinRows > cmdArgs.MaxRows {
+		return nil, 0, nil, fmt.Errorf("min-rows (%d) must not be greater than max-rows (%d)", cmdArgs.MinRows, cmdArgs.MaxRows)
+	}
+	// Import shares this function but has no --write-buffer-size
+	if bufferSize, err := cmd.Flags().GetInt("write-buffer-size"); err == nil {
+		if bufferSize < 1 {
+			return nil, 0, nil, fmt.Errorf("write-buffer-size must be at least 1 MB")
+		}
+		cmdArgs.WriteBufferSize = bufferSize
+	}
+	cmdArgs.ChunkSize, _ = cmd.Flags().GetInt("chunk-size")
+	if cmdArgs.ChunkSize < 0 {
+		return nil, 0, nil, fmt.Errorf("chunk-size must not be negative, got %d", cmdArgs.ChunkSize)
+	}
+	if maxInsertBytes, _ := cmd.Flags().GetString("max-insert-bytes"); maxInsertBytes != "" {
+		if cmdArgs.MaxInsertBytes, err = parseByteSize(maxInsertBytes); err != nil {
+			return nil, 0, nil, fmt.Errorf("invalid max-insert-bytes: %v", err)
+		}
+	}
+	if maxFileSize, _ := cmd.Flags().GetString("max-file-size"); maxFileSize != "" {
+		if cmdArgs.MaxFileSize, err = parseByteSize(maxFileSize); err != nil {
+			return nil, 0, nil, fmt.Errorf("invalid max-file-size: %v", err)
+		}
+	}
+	if blobThreshold, _ := cmd.Flags().GetString("blob-threshold"); blobThreshold != "" {
+		if cmdArgs.BlobThreshold, err = parseByteSize(blobThreshold); err != nil {
+			return nil, 0, nil, fmt.Errorf("invalid blob-threshold: %v", err)
+		}
+	}
+	// Import reads metadata in either format and has no --metadata-format
+	if metadataFormat, err := cmd.Flags().GetString("metadata-format"); err == nil {
+		if err := validateMetadataFormat(metadataFormat); err != nil {
+			return nil, 0, nil, err
+		}
+		cmdArgs.MetadataFormat = metadataFormat
+	}
+	cmdArgs.ExportRetryCount, _ = cmd.Flags().GetInt("export-retry-count")
+	cmdArgs.ExportRetryDelay, _ = cmd.Flags().GetDuration("export-retry-delay")
+	if cmdArgs.ExportRetryCount < 0 {
+		return nil, 0, nil, fmt.Errorf("export-retry-count must not be negative, got %d", cmdArgs.ExportRetryCount)
+	}
+	cmdArgs.FollowFK, _ = cmd.Flags().GetBool("follow-fk")
+	cmdArgs.FKDepth, _ = cmd.Flags().GetInt("fk-depth")
+	if cmdArgs.FKDepth < 0 {
+		return nil, 0, nil, fmt.Errorf("fk-depth must not be negative, got %d", cmdArgs.FKDepth)
+	}
+	cmdArgs.FKSchemaOnly, _ = cmd.Flags().GetBool("fk-schema-only")
+	cmdArgs.RowChecksum, _ = cmd.Flags().GetBool("row-checksum")
+	if cmdArgs.RowChecksum && (cmdArgs.Format == exportFormatJSON || cmdArgs.Format == exportFormatParquet) {
+		return nil, 0, nil, fmt.Errorf("--row-checksum requires SQL data files and cannot be combined with --format %s", cmdArgs.Format)
+	}
+	if cmdArgs.Format == exportFormatParquet && (cmdArgs.ChunkSize > 0 || cmdArgs.MaxFileSize > 0) {
+		return nil, 0, nil, fmt.Errorf("--format parquet writes one file per table and cannot be combined with --chunk-size or --max-file-size")
+	}
+	cmdArgs.NormalizeJSON, _ = cmd.Flags().GetBool("normalize-json")
+	cmdArgs.MySQLSetNames, _ = cmd.Flags().GetString("mysql-set-names")
+	if _, err := buildSetNamesStatements(cmdArgs.Driver, cmdArgs.MySQLSetNames); err != nil {
+		return nil, 0, nil, err
+	}
+	if sqlHeader, err := cmd.Flags().GetString("sql-header"); err == nil {
+		cmdArgs.SQLHeader = renderSQLHeader(sqlHeader, &cmdArgs, time.Now())
+	}
+	cmdArgs.Base64Blobs, _ = cmd.Flags().GetBool("base64-blobs")
+	cmdArgs.Base64Strings, _ = cmd.Flags().GetBool("base64-strings")
+	if cmdArgs.Base64 {
+		// The deprecated --base64 encodes binary and string values alike
+		cmdArgs.Base64Blobs, cmdArgs.Base64Strings = true, true
+	}
+	cmdArgs.BinaryFormat, _ = cmd.Flags().GetString("binary-format")
+	if err := resolveBinaryFormat(&cmdArgs); err != nil {
+		return nil, 0, nil, err
+	}
+	if err := loadDateTimeArgs(cmd, &cmdArgs); err != nil {
+		return nil, 0, nil, err
+	}
+	orderByPK, _ := cmd.Flags().GetBool("order-by-pk")
+	deterministic, _ := cmd.Flags().GetBool("deterministic")
+	cmdArgs.OrderByPK = orderByPK || deterministic
+	if err := db.ValidateQuoteStyle(cmdArgs.QuoteIdentifiers); err != nil {
+		return nil, 0, nil, err
+	}
+	if cmdArgs.ANSIQuotes && cmdArgs.QuoteIdentifiers != "" && cmdArgs.QuoteIdentifiers != db.QuoteStyleAuto {
+		return nil, 0, nil, fmt.Errorf("--ansi-quotes cannot be combined with --quote-identifiers")
+	}
+	stmtTerminator, _ := cmd.Flags().GetString("stmt-terminator")
+	commentStyle, _ := cmd.Flags().GetString("comment-style")
+	if cmdArgs.SQLStyle, err = newSQLStyle(stmtTerminator, commentStyle); err != nil {
+		return nil, 0, nil, err
+	}
+	if cmdArgs.SQLStyle != (sqlStyle{}) && cmdArgs.Format != exportFormatSQL {
+		return nil, 0, nil, fmt.Errorf("--stmt-terminator and --comment-style require --format sql")
+	}
+	cmdArgs.IncludeGenerated, _ = cmd.Flags().GetBool("include-generated")
+	// Import shares this function but has no --filter-file
+	if cmdArgs.FilterFile != "" && cmd.Flags().Lookup("filter-file") != nil {
+		if cmdArgs.FilterSpec, err = filter.ParseFilterFile(cmdArgs.FilterFile); err != nil {
+			return nil, 0, nil, err
+		}
+		applyFilterSpec(cmd, &cmdArgs, cmdArgs.FilterSpec)
+	}
+	cmdArgs.PageSize, _ = cmd.Flags().GetInt("page-size")
+	cmdArgs.PageNumber, _ = cmd.Flags().GetInt("page-number")
+	if err := applyExportPage(&cmdArgs); err != nil {
+		return nil, 0, nil, err
+	}
+	// Without a stable order every page could hold any of the rows
+	if cmdArgs.RecordOffset > 0 && !cmdArgs.OrderByPK {
+		return nil, 0, nil, fmt.Errorf("--offset requires --order-by-pk, so that every page has the same rows")
+	}
+	if precision, err := cmd.Flags().GetInt("float-precision"); err == nil && precision >= 0 {
+		cmdArgs.FloatPrecision = &precision
+	}
+	cmdArgs.StripDecimalZeros, _ = cmd.Flags().GetBool("decimal-strip-trailing-zeros")
+	cmdArgs.IncludeStats, _ = cmd.Flags().GetBool("include-stats")
+	cmdArgs.Incremental, _ = cmd.Flags().GetBool("incremental")
+	if cmdArgs.UUIDFormat, err = cmd.Flags().GetString("uuid-format"); err == nil {
+		if err := validateUUIDFormat(cmdArgs.UUIDFormat, cmdArgs.Driver); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	cmdArgs.SampleRate, _ = cmd.Flags().GetFloat64("sample-rate")
+	cmdArgs.SampleSeed, _ = cmd.Flags().GetInt64("sample-seed")
+	if cmdArgs.SampleRate < 0 || cmdArgs.SampleRate > 1 {
+		return nil, 0, nil, fmt.Errorf("sample-rate must be between 0.0 and 1.0, got %g", cmdArgs.SampleRate)
+	}
+	cmdArgs.KeepLast, _ = cmd.Flags().GetInt("keep-last")
+	cmdArgs.PruneDryRun, _ = cmd.Flags().GetBool("prune-dry-run")
+	cmdArgs.LockFile, _ = cmd.Flags().GetString("lock-file")
+	cmdArgs.LockTimeout, _ = cmd.Flags().GetDuration("lock-timeout")
+	cmdArgs.NoLock, _ = cmd.Flags().GetBool("no-lock")
+	if cmdArgs.KeepLast < 0 {
+		return nil, 0, nil, fmt.Errorf("keep-last must not be negative")
+	}
+	if err := loadHookSQLFile(cmd, "pre-export-sql", "pre-export-sql-file", &cmdArgs.PreExportSQL); err != nil {
+		return nil, 0, nil, err
+	}
+	if err := loadHookSQLFile(cmd, "post-export-sql", "post-export-sql-file", &cmdArgs.PostExportSQL); err != nil {
+		return nil, 0, nil, err
+	}
+
+	// Import can connect to another database than the one the export was made
+	// from; --database then only selects the export to import
+	cmdArgs.TargetDatabase, _ = cmd.Flags().GetString("target-database")
+	connectDatabase := cmdArgs.Database
+	if cmdArgs.TargetDatabase != "" {
+		connectDatabase = cmdArgs.TargetDatabase
+	}
 
 	// Validate required values (Database name should now be resolved considering profile)
-	if cmdArgs.Database == "" {
+	if connectDatabase == "" {
 		return nil, 0, nil, fmt.Errorf("database name is required (set via --database flag, SYNCDB_EXPORT_DATABASE env, or profile)")
 	}
 
+	if cmdArgs.InsertMode, err = resolveInsertMode(cmdArgs.InsertMode, cmdArgs.Driver); err != nil {
+		return nil, 0, nil, err
+	}
+	if err := validateTableOrder(cmdArgs.TableOrder); err != nil {
+		return nil, 0, nil, err
+	}
+	if err := validateCompressFormat(cmdArgs.CompressFormat, cmdArgs.CompressLevel); err != nil {
+		return nil, 0, nil, err
+	}
+	cmdArgs.CompressSQLFiles, _ = cmd.Flags().GetBool("compress-sql-files")
+	if cmdArgs.CompressSQLFiles {
+		if format := dataFileFormat(cmdArgs.Format); format != exportFormatSQL {
+			return nil, 0, nil, fmt.Errorf("--compress-sql-files requires SQL data files and cannot be combined with --format %s", cmdArgs.Format)
+		}
+		if _, err := parseDeflateLevel(cmdArgs.CompressLevel); err != nil {
+			return nil, 0, nil, fmt.Errorf("--compress-sql-files uses gzip: %v", err)
+		}
+	}
+	if cmdArgs.DisableUniqueChecks && !cmdArgs.DisableKeys {
+		return nil, 0, nil, fmt.Errorf("--disable-unique-checks requires --disable-keys")
+	}
+	if _, err := db.IsolationLevelSQL(cmdArgs.Driver, cmdArgs.TxIsolation); err != nil {
+		return nil, 0, nil, err
+	}
+	if cmdArgs.Driver == db.DriverPostgres {
+		if err := db.ValidateSchemaName(cmdArgs.PgSchema); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+
 	// Validate storage-specific arguments
 	switch cmdArgs.Storage {
 	case "s3":
@@ -102,8 +431,17 @@ func loadAndValidateArgs(cmd *cobra.Command) (*CommonArgs, int, *db.Connection,
 		if cmdArgs.S3Region == "" {
 			return nil, 0, nil, fmt.Errorf("s3-region is required when storage is set to s3")
 		}
+		if int64(cmdArgs.S3PartSize)*1024*1024 < storage.MinS3PartSize {
+			return nil, 0, nil, fmt.Errorf("s3-part-size must be at least %d MB", storage.MinS3PartSize/(1024*1024))
+		}
+		if err := storage.ValidateS3StorageClass(cmdArgs.S3StorageClass); err != nil {
+			return nil, 0, nil, err
+		}
+		if _, err := storage.ParseS3Tags(cmdArgs.S3Tags); err != nil {
+			return nil, 0, nil, err
+		}
 	case "gdrive":
-		creds, _ := cmd.Flags().GetString("gdrive-credentials")
+		creds := cmdArgs.GdriveCredentials
 		if creds == "" {
 			syncDBDir, err := profile.GetSyncDBDir("")
 			if err != nil {
@@ -111,7 +449,7 @@ func loadAndValidateArgs(cmd *cobra.Command) (*CommonArgs, int, *db.Connection,
 			}
 			creds = filepath.Join(syncDBDir, "google-creds.json")
 		}
-		folder, _ := cmd.Flags().GetString("gdrive-folder")
+		folder := cmdArgs.GdriveFolder
 		if creds == "" {
 			return nil, 0, nil, fmt.Errorf("gdrive-credentials is required when storage is set to gdrive")
 		}
@@ -120,35 +458,73 @@ func loadAndValidateArgs(cmd *cobra.Command) (*CommonArgs, int, *db.Connection,
 		}
 		cmdArgs.GdriveCredentials = creds
 		cmdArgs.GdriveFolder = folder
+		chunkSize, _ := cmd.Flags().GetString("gdrive-chunk-size")
+		if cmdArgs.GdriveChunkSize, err = parseByteSize(chunkSize); err != nil {
+			return nil, 0, nil, fmt.Errorf("invalid gdrive-chunk-size: %v", err)
+		}
+		if err := storage.ValidateGDriveChunkSize(cmdArgs.GdriveChunkSize); err != nil {
+			return nil, 0, nil, err
+		}
+	case "gcs":
+		if cmdArgs.GCSBucket == "" {
+			return nil, 0, nil, fmt.Errorf("gcs-bucket is required when storage is set to gcs")
+		}
 	}
 
-	// Initialize database connection
-	database, err := db.InitDB(cmdArgs.Driver, cmdArgs.Host, cmdArgs.Port, cmdArgs.Username, cmdArgs.Password, cmdArgs.Database)
+	// Initialize database connection, retrying while the database starts up if requested
+	connConfig := db.ConnectionConfig{
+		Driver:               cmdArgs.Driver,
+		Host:                 cmdArgs.Host,
+		Port:                 cmdArgs.Port,
+		User:                 cmdArgs.Username,
+		Password:             cmdArgs.Password,
+		Database:             connectDatabase,
+		RecordLimit:          cmdArgs.RecordLimit,
+		RecordOffset:         cmdArgs.RecordOffset,
+		SampleRate:           cmdArgs.SampleRate,
+		SampleSeed:           cmdArgs.SampleSeed,
+		BinaryFormat:         cmdArgs.BinaryFormat,
+		QuoteStyle:           cmdArgs.QuoteIdentifiers,
+		IncludeGenerated:     cmdArgs.IncludeGenerated,
+		TxIsolation:          cmdArgs.TxIsolation,
+		TxSize:               cmdArgs.TxSize,
+		Schema:               cmdArgs.PgSchema,
+		SSLMode:              cmdArgs.SSLMode,
+		QueryTimeout:         cmdArgs.QueryTimeout,
+		ConnectRetryCount:    cmdArgs.ConnectRetryCount,
+		ConnectRetryDelay:    cmdArgs.ConnectRetryDelay,
+		ConnectRetryMaxDelay: cmdArgs.ConnectRetryMaxDelay,
+		DeadlockRetryCount:   cmdArgs.DeadlockRetryCount,
+		DeadlockRetryDelay:   cmdArgs.DeadlockRetryDelay,
+		ExportRetryCount:     cmdArgs.ExportRetryCount,
+		ExportRetryDelay:     cmdArgs.ExportRetryDelay,
+	}
+	conn, err := db.NewConnection(connConfig)
+	// Import can create a missing database first (export has no --create-db)
+	if createDB, _ := cmd.Flags().GetBool("create-db"); createDB && db.IsUnknownDatabase(err) {
+		adminUser, _ := cmd.Flags().GetString("admin-user")
+		adminPassword, _ := cmd.Flags().GetString("admin-password")
+		fmt.Printf("Database %s does not exist, creating it\n", connectDatabase)
+		if _, err := db.EnsureDatabase(connConfig, adminUser, adminPassword, databaseOptions(&cmdArgs)); err != nil {
+			return nil, 0, nil, err
+		}
+		conn, err = db.NewConnection(connConfig)
+	}
 	if err != nil {
 		return nil, 0, nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 	// Note: The caller (runExport) will be responsible for closing the connection
 
-	// Create a Connection instance
-	conn := &db.Connection{
-		DB: database,
-		Config: db.ConnectionConfig{
-			Driver:      cmdArgs.Driver,
-			Host:        cmdArgs.Host,
-			Port:        cmdArgs.Port,
-			User:        cmdArgs.Username,
-			Password:    cmdArgs.Password,
-			Database:    cmdArgs.Database,
-			RecordLimit: cmdArgs.RecordLimit,
-		},
-	}
-
 	cmdArgs.FromTableIndex, _ = cmd.Flags().GetInt("from-table-index")
 	cmdArgs.FromChunkIndex, _ = cmd.Flags().GetInt("from-chunk-index")
+	cmdArgs.CheckpointFile, _ = cmd.Flags().GetString("checkpoint-file")
+	cmdArgs.Resume, _ = cmd.Flags().GetBool("resume")
 
-	return &cmdArgs, batchSize, conn, nil // Return address of cmdArgs
+	return &cmdArgs, rowsPerInsert, conn, nil // Return address of cmdArgs
 }
 
+// expandTablePatterns returns the tables matching any of the patterns, glob
+// or SQL LIKE patterns (see db.TablePatternMatch).
 func expandTablePatterns(allTables, patterns []string) map[string]bool {
 	result := make(map[string]bool)
 	for _, pat := range patterns {
@@ -161,33 +537,89 @@ func expandTablePatterns(allTables, patterns []string) map[string]bool {
 	return result
 }
 
+// followForeignKeys returns the tables that tables depend on through foreign
+// keys and are not in tables themselves, following the dependencies of the
+// added tables up to maxDepth levels (0 means no limit). Tables are returned
+// in the order they are found.
+func followForeignKeys(tables []string, maxDepth int, dependencies func(table string) ([]string, error)) ([]string, error) {
+	seen := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		seen[table] = true
+	}
+
+	var added []string
+	level := tables
+	for depth := 1; len(level) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+		var next []string
+		for _, table := range level {
+			deps, err := dependencies(table)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get dependencies for table %s: %v", table, err)
+			}
+			for _, dep := range deps {
+				if !seen[dep] {
+					seen[dep] = true
+					next = append(next, dep)
+				}
+			}
+		}
+		added = append(added, next...)
+		level = next
+	}
+	return added, nil
+}
+
 // getFinalTables determines the list of tables to be exported based on command arguments,
 // database schema dependencies, and exclusion lists. It also returns maps indicating
-// which tables should have their schema or data excluded.
-func getFinalTables(conn *db.Connection, cmdArgs *CommonArgs) ([]string, map[string]bool, map[string]bool, error) {
+// which tables should have their schema or data excluded, and the tables added
+// by --follow-fk.
+func getFinalTables(conn *db.Connection, cmdArgs *CommonArgs) ([]string, map[string]bool, map[string]bool, []string, error) {
 	var err error
 	currentTables := cmdArgs.Tables
 	allTables := currentTables
 	if len(currentTables) == 0 {
 		allTables, err = db.GetTables(conn)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to get tables: %v", err)
+			return nil, nil, nil, nil, fmt.Errorf("failed to get tables: %v", err)
 		}
 		currentTables = allTables
 	}
 
 	// Expand patterns for all table-related params
 	expandedInclude := expandTablePatterns(allTables, cmdArgs.Tables)
+
+	// Add the tables the selected ones reference, so the export can be imported
+	// with its foreign keys intact
+	var autoIncluded []string
+	if cmdArgs.FollowFK && len(cmdArgs.Tables) > 0 {
+		autoIncluded, err = followForeignKeys(currentTables, cmdArgs.FKDepth, func(table string) ([]string, error) {
+			return db.GetTableDependencies(conn, table)
+		})
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		currentTables = append(append([]string{}, currentTables...), autoIncluded...)
+		allTables = currentTables
+		for _, t := range autoIncluded {
+			expandedInclude[t] = true
+		}
+	}
+
 	expandedExclude := expandTablePatterns(allTables, cmdArgs.ExcludeTable)
 	expandedExcludeSchema := expandTablePatterns(allTables, cmdArgs.ExcludeTableSchema)
 	expandedExcludeData := expandTablePatterns(allTables, cmdArgs.ExcludeTableData)
+	if cmdArgs.FKSchemaOnly {
+		for _, t := range autoIncluded {
+			expandedExcludeData[t] = true
+		}
+	}
 
 	// Get table dependencies and sort tables to ensure proper order during export
 	deps := make(map[string][]string)
 	for _, table := range currentTables {
 		tableDeps, err := db.GetTableDependencies(conn, table)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("failed to get dependencies for table %s: %v", table, err)
+			return nil, nil, nil, nil, fmt.Errorf("failed to get dependencies for table %s: %v", table, err)
 		}
 		// Only include dependencies that are in our current table list
 		var filteredDeps []string
@@ -227,36 +659,203 @@ func getFinalTables(conn *db.Connection, cmdArgs *CommonArgs) ([]string, map[str
 		}
 	}
 
+	if cmdArgs.MinRows > 0 || cmdArgs.MaxRows > 0 {
+		finalTables, cmdArgs.ExcludedByRowCount, err = filterTablesByRowCount(finalTables, cmdArgs.MinRows, cmdArgs.MaxRows, func(table string) (int64, error) {
+			return db.GetTableRowCount(conn, table)
+		})
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		if len(cmdArgs.ExcludedByRowCount) > 0 {
+			fmt.Printf("Tables excluded by row count: %v\n", cmdArgs.ExcludedByRowCount)
+		}
+	}
+
+	if cmdArgs.TableOrder != tableOrderDependency {
+		finalTables = orderTables(finalTables, cmdArgs.TableOrder, cmdArgs.Tables)
+	}
+	cmdArgs.TableRelationships = exportedRelationships(finalTables, deps)
+
+	// Views go last, as they may select from any of the tables
+	if cmdArgs.IncludeViewData {
+		views, err := db.GetViews(conn)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to get views: %v", err)
+		}
+		finalTables, cmdArgs.Views = appendViews(finalTables, views, cmdArgs.Tables, cmdArgs.ExcludeTable)
+		for t := range expandTablePatterns(cmdArgs.Views, cmdArgs.ExcludeTableSchema) {
+			excludeSchemaMap[t] = true
+		}
+		for t := range expandTablePatterns(cmdArgs.Views, cmdArgs.ExcludeTableData) {
+			excludeDataMap[t] = true
+		}
+		if len(cmdArgs.Views) > 0 {
+			fmt.Printf("Views included in export: %v\n", cmdArgs.Views)
+		}
+	}
+
 	fmt.Printf("Final table order for export: %v\n", finalTables)
-	return finalTables, excludeSchemaMap, excludeDataMap, nil
-}
-
-// writeMetadata creates and writes the 0_metadata.json file.
-func writeMetadata(exportPath string, cmdArgs *CommonArgs, finalTables []string) error { // Changed commonArgs to CommonArgs
-	metadata := struct {
-		ExportedAt   time.Time `json:"exported_at"`
-		DatabaseName string    `json:"database_name"`
-		Tables       []string  `json:"tables"`
-		Schema       bool      `json:"include_schema"`
-		ViewData     bool      `json:"include_view_data"`
-		IncludeData  bool      `json:"include_data"`
-		Base64       bool      `json:"base64"`
-	}{
+	return finalTables, excludeSchemaMap, excludeDataMap, autoIncluded, nil
+}
+
+// exportedRelationships returns the dependencies of the exported tables that
+// are exported too, leaving out tables without any.
+func exportedRelationships(tables []string, deps map[string][]string) map[string][]string {
+	exported := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		exported[t] = true
+	}
+	relationships := make(map[string][]string)
+	for _, t := range tables {
+		for _, dep := range deps[t] {
+			if exported[dep] {
+				relationships[t] = append(relationships[t], dep)
+			}
+		}
+	}
+	return relationships
+}
+
+// appendViews adds the views matching the include patterns (all views when
+// there are none) and no exclude pattern to the end of tables. It returns the
+// new table list and the views in it, including views already listed in tables.
+func appendViews(tables, views, include, exclude []string) ([]string, []string) {
+	listed := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		listed[t] = true
+	}
+	included := expandTablePatterns(views, include)
+	excluded := expandTablePatterns(views, exclude)
+
+	var selected []string
+	for _, view := range views {
+		if excluded[view] || (len(include) > 0 && !included[view]) {
+			continue
+		}
+		selected = append(selected, view)
+		if !listed[view] {
+			tables = append(tables, view)
+		}
+	}
+	return tables, selected
+}
+
+// filterTablesByRowCount splits tables into those whose row count is within
+// minRows and maxRows (0 means no bound) and those outside the range, keeping
+// the order of tables.
+func filterTablesByRowCount(tables []string, minRows, maxRows int64, rowCount func(table string) (int64, error)) ([]string, []string, error) {
+	var kept, excluded []string
+	for _, table := range tables {
+		count, err := rowCount(table)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to count rows of table %s: %v", table, err)
+		}
+		if count < minRows || (maxRows > 0 && count > maxRows) {
+			excluded = append(excluded, table)
+			continue
+		}
+		kept = append(kept, table)
+	}
+	return kept, excluded, nil
+}
+
+// validateTableOrder returns an error if order is not a --table-order value.
+func validateTableOrder(order string) error {
+	switch order {
+	case tableOrderDependency, tableOrderAlpha, tableOrderReverseAlpha, tableOrderManual:
+		return nil
+	}
+	return fmt.Errorf("invalid table order %q (valid values: dependency, alpha, reverse-alpha, manual)", order)
+}
+
+// orderTables reorders the dependency sorted tables as requested by
+// --table-order. The manual order follows the entries of --tables, which may
+// be patterns, and appends the tables they do not match in dependency order.
+func orderTables(tables []string, order string, manual []string) []string {
+	ordered := append([]string{}, tables...)
+	switch order {
+	case tableOrderAlpha:
+		sort.Strings(ordered)
+	case tableOrderReverseAlpha:
+		sort.Sort(sort.Reverse(sort.StringSlice(ordered)))
+	case tableOrderManual:
+		ordered = ordered[:0]
+		placed := make(map[string]bool, len(tables))
+		for _, pattern := range manual {
+			for _, t := range tables {
+				if !placed[t] && db.TablePatternMatch(t, strings.TrimSpace(pattern)) {
+					placed[t] = true
+					ordered = append(ordered, t)
+				}
+			}
+		}
+		for _, t := range tables {
+			if !placed[t] {
+				ordered = append(ordered, t)
+			}
+		}
+	}
+	return ordered
+}
+
+// writeMetadata creates and writes the 0_metadata.json file, or 0_metadata.yaml
+// with --metadata-format yaml. Once the data is exported, it is written again
+// with the stats of the data export, which record the data file format and
+// number of chunk files of each table.
+func writeMetadata(exportPath string, cmdArgs *CommonArgs, finalTables []string, stats []ExportStats) error { // Changed commonArgs to CommonArgs
+	metadata := ExportMetadata{
 		ExportedAt:   time.Now(),
 		DatabaseName: cmdArgs.Database,
 		Tables:       finalTables,
 		Schema:       cmdArgs.IncludeSchema,
 		ViewData:     cmdArgs.IncludeViewData,
 		IncludeData:  cmdArgs.IncludeData,
-		Base64:       cmdArgs.Base64,
+		Base64:       cmdArgs.Base64Strings,
+		Base64Blobs:  cmdArgs.Base64Blobs,
+		Format:       cmdArgs.Format,
+		// Set by getFinalTables
+		ExcludedByRowCount: cmdArgs.ExcludedByRowCount,
+		Views:              cmdArgs.Views,
+		TableRelationships: cmdArgs.TableRelationships,
+		SchemaVersion:      cmdArgs.SchemaVersion,
+		StmtTerminator:     cmdArgs.SQLStyle.terminator,
+		CommentStyle:       cmdArgs.SQLStyle.comment,
+		PageNumber:         cmdArgs.PageNumber,
+		PageSize:           cmdArgs.PageSize,
+		FilterSpec:         cmdArgs.FilterSpec,
+	}
+	if cmdArgs.IncludeData {
+		metadata.InsertMode = cmdArgs.InsertMode
+		metadata.ExcludedColumns = cmdArgs.ExcludeColumns
+		for _, s := range stats {
+			if s.RecordsExported == 0 {
+				continue // No data file is written for empty tables
+			}
+			if metadata.DataFormats == nil {
+				metadata.DataFormats = make(map[string]string)
+			}
+			metadata.DataFormats[s.TableName] = dataFileFormat(cmdArgs.Format)
+			if s.BlobFiles > 0 {
+				metadata.HasBlobSidecars = true
+			}
+			if cmdArgs.ChunkSize > 0 || cmdArgs.MaxFileSize > 0 {
+				if metadata.ChunkCounts == nil {
+					metadata.ChunkCounts = make(map[string]int)
+				}
+				metadata.ChunkCounts[s.TableName] = s.Chunks
+			}
+		}
+		if cmdArgs.SampleRate > 0 && cmdArgs.SampleRate < 1 {
+			metadata.SampleRate = cmdArgs.SampleRate
+		}
 	}
 
-	metadataData, err := json.MarshalIndent(metadata, "", "  ")
+	metadataData, fileName, err := marshalMetadata(metadata, cmdArgs.MetadataFormat)
 	if err != nil {
 		return fmt.Errorf("failed to marshal metadata: %v", err)
 	}
 
-	metadataFile := filepath.Join(exportPath, "0_metadata.json")
+	metadataFile := filepath.Join(exportPath, fileName)
 	if err = os.WriteFile(metadataFile, metadataData, 0644); err != nil {
 		return fmt.Errorf("failed to write metadata file %s: %v", metadataFile, err)
 	}
@@ -264,19 +863,78 @@ func writeMetadata(exportPath string, cmdArgs *CommonArgs, finalTables []string)
 	return nil
 }
 
+// sqlQuoteStyle returns the quoting of identifiers in the SQL files of an
+// export: double quotes with --ansi-quotes, otherwise --quote-identifiers.
+func sqlQuoteStyle(conn *db.Connection, cmdArgs *CommonArgs) string {
+	if cmdArgs.ANSIQuotes && conn.Config.Driver == db.DriverMySQL {
+		return db.QuoteStyleDoubleQuote
+	}
+	return conn.Config.QuoteStyle
+}
+
 // writeSchema fetches and writes the schema definitions to a file (SQL or JSON).
 func writeSchema(conn *db.Connection, exportPath string, cmdArgs *CommonArgs, finalTables []string, excludeSchemaMap map[string]bool) error {
 	schemaDefinitions := make(map[string]string)
+	// CREATE INDEX statements of PostgreSQL tables, whose definitions have no indexes
+	tableIndexes := make(map[string]string)
+	var schemaTables []string
 	for _, table := range finalTables {
 		if excludeSchemaMap[table] {
 			continue // Skip excluded tables
 		}
+		schemaTables = append(schemaTables, table)
 
 		schema, err := db.GetTableSchema(conn, table)
 		if err != nil {
 			return fmt.Errorf("failed to get schema for table %s: %v", table, err)
 		}
 		schemaDefinitions[table] = schema.Definition
+
+		if conn.Config.Driver == db.DriverPostgres && !schema.IsView {
+			indexes, err := db.GetTableIndexes(conn, table)
+			if err != nil {
+				return fmt.Errorf("failed to get indexes for table %s: %v", table, err)
+			}
+			if len(indexes) > 0 {
+				tableIndexes[table] = strings.Join(indexes, "\n")
+			}
+		}
+	}
+
+	// Move secondary indexes to 0_indexes.sql, to be created after the data import
+	if cmdArgs.DeferIndexes {
+		indexDefinitions, err := stripIndexes(schemaDefinitions)
+		if err != nil {
+			return err
+		}
+		for table, indexSQL := range tableIndexes {
+			indexDefinitions[table] = indexSQL
+		}
+		for table, indexSQL := range indexDefinitions {
+			indexDefinitions[table] = db.RequoteIdentifiersStyle(conn.Config.Driver, conn.Config.QuoteStyle, indexSQL)
+		}
+		if len(indexDefinitions) > 0 {
+			indexesFile := filepath.Join(exportPath, indexesFileName)
+			if err := os.WriteFile(indexesFile, []byte(formatIndexesSQL(indexDefinitions, finalTables)), 0644); err != nil {
+				return fmt.Errorf("failed to write indexes file %s: %v", indexesFile, err)
+			}
+			fmt.Printf("Wrote indexes file: %s\n", indexesFile)
+		}
+	} else {
+		// Written after the CREATE TABLE statement of their table
+		for table, indexSQL := range tableIndexes {
+			schemaDefinitions[table] += "\n" + indexSQL
+		}
+	}
+
+	// Requote the definitions for --ansi-quotes and --quote-identifiers;
+	// 0_indexes.sql keeps backticks with --ansi-quotes since it is run without
+	// the ANSI_QUOTES statement
+	if cmdArgs.Format == "sql" {
+		quoteStyle := sqlQuoteStyle(conn, cmdArgs)
+		for table, definition := range schemaDefinitions {
+			schemaDefinitions[table] = db.RequoteIdentifiersStyle(conn.Config.Driver, quoteStyle, definition)
+		}
 	}
 
 	// Get SQL mode for MySQL databases
@@ -296,26 +954,17 @@ func writeSchema(conn *db.Connection, exportPath string, cmdArgs *CommonArgs, fi
 
 	if cmdArgs.Format == "sql" {
 		schemaFileName = "0_schema.sql"
-		var schemaOutput []string
-
-		// Add SQL mode as a comment at the top of the file for MySQL
-		if sqlMode, ok := schemaDefinitions["__sql_mode"]; ok {
-			schemaOutput = append(schemaOutput, fmt.Sprintf("-- SQL_MODE=%s", sqlMode))
-			delete(schemaDefinitions, "__sql_mode") // Remove from the table definitions
-		}
-
-		// Ensure consistent order for SQL output (iterate over finalTables which is sorted)
-		for _, table := range finalTables {
-			if definition, ok := schemaDefinitions[table]; ok {
-				schemaOutput = append(schemaOutput, fmt.Sprintf("-- Table structure for %s\n%s\n", table, definition))
-			}
+		schemaSQL, err := wrapSchemaSQL(formatSchemaSQL(schemaDefinitions, finalTables), cmdArgs, conn.Config.Driver)
+		if err != nil {
+			return err
 		}
-		schemaData = []byte(strings.Join(schemaOutput, "\n\n"))
+		schemaData = []byte(schemaSQL)
 	} else { // Default to JSON
+		// A structured description of the tables rather than their DDL, see db.SchemaToJSON
 		schemaFileName = "0_schema.json"
-		schemaData, err = json.MarshalIndent(schemaDefinitions, "", "  ")
+		schemaData, err = db.SchemaToJSON(conn, schemaTables)
 		if err != nil {
-			return fmt.Errorf("failed to marshal schema to JSON: %v", err)
+			return err
 		}
 	}
 
@@ -327,29 +976,112 @@ func writeSchema(conn *db.Connection, exportPath string, cmdArgs *CommonArgs, fi
 	return nil
 }
 
+// formatSchemaSQL renders schema definitions keyed by table name as the contents
+// of 0_schema.sql, in the order of tables. A "__sql_mode" entry is written as a
+// comment at the top of the file.
+func formatSchemaSQL(schemaDefinitions map[string]string, tables []string) string {
+	var schemaOutput []string
+
+	// Add SQL mode as a comment at the top of the file for MySQL
+	if sqlMode, ok := schemaDefinitions["__sql_mode"]; ok {
+		schemaOutput = append(schemaOutput, fmt.Sprintf("-- SQL_MODE=%s", sqlMode))
+	}
+
+	// Ensure consistent order for SQL output (iterate over tables which is sorted)
+	for _, table := range tables {
+		if definition, ok := schemaDefinitions[table]; ok {
+			schemaOutput = append(schemaOutput, fmt.Sprintf("-- Table structure for %s\n%s\n", table, definition))
+		}
+	}
+	return strings.Join(schemaOutput, "\n\n")
+}
+
+// warnUnknownExcludedColumns prints a warning for each excluded column that does
+// not exist in its table. Columns may have been dropped since the exclusion was
+// configured, so this is not treated as an error.
+func warnUnknownExcludedColumns(conn *db.Connection, excludeColumns map[string][]string) {
+	tables := make([]string, 0, len(excludeColumns))
+	for table := range excludeColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		schema, err := db.GetTableSchema(conn, table)
+		if err != nil {
+			fmt.Printf("Warning: cannot check excluded columns of table %s: %v\n", table, err)
+			continue
+		}
+		for _, col := range unknownColumns(schema.Columns, excludeColumns[table]) {
+			fmt.Printf("Warning: excluded column %s does not exist in table %s\n", col, table)
+		}
+	}
+}
+
+// unknownColumns returns the entries of names that are not in columns.
+func unknownColumns(columns, names []string) []string {
+	known := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		known[col] = true
+	}
+	var unknown []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
+
+// writeTableDataFileWithResume exports the data of a table, starting over when
+// an attempt fails with a transient database error (see withExportRetry).
+func writeTableDataFileWithResume(conn *db.Connection, exportPath string, table string, cmdArgs *CommonArgs, rowsPerInsert int, tableIndex int, fromChunk int) (int, []string, error) {
+	var recordCount int
+	var files []string
+	err := withExportRetry(conn.Config, table, func() error {
+		var err error
+		recordCount, files, err = writeTableDataFile(conn, exportPath, table, cmdArgs, rowsPerInsert, tableIndex)
+		if err != nil && isTransientError(err) {
+			// The next attempt starts the table from scratch
+			removeTableDataFiles(exportPath, tableIndex, table, dataFileFormat(cmdArgs.Format))
+		}
+		return err
+	})
+	return recordCount, files, err
+}
+
 // writeTableDataFile exports data for a single table, formats it as SQL INSERTs,
 // and writes it to a .sql file. Returns the number of records written.
-func writeTableDataFileWithResume(conn *db.Connection, exportPath string, table string, cmdArgs *CommonArgs, batchSize int, tableIndex int, fromChunk int) (int, error) {
+func writeTableDataFile(conn *db.Connection, exportPath string, table string, cmdArgs *CommonArgs, rowsPerInsert int, tableIndex int) (int, []string, error) {
 	fmt.Printf("Exporting data for table '%s'...", table)
 
 	isView, err := db.IsView(conn, table)
 	if err != nil {
-		return 0, fmt.Errorf("failed to check if %s is a view: %v", table, err)
+		return 0, nil, fmt.Errorf("failed to check if %s is a view: %v", table, err)
 	}
 	if isView && !cmdArgs.IncludeViewData {
 		fmt.Println(" skipping view.")
-		return 0, nil // Not an error, just skipping
+		return 0, nil, nil // Not an error, just skipping
+	}
+
+	orderBy, err := tableOrderBy(conn, table, cmdArgs)
+	if err != nil {
+		return 0, nil, err
 	}
 
 	// Create a buffer to store the raw JSON data from db.ExportTableData
 	var buf bytes.Buffer
-	if err := db.ExportTableData(conn, table, &buf); err != nil {
-		return 0, fmt.Errorf("failed to export raw data for table %s: %v", table, err)
+	if err := db.ExportTableData(conn, table, &buf, cmdArgs.Conditions, cmdArgs.ExcludeColumns, orderBy); err != nil {
+		return 0, nil, fmt.Errorf("failed to export raw data for table %s: %v", table, err)
 	}
 
 	// Decode the JSON data from the buffer
 	var operations []db.DataOperation
 	decoder := json.NewDecoder(&buf)
+	if cmdArgs.Format == exportFormatParquet {
+		// Parquet keeps integers as INT64, which float64 cannot hold past 2^53
+		decoder.UseNumber()
+	}
 	for {
 		var op db.DataOperation
 		if err := decoder.Decode(&op); err == io.EOF {
@@ -359,44 +1091,145 @@ func writeTableDataFileWithResume(conn *db.Connection, exportPath string, table
 			if buf.Len() == 0 {
 				break // No data was written to the buffer
 			}
-			return 0, fmt.Errorf("failed to decode operation for table %s: %v", table, err)
+			return 0, nil, fmt.Errorf("failed to decode operation for table %s: %v", table, err)
 		}
 		operations = append(operations, op)
 	}
 
 	// Convert operations to data map slice
 	data := make([]map[string]interface{}, len(operations))
+	var columnTypes map[string]string
+	hexColumns := make(map[string]bool)
 	for i, op := range operations {
 		data[i] = op.Data
+		columnTypes = op.ColumnTypes
+		for _, col := range op.HexColumns {
+			hexColumns[col] = true
+		}
+	}
+
+	if cmdArgs.Incremental {
+		if data, err = filterChangedRows(conn, exportPath, table, cmdArgs, data); err != nil {
+			return 0, nil, err
+		}
 	}
 
 	recordCount := len(data)
 	if recordCount == 0 {
 		fmt.Println(" done (0 records).")
 		// Optionally write an empty file or skip writing? For now, skip.
-		return 0, nil
+		return 0, nil, nil
 	}
 
-	// --- Convert data to SQL format ---
-	var sqlStatements []string
-
 	// Get columns from database schema to ensure consistency and order
 	tableSchema, err := db.GetTableSchema(conn, table)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get schema for table %s during data export: %v", table, err)
+		return 0, nil, fmt.Errorf("failed to get schema for table %s during data export: %v", table, err)
+	}
+	allColumns := db.FilterColumns(tableSchema.Columns, cmdArgs.ExcludeColumns[table])
+
+	if cmdArgs.Format == exportFormatParquet {
+		// Parquet files hold the typed values and none of the SQL statements below
+		file, err := writeParquetDataFile(exportPath, table, tableIndex, allColumns, columnTypes, hexColumns, data, db.DefaultTypeMapper{})
+		if err != nil {
+			return 0, nil, err
+		}
+		fmt.Printf(" done (%d records written to %s)\n", recordCount, file)
+		return recordCount, []string{file}, nil
+	}
+
+	// UUID columns are detected from the values of the first batch
+	var uuidColumns map[string]bool
+	if cmdArgs.UUIDFormat == uuidFormatHex || cmdArgs.UUIDFormat == uuidFormatBinary {
+		uuidColumns = detectUUIDColumns(allColumns, columnTypes, data[:min(rowsPerInsert, recordCount)])
+	}
+
+	// Upserts need to know the primary key to build the conflict clause
+	var pkColumns []string
+	if cmdArgs.InsertMode == insertModeUpsert {
+		pkColumns, err = db.GetPrimaryKeyColumns(conn, table)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to get primary key for table %s: %v", table, err)
+		}
+	}
+
+	var pre, post []string
+	if cmdArgs.DisableKeys {
+		engine := ""
+		if conn.Config.Driver == db.DriverMySQL {
+			if engine, err = db.GetTableEngine(conn, table); err != nil {
+				return 0, nil, err
+			}
+		}
+		pre, post = buildDisableKeysStatements(conn.Config.Driver, table, engine, cmdArgs.DisableUniqueChecks)
+	}
+	// With --ansi-quotes or --quote-identifiers the statements are built with
+	// the driver's quotes as usual and requoted before they are written
+	quoteStyle := sqlQuoteStyle(conn, cmdArgs)
+	for i := range pre {
+		pre[i] = db.RequoteIdentifiersStyle(conn.Config.Driver, quoteStyle, pre[i])
+	}
+	for i := range post {
+		post[i] = db.RequoteIdentifiersStyle(conn.Config.Driver, quoteStyle, post[i])
+	}
+	// The character set, ANSI_QUOTES and foreign key wrapper go into every
+	// chunk file, so each file imports on its own
+	header, err := buildSetNamesStatements(conn.Config.Driver, cmdArgs.MySQLSetNames)
+	if err != nil {
+		return 0, nil, err
+	}
+	header = append(header, buildANSIQuotesStatements(conn.Config.Driver, cmdArgs.ANSIQuotes)...)
+	var footer []string
+	if cmdArgs.DisableFKCheckOnExport {
+		fkHeader, fkFooter := buildDisableFKCheckStatements(conn.Config.Driver, cmdArgs.DisableKeys)
+		header = append(header, fkHeader...)
+		footer = fkFooter
+	}
+
+	// Use query separator for compatibility with import
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	if cmdArgs.QuerySeparator != "" {
+		separator = cmdArgs.QuerySeparator
 	}
-	allColumns := tableSchema.Columns
 
-	// Add backticks to column names
-	backtickedColumns := make([]string, len(allColumns))
-	for i, col := range allColumns {
-		backtickedColumns[i] = fmt.Sprintf("`%s`", col)
+	format := dataFileFormat(cmdArgs.Format)
+	compressLevel, err := parseDeflateLevel(cmdArgs.CompressLevel)
+	if cmdArgs.CompressSQLFiles && err != nil {
+		return 0, nil, err
+	}
+	out := &dataFileWriter{
+		exportPath: exportPath,
+		table:      table,
+		tableIndex: tableIndex,
+		format:     format,
+		chunkSize:  cmdArgs.ChunkSize,
+		maxSize:    cmdArgs.MaxFileSize,
+		bufferSize: cmdArgs.WriteBufferSize * 1024 * 1024,
+		separator:  cmdArgs.SQLStyle.separator(separator),
+		banner:     cmdArgs.SQLHeader,
+		style:      cmdArgs.SQLStyle,
+		header:     header,
+		footer:     footer,
+
+		compress:      cmdArgs.CompressSQLFiles && format == exportFormatSQL,
+		compressLevel: compressLevel,
+	}
+	if format == exportFormatJSON {
+		// NDJSON files hold one object per line and none of the SQL statements
+		out.separator, out.banner, out.header, out.footer, out.style = "", "", nil, nil, sqlStyle{}
+		pre, post = nil, nil
+	}
+	defer out.close()
+	var blobFiles []string // BLOB sidecar files, see --blob-threshold
+	for _, stmt := range pre {
+		if err := out.write(stmt, false); err != nil {
+			return 0, nil, fmt.Errorf("failed to write data file for table %s: %v", table, err)
+		}
 	}
-	columnList := strings.Join(backtickedColumns, ", ")
 
-	// Process in batches for bulk insert
-	for i := 0; i < recordCount; i += batchSize {
-		end := i + batchSize
+	// Process in batches for bulk insert, writing each statement as soon as it is built
+	for i := 0; i < recordCount; i += rowsPerInsert {
+		end := i + rowsPerInsert
 		if end > recordCount {
 			end = recordCount
 		}
@@ -405,160 +1238,727 @@ func writeTableDataFileWithResume(conn *db.Connection, exportPath string, table
 			continue
 		}
 
-		// Start the INSERT statement
-		insertStmt := fmt.Sprintf("INSERT INTO `%s` (%s) VALUES\n", table, columnList)
+		if format == exportFormatJSON {
+			for _, row := range batch {
+				line, err := ndjsonRow(allColumns, row, columnTypes, hexColumns)
+				if err != nil {
+					return 0, nil, fmt.Errorf("failed to encode row of table %s: %v", table, err)
+				}
+				if err := out.write(line+"\n", true); err != nil {
+					return 0, nil, fmt.Errorf("failed to write data file for table %s: %v", table, err)
+				}
+			}
+			continue
+		}
+
 		valueStrings := make([]string, 0, len(batch))
 
 		// Generate value sets for each row in the batch
-		for _, row := range batch {
+		for k, row := range batch {
 			values := make([]string, len(allColumns))
 			for j, col := range allColumns {
-				val, exists := row[col]
-				if !exists || val == nil {
-					values[j] = "NULL"
-				} else {
-					switch v := val.(type) {
-					case string:
-						if cmdArgs.Base64 {
-							encodedValue := base64.StdEncoding.EncodeToString([]byte(v))
-							values[j] = fmt.Sprintf("'%s'", encodedValue)
-						} else {
-							// Escape single quotes
-							escapedString := strings.ReplaceAll(v, "'", "''")
-							// Escape control characters (including tab, newline, etc.)
-							escapedString = escapeControlCharsForSQL(escapedString)
-							values[j] = fmt.Sprintf("'%s'", escapedString)
-						}
-					case time.Time:
-						// Format time consistently, handle potential zero time
-						if v.IsZero() {
-							values[j] = "NULL" // Or appropriate default like '0000-00-00 00:00:00'
-						} else {
-							values[j] = fmt.Sprintf("'%s'", v.Format("2006-01-02 15:04:05"))
-						}
-					case []byte: // Handle byte slices (e.g., BLOBs)
-						if cmdArgs.Base64 {
-							encodedValue := base64.StdEncoding.EncodeToString(v)
-							values[j] = fmt.Sprintf("'%s'", encodedValue)
-						} else {
-							// Representing raw bytes in SQL is tricky.
-							// For simplicity, maybe return error or require base64 for blobs?
-							// Or use a placeholder/warning.
-							// For now, let's assume base64 is preferred for binary.
-							// If not base64, maybe hex encode?
-							// values[j] = fmt.Sprintf("X'%x'", v) // Example for hex (MySQL specific?)
-							return 0, fmt.Errorf("binary data found in table %s column %s, use --base64 flag for export", table, col)
-						}
-					case bool:
-						if v {
-							values[j] = "1"
-						} else {
-							values[j] = "0"
-						}
-					default:
-						// Handle numbers, etc.
-						values[j] = fmt.Sprintf("%v", v) // Default representation
+				// Large binary values go into sidecar files instead of the SQL text
+				if value, ok := row[col].(string); ok && (hexColumns[col] || db.IsBinaryType(columnTypes[col])) {
+					file, placeholder, err := writeBlobSidecar(exportPath, tableIndex, table, col, i+k+1, value, hexColumns[col], cmdArgs.BlobThreshold)
+					if err != nil {
+						return 0, nil, fmt.Errorf("column %s in table %s: %v", col, table, err)
+					}
+					if file != "" {
+						blobFiles = append(blobFiles, file)
+						values[j] = placeholder
+						continue
 					}
 				}
+				if hexValue, ok := row[col].(string); ok && hexColumns[col] {
+					values[j] = hexLiteral(hexValue, conn.Config.Driver)
+					continue
+				}
+				if uuidColumns[col] {
+					if literal, ok := formatUUIDValue(row[col], cmdArgs.UUIDFormat); ok {
+						values[j] = literal
+						continue
+					}
+				}
+				values[j], err = formatColumnValue(row[col], columnTypes[col], conn.Config.Driver, cmdArgs)
+				if err != nil {
+					return 0, nil, fmt.Errorf("column %s in table %s: %v", col, table, err)
+				}
 			}
-			valueStrings = append(valueStrings, fmt.Sprintf("(%s)", strings.Join(values, ", ")))
+			valueString := fmt.Sprintf("(%s)", strings.Join(values, ", "))
+			if cmdArgs.RowChecksum {
+				valueString = withRowChecksum(valueString)
+			}
+			valueStrings = append(valueStrings, valueString)
 		}
 
-		// Complete the statement for the batch
-		// Make sure statement ends with semicolon if not already present
-		stmt := insertStmt + strings.Join(valueStrings, ",\n")
-		if !strings.HasSuffix(strings.TrimSpace(stmt), ";") {
-			stmt += ";"
+		// Complete the statements for the batch, more than one when --max-insert-bytes is reached first
+		groups := [][]string{valueStrings}
+		if cmdArgs.MaxInsertBytes > 0 {
+			empty, err := buildInsertStatement(conn.Config.Driver, cmdArgs.InsertMode, table, allColumns, pkColumns, nil)
+			if err != nil {
+				return 0, nil, fmt.Errorf("failed to build insert statement for table %s: %v", table, err)
+			}
+			groups = splitInsertValues(valueStrings, len(empty), cmdArgs.MaxInsertBytes)
+		}
+		for _, group := range groups {
+			stmt, err := buildInsertStatement(conn.Config.Driver, cmdArgs.InsertMode, table, allColumns, pkColumns, group)
+			if err != nil {
+				return 0, nil, fmt.Errorf("failed to build insert statement for table %s: %v", table, err)
+			}
+			stmt = db.RequoteIdentifiersStyle(conn.Config.Driver, quoteStyle, stmt)
+			if err := out.write(stmt, true); err != nil {
+				return 0, nil, fmt.Errorf("failed to write data file for table %s: %v", table, err)
+			}
 		}
-		sqlStatements = append(sqlStatements, stmt)
 	}
 
-	// Write data to file
-	// Use tableIndex directly since it's already 1-based
-	dataFile := filepath.Join(exportPath, fmt.Sprintf("%d_%s.sql", tableIndex, table))
+	for _, stmt := range post {
+		if err := out.write(stmt, false); err != nil {
+			return 0, nil, fmt.Errorf("failed to write data file for table %s: %v", table, err)
+		}
+	}
+	if err := out.close(); err != nil {
+		return 0, nil, fmt.Errorf("failed to write data file for table %s: %v", table, err)
+	}
 
-	// Use query separator for compatibility with import
-	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
-	if cmdArgs.QuerySeparator != "" {
-		separator = cmdArgs.QuerySeparator
+	if len(out.files) == 1 {
+		fmt.Printf(" done (%d records written to %s)\n", recordCount, out.files[0])
+	} else {
+		fmt.Printf(" done (%d records written to %d chunk files)\n", recordCount, len(out.files))
+	}
+	if len(blobFiles) > 0 {
+		fmt.Printf("  %d binary values larger than the BLOB threshold written to .bin files\n", len(blobFiles))
+	}
+	return recordCount, append(out.files, blobFiles...), nil
+}
+
+// splitInsertValues groups the value sets of one batch so that each INSERT
+// statement stays within maxBytes, given the size of the statement without
+// values (baseSize). Value sets are joined by ",\n". A value set that does not
+// fit on its own still gets a statement, which is then over the limit.
+func splitInsertValues(valueStrings []string, baseSize int, maxBytes int64) [][]string {
+	var groups [][]string
+	start, size := 0, int64(baseSize)
+	for i, value := range valueStrings {
+		added := int64(len(value))
+		if i > start {
+			added += int64(len(",\n"))
+		}
+		if i > start && size+added > maxBytes {
+			groups = append(groups, valueStrings[start:i])
+			start, size = i, int64(baseSize)
+			added = int64(len(value))
+		}
+		size += added
+	}
+	if start < len(valueStrings) {
+		groups = append(groups, valueStrings[start:])
+	}
+	return groups
+}
+
+// dataFileName returns the name of a table's data file, with the extension of
+// its format. With --chunk-size the data is split into files numbered from 1,
+// {index}_{table}_chunk{n}.sql; chunk 0 is the single unsplit file.
+func dataFileName(tableIndex int, table string, chunk int, format string) string {
+	if chunk > 0 {
+		return fmt.Sprintf("%d_%s_chunk%d.%s", tableIndex, table, chunk, format)
+	}
+	return fmt.Sprintf("%d_%s.%s", tableIndex, table, format)
+}
+
+// dataPartFileName returns the name of part n, from 1, of a table's data
+// split by --max-file-size alone: {index}_{table}_part{n}.sql.
+func dataPartFileName(tableIndex int, table string, part int, format string) string {
+	return fmt.Sprintf("%d_%s_part%d.%s", tableIndex, table, part, format)
+}
+
+// dataFileFormat returns the format of the data files written for an export
+// format: NDJSON for json, Parquet for parquet, SQL otherwise.
+func dataFileFormat(exportFormat string) string {
+	switch exportFormat {
+	case exportFormatJSON, exportFormatParquet:
+		return exportFormat
+	}
+	return exportFormatSQL
+}
+
+// dataFileWriter writes the statements of a table to its data file or, with a
+// chunk size, to chunk files holding up to chunkSize INSERT statements each.
+// With a maximum size, an INSERT that would grow the current file beyond
+// maxSize bytes starts the next chunk file, named as a part file (see
+// dataPartFileName) unless there is a chunk size too; a file holding a single larger
+// INSERT, or ending with the footer, may still exceed it. Statements that are
+// not INSERTs (see buildDisableKeysStatements) stay in the current file, so
+// they end up in the first and last chunk. Every file starts with the banner
+// comment and the header statements and ends with the footer statements.
+// With compress, each file is gzip compressed and named with a .gz suffix;
+// maxSize then applies to the uncompressed size.
+type dataFileWriter struct {
+	exportPath string
+	table      string
+	tableIndex int
+	format     string // sql or json, the extension of the files
+	chunkSize  int
+	maxSize    int64
+	bufferSize int
+	separator  string
+	banner     string
+	header     []string
+	footer     []string
+	style      sqlStyle // --stmt-terminator and --comment-style
+
+	compress      bool // --compress-sql-files
+	compressLevel int  // gzip level, see parseDeflateLevel
+
+	file    *os.File
+	gz      *gzip.Writer // Compresses the current file with compress
+	out     *statementWriter
+	size    *countingWriter // Bytes written to the current file, before compression
+	inserts int             // INSERT statements in the current file
+	files   []string        // Paths of the files written so far
+}
+
+// split reports whether the data is split into chunk files.
+func (w *dataFileWriter) split() bool {
+	return w.chunkSize > 0 || w.maxSize > 0
+}
+
+// full reports whether the INSERT stmt belongs in the next chunk file.
+func (w *dataFileWriter) full(stmt string) bool {
+	if w.inserts == 0 {
+		return false
+	}
+	if w.chunkSize > 0 && w.inserts >= w.chunkSize {
+		return true
+	}
+	return w.maxSize > 0 && w.size.n+int64(len(w.separator)+len(stmt)) > w.maxSize
+}
+
+// write appends stmt to the current file, first moving to the next chunk file
+// if stmt is an INSERT and the current one is full.
+func (w *dataFileWriter) write(stmt string, insert bool) error {
+	if w.file == nil || (insert && w.full(stmt)) {
+		if err := w.next(); err != nil {
+			return err
+		}
+	}
+	if insert {
+		w.inserts++
+	}
+	if err := w.out.write(w.style.statement(stmt)); err != nil {
+		return fmt.Errorf("%s: %v", w.file.Name(), err)
+	}
+	return nil
+}
+
+// next closes the current file and creates the next one.
+func (w *dataFileWriter) next() error {
+	if err := w.close(); err != nil {
+		return err
+	}
+	name := dataFileName(w.tableIndex, w.table, 0, w.format)
+	if w.chunkSize > 0 {
+		name = dataFileName(w.tableIndex, w.table, len(w.files)+1, w.format)
+	} else if w.maxSize > 0 {
+		name = dataPartFileName(w.tableIndex, w.table, len(w.files)+1, w.format)
+	}
+	path := filepath.Join(w.exportPath, name)
+	if w.compress {
+		path += gzipFileExt
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	w.file = file
+	var dest io.Writer = file
+	if w.compress {
+		if w.gz, err = gzip.NewWriterLevel(file, w.compressLevel); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		dest = w.gz
+	}
+	w.size = &countingWriter{w: dest}
+	w.inserts = 0
+	w.out = newStatementWriter(w.size, w.bufferSize, w.separator)
+	w.files = append(w.files, path)
+	if err := w.out.writeRaw(w.style.restyleComments(w.banner)); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, stmt := range w.header {
+		if err := w.out.write(w.style.statement(stmt)); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// close closes the current file, if any. It is safe to call more than once.
+func (w *dataFileWriter) close() error {
+	if w.file == nil {
+		return nil
+	}
+	file := w.file
+	w.file = nil
+	for _, stmt := range w.footer {
+		if err := w.out.write(w.style.statement(stmt)); err != nil {
+			file.Close()
+			return fmt.Errorf("%s: %v", file.Name(), err)
+		}
+	}
+	// The separator ends every statement but the last
+	if line := w.style.terminatorLine(); line != "" && w.out.written {
+		if err := w.out.writeRaw("\n" + line + "\n"); err != nil {
+			file.Close()
+			return fmt.Errorf("%s: %v", file.Name(), err)
+		}
+	}
+	if w.gz != nil {
+		gz := w.gz
+		w.gz = nil
+		if err := gz.Close(); err != nil {
+			file.Close()
+			return fmt.Errorf("%s: %v", file.Name(), err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("%s: %v", file.Name(), err)
+	}
+	return nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// statementWriter writes statements to a data file through a buffer, separated
+// by the query separator. Each statement is flushed once written, so memory use
+// is bounded by the buffer and the largest statement rather than the whole file.
+type statementWriter struct {
+	w         *bufio.Writer
+	separator string
+	written   bool
+}
+
+func newStatementWriter(w io.Writer, bufferSize int, separator string) *statementWriter {
+	return &statementWriter{w: bufio.NewWriterSize(w, bufferSize), separator: separator}
+}
+
+// write appends stmt, preceded by the separator unless it is the first statement.
+func (s *statementWriter) write(stmt string) error {
+	if s.written {
+		if _, err := s.w.WriteString(s.separator); err != nil {
+			return err
+		}
+	}
+	s.written = true
+	if _, err := s.w.WriteString(stmt); err != nil {
+		return err
 	}
+	return s.w.Flush()
+}
 
-	// Join statements with separator, ensuring each statement has a semicolon
-	finalContent := strings.Join(sqlStatements, separator)
+// writeRaw appends text as is, without a separator, e.g. comments before the
+// first statement.
+func (s *statementWriter) writeRaw(text string) error {
+	if _, err := s.w.WriteString(text); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
 
-	if err := os.WriteFile(dataFile, []byte(finalContent), 0644); err != nil {
-		return 0, fmt.Errorf("failed to write data file for table %s (%s): %v", table, dataFile, err)
+// ndjsonRow renders a row as a JSON object for an NDJSON data file, with the
+// columns in table order. Values of binary columns are base64 encoded.
+func ndjsonRow(columns []string, row map[string]interface{}, columnTypes map[string]string, hexColumns map[string]bool) (string, error) {
+	var line strings.Builder
+	line.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, err := json.Marshal(col)
+		if err != nil {
+			return "", err
+		}
+		line.Write(key)
+		line.WriteByte(':')
+
+		value := row[col]
+		if s, ok := value.(string); ok && (hexColumns[col] || db.IsBinaryType(columnTypes[col])) {
+			data := []byte(s)
+			if hexColumns[col] {
+				if data, err = hex.DecodeString(s); err != nil {
+					return "", fmt.Errorf("column %s: %v", col, err)
+				}
+			}
+			value = base64.StdEncoding.EncodeToString(data)
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("column %s: %v", col, err)
+		}
+		line.Write(encoded)
 	}
+	line.WriteByte('}')
+	return line.String(), nil
+}
 
-	fmt.Printf(" done (%d records written to %s)\n", recordCount, dataFile)
-	return recordCount, nil
+// quoteSQLString renders s as a quoted SQL string literal, escaping single
+// quotes and control characters (including tab, newline, etc.).
+func quoteSQLString(s string) string {
+	return "'" + escapeControlCharsForSQL(strings.ReplaceAll(s, "'", "''")) + "'"
+}
+
+// formatSQLValue renders a column value as a SQL literal. NULL values (and empty
+// strings with --empty-string-as-null) are written as the configured null token.
+func formatSQLValue(val interface{}, cmdArgs *CommonArgs) (string, error) {
+	nullToken := cmdArgs.NullToken
+	if nullToken == "" {
+		nullToken = defaultNullToken
+	}
+	if val == nil {
+		return nullToken, nil
+	}
+
+	switch v := val.(type) {
+	case string:
+		if v == "" && cmdArgs.EmptyStringAsNull {
+			return nullToken, nil
+		}
+		if cmdArgs.Base64Strings {
+			encodedValue := base64.StdEncoding.EncodeToString([]byte(v))
+			return fmt.Sprintf("'%s'", encodedValue), nil
+		}
+		return quoteSQLString(v), nil
+	case time.Time:
+		// Zero time is written as NULL unless --zero-time-as-null=false
+		if v.IsZero() && !cmdArgs.KeepZeroTime {
+			return nullToken, nil
+		}
+		return fmt.Sprintf("'%s'", formatDateTime(v, cmdArgs)), nil
+	case []byte: // Handle byte slices (e.g., BLOBs)
+		if cmdArgs.Base64Blobs {
+			encodedValue := base64.StdEncoding.EncodeToString(v)
+			return fmt.Sprintf("'%s'", encodedValue), nil
+		}
+		// Representing raw bytes in SQL is tricky.
+		// For simplicity, maybe return error or require base64 for blobs?
+		// Or use a placeholder/warning.
+		// For now, let's assume base64 is preferred for binary.
+		// If not base64, maybe hex encode?
+		// values[j] = fmt.Sprintf("X'%x'", v) // Example for hex (MySQL specific?)
+		return "", fmt.Errorf("binary data found, use --base64-blobs flag for export")
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case float32:
+		return formatFloat(float64(v), 32)
+	case float64:
+		return formatFloat(v, 64)
+	case json.Number:
+		// Exact decimal text, e.g. from PostgreSQL numeric columns
+		return v.String(), nil
+	default:
+		// Handle numbers, etc.
+		return fmt.Sprintf("%v", v), nil // Default representation
+	}
+}
+
+// resolveInsertMode checks that the insert mode is known and returns the mode
+// used for the driver. PostgreSQL has no REPLACE INTO, so replace falls back to
+// insert-ignore (INSERT ... ON CONFLICT DO NOTHING) with a warning.
+func resolveInsertMode(insertMode, driver string) (string, error) {
+	switch insertMode {
+	case insertModeInsert, insertModeInsertIgnore, insertModeUpsert:
+		return insertMode, nil
+	case insertModeReplace:
+		if driver == db.DriverPostgres {
+			fmt.Println("Warning: PostgreSQL does not support REPLACE INTO, using INSERT ... ON CONFLICT DO NOTHING instead")
+			return insertModeInsertIgnore, nil
+		}
+		return insertMode, nil
+	default:
+		return "", fmt.Errorf("invalid insert mode %q (valid values: insert, insert-ignore, replace, upsert)", insertMode)
+	}
+}
+
+// insertModeFlagAlias maps --insert-strategy to --insert-mode, so both names
+// set the same flag.
+func insertModeFlagAlias(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "insert-strategy" {
+		name = "insert-mode"
+	}
+	return pflag.NormalizedName(name)
+}
+
+// tableOrderBy returns the sort specification for a table's data export: its
+// --order-by entry, or its primary key columns with --order-by-pk. A UUID
+// primary key is preceded by an insertion time column when the table has one
+// (see uuidInsertionOrder). Tables without either are exported in the
+// database's order.
+func tableOrderBy(conn *db.Connection, table string, cmdArgs *CommonArgs) (string, error) {
+	if orderBy := cmdArgs.OrderBy[table]; orderBy != "" || !cmdArgs.OrderByPK {
+		return orderBy, nil
+	}
+	pkColumns, err := db.GetPrimaryKeyColumns(conn, table)
+	if err != nil {
+		return "", fmt.Errorf("failed to get primary key for table %s: %v", table, err)
+	}
+	if len(pkColumns) == 0 {
+		fmt.Printf("\nWarning: table '%s' has no primary key, its rows are exported unordered\n", table)
+		return "", nil
+	}
+	if len(pkColumns) == 1 && uuidColumnNames[strings.ToLower(pkColumns[0])] {
+		schema, err := db.GetTableSchema(conn, table)
+		if err != nil {
+			return "", fmt.Errorf("failed to get schema for table %s: %v", table, err)
+		}
+		if orderBy := uuidInsertionOrder(pkColumns, schema.ColumnTypes); orderBy != "" {
+			return orderBy, nil
+		}
+	}
+	return strings.Join(pkColumns, ", "), nil
+}
+
+// buildInsertStatement builds a multi-row INSERT statement for a batch of value sets,
+// using the statement prefix and conflict clause required by the insert mode.
+func buildInsertStatement(driver, insertMode, table string, columns, pkColumns, valueStrings []string) (string, error) {
+	escapedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		escapedColumns[i] = db.EscapeIdentifier(driver, col)
+	}
+
+	prefix := "INSERT INTO"
+	suffix := ""
+	switch insertMode {
+	case "", insertModeInsert:
+	case insertModeInsertIgnore:
+		if driver == db.DriverPostgres {
+			suffix = "\nON CONFLICT DO NOTHING"
+		} else {
+			prefix = "INSERT IGNORE INTO"
+		}
+	case insertModeReplace:
+		if driver == db.DriverPostgres {
+			return "", fmt.Errorf("insert mode %q is only supported for MySQL", insertMode)
+		}
+		prefix = "REPLACE INTO"
+	case insertModeUpsert:
+		clause, err := buildUpsertClause(driver, columns, pkColumns)
+		if err != nil {
+			return "", err
+		}
+		suffix = clause
+	default:
+		return "", fmt.Errorf("invalid insert mode %q", insertMode)
+	}
+
+	return fmt.Sprintf("%s %s (%s) VALUES\n%s%s;",
+		prefix,
+		db.EscapeIdentifier(driver, table),
+		strings.Join(escapedColumns, ", "),
+		strings.Join(valueStrings, ",\n"),
+		suffix,
+	), nil
+}
+
+// buildDisableKeysStatements returns the statements written before and after a
+// table's inserts to defer index maintenance during import. DISABLE KEYS only
+// affects non-unique indexes of MyISAM tables, so InnoDB tables can additionally
+// skip unique checks. PostgreSQL has no equivalent; replica mode skips triggers
+// and foreign key checks instead.
+func buildDisableKeysStatements(driver, table, engine string, disableUniqueChecks bool) (pre, post []string) {
+	switch driver {
+	case db.DriverMySQL:
+		escapedTable := db.EscapeIdentifier(driver, table)
+		if disableUniqueChecks && strings.EqualFold(engine, "InnoDB") {
+			pre = append(pre, "SET UNIQUE_CHECKS=0;")
+		}
+		pre = append(pre, fmt.Sprintf("ALTER TABLE %s DISABLE KEYS;", escapedTable))
+		post = append(post, fmt.Sprintf("ALTER TABLE %s ENABLE KEYS;", escapedTable))
+		if disableUniqueChecks && strings.EqualFold(engine, "InnoDB") {
+			post = append(post, "SET UNIQUE_CHECKS=1;")
+		}
+	case db.DriverPostgres:
+		pre = append(pre, "SET session_replication_role = 'replica';")
+		post = append(post, "SET session_replication_role = 'origin';")
+	}
+	return pre, post
+}
+
+// buildDisableFKCheckStatements returns the statements written at the start and
+// end of each data file with --disable-fk-check-on-export, so the file imports
+// without foreign key checks in any client. PostgreSQL uses replica mode,
+// which --disable-keys already sets for the whole file.
+func buildDisableFKCheckStatements(driver string, disableKeys bool) (header, footer []string) {
+	switch driver {
+	case db.DriverMySQL:
+		return []string{"SET FOREIGN_KEY_CHECKS=0;"}, []string{"SET FOREIGN_KEY_CHECKS=1;"}
+	case db.DriverPostgres:
+		if !disableKeys {
+			return []string{"SET session_replication_role = 'replica';"}, []string{"SET session_replication_role = 'origin';"}
+		}
+	}
+	return nil, nil
+}
+
+// buildUpsertClause builds the ON DUPLICATE KEY / ON CONFLICT clause that updates
+// all non primary key columns when a row with the same key already exists.
+func buildUpsertClause(driver string, columns, pkColumns []string) (string, error) {
+	pkSet := make(map[string]bool, len(pkColumns))
+	for _, col := range pkColumns {
+		pkSet[col] = true
+	}
+	var updateColumns []string
+	for _, col := range columns {
+		if !pkSet[col] {
+			updateColumns = append(updateColumns, col)
+		}
+	}
+
+	switch driver {
+	case db.DriverMySQL:
+		if len(updateColumns) == 0 {
+			// Every column is part of the key, so there is nothing to update; keep the existing row
+			col := db.EscapeIdentifier(driver, columns[0])
+			return fmt.Sprintf("\nON DUPLICATE KEY UPDATE %s=%s", col, col), nil
+		}
+		return "\nON DUPLICATE KEY UPDATE " + db.BuildUpdateList(updateColumns, driver), nil
+	case db.DriverPostgres:
+		if len(pkColumns) == 0 {
+			return "", fmt.Errorf("upsert requires a primary key")
+		}
+		escapedPK := make([]string, len(pkColumns))
+		for i, col := range pkColumns {
+			escapedPK[i] = db.EscapeIdentifier(driver, col)
+		}
+		conflictTarget := strings.Join(escapedPK, ", ")
+		if len(updateColumns) == 0 {
+			return fmt.Sprintf("\nON CONFLICT (%s) DO NOTHING", conflictTarget), nil
+		}
+		return fmt.Sprintf("\nON CONFLICT (%s) DO UPDATE SET %s", conflictTarget, db.BuildUpdateList(updateColumns, driver)), nil
+	default:
+		return "", fmt.Errorf("%w: %s", db.ErrUnsupportedDriver, driver)
+	}
 }
 
 // TableExportResult holds the result of exporting a single table
 type TableExportResult struct {
 	TableName      string
 	RecordsWritten int
+	Stats          ExportStats
 	Error          error
+	// State of the worker's connection checked after exporting this table
+	WorkerConnHealth connHealth
 }
 
+// connHealth is the result of the health check of a worker connection
+type connHealth string
+
+const (
+	connHealthy     connHealth = "healthy"     // The connection answered the ping
+	connReconnected connHealth = "reconnected" // The ping failed and a new connection was opened
+	connUnhealthy   connHealth = "unhealthy"   // The ping and the reconnection failed
+)
+
 // writeDataFiles exports table data in parallel using goroutines.
-// Returns the total number of records exported across all tables.
-func writeDataFiles(conn *db.Connection, exportPath string, cmdArgs *CommonArgs, finalTables []string, excludeDataMap map[string]bool, batchSize int) (int, error) {
-	// Determine number of workers (default to number of CPU cores, but allow override via environment variable)
-	numWorkers := runtime.NumCPU() / 2
-	if envWorkers := os.Getenv("SYNCDB_EXPORT_WORKERS"); envWorkers != "" {
-		if n, err := strconv.Atoi(envWorkers); err == nil && n > 0 {
-			numWorkers = n
+// Returns the total number of records exported across all tables and per-table stats.
+func writeDataFiles(conn *db.Connection, exportPath string, cmdArgs *CommonArgs, finalTables []string, excludeDataMap map[string]bool, rowsPerInsert int) (int, []ExportStats, error) {
+	// Resolved from --workers, SYNCDB_EXPORT_WORKERS or the profile (see populateCommonArgsFromFlagsAndConfig)
+	numWorkers := max(cmdArgs.Workers, 1)
+
+	// Tables are recorded in the checkpoint as they complete; with --resume,
+	// the tables already in it are skipped
+	checkpointPath := exportCheckpointPath(exportPath, cmdArgs)
+	checkpoint := &exportCheckpoint{}
+	if cmdArgs.Resume {
+		var err error
+		if checkpoint, err = loadExportCheckpoint(checkpointPath); err != nil {
+			return 0, nil, err
+		}
+		if len(checkpoint.CompletedTables) > 0 {
+			fmt.Printf("Resuming export from %s: %d tables already exported\n", checkpointPath, len(checkpoint.CompletedTables))
+		} else {
+			fmt.Printf("No checkpoint found at %s, exporting all tables\n", checkpointPath)
 		}
-	}
-	if numWorkers < 1 {
-		numWorkers = 1
 	}
 
 	// Create channels for work distribution and results
 	tableChan := make(chan tableWork, len(finalTables))
 	resultChan := make(chan TableExportResult, len(finalTables))
 
-	// Create all worker connections first
-	workerConns := make([]*db.Connection, numWorkers)
-	for i := 0; i < numWorkers; i++ {
-		workerConfig := conn.Config // This is a copy of the ConnectionConfig struct
-		workerConn, err := db.NewConnection(workerConfig)
-		if err != nil {
-			// Clean up any connections we've created so far
-			for j := 0; j < i; j++ {
-				workerConns[j].Close()
-			}
-			close(tableChan)
-			return 0, fmt.Errorf("failed to create database connection for worker %d: %v", i+1, err)
-		}
-		workerConns[i] = workerConn
+	// Worker connections are opened when a worker picks up its first table,
+	// so there are no more connections than tables
+	workerConns := make([]*workerConn, numWorkers)
+	for i := range workerConns {
+		workerConns[i] = newExportWorkerConn(conn.Config, cmdArgs) // Each worker gets its own copy of the config
 	}
 
 	// Make sure we close all connections when we're done
 	defer func() {
-		for _, conn := range workerConns {
-			if conn != nil {
-				conn.Close()
-			}
+		for _, wc := range workerConns {
+			wc.close()
 		}
 	}()
 
 	// Start worker goroutines
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
-		// Each worker gets its own connection
-		workerConn := workerConns[i]
+		wc := workerConns[i]
+		worker := i + 1
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for work := range tableChan {
-				recordsWritten, err := writeTableDataFileWithResume(workerConn, exportPath, work.Table, cmdArgs, batchSize, work.FileIndex, work.FromChunk)
+				workerConn, err := wc.get()
+				if err != nil {
+					resultChan <- TableExportResult{
+						TableName: work.Table,
+						Error:     fmt.Errorf("failed to create database connection for worker %d: %v", worker, err),
+					}
+					continue
+				}
+
+				start := time.Now()
+				recordsWritten, dataFiles, err := writeTableDataFileWithResume(workerConn, exportPath, work.Table, cmdArgs, rowsPerInsert, work.FileIndex, work.FromChunk)
+				duration := time.Since(start)
+
+				// A failed or timed out export can leave the connection broken,
+				// so check it before the next table
+				health := wc.check()
+				if health != connHealthy {
+					fmt.Printf("Warning: connection of export worker %d was %s after table '%s'\n", worker, health, work.Table)
+				}
+
+				var fileSize int64
+				for _, dataFile := range dataFiles {
+					if info, statErr := os.Stat(dataFile); statErr == nil {
+						fileSize += info.Size()
+					}
+				}
+				stats := newExportStats(work.Table, recordsWritten, fileSize, duration)
+				stats.BlobFiles = countBlobSidecars(dataFiles)
+				if cmdArgs.ChunkSize > 0 || cmdArgs.MaxFileSize > 0 {
+					stats.Chunks = len(dataFiles) - stats.BlobFiles
+				}
 				resultChan <- TableExportResult{
-					TableName:      work.Table,
-					RecordsWritten: recordsWritten,
-					Error:          err,
+					TableName:        work.Table,
+					RecordsWritten:   recordsWritten,
+					Stats:            stats,
+					Error:            err,
+					WorkerConnHealth: health,
 				}
 			}
 		}()
@@ -583,6 +1983,11 @@ func writeDataFiles(conn *db.Connection, exportPath string, cmdArgs *CommonArgs,
 				fileIndex++
 				continue
 			}
+			if checkpoint.completed(table) {
+				fmt.Printf("Skipping data export for table '%s', already exported according to the checkpoint.\n", table)
+				fileIndex++
+				continue
+			}
 
 			fromChunk := 0
 			if i == startTable && cmdArgs.FromChunkIndex > 0 {
@@ -605,9 +2010,13 @@ func writeDataFiles(conn *db.Connection, exportPath string, cmdArgs *CommonArgs,
 		close(resultChan)
 	}()
 
-	// Collect results
+	// Collect results, starting with the tables exported before a resume
 	var totalRecords int
 	var errors []string
+	stats := append([]ExportStats(nil), checkpoint.Stats...)
+	for _, s := range stats {
+		totalRecords += s.RecordsExported
+	}
 
 	for result := range resultChan {
 		if result.Error != nil {
@@ -617,16 +2026,26 @@ func writeDataFiles(conn *db.Connection, exportPath string, cmdArgs *CommonArgs,
 			continue
 		}
 		totalRecords += result.RecordsWritten
+		stats = append(stats, result.Stats)
 		fmt.Printf("Exported %d records from table '%s'\n", result.RecordsWritten, result.TableName)
+
+		checkpoint.add(result.Stats)
+		if err := checkpoint.save(checkpointPath); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
 	}
 
 	// If there were any errors, return them all
 	if len(errors) > 0 {
-		return totalRecords, fmt.Errorf("encountered %d errors during export:\n%s",
+		return totalRecords, stats, fmt.Errorf("encountered %d errors during export:\n%s",
 			len(errors), strings.Join(errors, "\n"))
 	}
 
-	return totalRecords, nil
+	// The export is complete, so there is nothing left to resume
+	if err := os.Remove(checkpointPath); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove checkpoint file %s: %v\n", checkpointPath, err)
+	}
+	return totalRecords, stats, nil
 }
 
 // tableWork represents a unit of work for exporting a single table
@@ -636,94 +2055,90 @@ type tableWork struct {
 	FromChunk int
 }
 
-// createZipArchive creates a zip file containing the contents of the export directory.
-func createZipArchive(exportPath string, zipFileName string) error {
-	zipFile, err := os.Create(zipFileName)
-	if err != nil {
-		return fmt.Errorf("failed to create zip file %s: %v", zipFileName, err)
-	}
-	defer zipFile.Close() // Ensure file is closed even on error during walk
-
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close() // Ensure writer is closed
-
-	// Walk through the export directory and add files to zip
-	err = filepath.Walk(exportPath, func(path string, info os.FileInfo, walkErr error) error {
-		if walkErr != nil {
-			return walkErr // Propagate walk error
-		}
-
-		// Skip the root export directory itself
-		if path == exportPath {
-			return nil
-		}
-
-		// Skip directories (zip writer handles directory entries implicitly)
-		if info.IsDir() {
-			return nil
-		}
-
-		// Create a new file header
-		header, err := zip.FileInfoHeader(info)
-		if err != nil {
-			return fmt.Errorf("failed to create zip header for %s: %v", path, err)
-		}
+// workerConn is the database connection of an export worker. It is opened
+// lazily by get and replaced by check when it no longer answers.
+type workerConn struct {
+	config  db.ConnectionConfig
+	connect func(db.ConnectionConfig) (*db.Connection, error)
+	setup   func(*db.Connection) error // Run on every connection get opens, if set
+	conn    *db.Connection
+}
 
-		// Set the name in the archive relative to the export directory's parent
-		// This ensures the timestamped directory is the root inside the zip
-		relPath, err := filepath.Rel(filepath.Dir(exportPath), path)
-		if err != nil {
-			return fmt.Errorf("failed to get relative path for %s: %v", path, err)
-		}
-		header.Name = relPath
-		header.Method = zip.Deflate // Use compression
+func newWorkerConn(config db.ConnectionConfig) *workerConn {
+	return &workerConn{config: config, connect: db.NewConnection}
+}
 
-		// Create writer for this file within zip
-		writer, err := zipWriter.CreateHeader(header)
-		if err != nil {
-			return fmt.Errorf("failed to create zip entry for %s: %v", path, err)
+// newExportWorkerConn returns the connection of an export worker. The
+// pre-export hook runs on it as on the main connection, so that its session
+// settings apply to the data queries of the worker.
+func newExportWorkerConn(config db.ConnectionConfig, cmdArgs *CommonArgs) *workerConn {
+	w := newWorkerConn(config)
+	if strings.TrimSpace(cmdArgs.PreExportSQL) != "" {
+		w.setup = func(conn *db.Connection) error {
+			return executeSessionHookSQL(conn, "pre-export", cmdArgs.PreExportSQL)
 		}
+	}
+	return w
+}
 
-		// Open the file to be zipped
-		file, err := os.Open(path)
+// get returns the worker's connection, opening it if needed.
+func (w *workerConn) get() (*db.Connection, error) {
+	if w.conn == nil {
+		conn, err := w.connect(w.config)
 		if err != nil {
-			return fmt.Errorf("failed to open file %s for zipping: %v", path, err)
+			return nil, err
 		}
-		defer file.Close()
-
-		// Copy the file content into the zip
-		_, err = io.Copy(writer, file)
-		if err != nil {
-			return fmt.Errorf("failed to write file %s to zip: %v", path, err)
+		if w.setup != nil {
+			if err := w.setup(conn); err != nil {
+				conn.Close()
+				return nil, err
+			}
 		}
-
-		return nil
-	})
-
-	if err != nil {
-		// Attempt to remove partially created zip file on error
-		zipWriter.Close()
-		zipFile.Close()
-		os.Remove(zipFileName)
-		return fmt.Errorf("failed during zip archive creation: %v", err)
+		w.conn = conn
 	}
+	return w.conn, nil
+}
 
-	// Explicitly close writer and file before returning success
-	if err := zipWriter.Close(); err != nil {
-		return fmt.Errorf("failed to finalize zip writer: %v", err)
+// check pings the connection with SELECT 1 and opens a new one if the ping
+// fails. When reconnecting fails too, the connection is dropped so that the
+// next get tries again.
+func (w *workerConn) check() connHealth {
+	if w.conn == nil {
+		return connUnhealthy
+	}
+	var one int
+	if err := w.conn.DB.QueryRow("SELECT 1").Scan(&one); err == nil {
+		return connHealthy
 	}
-	if err := zipFile.Close(); err != nil {
-		return fmt.Errorf("failed to close zip file handle: %v", err)
+	w.close()
+	if _, err := w.get(); err != nil {
+		return connUnhealthy
 	}
+	return connReconnected
+}
 
-	fmt.Printf("Successfully created zip archive: %s\n", zipFileName)
-	return nil
+func (w *workerConn) close() {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
 }
 
 // uploadToS3 uploads either a single file (zip) or the contents of a directory to S3.
+// Files are streamed from disk; files above the multipart threshold use S3 multipart upload.
 func uploadToS3(localPath string, isDirectory bool, cmdArgs *CommonArgs, timestamp string) error { // Changed commonArgs to CommonArgs
 	// Initialize S3 storage
-	s3Store := storage.NewS3Storage(cmdArgs.S3Bucket, cmdArgs.S3Region)
+	tags, err := storage.ParseS3Tags(cmdArgs.S3Tags)
+	if err != nil {
+		return err
+	}
+	s3Store := storage.NewS3StorageWithOptions(cmdArgs.S3Bucket, cmdArgs.S3Region, storage.S3UploadOptions{
+		MultipartThreshold: int64(cmdArgs.S3MultipartThreshold) * 1024 * 1024,
+		PartSize:           int64(cmdArgs.S3PartSize) * 1024 * 1024,
+		StorageClass:       cmdArgs.S3StorageClass,
+		Tags:               tags,
+		KMSKeyID:           cmdArgs.S3KMSKeyID,
+	})
 	if s3Store == nil {
 		return fmt.Errorf("failed to initialize S3 storage. Please ensure AWS credentials are set (e.g., AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION)")
 	}
@@ -740,13 +2155,6 @@ func uploadToS3(localPath string, isDirectory bool, cmdArgs *CommonArgs, timesta
 				return nil // Skip directories
 			}
 
-			// Open file
-			file, err := os.Open(path)
-			if err != nil {
-				return fmt.Errorf("failed to open file %s for S3 upload: %v", path, err)
-			}
-			defer file.Close()
-
 			// Create S3 key: folderPath / timestamp / filename
 			relPath, err := filepath.Rel(localPath, path) // Path relative to the timestamp dir
 			if err != nil {
@@ -754,14 +2162,8 @@ func uploadToS3(localPath string, isDirectory bool, cmdArgs *CommonArgs, timesta
 			}
 			s3Key := filepath.Join(cmdArgs.Path, timestamp, relPath) // Base folder + timestamp + relative file path
 
-			// Read file data
-			fileData, err := io.ReadAll(file)
-			if err != nil {
-				return fmt.Errorf("failed to read file %s for S3 upload: %v", path, err)
-			}
-
-			// Upload to S3
-			if err := s3Store.Upload(fileData, s3Key); err != nil {
+			// Stream the file to S3
+			if err := uploadFileToStorage(s3Store, path, s3Key); err != nil {
 				return fmt.Errorf("failed to upload file %s to S3 key s3://%s/%s: %v", path, cmdArgs.S3Bucket, s3Key, err)
 			}
 			fmt.Printf("Uploaded %s to s3://%s/%s\n", filepath.Base(path), cmdArgs.S3Bucket, s3Key)
@@ -776,16 +2178,12 @@ func uploadToS3(localPath string, isDirectory bool, cmdArgs *CommonArgs, timesta
 	} else {
 		// Upload a single file (the zip archive)
 		zipFileName := localPath
-		zipFileData, err := os.ReadFile(zipFileName)
-		if err != nil {
-			return fmt.Errorf("failed to read zip file %s for S3 upload: %v", zipFileName, err)
-		}
 
 		// S3 key: Path / zipfilename.zip
 		s3Key := filepath.Join(cmdArgs.Path, filepath.Base(zipFileName))
 		fmt.Printf("Uploading %s to s3://%s/%s...\n", zipFileName, cmdArgs.S3Bucket, s3Key)
 
-		if err := s3Store.Upload(zipFileData, s3Key); err != nil {
+		if err := uploadFileToStorage(s3Store, zipFileName, s3Key); err != nil {
 			return fmt.Errorf("failed to upload zip file %s to S3: %v", zipFileName, err)
 		}
 		fmt.Printf("Successfully uploaded %s to s3://%s/%s\n", zipFileName, cmdArgs.S3Bucket, s3Key)
@@ -794,10 +2192,39 @@ func uploadToS3(localPath string, isDirectory bool, cmdArgs *CommonArgs, timesta
 	return nil
 }
 
+// uploadFileToStorage streams a local file to the store when it supports streaming,
+// and falls back to reading the whole file otherwise.
+func uploadFileToStorage(store storage.Storage, path string, key string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %v", path, err)
+	}
+	defer file.Close()
+
+	if streamer, ok := store.(storage.StreamUploader); ok {
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat file %s: %v", path, err)
+		}
+		return streamer.UploadStream(file, info.Size(), key)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %v", path, err)
+	}
+	return store.Upload(data, key)
+}
+
 // uploadToGDrive uploads either a single file (zip) or the contents of a directory to Google Drive.
 func uploadToGDrive(localPath string, isDirectory bool, cmdArgs *CommonArgs, timestamp string) error {
 	// Initialize Google Drive storage
-	gdriveStore, err := storage.NewGoogleDriveStorage(cmdArgs.GdriveCredentials, cmdArgs.GdriveFolder)
+	// Unfinished resumable uploads are kept next to the export, so that
+	// uploading the same files again continues them
+	gdriveStore, err := storage.NewGoogleDriveStorageWithOptions(cmdArgs.GdriveCredentials, cmdArgs.GdriveFolder, storage.GDriveUploadOptions{
+		ChunkSize:  cmdArgs.GdriveChunkSize,
+		SessionDir: filepath.Dir(localPath),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to initialize Google Drive storage: %v", err)
 	}
@@ -905,6 +2332,71 @@ func uploadToGDrive(localPath string, isDirectory bool, cmdArgs *CommonArgs, tim
 	return nil
 }
 
+// uploadToGCS uploads either a single file (zip) or the contents of a directory to Google Cloud Storage.
+func uploadToGCS(localPath string, isDirectory bool, cmdArgs *CommonArgs, timestamp string) error {
+	// Initialize GCS storage
+	gcsStore, err := storage.NewGCSStorage(cmdArgs.GCSBucket, cmdArgs.GCSProject, cmdArgs.GCSCredentials)
+	if err != nil {
+		return fmt.Errorf("failed to initialize GCS storage: %v", err)
+	}
+
+	if isDirectory {
+		// Upload individual files from the directory
+		fmt.Printf("Uploading individual files from %s to gs://%s/%s/%s/...\n", localPath, cmdArgs.GCSBucket, cmdArgs.Path, timestamp)
+
+		err := filepath.Walk(localPath, func(path string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if info.IsDir() {
+				return nil // Skip directories
+			}
+
+			// Create object name: folderPath / timestamp / filename
+			relPath, err := filepath.Rel(localPath, path) // Path relative to the timestamp dir
+			if err != nil {
+				return fmt.Errorf("failed to get relative path for %s: %v", path, err)
+			}
+			objectName := filepath.ToSlash(filepath.Join(cmdArgs.Path, timestamp, relPath))
+
+			fileData, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read file %s for GCS upload: %v", path, err)
+			}
+
+			if err := gcsStore.Upload(fileData, objectName); err != nil {
+				return fmt.Errorf("failed to upload file %s to gs://%s/%s: %v", path, cmdArgs.GCSBucket, objectName, err)
+			}
+			fmt.Printf("Uploaded %s to gs://%s/%s\n", filepath.Base(path), cmdArgs.GCSBucket, objectName)
+			return nil
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed during GCS directory upload: %v", err)
+		}
+		fmt.Printf("Successfully uploaded all files from %s to GCS bucket: %s, path prefix: %s/%s\n", localPath, cmdArgs.GCSBucket, cmdArgs.Path, timestamp)
+
+	} else {
+		// Upload a single file (the zip archive)
+		zipFileName := localPath
+		zipFileData, err := os.ReadFile(zipFileName)
+		if err != nil {
+			return fmt.Errorf("failed to read zip file %s for GCS upload: %v", zipFileName, err)
+		}
+
+		// Object name: Path / zipfilename.zip
+		objectName := filepath.ToSlash(filepath.Join(cmdArgs.Path, filepath.Base(zipFileName)))
+		fmt.Printf("Uploading %s to gs://%s/%s...\n", zipFileName, cmdArgs.GCSBucket, objectName)
+
+		if err := gcsStore.Upload(zipFileData, objectName); err != nil {
+			return fmt.Errorf("failed to upload zip file %s to GCS: %v", zipFileName, err)
+		}
+		fmt.Printf("Successfully uploaded %s to gs://%s/%s\n", zipFileName, cmdArgs.GCSBucket, objectName)
+	}
+
+	return nil
+}
+
 // cleanupLocalFiles removes the specified local files or directories, logging warnings on failure.
 func cleanupLocalFiles(paths ...string) {
 	for _, path := range paths {
@@ -918,18 +2410,19 @@ func cleanupLocalFiles(paths ...string) {
 	}
 }
 
-// runExport is the main execution function for the export command.
-func runExport(cmd *cobra.Command, cmdLineArgs []string) error {
-	cmdArgs, batchSize, conn, err := loadAndValidateArgs(cmd)
-	if err != nil {
-		return err // Error already formatted by loadAndValidateArgs
-	}
-	defer conn.Close() // Ensure connection is closed
-
+// writeExportFiles writes metadata, schema, data and stats files for the export and
+// returns the export directory path and the per-table stats.
+func writeExportFiles(conn *db.Connection, cmdArgs *CommonArgs, rowsPerInsert int) (string, []ExportStats, error) {
 	// Get the final list of tables to export, considering dependencies and exclusions
-	finalTables, excludeSchemaMap, excludeDataMap, err := getFinalTables(conn, cmdArgs)
+	finalTables, excludeSchemaMap, excludeDataMap, autoIncluded, err := getFinalTables(conn, cmdArgs)
 	if err != nil {
-		return err // Error already formatted by getFinalTables
+		return "", nil, err // Error already formatted by getFinalTables
+	}
+	if len(autoIncluded) > 0 {
+		fmt.Printf("Warning: --follow-fk added tables referenced by the selected tables: %v\n", autoIncluded)
+	}
+	if cmdArgs.IncludeData {
+		warnUnknownExcludedColumns(conn, cmdArgs.ExcludeColumns)
 	}
 
 	// If the provided path exists and contains metadata file, use it directly
@@ -944,42 +2437,242 @@ func runExport(cmd *cobra.Command, cmdLineArgs []string) error {
 		if fileName == "" {
 			fileName = fmt.Sprintf("%s_%s", cmdArgs.Database, timestamp)
 		}
+		if cmdArgs.PageNumber > 0 {
+			fileName = fmt.Sprintf("%s_page%d", fileName, cmdArgs.PageNumber)
+		}
 		exportPath = filepath.Join(cmdArgs.Path, fileName)
 	}
+	if cmdArgs.Incremental {
+		// Found before the new export directory exists
+		cmdArgs.IncrementalBase = findIncrementalBase(cmdArgs.Path, exportPath, cmdArgs.Database)
+		if cmdArgs.IncrementalBase == "" {
+			fmt.Println("No previous export found, the incremental export includes all rows")
+		} else {
+			fmt.Printf("Exporting rows changed since %s\n", cmdArgs.IncrementalBase)
+		}
+	}
 
 	// Create directory structure if needed
-	if err = os.MkdirAll(exportPath, 0755); err != nil {
-		return fmt.Errorf("failed to create export directory %s: %v", exportPath, err)
+	if err := os.MkdirAll(exportPath, 0755); err != nil {
+		return "", nil, fmt.Errorf("failed to create export directory %s: %v", exportPath, err)
 	}
 
 	// Write metadata first
-	if err = writeMetadata(exportPath, cmdArgs, finalTables); err != nil {
-		return err // Error already formatted by writeMetadata
+	if err := writeMetadata(exportPath, cmdArgs, finalTables, nil); err != nil {
+		return "", nil, err // Error already formatted by writeMetadata
 	}
 
 	// Export schema if requested
 	if cmdArgs.IncludeSchema {
-		if err = writeSchema(conn, exportPath, cmdArgs, finalTables, excludeSchemaMap); err != nil {
-			return err // Error already formatted by writeSchema
+		if err := writeSchema(conn, exportPath, cmdArgs, finalTables, excludeSchemaMap); err != nil {
+			return "", nil, err // Error already formatted by writeSchema
 		}
 	}
 
 	// Export table data
+	var stats []ExportStats
 	if cmdArgs.IncludeData {
-		recordsExported, err := writeDataFiles(conn, exportPath, cmdArgs, finalTables, excludeDataMap, batchSize)
+		var recordsExported int
+		recordsExported, stats, err = writeDataFiles(conn, exportPath, cmdArgs, finalTables, excludeDataMap, rowsPerInsert)
 		if err != nil {
-			return err // Error already formatted by writeDataFiles
+			return "", nil, err // Error already formatted by writeDataFiles
 		}
 		fmt.Printf("Total records exported: %d\n", recordsExported)
+
+		// The data files are only known now, so the metadata is written again
+		if err := writeMetadata(exportPath, cmdArgs, finalTables, stats); err != nil {
+			return "", nil, err
+		}
+
+		// Write stats before archiving so they are included in the archive
+		if err := writeExportStats(exportPath, stats); err != nil {
+			return "", nil, err
+		}
+		if cmdArgs.IncludeStats {
+			if err := writeColumnStats(conn, exportPath, stats); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	// The checksum manifest covers every other file, so it is written last
+	if err := writeChecksumManifest(exportPath); err != nil {
+		return "", nil, err
+	}
+	return exportPath, stats, nil
+}
+
+// acquireExportLock locks the export path, waiting up to cmdArgs.LockTimeout
+// for a concurrent export to finish.
+func acquireExportLock(cmdArgs *CommonArgs) (*lock.FileLock, error) {
+	lockPath := cmdArgs.LockFile
+	if lockPath == "" {
+		if cmdArgs.Path != "" {
+			if err := os.MkdirAll(cmdArgs.Path, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create export directory %s: %v", cmdArgs.Path, err)
+			}
+		}
+		lockPath = filepath.Join(cmdArgs.Path, exportLockFileName)
 	}
 
-	// Create zip file if requested
+	fileLock := lock.New(lockPath)
+	locked, err := fileLock.TryLockFor(cmdArgs.LockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, fmt.Errorf("another export is running: could not acquire lock %s within %s (use --no-lock to skip locking)",
+			lockPath, cmdArgs.LockTimeout)
+	}
+	return fileLock, nil
+}
+
+// runWithHooks runs pre, then body, then post. The post hook is deferred so it
+// also runs when body fails, unless postOnError is false; it is skipped if the
+// pre hook itself fails.
+func runWithHooks(pre, body, post func() error, postOnError bool) (err error) {
+	if err := pre(); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil && !postOnError {
+			return
+		}
+		if postErr := post(); postErr != nil {
+			if err != nil {
+				fmt.Printf("Warning: %v\n", postErr)
+				return
+			}
+			err = postErr
+		}
+	}()
+	return body()
+}
+
+// executeHookSQL runs the statements of a SQL hook. Statements are separated by
+// a semicolon at the end of a line.
+func executeHookSQL(conn *db.Connection, hookName string, hookSQL string) error {
+	if strings.TrimSpace(hookSQL) == "" {
+		return nil
+	}
+	fmt.Printf("Running %s SQL hook\n", hookName)
+	for _, stmt := range splitHookSQL(hookSQL) {
+		if _, err := conn.DB.Exec(stmt); err != nil {
+			return fmt.Errorf("%s hook failed: %v\nStatement: %s", hookName, err, stmt)
+		}
+	}
+	return nil
+}
+
+// executeSessionHookSQL runs a SQL hook whose session settings, such as SET
+// statements, must apply to the later queries on conn. The connection pool is
+// limited to one connection that does not expire, so that every query runs in
+// the session of the hook.
+func executeSessionHookSQL(conn *db.Connection, hookName string, hookSQL string) error {
+	if strings.TrimSpace(hookSQL) == "" {
+		return nil
+	}
+	conn.DB.SetMaxOpenConns(1)
+	conn.DB.SetConnMaxLifetime(0)
+	return executeHookSQL(conn, hookName, hookSQL)
+}
+
+// splitHookSQL splits hook SQL into individual statements on semicolons that end a line.
+func splitHookSQL(hookSQL string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(hookSQL, "\n") {
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") {
+			if stmt := strings.TrimSuffix(strings.TrimSpace(current.String()), ";"); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+		}
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+	return statements
+}
+
+// loadHookSQLFile replaces hookSQL with the contents of the file given by fileFlag, if set.
+func loadHookSQLFile(cmd *cobra.Command, sqlFlag, fileFlag string, hookSQL *string) error {
+	path, _ := cmd.Flags().GetString(fileFlag)
+	if path == "" {
+		return nil
+	}
+	if cmd.Flags().Changed(sqlFlag) {
+		return fmt.Errorf("--%s and --%s cannot be used together", sqlFlag, fileFlag)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", fileFlag, err)
+	}
+	*hookSQL = string(data)
+	return nil
+}
+
+// runExport is the main execution function for the export command.
+func runExport(cmd *cobra.Command, cmdLineArgs []string) error {
+	cmdArgs, rowsPerInsert, conn, err := loadAndValidateArgs(cmd)
+	if err != nil {
+		return err // Error already formatted by loadAndValidateArgs
+	}
+	defer conn.Close() // Ensure connection is closed
+
+	return exportDatabase(conn, cmdArgs, rowsPerInsert)
+}
+
+// exportDatabase writes the export described by cmdArgs from conn, archives
+// and uploads it, and prunes older exports.
+func exportDatabase(conn *db.Connection, cmdArgs *CommonArgs, rowsPerInsert int) (err error) {
+	start := time.Now()
+	var stats []ExportStats
+	defer func() {
+		var records int64
+		for _, s := range stats {
+			records += int64(s.RecordsExported)
+		}
+		sendWebhook(cmdArgs, "export", start, len(stats), records, err)
+	}()
+
+	// Keep other exports from writing to the same path while this one runs
+	if !cmdArgs.NoLock {
+		exportLock, err := acquireExportLock(cmdArgs)
+		if err != nil {
+			return err
+		}
+		defer exportLock.Unlock()
+	}
+
+	// Run the pre-export hook, write all export files, then run the post-export hook
+	var exportPath string
+	err = runWithHooks(
+		func() error { return executeSessionHookSQL(conn, "pre-export", cmdArgs.PreExportSQL) },
+		func() error {
+			path, tableStats, err := writeExportFiles(conn, cmdArgs, rowsPerInsert)
+			exportPath, stats = path, tableStats
+			return err
+		},
+		func() error { return executeHookSQL(conn, "post-export", cmdArgs.PostExportSQL) },
+		true,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Create archive if requested (a non-zip --compress-format implies --zip)
 	var zipFileName string
+	if cmdArgs.CompressFormat != archiveFormatZip {
+		cmdArgs.Zip = true
+	}
 	if cmdArgs.Zip {
-		zipFileName = exportPath + ".zip"
-		fmt.Printf("Creating zip archive: %s\n", zipFileName)
-		if err = createZipArchive(exportPath, zipFileName); err != nil {
-			return fmt.Errorf("failed to create zip archive: %v", err)
+		zipFileName = exportPath + archiveExtension(cmdArgs.CompressFormat)
+		fmt.Printf("Creating %s archive: %s\n", cmdArgs.CompressFormat, zipFileName)
+		if err = createArchive(exportPath, zipFileName, cmdArgs.CompressFormat, cmdArgs.CompressLevel); err != nil {
+			return fmt.Errorf("failed to create archive: %v", err)
 		}
 		// Zip successful, remove original directory *unless* S3 upload fails later
 		// We'll handle cleanup after potential S3 upload
@@ -1024,6 +2717,27 @@ func runExport(cmd *cobra.Command, cmdLineArgs []string) error {
 			return err
 		}
 
+		// Clean up local files after successful upload (unless --keep-local was specified)
+		cleanupLocalFiles(exportPath)
+		if cmdArgs.Zip {
+			cleanupLocalFiles(zipFileName)
+		}
+
+	case "gcs":
+		uploadPath := exportPath // Default to uploading the directory
+		isDirectory := true
+		if cmdArgs.Zip {
+			uploadPath = zipFileName // Upload the zip file instead
+			isDirectory = false
+		}
+
+		if err = uploadToGCS(uploadPath, isDirectory, cmdArgs, filepath.Base(exportPath)); err != nil {
+			// GCS upload failed. Don't clean up local files automatically.
+			fmt.Printf("GCS Upload failed: %v\n", err)
+			fmt.Println("Local files/zip kept due to GCS upload failure.")
+			return err
+		}
+
 		// Clean up local files after successful upload (unless --keep-local was specified)
 		cleanupLocalFiles(exportPath)
 		if cmdArgs.Zip {
@@ -1031,9 +2745,171 @@ func runExport(cmd *cobra.Command, cmdLineArgs []string) error {
 		}
 	}
 
+	// Rotate old exports if requested
+	if cmdArgs.KeepLast > 0 {
+		var pruned []string
+		switch cmdArgs.Storage {
+		case "local":
+			pruned, err = pruneOldExports(filepath.Dir(exportPath), cmdArgs.Database, cmdArgs.KeepLast, cmdArgs.PruneDryRun)
+		case "s3":
+			s3Store := storage.NewS3Storage(cmdArgs.S3Bucket, cmdArgs.S3Region)
+			if s3Store == nil {
+				return fmt.Errorf("failed to initialize S3 storage for pruning old exports")
+			}
+			pruned, err = pruneOldRemoteExports(s3Store, cmdArgs.Path, cmdArgs.Database, cmdArgs.KeepLast, cmdArgs.PruneDryRun)
+		default:
+			fmt.Printf("Warning: --keep-last is not supported for %s storage, skipping rotation\n", cmdArgs.Storage)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to prune old exports: %v", err)
+		}
+		for _, name := range pruned {
+			if cmdArgs.PruneDryRun {
+				fmt.Printf("Would delete old export: %s\n", name)
+			} else {
+				fmt.Printf("Deleted old export: %s\n", name)
+			}
+		}
+	}
+
+	// Record the export as the latest one of this database
+	uploadPath := exportPath
+	if cmdArgs.Zip {
+		uploadPath = zipFileName
+	}
+	if err := recordExportInCatalog(cmdArgs, uploadPath, stats); err != nil {
+		fmt.Printf("Warning: failed to update export catalog: %v\n", err)
+	}
+
+	if len(stats) > 0 {
+		fmt.Println("\nExport statistics:")
+		printExportStats(os.Stdout, stats)
+	}
+
 	return nil
 }
 
+// exportTimestampLayout is the timestamp format used in default export names.
+const exportTimestampLayout = "20060102_150405"
+
+// parseExportTimestamp parses the timestamp from an export name of the form
+// {database}_{yyyymmdd_hhmmss}. Archive extensions must be trimmed by the caller.
+func parseExportTimestamp(name, database string) (time.Time, bool) {
+	prefix := database + "_"
+	if !strings.HasPrefix(name, prefix) {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(exportTimestampLayout, strings.TrimPrefix(name, prefix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// selectExportsToPrune returns the export names older than the keepLast most
+// recent ones, newest first.
+func selectExportsToPrune(timestamps map[string]time.Time, keepLast int) []string {
+	names := make([]string, 0, len(timestamps))
+	for name := range timestamps {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return timestamps[names[i]].After(timestamps[names[j]])
+	})
+	if len(names) <= keepLast {
+		return nil
+	}
+	return names[keepLast:]
+}
+
+// pruneOldExports deletes all but the keepLast most recent exports of database in
+// basePath. Export directories and archives with the same name count as one export.
+// With dryRun nothing is removed. It returns the names of the pruned exports.
+func pruneOldExports(basePath, database string, keepLast int, dryRun bool) ([]string, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+
+	timestamps := make(map[string]time.Time)
+	paths := make(map[string][]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() {
+			if detectArchiveFormat(name) == "" {
+				continue
+			}
+			name = trimArchiveExtension(name)
+		}
+		ts, ok := parseExportTimestamp(name, database)
+		if !ok {
+			continue
+		}
+		timestamps[name] = ts
+		paths[name] = append(paths[name], filepath.Join(basePath, entry.Name()))
+	}
+
+	pruned := selectExportsToPrune(timestamps, keepLast)
+	if dryRun {
+		return pruned, nil
+	}
+	for _, name := range pruned {
+		for _, path := range paths[name] {
+			if err := os.RemoveAll(path); err != nil {
+				return nil, fmt.Errorf("failed to delete %s: %v", path, err)
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// pruneOldRemoteExports deletes all but the keepLast most recent exports of database
+// stored under prefix in remote storage. Objects are grouped by export name (the
+// first path segment below prefix). The storage must implement storage.Deleter.
+func pruneOldRemoteExports(store storage.Storage, prefix, database string, keepLast int, dryRun bool) ([]string, error) {
+	deleter, ok := store.(storage.Deleter)
+	if !ok && !dryRun {
+		return nil, fmt.Errorf("storage does not support deleting objects")
+	}
+
+	objects, err := store.ListObjects(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %v", err)
+	}
+
+	keyPrefix := strings.Trim(filepath.ToSlash(prefix), "/")
+	if keyPrefix != "" {
+		keyPrefix += "/"
+	}
+
+	timestamps := make(map[string]time.Time)
+	keys := make(map[string][]string)
+	for _, object := range objects {
+		rel := strings.TrimPrefix(object, keyPrefix)
+		name := trimArchiveExtension(strings.SplitN(rel, "/", 2)[0])
+		ts, ok := parseExportTimestamp(name, database)
+		if !ok {
+			continue
+		}
+		timestamps[name] = ts
+		keys[name] = append(keys[name], object)
+	}
+
+	pruned := selectExportsToPrune(timestamps, keepLast)
+	if dryRun || len(pruned) == 0 {
+		return pruned, nil
+	}
+
+	var toDelete []string
+	for _, name := range pruned {
+		toDelete = append(toDelete, keys[name]...)
+	}
+	if err := deleter.DeleteObjects(toDelete); err != nil {
+		return nil, err
+	}
+	return pruned, nil
+}
+
 // escapeControlCharsForSQL escapes control characters in a string for SQL/JSON compatibility
 func escapeControlCharsForSQL(s string) string {
 	replacer := strings.NewReplacer(
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// applyExportPage turns --page-size and --page-number into the limit and
+// offset of every table's query, with the rows ordered by primary key so that
+// the pages do not overlap.
+func applyExportPage(cmdArgs *CommonArgs) error {
+	if cmdArgs.PageSize == 0 && cmdArgs.PageNumber == 0 {
+		return nil
+	}
+	if cmdArgs.PageSize <= 0 || cmdArgs.PageNumber <= 0 {
+		return fmt.Errorf("--page-size and --page-number must both be positive, got %d and %d", cmdArgs.PageSize, cmdArgs.PageNumber)
+	}
+	if cmdArgs.RecordLimit > 0 || cmdArgs.RecordOffset > 0 {
+		return fmt.Errorf("--page-size and --page-number cannot be combined with --limit or --offset")
+	}
+	cmdArgs.RecordLimit = cmdArgs.PageSize
+	cmdArgs.RecordOffset = (cmdArgs.PageNumber - 1) * cmdArgs.PageSize
+	cmdArgs.OrderByPK = true
+	return nil
+}
+
+func newExportPagesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-pages",
+		Short: "Export the pages of a paginated export in parallel",
+		Long: `Run one export per page of --page-size rows, in parallel and each on its own connection, named
+{database}_{timestamp}_page{N}. Unlike --workers, which exports tables in parallel, this splits
+every table into pages. Import the pages together with import --path page1,page2,...
+Takes the flags of export, except --page-number.
+Examples:
+  syncdb export-pages --total-pages 4 --page-size 1000000 --database mydb --path ./backups`,
+		Args: cobra.NoArgs,
+		RunE: runExportPages,
+	}
+	addExportFlags(cmd)
+	cmd.Flags().Int("total-pages", 0, "Number of pages exported, from page 1")
+	cmd.MarkFlagRequired("total-pages")
+
+	return cmd
+}
+
+// exportPageRun is the export of one page by export-pages.
+type exportPageRun struct {
+	cmdArgs       *CommonArgs
+	rowsPerInsert int
+	conn          *db.Connection
+}
+
+func runExportPages(cmd *cobra.Command, args []string) error {
+	totalPages, _ := cmd.Flags().GetInt("total-pages")
+	if totalPages < 1 {
+		return fmt.Errorf("total-pages must be at least 1, got %d", totalPages)
+	}
+	if cmd.Flags().Changed("page-number") {
+		return fmt.Errorf("export-pages sets the --page-number of each export itself")
+	}
+
+	// The arguments of each page are loaded in turn, each with its own connection
+	var runs []exportPageRun
+	defer func() {
+		for _, run := range runs {
+			run.conn.Close()
+		}
+	}()
+	timestamp := time.Now().Format(exportTimestampLayout)
+	for page := 1; page <= totalPages; page++ {
+		if err := cmd.Flags().Set("page-number", strconv.Itoa(page)); err != nil {
+			return err
+		}
+		cmdArgs, rowsPerInsert, conn, err := loadAndValidateArgs(cmd)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, exportPageRun{cmdArgs: cmdArgs, rowsPerInsert: rowsPerInsert, conn: conn})
+	}
+
+	first := runs[0].cmdArgs
+	if storage.IsExportPath(first.Path) {
+		return fmt.Errorf("export-pages creates an export directory per page, --path must not be an export directory")
+	}
+	if first.KeepLast > 0 {
+		return fmt.Errorf("--keep-last cannot be used with export-pages")
+	}
+	// The pages write to their own directories under the same path, so they
+	// hold its lock together
+	if !first.NoLock {
+		exportLock, err := acquireExportLock(first)
+		if err != nil {
+			return err
+		}
+		defer exportLock.Unlock()
+	}
+	for _, run := range runs {
+		run.cmdArgs.NoLock = true
+		// The pages share the timestamp of their names
+		if run.cmdArgs.FileName == "" {
+			run.cmdArgs.FileName = fmt.Sprintf("%s_%s", run.cmdArgs.Database, timestamp)
+		}
+	}
+
+	errs := make([]error, len(runs))
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := exportDatabase(run.conn, run.cmdArgs, run.rowsPerInsert); err != nil {
+				errs[i] = fmt.Errorf("page %d: %v", run.cmdArgs.PageNumber, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
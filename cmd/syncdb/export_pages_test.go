@@ -0,0 +1,27 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyExportPage(t *testing.T) {
+	cmdArgs := &CommonArgs{PageSize: 1000, PageNumber: 3}
+	require.NoError(t, applyExportPage(cmdArgs))
+	assert.Equal(t, 1000, cmdArgs.RecordLimit)
+	assert.Equal(t, 2000, cmdArgs.RecordOffset)
+	assert.True(t, cmdArgs.OrderByPK)
+
+	// Not paginated
+	cmdArgs = &CommonArgs{RecordLimit: 10}
+	require.NoError(t, applyExportPage(cmdArgs))
+	assert.Equal(t, 10, cmdArgs.RecordLimit)
+	assert.False(t, cmdArgs.OrderByPK)
+
+	assert.ErrorContains(t, applyExportPage(&CommonArgs{PageSize: 1000}), "must both be positive")
+	assert.ErrorContains(t, applyExportPage(&CommonArgs{PageNumber: 1}), "must both be positive")
+	assert.ErrorContains(t, applyExportPage(&CommonArgs{PageSize: 10, PageNumber: -1}), "must both be positive")
+	assert.ErrorContains(t, applyExportPage(&CommonArgs{PageSize: 10, PageNumber: 1, RecordOffset: 5}), "cannot be combined with --limit or --offset")
+}
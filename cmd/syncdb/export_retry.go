@@ -0,0 +1,104 @@
+package main
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// transientMySQLErrors are the MySQL error codes of a lost connection
+var transientMySQLErrors = []uint16{
+	2006, // CR_SERVER_GONE_ERROR
+	2013, // CR_SERVER_LOST
+}
+
+// transientErrorMessages are matched against error messages, as most errors
+// reach the export loop formatted into a string
+var transientErrorMessages = []string{
+	"Error 2006",
+	"Error 2013",
+	"server has gone away",
+	"lost connection to mysql server",
+	"connection reset by peer",
+	"broken pipe",
+	"invalid connection",
+	"bad connection",
+	"unexpected eof",
+	"terminating connection due to administrator command",
+}
+
+// exportRetrySleep waits between table export attempts; tests replace it to avoid real delays.
+var exportRetrySleep = time.Sleep
+
+// isTransientError reports whether err was caused by a connection problem that
+// a new attempt may not run into, rather than by the query or the data.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		for _, code := range transientMySQLErrors {
+			if mysqlErr.Number == code {
+				return true
+			}
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, transient := range transientErrorMessages {
+		if strings.Contains(msg, strings.ToLower(transient)) {
+			return true
+		}
+	}
+	return false
+}
+
+// withExportRetry runs export, running it again up to config.ExportRetryCount
+// times, config.ExportRetryDelay apart, while it fails with a transient error.
+func withExportRetry(config db.ConnectionConfig, table string, export func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := export()
+		if err == nil || !isTransientError(err) {
+			return err
+		}
+		if attempt >= config.ExportRetryCount {
+			if attempt == 0 {
+				return err
+			}
+			return fmt.Errorf("table %s still failing after %d retries: %w", table, attempt, err)
+		}
+		fmt.Printf("\nTransient error exporting table '%s': %v. Retrying (%d/%d) in %s...\n",
+			table, err, attempt+1, config.ExportRetryCount, config.ExportRetryDelay)
+		exportRetrySleep(config.ExportRetryDelay)
+	}
+}
+
+// removeTableDataFiles removes the data files of a table left by a failed
+// attempt: its single data file or its chunk or part files, compressed or
+// not, and its BLOB files.
+func removeTableDataFiles(exportPath string, tableIndex int, table, format string) {
+	removeBlobSidecars(exportPath, tableIndex, table)
+	for _, ext := range []string{"", gzipFileExt} {
+		os.Remove(filepath.Join(exportPath, dataFileName(tableIndex, table, 0, format)+ext))
+		for chunk := 1; ; chunk++ {
+			if err := os.Remove(filepath.Join(exportPath, dataFileName(tableIndex, table, chunk, format)+ext)); err != nil {
+				break
+			}
+		}
+		for part := 1; ; part++ {
+			if err := os.Remove(filepath.Join(exportPath, dataPartFileName(tableIndex, table, part, format)+ext)); err != nil {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, isTransientError(&mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"}))
+	assert.True(t, isTransientError(fmt.Errorf("failed to export raw data for table users: %v", mysql.ErrInvalidConn)))
+	assert.True(t, isTransientError(errors.New("read tcp 10.0.0.2:5432: read: connection reset by peer")))
+	assert.True(t, isTransientError(errors.New("Error 2006 (HY000): MySQL server has gone away")))
+	assert.False(t, isTransientError(&mysql.MySQLError{Number: 1146, Message: "Table 'shop.users' doesn't exist"}))
+	assert.False(t, isTransientError(nil))
+}
+
+func TestWithExportRetry(t *testing.T) {
+	var sleeps []time.Duration
+	exportRetrySleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	t.Cleanup(func() { exportRetrySleep = time.Sleep })
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	query := "SELECT COUNT(*) FROM users"
+	mock.ExpectQuery(query).WillReturnError(&mysql.MySQLError{Number: 2013, Message: "Lost connection to MySQL server during query"})
+	mock.ExpectQuery(query).WillReturnError(errors.New("write: broken pipe"))
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	config := db.ConnectionConfig{ExportRetryCount: 3, ExportRetryDelay: time.Second}
+	var count, attempts int
+	err = withExportRetry(config, "users", func() error {
+		attempts++
+		return mockDB.QueryRow(query).Scan(&count)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 42, count)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, sleeps)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Other errors and exhausted retries are returned
+	attempts = 0
+	err = withExportRetry(config, "users", func() error { attempts++; return errors.New("syntax error") })
+	assert.EqualError(t, err, "syntax error")
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	err = withExportRetry(config, "users", func() error { attempts++; return mysql.ErrInvalidConn })
+	assert.ErrorContains(t, err, "after 3 retries")
+	assert.Equal(t, 4, attempts)
+}
+
+func TestRemoveTableDataFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"2_users_chunk1.sql", "2_users_chunk2.sql", "2_users_part1.sql.gz", "3_orders.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	removeTableDataFiles(dir, 2, "users", exportFormatSQL)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "3_orders.sql", entries[0].Name())
+}
@@ -0,0 +1,790 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitInsertValues(t *testing.T) {
+	values := []string{"(1)", "(22)", "(333)", "(4444)", "(55555555555555555555)", "(6)"}
+
+	// A 16-byte limit with a 6-byte statement leaves 10 bytes for the values
+	// and their ",\n" separators
+	assert.Equal(t, [][]string{
+		{"(1)", "(22)"},
+		{"(333)"},
+		{"(4444)"},
+		{"(55555555555555555555)"},
+		{"(6)"},
+	}, splitInsertValues(values, 6, 16))
+
+	// Without the statement overhead more values fit together
+	assert.Equal(t, [][]string{
+		{"(1)", "(22)", "(333)"},
+		{"(4444)"},
+		{"(55555555555555555555)"},
+		{"(6)"},
+	}, splitInsertValues(values, 0, 16))
+
+	assert.Equal(t, [][]string{values}, splitInsertValues(values, 0, 1<<20))
+	assert.Empty(t, splitInsertValues(nil, 6, 16))
+
+	// Every statement built from the groups stays within the limit unless it holds a single row
+	empty, err := buildInsertStatement("mysql", insertModeInsert, "t", []string{"id"}, nil, nil)
+	require.NoError(t, err)
+	for _, group := range splitInsertValues(values, len(empty), 40) {
+		stmt, err := buildInsertStatement("mysql", insertModeInsert, "t", []string{"id"}, nil, group)
+		require.NoError(t, err)
+		if len(group) > 1 {
+			assert.LessOrEqual(t, len(stmt), 40, stmt)
+		}
+	}
+}
+
+func TestBuildInsertStatement(t *testing.T) {
+	columns := []string{"id", "name", "email"}
+	pkColumns := []string{"id"}
+	values := []string{"(1, 'alice', 'a@example.com')", "(2, 'bob', NULL)"}
+
+	testCases := []struct {
+		name     string
+		driver   string
+		mode     string
+		expected string
+	}{
+		{
+			name:   "MySQL insert",
+			driver: "mysql",
+			mode:   insertModeInsert,
+			expected: "INSERT INTO `users` (`id`, `name`, `email`) VALUES\n" +
+				"(1, 'alice', 'a@example.com'),\n(2, 'bob', NULL);",
+		},
+		{
+			name:   "MySQL insert-ignore",
+			driver: "mysql",
+			mode:   insertModeInsertIgnore,
+			expected: "INSERT IGNORE INTO `users` (`id`, `name`, `email`) VALUES\n" +
+				"(1, 'alice', 'a@example.com'),\n(2, 'bob', NULL);",
+		},
+		{
+			name:   "MySQL replace",
+			driver: "mysql",
+			mode:   insertModeReplace,
+			expected: "REPLACE INTO `users` (`id`, `name`, `email`) VALUES\n" +
+				"(1, 'alice', 'a@example.com'),\n(2, 'bob', NULL);",
+		},
+		{
+			name:   "MySQL upsert",
+			driver: "mysql",
+			mode:   insertModeUpsert,
+			expected: "INSERT INTO `users` (`id`, `name`, `email`) VALUES\n" +
+				"(1, 'alice', 'a@example.com'),\n(2, 'bob', NULL)\n" +
+				"ON DUPLICATE KEY UPDATE `name`=VALUES(`name`),`email`=VALUES(`email`);",
+		},
+		{
+			name:   "PostgreSQL insert-ignore",
+			driver: "postgres",
+			mode:   insertModeInsertIgnore,
+			expected: `INSERT INTO "users" ("id", "name", "email") VALUES` + "\n" +
+				"(1, 'alice', 'a@example.com'),\n(2, 'bob', NULL)\nON CONFLICT DO NOTHING;",
+		},
+		{
+			name:   "PostgreSQL upsert",
+			driver: "postgres",
+			mode:   insertModeUpsert,
+			expected: `INSERT INTO "users" ("id", "name", "email") VALUES` + "\n" +
+				"(1, 'alice', 'a@example.com'),\n(2, 'bob', NULL)\n" +
+				`ON CONFLICT ("id") DO UPDATE SET "name"=EXCLUDED."name","email"=EXCLUDED."email";`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stmt, err := buildInsertStatement(tc.driver, tc.mode, "users", columns, pkColumns, values)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stmt)
+		})
+	}
+
+	t.Run("PostgreSQL replace is rejected", func(t *testing.T) {
+		_, err := buildInsertStatement("postgres", insertModeReplace, "users", columns, pkColumns, values)
+		assert.Error(t, err)
+	})
+
+	t.Run("PostgreSQL upsert without primary key is rejected", func(t *testing.T) {
+		_, err := buildInsertStatement("postgres", insertModeUpsert, "users", columns, nil, values)
+		assert.Error(t, err)
+	})
+}
+
+func TestResolveInsertMode(t *testing.T) {
+	for _, tc := range []struct{ mode, driver, expected string }{
+		{insertModeInsert, "mysql", insertModeInsert},
+		{insertModeReplace, "mysql", insertModeReplace},
+		{insertModeUpsert, "postgres", insertModeUpsert},
+		{insertModeReplace, "postgres", insertModeInsertIgnore},
+	} {
+		mode, err := resolveInsertMode(tc.mode, tc.driver)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, mode, "%s on %s", tc.mode, tc.driver)
+	}
+	_, err := resolveInsertMode("merge", "mysql")
+	assert.Error(t, err)
+}
+
+func TestOrderTables(t *testing.T) {
+	// order_items references orders and products, orders references customers
+	deps := map[string][]string{
+		"customers":   nil,
+		"products":    nil,
+		"orders":      {"customers"},
+		"order_items": {"orders", "products"},
+	}
+	tables := db.SortTablesByDependencies([]string{"products", "order_items", "orders", "customers"}, deps)
+	require.Len(t, tables, 4)
+	original := append([]string{}, tables...)
+
+	// The dependency order keeps every table after the tables it references
+	position := make(map[string]int)
+	for i, table := range tables {
+		position[table] = i
+	}
+	for table, refs := range deps {
+		for _, ref := range refs {
+			assert.Less(t, position[ref], position[table], "%s before %s", ref, table)
+		}
+	}
+
+	assert.Equal(t, []string{"customers", "order_items", "orders", "products"}, orderTables(tables, tableOrderAlpha, nil))
+	assert.Equal(t, []string{"products", "orders", "order_items", "customers"}, orderTables(tables, tableOrderReverseAlpha, nil))
+	assert.Equal(t, "order_items", orderTables(tables, tableOrderManual, []string{"order_items"})[0])
+	assert.Equal(t, tables, orderTables(tables, tableOrderManual, nil))
+
+	// Manual entries keep their order, patterns expand in dependency order and
+	// the remaining tables follow in dependency order
+	manual := orderTables(tables, tableOrderManual, []string{"products", "order*"})
+	assert.Equal(t, []string{"products", "orders", "order_items", "customers"}, manual)
+
+	assert.Equal(t, original, tables, "input is left unchanged")
+	for _, order := range []string{tableOrderDependency, tableOrderAlpha, tableOrderReverseAlpha, tableOrderManual} {
+		assert.NoError(t, validateTableOrder(order))
+	}
+	assert.Error(t, validateTableOrder("random"))
+}
+
+func TestInsertStrategyFlagAlias(t *testing.T) {
+	cmd := newExportCommand()
+	require.NoError(t, cmd.Flags().Parse([]string{"--insert-strategy", "replace"}))
+	assert.True(t, cmd.Flags().Changed("insert-mode"))
+	mode, _ := cmd.Flags().GetString("insert-mode")
+	assert.Equal(t, insertModeReplace, mode)
+}
+
+func TestReplaceStatementMatchesInsertRows(t *testing.T) {
+	// REPLACE only differs from INSERT in the statement verb, so re-importing a
+	// REPLACE export writes the same rows as a fresh import of an INSERT export
+	columns := []string{"id", "name"}
+	values := []string{"(1, 'alice')", "(2, 'it''s')"}
+	insertStmt, err := buildInsertStatement("mysql", insertModeInsert, "users", columns, nil, values)
+	require.NoError(t, err)
+	replaceStmt, err := buildInsertStatement("mysql", insertModeReplace, "users", columns, nil, values)
+	require.NoError(t, err)
+
+	inserted, ok, err := parseInsertStatement(insertStmt)
+	require.NoError(t, err)
+	require.True(t, ok)
+	replaced, ok, err := parseInsertStatement(replaceStmt)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, inserted.columns, replaced.columns)
+	assert.Equal(t, inserted.values, replaced.values)
+	assert.Equal(t, inserted.tail, replaced.tail)
+
+	assert.Error(t, validateImportInsertMode(insertModeReplace, "postgres"))
+	assert.NoError(t, validateImportInsertMode(insertModeReplace, "mysql"))
+}
+
+func TestRunWithHooks(t *testing.T) {
+	t.Run("Runs pre, body and post in order", func(t *testing.T) {
+		var calls []string
+		err := runWithHooks(
+			func() error { calls = append(calls, "pre"); return nil },
+			func() error { calls = append(calls, "export"); return nil },
+			func() error { calls = append(calls, "post"); return nil },
+			true,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"pre", "export", "post"}, calls)
+	})
+
+	t.Run("Post hook runs when export fails", func(t *testing.T) {
+		var calls []string
+		err := runWithHooks(
+			func() error { calls = append(calls, "pre"); return nil },
+			func() error { calls = append(calls, "export"); return errors.New("export failed") },
+			func() error { calls = append(calls, "post"); return errors.New("post failed") },
+			true,
+		)
+		assert.EqualError(t, err, "export failed")
+		assert.Equal(t, []string{"pre", "export", "post"}, calls)
+	})
+
+	t.Run("Post hook error is returned when export succeeds", func(t *testing.T) {
+		err := runWithHooks(
+			func() error { return nil },
+			func() error { return nil },
+			func() error { return errors.New("post failed") },
+			true,
+		)
+		assert.EqualError(t, err, "post failed")
+	})
+
+	t.Run("Post hook is skipped on failure when postOnError is false", func(t *testing.T) {
+		var calls []string
+		err := runWithHooks(
+			func() error { calls = append(calls, "pre"); return nil },
+			func() error { calls = append(calls, "import"); return errors.New("import failed") },
+			func() error { calls = append(calls, "post"); return nil },
+			false,
+		)
+		assert.EqualError(t, err, "import failed")
+		assert.Equal(t, []string{"pre", "import"}, calls)
+	})
+
+	t.Run("Failed pre hook skips export and post hook", func(t *testing.T) {
+		var calls []string
+		err := runWithHooks(
+			func() error { calls = append(calls, "pre"); return errors.New("pre failed") },
+			func() error { calls = append(calls, "export"); return nil },
+			func() error { calls = append(calls, "post"); return nil },
+			true,
+		)
+		assert.EqualError(t, err, "pre failed")
+		assert.Equal(t, []string{"pre"}, calls)
+	})
+}
+
+func TestSplitHookSQL(t *testing.T) {
+	hookSQL := "SET @export_started = NOW();\nREFRESH MATERIALIZED VIEW\n  sales_summary;\n\nSELECT 1"
+	assert.Equal(t, []string{
+		"SET @export_started = NOW()",
+		"REFRESH MATERIALIZED VIEW\n  sales_summary",
+		"SELECT 1",
+	}, splitHookSQL(hookSQL))
+}
+
+func TestPruneOldExports(t *testing.T) {
+	setup := func(t *testing.T) string {
+		basePath := t.TempDir()
+		for _, name := range []string{
+			"mydb_20240101_120000",
+			"mydb_20240102_120000",
+			"mydb_20240103_120000",
+			"otherdb_20230101_120000",
+			"mydb_custom_name",
+		} {
+			require.NoError(t, os.MkdirAll(filepath.Join(basePath, name), 0755))
+		}
+		// The archive of an old export belongs to the same export as its directory
+		require.NoError(t, os.WriteFile(filepath.Join(basePath, "mydb_20240101_120000.zip"), []byte("zip"), 0644))
+		return basePath
+	}
+
+	t.Run("Deletes all but the most recent exports", func(t *testing.T) {
+		basePath := setup(t)
+		pruned, err := pruneOldExports(basePath, "mydb", 1, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mydb_20240102_120000", "mydb_20240101_120000"}, pruned)
+
+		entries, err := os.ReadDir(basePath)
+		require.NoError(t, err)
+		var remaining []string
+		for _, e := range entries {
+			remaining = append(remaining, e.Name())
+		}
+		assert.ElementsMatch(t, []string{"mydb_20240103_120000", "otherdb_20230101_120000", "mydb_custom_name"}, remaining)
+	})
+
+	t.Run("Dry run keeps files", func(t *testing.T) {
+		basePath := setup(t)
+		pruned, err := pruneOldExports(basePath, "mydb", 2, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"mydb_20240101_120000"}, pruned)
+		assert.DirExists(t, filepath.Join(basePath, "mydb_20240101_120000"))
+		assert.FileExists(t, filepath.Join(basePath, "mydb_20240101_120000.zip"))
+	})
+
+	t.Run("Nothing to prune", func(t *testing.T) {
+		basePath := setup(t)
+		pruned, err := pruneOldExports(basePath, "mydb", 5, false)
+		require.NoError(t, err)
+		assert.Empty(t, pruned)
+	})
+}
+
+func TestPruneOldRemoteExports(t *testing.T) {
+	store := &fakeStorage{objects: map[string][]byte{
+		"backups/mydb_20240101_120000/0_metadata.json": nil,
+		"backups/mydb_20240101_120000/1_users.sql":     nil,
+		"backups/mydb_20240102_120000.zip":             nil,
+		"backups/mydb_20240103_120000/0_metadata.json": nil,
+		"backups/otherdb_20230101_120000.zip":          nil,
+	}}
+
+	pruned, err := pruneOldRemoteExports(store, "backups", "mydb", 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mydb_20240102_120000", "mydb_20240101_120000"}, pruned)
+
+	var remaining []string
+	for name := range store.objects {
+		remaining = append(remaining, name)
+	}
+	sort.Strings(remaining)
+	assert.Equal(t, []string{
+		"backups/mydb_20240103_120000/0_metadata.json",
+		"backups/otherdb_20230101_120000.zip",
+	}, remaining)
+}
+
+func TestFormatSQLValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     CommonArgs
+		value    interface{}
+		expected string
+	}{
+		{"NULL with default token", CommonArgs{}, nil, "NULL"},
+		{"NULL with custom token", CommonArgs{NullToken: `\N`}, nil, `\N`},
+		{"NULL as empty string token", CommonArgs{NullToken: "''"}, nil, "''"},
+		{"Empty string kept by default", CommonArgs{}, "", "''"},
+		{"Empty string as NULL", CommonArgs{EmptyStringAsNull: true}, "", "NULL"},
+		{"Empty string as custom token", CommonArgs{EmptyStringAsNull: true, NullToken: `\N`}, "", `\N`},
+		{"Zero string is not NULL", CommonArgs{EmptyStringAsNull: true}, "0", "'0'"},
+		{"Zero int is not NULL", CommonArgs{EmptyStringAsNull: true}, int64(0), "0"},
+		{"False is not NULL", CommonArgs{EmptyStringAsNull: true}, false, "0"},
+		{"Zero time uses token", CommonArgs{NullToken: `\N`}, time.Time{}, `\N`},
+		{"Quotes are escaped", CommonArgs{}, "it's", "'it''s'"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := formatSQLValue(tc.value, &tc.args)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+
+	_, err := formatSQLValue([]byte{0x01}, &CommonArgs{})
+	assert.Error(t, err)
+}
+
+func TestBuildDisableKeysStatements(t *testing.T) {
+	testCases := []struct {
+		name                string
+		driver              string
+		engine              string
+		disableUniqueChecks bool
+		expectedPre         []string
+		expectedPost        []string
+	}{
+		{
+			name:         "MySQL MyISAM",
+			driver:       "mysql",
+			engine:       "MyISAM",
+			expectedPre:  []string{"ALTER TABLE `users` DISABLE KEYS;"},
+			expectedPost: []string{"ALTER TABLE `users` ENABLE KEYS;"},
+		},
+		{
+			name:                "MySQL MyISAM ignores unique checks",
+			driver:              "mysql",
+			engine:              "MyISAM",
+			disableUniqueChecks: true,
+			expectedPre:         []string{"ALTER TABLE `users` DISABLE KEYS;"},
+			expectedPost:        []string{"ALTER TABLE `users` ENABLE KEYS;"},
+		},
+		{
+			name:                "MySQL InnoDB with unique checks disabled",
+			driver:              "mysql",
+			engine:              "InnoDB",
+			disableUniqueChecks: true,
+			expectedPre:         []string{"SET UNIQUE_CHECKS=0;", "ALTER TABLE `users` DISABLE KEYS;"},
+			expectedPost:        []string{"ALTER TABLE `users` ENABLE KEYS;", "SET UNIQUE_CHECKS=1;"},
+		},
+		{
+			name:         "PostgreSQL",
+			driver:       "postgres",
+			expectedPre:  []string{"SET session_replication_role = 'replica';"},
+			expectedPost: []string{"SET session_replication_role = 'origin';"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pre, post := buildDisableKeysStatements(tc.driver, "users", tc.engine, tc.disableUniqueChecks)
+			assert.Equal(t, tc.expectedPre, pre)
+			assert.Equal(t, tc.expectedPost, post)
+		})
+	}
+}
+
+func TestBuildDisableFKCheckStatements(t *testing.T) {
+	header, footer := buildDisableFKCheckStatements(db.DriverMySQL, true)
+	assert.Equal(t, []string{"SET FOREIGN_KEY_CHECKS=0;"}, header)
+	assert.Equal(t, []string{"SET FOREIGN_KEY_CHECKS=1;"}, footer)
+
+	header, footer = buildDisableFKCheckStatements(db.DriverPostgres, false)
+	assert.Equal(t, []string{"SET session_replication_role = 'replica';"}, header)
+	assert.Equal(t, []string{"SET session_replication_role = 'origin';"}, footer)
+
+	// --disable-keys already runs the PostgreSQL file in replica mode
+	header, footer = buildDisableFKCheckStatements(db.DriverPostgres, true)
+	assert.Empty(t, header)
+	assert.Empty(t, footer)
+}
+
+func TestUnknownColumns(t *testing.T) {
+	columns := []string{"id", "name", "password_hash"}
+	assert.Equal(t, []string{"api_token"}, unknownColumns(columns, []string{"password_hash", "api_token"}))
+	assert.Empty(t, unknownColumns(columns, []string{"id", "name"}))
+}
+
+func TestAcquireExportLock(t *testing.T) {
+	cmdArgs := &CommonArgs{Path: filepath.Join(t.TempDir(), "backups")}
+
+	first, err := acquireExportLock(cmdArgs)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(cmdArgs.Path, exportLockFileName))
+
+	_, err = acquireExportLock(cmdArgs)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "another export is running")
+
+	require.NoError(t, first.Unlock())
+	second, err := acquireExportLock(cmdArgs)
+	require.NoError(t, err)
+	require.NoError(t, second.Unlock())
+}
+
+func TestStatementWriter(t *testing.T) {
+	statements := []string{"SET FOREIGN_KEY_CHECKS=0;", "INSERT INTO `users` (`id`) VALUES\n(1);", "INSERT INTO `users` (`id`) VALUES\n(2);"}
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+
+	var buf bytes.Buffer
+	out := newStatementWriter(&buf, 16, separator)
+	for _, stmt := range statements {
+		require.NoError(t, out.write(stmt))
+		// Each statement is flushed as soon as it is written
+		assert.True(t, strings.HasSuffix(buf.String(), stmt))
+	}
+	assert.Equal(t, strings.Join(statements, separator), buf.String())
+}
+
+func TestDataFileWriterChunks(t *testing.T) {
+	dir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	out := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "users", tableIndex: 2, chunkSize: 2, bufferSize: 16, separator: separator}
+	require.NoError(t, out.write("ALTER TABLE `users` DISABLE KEYS;", false))
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, out.write(fmt.Sprintf("INSERT INTO `users` (`id`) VALUES\n(%d);", i), true))
+	}
+	require.NoError(t, out.write("ALTER TABLE `users` ENABLE KEYS;", false))
+	require.NoError(t, out.close())
+
+	require.Len(t, out.files, 3)
+	expected := []string{
+		"ALTER TABLE `users` DISABLE KEYS;" + separator + "INSERT INTO `users` (`id`) VALUES\n(1);" + separator + "INSERT INTO `users` (`id`) VALUES\n(2);",
+		"INSERT INTO `users` (`id`) VALUES\n(3);" + separator + "INSERT INTO `users` (`id`) VALUES\n(4);",
+		"INSERT INTO `users` (`id`) VALUES\n(5);" + separator + "ALTER TABLE `users` ENABLE KEYS;",
+	}
+	for i, content := range expected {
+		assert.Equal(t, filepath.Join(dir, fmt.Sprintf("2_users_chunk%d.sql", i+1)), out.files[i])
+		data, err := os.ReadFile(out.files[i])
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+
+	// The banner, header and footer statements wrap every chunk file
+	wrapped := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "items", tableIndex: 4, chunkSize: 1, bufferSize: 16, separator: separator,
+		banner: "-- Generated by syncdb\n", header: []string{"SET FOREIGN_KEY_CHECKS=0;"}, footer: []string{"SET FOREIGN_KEY_CHECKS=1;"}}
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, wrapped.write(fmt.Sprintf("INSERT INTO `items` (`id`) VALUES\n(%d);", i), true))
+	}
+	require.NoError(t, wrapped.close())
+	require.Len(t, wrapped.files, 2)
+	for i, path := range wrapped.files {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("-- Generated by syncdb\nSET FOREIGN_KEY_CHECKS=0;%sINSERT INTO `items` (`id`) VALUES\n(%d);%sSET FOREIGN_KEY_CHECKS=1;", separator, i+1, separator), string(data))
+	}
+
+	// Without a chunk size everything goes to the table's single data file
+	single := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "orders", tableIndex: 3, bufferSize: 16, separator: separator}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, single.write("INSERT INTO `orders` (`id`) VALUES\n(1);", true))
+	}
+	require.NoError(t, single.close())
+	assert.Equal(t, []string{filepath.Join(dir, "3_orders.sql")}, single.files)
+}
+
+func TestDataFileWriterCompress(t *testing.T) {
+	dir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	out := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "users", tableIndex: 2, chunkSize: 1, bufferSize: 16, separator: separator,
+		footer: []string{"SET FOREIGN_KEY_CHECKS=1;"}, compress: true, compressLevel: gzip.BestCompression}
+	for i := 1; i <= 2; i++ {
+		require.NoError(t, out.write(fmt.Sprintf("INSERT INTO `users` (`id`) VALUES\n(%d);", i), true))
+	}
+	require.NoError(t, out.close())
+
+	require.Len(t, out.files, 2)
+	for i, path := range out.files {
+		assert.Equal(t, filepath.Join(dir, fmt.Sprintf("2_users_chunk%d.sql.gz", i+1)), path)
+		data, err := readDataFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("INSERT INTO `users` (`id`) VALUES\n(%d);%sSET FOREIGN_KEY_CHECKS=1;", i+1, separator), string(data))
+
+		// Import finds the compressed files like plain ones
+		table, format, chunk := dataFileTable(filepath.Base(path), map[string]bool{"users": true})
+		assert.Equal(t, "users", table)
+		assert.Equal(t, exportFormatSQL, format)
+		assert.Equal(t, i+1, chunk)
+	}
+
+	removeTableDataFiles(dir, 2, "users", exportFormatSQL)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestDataFileWriterMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	banner := "-- Generated by syncdb\n"
+	statement := func(i int) string {
+		return fmt.Sprintf("INSERT INTO `events` (`id`, `payload`) VALUES\n(%d, '%s');", i, strings.Repeat("x", 40))
+	}
+	// Room for the banner and two INSERT statements per file
+	maxSize := int64(len(banner) + 2*len(statement(1)) + len(separator))
+
+	out := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "events", tableIndex: 1, maxSize: maxSize, bufferSize: 16, separator: separator, banner: banner}
+	for i := 1; i <= 6; i++ {
+		require.NoError(t, out.write(statement(i), true))
+	}
+	require.NoError(t, out.close())
+
+	require.Len(t, out.files, 3)
+	for i, path := range out.files {
+		assert.Equal(t, filepath.Join(dir, fmt.Sprintf("1_events_part%d.sql", i+1)), path)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, banner+statement(2*i+1)+separator+statement(2*i+2), string(data))
+		assert.LessOrEqual(t, int64(len(data)), maxSize)
+	}
+
+	// The part files are imported in order as chunks of the table
+	table, _, chunk := dataFileTable(filepath.Base(out.files[2]), map[string]bool{"events": true})
+	assert.Equal(t, "events", table)
+	assert.Equal(t, 3, chunk)
+
+	// A statement larger than the limit still gets a file of its own
+	large := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "blobs", tableIndex: 2, maxSize: 10, bufferSize: 16, separator: separator}
+	require.NoError(t, large.write(statement(1), true))
+	require.NoError(t, large.write(statement(2), true))
+	require.NoError(t, large.close())
+	assert.Len(t, large.files, 2)
+
+	// With a chunk size as well, the files keep their chunk names
+	both := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "logs", tableIndex: 3, chunkSize: 5, maxSize: 10, bufferSize: 16, separator: separator}
+	require.NoError(t, both.write(statement(1), true))
+	require.NoError(t, both.write(statement(2), true))
+	require.NoError(t, both.close())
+	assert.Equal(t, []string{filepath.Join(dir, "3_logs_chunk1.sql"), filepath.Join(dir, "3_logs_chunk2.sql")}, both.files)
+}
+
+func TestFollowForeignKeys(t *testing.T) {
+	// order_items -> orders -> customers -> regions, and order_items -> products
+	graph := map[string][]string{
+		"order_items": {"orders", "products"},
+		"orders":      {"customers"},
+		"customers":   {"regions"},
+		"products":    {"order_items"},
+	}
+	dependencies := func(table string) ([]string, error) { return graph[table], nil }
+
+	added, err := followForeignKeys([]string{"order_items"}, 0, dependencies)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "products", "customers", "regions"}, added)
+
+	added, err = followForeignKeys([]string{"order_items"}, 1, dependencies)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders", "products"}, added)
+
+	added, err = followForeignKeys([]string{"orders"}, 2, dependencies)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customers", "regions"}, added)
+
+	added, err = followForeignKeys([]string{"regions"}, 0, dependencies)
+	require.NoError(t, err)
+	assert.Empty(t, added)
+
+	_, err = followForeignKeys([]string{"orders"}, 0, func(string) ([]string, error) { return nil, errors.New("boom") })
+	assert.Error(t, err)
+}
+
+func TestFilterTablesByRowCount(t *testing.T) {
+	counts := map[string]int64{"countries": 250, "users": 12000, "events": 5000000, "settings": 0}
+	tables := []string{"countries", "users", "events", "settings"}
+	rowCount := func(table string) (int64, error) { return counts[table], nil }
+
+	kept, excluded, err := filterTablesByRowCount(tables, 0, 100000, rowCount)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"countries", "users", "settings"}, kept)
+	assert.Equal(t, []string{"events"}, excluded)
+
+	kept, excluded, err = filterTablesByRowCount(tables, 1, 0, rowCount)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"countries", "users", "events"}, kept)
+	assert.Equal(t, []string{"settings"}, excluded)
+
+	kept, excluded, err = filterTablesByRowCount(tables, 250, 12000, rowCount)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"countries", "users"}, kept, "bounds are inclusive")
+	assert.Equal(t, []string{"events", "settings"}, excluded)
+
+	_, _, err = filterTablesByRowCount(tables, 1, 0, func(string) (int64, error) { return 0, errors.New("boom") })
+	assert.Error(t, err)
+}
+
+func TestAppendViews(t *testing.T) {
+	views := []string{"active_users", "order_totals", "v_report"}
+
+	tables, selected := appendViews([]string{"users", "orders"}, views, nil, []string{"v_*"})
+	assert.Equal(t, []string{"users", "orders", "active_users", "order_totals"}, tables)
+	assert.Equal(t, []string{"active_users", "order_totals"}, selected)
+
+	// With --tables only matching views are added, once
+	tables, selected = appendViews([]string{"order_totals", "orders"}, views, []string{"orders", "order_*"}, nil)
+	assert.Equal(t, []string{"order_totals", "orders"}, tables)
+	assert.Equal(t, []string{"order_totals"}, selected)
+}
+
+func TestExportedRelationships(t *testing.T) {
+	deps := map[string][]string{
+		"orders":      {"users", "products"},
+		"order_items": {"orders"},
+		"users":       {},
+	}
+	// products is not exported, so the relationship is left out
+	relationships := exportedRelationships([]string{"users", "orders", "order_items"}, deps)
+	assert.Equal(t, map[string][]string{
+		"orders":      {"users"},
+		"order_items": {"orders"},
+	}, relationships)
+}
+
+func TestWorkerConnLazyAndHealthCheck(t *testing.T) {
+	var opened []sqlmock.Sqlmock
+	connect := func(config db.ConnectionConfig) (*db.Connection, error) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		opened = append(opened, mock)
+		return &db.Connection{DB: mockDB, Config: config}, nil
+	}
+	wc := &workerConn{config: db.ConnectionConfig{Driver: db.DriverMySQL}, connect: connect}
+
+	// Nothing is opened until the worker picks up a table
+	assert.Empty(t, opened)
+	first, err := wc.get()
+	require.NoError(t, err)
+	again, err := wc.get()
+	require.NoError(t, err)
+	assert.Same(t, first, again)
+	assert.Len(t, opened, 1)
+
+	opened[0].ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	assert.Equal(t, connHealthy, wc.check())
+	assert.Len(t, opened, 1)
+
+	// A failed ping replaces the connection
+	opened[0].ExpectQuery("SELECT 1").WillReturnError(errors.New("connection reset"))
+	opened[0].ExpectClose()
+	assert.Equal(t, connReconnected, wc.check())
+	require.Len(t, opened, 2)
+	replaced, err := wc.get()
+	require.NoError(t, err)
+	assert.NotSame(t, first, replaced)
+	assert.NoError(t, opened[0].ExpectationsWereMet())
+
+	// When reconnecting fails, the next get tries again
+	wc.connect = func(db.ConnectionConfig) (*db.Connection, error) { return nil, errors.New("refused") }
+	opened[1].ExpectQuery("SELECT 1").WillReturnError(errors.New("connection reset"))
+	opened[1].ExpectClose()
+	assert.Equal(t, connUnhealthy, wc.check())
+	_, err = wc.get()
+	assert.Error(t, err)
+	wc.connect = connect
+	_, err = wc.get()
+	require.NoError(t, err)
+	assert.Len(t, opened, 3)
+}
+
+func TestPreExportHookOnWorkerConn(t *testing.T) {
+	var mock sqlmock.Sqlmock
+	wc := newExportWorkerConn(db.ConnectionConfig{Driver: db.DriverMySQL}, &CommonArgs{PreExportSQL: "SET SESSION time_zone = '+00:00';"})
+	wc.connect = func(config db.ConnectionConfig) (*db.Connection, error) {
+		mockDB, m, err := sqlmock.New()
+		require.NoError(t, err)
+		m.ExpectExec(`SET SESSION time_zone = '\+00:00'`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock = m
+		return &db.Connection{DB: mockDB, Config: config}, nil
+	}
+
+	// The session setting is made when the worker opens its connection,
+	// before the data query that depends on it
+	conn, err := wc.get()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT COLUMN_NAME, DATA_TYPE").WithArgs("events").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"}).AddRow("created_at", "timestamp"))
+	mock.ExpectQuery("SELECT `created_at` FROM `events`").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow("2024-01-15 10:30:00"))
+	var buf bytes.Buffer
+	require.NoError(t, db.ExportTableData(conn, "events", &buf, nil, nil, ""))
+	assert.Contains(t, buf.String(), "2024-01-15 10:30:00")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// All queries share the session of the hook
+	assert.Equal(t, 1, conn.DB.Stats().MaxOpenConnections)
+
+	// A failing hook fails the worker's connection
+	wc = newExportWorkerConn(db.ConnectionConfig{Driver: db.DriverMySQL}, &CommonArgs{PreExportSQL: "SET SESSION bogus = 1"})
+	wc.connect = func(config db.ConnectionConfig) (*db.Connection, error) {
+		mockDB, m, err := sqlmock.New()
+		require.NoError(t, err)
+		m.ExpectExec("SET SESSION bogus = 1").WillReturnError(errors.New("unknown variable"))
+		m.ExpectClose()
+		return &db.Connection{DB: mockDB, Config: config}, nil
+	}
+	_, err = wc.get()
+	assert.ErrorContains(t, err, "pre-export hook failed")
+}
+
+func TestExpandTablePatterns(t *testing.T) {
+	tables := []string{"users", "auser", "my_orders", "orders_archive", "order_items"}
+	assert.Equal(t, map[string]bool{"my_orders": true, "orders_archive": true}, expandTablePatterns(tables, []string{"%orders%"}))
+	assert.Equal(t, map[string]bool{"auser": true, "users": true, "order_items": true},
+		expandTablePatterns(tables, []string{"_user", " user* ", "order?items"}))
+	assert.Empty(t, expandTablePatterns(tables, nil))
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+func newGDriveListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gdrive-list",
+		Short: "List the export archives of a database in Google Drive",
+		Long: `List the export archives of a database in a Google Drive folder, named {database}_{yyyymmdd_hhmmss}.zip,
+the most recent first. Import one with import --storage gdrive --path <name>, or by its number with --gdrive-index.
+Examples:
+  syncdb gdrive-list --database mydb --gdrive-credentials creds.json --gdrive-folder <folder-id>`,
+		Args: cobra.NoArgs,
+		RunE: runGDriveList,
+	}
+
+	flags := cmd.Flags()
+	flags.String("database", "", "Database whose exports are listed")
+	flags.String("gdrive-credentials", "", "Google Drive service account credentials file path")
+	flags.String("gdrive-folder", "", "Google Drive folder ID")
+	cmd.MarkFlagRequired("database")
+
+	return cmd
+}
+
+func runGDriveList(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	database, _ := flags.GetString("database")
+	creds, _ := flags.GetString("gdrive-credentials")
+	folder, _ := flags.GetString("gdrive-folder")
+	if creds == "" || folder == "" {
+		return fmt.Errorf("gdrive-credentials and gdrive-folder are required")
+	}
+
+	store, err := storage.NewGoogleDriveStorage(creds, folder)
+	if err != nil {
+		return fmt.Errorf("failed to initialize Google Drive storage: %v", err)
+	}
+	exports, err := listGDriveExports(store, database)
+	if err != nil {
+		return err
+	}
+	return printGDriveExports(os.Stdout, exports)
+}
+
+// listGDriveExports returns the export archives of database in Google Drive,
+// the most recent first.
+func listGDriveExports(store storage.Storage, database string) ([]storage.ExportFile, error) {
+	lister, ok := store.(storage.ExportFileLister)
+	if !ok {
+		return nil, fmt.Errorf("Google Drive storage cannot list exports")
+	}
+	exports, err := lister.ListExportsByDatabase(database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exports in Google Drive: %v", err)
+	}
+	return exports, nil
+}
+
+// selectGDriveExport returns the name of the index-th most recent export
+// archive of database in Google Drive, counting from 1 as gdrive-list does.
+func selectGDriveExport(store storage.Storage, database string, index int) (string, error) {
+	exports, err := listGDriveExports(store, database)
+	if err != nil {
+		return "", err
+	}
+	if len(exports) == 0 {
+		return "", fmt.Errorf("no exports of %s found in Google Drive", database)
+	}
+	if index < 1 || index > len(exports) {
+		return "", fmt.Errorf("gdrive-index %d is out of range: found %d exports of %s", index, len(exports), database)
+	}
+	return exports[index-1].Name, nil
+}
+
+// printGDriveExports writes the export archives as a numbered table.
+func printGDriveExports(w io.Writer, exports []storage.ExportFile) error {
+	if len(exports) == 0 {
+		fmt.Fprintln(w, "No exports found.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tNAME\tCREATED\tSIZE")
+	for i, e := range exports {
+		created := "-"
+		if !e.CreatedTime.IsZero() {
+			created = e.CreatedTime.Local().Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", i+1, e.Name, created, formatSize(e.Size))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExportLister lists fixed export archives.
+type fakeExportLister struct {
+	storage.Storage
+	exports []storage.ExportFile
+}
+
+func (f *fakeExportLister) ListExportsByDatabase(database string) ([]storage.ExportFile, error) {
+	return f.exports, nil
+}
+
+func TestSelectGDriveExport(t *testing.T) {
+	store := &fakeExportLister{exports: []storage.ExportFile{
+		{Name: "mydb_20240301_080000.zip", Size: 2048},
+		{Name: "mydb_20240101_120000.zip", Size: 1024},
+	}}
+
+	name, err := selectGDriveExport(store, "mydb", 1)
+	require.NoError(t, err)
+	assert.Equal(t, "mydb_20240301_080000.zip", name)
+	name, err = selectGDriveExport(store, "mydb", 2)
+	require.NoError(t, err)
+	assert.Equal(t, "mydb_20240101_120000.zip", name)
+	_, err = selectGDriveExport(store, "mydb", 3)
+	assert.EqualError(t, err, "gdrive-index 3 is out of range: found 2 exports of mydb")
+
+	_, err = selectGDriveExport(&fakeExportLister{}, "mydb", 1)
+	assert.EqualError(t, err, "no exports of mydb found in Google Drive")
+}
+
+func TestPrintGDriveExports(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printGDriveExports(&out, []storage.ExportFile{
+		{Name: "mydb_20240301_080000.zip", Size: 2048},
+		{Name: "mydb_20240101_120000.zip", Size: 1536, CreatedTime: time.Date(2024, 1, 1, 12, 0, 5, 0, time.Local)},
+	}))
+	assert.Equal(t, "#  NAME                      CREATED              SIZE\n"+
+		"1  mydb_20240301_080000.zip  -                    2 KB\n"+
+		"2  mydb_20240101_120000.zip  2024-01-01 12:00:05  1.5 KB\n", out.String())
+
+	out.Reset()
+	require.NoError(t, printGDriveExports(&out, nil))
+	assert.Equal(t, "No exports found.\n", out.String())
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"archive/zip"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +10,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/hoangnguyenba/syncdb/pkg/db"
@@ -33,19 +33,14 @@ func getLatestTimestampDir(basePath string, dbName string) (string, error) {
 	var latestTime time.Time
 	var latestDir string
 	prefix := dbName + "_"
-	timestampLayout := "20060102_150405"
 
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
 		}
 		name := entry.Name()
-		if !strings.HasPrefix(name, prefix) {
-			continue
-		}
-		ts := strings.TrimPrefix(name, prefix)
-		dirTime, err := time.Parse(timestampLayout, ts)
-		if err != nil {
+		dirTime, ok := parseExportTimestamp(name, dbName)
+		if !ok {
 			continue
 		}
 		if latestDir == "" || dirTime.After(latestTime) {
@@ -76,7 +71,6 @@ func getLatestZipFile(basePath string, dbName string) (string, error) {
 	var latestTime time.Time
 	var latestZip string
 	prefix := dbName + "_"
-	timestampLayout := "20060102_150405"
 
 	for _, entry := range entries {
 		if entry.IsDir() {
@@ -84,12 +78,11 @@ func getLatestZipFile(basePath string, dbName string) (string, error) {
 		}
 
 		name := entry.Name()
-		if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ".zip") {
+		if detectArchiveFormat(name) == "" {
 			continue
 		}
-		ts := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".zip")
-		fileTime, err := time.Parse(timestampLayout, ts)
-		if err != nil {
+		fileTime, ok := parseExportTimestamp(trimArchiveExtension(name), dbName)
+		if !ok {
 			continue
 		}
 		if latestZip == "" || fileTime.After(latestTime) {
@@ -165,7 +158,96 @@ func unzipFile(zipPath string, destPath string) error {
 	return nil
 }
 
+// downloadRemoteExport downloads an export from remote storage into tempDir
+// and returns its local path. An archive key is downloaded as a single file; any
+// other key is taken as an export directory and all objects below it are
+// downloaded.
+func downloadRemoteExport(store storage.Storage, key, tempDir string) (string, error) {
+	if detectArchiveFormat(key) != "" {
+		fmt.Printf("Downloading %s...\n", key)
+		data, err := store.Download(key)
+		if err != nil {
+			return "", err
+		}
+		tempFile, err := os.CreateTemp(tempDir, "syncdb-download-*"+fileExtension(key))
+		if err != nil {
+			return "", fmt.Errorf("failed to create temporary file: %v", err)
+		}
+		tempFile.Close()
+		if err := os.WriteFile(tempFile.Name(), data, 0644); err != nil {
+			os.Remove(tempFile.Name())
+			return "", fmt.Errorf("failed to write downloaded file: %v", err)
+		}
+		return tempFile.Name(), nil
+	}
+
+	keys, err := store.ListObjects(key)
+	if err != nil {
+		return "", err
+	}
+	localDir, err := os.MkdirTemp(tempDir, "syncdb-download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	downloaded := 0
+	for _, objectKey := range keys {
+		relPath, ok := strings.CutPrefix(objectKey, key+"/")
+		if !ok || relPath == "" || strings.HasSuffix(relPath, "/") {
+			continue
+		}
+		data, err := store.Download(objectKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s: %v", objectKey, err)
+		}
+		localFile := filepath.Join(localDir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(localFile), 0755); err != nil {
+			return "", fmt.Errorf("failed to create directory for %s: %v", localFile, err)
+		}
+		if err := os.WriteFile(localFile, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write downloaded file: %v", err)
+		}
+		downloaded++
+	}
+	if downloaded == 0 {
+		os.RemoveAll(localDir)
+		return "", fmt.Errorf("no files found under %s", key)
+	}
+	fmt.Printf("Downloaded %d files from %s to %s\n", downloaded, key, localDir)
+	return localDir, nil
+}
+
 func getImportPath(cmdArgs *CommonArgs) (string, error) {
+	// If using S3, download the export first
+	if cmdArgs.Storage == "s3" {
+		s3Store := storage.NewS3Storage(cmdArgs.S3Bucket, cmdArgs.S3Region)
+		if s3Store == nil {
+			return "", fmt.Errorf("failed to initialize S3 storage. Please ensure AWS credentials are set (e.g., AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION)")
+		}
+
+		key := strings.TrimSuffix(filepath.ToSlash(cmdArgs.Path), "/")
+		if cmdArgs.S3AutoLatest {
+			if cmdArgs.Database == "" {
+				return "", fmt.Errorf("--s3-auto-latest needs --database to find the exports of a database")
+			}
+			browser, ok := s3Store.(storage.ExportBrowser)
+			if !ok {
+				return "", fmt.Errorf("S3 storage cannot list exports")
+			}
+			latest, err := browser.GetLatestExportPath(key, cmdArgs.Database)
+			if err != nil {
+				return "", fmt.Errorf("failed to find latest export in S3: %v", err)
+			}
+			fmt.Printf("Found latest export: s3://%s/%s\n", cmdArgs.S3Bucket, latest)
+			key = latest
+		}
+
+		localPath, err := downloadRemoteExport(s3Store, key, cmdArgs.TempDir)
+		if err != nil {
+			return "", fmt.Errorf("failed to download export from S3: %v", err)
+		}
+		cmdArgs.Path = localPath
+	}
+
 	// If using Google Drive storage, download the file first
 	if cmdArgs.Storage == "gdrive" {
 		// Initialize Google Drive storage
@@ -176,6 +258,15 @@ func getImportPath(cmdArgs *CommonArgs) (string, error) {
 
 		// Extract file name from path
 		fileName := filepath.Base(cmdArgs.Path)
+		if cmdArgs.GdriveIndex > 0 {
+			if cmdArgs.Database == "" {
+				return "", fmt.Errorf("--gdrive-auto-latest and --gdrive-index need --database to find the exports of a database")
+			}
+			if fileName, err = selectGDriveExport(gdriveStore, cmdArgs.Database, cmdArgs.GdriveIndex); err != nil {
+				return "", err
+			}
+			fmt.Printf("Found export: %s\n", fileName)
+		}
 		fmt.Printf("Downloading %s from Google Drive...\n", fileName)
 
 		// Download file from Google Drive
@@ -185,7 +276,7 @@ func getImportPath(cmdArgs *CommonArgs) (string, error) {
 		}
 
 		// Create a temporary file to store the downloaded content
-		tempFile, err := os.CreateTemp("", "syncdb-gdrive-*"+filepath.Ext(fileName))
+		tempFile, err := os.CreateTemp(cmdArgs.TempDir, "syncdb-gdrive-*"+fileExtension(fileName))
 		if err != nil {
 			return "", fmt.Errorf("failed to create temporary file: %v", err)
 		}
@@ -202,6 +293,43 @@ func getImportPath(cmdArgs *CommonArgs) (string, error) {
 		cmdArgs.Path = tempFile.Name()
 	}
 
+	// If using Google Cloud Storage, download the object first
+	if cmdArgs.Storage == "gcs" {
+		gcsStore, err := storage.NewGCSStorage(cmdArgs.GCSBucket, cmdArgs.GCSProject, cmdArgs.GCSCredentials)
+		if err != nil {
+			return "", fmt.Errorf("failed to initialize GCS storage: %v", err)
+		}
+
+		// Use the given path as the object name, or fall back to the latest zip in the bucket
+		objectName := filepath.ToSlash(cmdArgs.Path)
+		if objectName == "" {
+			objectName, err = gcsStore.GetLatestZipFile()
+			if err != nil {
+				return "", fmt.Errorf("failed to find latest export in GCS: %v", err)
+			}
+		}
+		fmt.Printf("Downloading gs://%s/%s...\n", cmdArgs.GCSBucket, objectName)
+
+		data, err := gcsStore.Download(objectName)
+		if err != nil {
+			return "", fmt.Errorf("failed to download file from GCS: %v", err)
+		}
+
+		tempFile, err := os.CreateTemp(cmdArgs.TempDir, "syncdb-gcs-*"+fileExtension(objectName))
+		if err != nil {
+			return "", fmt.Errorf("failed to create temporary file: %v", err)
+		}
+		tempFile.Close()
+
+		if err := os.WriteFile(tempFile.Name(), data, 0644); err != nil {
+			os.Remove(tempFile.Name())
+			return "", fmt.Errorf("failed to write downloaded file: %v", err)
+		}
+
+		fmt.Printf("Successfully downloaded %s to %s\n", objectName, tempFile.Name())
+		cmdArgs.Path = tempFile.Name()
+	}
+
 	// Continue with existing logic for local files
 	// If path is a directory and contains metadata file, use it directly
 	if storage.IsExportPath(cmdArgs.Path) {
@@ -209,6 +337,12 @@ func getImportPath(cmdArgs *CommonArgs) (string, error) {
 		return cmdArgs.Path, nil
 	}
 
+	// Exports are looked up by the name of the exported database, which is
+	// unknown when only --target-database is set
+	if cmdArgs.Database == "" && detectArchiveFormat(cmdArgs.Path) == "" {
+		return "", fmt.Errorf("%s is not an export directory or archive; set --path to the export to import, or --database to the exported database to find its latest export", cmdArgs.Path)
+	}
+
 	// If path doesn't exist or is a directory without metadata, look for latest timestamp dir
 	stat, err := os.Stat(cmdArgs.Path)
 	if err == nil && stat.IsDir() {
@@ -222,8 +356,8 @@ func getImportPath(cmdArgs *CommonArgs) (string, error) {
 		return importPath, nil
 	}
 
-	// If path doesn't exist or is not a directory, assume it's a zip file
-	if strings.HasSuffix(cmdArgs.Path, ".zip") {
+	// If path doesn't exist or is not a directory, assume it's an archive
+	if detectArchiveFormat(cmdArgs.Path) != "" {
 		return cmdArgs.Path, nil
 	}
 
@@ -236,6 +370,27 @@ func getImportPath(cmdArgs *CommonArgs) (string, error) {
 	return zipPath, nil
 }
 
+// validateTempDir checks that dir, if set, is an existing directory we can write to.
+func validateTempDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("invalid temp-dir %s: %v", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid temp-dir %s: not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, ".syncdb-write-test-*")
+	if err != nil {
+		return fmt.Errorf("temp-dir %s is not writable: %v", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
 func newImportCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "import",
@@ -243,331 +398,1367 @@ func newImportCommand() *cobra.Command {
 		Long: `Import database schema and/or data from files.
 Examples:
   syncdb import --path ./backup/mydb_20240101 --host localhost --database targetdb
-  syncdb import --path backup.zip --driver mysql --database targetdb --include-schema`,
-		RunE: func(cmd *cobra.Command, args []string) error {
-			cmdArgs, _, conn, err := loadAndValidateArgs(cmd)
-			if err != nil {
-				return err // Error already formatted by loadAndValidateArgs
-			}
-			defer conn.Close() // Ensure connection is closed
+  syncdb import --path backup.zip --driver mysql --database targetdb --include-schema
+  syncdb import --path ./backup/mydb_20240101 --database targetdb --schema-only`,
+		RunE: runImport, // Use the named function
+	}
 
-			importPath, err := getImportPath(cmdArgs)
-			if err != nil {
-				return err
-			}
+	// Add shared flags
+	AddSharedFlags(cmd, true) // Pass true for import command
 
-			// If path is a zip file, extract it to a temp directory
-			if strings.HasSuffix(importPath, ".zip") {
-				// Create temp directory for import
-				importDir := filepath.Join(os.TempDir(), "syncdb-import-"+time.Now().Format("20060102150405"))
-				err := os.MkdirAll(importDir, 0755)
-				if err != nil {
-					return err
-				}
-				defer os.RemoveAll(importDir) // Clean up temp directory when done
+	// Add import-specific flags
+	flags := cmd.Flags()
+	flags.Bool("truncate", false, "Truncate tables before import")
+	flags.Bool("drop", false, "Drop and recreate database before import")
+	flags.String("query-separator", "\n--SYNCDB_QUERY_SEPARATOR--\n", "String used to separate SQL queries in import file")
+	flags.String("pre-import-sql", "", "SQL to run before any schema or data changes")
+	flags.String("post-import-sql", "", "SQL to run after all tables are imported")
+	flags.String("pre-import-sql-file", "", "File containing SQL to run before any schema or data changes")
+	flags.String("post-import-sql-file", "", "File containing SQL to run after all tables are imported")
+	flags.String("temp-dir", "", "Directory for extracting archives and downloads (default: system temp directory)")
+	flags.Bool("keep-temp", false, "Keep extracted files in the temp directory after import (for debugging)")
+	flags.Bool("empty-string-as-null", false, "Import empty string values ('') as NULL")
+	flags.String("from-catalog", "", "Import the last export of this database recorded in the catalog when --path is not set")
+	flags.Bool("s3-auto-latest", false, "With --storage s3, import the most recent export of the database found under --path")
+	flags.Bool("gdrive-auto-latest", false, "With --storage gdrive, import the most recent {database}_{timestamp}.zip export in the folder instead of --path")
+	flags.Int("gdrive-index", 0, "With --storage gdrive, import the N-th most recent export of the database in the folder, as numbered by gdrive-list")
+	flags.Bool("auto-migrate", false, "Adapt data to the target table: skip columns it no longer has and fill new columns with their default")
+	flags.String("type-coerce", "", "YAML file of rules rewriting values for the column types of the target tables, or a built-in preset (mysql-to-postgres, postgres-to-mysql)")
+	flags.Bool("verify-checksums", false, "Verify every file against the export's checksum manifest before importing")
+	flags.String("schema-version", "", "Schema version the export is expected to have, see --require-schema-version")
+	flags.Bool("require-schema-version", false, "Abort unless the export's schema version matches --schema-version")
+	flags.Bool("verify-row-checksums", false, "Verify the CRC32 of each row written by export --row-checksum")
+	flags.String("target-database", "", "Database to import into, when it differs from the exported database (--database then selects the export to import)")
+	flags.Bool("create-db", false, "Create the target database if it does not exist")
+	flags.String("admin-user", "", "User that creates the database with --create-db (default: the connection user)")
+	flags.String("admin-password", "", "Password of --admin-user")
+	flags.Bool("defer-indexes", false, "Create the indexes of 0_indexes.sql after all data files are imported instead of right after the schema")
+	flags.String("on-duplicate", onDuplicateSkip, "Which export a table found in several --path exports is imported from: skip (the first) or overwrite (the last)")
+	flags.String("schema-conflict", schemaConflictError, "What to do with schema tables that already exist: error, skip (CREATE TABLE IF NOT EXISTS) or replace (drop and recreate them, deleting their rows)")
+	flags.Bool("schema-only", false, "Import only the schema (tables, indexes and views) and no data files, e.g. to promote DDL in CI pipelines")
+	flags.Bool("merge-schema", false, "With several --path exports, also create the tables missing from the first export's schema from the other exports")
+	flags.Int("workers", 1, "Number of tables imported in parallel, each on its own connection (1-64); a table starts once the tables it references are imported. Overrides SYNCDB_IMPORT_WORKERS and the profile's workers")
+	flags.String("pk-min", "", "Only import rows whose primary key is at least this value, e.g. 1000 or 2024-01-01 (tables need a single-column primary key)")
+	flags.String("pk-max", "", "Only import rows whose primary key is at most this value")
+	flags.Bool("skip-existing", false, "Skip rows whose primary key already exists in the target table (slower, but safe to re-run)")
+	flags.Bool("resume", false, "Continue an interrupted import of the same --path: skip the tables recorded as imported in its .syncdb_import_checkpoint.json and continue partly imported tables after their last committed chunk")
+	flags.Bool("continue-on-error", false, "Keep importing when a chunk fails; failed chunks are saved to {table}_errors.sql and the command exits with code 2")
+	flags.Bool("post-import-on-error", true, "Run the post-import hook even when the import fails")
+	flags.Int("deadlock-retry-count", db.DefaultDeadlockRetryCount, "Number of times a chunk is retried when its transaction is aborted by a deadlock (0 = no retry)")
+	flags.Duration("deadlock-retry-delay", db.DefaultDeadlockRetryDelay, "Base delay before retrying a deadlocked chunk, randomized by ±50%")
+	flags.String("charset", "", "MySQL default character set of the imported tables and of the database recreated with --drop (e.g. utf8mb4)")
+	flags.String("collation", "", "MySQL default collation of the imported tables and of the database recreated with --drop (e.g. utf8mb4_unicode_ci)")
+	flags.String("encoding", "", "PostgreSQL encoding of the database recreated with --drop (e.g. UTF8)")
+	flags.String("import-tx-scope", txScopeChunk, "Data imported per transaction: chunk (one transaction per chunk), table (one per table) or all (one for the whole import)")
+	flags.Int("import-tx-size", 0, "Maximum number of INSERT statements per transaction; larger chunks are split into several transactions (0 = one transaction per chunk)")
+	flags.String("tx-isolation", "", "Transaction isolation level for data import (read-uncommitted, read-committed, repeatable-read, serializable)")
 
-				fmt.Printf("Unzipping file to: %s\n", importDir)
-				if err := unzipFile(importPath, importDir); err != nil {
-					return err
-				}
+	return cmd
+}
 
-				// Find the metadata file
-				var metadataDir string
-				err = filepath.Walk(importDir, func(path string, info os.FileInfo, err error) error {
-					if err != nil {
-						return err
+// runImport is the main execution function for the import command.
+func runImport(cmd *cobra.Command, args []string) (err error) {
+	start := time.Now()
+	cmdArgs, _, conn, err := loadAndValidateArgs(cmd)
+	if err != nil {
+		return err // Error already formatted by loadAndValidateArgs
+	}
+	defer conn.Close() // Ensure connection is closed
+
+	var tablesToImport []string
+	result := &ImportResult{}
+	defer func() { sendWebhook(cmdArgs, "import", start, len(tablesToImport), result.RowsImported, err) }()
+
+	if err := loadHookSQLFile(cmd, "pre-import-sql", "pre-import-sql-file", &cmdArgs.PreImportSQL); err != nil {
+		return err
+	}
+	if err := loadHookSQLFile(cmd, "post-import-sql", "post-import-sql-file", &cmdArgs.PostImportSQL); err != nil {
+		return err
+	}
+	if err := validateTempDir(cmdArgs.TempDir); err != nil {
+		return err
+	}
+
+	// Fall back to the last export recorded in the catalog
+	if catalogName, _ := cmd.Flags().GetString("from-catalog"); catalogName != "" && cmdArgs.Path == "" {
+		entry, err := findCatalogEntry(catalogName)
+		if err != nil {
+			return err
+		}
+		if entry.Storage != cmdArgs.Storage {
+			return fmt.Errorf("the last export of %s is in %s storage, use --storage %s", entry.Key(), entry.Storage, entry.Storage)
+		}
+		fmt.Printf("Using the last export of %s from the catalog: %s\n", entry.Key(), entry.Path)
+		cmdArgs.Path = entry.Path
+	}
+
+	// Every export is located, extracted and validated before anything is imported
+	paths := cmdArgs.Paths
+	if len(paths) == 0 {
+		paths = []string{cmdArgs.Path}
+	}
+	if len(paths) > 1 && (cmdArgs.FromTableIndex > 0 || cmdArgs.FromChunkIndex > 0) {
+		return fmt.Errorf("--from-table-index and --from-chunk-index cannot be used when importing from several paths")
+	}
+	var sources []*importSource
+	for _, path := range paths {
+		cmdArgs.Path = path
+		src, cleanup, err := openImportSource(cmd, cmdArgs, conn.Config.Driver)
+		if cleanup != nil {
+			defer cleanup()
+		}
+		if err != nil {
+			return err
+		}
+		src.tables = selectImportTables(src.metadata.Metadata.Tables, cmdArgs.Tables)
+		sources = append(sources, src)
+	}
+	sortImportPages(sources)
+
+	tablesToImport = planImportSources(sources, cmdArgs.OnDuplicate, cmdArgs.MergeSchema)
+	if len(tablesToImport) == 0 {
+		return fmt.Errorf("no tables to import after applying table filter")
+	}
+
+	if cmdArgs.TargetDatabase != "" {
+		fmt.Printf("Importing export of %s into database %s\n", sources[0].metadata.Metadata.DatabaseName, cmdArgs.TargetDatabase)
+	}
+	fmt.Printf("Tables to import: %v\n", tablesToImport)
+	if cmdArgs.SchemaOnly {
+		if err := checkSchemaOnlySources(sources); err != nil {
+			return err
+		}
+		if !cmdArgs.Drop {
+			if err := checkSchemaConflicts(conn, sources, cmdArgs.SchemaConflict); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Run the pre-import hook, import schema and data, then run the post-import hook
+	return runWithHooks(
+		func() error { return executeHookSQL(conn, "pre-import", cmdArgs.PreImportSQL) },
+		func() error {
+			if err := importTables(conn, cmdArgs, sources, result); err != nil {
+				return err
+			}
+			if cmdArgs.DeferIndexes && cmdArgs.IncludeSchema {
+				for _, src := range sources {
+					if len(src.schemaTables) == 0 {
+						continue
 					}
-					if !info.IsDir() && strings.HasSuffix(path, "0_metadata.json") {
-						metadataDir = filepath.Dir(path)
-						return filepath.SkipAll
+					if err := importIndexes(conn, src.path, src.schemaTables); err != nil {
+						return err
 					}
-					return nil
-				})
-				if err != nil {
-					return fmt.Errorf("failed to find metadata file: %v", err)
 				}
+			}
+			return nil
+		},
+		func() error { return executeHookSQL(conn, "post-import", cmdArgs.PostImportSQL) },
+		cmdArgs.PostImportOnError,
+	)
+}
 
-				if metadataDir == "" {
-					return fmt.Errorf("no metadata file found in zip file")
-				}
+// Strategies for tables found in several exports, set by --on-duplicate
+const (
+	onDuplicateSkip      = "skip"
+	onDuplicateOverwrite = "overwrite"
+)
 
-				importPath = metadataDir
-			}
+// Data imported per transaction, set by --import-tx-scope
+const (
+	txScopeChunk = "chunk"
+	txScopeTable = "table"
+	txScopeAll   = "all"
+)
 
-			if !storage.IsExportPath(importPath) {
-				return fmt.Errorf("invalid import path: %s (no metadata file found)", importPath)
-			}
+// importSource is an export read by an import. An import reads several
+// exports when --path lists more than one.
+type importSource struct {
+	path     string // Export directory, extracted from its archive if needed
+	format   string // Format of the schema file
+	metadata *ExportData
+	tables   []string // Tables selected with --tables
+	// Tables whose schema and data are imported from this export
+	schemaTables   []string
+	dataTables     []string
+	checkpoint     *importCheckpoint // Progress of the data import, nil if not recorded
+	checkpointPath string            // Checkpoint file of the import (see importCheckpointPath)
+	appendData     bool              // The data adds to the rows of an earlier page of a paginated export
+}
+
+// openImportCheckpoint returns the checkpoint recording the data import of
+// tables in the checkpoint file at path: the one left by an interrupted
+// import with --resume, a new one otherwise. With --import-tx-scope all
+// nothing is recorded, as the import is rolled back as a whole, and it
+// returns nil.
+func openImportCheckpoint(cmdArgs *CommonArgs, path string, tables []string) (*importCheckpoint, error) {
+	if cmdArgs.TxScope == txScopeAll {
+		if cmdArgs.Resume {
+			fmt.Fprintln(os.Stderr, "Warning: --resume has no effect with --import-tx-scope all")
+		}
+		return nil, nil
+	}
+	checkpoint := &importCheckpoint{path: path}
+	if cmdArgs.Resume {
+		var err error
+		if checkpoint, err = loadImportCheckpoint(path); err != nil {
+			return nil, err
+		}
+		if len(checkpoint.CompletedTables) == 0 && len(checkpoint.Chunks) == 0 {
+			fmt.Fprintf(os.Stderr, "No checkpoint found at %s, importing all tables\n", path)
+		}
+	}
+	checkpoint.Tables = tables
+	return checkpoint, nil
+}
+
+// openImportSource finds the export at cmdArgs.Path, downloading and
+// extracting it if needed, and reads its metadata. The returned cleanup
+// function, if not nil, removes the extracted files.
+func openImportSource(cmd *cobra.Command, cmdArgs *CommonArgs, driver string) (*importSource, func(), error) {
+	// Remote exports are downloaded to a new temporary path on each run, so
+	// there is no checkpoint to resume from
+	remote := cmdArgs.Storage == "s3" || cmdArgs.Storage == "gdrive" || cmdArgs.Storage == "gcs"
+	if remote && cmdArgs.Resume {
+		return nil, nil, fmt.Errorf("--resume cannot be used with --storage %s: download the export and import it from a local path", cmdArgs.Storage)
+	}
+	importPath, err := getImportPath(cmdArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	checkpointPath := importCheckpointPath(importPath)
 
-			// Read metadata file
-			metadataFile := filepath.Join(importPath, "0_metadata.json")
-			metadataBytes, err := os.ReadFile(metadataFile)
+	// If path is an archive, extract it to a temp directory
+	var cleanup func()
+	if detectArchiveFormat(importPath) != "" {
+		// Create temp directory for import (an empty TempDir falls back to os.TempDir())
+		importDir, err := os.MkdirTemp(cmdArgs.TempDir, "syncdb-import-*")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create temp directory: %v", err)
+		}
+		if cmdArgs.KeepTemp {
+			cleanup = func() { fmt.Printf("Keeping extracted files in: %s\n", importDir) }
+		} else {
+			cleanup = func() { os.RemoveAll(importDir) } // Clean up temp directory when done
+		}
+
+		fmt.Printf("Extracting archive to: %s\n", importDir)
+		if err := extractArchive(importPath, importDir); err != nil {
+			return nil, cleanup, err
+		}
+
+		// Find the metadata file
+		var metadataDir string
+		err = filepath.Walk(importDir, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
-				return fmt.Errorf("failed to read metadata file: %v", err)
+				return err
 			}
-
-			// Parse metadata
-			var metadata ExportData
-			if err := json.Unmarshal(metadataBytes, &metadata.Metadata); err != nil {
-				return fmt.Errorf("failed to parse metadata: %v", err)
+			if !info.IsDir() && storage.IsMetadataFile(info.Name()) {
+				metadataDir = filepath.Dir(path)
+				return filepath.SkipAll
 			}
+			return nil
+		})
+		if err != nil {
+			return nil, cleanup, fmt.Errorf("failed to find metadata file: %v", err)
+		}
 
-			// Filter tables based on --tables parameter
-			var tablesToImport []string
-			if len(cmdArgs.Tables) > 0 {
-				availableTables := make(map[string]bool)
-				for _, table := range metadata.Metadata.Tables {
-					availableTables[table] = true
-				}
+		if metadataDir == "" {
+			return nil, cleanup, fmt.Errorf("no metadata file found in archive")
+		}
 
-				// Expand table patterns
-				for _, pattern := range cmdArgs.Tables {
-					pattern = strings.TrimSpace(pattern)
-					for table := range availableTables {
-						if db.TablePatternMatch(table, pattern) {
-							tablesToImport = append(tablesToImport, table)
-						}
-					}
-				}
-				// Sort the tables for consistent order
-				sort.Strings(tablesToImport)
-			} else {
-				tablesToImport = metadata.Metadata.Tables
-			}
+		importPath = metadataDir
+		if remote {
+			checkpointPath = importCheckpointPath(importPath)
+		}
+	}
 
-			if len(tablesToImport) == 0 {
-				return fmt.Errorf("no tables to import after applying table filter")
-			}
+	if !storage.IsExportPath(importPath) {
+		return nil, cleanup, fmt.Errorf("invalid import path: %s (no metadata file found)", importPath)
+	}
+	if cmdArgs.VerifyChecksums {
+		if err := verifyChecksumManifest(importPath); err != nil {
+			return nil, cleanup, err
+		}
+	}
 
-			fmt.Printf("Tables to import: %v\n", tablesToImport)
+	// Read metadata file
+	var metadata ExportData
+	if metadata.Metadata, err = readMetadataFile(importPath); err != nil {
+		return nil, cleanup, err
+	}
+	format, err := resolveImportFormat(cmd, metadata.Metadata.Format)
+	if err != nil {
+		return nil, cleanup, err
+	}
+	if err := validateImportInsertMode(metadata.Metadata.InsertMode, driver); err != nil {
+		return nil, cleanup, err
+	}
+	if cmdArgs.RequireSchemaVersion {
+		if err := checkSchemaVersion(cmdArgs.SchemaVersion, metadata.Metadata.SchemaVersion); err != nil {
+			return nil, cleanup, fmt.Errorf("%s: %w", importPath, err)
+		}
+	}
+	if metadata.Metadata.SampleRate > 0 {
+		fmt.Printf("Warning: this export is a %g%% sample of each table (--sample-rate %g), so the imported data is partial\n",
+			metadata.Metadata.SampleRate*100, metadata.Metadata.SampleRate)
+	}
 
-			// Read schema file first to get SQL mode if it exists
-			var sqlMode string
-			if metadata.Metadata.Schema && cmdArgs.IncludeSchema {
-				schemaFile := filepath.Join(importPath, "0_schema.sql")
-				schemaData, err := os.ReadFile(schemaFile)
-				if err != nil {
-					return fmt.Errorf("failed to read schema file: %v", err)
-				}
+	return &importSource{path: importPath, format: format, metadata: &metadata, checkpointPath: checkpointPath}, cleanup, nil
+}
 
-				// Extract SQL mode from schema file if it exists
-				lines := strings.Split(string(schemaData), "\n")
-				for _, line := range lines {
-					line = strings.TrimSpace(line)
-					if strings.HasPrefix(line, "-- SQL_MODE=") {
-						sqlMode = strings.TrimPrefix(line, "-- SQL_MODE=")
-						break
-					}
-				}
-			}
+// selectImportTables returns the exported tables matching the --tables
+// patterns, sorted for a consistent order, or all exported tables.
+func selectImportTables(exported, patterns []string) []string {
+	if len(patterns) == 0 {
+		return exported
+	}
 
-			// Handle drop and recreate database if requested
-			if cmdArgs.Drop {
-				fmt.Println("Dropping and recreating database...")
-				if err := db.DropDatabase(conn); err != nil {
-					return fmt.Errorf("failed to drop database: %v", err)
-				}
-				if err := db.CreateDatabase(conn); err != nil {
-					return fmt.Errorf("failed to create database: %v", err)
-				}
+	availableTables := make(map[string]bool)
+	for _, table := range exported {
+		availableTables[table] = true
+	}
 
-				// Set SQL mode if it was found in the schema file
-				if conn.Config.Driver == "mysql" {
-					setModeSQL := fmt.Sprintf("SET GLOBAL sql_mode = '%s'", strings.TrimSpace(sqlMode))
-					_, err := conn.DB.Exec(setModeSQL)
-					if err != nil {
-						return fmt.Errorf("failed to set global SQL mode to '%s': %v", sqlMode, err)
-					}
-					fmt.Printf("Set global SQL mode to: %s\n", sqlMode)
-				}
+	// Expand table patterns
+	var tables []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		for table := range availableTables {
+			if db.TablePatternMatch(table, pattern) {
+				tables = append(tables, table)
 			}
+		}
+	}
+	// Sort the tables for consistent order
+	sort.Strings(tables)
+	return tables
+}
 
-			// Import schema if included and requested
-			if metadata.Metadata.Schema && cmdArgs.IncludeSchema {
-				fmt.Println("Importing schema...")
-				schemaFile := filepath.Join(importPath, "0_schema.sql")
-				schemaData, err := os.ReadFile(schemaFile)
-				if err != nil {
-					return fmt.Errorf("failed to read schema file: %v", err)
-				}
+// isExportPage reports whether src is a page of a paginated export, written
+// with --page-number.
+func isExportPage(src *importSource) bool {
+	return src.metadata.Metadata.PageNumber > 0
+}
 
-				// Filter schema content to only include selected tables
-				if len(cmdArgs.Tables) > 0 {
-					schemaData = filterSchemaContent(schemaData, tablesToImport)
-				}
+// sortImportPages sorts sources in page order when they are all pages of a
+// paginated export.
+func sortImportPages(sources []*importSource) {
+	for _, src := range sources {
+		if !isExportPage(src) {
+			return
+		}
+	}
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].metadata.Metadata.PageNumber < sources[j].metadata.Metadata.PageNumber
+	})
+}
 
-				if err := importSchema(conn, schemaData); err != nil {
-					return fmt.Errorf("failed to execute schema: %v", err)
+// planImportSources decides which export the schema and the data of each
+// table are imported from, and returns the tables of all exports. The data of
+// a table found in several exports comes from the first export with data, or
+// the last one with --on-duplicate overwrite, except for the pages of a
+// paginated export, which each hold part of the rows and are all imported.
+// Only the first export with a schema creates tables, unless --merge-schema
+// also creates the tables it lacks from the other exports.
+func planImportSources(sources []*importSource, onDuplicate string, mergeSchema bool) []string {
+	var tables []string
+	seen := make(map[string]bool)
+	schemaOwner := make(map[string]*importSource)
+	dataOwner := make(map[string]*importSource)
+	var schemaSource *importSource
+	for _, src := range sources {
+		if src.metadata.Metadata.Schema && schemaSource == nil {
+			schemaSource = src
+		}
+		for _, table := range src.tables {
+			if !seen[table] {
+				seen[table] = true
+				tables = append(tables, table)
+			}
+			if src.metadata.Metadata.Schema && (src == schemaSource || mergeSchema) &&
+				(schemaOwner[table] == nil || (mergeSchema && onDuplicate == onDuplicateOverwrite)) {
+				schemaOwner[table] = src
+			}
+			if src.metadata.Metadata.IncludeData && isExportPage(src) && dataOwner[table] != nil && isExportPage(dataOwner[table]) {
+				continue // Every page holds part of the rows, see below
+			}
+			if src.metadata.Metadata.IncludeData && (dataOwner[table] == nil || onDuplicate == onDuplicateOverwrite) {
+				if dataOwner[table] != nil {
+					fmt.Printf("Table %s is in %s and %s, importing its data from %s\n", table, dataOwner[table].path, src.path, src.path)
 				}
+				dataOwner[table] = src
+			} else if src.metadata.Metadata.IncludeData {
+				fmt.Printf("Table %s is in %s and %s, skipping its data in %s\n", table, dataOwner[table].path, src.path, src.path)
 			}
+		}
+	}
 
-			// Skip data import if not included in export or not requested
-			if !metadata.Metadata.IncludeData || !cmdArgs.IncludeData {
-				fmt.Println("Skipping data import as requested")
-				return nil
+	for _, src := range sources {
+		src.schemaTables, src.dataTables = nil, nil
+		for _, table := range src.tables {
+			if schemaOwner[table] == src {
+				src.schemaTables = append(src.schemaTables, table)
 			}
-
-			// Import data
-			fmt.Println("Importing data...")
-
-			// Create a map of available tables from metadata
-			availableTables := make(map[string]bool)
-			for _, table := range metadata.Metadata.Tables {
-				availableTables[table] = true
+			owner := dataOwner[table]
+			if owner == src {
+				src.dataTables = append(src.dataTables, table)
+			} else if src.metadata.Metadata.IncludeData && isExportPage(src) && owner != nil && isExportPage(owner) {
+				src.dataTables = append(src.dataTables, table)
+				src.appendData = true
 			}
+		}
+	}
+	return tables
+}
 
-			// Prepare file list based on metadata table order
-			fileList := make([]string, 0)
-			tableFileMap := make(map[string]string)
-			skippedFiles := make([]string, 0)
+// validateImportInsertMode checks that the statements written for the export's
+// insert mode can be executed by the target driver.
+func validateImportInsertMode(insertMode, driver string) error {
+	if insertMode == insertModeReplace && driver == db.DriverPostgres {
+		return fmt.Errorf("this export was written with REPLACE INTO statements, which PostgreSQL does not support; re-export with --insert-mode insert-ignore or upsert")
+	}
+	return nil
+}
 
-			// Read directory entries
-			entries, err := os.ReadDir(importPath)
+// importTables drops/recreates the database if requested, then imports the schema
+// and data files of the tables planned for each export.
+func importTables(conn *db.Connection, cmdArgs *CommonArgs, sources []*importSource, result *ImportResult) error {
+	// Read schema file first to get SQL mode if it exists
+	var sqlMode string
+	if cmdArgs.IncludeSchema {
+		for _, src := range sources {
+			if len(src.schemaTables) == 0 {
+				continue
+			}
+			schemaData, err := readSchemaSQL(src.path, src.format, src.metadata.Metadata.Tables)
 			if err != nil {
-				return fmt.Errorf("failed to read import directory: %v", err)
+				return err
 			}
 
-			// Create file mapping
-			for _, entry := range entries {
-				if entry.IsDir() {
-					continue
+			// Extract SQL mode from schema file if it exists
+			lines := strings.Split(string(schemaData), "\n")
+			for _, line := range lines {
+				line = strings.TrimSpace(line)
+				if strings.HasPrefix(line, "-- SQL_MODE=") {
+					sqlMode = strings.TrimPrefix(line, "-- SQL_MODE=")
+					break
 				}
+			}
+			break
+		}
+	}
 
-				fileName := entry.Name()
-				if fileName == "0_schema.sql" || fileName == "0_metadata.json" {
-					continue // Skip schema and metadata files
-				}
+	// Handle drop and recreate database if requested
+	if cmdArgs.Drop {
+		fmt.Println("Dropping and recreating database...")
+		if err := db.DropDatabase(conn); err != nil {
+			return fmt.Errorf("failed to drop database: %v", err)
+		}
+		if err := db.CreateDatabase(conn, databaseOptions(cmdArgs)); err != nil {
+			return fmt.Errorf("failed to create database: %v", err)
+		}
 
-				tableName := extractTableNameFromFile(fileName)
-				if !validateTableName(tableName, availableTables) {
-					skippedFiles = append(skippedFiles, fileName)
-					continue
-				}
+		// Set SQL mode if it was found in the schema file
+		if conn.Config.Driver == "mysql" {
+			setModeSQL := fmt.Sprintf("SET GLOBAL sql_mode = '%s'", strings.TrimSpace(sqlMode))
+			_, err := conn.DB.Exec(setModeSQL)
+			if err != nil {
+				return fmt.Errorf("failed to set global SQL mode to '%s': %v", sqlMode, err)
+			}
+			fmt.Printf("Set global SQL mode to: %s\n", sqlMode)
+		}
+	}
 
-				// Check if this table should be imported based on user-specified tables
-				if len(tablesToImport) > 0 {
-					found := false
-					for _, t := range tablesToImport {
-						if t == tableName {
-							found = true
-							break
-						}
-					}
-					if !found {
-						skippedFiles = append(skippedFiles, fileName)
-						continue
-					}
-				}
+	// Make sure a non-public PostgreSQL schema exists before importing into it
+	if err := db.EnsureSchema(conn); err != nil {
+		return err
+	}
 
-				fmt.Printf("Found data file for table '%s': %s\n", tableName, fileName)
-				tableFileMap[tableName] = fileName
+	// Import schema if included and requested
+	if cmdArgs.IncludeSchema {
+		for _, src := range sources {
+			if len(src.schemaTables) == 0 {
+				continue
 			}
-
-			if len(skippedFiles) > 0 {
-				fmt.Printf("Skipped %d files:\n", len(skippedFiles))
-				for _, file := range skippedFiles {
-					fmt.Printf("  - %s\n", file)
-				}
+			fmt.Println("Importing schema...")
+			schemaData, err := readSchemaSQL(src.path, src.format, src.metadata.Metadata.Tables)
+			if err != nil {
+				return err
 			}
 
-			// Reorder fileList based on metadata table order
-			for _, table := range tablesToImport {
-				if fileName, exists := tableFileMap[table]; exists {
-					fileList = append(fileList, fileName)
-				}
+			// Filter schema content to only include selected tables
+			if len(cmdArgs.Tables) > 0 || len(sources) > 1 {
+				schemaData = filterSchemaContent(schemaData, src.schemaTables)
 			}
 
-			if cmdArgs.FromTableIndex > 0 {
-				fileList = fileList[cmdArgs.FromTableIndex-1:]
+			if err := importSchema(conn, schemaData, cmdArgs.Charset, cmdArgs.Collation, cmdArgs.SchemaConflict); err != nil {
+				return fmt.Errorf("failed to execute schema: %v", err)
 			}
 
-			if len(fileList) == 0 {
-				fmt.Println("No data files found to import from the specified table index")
-				return nil
+			// Indexes of an export made with --defer-indexes are created here
+			// unless they are deferred until the data is imported
+			if !cmdArgs.DeferIndexes {
+				if err := importIndexes(conn, src.path, src.schemaTables); err != nil {
+					return err
+				}
 			}
+		}
+	}
 
-			fmt.Printf("Found %d data files to import from table index %d\n", len(fileList), cmdArgs.FromTableIndex)
+	// A view created from the schema selects its rows from its tables
+	if cmdArgs.IncludeSchema {
+		skipCreatedViewData(sources)
+	}
 
-			for i, fileName := range fileList {
-				fmt.Printf("Importing %s...\n", fileName)
+	if cmdArgs.SchemaOnly {
+		printSchemaOnlySummary(sources)
+		return nil
+	}
 
-				fileData, err := os.ReadFile(filepath.Join(importPath, fileName))
-				if err != nil {
-					return fmt.Errorf("failed to read data file %s: %v", fileName, err)
-				}
+	// Skip data import if not included in export or not requested
+	hasData := false
+	for _, src := range sources {
+		hasData = hasData || len(src.dataTables) > 0
+	}
+	if !hasData || !cmdArgs.IncludeData {
+		fmt.Println("Skipping data import as requested")
+		return nil
+	}
 
-				if cmdArgs.Truncate {
-					tableName := extractTableNameFromFile(fileName)
-					fmt.Printf("Truncating table '%s'...\n", tableName)
-					if err := db.TruncateTable(conn, tableName); err != nil {
-						return fmt.Errorf("failed to truncate table %s: %v", tableName, err)
-					}
+	// Import data
+	fmt.Println("Importing data...")
+	err := withTxScope(conn, cmdArgs, txScopeAll, func() error {
+		for _, src := range sources {
+			if len(src.dataTables) == 0 {
+				continue
+			}
+			if len(sources) > 1 {
+				fmt.Printf("Importing data from %s\n", src.path)
+			}
+			if err := importSourceData(conn, cmdArgs, src, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if cmdArgs.SkipExisting {
+		fmt.Printf("Skipped %d existing rows\n", result.RowsSkipped)
+	}
+	if cmdArgs.PKMin != "" || cmdArgs.PKMax != "" {
+		fmt.Printf("Skipped %d rows outside the primary key range\n", result.RowsOutOfRange)
+	}
+	if len(result.Errors) > 0 {
+		printImportErrorSummary(result)
+		return &partialImportError{result: result}
+	}
+
+	// The import is complete, so there is nothing left to resume
+	for _, src := range sources {
+		src.checkpoint.remove()
+	}
+	fmt.Println("Import completed successfully")
+	return nil
+}
+
+// skipCreatedViewData removes the views whose schema is imported from the
+// tables whose data is imported, as inserting into a view fails or duplicates
+// the rows of its tables.
+func skipCreatedViewData(sources []*importSource) {
+	created := make(map[string]bool)
+	for _, src := range sources {
+		views := make(map[string]bool, len(src.metadata.Metadata.Views))
+		for _, view := range src.metadata.Metadata.Views {
+			views[view] = true
+		}
+		for _, table := range src.schemaTables {
+			if views[table] {
+				created[table] = true
+			}
+		}
+	}
+	if len(created) == 0 {
+		return
+	}
+
+	for _, src := range sources {
+		var dataTables []string
+		for _, table := range src.dataTables {
+			if created[table] {
+				fmt.Printf("Skipping data of view %s, created from the schema\n", table)
+				continue
+			}
+			dataTables = append(dataTables, table)
+		}
+		src.dataTables = dataTables
+	}
+}
+
+// importSourceData imports the data files of the tables whose data is
+// imported from src.
+func importSourceData(conn *db.Connection, cmdArgs *CommonArgs, src *importSource, result *ImportResult) error {
+	importPath, metadata, tablesToImport := src.path, src.metadata, src.dataTables
+	if src.appendData && cmdArgs.Truncate {
+		// The tables were truncated before the first page was imported
+		pageArgs := *cmdArgs
+		pageArgs.Truncate = false
+		cmdArgs = &pageArgs
+	}
+
+	// Create a map of available tables from metadata
+	availableTables := make(map[string]bool)
+	for _, table := range metadata.Metadata.Tables {
+		availableTables[table] = true
+	}
+
+	// Prepare file list based on metadata table order. Tables exported with
+	// --chunk-size have several data files, imported in chunk order
+	fileList := make([][]dataFile, 0)
+	tableFileMap := make(map[string][]dataFile)
+	skippedFiles := make([]string, 0)
+
+	// Read directory entries
+	entries, err := os.ReadDir(importPath)
+	if err != nil {
+		return fmt.Errorf("failed to read import directory: %v", err)
+	}
+
+	// Create file mapping
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		fileName := entry.Name()
+		if fileName == "0_schema.sql" || fileName == "0_schema.json" || storage.IsMetadataFile(fileName) || fileName == statsFileName ||
+			fileName == checksumManifestName || fileName == indexesFileName || fileName == columnStatsFileName ||
+			isRowHashesFile(fileName) || isBlobSidecarFile(fileName) || strings.HasPrefix(fileName, importCheckpointFileName) {
+			continue // Skip schema, metadata, stats, checksum, index, row hash, BLOB and checkpoint files
+		}
+
+		tableName, format, chunk := dataFileTable(fileName, availableTables)
+		if !validateTableName(tableName, availableTables) {
+			skippedFiles = append(skippedFiles, fileName)
+			continue
+		}
+
+		// Check if this table should be imported based on user-specified tables
+		if len(tablesToImport) > 0 {
+			found := false
+			for _, t := range tablesToImport {
+				if t == tableName {
+					found = true
+					break
 				}
+			}
+			if !found {
+				skippedFiles = append(skippedFiles, fileName)
+				continue
+			}
+		}
+
+		fmt.Printf("Found data file for table '%s': %s\n", tableName, fileName)
+		if expected := metadata.Metadata.DataFormats[tableName]; expected != "" && expected != format {
+			return fmt.Errorf("data file %s is %s, but the export wrote %s data for table %s", fileName, format, expected, tableName)
+		}
+		tableFileMap[tableName] = append(tableFileMap[tableName], dataFile{name: fileName, format: format, chunk: chunk})
+	}
+
+	if len(skippedFiles) > 0 {
+		fmt.Printf("Skipped %d files:\n", len(skippedFiles))
+		for _, file := range skippedFiles {
+			fmt.Printf("  - %s\n", file)
+		}
+	}
+
+	// Reorder fileList based on metadata table order
+	for _, table := range tablesToImport {
+		if files, exists := tableFileMap[table]; exists {
+			sort.Slice(files, func(a, b int) bool { return files[a].chunk < files[b].chunk })
+			if expected := metadata.Metadata.ChunkCounts[table]; expected > 0 && expected != len(files) {
+				return fmt.Errorf("table %s has %d chunk files, but the export wrote %d", table, len(files), expected)
+			}
+			fileList = append(fileList, files)
+		}
+	}
+
+	if cmdArgs.FromTableIndex > 0 {
+		fileList = fileList[cmdArgs.FromTableIndex-1:]
+	}
+
+	if len(fileList) == 0 {
+		fmt.Println("No data files found to import from the specified table index")
+		return nil
+	}
+
+	fmt.Printf("Found %d data files to import from table index %d\n", len(fileList), cmdArgs.FromTableIndex)
+
+	tables := make([]string, 0, len(fileList))
+	tableFiles := make(map[string][]dataFile, len(fileList))
+	for _, files := range fileList {
+		tableName, _, _ := dataFileTable(files[0].name, availableTables)
+		tables = append(tables, tableName)
+		tableFiles[tableName] = files
+	}
+
+	// Progress is recorded in the checkpoint file of the export, unless the
+	// whole import is a single transaction; with --resume, completed tables
+	// are skipped and partly imported tables continue after their last
+	// committed chunk
+	checkpointPath := src.checkpointPath
+	if checkpointPath == "" {
+		checkpointPath = importCheckpointPath(importPath)
+	}
+	checkpoint, err := openImportCheckpoint(cmdArgs, checkpointPath, tables)
+	if err != nil {
+		return err
+	}
+	src.checkpoint = checkpoint
+	chunkCheckpoint := checkpoint
+	if cmdArgs.TxScope != txScopeChunk {
+		chunkCheckpoint = nil // Chunks are only committed with their table
+	}
+	if cmdArgs.Resume {
+		pending := make([]string, 0, len(tables))
+		for _, tableName := range tables {
+			if checkpoint.completed(tableName) {
+				fmt.Printf("Skipping table '%s', already imported according to the checkpoint\n", tableName)
+				continue
+			}
+			pending = append(pending, tableName)
+		}
+		if len(pending) == 0 {
+			fmt.Println("All tables are already imported according to the checkpoint")
+			return nil
+		}
+		tables = pending
+	}
+
+	// Workers import tables concurrently, so each table collects its own
+	// result, merged into the import's result once it is done
+	var resultMu sync.Mutex
+	importTable := func(tableConn *db.Connection, tableName string) error {
+		// Chunk indexes count across all data files of the table
+		startChunk := 0
+		if cmdArgs.FromChunkIndex > 0 && tableName == tables[0] {
+			startChunk = cmdArgs.FromChunkIndex - 1 // 1-based to 0-based
+		} else if cmdArgs.Resume {
+			if startChunk = chunkCheckpoint.startChunk(tableName); startChunk > 0 {
+				fmt.Printf("Resuming table '%s' after chunk %d\n", tableName, startChunk)
+			}
+		}
+		tableResult := &ImportResult{}
+		err := withTxScope(tableConn, cmdArgs, txScopeTable, func() error {
+			return importTableData(tableConn, cmdArgs, importPath, metadata, tableName, tableFiles[tableName], startChunk, chunkCheckpoint, tableResult)
+		})
+		resultMu.Lock()
+		result.merge(tableResult)
+		resultMu.Unlock()
+		if err == nil {
+			checkpoint.tableDone(tableName)
+		}
+		return err
+	}
+
+	if cmdArgs.Workers > 1 && len(tables) > 1 {
+		deps, err := importDependencies(conn, src, tables)
+		if err != nil {
+			return err
+		}
+		return importTablesInParallel(conn, tables, deps, cmdArgs.Workers, importTable)
+	}
+	for _, warning := range dependencyOrderWarnings(tables, metadata.Metadata.TableRelationships) {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	for _, tableName := range tables {
+		if err := importTable(conn, tableName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTxScope checks --import-tx-scope and the flags that cannot be
+// combined with a transaction spanning several chunks.
+func validateTxScope(cmdArgs *CommonArgs) error {
+	switch cmdArgs.TxScope {
+	case txScopeChunk:
+		return nil
+	case txScopeTable, txScopeAll:
+	default:
+		return fmt.Errorf("invalid --import-tx-scope %q (valid values: chunk, table, all)", cmdArgs.TxScope)
+	}
+	if cmdArgs.ContinueOnError {
+		return fmt.Errorf("--import-tx-scope %s cannot be combined with --continue-on-error, a failing chunk rolls back the whole transaction", cmdArgs.TxScope)
+	}
+	if cmdArgs.TxSize > 0 {
+		return fmt.Errorf("--import-tx-size only applies to --import-tx-scope chunk")
+	}
+	if cmdArgs.TxScope == txScopeAll && cmdArgs.Workers > 1 {
+		return fmt.Errorf("--import-tx-scope all imports on a single connection and cannot be combined with --workers")
+	}
+	return nil
+}
+
+// withTxScope runs importData in a single data import transaction on conn when
+// --import-tx-scope is scope, committed if importData succeeds and rolled back
+// otherwise. For other scopes importData runs as is.
+func withTxScope(conn *db.Connection, cmdArgs *CommonArgs, scope string, importData func() error) error {
+	if cmdArgs.TxScope != scope {
+		return importData()
+	}
+	if err := db.BeginDataTx(conn); err != nil {
+		return err
+	}
+	if err := importData(); err != nil {
+		fmt.Printf("Rolling back the %s transaction\n", scope)
+		if rollbackErr := db.RollbackDataTx(conn); rollbackErr != nil {
+			fmt.Printf("Warning: %v\n", rollbackErr)
+		}
+		return err
+	}
+	return db.CommitDataTx(conn)
+}
+
+// importTableData imports the data files of a table, in chunk order, starting
+// at chunk startChunk counted across the files. Each committed chunk is
+// recorded in checkpoint, if not nil.
+func importTableData(conn *db.Connection, cmdArgs *CommonArgs, importPath string, metadata *ExportData, tableName string, files []dataFile, startChunk int, checkpoint *importCheckpoint, result *ImportResult) error {
+	// A table resumed after its first chunks keeps the rows imported so far
+	if cmdArgs.Truncate && startChunk == 0 {
+		fmt.Printf("Truncating table '%s'...\n", tableName)
+		if err := db.TruncateTable(conn, tableName); err != nil {
+			return fmt.Errorf("failed to truncate table %s: %v", tableName, err)
+		}
+	}
+
+	var pkColumns []string
+	var err error
+	if cmdArgs.SkipExisting {
+		if pkColumns, err = db.GetPrimaryKeyColumns(conn, tableName); err != nil {
+			return fmt.Errorf("failed to get primary key for table %s: %v", tableName, err)
+		}
+		if len(pkColumns) == 0 {
+			return fmt.Errorf("--skip-existing requires a primary key, but table %s has none", tableName)
+		}
+	}
+
+	// Rows outside --pk-min and --pk-max are compared on the single primary key column
+	rowRange := pkRange{min: cmdArgs.PKMin, max: cmdArgs.PKMax}
+	var rangeColumn string
+	if rowRange.active() {
+		rangeColumns, err := db.GetPrimaryKeyColumns(conn, tableName)
+		if err != nil {
+			return fmt.Errorf("failed to get primary key for table %s: %v", tableName, err)
+		}
+		if len(rangeColumns) != 1 {
+			return fmt.Errorf("--pk-min and --pk-max require a single-column primary key, but table %s has %d primary key columns", tableName, len(rangeColumns))
+		}
+		rangeColumn = rangeColumns[0]
+	}
 
-				// Split into chunks and import chunk by chunk
-				separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
-				if cmdArgs.QuerySeparator != "" {
-					separator = cmdArgs.QuerySeparator
+	var migrator *columnMigrator
+	var coercer *typeCoercer
+	if cmdArgs.AutoMigrate || len(cmdArgs.TypeCoercions) > 0 {
+		schema, err := db.GetTableSchema(conn, tableName)
+		if err != nil {
+			return fmt.Errorf("failed to get schema for table %s: %v", tableName, err)
+		}
+		if cmdArgs.AutoMigrate {
+			migrator = newColumnMigrator(conn.Config.Driver, schema)
+		}
+		coercer = newTypeCoercer(cmdArgs.TypeCoercions, schema)
+	}
+
+	// Placeholders of binary values exported with --blob-threshold are bound
+	// to their sidecar files
+	blobDir := ""
+	if metadata.Metadata.HasBlobSidecars {
+		blobDir = importPath
+	}
+
+	processedRows := 0
+	chunkOffset := 0                  // Chunks of the table in the files before this one
+	var binaryColumns map[string]bool // Read from the target table for NDJSON data files
+	for _, file := range files {
+		fileName := file.name
+		fmt.Printf("Importing %s...\n", fileName)
+
+		fileData, err := readDataFile(filepath.Join(importPath, fileName))
+		if err != nil {
+			return fmt.Errorf("failed to read data file %s: %v", fileName, err)
+		}
+
+		// Split into chunks and import chunk by chunk
+		separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+		if cmdArgs.QuerySeparator != "" {
+			separator = cmdArgs.QuerySeparator
+		}
+		var chunks []string
+		switch file.format {
+		case exportFormatCSV:
+			if chunks, err = csvToInsertStatements(conn.Config.Driver, tableName, fileData, cmdArgs.NullToken); err != nil {
+				return err
+			}
+		case exportFormatJSON:
+			if binaryColumns == nil {
+				if binaryColumns, err = tableBinaryColumns(conn, tableName); err != nil {
+					return err
 				}
-				chunks := strings.Split(string(fileData), separator)
-				fmt.Printf("Processing %s: Found %d chunks to import\n", fileName, len(chunks))
+			}
+			if chunks, err = ndjsonToInsertStatements(conn.Config.Driver, tableName, fileData, binaryColumns); err != nil {
+				return err
+			}
+		case exportFormatParquet:
+			if chunks, err = parquetToInsertStatements(conn.Config.Driver, tableName, fileData); err != nil {
+				return err
+			}
+		case exportFormatSQL:
+			if metadata.Metadata.StmtTerminator != "" || metadata.Metadata.CommentStyle != "" {
+				fileData = restoreSQLStyle(fileData, strings.Trim(separator, "\n"))
+			}
+			chunks = strings.Split(string(backtickQuoteSQL(fileData)), separator)
+		default:
+			return fmt.Errorf("data file %s: importing %s data files is not supported", fileName, file.format)
+		}
+		fmt.Printf("Processing %s: Found %d chunks to import\n", fileName, len(chunks))
 
-				startChunk := 0
-				if cmdArgs.FromChunkIndex > 0 && i == 0 {
-					startChunk = cmdArgs.FromChunkIndex - 1 // 1-based to 0-based
+		fileStartChunk := min(startChunk, len(chunks))
+		startChunk -= fileStartChunk
+
+		importChunk := func(chunk string) error {
+			// Rows are checked as exported, before any rewriting below
+			if cmdArgs.VerifyRowChecksum {
+				if err := verifyRowChecksums(chunk); err != nil {
+					return fmt.Errorf("%s: %v", fileName, err)
+				}
+			}
+			if cmdArgs.EmptyStringAsNull {
+				chunk = emptyStringsToNull(chunk)
+			}
+			if rangeColumn != "" {
+				var outside int
+				var rangeErr error
+				if chunk, outside, rangeErr = filterPKRange(tableName, rangeColumn, rowRange, chunk); rangeErr != nil {
+					return rangeErr
+				}
+				result.RowsOutOfRange += outside
+				if chunk == "" {
+					return nil // No row of the chunk is in the range
+				}
+			}
+			if migrator != nil {
+				var migrateErr error
+				if chunk, migrateErr = migrator.migrate(chunk); migrateErr != nil {
+					return migrateErr
+				}
+			}
+			if coercer != nil {
+				var coerceErr error
+				if chunk, coerceErr = coercer.coerce(chunk); coerceErr != nil {
+					return coerceErr
+				}
+			}
+			if cmdArgs.SkipExisting {
+				var skipped int
+				var skipErr error
+				if chunk, skipped, skipErr = skipExistingRows(conn, tableName, pkColumns, chunk); skipErr != nil {
+					return skipErr
+				}
+				result.RowsSkipped += skipped
+			}
+			for _, batch := range db.SplitTxBatches(chunk, conn.Config.TxSize) {
+				if err := excludedColumnsHint(db.ExecuteDataWithBlobs(conn, batch, blobDir), metadata.Metadata.ExcludedColumns[tableName]); err != nil {
+					return err
 				}
+			}
+			result.RowsImported += int64(countInsertRows(chunk))
+			return nil
+		}
+		processed, err := importChunks(chunks, fileName, fileStartChunk, cmdArgs.ContinueOnError, func(chunkIdx int, chunk string) error {
+			if err := importChunk(chunk); err != nil {
+				return err
+			}
+			checkpoint.chunkDone(tableName, chunkOffset+chunkIdx+1)
+			return nil
+		}, result)
+		if err != nil {
+			return err
+		}
+		processedRows += processed
+		chunkOffset += len(chunks)
+	}
+	fmt.Printf("Completed importing %s: Processed %d chunks successfully\n",
+		tableName, processedRows)
+	return nil
+}
 
-				processedRows := 0
-				for chunkIdx, chunk := range chunks {
-					if chunkIdx < startChunk {
-						continue
-					}
+// tableBinaryColumns returns the binary columns of a table in the target
+// database, whose values NDJSON data files hold base64 encoded.
+func tableBinaryColumns(conn *db.Connection, tableName string) (map[string]bool, error) {
+	schema, err := db.GetTableSchema(conn, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema for table %s: %v", tableName, err)
+	}
+	binaryColumns := make(map[string]bool)
+	for col, colType := range schema.ColumnTypes {
+		if db.IsBinaryType(colType) {
+			binaryColumns[col] = true
+		}
+	}
+	return binaryColumns, nil
+}
 
-					// Skip empty chunks
-					chunk = strings.TrimSpace(chunk)
-					if chunk == "" {
-						continue
-					}
+// importDependencies returns the tables each table references through its
+// foreign keys, recorded in the export metadata or read from the exported
+// schema or, for older exports without one, from the target database.
+func importDependencies(conn *db.Connection, src *importSource, tables []string) (map[string][]string, error) {
+	if relationships := src.metadata.Metadata.TableRelationships; len(relationships) > 0 {
+		return relationships, nil
+	}
+	if src.metadata.Metadata.Schema {
+		schemaData, err := readSchemaSQL(src.path, src.format, src.metadata.Metadata.Tables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %v", err)
+		}
+		createTableStatements, _ := parseSchemaStatements(schemaData)
+		return foreignKeyDependencies(createTableStatements), nil
+	}
 
-					currentTableName := extractTableNameFromFile(fileName)
-					fmt.Printf("  Importing chunk %d/%d for %s (%d bytes)...\n",
-						chunkIdx+1, len(chunks), currentTableName, len(chunk))
-
-					err = db.ExecuteData(conn, chunk)
-					if err != nil {
-						// Log the failing chunk to a file for debugging
-						logFile := fmt.Sprintf("%s_chunk_%d_error.sql", currentTableName, chunkIdx+1)
-						logErr := os.WriteFile(logFile, []byte(chunk), 0644)
-						if logErr != nil {
-							fmt.Printf("Warning: Failed to write error log: %v\n", logErr)
-						}
-						return fmt.Errorf("failed to execute chunk %d in %s (chunk saved to %s): %v",
-							chunkIdx+1, fileName, logFile, err)
-					}
-					processedRows++
+	deps := make(map[string][]string)
+	for _, table := range tables {
+		tableDeps, err := db.GetTableDependencies(conn, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dependencies for table %s: %v", table, err)
+		}
+		deps[table] = tableDeps
+	}
+	return deps, nil
+}
 
-					if processedRows%10 == 0 {
-						fmt.Printf("    Progress: %d/%d chunks processed\n", processedRows, len(chunks))
-					}
+// dependencyOrderWarnings checks that tables, in import order, come after the
+// tables they reference according to the export metadata. It returns a warning
+// for each referenced table imported later, or not at all, for instance when
+// --from-table-index skipped it.
+func dependencyOrderWarnings(tables []string, relationships map[string][]string) []string {
+	position := make(map[string]int, len(tables))
+	for i, table := range tables {
+		position[table] = i
+	}
+	var warnings []string
+	for i, table := range tables {
+		for _, dep := range relationships[table] {
+			if dep == table {
+				continue
+			}
+			depPosition, imported := position[dep]
+			switch {
+			case !imported:
+				warnings = append(warnings, fmt.Sprintf("table %s references %s, which is not imported by this run", table, dep))
+			case depPosition > i:
+				warnings = append(warnings, fmt.Sprintf("table %s references %s, which is imported after it", table, dep))
+			}
+		}
+	}
+	return warnings
+}
+
+// importTablesInParallel imports tables with a pool of workers, each with its
+// own connection to the target database.
+func importTablesInParallel(conn *db.Connection, tables []string, deps map[string][]string, workers int, importTable func(conn *db.Connection, table string) error) error {
+	if workers > len(tables) {
+		workers = len(tables)
+	}
+	fmt.Printf("Importing %d tables with %d workers\n", len(tables), workers)
+
+	conns := make([]*workerConn, workers)
+	for i := range conns {
+		conns[i] = newWorkerConn(conn.Config)
+	}
+	defer func() {
+		for _, wc := range conns {
+			wc.close()
+		}
+	}()
+
+	return scheduleTables(tables, deps, workers, func(worker int, table string) error {
+		workerDB, err := conns[worker].get()
+		if err != nil {
+			return fmt.Errorf("failed to connect worker for table %s: %v", table, err)
+		}
+		return importTable(workerDB, table)
+	})
+}
+
+// tableDone reports a table finished by a worker of scheduleTables
+type tableDone struct {
+	table string
+	err   error
+}
+
+// scheduleTables runs run for each table on a pool of workers. A table is
+// started once the tables it depends on, among tables, have completed, in the
+// order given by db.SortTablesByDependencies. When a table fails no more
+// tables are started, and the first error is returned once the running ones
+// have finished. Tables in a dependency cycle are started one at a time when
+// nothing else can run.
+func scheduleTables(tables []string, deps map[string][]string, workers int, run func(worker int, table string) error) error {
+	selected := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		selected[table] = true
+	}
+	waitsFor := make(map[string][]string, len(tables))
+	for _, table := range tables {
+		for _, dep := range deps[table] {
+			if dep != table && selected[dep] {
+				waitsFor[table] = append(waitsFor[table], dep)
+			}
+		}
+	}
+
+	workChan := make(chan string)
+	doneChan := make(chan tableDone, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for table := range workChan {
+				doneChan <- tableDone{table: table, err: run(worker, table)}
+			}
+		}(i)
+	}
+
+	// The sort leaves out tables of a dependency cycle, they come last
+	pending := db.SortTablesByDependencies(tables, waitsFor)
+	sorted := make(map[string]bool, len(pending))
+	for _, table := range pending {
+		sorted[table] = true
+	}
+	for _, table := range tables {
+		if !sorted[table] {
+			pending = append(pending, table)
+		}
+	}
+	completed := make(map[string]bool, len(tables))
+	ready := func(table string) bool {
+		for _, dep := range waitsFor[table] {
+			if !completed[dep] {
+				return false
+			}
+		}
+		return true
+	}
+
+	running := 0
+	var firstErr error
+	for {
+		if firstErr == nil {
+			remaining := pending[:0]
+			for _, table := range pending {
+				if running < workers && ready(table) {
+					workChan <- table
+					running++
+				} else {
+					remaining = append(remaining, table)
 				}
-				fmt.Printf("Completed importing %s: Processed %d chunks successfully\n",
-					extractTableNameFromFile(fileName), processedRows)
 			}
+			pending = remaining
+		}
+		if running == 0 {
+			if firstErr != nil || len(pending) == 0 {
+				break
+			}
+			// Only tables of a dependency cycle are left
+			fmt.Printf("Warning: circular dependency between tables, importing %s before the tables it references\n", pending[0])
+			workChan <- pending[0]
+			pending = pending[1:]
+			running++
+		}
 
-			fmt.Println("Import completed successfully")
-			return nil
-		},
+		done := <-doneChan
+		running--
+		if done.err != nil {
+			if firstErr == nil {
+				firstErr = done.err
+			}
+			continue
+		}
+		completed[done.table] = true
 	}
 
-	// Add shared flags
-	AddSharedFlags(cmd, true) // Pass true for import command
+	close(workChan)
+	wg.Wait()
+	return firstErr
+}
 
-	// Add import-specific flags
-	flags := cmd.Flags()
-	flags.Bool("truncate", false, "Truncate tables before import")
-	flags.Bool("drop", false, "Drop and recreate database before import")
-	flags.String("query-separator", "\n--SYNCDB_QUERY_SEPARATOR--\n", "String used to separate SQL queries in import file")
+// ImportError describes a chunk that failed to import.
+type ImportError struct {
+	Table      string
+	ChunkIndex int // 1-based
+	Err        error
+}
 
-	return cmd
+// ImportResult accumulates chunk outcomes across an import.
+type ImportResult struct {
+	ChunksImported int
+	ChunksFailed   int
+	RowsSkipped    int   // Rows left out by --skip-existing
+	RowsOutOfRange int   // Rows left out by --pk-min and --pk-max
+	RowsImported   int64 // Rows in successfully imported chunks
+	Errors         []ImportError
+}
+
+// merge adds the counts and errors of other to r.
+func (r *ImportResult) merge(other *ImportResult) {
+	r.ChunksImported += other.ChunksImported
+	r.ChunksFailed += other.ChunksFailed
+	r.RowsSkipped += other.RowsSkipped
+	r.RowsOutOfRange += other.RowsOutOfRange
+	r.RowsImported += other.RowsImported
+	r.Errors = append(r.Errors, other.Errors...)
+}
+
+// partialImportError is returned when --continue-on-error finished with failed chunks.
+type partialImportError struct {
+	result *ImportResult
+}
+
+func (e *partialImportError) Error() string {
+	return fmt.Sprintf("import finished with %d failed chunks (%d imported)",
+		e.result.ChunksFailed, e.result.ChunksImported)
+}
+
+// importChunks executes the chunks of a data file starting at startChunk and
+// returns the number of chunks imported. execute gets the 0-based index of the
+// chunk in the file. A failing chunk aborts the import, unless continueOnError
+// is set, in which case it is appended to {table}_errors.sql, recorded in
+// result and skipped.
+func importChunks(chunks []string, fileName string, startChunk int, continueOnError bool, execute func(chunkIdx int, chunk string) error, result *ImportResult) (int, error) {
+	tableName, _, _ := dataFileTable(fileName, nil)
+	processedRows := 0
+	for chunkIdx, chunk := range chunks {
+		if chunkIdx < startChunk {
+			continue
+		}
+
+		// Skip empty chunks, and the comments at the top of a file
+		chunk = trimLeadingComments(chunk)
+		if chunk == "" {
+			continue
+		}
+
+		fmt.Printf("  Importing chunk %d/%d for %s (%d bytes)...\n",
+			chunkIdx+1, len(chunks), tableName, len(chunk))
+
+		if err := execute(chunkIdx, chunk); err != nil {
+			if !continueOnError {
+				// Log the failing chunk to a file for debugging
+				logFile := fmt.Sprintf("%s_chunk_%d_error.sql", tableName, chunkIdx+1)
+				logErr := os.WriteFile(logFile, []byte(chunk), 0644)
+				if logErr != nil {
+					fmt.Printf("Warning: Failed to write error log: %v\n", logErr)
+				}
+				return processedRows, fmt.Errorf("failed to execute chunk %d in %s (chunk saved to %s): %v",
+					chunkIdx+1, fileName, logFile, err)
+			}
+
+			fmt.Printf("  Error in chunk %d of %s, continuing: %v\n", chunkIdx+1, tableName, err)
+			if logErr := appendErrorChunk(tableName, chunkIdx+1, chunk, err); logErr != nil {
+				fmt.Printf("Warning: Failed to write error log: %v\n", logErr)
+			}
+			result.ChunksFailed++
+			result.Errors = append(result.Errors, ImportError{Table: tableName, ChunkIndex: chunkIdx + 1, Err: err})
+			continue
+		}
+		processedRows++
+		result.ChunksImported++
+
+		if processedRows%10 == 0 {
+			fmt.Printf("    Progress: %d/%d chunks processed\n", processedRows, len(chunks))
+		}
+	}
+	return processedRows, nil
+}
+
+// excludedColumnsHint adds the columns that were excluded at export to a data
+// import error. Rows without those columns fail on NOT NULL columns that have
+// no default, and the database error alone does not say why the column is missing.
+func excludedColumnsHint(err error, excludedColumns []string) error {
+	if err == nil || len(excludedColumns) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w\nNote: columns %s were excluded from this export (--exclude-columns); "+
+		"they must be nullable or have a default value in the target table",
+		err, strings.Join(excludedColumns, ", "))
+}
+
+// emptyStringsToNull replaces empty string literals ('') in a data chunk with
+// NULL. Quotes inside other string literals are left untouched.
+func emptyStringsToNull(chunk string) string {
+	var out strings.Builder
+	out.Grow(len(chunk))
+	for i := 0; i < len(chunk); i++ {
+		if chunk[i] != '\'' {
+			out.WriteByte(chunk[i])
+			continue
+		}
+		// Find the closing quote, skipping doubled quotes and backslash escapes
+		j := i + 1
+		for j < len(chunk) {
+			if chunk[j] == '\\' {
+				j += 2
+				continue
+			}
+			if chunk[j] == '\'' {
+				if j+1 < len(chunk) && chunk[j+1] == '\'' {
+					j += 2
+					continue
+				}
+				break
+			}
+			j++
+		}
+		if j >= len(chunk) {
+			// Unterminated literal, copy the rest as is
+			out.WriteString(chunk[i:])
+			break
+		}
+		// Prefixed literals such as an empty binary X'' are not strings
+		prefixed := i > 0 && (chunk[i-1]|0x20 >= 'a' && chunk[i-1]|0x20 <= 'z')
+		if j == i+1 && !prefixed {
+			out.WriteString("NULL")
+		} else {
+			out.WriteString(chunk[i : j+1])
+		}
+		i = j
+	}
+	return out.String()
+}
+
+// appendErrorChunk appends a failed chunk to {table}_errors.sql in the current directory.
+func appendErrorChunk(tableName string, chunkIndex int, chunk string, chunkErr error) error {
+	f, err := os.OpenFile(tableName+"_errors.sql", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	errMsg := strings.ReplaceAll(chunkErr.Error(), "\n", " ")
+	_, err = fmt.Fprintf(f, "-- chunk %d: %s\n%s\n\n", chunkIndex, errMsg, chunk)
+	return err
+}
+
+// printImportErrorSummary prints the chunks that failed during a --continue-on-error import.
+func printImportErrorSummary(result *ImportResult) {
+	fmt.Printf("\nImport completed with errors: %d chunks imported, %d chunks failed\n",
+		result.ChunksImported, result.ChunksFailed)
+	for _, e := range result.Errors {
+		fmt.Printf("  - %s chunk %d: %v (saved to %s_errors.sql)\n", e.Table, e.ChunkIndex, e.Err, e.Table)
+	}
 }
 
 // Helper function to extract table name from schema statement
@@ -586,65 +1777,46 @@ func extractTableNameFromSchema(stmt string) string {
 		return matches[1]
 	}
 
+	// Try to match CREATE VIEW
+	if matches := createViewRegex.FindStringSubmatch(stmt); len(matches) > 1 {
+		return matches[1]
+	}
+
+	// Try to match CREATE INDEX, which belongs to its table
+	if matches := createIndexRegex.FindStringSubmatch(stmt); len(matches) > 1 {
+		return matches[1]
+	}
+
 	return ""
 }
 
-func importSchema(conn *db.Connection, schemaContent []byte) error {
+func importSchema(conn *db.Connection, schemaContent []byte, charset, collation, schemaConflict string) error {
 	// First pass: collect SQL mode and CREATE TABLE statements
-	createTableStatements := make(map[string]string)
-	var currentStatement strings.Builder
-	sqlMode := ""
-
-	lines := strings.Split(string(schemaContent), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+	createTableStatements, sqlMode := parseSchemaStatements(schemaContent)
+	viewStatements := parseViewStatements(schemaContent)
+	indexStatements := parseIndexStatements(schemaContent)
+	if len(createTableStatements) == 0 && len(viewStatements) == 0 {
+		return fmt.Errorf("no CREATE TABLE statements found in schema")
+	}
+	if conn.Config.Driver == db.DriverMySQL {
+		for tableName, stmt := range createTableStatements {
+			createTableStatements[tableName] = withTableCharset(stmt, charset, collation)
 		}
-
-		// Check for SQL mode comment
-		if strings.HasPrefix(line, "--") {
-			if strings.HasPrefix(line, "-- SQL_MODE=") {
-				sqlMode = strings.TrimPrefix(line, "-- SQL_MODE=")
-			}
-			continue
+	}
+	if schemaConflict == schemaConflictSkip {
+		for tableName, stmt := range createTableStatements {
+			createTableStatements[tableName] = withIfNotExists(stmt)
 		}
-
-		currentStatement.WriteString(line)
-		currentStatement.WriteString("\n")
-
-		if strings.HasSuffix(line, ";") {
-			stmt := currentStatement.String()
-			if strings.Contains(strings.ToUpper(stmt), "CREATE TABLE") {
-				// Extract table name and validate it exists
-				tableName := extractTableNameFromSchema(stmt)
-				if tableName != "" {
-					createTableStatements[tableName] = stmt
-					// fmt.Printf("Found CREATE TABLE for %s\n", tableName)
-				}
-			}
-			currentStatement.Reset()
+		for i, stmt := range indexStatements {
+			indexStatements[i] = withIfNotExists(stmt)
 		}
 	}
 
-	if len(createTableStatements) == 0 {
-		return fmt.Errorf("no CREATE TABLE statements found in schema")
-	}
-
 	// Build dependency graph
-	deps := make(map[string][]string)
-	for tableName, stmt := range createTableStatements {
-		if strings.Contains(stmt, "FOREIGN KEY") {
-			// Look for all REFERENCES clauses
-			refRegex := regexp.MustCompile(`(?i)FOREIGN\s+KEY\s*\([^)]+\)\s*REFERENCES\s+[\x60"']?(\w+)[\x60"']?\s*\([^)]+\)`)
-			matches := refRegex.FindAllStringSubmatch(stmt, -1)
-			for _, match := range matches {
-				if len(match) > 1 {
-					referencedTable := match[1]
-					deps[tableName] = append(deps[tableName], referencedTable)
-					fmt.Printf("Table %s depends on %s\n", tableName, referencedTable)
-				}
-			}
+	deps := foreignKeyDependencies(createTableStatements)
+	for _, tableName := range sortedKeys(deps) {
+		for _, referencedTable := range deps[tableName] {
+			fmt.Printf("Table %s depends on %s\n", tableName, referencedTable)
 		}
 	}
 
@@ -676,6 +1848,12 @@ func importSchema(conn *db.Connection, schemaContent []byte) error {
 		}
 	}()
 
+	if schemaConflict == schemaConflictReplace {
+		if err = dropSchemaTables(tx, conn.Config.Driver, sortedTables); err != nil {
+			return err
+		}
+	}
+
 	// Execute statements in dependency order with retry mechanism
 	executedTables := make(map[string]bool)
 	maxRetries := 5
@@ -735,15 +1913,170 @@ func importSchema(conn *db.Connection, schemaContent []byte) error {
 		sortedTables = skippedTables
 	}
 
+	// Indexes written after the PostgreSQL CREATE TABLE statements
+	for _, stmt := range indexStatements {
+		if _, err = tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create index: %v\nStatement: %s", err, stmt)
+		}
+	}
+
 	// Commit transaction if all is well
 	if err = tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit schema changes: %v", err)
 	}
 
-	fmt.Printf("Schema import completed successfully. Created %d tables.\n", len(executedTables))
+	// Views may select from any table, so they are created last. PostgreSQL
+	// aborts a transaction on the first error, so they run outside of it to
+	// allow retries.
+	createdViews, err := createViews(conn, viewStatements)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Schema import completed successfully. Created %d tables and %d views.\n", len(executedTables), createdViews)
 	return nil
 }
 
+// parseSchemaStatements returns the CREATE TABLE statements of a schema file
+// keyed by table name, and the SQL mode recorded in its "-- SQL_MODE=" comment.
+func parseSchemaStatements(schemaContent []byte) (map[string]string, string) {
+	createTableStatements := make(map[string]string)
+	statements, sqlMode := splitSchemaStatements(schemaContent)
+	for _, stmt := range statements {
+		if strings.Contains(strings.ToUpper(stmt), "CREATE TABLE") {
+			// Extract table name and validate it exists
+			tableName := extractTableNameFromSchema(stmt)
+			if tableName != "" {
+				createTableStatements[tableName] = stmt
+			}
+		}
+	}
+	return createTableStatements, sqlMode
+}
+
+// createViewRegex matches a CREATE VIEW statement, capturing the view name
+var createViewRegex = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:OR\s+REPLACE\s+)?VIEW\s+[\x60"]?(\w+)[\x60"]?`)
+
+// parseViewStatements returns the CREATE VIEW statements of a schema file in
+// the order they appear.
+func parseViewStatements(schemaContent []byte) []string {
+	var views []string
+	statements, _ := splitSchemaStatements(schemaContent)
+	for _, stmt := range statements {
+		if createViewRegex.MatchString(stmt) {
+			views = append(views, stmt)
+		}
+	}
+	return views
+}
+
+// createIndexRegex matches a CREATE INDEX statement, capturing the table name
+// without its schema
+var createIndexRegex = regexp.MustCompile(`(?i)^\s*CREATE\s+(?:UNIQUE\s+|FULLTEXT\s+|SPATIAL\s+)?INDEX\s+.*?\bON\s+(?:ONLY\s+)?(?:[\w"]+\.)?[\x60"]?(\w+)[\x60"]?`)
+
+// parseIndexStatements returns the CREATE INDEX statements of a schema file in
+// the order they appear.
+func parseIndexStatements(schemaContent []byte) []string {
+	var indexes []string
+	statements, _ := splitSchemaStatements(schemaContent)
+	for _, stmt := range statements {
+		if createIndexRegex.MatchString(stmt) {
+			indexes = append(indexes, stmt)
+		}
+	}
+	return indexes
+}
+
+// splitSchemaStatements splits a schema file into statements ending with a
+// semicolon, and returns the SQL mode recorded in its "-- SQL_MODE=" comment.
+func splitSchemaStatements(schemaContent []byte) ([]string, string) {
+	var statements []string
+	var currentStatement strings.Builder
+	sqlMode := ""
+
+	lines := strings.Split(string(schemaContent), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// Check for SQL mode comment
+		if strings.HasPrefix(line, "--") {
+			if strings.HasPrefix(line, "-- SQL_MODE=") {
+				sqlMode = strings.TrimPrefix(line, "-- SQL_MODE=")
+			}
+			continue
+		}
+
+		currentStatement.WriteString(line)
+		currentStatement.WriteString("\n")
+
+		if strings.HasSuffix(line, ";") {
+			statements = append(statements, currentStatement.String())
+			currentStatement.Reset()
+		}
+	}
+	return statements, sqlMode
+}
+
+// createViews executes CREATE VIEW statements once the tables exist. A view
+// that selects from a view created later fails on the first pass, so failed
+// statements are retried until a pass creates no view.
+func createViews(conn *db.Connection, statements []string) (int, error) {
+	created := 0
+	remaining := statements
+	for len(remaining) > 0 {
+		var failed []string
+		var lastErr error
+		for _, stmt := range remaining {
+			if _, err := conn.DB.Exec(stmt); err != nil {
+				failed = append(failed, stmt)
+				lastErr = err
+				continue
+			}
+			created++
+		}
+		if len(failed) == len(remaining) {
+			var names []string
+			for _, stmt := range failed {
+				names = append(names, createViewRegex.FindStringSubmatch(stmt)[1])
+			}
+			return created, fmt.Errorf("failed to create views %v: %v", names, lastErr)
+		}
+		remaining = failed
+	}
+	return created, nil
+}
+
+// foreignKeyRefRegex matches a FOREIGN KEY clause, capturing the referenced table
+var foreignKeyRefRegex = regexp.MustCompile(`(?i)FOREIGN\s+KEY\s*\([^)]+\)\s*REFERENCES\s+[\x60"']?(\w+)[\x60"']?\s*\([^)]+\)`)
+
+// foreignKeyDependencies returns the tables each CREATE TABLE statement
+// references through its foreign keys.
+func foreignKeyDependencies(createTableStatements map[string]string) map[string][]string {
+	deps := make(map[string][]string)
+	for tableName, stmt := range createTableStatements {
+		if !strings.Contains(stmt, "FOREIGN KEY") {
+			continue
+		}
+		for _, match := range foreignKeyRefRegex.FindAllStringSubmatch(stmt, -1) {
+			deps[tableName] = append(deps[tableName], match[1])
+		}
+	}
+	return deps
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // filterSchemaContent filters SQL schema content to only include selected tables
 // and their related objects (foreign keys, indexes, etc.)
 func filterSchemaContent(schemaData []byte, tables []string) []byte {
@@ -782,30 +2115,75 @@ func filterSchemaContent(schemaData []byte, tables []string) []byte {
 	return []byte(strings.Join(filteredStmts, ";\n") + ";")
 }
 
+// dataFileFormats maps data file extensions to their format
+var dataFileFormats = map[string]string{
+	".sql":     exportFormatSQL,
+	".csv":     exportFormatCSV,
+	".json":    exportFormatJSON,
+	".parquet": exportFormatParquet,
+}
+
 // extractTableNameFromFile extracts the table name from a data file name,
-// handling numbered prefixes correctly (e.g., "79_postal_delivery_options.sql" -> "postal_delivery_options")
-func extractTableNameFromFile(fileName string) string {
-	// Skip files that don't have the .sql extension
-	if !strings.HasSuffix(fileName, ".sql") {
-		return ""
+// handling numbered prefixes correctly (e.g., "79_postal_delivery_options.sql" -> "postal_delivery_options"),
+// along with the data format given by the file extension. Both are empty for
+// files that are not data files.
+func extractTableNameFromFile(fileName string) (string, string) {
+	// Skip files that are not SQL, CSV, JSON or Parquet data files; SQL data
+	// files may be gzip compressed (--compress-sql-files)
+	if name, ok := strings.CutSuffix(fileName, gzipFileExt); ok && filepath.Ext(name) == ".sql" {
+		fileName = name
+	}
+	ext := filepath.Ext(fileName)
+	format, ok := dataFileFormats[ext]
+	if !ok {
+		return "", ""
 	}
 
-	// Remove .sql extension
-	baseName := strings.TrimSuffix(fileName, ".sql")
+	// Remove the extension
+	baseName := strings.TrimSuffix(fileName, ext)
 
 	// Split on underscore
 	parts := strings.SplitN(baseName, "_", 2)
 	if len(parts) != 2 {
-		return ""
+		return "", ""
 	}
 
 	// Validate that the first part is a number
 	if _, err := strconv.Atoi(parts[0]); err != nil {
-		return ""
+		return "", ""
 	}
 
 	// Return everything after the first underscore
-	return parts[1]
+	return parts[1], format
+}
+
+// dataFile is a data file of a table found in an import directory.
+type dataFile struct {
+	name   string
+	format string // Format given by the file extension (sql, json or csv)
+	chunk  int    // Chunk or part number from --chunk-size or --max-file-size, 0 for a single file
+}
+
+// chunkFileSuffix matches the _chunk{n} suffix of data files written with
+// --chunk-size, and the _part{n} suffix of those split by --max-file-size
+var chunkFileSuffix = regexp.MustCompile(`_(?:chunk|part)([0-9]+)$`)
+
+// dataFileTable returns the table and format of a data file (see
+// extractTableNameFromFile) and its chunk or part number, or 0 if the table's
+// data is in a single file. A name like 2_users_chunk3.sql or 2_users_part3.sql
+// belongs to table users, unless availableTables has a table of that name.
+func dataFileTable(fileName string, availableTables map[string]bool) (string, string, int) {
+	tableName, format := extractTableNameFromFile(fileName)
+	match := chunkFileSuffix.FindStringSubmatch(tableName)
+	if match == nil || availableTables[tableName] {
+		return tableName, format, 0
+	}
+	baseName := strings.TrimSuffix(tableName, match[0])
+	if !validateTableName(baseName, availableTables) {
+		return tableName, format, 0
+	}
+	chunk, _ := strconv.Atoi(match[1])
+	return baseName, format, chunk
 }
 
 // validateTableName checks if a table name is valid and exists in the provided list
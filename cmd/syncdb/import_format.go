@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// Export formats recorded in 0_metadata.json
+const (
+	exportFormatSQL  = "sql"
+	exportFormatJSON = "json"
+	exportFormatCSV  = "csv"
+)
+
+// csvImportBatchSize is the number of CSV rows combined into one INSERT statement.
+const csvImportBatchSize = 500
+
+// resolveImportFormat picks the format used to read an export: an explicit
+// --format flag wins, then the format recorded in the metadata. Exports made
+// before the format was recorded are SQL.
+func resolveImportFormat(cmd *cobra.Command, metadataFormat string) (string, error) {
+	format := exportFormatSQL
+	if cmd.Flags().Changed("format") {
+		format, _ = cmd.Flags().GetString("format")
+	} else if metadataFormat != "" {
+		format = metadataFormat
+		fmt.Printf("Detected export format '%s' from metadata\n", format)
+	}
+
+	switch format {
+	case exportFormatSQL, exportFormatJSON, exportFormatCSV, exportFormatParquet:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported import format '%s': must be sql, json, csv or parquet", format)
+	}
+}
+
+// readSchemaSQL reads the schema file of an export as SQL. JSON schema files,
+// written for --format json, csv and parquet, describe the tables (see db.SchemaToJSON)
+// and are converted to the 0_schema.sql layout in the order of tables. Older
+// JSON schema files, which map table names to definitions, are read as well.
+// SQL schema files written with --ansi-quotes are read with backtick quoting,
+// and those written with --stmt-terminator or --comment-style in the default style.
+func readSchemaSQL(importPath, format string, tables []string) ([]byte, error) {
+	if format == exportFormatSQL {
+		schemaData, err := os.ReadFile(filepath.Join(importPath, "0_schema.sql"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema file: %v", err)
+		}
+		return backtickQuoteSQL(restoreSQLStyle(schemaData, "")), nil
+	}
+
+	schemaData, err := os.ReadFile(filepath.Join(importPath, "0_schema.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %v", err)
+	}
+	var schemaDefinitions map[string]string
+	if err := json.Unmarshal(schemaData, &schemaDefinitions); err == nil {
+		return []byte(formatSchemaSQL(schemaDefinitions, tables)), nil
+	}
+	schema, err := db.ParseSchemaJSON(schemaData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema file: %v", err)
+	}
+
+	// Indexes moved to 0_indexes.sql by --defer-indexes are created from that file
+	_, statErr := os.Stat(filepath.Join(importPath, indexesFileName))
+	withIndexes := os.IsNotExist(statErr)
+	schemaDefinitions = make(map[string]string, len(schema.Tables)+1)
+	if schema.SQLMode != "" {
+		schemaDefinitions["__sql_mode"] = schema.SQLMode
+	}
+	for _, table := range schema.Tables {
+		schemaDefinitions[table.Name] = db.JSONToCreateTable(schema.Driver, table, withIndexes)
+	}
+	return []byte(formatSchemaSQL(schemaDefinitions, tables)), nil
+}
+
+// csvToInsertStatements converts a CSV data file with a header row of column
+// names into INSERT statements of up to csvImportBatchSize rows. Fields equal
+// to nullToken are imported as NULL.
+func csvToInsertStatements(driver, table string, data []byte, nullToken string) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	columns, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header for table %s: %v", table, err)
+	}
+
+	var statements []string
+	var valueStrings []string
+	flush := func() error {
+		if len(valueStrings) == 0 {
+			return nil
+		}
+		stmt, err := buildInsertStatement(driver, insertModeInsert, table, columns, nil, valueStrings)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, stmt)
+		valueStrings = valueStrings[:0]
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV data for table %s: %v", table, err)
+		}
+		values := make([]string, len(record))
+		for i, field := range record {
+			if field == nullToken {
+				values[i] = "NULL"
+				continue
+			}
+			values[i] = "'" + escapeControlCharsForSQL(strings.ReplaceAll(field, "'", "''")) + "'"
+		}
+		valueStrings = append(valueStrings, "("+strings.Join(values, ", ")+")")
+		if len(valueStrings) == csvImportBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// ndjsonToInsertStatements converts an NDJSON data file, one JSON object per
+// row, into INSERT statements of up to csvImportBatchSize rows. Consecutive
+// rows with the same columns share a statement. Values of binaryColumns are
+// base64 decoded.
+func ndjsonToInsertStatements(driver, table string, data []byte, binaryColumns map[string]bool) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var statements []string
+	var columns []string
+	var valueStrings []string
+	flush := func() error {
+		if len(valueStrings) == 0 {
+			return nil
+		}
+		stmt, err := buildInsertStatement(driver, insertModeInsert, table, columns, nil, valueStrings)
+		if err != nil {
+			return err
+		}
+		statements = append(statements, stmt)
+		valueStrings = valueStrings[:0]
+		return nil
+	}
+
+	for row := 1; ; row++ {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read JSON data for table %s, row %d: %v", table, row, err)
+		}
+
+		rowColumns := sortedKeys(record)
+		if len(valueStrings) > 0 && strings.Join(rowColumns, ",") != strings.Join(columns, ",") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		columns = rowColumns
+
+		values := make([]string, len(columns))
+		for i, col := range columns {
+			value, err := jsonValueToSQL(driver, record[col], binaryColumns[col])
+			if err != nil {
+				return nil, fmt.Errorf("table %s, row %d, column %s: %v", table, row, col, err)
+			}
+			values[i] = value
+		}
+		valueStrings = append(valueStrings, "("+strings.Join(values, ", ")+")")
+		if len(valueStrings) == csvImportBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+// jsonValueToSQL renders a value decoded from an NDJSON data file as a SQL
+// literal. Nested objects and arrays are imported as JSON text.
+func jsonValueToSQL(driver string, value interface{}, binary bool) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case string:
+		if binary {
+			data, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return "", fmt.Errorf("invalid base64 value: %v", err)
+			}
+			return hexLiteral(hex.EncodeToString(data), driver), nil
+		}
+		return quoteSQLString(v), nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return quoteSQLString(string(encoded)), nil
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveImportFormat(t *testing.T) {
+	newCmd := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("format", "", "")
+		return cmd
+	}
+
+	format, err := resolveImportFormat(newCmd(), "")
+	require.NoError(t, err)
+	assert.Equal(t, exportFormatSQL, format)
+
+	format, err = resolveImportFormat(newCmd(), "csv")
+	require.NoError(t, err)
+	assert.Equal(t, exportFormatCSV, format)
+
+	cmd := newCmd()
+	require.NoError(t, cmd.Flags().Set("format", "sql"))
+	format, err = resolveImportFormat(cmd, "json")
+	require.NoError(t, err)
+	assert.Equal(t, exportFormatSQL, format)
+
+	format, err = resolveImportFormat(newCmd(), "parquet")
+	require.NoError(t, err)
+	assert.Equal(t, exportFormatParquet, format)
+
+	_, err = resolveImportFormat(newCmd(), "xml")
+	assert.Error(t, err)
+}
+
+func TestReadSchemaSQL(t *testing.T) {
+	dir := t.TempDir()
+	schemaJSON := `{"__sql_mode": "STRICT_TRANS_TABLES", "users": "CREATE TABLE users (id INT);", "orders": "CREATE TABLE orders (id INT);"}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0_schema.json"), []byte(schemaJSON), 0644))
+
+	schema, err := readSchemaSQL(dir, exportFormatJSON, []string{"orders", "users"})
+	require.NoError(t, err)
+	assert.Equal(t, "-- SQL_MODE=STRICT_TRANS_TABLES\n\n"+
+		"-- Table structure for orders\nCREATE TABLE orders (id INT);\n\n\n"+
+		"-- Table structure for users\nCREATE TABLE users (id INT);\n", string(schema))
+
+	_, err = readSchemaSQL(dir, exportFormatSQL, nil)
+	assert.Error(t, err)
+}
+
+func TestCSVToInsertStatements(t *testing.T) {
+	data := "id,name,note\n1,alice,\\N\n2,\"o'brien, jr\",ok\n"
+	statements, err := csvToInsertStatements(db.DriverMySQL, "users", []byte(data), "\\N")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"INSERT INTO `users` (`id`, `name`, `note`) VALUES\n" +
+		"('1', 'alice', NULL),\n('2', 'o''brien, jr', 'ok');"}, statements)
+
+	var rows strings.Builder
+	rows.WriteString("id\n")
+	for i := 0; i < csvImportBatchSize+1; i++ {
+		rows.WriteString("1\n")
+	}
+	statements, err = csvToInsertStatements(db.DriverPostgres, "users", []byte(rows.String()), "")
+	require.NoError(t, err)
+	assert.Len(t, statements, 2)
+
+	statements, err = csvToInsertStatements(db.DriverMySQL, "users", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, statements)
+}
+
+func TestReadSchemaSQLDescription(t *testing.T) {
+	dir := t.TempDir()
+	schemaJSON := `{"driver": "mysql", "sql_mode": "STRICT_TRANS_TABLES", "tables": [
+		{"name": "users", "columns": [{"name": "id", "type": "int", "nullable": false, "auto_increment": true},
+			{"name": "email", "type": "varchar(255)", "nullable": true}],
+		 "primary_key": ["id"], "indexes": [{"name": "email", "columns": ["email"], "unique": true}]}]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0_schema.json"), []byte(schemaJSON), 0644))
+
+	schema, err := readSchemaSQL(dir, exportFormatJSON, []string{"users"})
+	require.NoError(t, err)
+	assert.Equal(t, "-- SQL_MODE=STRICT_TRANS_TABLES\n\n"+
+		"-- Table structure for users\nCREATE TABLE `users` (\n"+
+		"  `id` int NOT NULL AUTO_INCREMENT,\n"+
+		"  `email` varchar(255),\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  UNIQUE KEY `email` (`email`)\n"+
+		");\n", string(schema))
+
+	// Indexes deferred to 0_indexes.sql are not created with the table
+	require.NoError(t, os.WriteFile(filepath.Join(dir, indexesFileName), []byte("CREATE UNIQUE INDEX `email` ON `users` (`email`);"), 0644))
+	schema, err = readSchemaSQL(dir, exportFormatJSON, []string{"users"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(schema), "UNIQUE KEY")
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	columns := []string{"id", "name", "avatar", "score", "note"}
+	columnTypes := map[string]string{"id": "int", "name": "varchar", "avatar": "blob", "score": "decimal", "note": "text"}
+	rows := []map[string]interface{}{
+		{"id": float64(1), "name": "o'brien", "avatar": "\x00\xff", "score": "12.50", "note": nil},
+		{"id": float64(2), "name": "<b>", "avatar": nil, "score": "3", "note": "line\nbreak"},
+	}
+
+	var data strings.Builder
+	for _, row := range rows {
+		line, err := ndjsonRow(columns, row, columnTypes, nil)
+		require.NoError(t, err)
+		data.WriteString(line + "\n")
+	}
+	lines := strings.Split(strings.TrimSuffix(data.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"id":1,"name":"o'brien","avatar":"AP8=","score":"12.50","note":null}`, lines[0])
+
+	statements, err := ndjsonToInsertStatements(db.DriverMySQL, "users", []byte(data.String()), map[string]bool{"avatar": true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"INSERT INTO `users` (`avatar`, `id`, `name`, `note`, `score`) VALUES\n" +
+		"(X'00ff', 1, 'o''brien', NULL, '12.50'),\n(NULL, 2, '<b>', 'line\\nbreak', '3');"}, statements)
+
+	// Hex encoded binary columns are written as base64 as well
+	line, err := ndjsonRow([]string{"data"}, map[string]interface{}{"data": "00ff"}, nil, map[string]bool{"data": true})
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":"AP8="}`, line)
+
+	// Rows with other columns start a new statement
+	statements, err = ndjsonToInsertStatements(db.DriverPostgres, "users", []byte(`{"id":1,"tags":["a"]}`+"\n"+`{"id":2}`+"\n"+`{"id":true}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"INSERT INTO \"users\" (\"id\", \"tags\") VALUES\n(1, '[\"a\"]');",
+		"INSERT INTO \"users\" (\"id\") VALUES\n(2),\n(TRUE);",
+	}, statements)
+
+	_, err = ndjsonToInsertStatements(db.DriverMySQL, "users", []byte(`{"id":1}`+"\n"+`{"id":`), nil)
+	assert.ErrorContains(t, err, "row 2")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+func newImportStatusCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-status",
+		Short: "Show the progress of an interrupted import",
+		Long: `Show which tables an interrupted import of an export directory or archive has imported, according
+to its checkpoint file (.syncdb_import_checkpoint.json in the directory, {archive}.syncdb_import_checkpoint.json
+next to an archive). Continue the import with import --resume.
+Examples:
+  syncdb import-status --path ./backups/mydb_20240101_120000
+  syncdb import-status --path ./backups/mydb_20240101_120000.zip`,
+		Args: cobra.NoArgs,
+		RunE: runImportStatus,
+	}
+
+	cmd.Flags().StringP("path", "o", "", "Path to the export directory or archive being imported")
+	cmd.MarkFlagRequired("path")
+
+	return cmd
+}
+
+func runImportStatus(cmd *cobra.Command, args []string) error {
+	importPath, _ := cmd.Flags().GetString("path")
+	checkpointPath := importCheckpointPath(importPath)
+	if _, err := os.Stat(checkpointPath); os.IsNotExist(err) {
+		fmt.Printf("No import checkpoint for %s: no import is in progress, or the last import completed\n", importPath)
+		return nil
+	}
+	checkpoint, err := loadImportCheckpoint(checkpointPath)
+	if err != nil {
+		return err
+	}
+	return printImportStatus(os.Stdout, checkpoint)
+}
+
+// printImportStatus writes the share of tables imported according to the
+// checkpoint, followed by the status of each table.
+func printImportStatus(w io.Writer, checkpoint *importCheckpoint) error {
+	tables := slices.Clone(checkpoint.Tables)
+	for _, table := range checkpoint.CompletedTables {
+		if !slices.Contains(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	if len(tables) == 0 {
+		fmt.Fprintln(w, "No tables recorded in the import checkpoint.")
+		return nil
+	}
+
+	completed := len(checkpoint.CompletedTables)
+	fmt.Fprintf(w, "Imported %d of %d tables (%.1f%%)\n\n", completed, len(tables), float64(completed)*100/float64(len(tables)))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tSTATUS")
+	for _, table := range tables {
+		status := "pending"
+		if checkpoint.completed(table) {
+			status = "imported"
+		} else if chunks := checkpoint.startChunk(table); chunks > 0 {
+			status = fmt.Sprintf("in progress (%d chunks imported)", chunks)
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", table, status)
+	}
+	return tw.Flush()
+}
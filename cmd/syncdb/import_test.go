@@ -0,0 +1,553 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateTempDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, validateTempDir(""))
+	assert.NoError(t, validateTempDir(dir))
+
+	// No write-test files are left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.Error(t, validateTempDir(filepath.Join(dir, "missing")))
+
+	file := filepath.Join(dir, "file.txt")
+	require.NoError(t, os.WriteFile(file, []byte("x"), 0644))
+	assert.Error(t, validateTempDir(file))
+}
+
+// chdirTemp switches into a fresh temp directory for the duration of the test.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+func TestImportChunks(t *testing.T) {
+	chunks := []string{
+		"INSERT INTO users VALUES (1);",
+		"INSERT INTO users VALUES (2",
+		"",
+		"INSERT INTO users VALUES (3);",
+	}
+	execute := func(_ int, chunk string) error {
+		if !strings.HasSuffix(chunk, ";") {
+			return errors.New("syntax error")
+		}
+		return nil
+	}
+
+	t.Run("Continue on error records failures and keeps going", func(t *testing.T) {
+		chdirTemp(t)
+		result := &ImportResult{}
+		processed, err := importChunks(chunks, "2_users.sql", 0, true, execute, result)
+		require.NoError(t, err)
+		assert.Equal(t, 2, processed)
+		assert.Equal(t, 2, result.ChunksImported)
+		assert.Equal(t, 1, result.ChunksFailed)
+		require.Len(t, result.Errors, 1)
+		assert.Equal(t, "users", result.Errors[0].Table)
+		assert.Equal(t, 2, result.Errors[0].ChunkIndex)
+
+		data, err := os.ReadFile("users_errors.sql")
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "-- chunk 2: syntax error")
+		assert.Contains(t, string(data), "INSERT INTO users VALUES (2")
+	})
+
+	t.Run("Aborts on first error by default", func(t *testing.T) {
+		chdirTemp(t)
+		result := &ImportResult{}
+		processed, err := importChunks(chunks, "2_users.sql", 0, false, execute, result)
+		assert.Error(t, err)
+		assert.Equal(t, 1, processed)
+		assert.Empty(t, result.Errors)
+		assert.FileExists(t, "users_chunk_2_error.sql")
+	})
+}
+
+func TestDataFileTable(t *testing.T) {
+	available := map[string]bool{"users": true, "log_chunk1": true}
+	testCases := []struct {
+		fileName string
+		table    string
+		format   string
+		chunk    int
+	}{
+		{"2_users.sql", "users", exportFormatSQL, 0},
+		{"2_users.csv", "users", exportFormatCSV, 0},
+		{"2_users.json", "users", exportFormatJSON, 0},
+		{"2_users.txt", "", "", 0},
+		{"2_users.sql.gz", "users", exportFormatSQL, 0},
+		{"2_users.json.gz", "", "", 0},
+		{"users.sql", "", "", 0},
+		{"2_users_chunk1.sql", "users", exportFormatSQL, 1},
+		{"2_users_chunk12.csv", "users", exportFormatCSV, 12},
+		{"2_users_part2.sql", "users", exportFormatSQL, 2},
+		{"2_users_part3.sql.gz", "users", exportFormatSQL, 3},
+		{"3_log_chunk1.sql", "log_chunk1", exportFormatSQL, 0},
+		{"3_log_chunk1_chunk2.sql", "log_chunk1", exportFormatSQL, 2},
+		{"4_orders_chunk1.sql", "orders_chunk1", exportFormatSQL, 0},
+	}
+	for _, tc := range testCases {
+		table, format, chunk := dataFileTable(tc.fileName, available)
+		assert.Equal(t, tc.table, table, tc.fileName)
+		assert.Equal(t, tc.format, format, tc.fileName)
+		assert.Equal(t, tc.chunk, chunk, tc.fileName)
+	}
+
+	// Without a table list the suffix is always taken as a chunk number
+	table, _, chunk := dataFileTable("2_users_chunk3.sql", nil)
+	assert.Equal(t, "users", table)
+	assert.Equal(t, 3, chunk)
+}
+
+func TestEmptyStringsToNull(t *testing.T) {
+	chunk := "INSERT INTO `users` (`id`, `name`, `note`, `count`) VALUES\n" +
+		"(1, '', 'it''s', 0),\n(2, 'a\\'b', '', ''''),\n(3, NULL, '0', '');"
+	expected := "INSERT INTO `users` (`id`, `name`, `note`, `count`) VALUES\n" +
+		"(1, NULL, 'it''s', 0),\n(2, 'a\\'b', NULL, ''''),\n(3, NULL, '0', NULL);"
+	assert.Equal(t, expected, emptyStringsToNull(chunk))
+
+	// Empty binary literals are kept
+	chunk = "INSERT INTO `files` (`data`) VALUES\n(X''),\n(E'');"
+	assert.Equal(t, chunk, emptyStringsToNull(chunk))
+}
+
+func TestExcludedColumnsHint(t *testing.T) {
+	t.Run("Import without a NOT NULL column names the excluded column", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer mockDB.Close()
+		conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO").WillReturnError(
+			errors.New(`pq: null value in column "password_hash" of relation "users" violates not-null constraint`))
+		mock.ExpectRollback()
+
+		err = excludedColumnsHint(db.ExecuteData(conn, `INSERT INTO "users" ("id") VALUES (1);`), []string{"password_hash"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "violates not-null constraint")
+		assert.Contains(t, err.Error(), "columns password_hash were excluded from this export")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Errors are unchanged without excluded columns", func(t *testing.T) {
+		chunkErr := errors.New("syntax error")
+		assert.Equal(t, chunkErr, excludedColumnsHint(chunkErr, nil))
+		assert.NoError(t, excludedColumnsHint(nil, []string{"password_hash"}))
+	})
+}
+
+func TestDownloadRemoteExport(t *testing.T) {
+	store := &fakeStorage{objects: map[string][]byte{
+		"backups/mydb_20240301_080000/0_metadata.json": []byte(`{"database":"mydb"}`),
+		"backups/mydb_20240301_080000/1_users.sql":     []byte("INSERT INTO users (id) VALUES\n(1);"),
+		"backups/mydb_20240301_080000_old/1_users.sql": []byte("ignored"),
+		"backups/mydb_20240401_000000.zip":             []byte("zip data"),
+	}}
+
+	t.Run("Directory", func(t *testing.T) {
+		localPath, err := downloadRemoteExport(store, "backups/mydb_20240301_080000", t.TempDir())
+		require.NoError(t, err)
+		assert.True(t, storage.IsExportPath(localPath))
+		entries, err := os.ReadDir(localPath)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("Archive", func(t *testing.T) {
+		localPath, err := downloadRemoteExport(store, "backups/mydb_20240401_000000.zip", t.TempDir())
+		require.NoError(t, err)
+		assert.Equal(t, ".zip", filepath.Ext(localPath))
+		data, err := os.ReadFile(localPath)
+		require.NoError(t, err)
+		assert.Equal(t, "zip data", string(data))
+	})
+
+	t.Run("Missing export", func(t *testing.T) {
+		_, err := downloadRemoteExport(store, "backups/otherdb_20240101_000000", t.TempDir())
+		assert.EqualError(t, err, "no files found under backups/otherdb_20240101_000000")
+	})
+}
+
+func TestGetImportPathTargetDatabase(t *testing.T) {
+	base := t.TempDir()
+	exportDir := filepath.Join(base, "prod_20240101_120000")
+	require.NoError(t, os.MkdirAll(exportDir, 0755))
+
+	// The latest export is looked up by the exported database, not the target
+	cmdArgs := &CommonArgs{Storage: "local", Path: base, Database: "prod", TargetDatabase: "staging"}
+	importPath, err := getImportPath(cmdArgs)
+	require.NoError(t, err)
+	assert.Equal(t, exportDir, importPath)
+
+	// Without --database there is nothing to look up
+	cmdArgs = &CommonArgs{Storage: "local", Path: base, TargetDatabase: "staging"}
+	_, err = getImportPath(cmdArgs)
+	assert.ErrorContains(t, err, "not an export directory")
+}
+
+func TestScheduleTables(t *testing.T) {
+	deps := map[string][]string{
+		"orders":      {"users", "products"},
+		"order_items": {"orders", "products"},
+		"users":       {"users"},   // self-reference is ignored
+		"products":    {"vendors"}, // not imported
+	}
+	tables := []string{"order_items", "orders", "products", "users", "tags"}
+
+	var mu sync.Mutex
+	var finished []string
+	err := scheduleTables(tables, deps, 3, func(worker int, table string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Less(t, worker, 3)
+		for _, dep := range deps[table] {
+			if dep != table && dep != "vendors" {
+				assert.Contains(t, finished, dep, "%s started before %s was imported", table, dep)
+			}
+		}
+		finished = append(finished, table)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, tables, finished)
+
+	// A failure stops the tables that were not started yet
+	var started []string
+	err = scheduleTables(tables, deps, 1, func(worker int, table string) error {
+		started = append(started, table)
+		if table == "users" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "boom")
+	assert.NotContains(t, started, "orders")
+	assert.NotContains(t, started, "order_items")
+
+	// Tables of a dependency cycle are still imported
+	var cycle []string
+	err = scheduleTables([]string{"a", "b"}, map[string][]string{"a": {"b"}, "b": {"a"}}, 2, func(worker int, table string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		cycle = append(cycle, table)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, cycle)
+}
+
+func TestDependencyOrderWarnings(t *testing.T) {
+	relationships := map[string][]string{
+		"orders":      {"users"},
+		"order_items": {"orders", "products"},
+		"users":       {"users"},
+	}
+	assert.Empty(t, dependencyOrderWarnings([]string{"users", "products", "orders", "order_items"}, relationships))
+
+	// --from-table-index 2 skipped users; products comes too late
+	assert.Equal(t, []string{
+		"table orders references users, which is not imported by this run",
+		"table order_items references products, which is imported after it",
+	}, dependencyOrderWarnings([]string{"orders", "order_items", "products"}, relationships))
+}
+
+func TestImportResultMerge(t *testing.T) {
+	result := &ImportResult{ChunksImported: 2, RowsImported: 20}
+	result.merge(&ImportResult{
+		ChunksImported: 1,
+		ChunksFailed:   1,
+		RowsSkipped:    3,
+		RowsImported:   10,
+		Errors:         []ImportError{{Table: "users", ChunkIndex: 2}},
+	})
+	assert.Equal(t, 3, result.ChunksImported)
+	assert.Equal(t, 1, result.ChunksFailed)
+	assert.Equal(t, 3, result.RowsSkipped)
+	assert.Equal(t, int64(30), result.RowsImported)
+	assert.Len(t, result.Errors, 1)
+}
+
+func TestImportPathFlag(t *testing.T) {
+	cmd := newImportCommand()
+	require.NoError(t, cmd.Flags().Parse([]string{"--path", "./prod_schema,./staging_data", "--path", "./extra.zip"}))
+	paths, err := cmd.Flags().GetStringSlice("path")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"./prod_schema", "./staging_data", "./extra.zip"}, paths)
+}
+
+func TestPlanImportSources(t *testing.T) {
+	newSource := func(path string, schema, data bool, tables ...string) *importSource {
+		metadata := &ExportData{}
+		metadata.Metadata.Schema = schema
+		metadata.Metadata.IncludeData = data
+		return &importSource{path: path, metadata: metadata, tables: tables}
+	}
+
+	t.Run("Schema from the first export, data from the others", func(t *testing.T) {
+		prod := newSource("prod", true, false, "users", "orders")
+		staging := newSource("staging", true, true, "users", "orders", "logs")
+		tables := planImportSources([]*importSource{prod, staging}, onDuplicateSkip, false)
+		assert.Equal(t, []string{"users", "orders", "logs"}, tables)
+		assert.Equal(t, []string{"users", "orders"}, prod.schemaTables)
+		assert.Empty(t, prod.dataTables)
+		assert.Empty(t, staging.schemaTables)
+		assert.Equal(t, []string{"users", "orders", "logs"}, staging.dataTables)
+	})
+
+	t.Run("Merged schema", func(t *testing.T) {
+		prod := newSource("prod", true, false, "users", "orders")
+		staging := newSource("staging", true, true, "users", "orders", "logs")
+		planImportSources([]*importSource{prod, staging}, onDuplicateSkip, true)
+		assert.Equal(t, []string{"users", "orders"}, prod.schemaTables)
+		assert.Equal(t, []string{"logs"}, staging.schemaTables)
+	})
+
+	t.Run("Duplicate data", func(t *testing.T) {
+		first := newSource("first", false, true, "users", "orders")
+		second := newSource("second", false, true, "orders", "tags")
+
+		planImportSources([]*importSource{first, second}, onDuplicateSkip, false)
+		assert.Equal(t, []string{"users", "orders"}, first.dataTables)
+		assert.Equal(t, []string{"tags"}, second.dataTables)
+
+		tables := planImportSources([]*importSource{first, second}, onDuplicateOverwrite, false)
+		assert.Equal(t, []string{"users", "orders", "tags"}, tables)
+		assert.Equal(t, []string{"users"}, first.dataTables)
+		assert.Equal(t, []string{"orders", "tags"}, second.dataTables)
+	})
+
+	t.Run("Pages of a paginated export", func(t *testing.T) {
+		page := func(name string, number int) *importSource {
+			src := newSource(name, true, true, "users", "orders")
+			src.metadata.Metadata.PageNumber = number
+			return src
+		}
+		page1, page2, page3 := page("page1", 1), page("page2", 2), page("page3", 3)
+		sources := []*importSource{page3, page1, page2}
+		sortImportPages(sources)
+		assert.Equal(t, []*importSource{page1, page2, page3}, sources)
+
+		planImportSources(sources, onDuplicateSkip, false)
+		assert.Equal(t, []string{"users", "orders"}, page1.schemaTables)
+		assert.Empty(t, page2.schemaTables)
+		for _, src := range sources {
+			assert.Equal(t, []string{"users", "orders"}, src.dataTables)
+		}
+		assert.False(t, page1.appendData)
+		assert.True(t, page3.appendData)
+
+		// Exports that are not pages keep their order
+		other := newSource("other", false, true, "users")
+		sources = []*importSource{page2, other, page1}
+		sortImportPages(sources)
+		assert.Equal(t, []*importSource{page2, other, page1}, sources)
+	})
+
+	t.Run("Single export", func(t *testing.T) {
+		src := newSource("export", true, true, "users", "orders")
+		assert.Equal(t, []string{"users", "orders"}, planImportSources([]*importSource{src}, onDuplicateSkip, false))
+		assert.Equal(t, src.tables, src.schemaTables)
+		assert.Equal(t, src.tables, src.dataTables)
+	})
+}
+
+func TestImportTxScope(t *testing.T) {
+	dir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"),
+		[]byte("INSERT INTO users (id) VALUES (1);"+separator+"INSERT INTO users (id) VALUES (2);"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2_orders.sql"), []byte("INSERT INTO orders (id) VALUES (1);"), 0644))
+	files := map[string][]dataFile{
+		"users":  {{name: "1_users.sql", format: exportFormatSQL}},
+		"orders": {{name: "2_orders.sql", format: exportFormatSQL}},
+	}
+
+	// Nested like importTables and importSourceData
+	importAll := func(conn *db.Connection, cmdArgs *CommonArgs) error {
+		return withTxScope(conn, cmdArgs, txScopeAll, func() error {
+			for _, table := range []string{"users", "orders"} {
+				err := withTxScope(conn, cmdArgs, txScopeTable, func() error {
+					return importTableData(conn, cmdArgs, dir, &ExportData{}, table, files[table], 0, nil, &ImportResult{})
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	newConn := func(t *testing.T) (*db.Connection, sqlmock.Sqlmock) {
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		t.Cleanup(func() { mockDB.Close() })
+		return &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}, mock
+	}
+	expectInsert := func(mock sqlmock.Sqlmock, table, id string) *sqlmock.ExpectedExec {
+		return mock.ExpectExec("INSERT INTO " + table + " (id) VALUES (" + id + ");")
+	}
+
+	t.Run("chunk", func(t *testing.T) {
+		conn, mock := newConn(t)
+		for _, insert := range []struct {
+			table, id string
+		}{{"users", "1"}, {"users", "2"}, {"orders", "1"}} {
+			mock.ExpectBegin()
+			expectInsert(mock, insert.table, insert.id).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		}
+		require.NoError(t, importAll(conn, &CommonArgs{TxScope: txScopeChunk}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("table", func(t *testing.T) {
+		conn, mock := newConn(t)
+		mock.ExpectBegin()
+		expectInsert(mock, "users", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+		expectInsert(mock, "users", "2").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		expectInsert(mock, "orders", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		require.NoError(t, importAll(conn, &CommonArgs{TxScope: txScopeTable}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("table rolls back all chunks of a failing table", func(t *testing.T) {
+		chdirTemp(t) // The failing chunk is saved in the working directory
+		conn, mock := newConn(t)
+		mock.ExpectBegin()
+		expectInsert(mock, "users", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+		expectInsert(mock, "users", "2").WillReturnError(errors.New("duplicate key"))
+		mock.ExpectRollback()
+		assert.ErrorContains(t, importAll(conn, &CommonArgs{TxScope: txScopeTable}), "duplicate key")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("all", func(t *testing.T) {
+		conn, mock := newConn(t)
+		mock.ExpectBegin()
+		expectInsert(mock, "users", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+		expectInsert(mock, "users", "2").WillReturnResult(sqlmock.NewResult(0, 1))
+		expectInsert(mock, "orders", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		require.NoError(t, importAll(conn, &CommonArgs{TxScope: txScopeAll}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestValidateTxScope(t *testing.T) {
+	assert.NoError(t, validateTxScope(&CommonArgs{TxScope: txScopeChunk, ContinueOnError: true, TxSize: 100, Workers: 4}))
+	assert.NoError(t, validateTxScope(&CommonArgs{TxScope: txScopeTable, Workers: 4}))
+	assert.NoError(t, validateTxScope(&CommonArgs{TxScope: txScopeAll, Workers: 1}))
+	assert.Error(t, validateTxScope(&CommonArgs{TxScope: "file"}))
+	assert.Error(t, validateTxScope(&CommonArgs{TxScope: txScopeTable, ContinueOnError: true}))
+	assert.Error(t, validateTxScope(&CommonArgs{TxScope: txScopeAll, TxSize: 100}))
+	assert.Error(t, validateTxScope(&CommonArgs{TxScope: txScopeAll, Workers: 4}))
+}
+
+func TestImportSchemaViews(t *testing.T) {
+	schema := []byte(`CREATE VIEW big_orders AS SELECT * FROM order_totals WHERE total > 100;
+CREATE TABLE orders (
+id INT
+);
+CREATE VIEW order_totals AS SELECT id, SUM(id) AS total FROM orders GROUP BY id;
+`)
+	assert.Equal(t, []string{
+		"CREATE VIEW big_orders AS SELECT * FROM order_totals WHERE total > 100;\n",
+		"CREATE VIEW order_totals AS SELECT id, SUM(id) AS total FROM orders GROUP BY id;\n",
+	}, parseViewStatements(schema))
+	assert.Equal(t, "big_orders", extractTableNameFromSchema("CREATE OR REPLACE VIEW `big_orders` AS SELECT 1"))
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}
+
+	// Views are created after the tables, retrying the one that needs a later view
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE orders (\nid INT\n);\n").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectExec("CREATE VIEW big_orders AS SELECT * FROM order_totals WHERE total > 100;\n").
+		WillReturnError(errors.New(`relation "order_totals" does not exist`))
+	mock.ExpectExec("CREATE VIEW order_totals AS SELECT id, SUM(id) AS total FROM orders GROUP BY id;\n").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE VIEW big_orders AS SELECT * FROM order_totals WHERE total > 100;\n").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, importSchema(conn, schema, "", "", schemaConflictError))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// A view that can never be created fails the import
+	mock.ExpectExec("CREATE VIEW broken AS SELECT * FROM missing;\n").WillReturnError(errors.New("no such table"))
+	_, err = createViews(conn, []string{"CREATE VIEW broken AS SELECT * FROM missing;\n"})
+	assert.ErrorContains(t, err, "[broken]")
+}
+
+func TestImportSchemaIndexes(t *testing.T) {
+	schema := []byte(`-- Table structure for users
+CREATE TABLE users (id integer NOT NULL, email character varying(255));
+CREATE UNIQUE INDEX users_email_lower ON public.users USING btree (lower((email)::text));
+CREATE INDEX users_active ON users USING btree (id) WHERE (email IS NOT NULL);
+
+-- Table structure for orders
+CREATE TABLE orders (id integer NOT NULL);
+CREATE INDEX orders_id ON ONLY public.orders USING btree (id);
+`)
+	assert.Equal(t, "users", extractTableNameFromSchema("CREATE UNIQUE INDEX users_email_lower ON public.users USING btree (lower((email)::text))"))
+	assert.Equal(t, "orders", extractTableNameFromSchema("CREATE INDEX `idx_person` ON `orders` (`id`)"))
+
+	// Indexes of tables left out by --tables are filtered with their table
+	filtered := string(filterSchemaContent(schema, []string{"users"}))
+	assert.Contains(t, filtered, "users_active")
+	assert.NotContains(t, filtered, "orders_id")
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}
+
+	// Indexes are created in file order once all tables exist
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE users (id integer NOT NULL, email character varying(255));\n").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE UNIQUE INDEX users_email_lower ON public.users USING btree (lower((email)::text));\n").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE INDEX users_active ON users USING btree (id) WHERE (email IS NOT NULL);\n").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	require.NoError(t, importSchema(conn, []byte(filtered), "", "", schemaConflictError))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSkipCreatedViewData(t *testing.T) {
+	src := &importSource{metadata: &ExportData{}}
+	src.metadata.Metadata.Views = []string{"order_totals"}
+	src.schemaTables = []string{"orders", "order_totals"}
+	src.dataTables = []string{"orders", "order_totals"}
+	skipCreatedViewData([]*importSource{src})
+	assert.Equal(t, []string{"orders"}, src.dataTables)
+
+	// Without the view's schema its data is imported
+	src.schemaTables = []string{"orders"}
+	src.dataTables = []string{"orders", "order_totals"}
+	skipCreatedViewData([]*importSource{src})
+	assert.Equal(t, []string{"orders", "order_totals"}, src.dataTables)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/db/hashcatalog"
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+)
+
+// rowHashesFilePrefix starts the name of the hash catalog file of each table
+// written with --incremental: 0_row_hashes_{table}.bin
+const rowHashesFilePrefix = "0_row_hashes_"
+
+// rowHashesFileName returns the name of a table's hash catalog file.
+func rowHashesFileName(table string) string {
+	return rowHashesFilePrefix + table + ".bin"
+}
+
+// isRowHashesFile reports whether fileName is a hash catalog file.
+func isRowHashesFile(fileName string) bool {
+	return strings.HasPrefix(fileName, rowHashesFilePrefix) && strings.HasSuffix(fileName, ".bin")
+}
+
+// findIncrementalBase returns the export whose hash catalogs an incremental
+// export compares against: exportPath itself when an existing export is
+// written again, otherwise the latest export of the database under basePath.
+// It returns "" when there is no previous export.
+func findIncrementalBase(basePath, exportPath, database string) string {
+	if storage.IsExportPath(exportPath) {
+		return exportPath
+	}
+	latest, err := getLatestTimestampDir(basePath, database)
+	if err != nil {
+		return ""
+	}
+	return latest
+}
+
+// filterChangedRows returns the rows of a table that are new or changed since
+// the export in cmdArgs.IncrementalBase, and writes the hash catalog of all
+// rows to exportPath for the next incremental export. Tables without a
+// primary key are exported in full.
+func filterChangedRows(conn *db.Connection, exportPath, table string, cmdArgs *CommonArgs, data []map[string]interface{}) ([]map[string]interface{}, error) {
+	pkColumns, err := db.GetPrimaryKeyColumns(conn, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key for table %s: %v", table, err)
+	}
+	if len(pkColumns) == 0 {
+		fmt.Printf("\nWarning: table '%s' has no primary key, all its rows are exported\n", table)
+		return data, nil
+	}
+
+	previous := hashcatalog.New()
+	if cmdArgs.IncrementalBase != "" {
+		loaded, err := hashcatalog.Load(filepath.Join(cmdArgs.IncrementalBase, rowHashesFileName(table)))
+		switch {
+		case err == nil:
+			previous = loaded
+		case errors.Is(err, os.ErrNotExist):
+			// The table is new or was not exported incrementally before
+		default:
+			return nil, err
+		}
+	}
+
+	changed, current := changedRows(previous, pkColumns, data)
+	if err := current.Save(filepath.Join(exportPath, rowHashesFileName(table))); err != nil {
+		return nil, err
+	}
+	fmt.Printf(" %d of %d rows new or changed...", len(changed), len(data))
+	return changed, nil
+}
+
+// changedRows compares the rows with the previous hash catalog and returns the
+// rows that are new or changed, along with the catalog of all rows.
+func changedRows(previous *hashcatalog.Catalog, pkColumns []string, data []map[string]interface{}) ([]map[string]interface{}, *hashcatalog.Catalog) {
+	current := hashcatalog.New()
+	var changed []map[string]interface{}
+	for _, row := range data {
+		key := hashcatalog.Key(pkColumns, row)
+		hash := hashcatalog.RowHash(row)
+		current.Set(key, hash)
+		if previous.Compare(key, hash) {
+			changed = append(changed, row)
+		}
+	}
+	return changed, current
+}
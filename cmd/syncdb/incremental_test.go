@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db/hashcatalog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedRows(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"id": float64(1), "name": "alice"},
+		{"id": float64(2), "name": "bob"},
+	}
+
+	// Without a previous catalog every row is new
+	changed, first := changedRows(hashcatalog.New(), []string{"id"}, rows)
+	assert.Equal(t, rows, changed)
+	assert.Equal(t, 2, first.Len())
+
+	// Only the changed and the new row are exported the next time
+	next := []map[string]interface{}{
+		{"id": float64(1), "name": "alice"},
+		{"id": float64(2), "name": "bobby"},
+		{"id": float64(3), "name": "carol"},
+	}
+	changed, second := changedRows(first, []string{"id"}, next)
+	assert.Equal(t, next[1:], changed)
+	assert.Equal(t, 3, second.Len())
+
+	changed, _ = changedRows(second, []string{"id"}, next)
+	assert.Empty(t, changed)
+}
+
+func TestIsRowHashesFile(t *testing.T) {
+	assert.Equal(t, "0_row_hashes_users.bin", rowHashesFileName("users"))
+	assert.True(t, isRowHashesFile(rowHashesFileName("order_items")))
+	assert.False(t, isRowHashesFile("0_stats.json"))
+	assert.False(t, isRowHashesFile("users.sql"))
+}
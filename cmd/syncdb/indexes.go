@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// indexesFileName is the file holding the CREATE INDEX statements of an export
+// made with --defer-indexes, grouped under a "-- Indexes for <table>" comment.
+const indexesFileName = "0_indexes.sql"
+
+const indexesHeaderPrefix = "-- Indexes for "
+
+// stripIndexes removes the secondary indexes from the schema definitions and
+// returns them keyed by table name. Only tables with indexes have an entry.
+func stripIndexes(schemaDefinitions map[string]string) (map[string]string, error) {
+	indexDefinitions := make(map[string]string)
+	for table, definition := range schemaDefinitions {
+		tableSQL, indexSQL, err := db.StripIndexesFromCreateTable(definition)
+		if err != nil {
+			return nil, fmt.Errorf("failed to strip indexes of table %s: %v", table, err)
+		}
+		schemaDefinitions[table] = tableSQL
+		if indexSQL != "" {
+			indexDefinitions[table] = indexSQL
+		}
+	}
+	return indexDefinitions, nil
+}
+
+// formatIndexesSQL renders index definitions keyed by table name as the
+// contents of 0_indexes.sql, in the order of tables.
+func formatIndexesSQL(indexDefinitions map[string]string, tables []string) string {
+	var output []string
+	for _, table := range tables {
+		if indexSQL, ok := indexDefinitions[table]; ok {
+			output = append(output, fmt.Sprintf("%s%s\n%s\n", indexesHeaderPrefix, table, indexSQL))
+		}
+	}
+	return strings.Join(output, "\n")
+}
+
+// parseIndexesSQL returns the statements of 0_indexes.sql belonging to tables,
+// in file order. An empty tables list selects every statement.
+func parseIndexesSQL(content string, tables []string) []string {
+	selected := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		selected[table] = true
+	}
+
+	var statements []string
+	include := len(tables) == 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, indexesHeaderPrefix):
+			include = len(tables) == 0 || selected[strings.TrimPrefix(line, indexesHeaderPrefix)]
+		case line == "" || strings.HasPrefix(line, "--"):
+			continue
+		case include:
+			statements = append(statements, line)
+		}
+	}
+	return statements
+}
+
+// importIndexes creates the indexes of tables listed in 0_indexes.sql, if the
+// export has one.
+func importIndexes(conn *db.Connection, importPath string, tables []string) error {
+	content, err := os.ReadFile(filepath.Join(importPath, indexesFileName))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", indexesFileName, err)
+	}
+
+	statements := parseIndexesSQL(string(content), tables)
+	if len(statements) == 0 {
+		return nil
+	}
+	fmt.Printf("Creating %d indexes...\n", len(statements))
+	for _, stmt := range statements {
+		if _, err := conn.DB.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create index: %v\nStatement: %s", err, stmt)
+		}
+	}
+	return nil
+}
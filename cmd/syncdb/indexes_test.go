@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexesRoundTrip(t *testing.T) {
+	schemaDefinitions := map[string]string{
+		"orders": "CREATE TABLE `orders` (\n  `id` int NOT NULL,\n  `user_id` int NOT NULL,\n  PRIMARY KEY (`id`),\n  KEY `idx_user` (`user_id`)\n) ENGINE=InnoDB;",
+		"users":  "CREATE TABLE `users` (\n  `id` int NOT NULL,\n  `email` varchar(255) NOT NULL,\n  PRIMARY KEY (`id`),\n  UNIQUE KEY `email` (`email`)\n) ENGINE=InnoDB;",
+		"tags":   "CREATE TABLE `tags` (\n  `name` varchar(50) NOT NULL,\n  PRIMARY KEY (`name`)\n) ENGINE=InnoDB;",
+	}
+
+	indexDefinitions, err := stripIndexes(schemaDefinitions)
+	require.NoError(t, err)
+	assert.NotContains(t, schemaDefinitions["orders"], "idx_user")
+	assert.NotContains(t, schemaDefinitions["users"], "UNIQUE KEY")
+	assert.NotContains(t, indexDefinitions, "tags")
+
+	content := formatIndexesSQL(indexDefinitions, []string{"orders", "tags", "users"})
+	assert.Equal(t, "-- Indexes for orders\nCREATE INDEX `idx_user` ON `orders` (`user_id`);\n\n"+
+		"-- Indexes for users\nCREATE UNIQUE INDEX `email` ON `users` (`email`);\n", content)
+
+	assert.Equal(t, []string{
+		"CREATE INDEX `idx_user` ON `orders` (`user_id`);",
+		"CREATE UNIQUE INDEX `email` ON `users` (`email`);",
+	}, parseIndexesSQL(content, nil))
+	assert.Equal(t, []string{"CREATE UNIQUE INDEX `email` ON `users` (`email`);"}, parseIndexesSQL(content, []string{"users"}))
+	assert.Empty(t, parseIndexesSQL(content, []string{"tags"}))
+}
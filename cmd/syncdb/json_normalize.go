@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// normalizeJSON rewrites a string holding a JSON object or array in a canonical
+// form, with object keys sorted and insignificant whitespace removed, so the
+// same document is exported identically whatever key order the database
+// returned. The second return value is false for other strings.
+func normalizeJSON(s string) (string, bool) {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" || (trimmed[0] != '{' && trimmed[0] != '[') || !json.Valid([]byte(trimmed)) {
+		return s, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	// Numbers are kept as written instead of going through float64
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return s, false
+	}
+	normalized, err := sortedJSONMarshal(v)
+	if err != nil {
+		return s, false
+	}
+	return string(normalized), true
+}
+
+// sortedJSONMarshal encodes a decoded JSON value with the keys of every
+// object, at any depth, in sorted order. Unlike json.Marshal it leaves <, >
+// and & unescaped, so strings keep their original characters.
+func sortedJSONMarshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeSortedJSON(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeSortedJSON appends the encoding of v to buf (see sortedJSONMarshal).
+func writeSortedJSON(buf *bytes.Buffer, v interface{}) error {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONScalar(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeSortedJSON(buf, value[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, element := range value {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeSortedJSON(buf, element); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	default:
+		return writeJSONScalar(buf, value)
+	}
+	return nil
+}
+
+// writeJSONScalar appends a string, number, boolean or null without HTML escaping.
+func writeJSONScalar(buf *bytes.Buffer, v interface{}) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	// Encode terminates each value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
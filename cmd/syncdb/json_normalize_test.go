@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeJSON(t *testing.T) {
+	// The same document with keys in different orders, as returned by two servers
+	first := `{"user": {"name": "Ann", "id": 7, "tags": [{"b": 2, "a": 1}]}, "active": true}`
+	second := `{ "active":true, "user":{"tags":[{"a":1,"b":2}],"id":7,"name":"Ann"} }`
+	expected := `{"active":true,"user":{"id":7,"name":"Ann","tags":[{"a":1,"b":2}]}}`
+
+	for _, input := range []string{first, second} {
+		normalized, ok := normalizeJSON(input)
+		require.True(t, ok, input)
+		assert.Equal(t, expected, normalized)
+	}
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`[3, {"z": null, "y": [1.50, 12345678901234567890]}]`, `[3,{"y":[1.50,12345678901234567890],"z":null}]`},
+		{`{"html": "<a href='x'>&</a>", "line": "a\nb"}`, `{"html":"<a href='x'>&</a>","line":"a\nb"}`},
+		{`{}`, `{}`},
+	}
+	for _, tc := range testCases {
+		normalized, ok := normalizeJSON(tc.input)
+		require.True(t, ok, tc.input)
+		assert.Equal(t, tc.expected, normalized)
+	}
+
+	// Scalars, invalid JSON and other text are left alone
+	for _, input := range []string{"", "plain text", `"quoted"`, "42", `{"a": }`, "{1,2,3}"} {
+		normalized, ok := normalizeJSON(input)
+		assert.False(t, ok, input)
+		assert.Equal(t, input, normalized)
+	}
+}
+
+func TestFormatColumnValueNormalizeJSON(t *testing.T) {
+	input := `{"b": 1, "a": "it's"}`
+	value, err := formatColumnValue(input, "json", db.DriverMySQL, &CommonArgs{NormalizeJSON: true})
+	require.NoError(t, err)
+	assert.Equal(t, `'{"a":"it''s","b":1}'`, value)
+
+	value, err = formatColumnValue(input, "jsonb", db.DriverPostgres, &CommonArgs{NormalizeJSON: true})
+	require.NoError(t, err)
+	assert.Equal(t, `CAST('{"a":"it''s","b":1}' AS JSONB)`, value)
+
+	value, err = formatColumnValue(input, "json", db.DriverMySQL, &CommonArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, `'{"b": 1, "a": "it''s"}'`, value)
+}
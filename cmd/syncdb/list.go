@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"github.com/spf13/cobra"
+)
+
+// exportInfo describes an export found in storage.
+type exportInfo struct {
+	Name       string    `json:"name"`
+	Database   string    `json:"database"`
+	ExportedAt time.Time `json:"exported_at"`
+	Tables     int       `json:"tables"`
+	Size       int64     `json:"size"` // Estimated size in bytes (-1 if unknown)
+}
+
+func newListCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List available exports",
+		Long: `List exports available in local, S3, Google Drive or GCS storage.
+Examples:
+  syncdb list --path ./backups
+  syncdb list --storage s3 --s3-bucket my-bucket --s3-region us-west-2 --path backups
+  syncdb list --path ./backups --format json`,
+		Args: cobra.NoArgs,
+		RunE: runList,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("path", "o", "", "Path (or key prefix for remote storage) to search for exports (default: current directory for local storage)")
+	flags.StringP("storage", "s", "local", "Storage type (local, s3, gdrive, gcs)")
+	flags.String("s3-bucket", "", "S3 bucket name")
+	flags.String("s3-region", "", "S3 region")
+	flags.String("gdrive-credentials", "", "Google Drive service account credentials file path")
+	flags.String("gdrive-folder", "", "Google Drive folder ID")
+	flags.String("gcs-bucket", "", "Google Cloud Storage bucket name")
+	flags.String("gcs-project", "", "Google Cloud project ID")
+	flags.String("gcs-credentials", "", "Google Cloud service account credentials file path")
+	flags.StringP("format", "f", "table", "Output format (table, json)")
+
+	return cmd
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	storageType, _ := flags.GetString("storage")
+	listPath, _ := flags.GetString("path")
+	format, _ := flags.GetString("format")
+
+	if format != "table" && format != "json" {
+		return fmt.Errorf("invalid format '%s': must be table or json", format)
+	}
+
+	var exports []exportInfo
+	var err error
+	switch storageType {
+	case "local":
+		if listPath == "" {
+			listPath = "."
+		}
+		exports, err = listLocalExports(listPath)
+	case "s3":
+		bucket, _ := flags.GetString("s3-bucket")
+		region, _ := flags.GetString("s3-region")
+		if bucket == "" || region == "" {
+			return fmt.Errorf("s3-bucket and s3-region are required when storage is set to s3")
+		}
+		store := storage.NewS3Storage(bucket, region)
+		if store == nil {
+			return fmt.Errorf("failed to initialize S3 storage")
+		}
+		exports, err = listRemoteExports(store, listPath)
+	case "gdrive":
+		creds, _ := flags.GetString("gdrive-credentials")
+		folder, _ := flags.GetString("gdrive-folder")
+		if creds == "" || folder == "" {
+			return fmt.Errorf("gdrive-credentials and gdrive-folder are required when storage is set to gdrive")
+		}
+		store, storeErr := storage.NewGoogleDriveStorage(creds, folder)
+		if storeErr != nil {
+			return fmt.Errorf("failed to initialize Google Drive storage: %v", storeErr)
+		}
+		// Google Drive file names are flat, so list everything in the folder
+		exports, err = listRemoteExports(store, "")
+	case "gcs":
+		bucket, _ := flags.GetString("gcs-bucket")
+		project, _ := flags.GetString("gcs-project")
+		creds, _ := flags.GetString("gcs-credentials")
+		if bucket == "" {
+			return fmt.Errorf("gcs-bucket is required when storage is set to gcs")
+		}
+		store, storeErr := storage.NewGCSStorage(bucket, project, creds)
+		if storeErr != nil {
+			return fmt.Errorf("failed to initialize GCS storage: %v", storeErr)
+		}
+		exports, err = listRemoteExports(store, listPath)
+	default:
+		return fmt.Errorf("unsupported storage type: %s", storageType)
+	}
+	if err != nil {
+		return err
+	}
+
+	return printExports(os.Stdout, exports, format)
+}
+
+// listLocalExports returns the export directories directly under basePath,
+// newest first.
+func listLocalExports(basePath string) ([]exportInfo, error) {
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+
+	var exports []exportInfo
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(basePath, entry.Name())
+		if !storage.IsExportPath(dir) {
+			continue
+		}
+
+		metadata, err := readMetadataFile(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", dir, err)
+			continue
+		}
+		info := newExportInfo(entry.Name(), metadata)
+		info.Size = dirSize(dir)
+		exports = append(exports, info)
+	}
+
+	sortExports(exports)
+	return exports, nil
+}
+
+// listRemoteExports finds exports in remote storage by looking for metadata
+// objects under prefix and downloading them. Exports that were uploaded as a
+// single archive are listed by name only.
+func listRemoteExports(store storage.Storage, prefix string) ([]exportInfo, error) {
+	objects, err := store.ListObjects(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %v", err)
+	}
+
+	var exports []exportInfo
+	for _, object := range objects {
+		switch {
+		case storage.IsMetadataFile(path.Base(object)):
+			data, err := store.Download(object)
+			if err != nil {
+				return nil, fmt.Errorf("failed to download %s: %v", object, err)
+			}
+			metadata, err := parseMetadata(object, data)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", object, err)
+				continue
+			}
+			info := newExportInfo(path.Base(path.Dir(object)), metadata)
+			info.Size = -1
+			exports = append(exports, info)
+		case detectArchiveFormat(object) != "":
+			exports = append(exports, exportInfo{
+				Name: path.Base(object),
+				Size: -1,
+			})
+		}
+	}
+
+	sortExports(exports)
+	return exports, nil
+}
+
+// newExportInfo builds an exportInfo from the metadata of the export named name.
+func newExportInfo(name string, metadata ExportMetadata) exportInfo {
+	return exportInfo{
+		Name:       name,
+		Database:   metadata.DatabaseName,
+		ExportedAt: metadata.ExportedAt,
+		Tables:     len(metadata.Tables),
+	}
+}
+
+// sortExports orders exports newest first, falling back to name for archives without metadata.
+func sortExports(exports []exportInfo) {
+	sort.SliceStable(exports, func(i, j int) bool {
+		if !exports[i].ExportedAt.Equal(exports[j].ExportedAt) {
+			return exports[i].ExportedAt.After(exports[j].ExportedAt)
+		}
+		return exports[i].Name > exports[j].Name
+	})
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// printExports writes the exports as an aligned table or as JSON.
+func printExports(w io.Writer, exports []exportInfo, format string) error {
+	if format == "json" {
+		if exports == nil {
+			exports = []exportInfo{}
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exports)
+	}
+
+	if len(exports) == 0 {
+		fmt.Fprintln(w, "No exports found.")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDATABASE\tEXPORTED AT\tTABLES\tSIZE")
+	for _, e := range exports {
+		exportedAt, tables, size := "-", "-", "-"
+		if !e.ExportedAt.IsZero() {
+			exportedAt = e.ExportedAt.Format("2006-01-02 15:04:05")
+			tables = fmt.Sprintf("%d", e.Tables)
+		}
+		if e.Size >= 0 {
+			size = formatSize(e.Size)
+		}
+		database := e.Database
+		if database == "" {
+			database = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Name, database, exportedAt, tables, size)
+	}
+	return tw.Flush()
+}
+
+// formatSize renders a byte count in a human readable unit.
+func formatSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", value), ".0") + " " + units[unit]
+}
+
+// parseByteSize parses a size such as 1048576, 512KB, 100MB or 1.5GB. Units
+// are powers of 1024, as printed by formatSize.
+func parseByteSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for i, unit := range []string{"KB", "MB", "GB", "TB"} {
+		if number, ok := strings.CutSuffix(value, unit); ok {
+			value, multiplier = number, int64(1)<<(10*(i+1))
+			break
+		}
+	}
+	if multiplier == 1 {
+		value = strings.TrimSuffix(value, "B")
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid size %q (use bytes or a KB, MB, GB or TB suffix)", s)
+	}
+	return int64(number * float64(multiplier)), nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStorage is an in-memory storage.Storage for list tests.
+type fakeStorage struct {
+	objects map[string][]byte
+}
+
+func (f *fakeStorage) Upload(data []byte, filename string) error {
+	f.objects[filename] = data
+	return nil
+}
+
+func (f *fakeStorage) Download(filename string) ([]byte, error) {
+	data, ok := f.objects[filename]
+	if !ok {
+		return nil, fmt.Errorf("object %s not found", filename)
+	}
+	return data, nil
+}
+
+func (f *fakeStorage) ListObjects(prefix string) ([]string, error) {
+	var names []string
+	for name := range f.objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (f *fakeStorage) DeleteObjects(filenames []string) error {
+	for _, name := range filenames {
+		delete(f.objects, name)
+	}
+	return nil
+}
+
+func (f *fakeStorage) GetLatestZipFile() (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func sampleMetadata(t *testing.T, database string, exportedAt time.Time, tables ...string) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"exported_at":   exportedAt,
+		"database_name": database,
+		"tables":        tables,
+	})
+	require.NoError(t, err)
+	return data
+}
+
+func TestListLocalExports(t *testing.T) {
+	basePath := t.TempDir()
+	older := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	for name, metadata := range map[string][]byte{
+		"mydb_20240101_120000": sampleMetadata(t, "mydb", older, "users"),
+		"mydb_20240201_120000": sampleMetadata(t, "mydb", newer, "users", "orders"),
+	} {
+		dir := filepath.Join(basePath, name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "0_metadata.json"), metadata, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1_users.sql"), []byte("INSERT INTO users VALUES (1);"), 0644))
+	}
+	// Directories without metadata and loose files are ignored
+	require.NoError(t, os.MkdirAll(filepath.Join(basePath, "not_an_export"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(basePath, "notes.txt"), []byte("x"), 0644))
+
+	exports, err := listLocalExports(basePath)
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+
+	assert.Equal(t, "mydb_20240201_120000", exports[0].Name)
+	assert.Equal(t, "mydb", exports[0].Database)
+	assert.Equal(t, 2, exports[0].Tables)
+	assert.True(t, exports[0].ExportedAt.Equal(newer))
+	assert.Greater(t, exports[0].Size, int64(0))
+	assert.Equal(t, "mydb_20240101_120000", exports[1].Name)
+	assert.Equal(t, 1, exports[1].Tables)
+}
+
+func TestListRemoteExports(t *testing.T) {
+	exportedAt := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	store := &fakeStorage{objects: map[string][]byte{
+		"backups/mydb_20240301_083000/0_metadata.json": sampleMetadata(t, "mydb", exportedAt, "users", "orders", "items"),
+		"backups/mydb_20240301_083000/1_users.sql":     []byte("INSERT INTO users VALUES (1);"),
+		"backups/mydb_20240201_120000.zip":             []byte("zip"),
+		"other/mydb_20240101_120000/0_metadata.json":   sampleMetadata(t, "mydb", exportedAt),
+	}}
+
+	exports, err := listRemoteExports(store, "backups")
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+
+	assert.Equal(t, "mydb_20240301_083000", exports[0].Name)
+	assert.Equal(t, "mydb", exports[0].Database)
+	assert.Equal(t, 3, exports[0].Tables)
+	assert.Equal(t, "mydb_20240201_120000.zip", exports[1].Name)
+	assert.True(t, exports[1].ExportedAt.IsZero())
+}
+
+func TestPrintExports(t *testing.T) {
+	exports := []exportInfo{{
+		Name:       "mydb_20240301_083000",
+		Database:   "mydb",
+		ExportedAt: time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC),
+		Tables:     3,
+		Size:       2048,
+	}}
+
+	var table bytes.Buffer
+	require.NoError(t, printExports(&table, exports, "table"))
+	assert.Contains(t, table.String(), "NAME")
+	assert.Contains(t, table.String(), "mydb_20240301_083000")
+	assert.Contains(t, table.String(), "2024-03-01 08:30:00")
+	assert.Contains(t, table.String(), "2 KB")
+
+	var out bytes.Buffer
+	require.NoError(t, printExports(&out, exports, "json"))
+	var decoded []exportInfo
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, exports[0].Name, decoded[0].Name)
+	assert.Equal(t, int64(2048), decoded[0].Size)
+}
+
+func TestParseByteSize(t *testing.T) {
+	for input, expected := range map[string]int64{
+		"1048576": 1048576,
+		"512B":    512,
+		"512KB":   512 << 10,
+		"100MB":   100 << 20,
+		"100mb":   100 << 20,
+		"1.5GB":   3 << 29,
+		"2 TB":    2 << 40,
+	} {
+		size, err := parseByteSize(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, size, input)
+	}
+	for _, input := range []string{"", "MB", "ten", "-1MB", "10PB"} {
+		_, err := parseByteSize(input)
+		assert.Error(t, err, input)
+	}
+}
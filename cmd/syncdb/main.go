@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -10,10 +11,23 @@ import (
 func main() {
 	if err := Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		// Exit code 2 signals a partial import with --continue-on-error
+		var partialErr *partialImportError
+		if errors.As(err, &partialErr) {
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
 
+// Build information, set at build time with
+// -ldflags "-X main.Version=v1.2.3 -X main.GitCommit=abc123 -X main.BuildDate=2024-01-15"
+var (
+	Version   = "dev"
+	GitCommit = "unknown"
+	BuildDate = "unknown"
+)
+
 var (
 	rootCmd = &cobra.Command{
 		Use:   "syncdb",
@@ -37,14 +51,27 @@ func newProfileCommand() *cobra.Command {
 	cmd.AddCommand(newProfileUpdateCommand())
 	cmd.AddCommand(newProfileListCommand())
 	cmd.AddCommand(newProfileDeleteCommand())
+	cmd.AddCommand(newProfileCopyCommand())
+	cmd.AddCommand(newProfileRenameCommand())
 	cmd.AddCommand(newProfileShowCommand()) // Add show command
+	cmd.AddCommand(newProfileExportEnvCommand())
 	return cmd
 }
 
 func init() {
 	rootCmd.AddCommand(newExportCommand())
+	rootCmd.AddCommand(newExportPagesCommand())
 	rootCmd.AddCommand(newImportCommand())
+	rootCmd.AddCommand(newImportStatusCommand())
+	rootCmd.AddCommand(newListCommand())
+	rootCmd.AddCommand(newGDriveListCommand())
+	rootCmd.AddCommand(newStatsCommand())
+	rootCmd.AddCommand(newDoctorCommand())
+	rootCmd.AddCommand(newCatalogCommand())
 	rootCmd.AddCommand(newProfileCommand()) // Add the profile command
+	rootCmd.AddCommand(newCompletionCommand())
+	rootCmd.AddCommand(newVersionCommand())
+	registerFlagCompletions(rootCmd)
 }
 
 func Execute() error {
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of the metadata file, set by --metadata-format
+const (
+	metadataFormatJSON = "json"
+	metadataFormatYAML = "yaml"
+)
+
+// Names of the metadata file in each format
+const (
+	metadataFileJSON = "0_metadata.json"
+	metadataFileYAML = "0_metadata.yaml"
+)
+
+// metadataComments are the comments written above each field of a YAML
+// metadata file, keyed by field name.
+var metadataComments = map[string]string{
+	"exported_at":           "Time the export started",
+	"database_name":         "Database the export was made from",
+	"tables":                "Exported tables and views, in import order",
+	"include_schema":        "Whether 0_schema.sql or 0_schema.json holds the table definitions",
+	"include_view_data":     "Whether the data of views was exported (--include-view-data)",
+	"include_data":          "Whether the export has data files",
+	"base64":                "String values are base64 encoded (--base64-strings)",
+	"base64_blobs":          "Binary values are base64 encoded (--base64-blobs)",
+	"excluded_columns":      "Columns left out of the data files with --exclude-columns",
+	"sample_rate":           "Fraction of rows exported with --sample-rate",
+	"format":                "Format of the schema and data files (sql, json, csv or parquet)",
+	"insert_mode":           "Statement used in the data files (see --insert-mode)",
+	"chunk_counts":          "Number of data files per table written with --chunk-size or --max-file-size",
+	"data_formats":          "Format of each table's data files",
+	"excluded_by_row_count": "Tables left out by --min-rows or --max-rows",
+	"views":                 "Entries of tables that are views",
+	"schema_version":        "Version of the exported schema (--schema-version)",
+	"table_relationships":   "Exported tables each table references through its foreign keys",
+	"stmt_terminator":       "Statement terminator of the SQL files (--stmt-terminator)",
+	"comment_style":         "Comment style of the SQL files (--comment-style)",
+	"page_number":           "Page of rows of each table in this export (--page-number)",
+	"page_size":             "Rows per page of a paginated export (--page-size)",
+	"filter_spec":           "Table filter rules read from --filter-file",
+}
+
+// validateMetadataFormat returns an error if format is not a --metadata-format value.
+func validateMetadataFormat(format string) error {
+	switch format {
+	case metadataFormatJSON, metadataFormatYAML:
+		return nil
+	}
+	return fmt.Errorf("invalid metadata format %q (valid values: json, yaml)", format)
+}
+
+// marshalMetadata encodes metadata in the given format, JSON when empty, and
+// returns the name of the file it is written to.
+func marshalMetadata(metadata ExportMetadata, format string) ([]byte, string, error) {
+	if format != metadataFormatYAML {
+		data, err := json.MarshalIndent(metadata, "", "  ")
+		return data, metadataFileJSON, err
+	}
+
+	// Encode through a node tree to add a comment above each field
+	var doc yaml.Node
+	if err := doc.Encode(metadata); err != nil {
+		return nil, "", err
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key := doc.Content[i]
+		key.HeadComment = metadataComments[key.Value]
+	}
+	data, err := yaml.Marshal(&doc)
+	return data, metadataFileYAML, err
+}
+
+// parseMetadata decodes the metadata file named name, as YAML when its
+// extension is .yaml and as JSON otherwise.
+func parseMetadata(name string, data []byte) (ExportMetadata, error) {
+	var metadata ExportMetadata
+	var err error
+	if path.Ext(name) == ".yaml" {
+		err = yaml.Unmarshal(data, &metadata)
+	} else {
+		err = json.Unmarshal(data, &metadata)
+	}
+	if err != nil {
+		return ExportMetadata{}, fmt.Errorf("failed to parse metadata: %v", err)
+	}
+	return metadata, nil
+}
+
+// readMetadataFile reads the metadata file of the export in dir, preferring
+// 0_metadata.json when both formats are present.
+func readMetadataFile(dir string) (ExportMetadata, error) {
+	for _, name := range storage.MetadataFileNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return ExportMetadata{}, fmt.Errorf("failed to read metadata file: %v", err)
+		}
+		return parseMetadata(name, data)
+	}
+	return ExportMetadata{}, fmt.Errorf("failed to read metadata file: no %s or %s in %s", metadataFileJSON, metadataFileYAML, dir)
+}
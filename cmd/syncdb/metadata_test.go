@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataYAMLRoundTrip(t *testing.T) {
+	metadata := ExportMetadata{
+		ExportedAt:      time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		DatabaseName:    "shop",
+		Tables:          []string{"users", "orders", "order_totals"},
+		Schema:          true,
+		IncludeData:     true,
+		ExcludedColumns: map[string][]string{"users": {"password"}},
+		SampleRate:      0.25,
+		Format:          exportFormatSQL,
+		InsertMode:      insertModeUpsert,
+		ChunkCounts:     map[string]int{"orders": 3},
+		DataFormats:     map[string]string{"users": exportFormatSQL, "orders": exportFormatSQL},
+		Views:           []string{"order_totals"},
+	}
+
+	data, name, err := marshalMetadata(metadata, metadataFormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, "0_metadata.yaml", name)
+	assert.Contains(t, string(data), "# Database the export was made from\ndatabase_name: shop\n")
+	assert.NotContains(t, string(data), "base64_blobs", "empty fields are left out")
+
+	parsed, err := parseMetadata(name, data)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, parsed)
+
+	// JSON stays the default
+	data, name, err = marshalMetadata(metadata, "")
+	require.NoError(t, err)
+	assert.Equal(t, "0_metadata.json", name)
+	parsed, err = parseMetadata(name, data)
+	require.NoError(t, err)
+	assert.Equal(t, metadata, parsed)
+}
+
+func TestReadMetadataFile(t *testing.T) {
+	dir := t.TempDir()
+	_, err := readMetadataFile(dir)
+	assert.Error(t, err)
+	assert.False(t, storage.IsExportPath(dir))
+
+	yamlData, _, err := marshalMetadata(ExportMetadata{DatabaseName: "from_yaml"}, metadataFormatYAML)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0_metadata.yaml"), yamlData, 0644))
+	assert.True(t, storage.IsExportPath(dir))
+	metadata, err := readMetadataFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "from_yaml", metadata.DatabaseName)
+
+	// JSON is preferred when both are present
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0_metadata.json"), []byte(`{"database_name": "from_json"}`), 0644))
+	metadata, err = readMetadataFile(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "from_json", metadata.DatabaseName)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// decimalValueRegex matches the text form of a DECIMAL/NUMERIC value
+var decimalValueRegex = regexp.MustCompile(`^-?\d+\.\d+$`)
+
+// formatFloat renders a float value with Go's shortest representation. NaN
+// and infinities have no SQL literal and are rejected; PostgreSQL columns
+// handle them in formatColumnValue.
+func formatFloat(v float64, bitSize int) (string, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", fmt.Errorf("float value %v cannot be written as a SQL literal", v)
+	}
+	if bitSize == 32 {
+		return fmt.Sprintf("%v", float32(v)), nil
+	}
+	return fmt.Sprintf("%v", v), nil
+}
+
+// formatFloatColumn renders the value of a FLOAT/DOUBLE column with exactly
+// --float-precision digits after the decimal point. ok is false when val is
+// not a finite float, the column is not a float column or no precision is
+// set. Integer columns are left alone: exported data is decoded from JSON,
+// so their values are float64 as well.
+func formatFloatColumn(val interface{}, columnType string, cmdArgs *CommonArgs) (string, bool) {
+	v, bitSize, ok := floatValue(val)
+	if !ok || cmdArgs.FloatPrecision == nil || !isFloatType(columnType) || math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", false
+	}
+	formatted := strconv.FormatFloat(v, 'f', *cmdArgs.FloatPrecision, bitSize)
+	if cmdArgs.StripDecimalZeros {
+		formatted = stripTrailingZeros(formatted)
+	}
+	return formatted, true
+}
+
+// postgresFloatLiteral returns the literal of a non-finite float value, which
+// PostgreSQL float columns accept as the strings 'NaN', 'Infinity' and
+// '-Infinity'.
+func postgresFloatLiteral(v float64) (string, bool) {
+	switch {
+	case math.IsNaN(v):
+		return "'NaN'", true
+	case math.IsInf(v, 1):
+		return "'Infinity'", true
+	case math.IsInf(v, -1):
+		return "'-Infinity'", true
+	}
+	return "", false
+}
+
+// floatValue returns val as a float64 with its bit size, if it is a float.
+func floatValue(val interface{}) (float64, int, bool) {
+	switch v := val.(type) {
+	case float32:
+		return float64(v), 32, true
+	case float64:
+		return v, 64, true
+	}
+	return 0, 0, false
+}
+
+// stripTrailingZeros removes the zeros after the last significant digit of
+// a decimal number, and the decimal point if nothing follows it: 1.23000
+// becomes 1.23 and 5.000 becomes 5. Numbers without a decimal point or with
+// an exponent are returned unchanged.
+func stripTrailingZeros(s string) string {
+	if !strings.Contains(s, ".") || strings.ContainsAny(s, "eE") {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// isFloatType reports whether a MySQL DATA_TYPE or PostgreSQL udt_name is an
+// approximate (floating point) numeric type.
+func isFloatType(columnType string) bool {
+	switch strings.ToLower(columnType) {
+	case "float", "double", "real", "float4", "float8":
+		return true
+	}
+	return false
+}
+
+// isDecimalType reports whether a MySQL DATA_TYPE or PostgreSQL udt_name is an
+// exact decimal type.
+func isDecimalType(columnType string) bool {
+	switch strings.ToLower(columnType) {
+	case "decimal", "numeric":
+		return true
+	}
+	return false
+}
+
+// formatDecimalString applies --decimal-strip-trailing-zeros to the text form
+// of a DECIMAL/NUMERIC column value. Values that are not plain decimals are
+// returned unchanged.
+func formatDecimalString(s, columnType string, cmdArgs *CommonArgs) string {
+	if !cmdArgs.StripDecimalZeros || !isDecimalType(columnType) || !decimalValueRegex.MatchString(s) {
+		return s
+	}
+	return stripTrailingZeros(s)
+}
+
+// formatNonFiniteFloat returns the literal of a NaN or infinite float value
+// for the driver, if val is one and the driver has a literal for it.
+func formatNonFiniteFloat(val interface{}, driver string) (string, bool) {
+	v, _, ok := floatValue(val)
+	if !ok || driver != db.DriverPostgres {
+		return "", false
+	}
+	return postgresFloatLiteral(v)
+}
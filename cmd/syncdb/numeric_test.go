@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatNumericValues(t *testing.T) {
+	two, zero, twelve := 2, 0, 12
+	testCases := []struct {
+		name       string
+		args       CommonArgs
+		value      interface{}
+		columnType string
+		driver     string
+		expected   string
+	}{
+		{"double unlimited", CommonArgs{}, 1.23456789012345, "double", db.DriverMySQL, "1.23456789012345"},
+		{"double rounded", CommonArgs{FloatPrecision: &two}, 1.23456789012345, "double", db.DriverMySQL, "1.23"},
+		{"double rounded half", CommonArgs{FloatPrecision: &two}, 2.675, "double", db.DriverMySQL, "2.67"},
+		{"float precision zero", CommonArgs{FloatPrecision: &zero}, 3.5, "float", db.DriverMySQL, "4"},
+		{"rounded zeros stripped", CommonArgs{FloatPrecision: &two, StripDecimalZeros: true}, 1.5, "float8", db.DriverPostgres, "1.5"},
+		{"rounded zeros kept", CommonArgs{FloatPrecision: &two}, 1.5, "float8", db.DriverPostgres, "1.50"},
+		{"float32", CommonArgs{}, float32(0.1), "float", db.DriverMySQL, "0.1"},
+		{"float32 rounded", CommonArgs{FloatPrecision: &two}, float32(0.125), "float", db.DriverMySQL, "0.12"},
+		{"very small fraction", CommonArgs{}, 1e-10, "double", db.DriverMySQL, "1e-10"},
+		{"very small fraction rounded", CommonArgs{FloatPrecision: &two, StripDecimalZeros: true}, 1e-10, "double", db.DriverMySQL, "0"},
+		{"very small fraction full precision", CommonArgs{FloatPrecision: &twelve}, 1e-10, "double", db.DriverMySQL, "0.000000000100"},
+		{"large integer column is not rounded", CommonArgs{FloatPrecision: &two}, float64(9007199254740993), "bigint", db.DriverMySQL, "9.007199254740992e+15"},
+		{"large double rounded", CommonArgs{FloatPrecision: &two}, 1e21, "double", db.DriverMySQL, "1000000000000000000000.00"},
+		{"json.Number verbatim", CommonArgs{FloatPrecision: &two}, json.Number("12345678901234567890.123456789"), "numeric", db.DriverPostgres, "12345678901234567890.123456789"},
+		{"decimal zeros stripped", CommonArgs{StripDecimalZeros: true}, "1.23000", "decimal", db.DriverMySQL, "'1.23'"},
+		{"decimal integer zeros stripped", CommonArgs{StripDecimalZeros: true}, "-5.000", "numeric", db.DriverPostgres, "'-5'"},
+		{"decimal zeros kept", CommonArgs{}, "1.23000", "decimal", db.DriverMySQL, "'1.23000'"},
+		{"integer decimal unchanged", CommonArgs{StripDecimalZeros: true}, "1000", "decimal", db.DriverMySQL, "'1000'"},
+		{"varchar unchanged", CommonArgs{StripDecimalZeros: true}, "1.10", "varchar", db.DriverMySQL, "'1.10'"},
+		{"NaN on PostgreSQL", CommonArgs{}, math.NaN(), "float8", db.DriverPostgres, "'NaN'"},
+		{"Inf on PostgreSQL", CommonArgs{FloatPrecision: &two}, math.Inf(1), "float8", db.DriverPostgres, "'Infinity'"},
+		{"-Inf on PostgreSQL", CommonArgs{}, math.Inf(-1), "float8", db.DriverPostgres, "'-Infinity'"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := formatColumnValue(tc.value, tc.columnType, tc.driver, &tc.args)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+		})
+	}
+
+	// MySQL has no literal for NaN and infinities
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		_, err := formatColumnValue(v, "double", db.DriverMySQL, &CommonArgs{})
+		assert.Error(t, err)
+	}
+}
+
+func TestStripTrailingZeros(t *testing.T) {
+	assert.Equal(t, "1.23", stripTrailingZeros("1.23000"))
+	assert.Equal(t, "5", stripTrailingZeros("5.000"))
+	assert.Equal(t, "100", stripTrailingZeros("100"))
+	assert.Equal(t, "1.5e+10", stripTrailingZeros("1.5e+10"))
+	assert.Equal(t, "0", stripTrailingZeros("0.00"))
+}
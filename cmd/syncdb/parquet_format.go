@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/parquet"
+)
+
+// exportFormatParquet writes each table's data to a Parquet file, with the
+// schema as JSON like --format json
+const exportFormatParquet = "parquet"
+
+// parquetTimestampLayout renders timestamps read from Parquet files as SQL
+// literals, in UTC
+const parquetTimestampLayout = "2006-01-02 15:04:05.999999"
+
+// writeParquetDataFile writes the rows of a table to {index}_{table}.parquet
+// and returns the path of the file. Column types come from mapper; binary
+// columns exported as hex (hexColumns) are decoded and stored as bytes.
+func writeParquetDataFile(exportPath string, table string, tableIndex int, columns []string, columnTypes map[string]string, hexColumns map[string]bool, data []map[string]interface{}, mapper db.TypeMapper) (string, error) {
+	parquetColumns := make([]parquet.Column, len(columns))
+	for i, col := range columns {
+		parquetColumns[i] = parquet.Column{Name: col, Type: mapper.ParquetType(columnTypes[col])}
+		if hexColumns[col] {
+			parquetColumns[i].Type = parquet.BytesType
+		}
+	}
+
+	path := filepath.Join(exportPath, dataFileName(tableIndex, table, 0, exportFormatParquet))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	defer file.Close()
+	out := bufio.NewWriter(file)
+	writer, err := parquet.NewWriter(out, parquetColumns)
+	if err != nil {
+		return "", err
+	}
+	for _, row := range data {
+		values := make([]interface{}, len(columns))
+		for i, col := range columns {
+			if values[i], err = parquetValue(row[col], parquetColumns[i].Type, hexColumns[col]); err != nil {
+				return "", fmt.Errorf("column %s in table %s: %v", col, table, err)
+			}
+		}
+		if err := writer.Write(values); err != nil {
+			return "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	if err := out.Flush(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return path, file.Close()
+}
+
+// parquetValue converts an exported column value (see db.ExportTableData) to
+// the value written to a Parquet column of type typ. MySQL zero dates are
+// written as NULL.
+func parquetValue(value interface{}, typ parquet.Type, hexEncoded bool) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch typ {
+	case parquet.BooleanType:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case float64:
+			return v != 0, nil
+		case json.Number:
+			return v.String() != "0", nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid boolean value %q", v)
+			}
+			return b, nil
+		}
+	case parquet.Int64Type:
+		switch v := value.(type) {
+		case float64:
+			return int64(v), nil
+		case json.Number:
+			n, err := strconv.ParseInt(v.String(), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer value %q: %v", v, err)
+			}
+			return n, nil
+		case string:
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer value %q: %v", v, err)
+			}
+			return n, nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case parquet.DoubleType:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", v)
+			}
+			return f, nil
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", v)
+			}
+			return f, nil
+		}
+	case parquet.DateType, parquet.TimestampType:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(s, "0000-00-00") {
+			return nil, nil
+		}
+		if typ == parquet.DateType && len(s) >= len(time.DateOnly) {
+			if t, err := time.Parse(time.DateOnly, s[:len(time.DateOnly)]); err == nil {
+				return t, nil
+			}
+		} else if t, ok := parseDateTime(s); ok {
+			return t, nil
+		}
+		return nil, fmt.Errorf("invalid date or time value %q", s)
+	case parquet.BytesType:
+		s, ok := value.(string)
+		if !ok {
+			break
+		}
+		if hexEncoded {
+			data, err := hex.DecodeString(s)
+			if err != nil {
+				return nil, err
+			}
+			return data, nil
+		}
+		return []byte(s), nil
+	default:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case json.Number:
+			return v.String(), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %T value", value)
+}
+
+// parquetToInsertStatements converts a Parquet data file into INSERT
+// statements of up to csvImportBatchSize rows.
+func parquetToInsertStatements(driver, table string, data []byte) ([]string, error) {
+	columns, rows, err := parquet.Read(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet data for table %s: %v", table, err)
+	}
+	columnNames := make([]string, len(columns))
+	for i, col := range columns {
+		columnNames[i] = col.Name
+	}
+
+	var statements []string
+	for start := 0; start < len(rows); start += csvImportBatchSize {
+		batch := rows[start:min(start+csvImportBatchSize, len(rows))]
+		valueStrings := make([]string, len(batch))
+		for i, row := range batch {
+			values := make([]string, len(row))
+			for j, value := range row {
+				values[j] = parquetValueToSQL(driver, value, columns[j].Type)
+			}
+			valueStrings[i] = "(" + strings.Join(values, ", ") + ")"
+		}
+		stmt, err := buildInsertStatement(driver, insertModeInsert, table, columnNames, nil, valueStrings)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	return statements, nil
+}
+
+// parquetValueToSQL renders a value read from a Parquet file (see parquet.Read)
+// as a SQL literal.
+func parquetValueToSQL(driver string, value interface{}, typ parquet.Type) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int32:
+		if typ.Logical == parquet.LogicalDate {
+			return "'" + time.Unix(int64(v)*86400, 0).UTC().Format(time.DateOnly) + "'"
+		}
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		switch typ.Logical {
+		case parquet.LogicalTimestampMillis:
+			return "'" + time.UnixMilli(v).UTC().Format(parquetTimestampLayout) + "'"
+		case parquet.LogicalTimestampMicros:
+			return "'" + time.UnixMicro(v).UTC().Format(parquetTimestampLayout) + "'"
+		}
+		return strconv.FormatInt(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		if typ.Logical == parquet.LogicalString {
+			return quoteSQLString(string(v))
+		}
+		return hexLiteral(hex.EncodeToString(v), driver)
+	}
+	return "NULL"
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/parquet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetRoundTrip(t *testing.T) {
+	columns := []string{"id", "name", "active", "avatar", "score", "born", "created_at", "price"}
+	columnTypes := map[string]string{"id": "int", "name": "varchar", "active": "bool", "avatar": "blob", "score": "double",
+		"born": "date", "created_at": "datetime", "price": "decimal"}
+	rows := []map[string]interface{}{
+		{"id": "1", "name": "o'brien", "active": "t", "avatar": "00ff", "score": float64(9.5),
+			"born": "1990-05-01", "created_at": "2024-01-15 10:30:00.123456", "price": "12.50"},
+		{"id": float64(2), "name": nil, "active": false, "avatar": nil, "score": nil,
+			"born": "0000-00-00", "created_at": "2024-01-15T10:30:00+02:00", "price": nil},
+	}
+
+	dir := t.TempDir()
+	path, err := writeParquetDataFile(dir, "users", 3, columns, columnTypes, map[string]bool{"avatar": true}, rows, db.DefaultTypeMapper{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "3_users.parquet"), path)
+
+	table, format := extractTableNameFromFile("3_users.parquet")
+	assert.Equal(t, "users", table)
+	assert.Equal(t, exportFormatParquet, format)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	fileColumns, _, err := parquet.Read(data)
+	require.NoError(t, err)
+	assert.Equal(t, parquet.BytesType, fileColumns[3].Type)
+	assert.Equal(t, parquet.StringType, fileColumns[7].Type)
+
+	statements, err := parquetToInsertStatements(db.DriverMySQL, "users", data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"INSERT INTO `users` (`id`, `name`, `active`, `avatar`, `score`, `born`, `created_at`, `price`) VALUES\n" +
+		"(1, 'o''brien', TRUE, X'00ff', 9.5, '1990-05-01', '2024-01-15 10:30:00.123456', '12.50'),\n" +
+		"(2, NULL, FALSE, NULL, NULL, NULL, '2024-01-15 08:30:00', NULL);"}, statements)
+
+	_, err = writeParquetDataFile(dir, "users", 3, []string{"id"}, columnTypes, nil, []map[string]interface{}{{"id": "one"}}, db.DefaultTypeMapper{})
+	assert.ErrorContains(t, err, "column id in table users")
+
+	_, err = parquetToInsertStatements(db.DriverMySQL, "users", []byte("id,name\n"))
+	assert.ErrorContains(t, err, "failed to read Parquet data")
+}
+
+func TestParquetLargeIntegers(t *testing.T) {
+	// Rows are decoded with UseNumber on the export path, as a float64 would
+	// round 9007199254740993 to 9007199254740992
+	decoder := json.NewDecoder(strings.NewReader(`{"id": 9007199254740993, "score": 1.5, "name": 42}`))
+	decoder.UseNumber()
+	var row map[string]interface{}
+	require.NoError(t, decoder.Decode(&row))
+
+	dir := t.TempDir()
+	columnTypes := map[string]string{"id": "bigint", "score": "double", "name": "varchar"}
+	path, err := writeParquetDataFile(dir, "users", 1, []string{"id", "score", "name"}, columnTypes, nil, []map[string]interface{}{row}, db.DefaultTypeMapper{})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	_, rows, err := parquet.Read(data)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, int64(9007199254740993), rows[0][0])
+	assert.Equal(t, 1.5, rows[0][1])
+	assert.Equal(t, []byte("42"), rows[0][2])
+
+	statements, err := parquetToInsertStatements(db.DriverMySQL, "users", data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"INSERT INTO `users` (`id`, `score`, `name`) VALUES\n(9007199254740993, 1.5, '42');"}, statements)
+
+	_, err = parquetValue(json.Number("1.5"), parquet.Int64Type, false)
+	assert.ErrorContains(t, err, "invalid integer value")
+}
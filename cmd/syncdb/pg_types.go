@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// pgNumericLiteral matches array elements that can be written without quotes
+var pgNumericLiteral = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// pgArrayElementTypes maps the udt_name of common array element types to the
+// name used in the ::type[] cast. Other types are cast with their udt_name.
+var pgArrayElementTypes = map[string]string{
+	"int2":   "smallint",
+	"int4":   "integer",
+	"int8":   "bigint",
+	"float4": "real",
+	"float8": "double precision",
+	"bool":   "boolean",
+}
+
+// formatColumnValue renders a column value as a SQL literal for its column
+// type. Geometry values are written with formatSpatialValue, timestamps are
+// reformatted when a datetime option is set (see customDateTime), PostgreSQL
+// json/jsonb values are written as CAST('...' AS JSONB) and arrays as
+// ARRAY[...]::type[]; everything else goes through formatSQLValue. With
+// --normalize-json, JSON documents are first rewritten by normalizeJSON.
+func formatColumnValue(val interface{}, columnType, driver string, cmdArgs *CommonArgs) (string, error) {
+	if literal, ok := formatNonFiniteFloat(val, driver); ok {
+		return literal, nil
+	}
+	if literal, ok := formatFloatColumn(val, columnType, cmdArgs); ok {
+		return literal, nil
+	}
+	s, ok := val.(string)
+	if ok {
+		s = formatDecimalString(s, columnType, cmdArgs)
+		val = s
+	}
+	if ok && db.IsSpatialType(columnType) {
+		return formatSpatialValue(s, driver)
+	}
+	if ok && cmdArgs.NormalizeJSON {
+		if normalized, isJSON := normalizeJSON(s); isJSON {
+			s, val = normalized, normalized
+		}
+	}
+	if ok && cmdArgs.customDateTime() && isDateTimeType(columnType) {
+		if t, parsed := parseDateTime(s); parsed {
+			return formatSQLValue(t, cmdArgs)
+		}
+	}
+	// Binary columns arrive as strings; only --base64-blobs encodes them
+	if ok && db.IsBinaryType(columnType) && !(s == "" && cmdArgs.EmptyStringAsNull) {
+		if cmdArgs.Base64Blobs {
+			return formatSQLValue([]byte(s), cmdArgs)
+		}
+		return quoteSQLString(s), nil
+	}
+	// --base64-strings encodes every string, so typed literals would not
+	// survive the round trip
+	if !ok || driver != db.DriverPostgres || cmdArgs.Base64Strings || (s == "" && cmdArgs.EmptyStringAsNull) {
+		return formatSQLValue(val, cmdArgs)
+	}
+	switch {
+	case columnType == "json" || columnType == "jsonb":
+		return fmt.Sprintf("CAST(%s AS %s)", pgStringLiteral(s), strings.ToUpper(columnType)), nil
+	case strings.HasPrefix(columnType, "_"):
+		return formatPostgresArray(s, columnType[1:])
+	}
+	return formatSQLValue(val, cmdArgs)
+}
+
+// formatPostgresArray converts the text form of a PostgreSQL array, e.g.
+// {1,2,3} or {{a,b},{c,"d e"}}, to an ARRAY constructor cast to its type.
+// Arrays with explicit bounds such as [0:1]={1,2} are cast from the text form.
+func formatPostgresArray(text, elementType string) (string, error) {
+	castType := elementType
+	if name, ok := pgArrayElementTypes[elementType]; ok {
+		castType = name
+	}
+	if !strings.HasPrefix(text, "{") {
+		return fmt.Sprintf("%s::%s[]", pgStringLiteral(text), castType), nil
+	}
+
+	array, end, err := parsePostgresArray(text, 0, elementType)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s[] value %q: %v", castType, text, err)
+	}
+	if end != len(text) {
+		return "", fmt.Errorf("invalid %s[] value %q: unexpected data after the array", castType, text)
+	}
+	return fmt.Sprintf("%s::%s[]", array, castType), nil
+}
+
+// parsePostgresArray reads the array starting at text[start] == '{' and
+// returns it as an ARRAY constructor, along with the index after its closing
+// brace. Nested arrays become nested constructors.
+func parsePostgresArray(text string, start int, elementType string) (string, int, error) {
+	var elements []string
+	pos := start + 1
+	for pos < len(text) {
+		switch text[pos] {
+		case '}':
+			return "ARRAY[" + strings.Join(elements, ",") + "]", pos + 1, nil
+		case ',':
+			pos++
+		case '{':
+			nested, end, err := parsePostgresArray(text, pos, elementType)
+			if err != nil {
+				return "", 0, err
+			}
+			elements = append(elements, nested)
+			pos = end
+		case '"':
+			// Quoted element with backslash escapes
+			var value strings.Builder
+			for pos++; pos < len(text) && text[pos] != '"'; pos++ {
+				if text[pos] == '\\' && pos+1 < len(text) {
+					pos++
+				}
+				value.WriteByte(text[pos])
+			}
+			if pos >= len(text) {
+				return "", 0, fmt.Errorf("unterminated quoted element")
+			}
+			elements = append(elements, pgStringLiteral(value.String()))
+			pos++
+		default:
+			end := pos
+			for end < len(text) && text[end] != ',' && text[end] != '}' {
+				end++
+			}
+			elements = append(elements, formatPostgresArrayElement(strings.TrimSpace(text[pos:end]), elementType))
+			pos = end
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated array")
+}
+
+// formatPostgresArrayElement renders an unquoted array element.
+func formatPostgresArrayElement(value, elementType string) string {
+	switch {
+	case strings.EqualFold(value, "NULL"):
+		return "NULL"
+	case elementType == "bool" && value == "t":
+		return "TRUE"
+	case elementType == "bool" && value == "f":
+		return "FALSE"
+	case pgNumericLiteral.MatchString(value):
+		return value
+	}
+	return pgStringLiteral(value)
+}
+
+// pgStringLiteral quotes a string for PostgreSQL. Strings with backslashes or
+// control characters use the E'...' escape syntax, since standard strings
+// take backslashes literally.
+func pgStringLiteral(s string) string {
+	escaped := strings.ReplaceAll(s, "'", "''")
+	if !strings.ContainsFunc(s, func(r rune) bool { return r == '\\' || r < 0x20 }) {
+		return "'" + escaped + "'"
+	}
+	var out strings.Builder
+	for _, r := range escaped {
+		switch {
+		case r == '\\':
+			out.WriteString(`\\`)
+		case r == '\n':
+			out.WriteString(`\n`)
+		case r == '\r':
+			out.WriteString(`\r`)
+		case r == '\t':
+			out.WriteString(`\t`)
+		case r < 0x20:
+			fmt.Fprintf(&out, `\x%02x`, r)
+		default:
+			out.WriteRune(r)
+		}
+	}
+	return "E'" + out.String() + "'"
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatColumnValue(t *testing.T) {
+	cmdArgs := &CommonArgs{}
+	testCases := []struct {
+		name       string
+		value      interface{}
+		columnType string
+		expected   string
+	}{
+		{"JSONB", `{"name": "O'Brien", "tags": ["a", "b"]}`, "jsonb", `CAST('{"name": "O''Brien", "tags": ["a", "b"]}' AS JSONB)`},
+		{"JSON with escapes", `{"text": "line\nbreak"}`, "json", `CAST(E'{"text": "line\\nbreak"}' AS JSON)`},
+		{"Integer array", "{1,2,3}", "_int4", "ARRAY[1,2,3]::integer[]"},
+		{"Empty array", "{}", "_int8", "ARRAY[]::bigint[]"},
+		{"Text array", `{red,"dark blue",NULL,"NULL","say \"hi\""}`, "_text", `ARRAY['red','dark blue',NULL,'NULL','say "hi"']::text[]`},
+		{"Multidimensional array", "{{1,2},{3,4}}", "_int4", "ARRAY[ARRAY[1,2],ARRAY[3,4]]::integer[]"},
+		{"Boolean array", "{t,f}", "_bool", "ARRAY[TRUE,FALSE]::boolean[]"},
+		{"Array with bounds", "[0:1]={1,2}", "_int4", "'[0:1]={1,2}'::integer[]"},
+		{"Plain text", "hello", "text", "'hello'"},
+		{"Number", 42, "int4", "42"},
+		{"NULL", nil, "jsonb", "NULL"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, err := formatColumnValue(tc.value, tc.columnType, db.DriverPostgres, cmdArgs)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, formatted)
+		})
+	}
+
+	t.Run("MySQL keeps the generic format", func(t *testing.T) {
+		formatted, err := formatColumnValue(`{"a": 1}`, "json", db.DriverMySQL, cmdArgs)
+		require.NoError(t, err)
+		assert.Equal(t, `'{"a": 1}'`, formatted)
+	})
+
+	t.Run("Malformed array", func(t *testing.T) {
+		_, err := formatColumnValue(`{1,"2`, "_int4", db.DriverPostgres, cmdArgs)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseInsertStatementWithArrays(t *testing.T) {
+	stmt, ok, err := parseInsertStatement(`INSERT INTO "events" ("id", "tags", "payload") VALUES` + "\n" +
+		`(1, ARRAY['a,b','c']::text[], CAST('{"x": [1, 2]}' AS JSONB)),` + "\n" +
+		`(2, ARRAY[ARRAY[1,2],ARRAY[3,4]]::integer[], NULL);`)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, stmt.values, 2)
+	assert.Equal(t, []string{"1", "ARRAY['a,b','c']::text[]", `CAST('{"x": [1, 2]}' AS JSONB)`}, stmt.values[0])
+	assert.Equal(t, "ARRAY[ARRAY[1,2],ARRAY[3,4]]::integer[]", stmt.values[1][1])
+}
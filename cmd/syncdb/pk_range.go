@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// pkRange is the range of primary key values imported with --pk-min and
+// --pk-max, bounds included. An empty bound leaves that side open.
+type pkRange struct {
+	min string
+	max string
+}
+
+// active reports whether the range has a bound.
+func (r pkRange) active() bool {
+	return r.min != "" || r.max != ""
+}
+
+// validate checks that min is not above max.
+func (r pkRange) validate() error {
+	if r.min != "" && r.max != "" && comparePKValues(r.min, r.max) > 0 {
+		return fmt.Errorf("--pk-min %s is greater than --pk-max %s", r.min, r.max)
+	}
+	return nil
+}
+
+// contains reports whether a primary key literal of an INSERT statement is
+// within the range. NULL is never within it.
+func (r pkRange) contains(literal string) bool {
+	if strings.EqualFold(literal, "NULL") {
+		return false
+	}
+	value := unquoteLiteral(literal)
+	if r.min != "" && comparePKValues(value, r.min) < 0 {
+		return false
+	}
+	return r.max == "" || comparePKValues(value, r.max) <= 0
+}
+
+// comparePKValues compares two primary key values like strings.Compare:
+// numerically when both are numbers, otherwise as strings, which orders
+// ISO dates and timestamps chronologically. Integers are compared exactly,
+// as a float64 cannot tell BIGINT keys above 2^53 apart.
+func comparePKValues(a, b string) int {
+	if i, ok := new(big.Int).SetString(a, 10); ok {
+		if j, ok := new(big.Int).SetString(b, 10); ok {
+			return i.Cmp(j)
+		}
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// filterPKRange removes the rows of an INSERT chunk whose pkColumn value is
+// outside r. It returns the rewritten chunk (empty if no row is left) and the
+// number of rows removed. Chunks that are not INSERT statements are returned
+// unchanged.
+func filterPKRange(tableName, pkColumn string, r pkRange, chunk string) (string, int, error) {
+	stmt, ok, err := parseInsertStatement(chunk)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse data for %s: %v", tableName, err)
+	}
+	if !ok || len(stmt.rows) == 0 {
+		return chunk, 0, nil
+	}
+	pkIndex := -1
+	for i, col := range stmt.columns {
+		if col == pkColumn {
+			pkIndex = i
+		}
+	}
+	if pkIndex < 0 {
+		return "", 0, fmt.Errorf("primary key column %s of table %s is missing from the data file", pkColumn, tableName)
+	}
+
+	var rows []string
+	for i, row := range stmt.rows {
+		if r.contains(stmt.values[i][pkIndex]) {
+			rows = append(rows, row)
+		}
+	}
+	removed := len(stmt.rows) - len(rows)
+	if removed == 0 {
+		return chunk, 0, nil
+	}
+	if len(rows) == 0 {
+		return "", removed, nil
+	}
+	stmt.rows = rows
+	return stmt.String(), removed, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventsInsert returns an INSERT statement for the events with ids from to to.
+func eventsInsert(from, to int) string {
+	rows := make([]string, 0, to-from+1)
+	for id := from; id <= to; id++ {
+		rows = append(rows, fmt.Sprintf("(%d, 'event %d')", id, id))
+	}
+	return "INSERT INTO events (id, name) VALUES\n" + strings.Join(rows, ",\n") + ";"
+}
+
+func TestPKRange(t *testing.T) {
+	r := pkRange{min: "10", max: "20"}
+	assert.True(t, r.contains("10"))
+	assert.True(t, r.contains("'20'"))
+	assert.False(t, r.contains("9"))
+	assert.False(t, r.contains("100"), "numbers are not compared as strings")
+	assert.False(t, r.contains("NULL"))
+	assert.True(t, pkRange{min: "5"}.contains("1000"))
+
+	dates := pkRange{min: "2024-01-01", max: "2024-01-31 23:59:59"}
+	assert.True(t, dates.contains("'2024-01-15 08:00:00'"))
+	assert.False(t, dates.contains("'2024-02-01 00:00:00'"))
+
+	assert.NoError(t, r.validate())
+	assert.Error(t, pkRange{min: "20", max: "10"}.validate())
+	assert.NoError(t, pkRange{min: "9", max: "10"}.validate())
+
+	// BIGINT keys past 2^53 are compared exactly, not as float64
+	big := pkRange{max: "9007199254740992"}
+	assert.True(t, big.contains("9007199254740992"))
+	assert.False(t, big.contains("9007199254740993"))
+	assert.False(t, pkRange{min: "18446744073709551614"}.contains("18446744073709551613"))
+	assert.Error(t, pkRange{min: "9007199254740993", max: "9007199254740992"}.validate())
+	assert.True(t, pkRange{min: "1", max: "2"}.contains("1.5"))
+}
+
+func TestFilterPKRange(t *testing.T) {
+	chunk := eventsInsert(1, 100)
+	filtered, removed, err := filterPKRange("events", "id", pkRange{min: "40", max: "60"}, chunk)
+	require.NoError(t, err)
+	assert.Equal(t, 79, removed)
+	assert.Equal(t, eventsInsert(40, 60), filtered)
+
+	// Every row in the range leaves the chunk as it is
+	filtered, removed, err = filterPKRange("events", "id", pkRange{min: "1"}, chunk)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	assert.Equal(t, chunk, filtered)
+
+	filtered, removed, err = filterPKRange("events", "id", pkRange{min: "500"}, chunk)
+	require.NoError(t, err)
+	assert.Equal(t, 100, removed)
+	assert.Empty(t, filtered)
+
+	_, _, err = filterPKRange("events", "uuid", pkRange{min: "1"}, chunk)
+	assert.ErrorContains(t, err, "primary key column uuid")
+}
+
+func TestImportTableDataPKRange(t *testing.T) {
+	dir := t.TempDir()
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_events.sql"),
+		[]byte(eventsInsert(1, 50)+separator+eventsInsert(51, 100)), 0644))
+	files := []dataFile{{name: "1_events.sql", format: exportFormatSQL}}
+
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverMySQL}}
+
+	mock.ExpectQuery("SELECT COLUMN_NAME").WithArgs("events").WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("id"))
+	mock.ExpectExec("SET FOREIGN_KEY_CHECKS = 0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(eventsInsert(91, 100))).WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectCommit()
+	mock.ExpectExec("SET FOREIGN_KEY_CHECKS = 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Only the last ten of the 100 rows are imported, the first chunk not at all
+	result := &ImportResult{}
+	cmdArgs := &CommonArgs{PKMin: "91", PKMax: "1000"}
+	require.NoError(t, importTableData(conn, cmdArgs, dir, &ExportData{}, "events", files, 0, nil, result))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 90, result.RowsOutOfRange)
+	assert.Equal(t, int64(10), result.RowsImported)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/spf13/cobra"
+)
+
+func newProfileCopyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy <source-profile> <dest-profile>",
+		Short: "Copy a configuration profile",
+		Long: `Copies a profile to a new name. Requires --force to overwrite an existing destination.
+Examples:
+  syncdb profile copy dev staging
+  syncdb profile copy dev staging --override-database staging_db --force`,
+		Args:              cobra.ExactArgs(2), // Requires source and destination profile names
+		ValidArgsFunction: completeProfileArg,
+		RunE:              runProfileCopy,
+	}
+
+	cmd.Flags().Bool("force", false, "Overwrite the destination profile if it exists")
+	cmd.Flags().String("override-database", "", "Database name to set in the copied profile")
+
+	return cmd
+}
+
+func newProfileRenameCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <old-profile> <new-profile>",
+		Short: "Rename a configuration profile",
+		Long:  `Renames a profile. Requires --force to overwrite an existing profile with the new name.`,
+		Args:              cobra.ExactArgs(2), // Requires old and new profile names
+		ValidArgsFunction: completeProfileArg,
+		RunE:              runProfileRename,
+	}
+
+	cmd.Flags().Bool("force", false, "Overwrite the destination profile if it exists")
+
+	return cmd
+}
+
+func runProfileCopy(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	overrideDatabase, _ := cmd.Flags().GetString("override-database")
+
+	if err := copyProfile(args[0], args[1], force, overrideDatabase); err != nil {
+		return err
+	}
+	fmt.Printf("Profile '%s' copied to '%s'.\n", args[0], args[1])
+	return nil
+}
+
+func runProfileRename(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+
+	if err := copyProfile(args[0], args[1], force, ""); err != nil {
+		return err
+	}
+
+	// Remove the old profile only once the copy has been saved
+	oldPath, err := profile.GetProfilePath(args[0])
+	if err != nil {
+		return fmt.Errorf("could not determine path for profile '%s': %w", args[0], err)
+	}
+	if err := os.Remove(oldPath); err != nil {
+		return fmt.Errorf("profile copied to '%s' but failed to delete '%s': %w", args[1], args[0], err)
+	}
+
+	fmt.Printf("Profile '%s' renamed to '%s'.\n", args[0], args[1])
+	return nil
+}
+
+// copyProfile loads and validates the source profile and saves it under destName,
+// optionally replacing its database. An existing destination is only overwritten with force.
+func copyProfile(sourceName, destName string, force bool, overrideDatabase string) error {
+	if sourceName == "" || destName == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+	if sourceName == destName {
+		return fmt.Errorf("source and destination profile are both '%s'", sourceName)
+	}
+
+	cfg, err := profile.LoadProfileFile(sourceName)
+	if err != nil {
+		return fmt.Errorf("failed to load profile '%s': %w", sourceName, err)
+	}
+
+	destPath, err := profile.GetProfilePath(destName)
+	if err != nil {
+		return fmt.Errorf("could not determine path for profile '%s': %w", destName, err)
+	}
+	if _, err := os.Stat(destPath); err == nil {
+		if !force {
+			return fmt.Errorf("profile '%s' already exists. Use --force to overwrite it", destName)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error checking profile file '%s': %w", destPath, err)
+	}
+
+	if overrideDatabase != "" {
+		cfg.Database = overrideDatabase
+	}
+
+	if err := profile.SaveProfile(destName, cfg); err != nil {
+		return fmt.Errorf("failed to save profile '%s': %w", destName, err)
+	}
+	warnWorldReadableProfile(destName, cfg)
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileCopyAndRename(t *testing.T) {
+	testCases := []struct {
+		name         string
+		sourceExists bool
+		destExists   bool
+		force        bool
+		wantErr      bool
+	}{
+		{"Source missing", false, false, false, true},
+		{"Source missing with force", false, false, true, true},
+		{"Source missing, dest exists", false, true, false, true},
+		{"Source missing, dest exists with force", false, true, true, true},
+		{"New destination", true, false, false, false},
+		{"New destination with force", true, false, true, false},
+		{"Existing destination", true, true, false, true},
+		{"Existing destination with force", true, true, true, false},
+	}
+
+	// setup points SYNCDB_PATH at a fresh profile directory with the requested profiles
+	setup := func(t *testing.T, sourceExists, destExists bool) string {
+		baseTmpDir, cleanup := setupTestProfileDir(t)
+		t.Cleanup(cleanup)
+		t.Setenv("SYNCDB_PATH", baseTmpDir)
+		profileDir := filepath.Join(baseTmpDir, "profiles")
+		if sourceExists {
+			createDummyCmdProfile(t, profileDir, "dev", "database: dev_db\nhost: dev_host\n")
+		}
+		if destExists {
+			createDummyCmdProfile(t, profileDir, "staging", "database: staging_db\nhost: staging_host\n")
+		}
+		return profileDir
+	}
+
+	for _, tc := range testCases {
+		t.Run("Copy/"+tc.name, func(t *testing.T) {
+			setup(t, tc.sourceExists, tc.destExists)
+
+			err := copyProfile("dev", "staging", tc.force, "")
+			if tc.wantErr {
+				require.Error(t, err)
+				if tc.destExists {
+					// The existing destination is left untouched
+					cfg, loadErr := profile.LoadProfile("staging")
+					require.NoError(t, loadErr)
+					assert.Equal(t, "staging_db", cfg.Database)
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			cfg, err := profile.LoadProfile("staging")
+			require.NoError(t, err)
+			assert.Equal(t, "dev_db", cfg.Database)
+			assert.Equal(t, "dev_host", cfg.Host)
+			_, err = profile.LoadProfile("dev")
+			assert.NoError(t, err, "copy keeps the source profile")
+		})
+
+		t.Run("Rename/"+tc.name, func(t *testing.T) {
+			profileDir := setup(t, tc.sourceExists, tc.destExists)
+
+			cmd := newProfileRenameCommand()
+			require.NoError(t, cmd.Flags().Set("force", strconv.FormatBool(tc.force)))
+			err := runProfileRename(cmd, []string{"dev", "staging"})
+			if tc.wantErr {
+				require.Error(t, err)
+				if tc.sourceExists {
+					assert.FileExists(t, filepath.Join(profileDir, "dev.yaml"))
+				}
+				return
+			}
+			require.NoError(t, err)
+
+			cfg, err := profile.LoadProfile("staging")
+			require.NoError(t, err)
+			assert.Equal(t, "dev_db", cfg.Database)
+			_, err = os.Stat(filepath.Join(profileDir, "dev.yaml"))
+			assert.True(t, os.IsNotExist(err), "rename removes the old profile")
+		})
+	}
+
+	t.Run("Copy with database override", func(t *testing.T) {
+		setup(t, true, false)
+		require.NoError(t, copyProfile("dev", "staging", false, "other_db"))
+
+		cfg, err := profile.LoadProfile("staging")
+		require.NoError(t, err)
+		assert.Equal(t, "other_db", cfg.Database)
+		assert.Equal(t, "dev_host", cfg.Host)
+	})
+
+	t.Run("Copy onto itself", func(t *testing.T) {
+		setup(t, true, false)
+		assert.Error(t, copyProfile("dev", "dev", true, ""))
+	})
+}
@@ -5,7 +5,10 @@ import (
 	"os"
 	"strings" // Ensure strings is imported
 
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/notify"
 	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -54,9 +57,18 @@ func runProfileCreate(cmd *cobra.Command, args []string) error {
 	// --- Populate ProfileConfig from flags ---
 	cfg := profile.ProfileConfig{}
 
-	// Required field validation
+	// Required field validation; a DSN can provide the database instead
 	cfg.Database, _ = flags.GetString("database")
-	if cfg.Database == "" {
+	cfg.DSN, _ = flags.GetString("dsn")
+	if cfg.DSN != "" {
+		settings, err := parseDSN(cfg.DSN)
+		if err != nil {
+			return err
+		}
+		if cfg.Database == "" && settings.Database == "" {
+			return fmt.Errorf("flag --database is required to create a profile when --dsn has no database")
+		}
+	} else if cfg.Database == "" {
 		return fmt.Errorf("flag --database is required to create a profile")
 	}
 
@@ -66,11 +78,74 @@ func runProfileCreate(cmd *cobra.Command, args []string) error {
 	cfg.Username, _ = flags.GetString("username")
 	cfg.Password, _ = flags.GetString("password")
 	cfg.Driver, _ = flags.GetString("driver")
+	cfg.PgSchema, _ = flags.GetString("pg-schema")
+	cfg.ConnectRetryCount, _ = flags.GetInt("connect-retry-count")
+	cfg.ConnectRetryDelay, _ = flags.GetString("connect-retry-delay")
+	cfg.DeadlockRetryCount, _ = flags.GetInt("deadlock-retry-count")
+	cfg.DeadlockRetryDelay, _ = flags.GetString("deadlock-retry-delay")
+	cfg.Workers, _ = flags.GetInt("workers")
+	if flags.Changed("workers") {
+		if err := validateWorkers(cfg.Workers); err != nil {
+			return err
+		}
+	}
 	cfg.Tables, _ = flags.GetStringSlice("tables")
 	cfg.Condition, _ = flags.GetString("condition")
+	if conditionsFile, _ := flags.GetString("conditions-file"); conditionsFile != "" {
+		if cfg.Conditions, err = loadConditionsFile(conditionsFile); err != nil {
+			return err
+		}
+	}
 	cfg.ExcludeTable, _ = flags.GetStringSlice("exclude-table")
 	cfg.ExcludeTableSchema, _ = flags.GetStringSlice("exclude-table-schema")
 	cfg.ExcludeTableData, _ = flags.GetStringSlice("exclude-table-data")
+	if excludeColumns, _ := flags.GetString("exclude-columns"); excludeColumns != "" {
+		if cfg.ExcludeColumns, err = parseExcludeColumns(excludeColumns); err != nil {
+			return err
+		}
+	}
+	if orderBy, _ := flags.GetString("order-by"); orderBy != "" {
+		if cfg.OrderBy, err = parseOrderBy(orderBy); err != nil {
+			return err
+		}
+	}
+	cfg.InsertMode, _ = flags.GetString("insert-mode")
+	cfg.TableOrder, _ = flags.GetString("table-order")
+	cfg.SchemaConflict, _ = flags.GetString("schema-conflict")
+	cfg.NullToken, _ = flags.GetString("null-token")
+	cfg.QueryTimeout, _ = flags.GetString("query-timeout")
+	cfg.CompressFormat, _ = flags.GetString("compress-format")
+	cfg.CompressLevel, _ = flags.GetString("compress-level")
+	cfg.PreExportSQL, _ = flags.GetString("pre-export-sql")
+	cfg.PostExportSQL, _ = flags.GetString("post-export-sql")
+	cfg.PreImportSQL, _ = flags.GetString("pre-import-sql")
+	cfg.PostImportSQL, _ = flags.GetString("post-import-sql")
+	cfg.TempDir, _ = flags.GetString("temp-dir")
+	cfg.Charset, _ = flags.GetString("charset")
+	cfg.Collation, _ = flags.GetString("collation")
+	cfg.Encoding, _ = flags.GetString("encoding")
+	cfg.Storage, _ = flags.GetString("storage")
+	cfg.S3Bucket, _ = flags.GetString("s3-bucket")
+	cfg.S3Region, _ = flags.GetString("s3-region")
+	cfg.S3StorageClass, _ = flags.GetString("s3-storage-class")
+	cfg.S3Tags, _ = flags.GetString("s3-tags")
+	if _, err := storage.ParseS3Tags(cfg.S3Tags); err != nil {
+		return err
+	}
+	cfg.S3KMSKeyID, _ = flags.GetString("s3-kms-key-id")
+	cfg.SchemaVersion, _ = flags.GetString("schema-version")
+	cfg.FilterFile, _ = flags.GetString("filter-file")
+	cfg.QuoteIdentifiers, _ = flags.GetString("quote-identifiers")
+	if err := db.ValidateQuoteStyle(cfg.QuoteIdentifiers); err != nil {
+		return err
+	}
+	cfg.GdriveCredentials, _ = flags.GetString("gdrive-credentials")
+	cfg.GdriveFolder, _ = flags.GetString("gdrive-folder")
+	cfg.WebhookURL, _ = flags.GetString("webhook-url")
+	cfg.WebhookHeaders, _ = flags.GetStringArray("webhook-header")
+	if _, err := notify.ParseHeaders(cfg.WebhookHeaders); err != nil {
+		return err
+	}
 
 	// Handle boolean flags (need to check if they were set)
 	if flags.Changed("profile-include-schema") {
@@ -81,6 +156,14 @@ func runProfileCreate(cmd *cobra.Command, args []string) error {
 		val, _ := flags.GetBool("profile-include-data")
 		cfg.IncludeData = &val
 	}
+	if flags.Changed("skip-existing") {
+		val, _ := flags.GetBool("skip-existing")
+		cfg.SkipExisting = &val
+	}
+	if flags.Changed("ansi-quotes") {
+		val, _ := flags.GetBool("ansi-quotes")
+		cfg.ANSIQuotes = &val
+	}
 
 	// --- Save Profile ---
 	err = profile.SaveProfile(profileName, &cfg)
@@ -92,6 +175,44 @@ func runProfileCreate(cmd *cobra.Command, args []string) error {
 	if cfg.Password != "" {
 		fmt.Println("Warning: Password was saved in plain text in the profile file.")
 	}
+	warnWorldReadableProfile(profileName, &cfg)
 
 	return nil
 }
+
+// warnWorldReadableProfile prints a warning when a profile holding credentials
+// is saved to a file that other users can read. New profiles are written
+// 0640, but an existing file keeps its permissions when it is overwritten.
+func warnWorldReadableProfile(profileName string, cfg *profile.ProfileConfig) {
+	if !profileHasCredentials(cfg) {
+		return
+	}
+	profilePath, err := profile.GetProfilePath(profileName)
+	if err != nil {
+		return
+	}
+	if !isWorldReadable(profilePath) {
+		return
+	}
+	fmt.Printf("Warning: profile '%s' contains credentials but %s is readable by all users; restrict it with: chmod 600 %s\n",
+		profileName, profilePath, profilePath)
+}
+
+// profileHasCredentials reports whether cfg stores a password, directly or in
+// its DSN, or the location of storage credentials.
+func profileHasCredentials(cfg *profile.ProfileConfig) bool {
+	if cfg.Password != "" || cfg.GdriveCredentials != "" {
+		return true
+	}
+	if cfg.DSN != "" {
+		settings, err := parseDSN(cfg.DSN)
+		return err != nil || settings.Password != ""
+	}
+	return false
+}
+
+// isWorldReadable reports whether other users can read the file at path.
+func isWorldReadable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().Perm()&0004 != 0
+}
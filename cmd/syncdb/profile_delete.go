@@ -13,8 +13,9 @@ func newProfileDeleteCommand() *cobra.Command {
 		Use:   "delete <profile-name>",
 		Short: "Delete a configuration profile",
 		Long:  `Deletes the specified configuration profile file. Requires the --force flag to proceed.`,
-		Args:  cobra.ExactArgs(1), // Requires exactly one argument: the profile name
-		RunE:  runProfileDelete,
+		Args:              cobra.ExactArgs(1), // Requires exactly one argument: the profile name
+		ValidArgsFunction: completeProfileArg,
+		RunE:              runProfileDelete,
 	}
 
 	// Add the required --force flag
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/spf13/cobra"
+)
+
+// Shells supported by profile export-to-env
+const (
+	envShellPOSIX      = "sh"
+	envShellPowerShell = "powershell"
+	envShellFish       = "fish"
+)
+
+// profileEnvPrefix is the prefix LoadConfig reads export settings from
+const profileEnvPrefix = "SYNCDB_EXPORT_"
+
+// profileEnvVar is one environment variable derived from a profile field.
+type profileEnvVar struct {
+	Name  string
+	Value string
+}
+
+func newProfileExportEnvCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export-to-env <profile-name>",
+		Short: "Print a profile as shell environment variables",
+		Long: `Loads the specified profile and prints the SYNCDB_EXPORT_* variables that
+configure the same connection, e.g. eval "$(syncdb profile export-to-env dev)".
+Only the fields read from the environment are printed. The password is left out
+unless --include-password is given.`,
+		Args:              cobra.ExactArgs(1), // Requires exactly one argument: the profile name
+		ValidArgsFunction: completeProfileArg,
+		RunE:              runProfileExportEnv,
+	}
+	cmd.Flags().String("format", envShellPOSIX, "Shell syntax of the output (sh, powershell or fish)")
+	cmd.Flags().Bool("include-password", false, "Include the password in the output")
+	return cmd
+}
+
+func runProfileExportEnv(cmd *cobra.Command, args []string) error {
+	profileName := args[0]
+
+	// Basic validation
+	if profileName == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+
+	format, _ := cmd.Flags().GetString("format")
+	switch format {
+	case envShellPOSIX, envShellPowerShell, envShellFish:
+	default:
+		return fmt.Errorf("invalid --format %q (expected %s, %s or %s)", format, envShellPOSIX, envShellPowerShell, envShellFish)
+	}
+
+	cfg, err := profile.LoadProfile(profileName)
+	if err != nil {
+		return fmt.Errorf("failed to load profile '%s': %w", profileName, err)
+	}
+
+	includePassword, _ := cmd.Flags().GetBool("include-password")
+	vars, err := profileEnvVars(cfg, includePassword)
+	if err != nil {
+		return fmt.Errorf("failed to read profile '%s': %w", profileName, err)
+	}
+	if !includePassword && profilePassword(cfg) != "" {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Note: the password of profile '%s' is not printed, use --include-password to include it\n", profileName)
+	}
+
+	out := cmd.OutOrStdout()
+	for _, v := range vars {
+		fmt.Fprintln(out, formatEnvVar(v, format))
+	}
+	return nil
+}
+
+// profilePassword returns the password of a profile, falling back to the one in its DSN.
+func profilePassword(cfg *profile.ProfileConfig) string {
+	if cfg.Password != "" || cfg.DSN == "" {
+		return cfg.Password
+	}
+	settings, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return ""
+	}
+	return settings.Password
+}
+
+// profileEnvVars maps a profile to the variables read by config.LoadConfig.
+// Connection fields left empty in the profile are taken from its DSN, which
+// has no variable of its own. Empty fields are skipped.
+func profileEnvVars(cfg *profile.ProfileConfig, includePassword bool) ([]profileEnvVar, error) {
+	var dsn dsnSettings
+	if cfg.DSN != "" {
+		var err error
+		if dsn, err = parseDSN(cfg.DSN); err != nil {
+			return nil, err
+		}
+	}
+	pick := func(value, fallback string) string {
+		if value != "" {
+			return value
+		}
+		return fallback
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = dsn.Port
+	}
+	var portValue string
+	if port != 0 {
+		portValue = strconv.Itoa(port)
+	}
+	var password string
+	if includePassword {
+		password = pick(cfg.Password, dsn.Password)
+	}
+
+	// Keys follow the order of loadCommonConfig in pkg/config
+	fields := []struct {
+		key   string
+		value string
+	}{
+		{"driver", pick(cfg.Driver, dsn.Driver)},
+		{"host", pick(cfg.Host, dsn.Host)},
+		{"port", portValue},
+		{"username", pick(cfg.Username, dsn.Username)},
+		{"password", password},
+		{"database", pick(cfg.Database, dsn.Database)},
+		{"s3_bucket", cfg.S3Bucket},
+		{"s3_region", cfg.S3Region},
+		{"storage", cfg.Storage},
+		{"tables", strings.Join(cfg.Tables, ",")},
+		{"exclude_table", strings.Join(cfg.ExcludeTable, ",")},
+		{"exclude_table_schema", strings.Join(cfg.ExcludeTableSchema, ",")},
+		{"exclude_table_data", strings.Join(cfg.ExcludeTableData, ",")},
+	}
+
+	var vars []profileEnvVar
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		vars = append(vars, profileEnvVar{Name: profileEnvPrefix + strings.ToUpper(f.key), Value: f.value})
+	}
+	return vars, nil
+}
+
+// formatEnvVar renders one variable assignment in the syntax of the given shell.
+// Values are single-quoted so the shell does not expand them.
+func formatEnvVar(v profileEnvVar, shell string) string {
+	switch shell {
+	case envShellPowerShell:
+		return fmt.Sprintf("$env:%s = '%s'", v.Name, strings.ReplaceAll(v.Value, "'", "''"))
+	case envShellFish:
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v.Value)
+		return fmt.Sprintf("set -gx %s '%s'", v.Name, value)
+	default:
+		return fmt.Sprintf("export %s='%s'", v.Name, strings.ReplaceAll(v.Value, "'", `'\''`))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/config"
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfileEnvVars(t *testing.T) {
+	cfg := &profile.ProfileConfig{
+		Host:         "db.internal",
+		Port:         5433,
+		Username:     "app",
+		Password:     "it's secret",
+		Database:     "shop",
+		Driver:       "postgres",
+		Tables:       []string{"users", "orders"},
+		ExcludeTable: []string{"logs"},
+		Storage:      "s3",
+		S3Bucket:     "backups",
+	}
+
+	vars, err := profileEnvVars(cfg, false)
+	require.NoError(t, err)
+	for _, v := range vars {
+		assert.NotEqual(t, "SYNCDB_EXPORT_PASSWORD", v.Name)
+	}
+
+	// The variables load back into the same settings through LoadConfig
+	vars, err = profileEnvVars(cfg, true)
+	require.NoError(t, err)
+	for _, v := range vars {
+		t.Setenv(v.Name, v.Value)
+	}
+	loaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Host, loaded.Export.Host)
+	assert.Equal(t, cfg.Port, loaded.Export.Port)
+	assert.Equal(t, cfg.Username, loaded.Export.Username)
+	assert.Equal(t, cfg.Password, loaded.Export.Password)
+	assert.Equal(t, cfg.Database, loaded.Export.Database)
+	assert.Equal(t, cfg.Driver, loaded.Export.Driver)
+	assert.Equal(t, cfg.Tables, loaded.Export.Tables)
+	assert.Equal(t, cfg.ExcludeTable, loaded.Export.ExcludeTable)
+	assert.Equal(t, cfg.Storage, loaded.Export.Storage)
+	assert.Equal(t, cfg.S3Bucket, loaded.Export.S3Bucket)
+
+	// Fields missing from the profile come from its DSN
+	vars, err = profileEnvVars(&profile.ProfileConfig{DSN: "mysql://root:pw@localhost/app", Database: "other"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []profileEnvVar{
+		{"SYNCDB_EXPORT_DRIVER", "mysql"},
+		{"SYNCDB_EXPORT_HOST", "localhost"},
+		{"SYNCDB_EXPORT_PORT", "3306"},
+		{"SYNCDB_EXPORT_USERNAME", "root"},
+		{"SYNCDB_EXPORT_DATABASE", "other"},
+	}, vars)
+}
+
+func TestFormatEnvVar(t *testing.T) {
+	v := profileEnvVar{Name: "SYNCDB_EXPORT_PASSWORD", Value: `it's a\b`}
+	assert.Equal(t, `export SYNCDB_EXPORT_PASSWORD='it'\''s a\b'`, formatEnvVar(v, envShellPOSIX))
+	assert.Equal(t, `$env:SYNCDB_EXPORT_PASSWORD = 'it''s a\b'`, formatEnvVar(v, envShellPowerShell))
+	assert.Equal(t, `set -gx SYNCDB_EXPORT_PASSWORD 'it\'s a\\b'`, formatEnvVar(v, envShellFish))
+}
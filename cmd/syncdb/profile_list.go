@@ -9,19 +9,30 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// profileListPlain is the default format of profile list, listing only names
+const profileListPlain = "plain"
+
 func newProfileListCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List all available configuration profiles",
-		Long:  `Lists the names of all saved configuration profiles found in the profile directory.`,
-		Args:  cobra.NoArgs, // No arguments expected
-		RunE:  runProfileList,
+		Long: `Lists the names of all saved configuration profiles found in the profile directory.
+With --format table, json or yaml the database, host and driver of each profile are shown too.`,
+		Args: cobra.NoArgs, // No arguments expected
+		RunE: runProfileList,
 	}
-	// No flags needed for list command currently
+	cmd.Flags().String("format", profileListPlain, "Output format (plain, table, json or yaml)")
 	return cmd
 }
 
 func runProfileList(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != profileListPlain {
+		if err := profile.ValidateFormat(format); err != nil {
+			return fmt.Errorf("%w, or %s", err, profileListPlain)
+		}
+	}
+
 	profileDir, err := profile.GetProfileDir("")
 	if err != nil {
 		// If the error is that the directory doesn't exist, that's fine, just means no profiles.
@@ -51,6 +62,20 @@ func runProfileList(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if format != profileListPlain {
+		summaries := make([]profile.ProfileSummary, 0, len(profileNames))
+		for _, name := range profileNames {
+			summary := profile.ProfileSummary{Name: name}
+			if cfg, loadErr := profile.LoadProfile(name); loadErr == nil {
+				summary.Database, summary.Host, summary.Driver = cfg.Database, cfg.Host, cfg.Driver
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: failed to load profile '%s': %v\n", name, loadErr)
+			}
+			summaries = append(summaries, summary)
+		}
+		return profile.FormatProfileList(cmd.OutOrStdout(), summaries, format)
+	}
+
 	if len(profileNames) == 0 {
 		fmt.Printf("No profiles found in %s.\n", profileDir)
 		return nil
@@ -58,14 +83,7 @@ func runProfileList(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("Available Profiles:")
 	for _, name := range profileNames {
-		// Optional Enhancement: Load key details (driver, database)
-		// cfg, loadErr := profile.LoadProfile(name)
-		// if loadErr == nil {
-		//  fmt.Printf("- %s (%s, %s)\n", name, cfg.Driver, cfg.Database)
-		// } else {
-		//  fmt.Printf("- %s (Error loading details: %v)\n", name, loadErr)
-		// }
-		fmt.Printf("- %s\n", name) // Simple listing for now
+		fmt.Printf("- %s\n", name)
 	}
 
 	return nil
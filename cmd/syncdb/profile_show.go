@@ -5,18 +5,20 @@ import (
 
 	"github.com/hoangnguyenba/syncdb/pkg/profile"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3" // Import YAML library
 )
 
 func newProfileShowCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "show <profile-name>",
 		Short: "Show the configuration details of a specific profile",
-		Long:  `Loads and displays the contents of the specified profile file in YAML format.`,
-		Args:  cobra.ExactArgs(1), // Requires exactly one argument: the profile name
-		RunE:  runProfileShow,
+		Long: `Loads and displays the contents of the specified profile file.
+Use --format to choose between YAML (default), JSON and a table of fields.`,
+		Args:              cobra.ExactArgs(1), // Requires exactly one argument: the profile name
+		ValidArgsFunction: completeProfileArg,
+		RunE:              runProfileShow,
 	}
-	// No flags needed for show command
+	cmd.Flags().String("format", profile.FormatYAML, "Output format (yaml, json or table)")
+	cmd.Flags().Bool("redact-password", false, "Replace the password with *** in the output")
 	return cmd
 }
 
@@ -35,15 +37,22 @@ func runProfileShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load profile '%s': %w", profileName, err)
 	}
 
-	// Marshal the loaded config back to YAML
-	yamlData, err := yaml.Marshal(cfg)
-	if err != nil {
-		return fmt.Errorf("failed to marshal profile '%s' to YAML: %w", profileName, err)
+	format, _ := cmd.Flags().GetString("format")
+	if err := profile.ValidateFormat(format); err != nil {
+		return err
+	}
+	if redact, _ := cmd.Flags().GetBool("redact-password"); redact {
+		cfg = profile.Redacted(cfg)
 	}
 
-	// Print the YAML output
-	fmt.Printf("--- Profile: %s ---\n", profileName)
-	fmt.Println(string(yamlData))
+	// Only the YAML output keeps the header, so JSON stays machine readable
+	out := cmd.OutOrStdout()
+	if format == profile.FormatYAML {
+		fmt.Fprintf(out, "--- Profile: %s ---\n", profileName)
+	}
+	if err := profile.FormatProfile(out, cfg, format); err != nil {
+		return fmt.Errorf("failed to format profile '%s': %w", profileName, err)
+	}
 
 	return nil
 }
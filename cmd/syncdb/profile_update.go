@@ -5,7 +5,10 @@ import (
 	"os"
 	"strings"
 
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/hoangnguyenba/syncdb/pkg/notify"
 	"github.com/hoangnguyenba/syncdb/pkg/profile"
+	"github.com/hoangnguyenba/syncdb/pkg/storage"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
@@ -17,8 +20,9 @@ func newProfileUpdateCommand() *cobra.Command {
 		Use:   "update <profile-name>",
 		Short: "Update an existing configuration profile or create it if it doesn't exist",
 		Long:  `Modifies an existing profile configuration file with the provided flags. If the profile doesn't exist, it will be created.`,
-		Args:  cobra.ExactArgs(1), // Requires exactly one argument: the profile name
-		RunE:  runProfileUpdate,
+		Args:              cobra.ExactArgs(1), // Requires exactly one argument: the profile name
+		ValidArgsFunction: completeProfileArg,
+		RunE:              runProfileUpdate,
 	}
 
 	// Add flags corresponding to ProfileConfig fields
@@ -37,7 +41,7 @@ func runProfileUpdate(cmd *cobra.Command, args []string) error {
 	}
 
 	// --- Load existing profile or create new ---
-	cfg, err := profile.LoadProfile(profileName)
+	cfg, err := profile.LoadProfileFile(profileName)
 	if err != nil {
 		// If error is "not found", create a new empty config
 		profilePath, _ := profile.GetProfilePath(profileName) // Get path for error message
@@ -50,6 +54,64 @@ func runProfileUpdate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Per-table conditions come from a file, so load it before visiting the other flags
+	if conditionsFile, _ := flags.GetString("conditions-file"); conditionsFile != "" {
+		if cfg.Conditions, err = loadConditionsFile(conditionsFile); err != nil {
+			return err
+		}
+	}
+
+	// Excluded columns need parsing, which can fail, so handle them outside Visit
+	if flags.Changed("exclude-columns") {
+		excludeColumns, _ := flags.GetString("exclude-columns")
+		if cfg.ExcludeColumns, err = parseExcludeColumns(excludeColumns); err != nil {
+			return err
+		}
+	}
+
+	if flags.Changed("order-by") {
+		orderBy, _ := flags.GetString("order-by")
+		if cfg.OrderBy, err = parseOrderBy(orderBy); err != nil {
+			return err
+		}
+	}
+
+	// The DSN is checked before it is stored, like the webhook headers below
+	if flags.Changed("dsn") {
+		cfg.DSN, _ = flags.GetString("dsn")
+		if cfg.DSN != "" {
+			if _, err := parseDSN(cfg.DSN); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Webhook headers are validated for the same reason
+	if flags.Changed("webhook-header") {
+		cfg.WebhookHeaders, _ = flags.GetStringArray("webhook-header")
+		if _, err := notify.ParseHeaders(cfg.WebhookHeaders); err != nil {
+			return err
+		}
+	}
+	if flags.Changed("quote-identifiers") {
+		quoteIdentifiers, _ := flags.GetString("quote-identifiers")
+		if err := db.ValidateQuoteStyle(quoteIdentifiers); err != nil {
+			return err
+		}
+	}
+	if flags.Changed("workers") {
+		workers, _ := flags.GetInt("workers")
+		if err := validateWorkers(workers); err != nil {
+			return err
+		}
+	}
+	if flags.Changed("s3-tags") {
+		s3Tags, _ := flags.GetString("s3-tags")
+		if _, err := storage.ParseS3Tags(s3Tags); err != nil {
+			return err
+		}
+	}
+
 	// --- Update fields based on changed flags ---
 	flags.Visit(func(f *pflag.Flag) {
 		// Use Visit instead of Changed because Changed doesn't work well with default values
@@ -67,6 +129,18 @@ func runProfileUpdate(cmd *cobra.Command, args []string) error {
 			cfg.Database, _ = flags.GetString("database")
 		case "driver":
 			cfg.Driver, _ = flags.GetString("driver")
+		case "connect-retry-count":
+			cfg.ConnectRetryCount, _ = flags.GetInt("connect-retry-count")
+		case "connect-retry-delay":
+			cfg.ConnectRetryDelay, _ = flags.GetString("connect-retry-delay")
+		case "deadlock-retry-count":
+			cfg.DeadlockRetryCount, _ = flags.GetInt("deadlock-retry-count")
+		case "deadlock-retry-delay":
+			cfg.DeadlockRetryDelay, _ = flags.GetString("deadlock-retry-delay")
+		case "workers":
+			cfg.Workers, _ = flags.GetInt("workers")
+		case "pg-schema":
+			cfg.PgSchema, _ = flags.GetString("pg-schema")
 		case "tables":
 			cfg.Tables, _ = flags.GetStringSlice("tables")
 		case "profile-include-schema":
@@ -75,6 +149,12 @@ func runProfileUpdate(cmd *cobra.Command, args []string) error {
 		case "profile-include-data":
 			val, _ := flags.GetBool("profile-include-data")
 			cfg.IncludeData = &val
+		case "skip-existing":
+			val, _ := flags.GetBool("skip-existing")
+			cfg.SkipExisting = &val
+		case "ansi-quotes":
+			val, _ := flags.GetBool("ansi-quotes")
+			cfg.ANSIQuotes = &val
 		case "condition":
 			cfg.Condition, _ = flags.GetString("condition")
 		case "exclude-table":
@@ -83,6 +163,60 @@ func runProfileUpdate(cmd *cobra.Command, args []string) error {
 			cfg.ExcludeTableSchema, _ = flags.GetStringSlice("exclude-table-schema")
 		case "exclude-table-data":
 			cfg.ExcludeTableData, _ = flags.GetStringSlice("exclude-table-data")
+		case "insert-mode":
+			cfg.InsertMode, _ = flags.GetString("insert-mode")
+		case "table-order":
+			cfg.TableOrder, _ = flags.GetString("table-order")
+		case "schema-conflict":
+			cfg.SchemaConflict, _ = flags.GetString("schema-conflict")
+		case "query-timeout":
+			cfg.QueryTimeout, _ = flags.GetString("query-timeout")
+		case "null-token":
+			cfg.NullToken, _ = flags.GetString("null-token")
+		case "compress-format":
+			cfg.CompressFormat, _ = flags.GetString("compress-format")
+		case "compress-level":
+			cfg.CompressLevel, _ = flags.GetString("compress-level")
+		case "pre-export-sql":
+			cfg.PreExportSQL, _ = flags.GetString("pre-export-sql")
+		case "post-export-sql":
+			cfg.PostExportSQL, _ = flags.GetString("post-export-sql")
+		case "pre-import-sql":
+			cfg.PreImportSQL, _ = flags.GetString("pre-import-sql")
+		case "post-import-sql":
+			cfg.PostImportSQL, _ = flags.GetString("post-import-sql")
+		case "temp-dir":
+			cfg.TempDir, _ = flags.GetString("temp-dir")
+		case "charset":
+			cfg.Charset, _ = flags.GetString("charset")
+		case "collation":
+			cfg.Collation, _ = flags.GetString("collation")
+		case "encoding":
+			cfg.Encoding, _ = flags.GetString("encoding")
+		case "storage":
+			cfg.Storage, _ = flags.GetString("storage")
+		case "s3-bucket":
+			cfg.S3Bucket, _ = flags.GetString("s3-bucket")
+		case "s3-region":
+			cfg.S3Region, _ = flags.GetString("s3-region")
+		case "s3-storage-class":
+			cfg.S3StorageClass, _ = flags.GetString("s3-storage-class")
+		case "s3-tags":
+			cfg.S3Tags, _ = flags.GetString("s3-tags")
+		case "s3-kms-key-id":
+			cfg.S3KMSKeyID, _ = flags.GetString("s3-kms-key-id")
+		case "schema-version":
+			cfg.SchemaVersion, _ = flags.GetString("schema-version")
+		case "filter-file":
+			cfg.FilterFile, _ = flags.GetString("filter-file")
+		case "quote-identifiers":
+			cfg.QuoteIdentifiers, _ = flags.GetString("quote-identifiers")
+		case "gdrive-credentials":
+			cfg.GdriveCredentials, _ = flags.GetString("gdrive-credentials")
+		case "gdrive-folder":
+			cfg.GdriveFolder, _ = flags.GetString("gdrive-folder")
+		case "webhook-url":
+			cfg.WebhookURL, _ = flags.GetString("webhook-url")
 		}
 	})
 
@@ -97,6 +231,7 @@ func runProfileUpdate(cmd *cobra.Command, args []string) error {
 	if flags.Changed("password") && cfg.Password != "" {
 		fmt.Println("Warning: Password was saved in plain text in the profile file.")
 	}
+	warnWorldReadableProfile(profileName, cfg)
 
 	return nil
 }
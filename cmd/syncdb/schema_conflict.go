@@ -0,0 +1,68 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// Behaviors for schema tables that already exist in the target database, set
+// by --schema-conflict
+const (
+	schemaConflictError   = "error"
+	schemaConflictSkip    = "skip"
+	schemaConflictReplace = "replace"
+)
+
+// validateSchemaConflict returns an error if mode is not a --schema-conflict value.
+func validateSchemaConflict(mode string) error {
+	switch mode {
+	case schemaConflictError, schemaConflictSkip, schemaConflictReplace:
+		return nil
+	}
+	return fmt.Errorf("invalid --schema-conflict %q (valid values: error, skip, replace)", mode)
+}
+
+// createIfNotExistsRegex matches the start of a CREATE TABLE or CREATE INDEX
+// statement, capturing an IF NOT EXISTS clause it already has
+var createIfNotExistsRegex = regexp.MustCompile(`(?i)\bCREATE\s+(?:UNIQUE\s+)?(?:TABLE|INDEX)\s+(IF\s+NOT\s+EXISTS\s+)?`)
+
+// withIfNotExists adds IF NOT EXISTS to a CREATE TABLE or CREATE INDEX
+// statement for --schema-conflict skip, so existing tables and indexes are
+// kept as they are.
+func withIfNotExists(stmt string) string {
+	loc := createIfNotExistsRegex.FindStringSubmatchIndex(stmt)
+	if loc == nil || loc[2] >= 0 {
+		return stmt
+	}
+	return stmt[:loc[1]] + "IF NOT EXISTS " + stmt[loc[1]:]
+}
+
+// dropSchemaTables drops the tables of the schema for --schema-conflict
+// replace, referencing tables first. MySQL foreign key checks are turned off
+// meanwhile, so tables referenced by tables outside the schema can be dropped
+// too; PostgreSQL drops the referencing constraints with CASCADE.
+func dropSchemaTables(tx *sql.Tx, driver string, tables []string) (err error) {
+	if driver == db.DriverMySQL {
+		if _, err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			return fmt.Errorf("failed to disable foreign key checks: %v", err)
+		}
+		defer func() {
+			if _, restoreErr := tx.Exec("SET FOREIGN_KEY_CHECKS = 1"); restoreErr != nil && err == nil {
+				err = fmt.Errorf("failed to re-enable foreign key checks: %v", restoreErr)
+			}
+		}()
+	}
+	for i := len(tables) - 1; i >= 0; i-- {
+		stmt := fmt.Sprintf("DROP TABLE IF EXISTS %s", db.EscapeIdentifier(driver, tables[i]))
+		if driver == db.DriverPostgres {
+			stmt += " CASCADE"
+		}
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to drop table %s: %v", tables[i], err)
+		}
+	}
+	return nil
+}
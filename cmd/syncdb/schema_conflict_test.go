@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIfNotExists(t *testing.T) {
+	assert.Equal(t, "CREATE TABLE IF NOT EXISTS `users` (\n`id` int\n);",
+		withIfNotExists("CREATE TABLE `users` (\n`id` int\n);"))
+	assert.Equal(t, "create table if not exists users (id int);",
+		withIfNotExists("create table if not exists users (id int);"))
+	assert.Equal(t, "CREATE UNIQUE INDEX IF NOT EXISTS users_email ON public.users USING btree (email);",
+		withIfNotExists("CREATE UNIQUE INDEX users_email ON public.users USING btree (email);"))
+	assert.Equal(t, "CREATE VIEW v AS SELECT 1;", withIfNotExists("CREATE VIEW v AS SELECT 1;"))
+
+	assert.NoError(t, validateSchemaConflict(schemaConflictReplace))
+	assert.ErrorContains(t, validateSchemaConflict("overwrite"), "valid values: error, skip, replace")
+}
+
+func TestImportSchemaConflict(t *testing.T) {
+	schema := []byte("CREATE TABLE `users` (\n`id` int\n);\n")
+	exists := errors.New("Error 1050 (42S01): Table 'users' already exists")
+
+	testCases := []struct {
+		mode   string
+		expect func(mock sqlmock.Sqlmock)
+		err    string
+	}{
+		{
+			mode: schemaConflictError,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("CREATE TABLE `users` (\n`id` int\n);\n").WillReturnError(exists)
+				mock.ExpectRollback()
+			},
+			err: "already exists",
+		},
+		{
+			mode: schemaConflictSkip,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("CREATE TABLE IF NOT EXISTS `users` (\n`id` int\n);\n").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			mode: schemaConflictReplace,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("SET FOREIGN_KEY_CHECKS = 0").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("DROP TABLE IF EXISTS `users`").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("SET FOREIGN_KEY_CHECKS = 1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("CREATE TABLE `users` (\n`id` int\n);\n").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.mode, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer mockDB.Close()
+			conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverMySQL}}
+
+			tc.expect(mock)
+			err = importSchema(conn, schema, "", "", tc.mode)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
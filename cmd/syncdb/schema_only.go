@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// schemaFileName returns the name of the schema file of an export in format
+func schemaFileName(format string) string {
+	if format == exportFormatSQL {
+		return "0_schema.sql"
+	}
+	return "0_schema.json"
+}
+
+// checkSchemaOnlySources checks that the exports imported with --schema-only
+// have a schema file for the selected tables.
+func checkSchemaOnlySources(sources []*importSource) error {
+	found := false
+	for _, src := range sources {
+		if len(src.schemaTables) == 0 {
+			continue
+		}
+		path := filepath.Join(src.path, schemaFileName(src.format))
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("--schema-only: schema file not found: %s", path)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("--schema-only: the export has no schema for the selected tables (it was exported without --include-schema)")
+	}
+	return nil
+}
+
+// conflictingSchemaTables returns the tables of the schema that already exist
+// in the target database, in the order of tables.
+func conflictingSchemaTables(tables, existing []string) []string {
+	exists := make(map[string]bool, len(existing))
+	for _, table := range existing {
+		exists[strings.ToLower(table)] = true
+	}
+	var conflicts []string
+	for _, table := range tables {
+		if exists[strings.ToLower(table)] {
+			conflicts = append(conflicts, table)
+		}
+	}
+	return conflicts
+}
+
+// checkSchemaConflicts compares the tables created by a --schema-only import
+// with the tables of the target database before anything is changed. With
+// --schema-conflict error, existing tables fail the import up front, all of
+// them listed; otherwise the tables that are kept or replaced are reported.
+func checkSchemaConflicts(conn *db.Connection, sources []*importSource, mode string) error {
+	existing, err := db.GetTables(conn)
+	if err != nil {
+		return fmt.Errorf("failed to list the tables of the target database: %v", err)
+	}
+	var tables []string
+	for _, src := range sources {
+		tables = append(tables, src.schemaTables...)
+	}
+	conflicts := conflictingSchemaTables(tables, existing)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	switch mode {
+	case schemaConflictSkip:
+		fmt.Printf("Keeping existing tables: %s\n", strings.Join(conflicts, ", "))
+	case schemaConflictReplace:
+		fmt.Printf("Replacing existing tables (their rows are deleted): %s\n", strings.Join(conflicts, ", "))
+	default:
+		return fmt.Errorf("tables already exist in the target database: %s (use --schema-conflict skip or replace)", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// printSchemaOnlySummary lists the tables and views created by a --schema-only
+// import.
+func printSchemaOnlySummary(sources []*importSource) {
+	var tables, views []string
+	for _, src := range sources {
+		isView := make(map[string]bool, len(src.metadata.Metadata.Views))
+		for _, view := range src.metadata.Metadata.Views {
+			isView[view] = true
+		}
+		for _, table := range src.schemaTables {
+			if isView[table] {
+				views = append(views, table)
+			} else {
+				tables = append(tables, table)
+			}
+		}
+	}
+	fmt.Printf("Schema-only import completed: %d tables, %d views, no data imported\n", len(tables), len(views))
+	if len(tables) > 0 {
+		fmt.Printf("  Tables: %s\n", strings.Join(tables, ", "))
+	}
+	if len(views) > 0 {
+		fmt.Printf("  Views: %s\n", strings.Join(views, ", "))
+	}
+}
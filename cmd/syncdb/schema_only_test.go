@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSchemaOnlySources(t *testing.T) {
+	dir := t.TempDir()
+	src := &importSource{path: dir, format: exportFormatSQL, metadata: &ExportData{}, schemaTables: []string{"users"}}
+	assert.ErrorContains(t, checkSchemaOnlySources([]*importSource{src}), "schema file not found")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0_schema.sql"), []byte("CREATE TABLE users (id int);"), 0644))
+	assert.NoError(t, checkSchemaOnlySources([]*importSource{src}))
+
+	// An export without a schema plans no schema tables
+	src.schemaTables = nil
+	assert.ErrorContains(t, checkSchemaOnlySources([]*importSource{src}), "has no schema")
+}
+
+func TestCheckSchemaConflicts(t *testing.T) {
+	assert.Equal(t, []string{"Users", "orders"}, conflictingSchemaTables([]string{"Users", "orders", "items"}, []string{"orders", "users"}))
+	assert.Nil(t, conflictingSchemaTables([]string{"items"}, []string{"users"}))
+
+	sources := []*importSource{{schemaTables: []string{"users", "orders", "items"}}}
+	for mode, expectedErr := range map[string]string{
+		schemaConflictError:   "tables already exist in the target database: users, orders (use --schema-conflict skip or replace)",
+		schemaConflictSkip:    "",
+		schemaConflictReplace: "",
+	} {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverMySQL}}
+		mock.ExpectQuery("SELECT TABLE_NAME").WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("orders").AddRow("users"))
+
+		err = checkSchemaConflicts(conn, sources, mode)
+		if expectedErr != "" {
+			assert.EqualError(t, err, expectedErr, mode)
+		} else {
+			assert.NoError(t, err, mode)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+		mockDB.Close()
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersionMismatchError is returned by import with --require-schema-version
+// when the export was tagged with another --schema-version than expected.
+type SchemaVersionMismatchError struct {
+	Expected string // Version required by the import
+	Actual   string // Version recorded in the export metadata, empty if untagged
+}
+
+func (e *SchemaVersionMismatchError) Error() string {
+	if e.Actual == "" {
+		return fmt.Sprintf("export has no schema version, expected %s", e.Expected)
+	}
+	if cmp, ok := compareSchemaVersions(e.Actual, e.Expected); ok {
+		relation := "older"
+		if cmp > 0 {
+			relation = "newer"
+		}
+		return fmt.Sprintf("export schema version %s is %s than the expected %s", e.Actual, relation, e.Expected)
+	}
+	return fmt.Sprintf("export schema version %s does not match the expected %s", e.Actual, e.Expected)
+}
+
+// checkSchemaVersion returns a *SchemaVersionMismatchError unless the export's
+// schema version matches the expected one. Semantic versions match when they
+// are equal as versions, so v1.2.0 matches 1.2.0; other versions, such as
+// commit SHAs or migration numbers, must be identical.
+func checkSchemaVersion(expected, actual string) error {
+	if cmp, ok := compareSchemaVersions(actual, expected); ok {
+		if cmp == 0 {
+			return nil
+		}
+	} else if actual != "" && actual == expected {
+		return nil
+	}
+	return &SchemaVersionMismatchError{Expected: expected, Actual: actual}
+}
+
+// compareSchemaVersions compares two semantic versions, returning -1, 0 or 1 as
+// a is lower than, equal to or higher than b. ok is false unless both versions
+// are semantic versions.
+func compareSchemaVersions(a, b string) (cmp int, ok bool) {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			if va.core[i] < vb.core[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease), true
+}
+
+// semver is a parsed semantic version, without its build metadata
+type semver struct {
+	core       [3]uint64
+	prerelease []string
+}
+
+// parseSemver parses MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD], with an optional
+// leading "v".
+func parseSemver(version string) (semver, bool) {
+	var v semver
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexByte(version, '+'); i >= 0 {
+		version = version[:i]
+	}
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		v.prerelease = strings.Split(version[i+1:], ".")
+		version = version[:i]
+		for _, id := range v.prerelease {
+			if id == "" {
+				return semver{}, false
+			}
+		}
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil || (len(part) > 1 && part[0] == '0') {
+			return semver{}, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// comparePrerelease orders pre-release identifiers as semver does: a version
+// without pre-release is higher, numeric identifiers compare numerically and
+// are lower than alphanumeric ones.
+func comparePrerelease(a, b []string) int {
+	if len(a) == 0 || len(b) == 0 {
+		switch {
+		case len(a) == len(b):
+			return 0
+		case len(a) == 0:
+			return 1
+		}
+		return -1
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.ParseUint(a[i], 10, 64)
+		nb, errB := strconv.ParseUint(b[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1
+				}
+				return 1
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		case a[i] != b[i]:
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSchemaVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"1.2.0", "1.2.0", 0, true},
+		{"v1.2.0", "1.2.0", 0, true},
+		{"1.2.0+build.7", "1.2.0", 0, true},
+		{"1.2.0", "1.10.0", -1, true},
+		{"2.0.0", "1.99.99", 1, true},
+		{"1.0.0-alpha", "1.0.0", -1, true},
+		{"1.0.0-alpha.2", "1.0.0-alpha.10", -1, true},
+		{"1.0.0-beta", "1.0.0-alpha.1", 1, true},
+		{"1.0.0-alpha", "1.0.0-1", 1, true},
+		{"1.0.0-alpha.1", "1.0.0-alpha", 1, true},
+		{"1.2", "1.2.0", 0, false},
+		{"01.2.0", "1.2.0", 0, false},
+		{"a1b2c3d", "a1b2c3d", 0, false},
+		{"20240101120000", "1.0.0", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := compareSchemaVersions(tt.a, tt.b)
+		assert.Equal(t, tt.ok, ok, "%s vs %s", tt.a, tt.b)
+		assert.Equal(t, tt.want, got, "%s vs %s", tt.a, tt.b)
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	assert.NoError(t, checkSchemaVersion("1.4.0", "v1.4.0"))
+	assert.NoError(t, checkSchemaVersion("a1b2c3d", "a1b2c3d"))
+	assert.NoError(t, checkSchemaVersion("42", "42"))
+
+	err := checkSchemaVersion("1.4.0", "2.0.0")
+	var mismatch *SchemaVersionMismatchError
+	if assert.True(t, errors.As(err, &mismatch)) {
+		assert.Equal(t, "1.4.0", mismatch.Expected)
+		assert.Equal(t, "2.0.0", mismatch.Actual)
+	}
+	assert.EqualError(t, err, "export schema version 2.0.0 is newer than the expected 1.4.0")
+	assert.EqualError(t, checkSchemaVersion("1.4.0", "1.3.9"), "export schema version 1.3.9 is older than the expected 1.4.0")
+	assert.EqualError(t, checkSchemaVersion("a1b2c3d", "e4f5a6b"), "export schema version e4f5a6b does not match the expected a1b2c3d")
+	assert.EqualError(t, checkSchemaVersion("42", ""), "export has no schema version, expected 42")
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// insertStatement is a multi-row INSERT statement from a data file, split into
+// the parts needed to drop individual rows.
+type insertStatement struct {
+	head      string     // Everything up to and including VALUES
+	columns   []string   // Unquoted column names
+	rows      []string   // Value tuples as written, e.g. "(1, 'alice')"
+	values    [][]string // Literals of each row
+	checksums []string   // Checksum from the "-- CRC:" comment before each row, or "" if none
+	tail      string     // Conflict clause after the last row, without the semicolon
+}
+
+// parseInsertStatement splits a data file statement into its rows. The second
+// return value is false for statements that are not INSERT/REPLACE ... VALUES,
+// such as the wrapper statements written by --disable-keys.
+func parseInsertStatement(stmt string) (*insertStatement, bool, error) {
+	stmt = strings.TrimSpace(stmt)
+	upper := strings.ToUpper(stmt)
+	if !strings.HasPrefix(upper, "INSERT") && !strings.HasPrefix(upper, "REPLACE") {
+		return nil, false, nil
+	}
+
+	open := strings.IndexByte(stmt, '(')
+	if open < 0 {
+		return nil, false, nil
+	}
+	closeIdx := strings.IndexByte(stmt[open:], ')')
+	if closeIdx < 0 {
+		return nil, false, fmt.Errorf("unterminated column list in INSERT statement")
+	}
+	closeIdx += open
+	rest := strings.TrimLeft(stmt[closeIdx+1:], " \t\r\n")
+	if !strings.HasPrefix(strings.ToUpper(rest), "VALUES") {
+		return nil, false, nil
+	}
+
+	parsed := &insertStatement{}
+	for _, col := range strings.Split(stmt[open+1:closeIdx], ",") {
+		parsed.columns = append(parsed.columns, strings.Trim(strings.TrimSpace(col), "`\""))
+	}
+	valuesStart := len(stmt) - len(rest) + len("VALUES")
+	parsed.head = stmt[:valuesStart]
+
+	pos := valuesStart
+	checksum := ""
+	for {
+		for pos < len(stmt) && strings.ContainsRune(" \t\r\n,", rune(stmt[pos])) {
+			pos++
+		}
+		// Line comments between rows, such as the checksums of --row-checksum
+		if strings.HasPrefix(stmt[pos:], "--") {
+			lineEnd := strings.IndexByte(stmt[pos:], '\n')
+			if lineEnd < 0 {
+				lineEnd = len(stmt) - pos
+			}
+			if crc, ok := strings.CutPrefix(stmt[pos:pos+lineEnd], rowChecksumPrefix); ok {
+				checksum = strings.TrimSpace(crc)
+			}
+			pos += lineEnd
+			continue
+		}
+		if pos >= len(stmt) || stmt[pos] != '(' {
+			break
+		}
+		end, values, err := scanTuple(stmt, pos)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(values) != len(parsed.columns) {
+			return nil, false, fmt.Errorf("row has %d values but statement has %d columns", len(values), len(parsed.columns))
+		}
+		parsed.rows = append(parsed.rows, stmt[pos:end])
+		parsed.values = append(parsed.values, values)
+		parsed.checksums = append(parsed.checksums, checksum)
+		checksum = ""
+		pos = end
+	}
+	parsed.tail = strings.TrimSuffix(strings.TrimSpace(stmt[pos:]), ";")
+	return parsed, true, nil
+}
+
+// scanTuple reads the value tuple starting at s[start] == '(' and returns the
+// index after its closing parenthesis along with its top-level literals.
+// Brackets nest like parentheses, so ARRAY[1,2] stays one literal.
+func scanTuple(s string, start int) (int, []string, error) {
+	var values []string
+	depth := 0
+	valueStart := start + 1
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			// Skip the string literal, including doubled quotes and backslash escapes
+			for i++; i < len(s); i++ {
+				if s[i] == '\\' {
+					i++
+				} else if s[i] == '\'' {
+					if i+1 < len(s) && s[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case '(', '[':
+			depth++
+		case ']':
+			depth--
+		case ')':
+			depth--
+			if depth == 0 {
+				values = append(values, strings.TrimSpace(s[valueStart:i]))
+				return i + 1, values, nil
+			}
+		case ',':
+			if depth == 1 {
+				values = append(values, strings.TrimSpace(s[valueStart:i]))
+				valueStart = i + 1
+			}
+		}
+	}
+	return 0, nil, fmt.Errorf("unterminated value tuple in INSERT statement")
+}
+
+// String rebuilds the statement from its remaining rows.
+func (s *insertStatement) String() string {
+	stmt := s.head + "\n" + strings.Join(s.rows, ",\n")
+	if s.tail != "" {
+		stmt += "\n" + s.tail
+	}
+	return stmt + ";"
+}
+
+// countInsertRows returns the number of rows inserted by a data chunk, or 0 if
+// it is not an INSERT/REPLACE ... VALUES statement.
+func countInsertRows(chunk string) int {
+	stmt, ok, err := parseInsertStatement(chunk)
+	if err != nil || !ok {
+		return 0
+	}
+	return len(stmt.rows)
+}
+
+// skipExistingRows removes the rows of an INSERT chunk whose primary key already
+// exists in the table, using one lookup query for the whole chunk. It returns
+// the rewritten chunk (empty if every row exists) and the number of rows removed.
+func skipExistingRows(conn *db.Connection, tableName string, pkColumns []string, chunk string) (string, int, error) {
+	stmt, ok, err := parseInsertStatement(chunk)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse data for %s: %v", tableName, err)
+	}
+	if !ok || len(stmt.rows) == 0 {
+		return chunk, 0, nil
+	}
+
+	pkIndexes := make([]int, len(pkColumns))
+	for i, pk := range pkColumns {
+		pkIndexes[i] = -1
+		for j, col := range stmt.columns {
+			if col == pk {
+				pkIndexes[i] = j
+			}
+		}
+		if pkIndexes[i] < 0 {
+			return "", 0, fmt.Errorf("primary key column %s of table %s is missing from the data file", pk, tableName)
+		}
+	}
+
+	keys := make([][]string, len(stmt.values))
+	for i, values := range stmt.values {
+		keys[i] = make([]string, len(pkIndexes))
+		for j, idx := range pkIndexes {
+			keys[i][j] = values[idx]
+		}
+	}
+	existing, err := db.ExistingKeys(conn, tableName, pkColumns, keys)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(existing) == 0 {
+		return chunk, 0, nil
+	}
+
+	var rows []string
+	for i, row := range stmt.rows {
+		if !existing[db.LiteralKey(keys[i])] {
+			rows = append(rows, row)
+		}
+	}
+	skipped := len(stmt.rows) - len(rows)
+	if len(rows) == 0 {
+		return "", skipped, nil
+	}
+	stmt.rows = rows
+	return stmt.String(), skipped, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInsertStatement(t *testing.T) {
+	stmt, ok, err := parseInsertStatement("INSERT INTO `users` (`id`, `name`) VALUES\n" +
+		"(1, 'a, (b)'),\n(2, 'it''s'),\n(3, NULL)\nON DUPLICATE KEY UPDATE `name`=VALUES(`name`);")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []string{"id", "name"}, stmt.columns)
+	assert.Equal(t, []string{"(1, 'a, (b)')", "(2, 'it''s')", "(3, NULL)"}, stmt.rows)
+	assert.Equal(t, [][]string{{"1", "'a, (b)'"}, {"2", "'it''s'"}, {"3", "NULL"}}, stmt.values)
+	assert.Equal(t, "ON DUPLICATE KEY UPDATE `name`=VALUES(`name`)", stmt.tail)
+
+	stmt.rows = stmt.rows[1:2]
+	assert.Equal(t, "INSERT INTO `users` (`id`, `name`) VALUES\n(2, 'it''s')\nON DUPLICATE KEY UPDATE `name`=VALUES(`name`);", stmt.String())
+
+	_, ok, err = parseInsertStatement("ALTER TABLE `users` DISABLE KEYS;")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = parseInsertStatement("INSERT INTO `users` (`id`, `name`) VALUES\n(1);")
+	assert.Error(t, err)
+}
+
+func TestSkipExistingRows(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &db.Connection{DB: mockDB, Config: db.ConnectionConfig{Driver: db.DriverPostgres}}
+
+	chunk := `INSERT INTO "users" ("id", "name") VALUES` + "\n(1, 'alice'),\n(2, 'bob'),\n(3, 'carol');"
+
+	t.Run("Existing rows are removed", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT "id" FROM "users" WHERE "id" IN (1, 2, 3)`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(3))
+
+		filtered, skipped, err := skipExistingRows(conn, "users", []string{"id"}, chunk)
+		require.NoError(t, err)
+		assert.Equal(t, 2, skipped)
+		assert.Equal(t, `INSERT INTO "users" ("id", "name") VALUES`+"\n(2, 'bob');", filtered)
+	})
+
+	t.Run("Chunk is dropped when every row exists", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT "id" FROM "users" WHERE "id" IN (1, 2, 3)`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+
+		filtered, skipped, err := skipExistingRows(conn, "users", []string{"id"}, chunk)
+		require.NoError(t, err)
+		assert.Equal(t, 3, skipped)
+		assert.Empty(t, filtered)
+	})
+
+	t.Run("Missing primary key column is an error", func(t *testing.T) {
+		_, _, err := skipExistingRows(conn, "users", []string{"uuid"}, chunk)
+		assert.Error(t, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCountInsertRows(t *testing.T) {
+	assert.Equal(t, 2, countInsertRows("INSERT INTO `users` (`id`) VALUES\n(1),\n(2);"))
+	assert.Equal(t, 0, countInsertRows("SET FOREIGN_KEY_CHECKS=0;"))
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// hexDigits matches the hex encoded WKB of exported geometry values
+var hexDigits = regexp.MustCompile(`^[0-9A-Fa-f]+$`)
+
+// formatSpatialValue renders an exported geometry value as a SQL expression.
+// MySQL values have the form {srid}:{hex WKB} (see db.IsSpatialType) and
+// become ST_GeomFromWKB(X'...', srid); PostGIS values are hex EWKB and become
+// ST_GeomFromEWKB(decode('...', 'hex')), which keeps the SRID.
+func formatSpatialValue(value, driver string) (string, error) {
+	switch driver {
+	case db.DriverMySQL:
+		srid, wkb, ok := strings.Cut(value, ":")
+		if !ok || !hexDigits.MatchString(wkb) || !pgNumericLiteral.MatchString(srid) {
+			return "", fmt.Errorf("invalid geometry value %q: expected {srid}:{hex WKB}", value)
+		}
+		return fmt.Sprintf("ST_GeomFromWKB(X'%s', %s)", wkb, srid), nil
+	case db.DriverPostgres:
+		if !hexDigits.MatchString(value) {
+			return "", fmt.Errorf("invalid geometry value %q: expected hex EWKB", value)
+		}
+		return fmt.Sprintf("ST_GeomFromEWKB(decode('%s', 'hex'))", value), nil
+	}
+	return "", fmt.Errorf("%w: %s", db.ErrUnsupportedDriver, driver)
+}
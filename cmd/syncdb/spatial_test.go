@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSpatialValue(t *testing.T) {
+	point := "0101000000000000000000F03F0000000000000040"
+	testCases := []struct {
+		name       string
+		driver     string
+		columnType string
+		value      string
+		args       CommonArgs
+		expected   string
+	}{
+		{"MySQL point with SRID", db.DriverMySQL, "point", "4326:" + point, CommonArgs{}, "ST_GeomFromWKB(X'" + point + "', 4326)"},
+		{"MySQL geometry ignores base64", db.DriverMySQL, "geometry", "0:" + point, CommonArgs{Base64Blobs: true, Base64Strings: true}, "ST_GeomFromWKB(X'" + point + "', 0)"},
+		{"PostGIS geometry", db.DriverPostgres, "geometry", point, CommonArgs{}, "ST_GeomFromEWKB(decode('" + point + "', 'hex'))"},
+		{"PostGIS geography", db.DriverPostgres, "geography", point, CommonArgs{}, "ST_GeomFromEWKB(decode('" + point + "', 'hex'))"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := formatColumnValue(tc.value, tc.columnType, tc.driver, &tc.args)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, value)
+
+			// The expression is read back as a single value of the row
+			stmt, ok, err := parseInsertStatement("INSERT INTO places (id, location) VALUES\n(1, " + value + ");")
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, value, stmt.values[0][1])
+		})
+	}
+
+	value, err := formatColumnValue(nil, "point", db.DriverMySQL, &CommonArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, "NULL", value)
+
+	for _, invalid := range []string{point, "4326:POINT(1 2)", "x:" + point} {
+		_, err := formatSpatialValue(invalid, db.DriverMySQL)
+		assert.Error(t, err, invalid)
+	}
+	_, err = formatSpatialValue("POINT(1 2)", db.DriverPostgres)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+// defaultSQLHeader is the default --sql-header template. Line breaks are
+// written as \n so the template fits on the command line.
+const defaultSQLHeader = `-- Generated by syncdb on {date}\n-- Source: {driver}://{host}:{port}/{database}\n`
+
+// charsetNameRegex matches MySQL character set names such as utf8mb4
+var charsetNameRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// renderSQLHeader expands the placeholders {date}, {driver}, {host}, {port}
+// and {database} of a --sql-header template and returns it as comment lines,
+// each ending with a newline. Lines that are not comments are prefixed with
+// "-- ", so the header never changes what the file executes. An empty
+// template gives an empty header.
+func renderSQLHeader(template string, cmdArgs *CommonArgs, now time.Time) string {
+	expanded := strings.NewReplacer(
+		`\n`, "\n",
+		"{date}", now.Format("2006-01-02 15:04:05"),
+		"{driver}", cmdArgs.Driver,
+		"{host}", cmdArgs.Host,
+		"{port}", strconv.Itoa(cmdArgs.Port),
+		"{database}", cmdArgs.Database,
+	).Replace(template)
+	if strings.TrimSpace(expanded) == "" {
+		return ""
+	}
+
+	var header strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(expanded, "\n"), "\n") {
+		if !strings.HasPrefix(line, "--") {
+			line = "-- " + line
+		}
+		header.WriteString(line)
+		header.WriteString("\n")
+	}
+	return header.String()
+}
+
+// buildSetNamesStatements returns the statements selecting the character set
+// of --mysql-set-names, written at the top of the schema and data files.
+func buildSetNamesStatements(driver, charset string) ([]string, error) {
+	if charset == "" {
+		return nil, nil
+	}
+	if driver != db.DriverMySQL {
+		return nil, fmt.Errorf("--mysql-set-names is only supported for MySQL, not %s", driver)
+	}
+	if !charsetNameRegex.MatchString(charset) {
+		return nil, fmt.Errorf("invalid character set for --mysql-set-names: %q", charset)
+	}
+	return []string{
+		fmt.Sprintf("SET NAMES %s;", charset),
+		fmt.Sprintf("SET CHARACTER_SET_CLIENT=%s;", charset),
+	}, nil
+}
+
+// ansiQuotesStatement turns on the ANSI_QUOTES SQL mode, keeping the other modes
+const ansiQuotesStatement = "SET SESSION sql_mode = CONCAT(@@SESSION.sql_mode, ',ANSI_QUOTES');"
+
+// ansiQuotesRegex matches the statement of SQL files written with --ansi-quotes
+var ansiQuotesRegex = regexp.MustCompile(`(?im)^SET\s+SESSION\s+sql_mode\s*=.*ANSI_QUOTES.*$\n?`)
+
+// buildANSIQuotesStatements returns the statements written at the top of SQL
+// files exported with --ansi-quotes, which MySQL needs to read double-quoted
+// identifiers. PostgreSQL always quotes identifiers that way.
+func buildANSIQuotesStatements(driver string, ansiQuotes bool) []string {
+	if !ansiQuotes || driver != db.DriverMySQL {
+		return nil
+	}
+	return []string{ansiQuotesStatement}
+}
+
+// backtickQuoteSQL converts SQL written with --ansi-quotes back to backtick
+// quoted identifiers and drops its ANSI_QUOTES statement, so the import parses
+// and runs it like any other export without changing the session's SQL mode.
+// Other SQL is returned unchanged.
+func backtickQuoteSQL(sql []byte) []byte {
+	if !ansiQuotesRegex.Match(sql) {
+		return sql
+	}
+	sql = ansiQuotesRegex.ReplaceAll(sql, nil)
+	return []byte(db.BacktickQuoteIdentifiers(string(sql)))
+}
+
+// wrapSchemaSQL adds the SQL header, the --mysql-set-names statements and the
+// --ansi-quotes statement to the contents of 0_schema.sql. With
+// --disable-fk-check-on-export, MySQL foreign key checks are also turned off
+// for the file and restored at its end.
+// Import only runs the CREATE TABLE statements of the schema file; the other
+// statements are for replaying it with other tools, such as the mysql client.
+// The result is in the --stmt-terminator and --comment-style of the export.
+func wrapSchemaSQL(schemaSQL string, cmdArgs *CommonArgs, driver string) (string, error) {
+	setNames, err := buildSetNamesStatements(driver, cmdArgs.MySQLSetNames)
+	if err != nil {
+		return "", err
+	}
+	pre, post := append(setNames, buildANSIQuotesStatements(driver, cmdArgs.ANSIQuotes)...), []string(nil)
+	if cmdArgs.DisableFKCheckOnExport && driver == db.DriverMySQL {
+		pre = append(pre, "SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0;")
+		post = append(post, "SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS;")
+	}
+
+	var out strings.Builder
+	out.WriteString(cmdArgs.SQLHeader)
+	if len(pre) > 0 {
+		out.WriteString(strings.Join(pre, "\n"))
+		out.WriteString("\n\n")
+	}
+	out.WriteString(schemaSQL)
+	if len(post) > 0 {
+		out.WriteString("\n\n")
+		out.WriteString(strings.Join(post, "\n"))
+		out.WriteString("\n")
+	}
+	return cmdArgs.SQLStyle.script(out.String()), nil
+}
+
+// trimLeadingComments removes the comment lines at the start of a chunk, such
+// as the SQL header of the first chunk of a data file.
+func trimLeadingComments(chunk string) string {
+	chunk = strings.TrimSpace(chunk)
+	for strings.HasPrefix(chunk, "--") {
+		lineEnd := strings.IndexByte(chunk, '\n')
+		if lineEnd < 0 {
+			return ""
+		}
+		chunk = strings.TrimSpace(chunk[lineEnd+1:])
+	}
+	return chunk
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSQLHeader(t *testing.T) {
+	cmdArgs := &CommonArgs{Driver: "mysql", Host: "db.internal", Port: 3306, Database: "shop"}
+	now := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "-- Generated by syncdb on 2024-03-01 12:30:00\n-- Source: mysql://db.internal:3306/shop\n",
+		renderSQLHeader(defaultSQLHeader, cmdArgs, now))
+	// Lines that are not comments are turned into comments
+	assert.Equal(t, "-- Copyright ACME\n-- shop\n", renderSQLHeader("Copyright ACME\n-- {database}", cmdArgs, now))
+	assert.Empty(t, renderSQLHeader("", cmdArgs, now))
+	assert.Empty(t, renderSQLHeader(`\n`, cmdArgs, now))
+}
+
+func TestBuildSetNamesStatements(t *testing.T) {
+	stmts, err := buildSetNamesStatements("mysql", "utf8mb4")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"SET NAMES utf8mb4;", "SET CHARACTER_SET_CLIENT=utf8mb4;"}, stmts)
+
+	stmts, err = buildSetNamesStatements("postgres", "")
+	require.NoError(t, err)
+	assert.Empty(t, stmts)
+
+	_, err = buildSetNamesStatements("postgres", "utf8mb4")
+	assert.Error(t, err)
+	_, err = buildSetNamesStatements("mysql", "utf8; DROP TABLE users")
+	assert.Error(t, err)
+}
+
+func TestWrapSchemaSQL(t *testing.T) {
+	cmdArgs := &CommonArgs{SQLHeader: "-- header\n", MySQLSetNames: "utf8mb4", DisableFKCheckOnExport: true}
+	schema, err := wrapSchemaSQL("-- Table structure for users\nCREATE TABLE `users` (`id` int);\n", cmdArgs, "mysql")
+	require.NoError(t, err)
+	assert.Equal(t, "-- header\n"+
+		"SET NAMES utf8mb4;\nSET CHARACTER_SET_CLIENT=utf8mb4;\nSET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0;\n\n"+
+		"-- Table structure for users\nCREATE TABLE `users` (`id` int);\n"+
+		"\n\nSET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS;\n", schema)
+
+	schema, err = wrapSchemaSQL("CREATE TABLE users (id integer);", &CommonArgs{DisableFKCheckOnExport: true}, "postgres")
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE users (id integer);", schema)
+}
+
+func TestTrimLeadingComments(t *testing.T) {
+	assert.Equal(t, "INSERT INTO `t` VALUES\n-- CRC:1234\n(1);",
+		trimLeadingComments("-- Generated by syncdb\n-- Source: mysql://h:3306/db\nINSERT INTO `t` VALUES\n-- CRC:1234\n(1);"))
+	assert.Equal(t, "SET NAMES utf8mb4;", trimLeadingComments("\n  SET NAMES utf8mb4;\n"))
+	assert.Empty(t, trimLeadingComments("-- only a comment"))
+}
+
+func TestANSIQuotes(t *testing.T) {
+	assert.Equal(t, []string{ansiQuotesStatement}, buildANSIQuotesStatements("mysql", true))
+	assert.Empty(t, buildANSIQuotesStatements("mysql", false))
+	assert.Empty(t, buildANSIQuotesStatements("postgres", true))
+
+	schema, err := wrapSchemaSQL("CREATE TABLE \"users\" (\"id\" int);\n", &CommonArgs{ANSIQuotes: true}, "mysql")
+	require.NoError(t, err)
+	assert.Equal(t, ansiQuotesStatement+"\n\nCREATE TABLE \"users\" (\"id\" int);\n", schema)
+
+	// Import reads the file with backticks and without the ANSI_QUOTES statement
+	assert.Equal(t, "\nCREATE TABLE `users` (`id` int);\n", string(backtickQuoteSQL([]byte(schema))))
+	plain := []byte("INSERT INTO `t` VALUES ('say \"hi\"');")
+	assert.Equal(t, plain, backtickQuoteSQL(plain))
+}
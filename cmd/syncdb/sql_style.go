@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Statement terminators of SQL files, set by --stmt-terminator
+const (
+	stmtTerminatorSemicolon = "semicolon"
+	stmtTerminatorGo        = "go"    // GO on its own line, as read by sqlcmd and other MSSQL tools
+	stmtTerminatorSlash     = "slash" // / on its own line, as read by Oracle SQL*Plus
+)
+
+// Comment styles of SQL files, set by --comment-style
+const (
+	commentStyleDashDash = "dash-dash" // -- comment
+	commentStyleHash     = "hash"      // # comment (MySQL)
+	commentStyleCStyle   = "c-style"   // /* comment */
+)
+
+// querySeparatorName is the text of the comment separating the statements
+// of data files, --SYNCDB_QUERY_SEPARATOR-- by default.
+const querySeparatorName = "SYNCDB_QUERY_SEPARATOR"
+
+// sqlStyle is the statement terminator and comment style of exported SQL
+// files, for tools other than syncdb. The zero value is the default style:
+// statements end with a semicolon and comments start with --.
+type sqlStyle struct {
+	terminator string
+	comment    string
+}
+
+// newSQLStyle validates --stmt-terminator and --comment-style.
+func newSQLStyle(terminator, comment string) (sqlStyle, error) {
+	switch terminator {
+	case "", stmtTerminatorSemicolon, stmtTerminatorGo, stmtTerminatorSlash:
+	default:
+		return sqlStyle{}, fmt.Errorf("invalid --stmt-terminator %q (valid values: semicolon, go, slash)", terminator)
+	}
+	switch comment {
+	case "", commentStyleDashDash, commentStyleHash, commentStyleCStyle:
+	default:
+		return sqlStyle{}, fmt.Errorf("invalid --comment-style %q (valid values: dash-dash, hash, c-style)", comment)
+	}
+	if terminator == stmtTerminatorSemicolon {
+		terminator = ""
+	}
+	if comment == commentStyleDashDash {
+		comment = ""
+	}
+	return sqlStyle{terminator: terminator, comment: comment}, nil
+}
+
+// terminatorLine returns the line ending each statement, or "" if statements
+// end with a semicolon.
+func (s sqlStyle) terminatorLine() string {
+	switch s.terminator {
+	case stmtTerminatorGo:
+		return "GO"
+	case stmtTerminatorSlash:
+		return "/"
+	}
+	return ""
+}
+
+// commentLine returns text as a comment line.
+func (s sqlStyle) commentLine(text string) string {
+	switch s.comment {
+	case commentStyleHash:
+		return strings.TrimRight("# "+text, " ")
+	case commentStyleCStyle:
+		return "/* " + text + " */"
+	}
+	return strings.TrimRight("-- "+text, " ")
+}
+
+// restyleComments rewrites the -- comment lines of sql in the comment style.
+// The separator comment of data files becomes a comment in the style too.
+func (s sqlStyle) restyleComments(sql string) string {
+	if s.comment == "" || !strings.Contains(sql, "--") {
+		return sql
+	}
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "--"+querySeparatorName+"--":
+			lines[i] = s.commentLine(querySeparatorName)
+		case line == "--" || strings.HasPrefix(line, "-- "):
+			lines[i] = s.commentLine(strings.TrimPrefix(strings.TrimPrefix(line, "--"), " "))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// separator returns the separator between the statements of a data file: the
+// terminator line, or sep with its comment in the comment style.
+func (s sqlStyle) separator(sep string) string {
+	if line := s.terminatorLine(); line != "" {
+		return "\n" + line + "\n"
+	}
+	return s.restyleComments(sep)
+}
+
+// statement returns a statement of a data file in the style. With a
+// terminator line, which the data file separator provides, the statement's
+// semicolon is dropped.
+func (s sqlStyle) statement(stmt string) string {
+	stmt = s.restyleComments(stmt)
+	if s.terminator != "" {
+		stmt = strings.TrimSuffix(strings.TrimRight(stmt, " \n"), ";")
+	}
+	return stmt
+}
+
+// script returns SQL with one statement after the other, such as the schema
+// file, in the style: each line ending with a semicolon ends a statement.
+func (s sqlStyle) script(sql string) string {
+	sql = s.restyleComments(sql)
+	line := s.terminatorLine()
+	if line == "" {
+		return sql
+	}
+	lines := strings.Split(sql, "\n")
+	out := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if trimmed := strings.TrimRight(l, " \t"); strings.HasSuffix(trimmed, ";") {
+			out = append(out, strings.TrimSuffix(trimmed, ";"), line)
+			continue
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
+
+// restoreSQLStyle converts SQL written with --stmt-terminator or
+// --comment-style back to the default style, so that import reads it like
+// any other export: GO and / lines end the statement before them with a
+// semicolon and are replaced by separatorLine (dropped if it is empty or at
+// the end of the file), and # and /* */ comment lines become -- comments.
+// SQL in the default style is returned unchanged.
+func restoreSQLStyle(sql []byte, separatorLine string) []byte {
+	lines := strings.Split(string(sql), "\n")
+	out := make([]string, 0, len(lines))
+	changed := false
+	lastStatement := -1 // Index in out of the last line of a statement
+lines:
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "GO" || trimmed == "/":
+			changed = true
+			if lastStatement >= 0 && !strings.HasSuffix(out[lastStatement], ";") {
+				out[lastStatement] += ";"
+			}
+			lastStatement = -1
+			if onlyBlankLines(lines[i+1:]) {
+				break lines // The end of the last statement
+			}
+			if separatorLine != "" {
+				out = append(out, separatorLine)
+			}
+			continue
+		case trimmed == "# "+querySeparatorName || trimmed == "/* "+querySeparatorName+" */":
+			changed = true
+			if separatorLine != "" {
+				out = append(out, separatorLine)
+			}
+			continue
+		case trimmed == "#" || strings.HasPrefix(trimmed, "# "):
+			changed = true
+			line = strings.TrimRight("-- "+strings.TrimPrefix(strings.TrimPrefix(trimmed, "#"), " "), " ")
+		case strings.HasPrefix(trimmed, "/* ") && strings.HasSuffix(trimmed, " */"):
+			changed = true
+			line = strings.TrimRight("-- "+strings.TrimSuffix(strings.TrimPrefix(trimmed, "/* "), " */"), " ")
+		case trimmed != "" && !strings.HasPrefix(trimmed, "--"):
+			lastStatement = len(out)
+		}
+		out = append(out, line)
+	}
+	if !changed {
+		return sql
+	}
+	return []byte(strings.Join(out, "\n"))
+}
+
+// onlyBlankLines reports whether lines are all empty or whitespace.
+func onlyBlankLines(lines []string) bool {
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSQLStyle(t *testing.T) {
+	style, err := newSQLStyle(stmtTerminatorSemicolon, commentStyleDashDash)
+	require.NoError(t, err)
+	assert.Equal(t, sqlStyle{}, style)
+
+	style, err = newSQLStyle("go", "hash")
+	require.NoError(t, err)
+	assert.Equal(t, "GO", style.terminatorLine())
+
+	_, err = newSQLStyle("comma", "")
+	assert.ErrorContains(t, err, "invalid --stmt-terminator")
+	_, err = newSQLStyle("", "rem")
+	assert.ErrorContains(t, err, "invalid --comment-style")
+}
+
+func TestDataFileWriterSQLStyle(t *testing.T) {
+	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
+	write := func(style sqlStyle) string {
+		dir := t.TempDir()
+		out := &dataFileWriter{exportPath: dir, format: exportFormatSQL, table: "users", tableIndex: 1, bufferSize: 16,
+			separator: style.separator(separator), style: style, banner: "-- Generated by syncdb\n",
+			header: []string{"SET FOREIGN_KEY_CHECKS=0;"}}
+		for i := 1; i <= 2; i++ {
+			require.NoError(t, out.write(fmt.Sprintf("INSERT INTO `users` (`id`) VALUES\n-- CRC:%d\n(%d);", i, i), true))
+		}
+		require.NoError(t, out.close())
+		data, err := os.ReadFile(out.files[0])
+		require.NoError(t, err)
+		return string(data)
+	}
+	defaultFile := write(sqlStyle{})
+
+	mssql := write(sqlStyle{terminator: stmtTerminatorGo, comment: commentStyleHash})
+	assert.Equal(t, "# Generated by syncdb\nSET FOREIGN_KEY_CHECKS=0\nGO\n"+
+		"INSERT INTO `users` (`id`) VALUES\n# CRC:1\n(1)\nGO\n"+
+		"INSERT INTO `users` (`id`) VALUES\n# CRC:2\n(2)\nGO\n", mssql)
+	assert.Equal(t, defaultFile, string(restoreSQLStyle([]byte(mssql), strings.Trim(separator, "\n"))))
+
+	oracle := write(sqlStyle{terminator: stmtTerminatorSlash})
+	assert.Contains(t, oracle, "(1)\n/\nINSERT")
+	assert.Equal(t, defaultFile, string(restoreSQLStyle([]byte(oracle), strings.Trim(separator, "\n"))))
+
+	// Without a terminator line the separator comment is in the comment style
+	cStyle := write(sqlStyle{comment: commentStyleCStyle})
+	assert.Contains(t, cStyle, "(1);\n/* SYNCDB_QUERY_SEPARATOR */\nINSERT")
+	assert.Equal(t, defaultFile, string(restoreSQLStyle([]byte(cStyle), strings.Trim(separator, "\n"))))
+}
+
+func TestSQLStyleSchema(t *testing.T) {
+	cmdArgs := &CommonArgs{SQLHeader: "-- header\n", SQLStyle: sqlStyle{terminator: stmtTerminatorGo, comment: commentStyleCStyle}}
+	table := "-- SQL_MODE=ANSI\n\n-- Table structure for users\nCREATE TABLE `users` (\n  `id` int\n);\n"
+	schema, err := wrapSchemaSQL(table, cmdArgs, "mysql")
+	require.NoError(t, err)
+	assert.Equal(t, "/* header */\n/* SQL_MODE=ANSI */\n\n/* Table structure for users */\nCREATE TABLE `users` (\n  `id` int\n)\nGO\n", schema)
+
+	// Import reads the schema in the default style
+	assert.Equal(t, "-- header\n"+strings.TrimSuffix(table, "\n"), string(restoreSQLStyle([]byte(schema), "")))
+	plain := []byte("-- header\nCREATE TABLE `users` (`id` int);\n")
+	assert.Equal(t, plain, restoreSQLStyle(plain, ""))
+}
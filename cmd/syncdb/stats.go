@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// statsFileName is the export statistics file written next to 0_metadata.json.
+const statsFileName = "0_stats.json"
+
+// columnStatsFileName is the per-column statistics file written with --include-stats.
+const columnStatsFileName = "0_column_stats.json"
+
+// ExportStats holds timing and throughput for the data export of a single table.
+type ExportStats struct {
+	TableName        string        `json:"table_name"`
+	RecordsExported  int           `json:"records_exported"`
+	FileSizeBytes    int64         `json:"file_size_bytes"`
+	Duration         time.Duration `json:"duration_ns"`
+	RecordsPerSecond float64       `json:"records_per_second"`
+	MBPerSecond      float64       `json:"mb_per_second"`
+	Chunks           int           `json:"chunks,omitempty"`     // Number of data files written with --chunk-size or --max-file-size
+	BlobFiles        int           `json:"blob_files,omitempty"` // Number of BLOB sidecar files written with --blob-threshold
+}
+
+// newExportStats builds an ExportStats and computes its throughput figures.
+func newExportStats(table string, records int, fileSize int64, duration time.Duration) ExportStats {
+	stats := ExportStats{
+		TableName:       table,
+		RecordsExported: records,
+		FileSizeBytes:   fileSize,
+		Duration:        duration,
+	}
+	if seconds := duration.Seconds(); seconds > 0 {
+		stats.RecordsPerSecond = float64(records) / seconds
+		stats.MBPerSecond = float64(fileSize) / (1024 * 1024) / seconds
+	}
+	return stats
+}
+
+func newStatsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show statistics of an existing export",
+		Long: `Show the per-table timing and throughput report (0_stats.json) of an export directory.
+Examples:
+  syncdb stats --path ./backups/mydb_20240101_120000`,
+		Args: cobra.NoArgs,
+		RunE: runStats,
+	}
+
+	cmd.Flags().StringP("path", "o", "", "Path to the export directory")
+	cmd.MarkFlagRequired("path")
+
+	return cmd
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	exportPath, _ := cmd.Flags().GetString("path")
+	stats, err := readExportStats(exportPath)
+	if err != nil {
+		return err
+	}
+	return printExportStats(os.Stdout, stats)
+}
+
+// writeExportStats writes the stats, ordered by table name, to 0_stats.json in exportPath.
+func writeExportStats(exportPath string, stats []ExportStats) error {
+	sort.Slice(stats, func(i, j int) bool { return stats[i].TableName < stats[j].TableName })
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export stats: %v", err)
+	}
+	statsFile := filepath.Join(exportPath, statsFileName)
+	if err := os.WriteFile(statsFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write stats file %s: %v", statsFile, err)
+	}
+	return nil
+}
+
+// writeColumnStats computes the column statistics (see db.GetTableColumnStats)
+// of the exported tables and writes them to 0_column_stats.json in exportPath,
+// keyed by table and column name.
+func writeColumnStats(conn *db.Connection, exportPath string, stats []ExportStats) error {
+	columnStats := make(map[string]map[string]db.ColumnStats, len(stats))
+	for _, s := range stats {
+		fmt.Printf("Computing column statistics of table '%s'...\n", s.TableName)
+		tableStats, err := db.GetTableColumnStats(conn, s.TableName)
+		if err != nil {
+			return err
+		}
+		columnStats[s.TableName] = tableStats
+	}
+	data, err := json.MarshalIndent(columnStats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal column stats: %v", err)
+	}
+	statsFile := filepath.Join(exportPath, columnStatsFileName)
+	if err := os.WriteFile(statsFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write column stats file %s: %v", statsFile, err)
+	}
+	return nil
+}
+
+// readExportStats reads 0_stats.json from an export directory.
+func readExportStats(exportPath string) ([]ExportStats, error) {
+	data, err := os.ReadFile(filepath.Join(exportPath, statsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats file: %v", err)
+	}
+	var stats []ExportStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse stats file: %v", err)
+	}
+	return stats, nil
+}
+
+// printExportStats writes the stats as an aligned table followed by a total row.
+func printExportStats(w io.Writer, stats []ExportStats) error {
+	if len(stats) == 0 {
+		fmt.Fprintln(w, "No table statistics recorded.")
+		return nil
+	}
+
+	var totalRecords int
+	var totalSize int64
+	var totalDuration time.Duration
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tRECORDS\tSIZE\tDURATION\tRECORDS/S\tMB/S")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.0f\t%.2f\n", s.TableName, s.RecordsExported,
+			formatSize(s.FileSizeBytes), s.Duration.Round(time.Millisecond), s.RecordsPerSecond, s.MBPerSecond)
+		totalRecords += s.RecordsExported
+		totalSize += s.FileSizeBytes
+		totalDuration += s.Duration
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%s\t%s\t\t\n", totalRecords, formatSize(totalSize), totalDuration.Round(time.Millisecond))
+	return tw.Flush()
+}
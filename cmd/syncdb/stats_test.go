@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewExportStats(t *testing.T) {
+	stats := newExportStats("users", 1000, 2*1024*1024, 2*time.Second)
+	assert.Equal(t, 500.0, stats.RecordsPerSecond)
+	assert.Equal(t, 1.0, stats.MBPerSecond)
+
+	empty := newExportStats("empty", 0, 0, 0)
+	assert.Zero(t, empty.RecordsPerSecond)
+	assert.Zero(t, empty.MBPerSecond)
+}
+
+func TestExportStatsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	stats := []ExportStats{
+		newExportStats("users", 1000, 2048, time.Second),
+		newExportStats("orders", 10, 512, 500*time.Millisecond),
+	}
+	require.NoError(t, writeExportStats(dir, stats))
+
+	read, err := readExportStats(dir)
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+	assert.Equal(t, "orders", read[0].TableName)
+	assert.Equal(t, 500*time.Millisecond, read[0].Duration)
+
+	var buf bytes.Buffer
+	require.NoError(t, printExportStats(&buf, read))
+	assert.Contains(t, buf.String(), "users")
+	assert.Contains(t, buf.String(), "TOTAL")
+
+	_, err = readExportStats(t.TempDir())
+	assert.Error(t, err)
+}
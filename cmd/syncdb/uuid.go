@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+)
+
+const (
+	uuidFormatString = "string"
+	uuidFormatHex    = "hex"
+	uuidFormatBinary = "binary"
+)
+
+// uuidPattern matches a UUID in its canonical 8-4-4-4-12 text form
+const uuidPattern = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+
+var uuidRegex = regexp.MustCompile(uuidPattern)
+
+// uuidColumnNames are the column names considered for UUID detection
+var uuidColumnNames = map[string]bool{"id": true, "uuid": true, "guid": true}
+
+// insertionOrderColumns are the columns, in order of preference, used to sort
+// a table with a UUID primary key by insertion order with --order-by-pk
+var insertionOrderColumns = []string{"created_at", "inserted_at", "created"}
+
+// validateUUIDFormat validates --uuid-format for the driver. binary relies on
+// UNHEX(), so it is only available for MySQL.
+func validateUUIDFormat(format, driver string) error {
+	switch format {
+	case "", uuidFormatString, uuidFormatHex:
+	case uuidFormatBinary:
+		if driver == db.DriverPostgres {
+			return fmt.Errorf("--uuid-format binary is only supported for MySQL")
+		}
+	default:
+		return fmt.Errorf("invalid uuid format %q (valid values: string, hex, binary)", format)
+	}
+	return nil
+}
+
+// isUUIDCandidate reports whether a column may hold UUIDs: a CHAR (or
+// PostgreSQL uuid) column named id, uuid or guid.
+func isUUIDCandidate(column, columnType string) bool {
+	if !uuidColumnNames[strings.ToLower(column)] {
+		return false
+	}
+	switch strings.ToLower(columnType) {
+	case "char", "bpchar", "uuid":
+		return true
+	}
+	return false
+}
+
+// detectUUIDColumns returns the UUID candidate columns whose values in sample
+// (the first batch of rows) are all UUIDs. Columns with only NULLs in the
+// sample are not reported.
+func detectUUIDColumns(columns []string, columnTypes map[string]string, sample []map[string]interface{}) map[string]bool {
+	uuidColumns := make(map[string]bool)
+	for _, col := range columns {
+		if !isUUIDCandidate(col, columnTypes[col]) {
+			continue
+		}
+		matched := false
+		for _, row := range sample {
+			if row[col] == nil {
+				continue
+			}
+			value, ok := row[col].(string)
+			if !ok || !uuidRegex.MatchString(value) {
+				matched = false
+				break
+			}
+			matched = true
+		}
+		if matched {
+			uuidColumns[col] = true
+		}
+	}
+	return uuidColumns
+}
+
+// formatUUIDValue renders a UUID of a detected column for --uuid-format: hex
+// as its 32 hex digits, binary as UNHEX() of them. ok is false when the value
+// is not a UUID or the format leaves it as is.
+func formatUUIDValue(value interface{}, format string) (string, bool) {
+	s, isString := value.(string)
+	if !isString || !uuidRegex.MatchString(s) {
+		return "", false
+	}
+	digits := strings.ToLower(strings.ReplaceAll(s, "-", ""))
+	switch format {
+	case uuidFormatHex:
+		return "'" + digits + "'", true
+	case uuidFormatBinary:
+		return "UNHEX('" + digits + "')", true
+	}
+	return "", false
+}
+
+// uuidInsertionOrder returns the sort specification for a table whose primary
+// key is a single UUID candidate column: its first insertionOrderColumns column
+// followed by the key to break ties. Random UUIDs give no useful order of
+// their own. It returns "" when the key is not a UUID or the table has no
+// such column.
+func uuidInsertionOrder(pkColumns []string, columnTypes map[string]string) string {
+	if len(pkColumns) != 1 || !isUUIDCandidate(pkColumns[0], columnTypes[pkColumns[0]]) {
+		return ""
+	}
+	for _, col := range insertionOrderColumns {
+		if columnType, ok := columnTypes[col]; ok && isTimeType(columnType) {
+			return col + ", " + pkColumns[0]
+		}
+	}
+	return ""
+}
+
+// isTimeType reports whether a MySQL DATA_TYPE or PostgreSQL udt_name holds a
+// point in time.
+func isTimeType(columnType string) bool {
+	switch strings.ToLower(columnType) {
+	case "datetime", "timestamp", "timestamptz":
+		return true
+	}
+	return false
+}
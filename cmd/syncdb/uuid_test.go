@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hoangnguyenba/syncdb/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUUIDFormat(t *testing.T) {
+	assert.NoError(t, validateUUIDFormat("", db.DriverMySQL))
+	assert.NoError(t, validateUUIDFormat(uuidFormatHex, db.DriverPostgres))
+	assert.NoError(t, validateUUIDFormat(uuidFormatBinary, db.DriverMySQL))
+	assert.Error(t, validateUUIDFormat(uuidFormatBinary, db.DriverPostgres))
+	assert.Error(t, validateUUIDFormat("base64", db.DriverMySQL))
+}
+
+func TestDetectUUIDColumns(t *testing.T) {
+	columns := []string{"id", "uuid", "guid", "name", "code"}
+	columnTypes := map[string]string{"id": "char", "uuid": "varchar", "guid": "char", "name": "char", "code": "char"}
+	sample := []map[string]interface{}{
+		{"id": "550e8400-e29b-41d4-a716-446655440000", "uuid": "550e8400-e29b-41d4-a716-446655440000", "guid": nil, "name": "550e8400-e29b-41d4-a716-446655440000"},
+		{"id": "6BA7B810-9DAD-11D1-80B4-00C04FD430C8", "guid": "not-a-uuid"},
+	}
+
+	// uuid is not a CHAR column, guid has a non-UUID value and name is not a UUID column name
+	assert.Equal(t, map[string]bool{"id": true}, detectUUIDColumns(columns, columnTypes, sample))
+	assert.Empty(t, detectUUIDColumns(columns, columnTypes, nil))
+}
+
+func TestFormatUUIDValue(t *testing.T) {
+	value := "6BA7B810-9DAD-11D1-80B4-00C04FD430C8"
+
+	literal, ok := formatUUIDValue(value, uuidFormatHex)
+	assert.True(t, ok)
+	assert.Equal(t, "'6ba7b8109dad11d180b400c04fd430c8'", literal)
+
+	literal, ok = formatUUIDValue(value, uuidFormatBinary)
+	assert.True(t, ok)
+	assert.Equal(t, "UNHEX('6ba7b8109dad11d180b400c04fd430c8')", literal)
+
+	_, ok = formatUUIDValue(value, uuidFormatString)
+	assert.False(t, ok)
+	_, ok = formatUUIDValue("legacy-id-1", uuidFormatHex)
+	assert.False(t, ok)
+	_, ok = formatUUIDValue(nil, uuidFormatHex)
+	assert.False(t, ok)
+}
+
+func TestUUIDInsertionOrder(t *testing.T) {
+	columnTypes := map[string]string{"id": "char", "created": "date", "created_at": "timestamp"}
+	assert.Equal(t, "created_at, id", uuidInsertionOrder([]string{"id"}, columnTypes))
+
+	// No insertion time column
+	assert.Equal(t, "", uuidInsertionOrder([]string{"id"}, map[string]string{"id": "char"}))
+	// Not a UUID key
+	assert.Equal(t, "", uuidInsertionOrder([]string{"id"}, map[string]string{"id": "int", "created_at": "datetime"}))
+	assert.Equal(t, "", uuidInsertionOrder([]string{"id", "tenant"}, columnTypes))
+}
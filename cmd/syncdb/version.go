@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// latestReleaseURL is the GitHub API endpoint of the latest syncdb release
+const latestReleaseURL = "https://api.github.com/repos/hoangnguyenba/syncdb/releases/latest"
+
+// versionInfo is the build information printed by 'syncdb version'.
+type versionInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	// Set with --check-latest
+	LatestVersion   string `json:"latest_version,omitempty"`
+	UpdateAvailable bool   `json:"update_available,omitempty"`
+}
+
+func newVersionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version and build information",
+		Long: `Print the syncdb version, the git commit and date it was built from, the Go
+version and the target OS/architecture.
+Examples:
+  syncdb version
+  syncdb version --format json
+  syncdb version --check-latest`,
+		Args: cobra.NoArgs,
+		RunE: runVersion,
+	}
+
+	flags := cmd.Flags()
+	flags.StringP("format", "f", "text", "Output format (text, json)")
+	flags.Bool("check-latest", false, "Compare with the latest release on GitHub and tell whether an update is available")
+
+	return cmd
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid format '%s': must be text or json", format)
+	}
+
+	info := currentVersionInfo()
+	if checkLatest, _ := cmd.Flags().GetBool("check-latest"); checkLatest {
+		client := &http.Client{Timeout: 10 * time.Second}
+		latest, err := fetchLatestRelease(client, latestReleaseURL)
+		if err != nil {
+			return err
+		}
+		info.LatestVersion = latest
+		info.UpdateAvailable = isNewerRelease(info.Version, latest)
+	}
+	return printVersionInfo(cmd.OutOrStdout(), info, format)
+}
+
+// currentVersionInfo returns the build information of the running binary.
+func currentVersionInfo() versionInfo {
+	return versionInfo{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+}
+
+// printVersionInfo writes the build information as text or as a JSON object.
+func printVersionInfo(w io.Writer, info versionInfo, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	fmt.Fprintf(w, "syncdb %s\n", info.Version)
+	fmt.Fprintf(w, "  Git commit: %s\n", info.GitCommit)
+	fmt.Fprintf(w, "  Build date: %s\n", info.BuildDate)
+	fmt.Fprintf(w, "  Go version: %s\n", info.GoVersion)
+	fmt.Fprintf(w, "  OS/Arch:    %s/%s\n", info.OS, info.Arch)
+	if info.LatestVersion == "" {
+		return nil
+	}
+	if info.UpdateAvailable {
+		fmt.Fprintf(w, "\nA new release is available: %s (https://github.com/hoangnguyenba/syncdb/releases/latest)\n", info.LatestVersion)
+	} else if _, ok := compareSchemaVersions(info.LatestVersion, info.Version); ok {
+		fmt.Fprintf(w, "\nsyncdb is up to date (latest release: %s)\n", info.LatestVersion)
+	} else {
+		// A development build cannot be compared with the releases
+		fmt.Fprintf(w, "\nLatest release: %s\n", info.LatestVersion)
+	}
+	return nil
+}
+
+// fetchLatestRelease returns the tag of the latest release reported by the
+// GitHub releases API at url.
+func fetchLatestRelease(client *http.Client, url string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to check the latest release: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to check the latest release: %s", resp.Status)
+	}
+
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", fmt.Errorf("failed to read the latest release: %v", err)
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("latest release has no tag")
+	}
+	return release.TagName, nil
+}
+
+// isNewerRelease reports whether latest is a higher semantic version than
+// current. Development builds and other non-semantic versions are never
+// reported as outdated.
+func isNewerRelease(current, latest string) bool {
+	cmp, ok := compareSchemaVersions(latest, current)
+	return ok && cmp > 0
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintVersionInfo(t *testing.T) {
+	info := versionInfo{Version: "v1.2.3", GitCommit: "abc123", BuildDate: "2024-01-15", GoVersion: "go1.23.0", OS: "linux", Arch: "amd64"}
+
+	var buf bytes.Buffer
+	require.NoError(t, printVersionInfo(&buf, info, "json"))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, map[string]interface{}{
+		"version":    "v1.2.3",
+		"git_commit": "abc123",
+		"build_date": "2024-01-15",
+		"go_version": "go1.23.0",
+		"os":         "linux",
+		"arch":       "amd64",
+	}, decoded)
+
+	// The latest release is only reported with --check-latest
+	info.LatestVersion, info.UpdateAvailable = "v1.3.0", true
+	buf.Reset()
+	require.NoError(t, printVersionInfo(&buf, info, "json"))
+	assert.Contains(t, buf.String(), `"latest_version": "v1.3.0"`)
+	assert.Contains(t, buf.String(), `"update_available": true`)
+
+	buf.Reset()
+	require.NoError(t, printVersionInfo(&buf, info, "text"))
+	assert.Contains(t, buf.String(), "syncdb v1.2.3\n")
+	assert.Contains(t, buf.String(), "OS/Arch:    linux/amd64")
+	assert.Contains(t, buf.String(), "A new release is available: v1.3.0")
+}
+
+func TestIsNewerRelease(t *testing.T) {
+	assert.True(t, isNewerRelease("v1.2.3", "v1.3.0"))
+	assert.True(t, isNewerRelease("1.2.3", "v1.2.4"))
+	assert.True(t, isNewerRelease("v1.3.0-rc.1", "v1.3.0"))
+	assert.False(t, isNewerRelease("v1.3.0", "v1.3.0"))
+	assert.False(t, isNewerRelease("v1.4.0", "v1.3.0"))
+	assert.False(t, isNewerRelease("dev", "v1.3.0"))
+}
+
+func TestFetchLatestRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"tag_name": "v1.3.0", "name": "syncdb 1.3.0"}`))
+	}))
+	defer server.Close()
+
+	latest, err := fetchLatestRelease(server.Client(), server.URL+"/latest")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.3.0", latest)
+
+	_, err = fetchLatestRelease(server.Client(), server.URL+"/missing")
+	assert.ErrorContains(t, err, "404")
+}
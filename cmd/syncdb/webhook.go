@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/notify"
+)
+
+// sendWebhook reports the outcome of an export or import to the configured
+// webhook. Delivery failures are printed as warnings and never change the
+// result of the command.
+func sendWebhook(cmdArgs *CommonArgs, operation string, start time.Time, tablesCount int, records int64, runErr error) {
+	if cmdArgs == nil || cmdArgs.WebhookURL == "" {
+		return
+	}
+
+	payload := notify.Payload{
+		Event:           webhookEvent(operation, runErr),
+		Database:        cmdArgs.Database,
+		TablesCount:     tablesCount,
+		Records:         records,
+		DurationSeconds: time.Since(start).Seconds(),
+	}
+	if runErr != nil {
+		payload.Error = runErr.Error()
+	}
+
+	if err := notify.NotifyWebhook(cmdArgs.WebhookURL, cmdArgs.WebhookMethod, cmdArgs.WebhookHeaders, payload, cmdArgs.WebhookTimeout); err != nil {
+		fmt.Printf("Warning: failed to send webhook notification: %v\n", err)
+	}
+}
+
+// webhookEvent returns the event name for an operation ("export" or "import").
+func webhookEvent(operation string, runErr error) string {
+	switch {
+	case operation == "export" && runErr == nil:
+		return notify.EventExportComplete
+	case operation == "export":
+		return notify.EventExportFailed
+	case runErr == nil:
+		return notify.EventImportComplete
+	default:
+		return notify.EventImportFailed
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendWebhook(t *testing.T) {
+	var received notify.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = notify.Payload{}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	cmdArgs := &CommonArgs{Database: "shop", WebhookURL: server.URL, WebhookMethod: http.MethodPost, WebhookTimeout: time.Second}
+	sendWebhook(cmdArgs, "import", time.Now(), 2, 10, errors.New("chunk failed"))
+	assert.Equal(t, notify.EventImportFailed, received.Event)
+	assert.Equal(t, "shop", received.Database)
+	assert.Equal(t, 2, received.TablesCount)
+	assert.Equal(t, int64(10), received.Records)
+	assert.Equal(t, "chunk failed", received.Error)
+
+	sendWebhook(cmdArgs, "export", time.Now(), 1, 5, nil)
+	assert.Equal(t, notify.EventExportComplete, received.Event)
+	assert.Empty(t, received.Error)
+}
+
+func TestSendWebhookFailureIsIgnored(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	require.NotPanics(t, func() {
+		sendWebhook(&CommonArgs{WebhookURL: server.URL, WebhookMethod: http.MethodPost, WebhookTimeout: time.Second}, "export", time.Now(), 0, 0, nil)
+	})
+}
@@ -0,0 +1,180 @@
+// Package catalog records the last successful export of each database in a
+// JSON file, so later commands can find it without knowing its path.
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hoangnguyenba/syncdb/pkg/lock"
+	"github.com/hoangnguyenba/syncdb/pkg/profile"
+)
+
+// FileName is the name of the catalog file in the syncdb directory.
+const FileName = "catalog.json"
+
+// lockTimeout is how long an update waits for another process to release the catalog.
+const lockTimeout = 10 * time.Second
+
+// Entry describes the last successful export of a database.
+type Entry struct {
+	Driver     string    `json:"driver"`
+	Host       string    `json:"host"`
+	Port       int       `json:"port"`
+	Database   string    `json:"database"`
+	Storage    string    `json:"storage"`
+	Path       string    `json:"path"`
+	ExportedAt time.Time `json:"exported_at"`
+	Tables     int       `json:"tables"`
+	Records    int64     `json:"records"`
+	Format     string    `json:"format"`
+	// Version of the exported schema, set with --schema-version
+	SchemaVersion string `json:"schema_version,omitempty"`
+}
+
+// Key returns the catalog key of the database described by the entry.
+func (e Entry) Key() string {
+	return Key(e.Driver, e.Host, e.Port, e.Database)
+}
+
+// Key returns the catalog key of a database, in the form driver:host:port/database.
+func Key(driver, host string, port int, database string) string {
+	return fmt.Sprintf("%s:%s:%d/%s", driver, host, port, database)
+}
+
+// Catalog is a catalog file. Updates are serialized with a lock file next to it.
+type Catalog struct {
+	path string
+}
+
+// New returns the catalog stored at path.
+func New(path string) *Catalog {
+	return &Catalog{path: path}
+}
+
+// Open returns the catalog in the syncdb directory: syncDBPath if set, the
+// default configuration directory otherwise.
+func Open(syncDBPath string) (*Catalog, error) {
+	dir, err := profile.GetSyncDBDir(syncDBPath)
+	if err != nil {
+		return nil, err
+	}
+	return New(filepath.Join(dir, FileName)), nil
+}
+
+// Path returns the path of the catalog file.
+func (c *Catalog) Path() string {
+	return c.path
+}
+
+// Entries returns all entries sorted by key. A missing catalog has no entries.
+func (c *Catalog) Entries() ([]Entry, error) {
+	entries, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key() < list[j].Key() })
+	return list, nil
+}
+
+// Find returns the entries matching name, which is either a full catalog key
+// or a database name.
+func (c *Catalog) Find(name string) ([]Entry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+	var matches []Entry
+	for _, entry := range entries {
+		if entry.Key() == name {
+			return []Entry{entry}, nil
+		}
+		if entry.Database == name {
+			matches = append(matches, entry)
+		}
+	}
+	return matches, nil
+}
+
+// Put adds or replaces the entry for its database.
+func (c *Catalog) Put(entry Entry) error {
+	return c.update(func(entries map[string]Entry) {
+		entries[entry.Key()] = entry
+	})
+}
+
+// Remove deletes the entry with the given key. It is not an error if the key
+// does not exist.
+func (c *Catalog) Remove(key string) error {
+	return c.update(func(entries map[string]Entry) {
+		delete(entries, key)
+	})
+}
+
+// update applies change to the catalog while holding the catalog lock, so
+// concurrent exports do not overwrite each other's entries.
+func (c *Catalog) update(change func(entries map[string]Entry)) error {
+	fileLock := lock.New(c.path + ".lock")
+	locked, err := fileLock.TryLockFor(lockTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to lock catalog: %w", err)
+	}
+	if !locked {
+		return fmt.Errorf("catalog %s is locked by another process", c.path)
+	}
+	defer fileLock.Unlock()
+
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+	change(entries)
+	return c.write(entries)
+}
+
+func (c *Catalog) read() (map[string]Entry, error) {
+	entries := make(map[string]Entry)
+	data, err := os.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog %s: %w", c.path, err)
+	}
+	return entries, nil
+}
+
+// write replaces the catalog file atomically, so readers never see a partial file.
+func (c *Catalog) write(entries map[string]Entry) error {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), FileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to write catalog: %w", err)
+	}
+	return nil
+}
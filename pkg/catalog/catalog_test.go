@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogPutFindRemove(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), FileName))
+
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	first := Entry{Driver: "mysql", Host: "localhost", Port: 3306, Database: "shop", Path: "/backups/shop_1.zip", ExportedAt: time.Now().UTC()}
+	second := Entry{Driver: "postgres", Host: "db", Port: 5432, Database: "shop", Path: "/backups/shop_2.zip"}
+	require.NoError(t, c.Put(first))
+	require.NoError(t, c.Put(second))
+
+	first.Path = "/backups/shop_3.zip"
+	require.NoError(t, c.Put(first))
+
+	matches, err := c.Find("shop")
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+
+	matches, err = c.Find("mysql:localhost:3306/shop")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "/backups/shop_3.zip", matches[0].Path)
+	assert.True(t, first.ExportedAt.Equal(matches[0].ExportedAt))
+
+	require.NoError(t, c.Remove(first.Key()))
+	entries, err = c.Entries()
+	require.NoError(t, err)
+	assert.Equal(t, []Entry{second}, entries)
+}
+
+func TestCatalogConcurrentPut(t *testing.T) {
+	c := New(filepath.Join(t.TempDir(), FileName))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, c.Put(Entry{Driver: "mysql", Host: "localhost", Port: 3306, Database: fmt.Sprintf("db%d", i)}))
+		}(i)
+	}
+	wg.Wait()
+
+	entries, err := c.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 10)
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "postgres:db.internal:5432/shop", Key("postgres", "db.internal", 5432, "shop"))
+}
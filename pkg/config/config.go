@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -24,6 +25,10 @@ type CommonConfig struct {
 	Storage            string
 	S3Bucket           string
 	S3Region           string
+	GCSBucket          string
+	GCSProject         string
+	GCSCredentials     string
+	Workers            int // Tables exported or imported in parallel (0 means the command's default)
 }
 
 // Config holds the overall application configuration
@@ -51,7 +56,11 @@ func loadCommonConfig(prefix string) CommonConfig {
 	cfg.Path = getViperString(prefix+"path", "")
 	cfg.S3Bucket = getViperString(prefix+"s3_bucket", "")
 	cfg.S3Region = getViperString(prefix+"s3_region", "")
+	cfg.GCSBucket = getViperString(prefix+"gcs_bucket", "")
+	cfg.GCSProject = getViperString(prefix+"gcs_project", "")
+	cfg.GCSCredentials = getViperString(prefix+"gcs_credentials", "")
 	cfg.Storage = getViperString(prefix+"storage", "local")
+	cfg.Workers = getViperInt(prefix+"workers", 0)
 
 	// Handle tables
 	if tables := getViperString(prefix+"tables", ""); tables != "" {
@@ -79,9 +88,9 @@ func LoadConfig() (*Config, error) {
 
 	// Read .env file if it exists (ignore error if it doesn't)
 	if err := viper.ReadInConfig(); err != nil {
-		fmt.Printf("Debug: Error reading config file: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Debug: Error reading config file: %v\n", err)
 	} else {
-		fmt.Printf("Debug: Successfully read config from: %s\n", viper.ConfigFileUsed())
+		fmt.Fprintf(os.Stderr, "Debug: Successfully read config from: %s\n", viper.ConfigFileUsed())
 	}
 
 	// Enable environment variable reading
@@ -103,9 +112,9 @@ func LoadConfig() (*Config, error) {
 		config.Import.Format = "json" // Set import-specific default if not overridden
 	}
 
-	// Debug output (optional, adjust as needed)
-	fmt.Printf("Debug: Import Config Loaded: %+v\n", config.Import)
-	fmt.Printf("Debug: Export Config Loaded: %+v\n", config.Export)
+	// Debug output goes to stderr so it does not mix with command output such as completion scripts
+	fmt.Fprintf(os.Stderr, "Debug: Import Config Loaded: %+v\n", config.Import)
+	fmt.Fprintf(os.Stderr, "Debug: Export Config Loaded: %+v\n", config.Export)
 	// fmt.Printf("Debug: Export Database = %s\n", config.Export.Database)
 	// fmt.Printf("Debug: Export Driver = %s\n", config.Export.Driver)
 	// fmt.Printf("Debug: Export Host = %s\n", config.Export.Host)
@@ -62,14 +62,16 @@ func TestLoadConfig(t *testing.T) {
 		cfg, err := LoadConfig() // Assumes it won't find a .env file
 		require.NoError(t, err)
 
-		// Check defaults (assuming defaults are zero/empty strings in the struct definition)
-		assert.Equal(t, "", cfg.Export.Host)
-		assert.Equal(t, 0, cfg.Export.Port)
+		// Check the defaults of loadCommonConfig and LoadConfig
+		assert.Equal(t, "localhost", cfg.Export.Host)
+		assert.Equal(t, 3306, cfg.Export.Port)
 		assert.Equal(t, "", cfg.Export.Database)
-		assert.Equal(t, "", cfg.Import.Host)
-		assert.Equal(t, 0, cfg.Import.Port)
+		assert.Equal(t, "localhost", cfg.Import.Host)
+		assert.Equal(t, 3306, cfg.Import.Port)
 		assert.Equal(t, "", cfg.Import.Database)
-		assert.Equal(t, 0, cfg.Export.BatchSize) // Check default batch size if defined
+		assert.Equal(t, 500, cfg.Export.BatchSize)
+		assert.Equal(t, "sql", cfg.Export.Format)
+		assert.Equal(t, "json", cfg.Import.Format)
 	})
 
 	t.Run("Load from .env file only", func(t *testing.T) {
@@ -110,15 +112,16 @@ SYNCDB_EXPORT_BATCH_SIZE=100
 		assert.Equal(t, 100, cfg.Export.BatchSize)
 	})
 
+	// Variables set with t.Setenv are restored after each subtest
 	t.Run("Load from environment variables only", func(t *testing.T) {
-		os.Setenv("SYNCDB_EXPORT_HOST", "os_export_host")
-		os.Setenv("SYNCDB_EXPORT_PORT", "3333")
-		os.Setenv("SYNCDB_EXPORT_DATABASE", "os_export_db")
-		os.Setenv("SYNCDB_IMPORT_HOST", "os_import_host")
-		os.Setenv("SYNCDB_IMPORT_PORT", "4444")
-		os.Setenv("SYNCDB_IMPORT_DATABASE", "os_import_db")
-		os.Setenv("SYNCDB_EXPORT_TABLES", "os_tableA,os_tableB")
-		os.Setenv("SYNCDB_EXPORT_BATCH_SIZE", "200")
+		t.Setenv("SYNCDB_EXPORT_HOST", "os_export_host")
+		t.Setenv("SYNCDB_EXPORT_PORT", "3333")
+		t.Setenv("SYNCDB_EXPORT_DATABASE", "os_export_db")
+		t.Setenv("SYNCDB_IMPORT_HOST", "os_import_host")
+		t.Setenv("SYNCDB_IMPORT_PORT", "4444")
+		t.Setenv("SYNCDB_IMPORT_DATABASE", "os_import_db")
+		t.Setenv("SYNCDB_EXPORT_TABLES", "os_tableA,os_tableB")
+		t.Setenv("SYNCDB_EXPORT_BATCH_SIZE", "200")
 
 		cfg, err := LoadConfig() // Assumes no .env file present
 		require.NoError(t, err)
@@ -145,10 +148,10 @@ SYNCDB_EXPORT_BATCH_SIZE=100
 		defer cleanupEnv()
 
 		// Environment variable settings (should override)
-		os.Setenv("SYNCDB_EXPORT_HOST", "os_export_host")
-		os.Setenv("SYNCDB_EXPORT_PORT", "3333")
+		t.Setenv("SYNCDB_EXPORT_HOST", "os_export_host")
+		t.Setenv("SYNCDB_EXPORT_PORT", "3333")
 		// Database not set in OS env, should take from .env
-		os.Setenv("SYNCDB_EXPORT_BATCH_SIZE", "200")
+		t.Setenv("SYNCDB_EXPORT_BATCH_SIZE", "200")
 
 		// Temporarily move to the directory of the temp .env file
 		originalWd, _ := os.Getwd()
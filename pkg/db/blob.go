@@ -0,0 +1,62 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// blobPlaceholderFunc is the SQL function name of a BLOB placeholder.
+const blobPlaceholderFunc = "LOAD_BLOB_FILE"
+
+// blobPlaceholderRegex matches the placeholders written by BlobPlaceholder. A
+// placeholder inside a string literal does not match, since the quotes around
+// the file name are escaped there.
+var blobPlaceholderRegex = regexp.MustCompile(blobPlaceholderFunc + `\('([^'\\/]+\.bin)'\)`)
+
+// BlobPlaceholder returns the SQL written in place of a binary value that is
+// exported to the sidecar file name: LOAD_BLOB_FILE('name'). It is not a real
+// SQL function; ExecuteDataWithBlobs replaces it with a bound parameter.
+func BlobPlaceholder(name string) string {
+	return fmt.Sprintf("%s('%s')", blobPlaceholderFunc, name)
+}
+
+// blobBinder binds the BLOB placeholders of data statements to the content
+// of the sidecar files in dir.
+type blobBinder struct {
+	driver string
+	dir    string // Empty means placeholders are left as they are
+}
+
+// bind replaces the BLOB placeholders of stmt with parameters (? for MySQL,
+// $n for PostgreSQL) and returns the statement with the file contents as
+// arguments. A statement without placeholders is returned unchanged.
+func (b blobBinder) bind(stmt string) (string, []interface{}, error) {
+	if b.dir == "" || !strings.Contains(stmt, blobPlaceholderFunc+"(") {
+		return stmt, nil, nil
+	}
+	var args []interface{}
+	var readErr error
+	query := blobPlaceholderRegex.ReplaceAllStringFunc(stmt, func(placeholder string) string {
+		if readErr != nil {
+			return placeholder
+		}
+		name := blobPlaceholderRegex.FindStringSubmatch(placeholder)[1]
+		data, err := os.ReadFile(filepath.Join(b.dir, name))
+		if err != nil {
+			readErr = fmt.Errorf("failed to read BLOB file %s: %v", name, err)
+			return placeholder
+		}
+		args = append(args, data)
+		if b.driver == DriverPostgres {
+			return fmt.Sprintf("$%d", len(args))
+		}
+		return "?"
+	})
+	if readErr != nil {
+		return "", nil, readErr
+	}
+	return query, args, nil
+}
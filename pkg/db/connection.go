@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -11,20 +12,74 @@ import (
 
 // ConnectionConfig contains configuration for a database connection
 type ConnectionConfig struct {
-	Driver      string
-	Host        string
-	Port        int
-	User        string
-	Password    string
-	Database    string
-	Timeout     time.Duration
-	RecordLimit int // Maximum number of records to export per table (0 means no limit)
+	Driver       string
+	Host         string
+	Port         int
+	User         string
+	Password     string
+	Database     string
+	Timeout      time.Duration
+	RecordLimit  int           // Maximum number of records to export per table (0 means no limit)
+	RecordOffset int           // Number of records to skip in each table before exporting (0 means none)
+	TxIsolation  string        // Transaction isolation level for imports (empty means database default)
+	Schema       string        // PostgreSQL schema used as search_path (empty means public)
+	SSLMode      string        // PostgreSQL sslmode (empty means disable)
+	QueryTimeout time.Duration // Maximum duration of each table export query (0 means no limit)
+	SampleRate   float64       // Fraction of rows to export, between 0 and 1 (0 means all rows)
+	SampleSeed   int64         // Seed for repeatable sampling (0 means a different sample each run)
+	BinaryFormat string        // Encoding of binary column values in exported data (BinaryFormatHex or empty)
+	QuoteStyle   string        // Quoting of identifiers in exported SQL (a QuoteStyle value, empty means QuoteStyleAuto)
+	TxSize       int           // Maximum number of INSERT statements per import transaction (0 means one transaction per chunk)
+	// Export the values of generated (computed) columns, which are left out by default
+	IncludeGenerated bool
+
+	// Connection retry settings, used when the database is not reachable yet
+	ConnectRetryCount    int           // Number of retries after the first failed ping (0 means no retry)
+	ConnectRetryDelay    time.Duration // Delay before the first retry, doubled on each further retry
+	ConnectRetryMaxDelay time.Duration // Upper bound for the retry delay (0 means no bound)
+
+	// Deadlock retry settings, used when an import transaction is chosen as a deadlock victim
+	DeadlockRetryCount int           // Number of times a deadlocked transaction is retried (0 means no retry)
+	DeadlockRetryDelay time.Duration // Base delay before a retry, randomized by ±50%
+
+	// Export retry settings, used when a table export fails with a transient error
+	ExportRetryCount int           // Number of times a table is exported again from scratch (0 means no retry)
+	ExportRetryDelay time.Duration // Delay before each retry
+}
+
+// Default connection retry settings used by the --connect-retry-* flags
+const (
+	DefaultConnectRetryDelay    = 5 * time.Second
+	DefaultConnectRetryMaxDelay = time.Minute
+)
+
+// Default deadlock retry settings used by the --deadlock-retry-* flags
+const (
+	DefaultDeadlockRetryCount = 3
+	DefaultDeadlockRetryDelay = 100 * time.Millisecond
+)
+
+// Default export retry settings used by the --export-retry-* flags
+const (
+	DefaultExportRetryCount = 3
+	DefaultExportRetryDelay = 5 * time.Second
+)
+
+// Pinger is implemented by *sql.DB and checks that the database is reachable.
+type Pinger interface {
+	Ping() error
 }
 
+// retrySleep waits between connection attempts; tests replace it to avoid real delays.
+var retrySleep = time.Sleep
+
 // Connection represents a database connection
 type Connection struct {
 	DB     *sql.DB
 	Config ConnectionConfig
+
+	dataTx      *dataTx  // Transaction opened by BeginDataTx, used by ExecuteData
+	primaryKeys sync.Map // Table name to primary key columns, cached by GetPrimaryKeyColumns
 }
 
 // NewConnection creates a new database connection
@@ -44,8 +99,9 @@ func NewConnection(config ConnectionConfig) (*Connection, error) {
 	db.SetMaxIdleConns(25)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	// Test the connection
-	if err := db.Ping(); err != nil {
+	// Test the connection, retrying while the database starts up
+	if err := pingWithRetry(db, config); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -55,6 +111,28 @@ func NewConnection(config ConnectionConfig) (*Connection, error) {
 	}, nil
 }
 
+// pingWithRetry pings the database, retrying up to config.ConnectRetryCount
+// times with exponential backoff starting at config.ConnectRetryDelay.
+func pingWithRetry(p Pinger, config ConnectionConfig) error {
+	delay := config.ConnectRetryDelay
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = p.Ping(); err == nil {
+			return nil
+		}
+		if attempt >= config.ConnectRetryCount {
+			return err
+		}
+		fmt.Printf("Connection attempt %d/%d failed: %v. Retrying in %s...\n",
+			attempt+1, config.ConnectRetryCount+1, err, delay)
+		retrySleep(delay)
+		delay *= 2
+		if config.ConnectRetryMaxDelay > 0 && delay > config.ConnectRetryMaxDelay {
+			delay = config.ConnectRetryMaxDelay
+		}
+	}
+}
+
 // Close closes the database connection
 func (c *Connection) Close() error {
 	return c.DB.Close()
@@ -73,13 +151,19 @@ func buildDSN(config ConnectionConfig) (string, error) {
 			config.Timeout,
 		), nil
 	case DriverPostgres:
-		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable connect_timeout=%d",
+		sslMode := config.SSLMode
+		if sslMode == "" {
+			sslMode = "disable"
+		}
+		return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d%s",
 			config.Host,
 			config.Port,
 			config.User,
 			config.Password,
 			config.Database,
+			sslMode,
 			int(config.Timeout.Seconds()),
+			searchPathOption(config.Schema),
 		), nil
 	default:
 		return "", fmt.Errorf("%w: %s", ErrUnsupportedDriver, config.Driver)
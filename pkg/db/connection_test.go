@@ -0,0 +1,83 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingPinger fails the first failures pings and then succeeds.
+type failingPinger struct {
+	failures int
+	calls    int
+}
+
+func (p *failingPinger) Ping() error {
+	p.calls++
+	if p.calls <= p.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestPingWithRetry(t *testing.T) {
+	var delays []time.Duration
+	retrySleep = func(d time.Duration) { delays = append(delays, d) }
+	t.Cleanup(func() { retrySleep = time.Sleep })
+
+	testCases := []struct {
+		name           string
+		failures       int
+		config         ConnectionConfig
+		expectErr      bool
+		expectedCalls  int
+		expectedDelays []time.Duration
+	}{
+		{
+			name:          "No retry by default",
+			failures:      1,
+			config:        ConnectionConfig{ConnectRetryDelay: time.Second},
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+		{
+			name:           "Succeeds after retries with doubling delay",
+			failures:       3,
+			config:         ConnectionConfig{ConnectRetryCount: 5, ConnectRetryDelay: time.Second},
+			expectedCalls:  4,
+			expectedDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:           "Delay is capped at max delay",
+			failures:       4,
+			config:         ConnectionConfig{ConnectRetryCount: 4, ConnectRetryDelay: time.Second, ConnectRetryMaxDelay: 3 * time.Second},
+			expectedCalls:  5,
+			expectedDelays: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second},
+		},
+		{
+			name:           "Fails when retries are exhausted",
+			failures:       10,
+			config:         ConnectionConfig{ConnectRetryCount: 2, ConnectRetryDelay: time.Second},
+			expectErr:      true,
+			expectedCalls:  3,
+			expectedDelays: []time.Duration{time.Second, 2 * time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delays = nil
+			pinger := &failingPinger{failures: tc.failures}
+			err := pingWithRetry(pinger, tc.config)
+			if tc.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedCalls, pinger.calls)
+			assert.Equal(t, tc.expectedDelays, delays)
+		})
+	}
+}
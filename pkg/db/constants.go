@@ -8,6 +8,20 @@ const (
 	DriverPostgres = "postgres"
 )
 
+// Transaction isolation levels accepted by --tx-isolation
+const (
+	IsolationReadUncommitted = "read-uncommitted"
+	IsolationReadCommitted   = "read-committed"
+	IsolationRepeatableRead  = "repeatable-read"
+	IsolationSerializable    = "serializable"
+)
+
+// Binary column encodings accepted by --binary-format
+const (
+	BinaryFormatBase64 = "base64"
+	BinaryFormatHex    = "hex"
+)
+
 // Error definitions
 var (
 	ErrUnsupportedDriver = errors.New("unsupported database driver")
@@ -15,4 +29,5 @@ var (
 	ErrInvalidTableName  = errors.New("invalid table name")
 	ErrInvalidOperation  = errors.New("invalid operation")
 	ErrInvalidQuery      = errors.New("invalid query")
+	ErrInvalidIsolation  = errors.New("invalid transaction isolation level")
 )
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Error codes reported when connecting to a database that does not exist
+const (
+	mysqlErrBadDB       = 1049    // ER_BAD_DB_ERROR
+	pqErrInvalidCatalog = "3D000" // invalid_catalog_name
+)
+
+// IsUnknownDatabase reports whether err was caused by connecting to a
+// database that does not exist.
+func IsUnknownDatabase(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrBadDB
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqErrInvalidCatalog
+	}
+	return false
+}
+
+// EnsureDatabase creates the database of config if it does not exist. It
+// connects to the server as adminUser, or as config.User when adminUser is
+// empty, since the database itself cannot be connected to yet. It reports
+// whether the database was created.
+func EnsureDatabase(config ConnectionConfig, adminUser, adminPassword string, opts DatabaseOptions) (bool, error) {
+	admin := config
+	admin.Schema = ""
+	admin.ConnectRetryCount = 0
+	switch config.Driver {
+	case DriverMySQL:
+		admin.Database = ""
+	case DriverPostgres:
+		admin.Database = "postgres" // Maintenance database, present on every server
+	default:
+		return false, fmt.Errorf("%w: %s", ErrUnsupportedDriver, config.Driver)
+	}
+	if adminUser != "" {
+		admin.User = adminUser
+		admin.Password = adminPassword
+	}
+
+	conn, err := NewConnection(admin)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect as %s to create database %s: %w", admin.User, config.Database, err)
+	}
+	defer conn.Close()
+	return ensureDatabase(conn, config.Database, opts)
+}
+
+// ensureDatabase creates dbName with conn unless the server already has it.
+func ensureDatabase(conn *Connection, dbName string, opts DatabaseOptions) (bool, error) {
+	var query string
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		query = "SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?"
+	case DriverPostgres:
+		query = "SELECT COUNT(*) FROM pg_database WHERE datname = $1"
+	default:
+		return false, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	var count int
+	if err := conn.DB.QueryRow(query, dbName).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check if database %s exists: %w", dbName, err)
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	if _, err := conn.DB.Exec(createDatabaseSQL(conn.Config.Driver, dbName, opts)); err != nil {
+		return false, fmt.Errorf("failed to create database %s: %w", dbName, err)
+	}
+	return true, nil
+}
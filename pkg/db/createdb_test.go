@@ -0,0 +1,40 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUnknownDatabase(t *testing.T) {
+	assert.True(t, IsUnknownDatabase(&mysql.MySQLError{Number: 1049}))
+	assert.True(t, IsUnknownDatabase(fmt.Errorf("failed to ping database: %w", &pq.Error{Code: "3D000"})))
+	assert.False(t, IsUnknownDatabase(&mysql.MySQLError{Number: 1045}))
+	assert.False(t, IsUnknownDatabase(nil))
+}
+
+func TestEnsureDatabase(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverMySQL}}
+
+	existsQuery := "SELECT COUNT(*) FROM information_schema.schemata WHERE schema_name = ?"
+	mock.ExpectQuery(existsQuery).WithArgs("shop").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("CREATE DATABASE `shop` CHARACTER SET utf8mb4").WillReturnResult(sqlmock.NewResult(0, 1))
+	created, err := ensureDatabase(conn, "shop", DatabaseOptions{Charset: "utf8mb4"})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	// An existing database is left alone
+	mock.ExpectQuery(existsQuery).WithArgs("shop").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	created, err = ensureDatabase(conn, "shop", DatabaseOptions{})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -1,13 +1,21 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
 )
 
 // DataOperation represents a database operation (INSERT, UPDATE, DELETE)
@@ -17,29 +25,86 @@ type DataOperation struct {
 	Data    map[string]interface{}
 	Where   map[string]interface{}
 	Columns []string
+	// ColumnTypes maps column names to their database type: udt_name on
+	// PostgreSQL (e.g. jsonb, _int4 for integer[]) and DATA_TYPE on MySQL
+	ColumnTypes map[string]string `json:",omitempty"`
+	// HexColumns lists the binary columns whose values are hex encoded, with
+	// BinaryFormatHex
+	HexColumns []string `json:",omitempty"`
+}
+
+// AllTablesConditionKey is the conditions map key whose condition applies to
+// tables that have no condition of their own.
+const AllTablesConditionKey = "*"
+
+// TableCondition returns the WHERE condition for a table from a conditions map,
+// falling back to the AllTablesConditionKey entry.
+func TableCondition(conditions map[string]string, tableName string) string {
+	if condition, ok := conditions[tableName]; ok {
+		return condition
+	}
+	return conditions[AllTablesConditionKey]
+}
+
+// FilterColumns returns columns without the excluded ones, keeping their order.
+func FilterColumns(columns, excluded []string) []string {
+	if len(excluded) == 0 {
+		return columns
+	}
+	skip := make(map[string]bool, len(excluded))
+	for _, col := range excluded {
+		skip[col] = true
+	}
+	filtered := make([]string, 0, len(columns))
+	for _, col := range columns {
+		if !skip[col] {
+			filtered = append(filtered, col)
+		}
+	}
+	return filtered
 }
 
-// ExportTableData exports data from a table to a writer
-func ExportTableData(conn *Connection, tableName string, writer io.Writer) error {
+// ExportTableData exports data from a table to a writer. Rows are filtered by
+// the table's entry in conditions (see TableCondition) and the table's columns
+// listed in excludeColumns are left out; both maps may be nil. A non-empty
+// orderBy (see ParseOrderBy) sorts the rows, otherwise they are returned in
+// the database's order. A non-zero conn.Config.QueryTimeout bounds the data query.
+func ExportTableData(conn *Connection, tableName string, writer io.Writer, conditions map[string]string, excludeColumns map[string][]string, orderBy string) error {
 	// Get non-virtual columns
-	columns, err := getNonVirtualColumns(conn.DB, tableName, conn.Config.Driver)
+	columns, allColumnTypes, err := getNonVirtualColumns(conn.DB, tableName, conn.Config.Driver, conn.Config.IncludeGenerated)
 	if err != nil {
 		return fmt.Errorf("failed to get columns: %w", err)
 	}
-
-	// Build query
-	escapedColumns := make([]string, len(columns))
-	for i, col := range columns {
-		escapedColumns[i] = EscapeIdentifier(conn.Config.Driver, col)
+	columns = FilterColumns(columns, excludeColumns[tableName])
+	if len(columns) == 0 {
+		return fmt.Errorf("all columns of table %s are excluded", tableName)
 	}
-	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(escapedColumns, ", "), EscapeIdentifier(conn.Config.Driver, tableName))
-	if conn.Config.RecordLimit > 0 {
-		query += fmt.Sprintf(" LIMIT %d", conn.Config.RecordLimit)
+	columnTypes := make(map[string]string, len(columns))
+	for _, col := range columns {
+		columnTypes[col] = allColumnTypes[col]
 	}
-	rows, err := conn.DB.Query(query)
+	var sortColumns []SortColumn
+	if orderBy != "" {
+		if sortColumns, err = ParseOrderBy(orderBy); err != nil {
+			return fmt.Errorf("table %s: %w", tableName, err)
+		}
+	}
+
+	ctx := context.Background()
+	if conn.Config.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conn.Config.QueryTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+
+	query := buildExportQuery(conn.Config.Driver, tableName, columns, columnTypes, TableCondition(conditions, tableName), sortColumns,
+		conn.Config.RecordLimit, conn.Config.RecordOffset, conn.Config.SampleRate, conn.Config.SampleSeed)
+	rows, release, err := queryWithTimeout(ctx, conn, query)
 	if err != nil {
-		return fmt.Errorf("failed to query data: %w", err)
+		return queryTimeoutError(ctx, tableName, start, fmt.Errorf("failed to query data: %w", err))
 	}
+	defer release()
 	defer rows.Close()
 
 	// Get column names
@@ -48,6 +113,23 @@ func ExportTableData(conn *Connection, tableName string, writer io.Writer) error
 		return fmt.Errorf("failed to get column names: %w", err)
 	}
 
+	// With BinaryFormatHex, binary columns are hex encoded. The type is taken
+	// from the result set, since MySQL returns text columns as []byte as well
+	var hexColumns []string
+	isHexColumn := make([]bool, len(colNames))
+	if conn.Config.BinaryFormat == BinaryFormatHex {
+		resultTypes, err := rows.ColumnTypes()
+		if err != nil {
+			return fmt.Errorf("failed to get column types: %w", err)
+		}
+		for i, resultType := range resultTypes {
+			if IsBinaryType(resultType.DatabaseTypeName()) {
+				isHexColumn[i] = true
+				hexColumns = append(hexColumns, colNames[i])
+			}
+		}
+	}
+
 	// Create slice of pointers for scanning
 	values := make([]interface{}, len(colNames))
 	valuePtrs := make([]interface{}, len(colNames))
@@ -59,7 +141,7 @@ func ExportTableData(conn *Connection, tableName string, writer io.Writer) error
 	for rows.Next() {
 		err := rows.Scan(valuePtrs...)
 		if err != nil {
-			return fmt.Errorf("failed to scan row: %w", err)
+			return queryTimeoutError(ctx, tableName, start, fmt.Errorf("failed to scan row: %w", err))
 		}
 
 		// Convert row to map
@@ -73,6 +155,10 @@ func ExportTableData(conn *Connection, tableName string, writer io.Writer) error
 				case string:
 					strVal = v
 				case []byte:
+					if isHexColumn[i] {
+						strVal = hex.EncodeToString(v)
+						break
+					}
 					strVal = string(v)
 				default:
 					rowData[col] = val
@@ -85,10 +171,12 @@ func ExportTableData(conn *Connection, tableName string, writer io.Writer) error
 
 		// Create operation
 		op := DataOperation{
-			Type:    "INSERT",
-			Table:   tableName,
-			Data:    rowData,
-			Columns: columns,
+			Type:        "INSERT",
+			Table:       tableName,
+			Data:        rowData,
+			Columns:     columns,
+			ColumnTypes: columnTypes,
+			HexColumns:  hexColumns,
 		}
 
 		// Write to output
@@ -99,12 +187,259 @@ func ExportTableData(conn *Connection, tableName string, writer io.Writer) error
 	}
 
 	if err = rows.Err(); err != nil {
-		return fmt.Errorf("error iterating rows: %w", err)
+		return queryTimeoutError(ctx, tableName, start, fmt.Errorf("error iterating rows: %w", err))
 	}
 
 	return nil
 }
 
+// IsBinaryType reports whether a database type name, as reported by the driver
+// for a result column, holds binary data.
+func IsBinaryType(typeName string) bool {
+	switch strings.ToUpper(typeName) {
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA":
+		return true
+	}
+	return false
+}
+
+// queryWithTimeout runs an export query. With a QueryTimeout the query runs on a
+// dedicated connection with the matching server-side limit, so the database stops
+// the query (and releases its locks) even if the client is slow to cancel. The
+// returned release function must be called after the rows are closed.
+func queryWithTimeout(ctx context.Context, conn *Connection, query string) (*sql.Rows, func(), error) {
+	timeout := conn.Config.QueryTimeout
+	if timeout <= 0 {
+		rows, err := conn.DB.QueryContext(ctx, query)
+		return rows, func() {}, err
+	}
+
+	sqlConn, err := conn.DB.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		release := func() {
+			// Reset the session limit before the connection goes back to the pool
+			sqlConn.ExecContext(context.Background(), "SET SESSION max_execution_time = 0")
+			sqlConn.Close()
+		}
+		if _, err := sqlConn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", timeout.Milliseconds())); err != nil {
+			release()
+			return nil, nil, fmt.Errorf("failed to set max_execution_time: %w", err)
+		}
+		rows, err := sqlConn.QueryContext(ctx, query)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		return rows, release, nil
+	case DriverPostgres:
+		// SET LOCAL only lasts until the end of the transaction
+		tx, err := sqlConn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			sqlConn.Close()
+			return nil, nil, err
+		}
+		release := func() {
+			tx.Rollback()
+			sqlConn.Close()
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = '%d ms'", timeout.Milliseconds())); err != nil {
+			release()
+			return nil, nil, fmt.Errorf("failed to set statement_timeout: %w", err)
+		}
+		rows, err := tx.QueryContext(ctx, query)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		return rows, release, nil
+	default:
+		sqlConn.Close()
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+}
+
+// queryTimeoutError adds the table name and elapsed time to err if the query
+// was stopped by the client deadline or by the server-side statement timeout.
+func queryTimeoutError(ctx context.Context, tableName string, start time.Time, err error) error {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(errors.As(err, &mysqlErr) && mysqlErr.Number == 3024) || // ER_QUERY_TIMEOUT
+		(errors.As(err, &pqErr) && pqErr.Code == "57014") // query_canceled
+	if !timedOut {
+		return err
+	}
+	return fmt.Errorf("export query for table %s timed out after %s: %w",
+		tableName, time.Since(start).Round(time.Millisecond), err)
+}
+
+// buildExportQuery builds the SELECT statement used to export a table. A sample
+// rate between 0 and 1 keeps a random fraction of the rows, using TABLESAMPLE on
+// PostgreSQL and a RAND() filter on MySQL; a non-zero seed makes it repeatable.
+// Rows are sorted by orderBy when it is not empty, and a non-zero limit and
+// offset select a page of them. Geometry columns in columnTypes are selected
+// with spatialSelectExpr.
+func buildExportQuery(driver, tableName string, columns []string, columnTypes map[string]string, condition string, orderBy []SortColumn, limit, offset int, sampleRate float64, sampleSeed int64) string {
+	escapedColumns := make([]string, len(columns))
+	for i, col := range columns {
+		if IsSpatialType(columnTypes[col]) {
+			escapedColumns[i] = spatialSelectExpr(driver, col)
+		} else {
+			escapedColumns[i] = EscapeIdentifier(driver, col)
+		}
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(escapedColumns, ", "), EscapeIdentifier(driver, tableName))
+
+	var filters []string
+	if strings.TrimSpace(condition) != "" {
+		filters = append(filters, condition)
+	}
+	if sampleRate > 0 && sampleRate < 1 {
+		switch driver {
+		case DriverPostgres:
+			// TABLESAMPLE (PostgreSQL 9.5+) reads only the sampled pages instead of the whole table
+			query += fmt.Sprintf(" TABLESAMPLE SYSTEM (%s)", strconv.FormatFloat(sampleRate*100, 'f', -1, 64))
+			if sampleSeed != 0 {
+				query += fmt.Sprintf(" REPEATABLE (%d)", sampleSeed)
+			}
+		default:
+			seed := ""
+			if sampleSeed != 0 {
+				seed = strconv.FormatInt(sampleSeed, 10)
+			}
+			filters = append(filters, fmt.Sprintf("RAND(%s) < %s", seed, strconv.FormatFloat(sampleRate, 'f', -1, 64)))
+		}
+	}
+	if len(filters) == 1 {
+		query += fmt.Sprintf(" WHERE %s", filters[0])
+	} else if len(filters) > 1 {
+		query += fmt.Sprintf(" WHERE (%s) AND %s", filters[0], filters[1])
+	}
+	if len(orderBy) > 0 {
+		query += " ORDER BY " + orderByClause(driver, orderBy)
+	}
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	} else if offset > 0 && driver == DriverMySQL {
+		// MySQL only accepts OFFSET after a LIMIT; this is the largest one it allows
+		query += " LIMIT 18446744073709551615"
+	}
+	if offset > 0 {
+		query += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return query
+}
+
+// ExistingKeys looks up which of the given primary keys already exist in a table
+// with a single query. Each key holds one SQL literal per column in pkColumns, as
+// written in data files. The returned set is keyed by LiteralKey.
+func ExistingKeys(conn *Connection, tableName string, pkColumns []string, keys [][]string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(keys) == 0 {
+		return existing, nil
+	}
+
+	rows, err := conn.DB.Query(buildExistingKeysQuery(conn.Config.Driver, tableName, pkColumns, keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up existing rows in %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	values := make([]sql.NullString, len(pkColumns))
+	valuePtrs := make([]interface{}, len(pkColumns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, fmt.Errorf("failed to scan existing key: %w", err)
+		}
+		key := make([]string, len(values))
+		for i, v := range values {
+			key[i] = v.String
+		}
+		existing[strings.Join(key, "\x00")] = true
+	}
+	return existing, rows.Err()
+}
+
+// buildExistingKeysQuery builds a query selecting the primary key columns of the
+// rows whose key is in keys. Composite keys use a row value comparison, which
+// both MySQL and PostgreSQL support.
+func buildExistingKeysQuery(driver, tableName string, pkColumns []string, keys [][]string) string {
+	escapedColumns := make([]string, len(pkColumns))
+	for i, col := range pkColumns {
+		escapedColumns[i] = EscapeIdentifier(driver, col)
+	}
+	columnList := strings.Join(escapedColumns, ", ")
+
+	keyList := make([]string, len(keys))
+	for i, key := range keys {
+		if len(pkColumns) == 1 {
+			keyList[i] = key[0]
+		} else {
+			keyList[i] = "(" + strings.Join(key, ", ") + ")"
+		}
+	}
+
+	target := columnList
+	if len(pkColumns) > 1 {
+		target = "(" + columnList + ")"
+	}
+	return fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (%s)",
+		columnList, EscapeIdentifier(driver, tableName), target, strings.Join(keyList, ", "))
+}
+
+// LiteralKey converts the SQL literals of a primary key into the form used by
+// ExistingKeys, so keys from data files can be compared with keys in the database.
+func LiteralKey(literals []string) string {
+	values := make([]string, len(literals))
+	for i, literal := range literals {
+		values[i] = unquoteLiteral(literal)
+	}
+	return strings.Join(values, "\x00")
+}
+
+// unquoteLiteral returns the value of a quoted SQL string literal, undoing the
+// doubled quotes and backslash escapes written on export. Other literals such
+// as numbers are returned unchanged.
+func unquoteLiteral(literal string) string {
+	if len(literal) < 2 || literal[0] != '\'' || literal[len(literal)-1] != '\'' {
+		return literal
+	}
+	inner := literal[1 : len(literal)-1]
+	var out strings.Builder
+	for i := 0; i < len(inner); i++ {
+		switch {
+		case inner[i] == '\'' && i+1 < len(inner) && inner[i+1] == '\'':
+			out.WriteByte('\'')
+			i++
+		case inner[i] == '\\' && i+1 < len(inner):
+			i++
+			switch inner[i] {
+			case 'n':
+				out.WriteByte('\n')
+			case 'r':
+				out.WriteByte('\r')
+			case 't':
+				out.WriteByte('\t')
+			case '0':
+				out.WriteByte(0)
+			default:
+				out.WriteByte(inner[i])
+			}
+		default:
+			out.WriteByte(inner[i])
+		}
+	}
+	return out.String()
+}
+
 // setForeignKeyChecks enables or disables foreign key checks in MySQL
 func setForeignKeyChecks(conn *Connection, enabled bool) error {
 	if conn.Config.Driver != "mysql" {
@@ -124,8 +459,13 @@ func setForeignKeyChecks(conn *Connection, enabled bool) error {
 	return nil
 }
 
-// ImportTableData imports data into a table from a reader
-func ImportTableData(conn *Connection, tableName string, reader io.Reader, disableForeignKeyCheck bool) error {
+// RowErrorFunc is called when a single operation fails during ImportTableData.
+// Returning nil skips the row and continues the import; returning an error aborts it.
+type RowErrorFunc func(op DataOperation, err error) error
+
+// ImportTableData imports data into a table from a reader. If onRowError is nil,
+// the first failing operation aborts the import.
+func ImportTableData(conn *Connection, tableName string, reader io.Reader, disableForeignKeyCheck bool, onRowError RowErrorFunc) error {
 	// Only relevant for MySQL
 	if disableForeignKeyCheck {
 		if err := setForeignKeyChecks(conn, false); err != nil {
@@ -154,22 +494,31 @@ func ImportTableData(conn *Connection, tableName string, reader io.Reader, disab
 			return fmt.Errorf("operation table name mismatch: expected %s, got %s", tableName, op.Table)
 		}
 
+		var opErr error
 		switch op.Type {
 		case "INSERT":
 			if err := executeInsertOperation(conn, op); err != nil {
-				return fmt.Errorf("failed to execute insert: %w", err)
+				opErr = fmt.Errorf("failed to execute insert: %w", err)
 			}
 		case "UPDATE":
 			if err := executeUpdateOperation(conn, op); err != nil {
-				return fmt.Errorf("failed to execute update: %w", err)
+				opErr = fmt.Errorf("failed to execute update: %w", err)
 			}
 		case "DELETE":
 			if err := executeDeleteOperation(conn, op); err != nil {
-				return fmt.Errorf("failed to execute delete: %w", err)
+				opErr = fmt.Errorf("failed to execute delete: %w", err)
 			}
 		default:
 			return fmt.Errorf("unsupported operation type: %s", op.Type)
 		}
+		if opErr != nil {
+			if onRowError == nil {
+				return opErr
+			}
+			if err := onRowError(op, opErr); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -279,45 +628,63 @@ func getDataPlaceholder(driver string, position int) string {
 	}
 }
 
-// getNonVirtualColumns returns a list of non-virtual columns for the given table
-func getNonVirtualColumns(db *sql.DB, tableName string, driver string) ([]string, error) {
+// getNonVirtualColumns returns the non-virtual columns of the given table in
+// order, along with the type of each column (see DataOperation.ColumnTypes).
+// Generated columns are left out, as the database computes their values,
+// unless includeGenerated is set. MySQL marks them with an expression and
+// VIRTUAL or STORED in EXTRA; MariaDB leaves GENERATION_EXPRESSION NULL for
+// other columns, so EXTRA is what identifies them there.
+func getNonVirtualColumns(db *sql.DB, tableName string, driver string, includeGenerated bool) ([]string, map[string]string, error) {
 	var query string
 	switch driver {
 	case DriverMySQL:
+		generated := `
+			AND COALESCE(GENERATION_EXPRESSION, '') = ''
+			AND EXTRA NOT LIKE '%VIRTUAL%'
+			AND EXTRA NOT LIKE '%STORED%'`
+		if includeGenerated {
+			generated = ""
+		}
 		query = `
-			SELECT COLUMN_NAME 
+			SELECT COLUMN_NAME, DATA_TYPE
 			FROM INFORMATION_SCHEMA.COLUMNS 
 			WHERE TABLE_SCHEMA = DATABASE() 
-			AND TABLE_NAME = ? 
-			AND GENERATION_EXPRESSION = ''
+			AND TABLE_NAME = ?` + generated + `
 			ORDER BY ORDINAL_POSITION`
 	case DriverPostgres:
+		generated := `
+			AND is_generated <> 'ALWAYS'`
+		if includeGenerated {
+			generated = ""
+		}
 		query = `
-			SELECT column_name 
+			SELECT column_name, udt_name
 			FROM information_schema.columns 
 			WHERE table_name = $1 
-			AND is_generated = 'NEVER'
+			AND table_schema = current_schema()` + generated + `
 			ORDER BY ordinal_position`
 	default:
-		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
 	}
 
 	rows, err := db.Query(query, tableName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
 	var columns []string
+	columnTypes := make(map[string]string)
 	for rows.Next() {
-		var col string
-		if err := rows.Scan(&col); err != nil {
-			return nil, err
+		var col, colType string
+		if err := rows.Scan(&col, &colType); err != nil {
+			return nil, nil, err
 		}
 		columns = append(columns, col)
+		columnTypes[col] = colType
 	}
 
-	return columns, rows.Err()
+	return columns, columnTypes, rows.Err()
 }
 
 // tryBase64Decode attempts to decode a base64 string with multiple strategies
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMockConnection(t *testing.T, config ConnectionConfig) (*Connection, sqlmock.Sqlmock) {
+	t.Helper()
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+	return &Connection{DB: mockDB, Config: config}, mock
+}
+
+func expectColumnsQuery(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`
+			SELECT COLUMN_NAME, DATA_TYPE
+			FROM INFORMATION_SCHEMA.COLUMNS 
+			WHERE TABLE_SCHEMA = DATABASE() 
+			AND TABLE_NAME = ?
+			AND COALESCE(GENERATION_EXPRESSION, '') = ''
+			AND EXTRA NOT LIKE '%VIRTUAL%'
+			AND EXTRA NOT LIKE '%STORED%'
+			ORDER BY ORDINAL_POSITION`).
+		WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"}).AddRow("id", "int").AddRow("name", "varchar"))
+}
+
+func TestExportTableDataQueryTimeout(t *testing.T) {
+	t.Run("Query within timeout", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL, QueryTimeout: time.Second})
+		expectColumnsQuery(mock)
+		mock.ExpectExec("SET SESSION max_execution_time = 1000").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT `id`, `name` FROM `users`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+		mock.ExpectExec("SET SESSION max_execution_time = 0").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		var buf bytes.Buffer
+		require.NoError(t, ExportTableData(conn, "users", &buf, nil, nil, ""))
+		assert.Contains(t, buf.String(), "alice")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Slow query times out", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL, QueryTimeout: 50 * time.Millisecond})
+		expectColumnsQuery(mock)
+		mock.ExpectExec("SET SESSION max_execution_time = 50").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT `id`, `name` FROM `users`").
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "alice"))
+
+		start := time.Now()
+		err := ExportTableData(conn, "users", &bytes.Buffer{}, nil, nil, "")
+		require.Error(t, err)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.True(t, strings.HasPrefix(err.Error(), "export query for table users timed out after"), err.Error())
+	})
+
+	t.Run("PostgreSQL sets a local statement timeout", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverPostgres, QueryTimeout: 30 * time.Second})
+		mock.ExpectQuery(`
+			SELECT column_name, udt_name
+			FROM information_schema.columns 
+			WHERE table_name = $1 
+			AND table_schema = current_schema()
+			AND is_generated <> 'ALWAYS'
+			ORDER BY ordinal_position`).
+			WithArgs("users").
+			WillReturnRows(sqlmock.NewRows([]string{"column_name", "udt_name"}).AddRow("id", "int4"))
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout = '30000 ms'").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT "id" FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectRollback()
+
+		require.NoError(t, ExportTableData(conn, "users", &bytes.Buffer{}, nil, nil, ""))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestExportTableDataExcludeColumns(t *testing.T) {
+	t.Run("Excluded columns are not selected", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL})
+		expectColumnsQuery(mock)
+		mock.ExpectQuery("SELECT `id` FROM `users`").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		var buf bytes.Buffer
+		excludeColumns := map[string][]string{"users": {"name", "missing"}, "orders": {"id"}}
+		require.NoError(t, ExportTableData(conn, "users", &buf, nil, excludeColumns, ""))
+		assert.NotContains(t, buf.String(), "name")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Excluding every column fails", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL})
+		expectColumnsQuery(mock)
+
+		err := ExportTableData(conn, "users", &bytes.Buffer{}, nil, map[string][]string{"users": {"id", "name"}}, "")
+		assert.EqualError(t, err, "all columns of table users are excluded")
+	})
+}
+
+func TestExportTableDataGeneratedColumns(t *testing.T) {
+	// CREATE TABLE orders (id int, price int, qty int, total int AS (price * qty) VIRTUAL)
+	const columnsQuery = `
+			SELECT COLUMN_NAME, DATA_TYPE
+			FROM INFORMATION_SCHEMA.COLUMNS 
+			WHERE TABLE_SCHEMA = DATABASE() 
+			AND TABLE_NAME = ?`
+	columns := func(names ...string) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"})
+		for _, name := range names {
+			rows.AddRow(name, "int")
+		}
+		return rows
+	}
+
+	t.Run("Generated columns are not selected", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL})
+		mock.ExpectQuery(columnsQuery + `
+			AND COALESCE(GENERATION_EXPRESSION, '') = ''
+			AND EXTRA NOT LIKE '%VIRTUAL%'
+			AND EXTRA NOT LIKE '%STORED%'
+			ORDER BY ORDINAL_POSITION`).
+			WithArgs("orders").
+			WillReturnRows(columns("id", "price", "qty"))
+		mock.ExpectQuery("SELECT `id`, `price`, `qty` FROM `orders`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price", "qty"}).AddRow(1, 5, 2))
+
+		var buf bytes.Buffer
+		require.NoError(t, ExportTableData(conn, "orders", &buf, nil, nil, ""))
+		assert.NotContains(t, buf.String(), "total")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("IncludeGenerated selects them", func(t *testing.T) {
+		conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL, IncludeGenerated: true})
+		mock.ExpectQuery(columnsQuery + `
+			ORDER BY ORDINAL_POSITION`).
+			WithArgs("orders").
+			WillReturnRows(columns("id", "price", "qty", "total"))
+		mock.ExpectQuery("SELECT `id`, `price`, `qty`, `total` FROM `orders`").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "price", "qty", "total"}).AddRow(1, 5, 2, 10))
+
+		var buf bytes.Buffer
+		require.NoError(t, ExportTableData(conn, "orders", &buf, nil, nil, ""))
+		assert.Contains(t, buf.String(), `"total":10`)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestExportTableDataColumnTypes(t *testing.T) {
+	conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverPostgres})
+	mock.ExpectQuery(`
+			SELECT column_name, udt_name
+			FROM information_schema.columns 
+			WHERE table_name = $1 
+			AND table_schema = current_schema()
+			AND is_generated <> 'ALWAYS'
+			ORDER BY ordinal_position`).
+		WithArgs("events").
+		WillReturnRows(sqlmock.NewRows([]string{"column_name", "udt_name"}).
+			AddRow("id", "int4").AddRow("payload", "jsonb").AddRow("tags", "_text"))
+	mock.ExpectQuery(`SELECT "id", "payload", "tags" FROM "events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "tags"}).
+			AddRow(1, []byte(`{"a": [1, 2]}`), []byte(`{red,"dark blue"}`)))
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportTableData(conn, "events", &buf, nil, nil, ""))
+	var op DataOperation
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &op))
+	assert.Equal(t, map[string]string{"id": "int4", "payload": "jsonb", "tags": "_text"}, op.ColumnTypes)
+	assert.Equal(t, `{"a": [1, 2]}`, op.Data["payload"])
+	assert.Equal(t, `{red,"dark blue"}`, op.Data["tags"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExportTableDataHexBinary(t *testing.T) {
+	conn, mock := newMockConnection(t, ConnectionConfig{Driver: DriverMySQL, BinaryFormat: BinaryFormatHex})
+	mock.ExpectQuery(`
+			SELECT COLUMN_NAME, DATA_TYPE
+			FROM INFORMATION_SCHEMA.COLUMNS 
+			WHERE TABLE_SCHEMA = DATABASE() 
+			AND TABLE_NAME = ?
+			AND COALESCE(GENERATION_EXPRESSION, '') = ''
+			AND EXTRA NOT LIKE '%VIRTUAL%'
+			AND EXTRA NOT LIKE '%STORED%'
+			ORDER BY ORDINAL_POSITION`).
+		WithArgs("files").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"}).AddRow("name", "varchar").AddRow("data", "blob"))
+	mock.ExpectQuery("SELECT `name`, `data` FROM `files`").
+		WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+			sqlmock.NewColumn("name").OfType("VARCHAR", ""),
+			sqlmock.NewColumn("data").OfType("BLOB", []byte{}),
+		).AddRow([]byte("logo.png"), []byte{0xde, 0xad, 0xbe, 0xef}))
+
+	var buf bytes.Buffer
+	require.NoError(t, ExportTableData(conn, "files", &buf, nil, nil, ""))
+	var op DataOperation
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &op))
+	assert.Equal(t, []string{"data"}, op.HexColumns)
+	assert.Equal(t, "logo.png", op.Data["name"])
+	assert.Equal(t, "deadbeef", op.Data["data"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
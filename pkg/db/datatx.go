@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// dataTx is a data import transaction spanning several ExecuteData calls.
+type dataTx struct {
+	conn *sql.Conn
+	tx   *sql.Tx
+}
+
+// BeginDataTx starts a transaction that the following ExecuteData calls on conn
+// run in, instead of a transaction per call, until CommitDataTx or
+// RollbackDataTx ends it. Deadlocked statements are not retried inside it.
+func BeginDataTx(conn *Connection) error {
+	if conn.dataTx != nil {
+		return fmt.Errorf("a data import transaction is already open")
+	}
+	isolationSQL, err := IsolationLevelSQL(conn.Config.Driver, conn.Config.TxIsolation)
+	if err != nil {
+		return err
+	}
+
+	// Pin a single connection, which keeps the session settings below for the
+	// whole transaction
+	ctx := context.Background()
+	sqlConn, err := conn.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+	if conn.Config.Driver == DriverMySQL {
+		if _, err := sqlConn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+			sqlConn.Close()
+			return fmt.Errorf("failed to disable foreign key checks: %v", err)
+		}
+	}
+	tx, err := beginDataTx(ctx, sqlConn, conn.Config.Driver, isolationSQL)
+	if err != nil {
+		releaseDataConn(sqlConn, conn.Config.Driver)
+		return err
+	}
+	conn.dataTx = &dataTx{conn: sqlConn, tx: tx}
+	return nil
+}
+
+// CommitDataTx commits the transaction opened by BeginDataTx.
+func CommitDataTx(conn *Connection) error {
+	if conn.dataTx == nil {
+		return fmt.Errorf("no data import transaction is open")
+	}
+	t := conn.dataTx
+	conn.dataTx = nil
+	defer releaseDataConn(t.conn, conn.Config.Driver)
+	if err := t.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit data import: %w", err)
+	}
+	return nil
+}
+
+// RollbackDataTx rolls back the transaction opened by BeginDataTx, if any.
+func RollbackDataTx(conn *Connection) error {
+	if conn.dataTx == nil {
+		return nil
+	}
+	t := conn.dataTx
+	conn.dataTx = nil
+	defer releaseDataConn(t.conn, conn.Config.Driver)
+	if err := t.tx.Rollback(); err != nil {
+		return fmt.Errorf("failed to roll back data import: %v", err)
+	}
+	return nil
+}
+
+// releaseDataConn restores the session settings changed by BeginDataTx and
+// returns the connection to the pool.
+func releaseDataConn(sqlConn *sql.Conn, driver string) {
+	if driver == DriverMySQL {
+		if _, err := sqlConn.ExecContext(context.Background(), "SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+			fmt.Printf("Warning: failed to re-enable foreign key checks: %v\n", err)
+		}
+	}
+	sqlConn.Close()
+}
@@ -1,9 +1,11 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -25,12 +27,12 @@ func NewDatabase(conn *Connection) *Database {
 
 // ExportTable exports data from a table to a writer
 func (db *Database) ExportTable(tableName string, writer io.Writer) error {
-	return ExportTableData(db.Conn, tableName, writer)
+	return ExportTableData(db.Conn, tableName, writer, nil, nil, "")
 }
 
 // ImportTable imports data into a table from a reader
 func (db *Database) ImportTable(tableName string, reader io.Reader, disableForeignKeyCheck bool) error {
-	return ImportTableData(db.Conn, tableName, reader, disableForeignKeyCheck)
+	return ImportTableData(db.Conn, tableName, reader, disableForeignKeyCheck, nil)
 }
 
 // GetTableInfo retrieves information about a table
@@ -62,14 +64,14 @@ type TableExport struct {
 }
 
 // InitDB initializes a database connection
-func InitDB(driver, host string, port int, username, password, dbName string) (*sql.DB, error) {
+func InitDB(driver, host string, port int, username, password, dbName, schema string) (*sql.DB, error) {
 	var dsn string
 	switch driver {
 	case "mysql":
 		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", username, password, host, port, dbName)
 	case "postgres":
-		dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-			host, port, username, password, dbName)
+		dsn = fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable%s",
+			host, port, username, password, dbName, searchPathOption(schema))
 	default:
 		return nil, fmt.Errorf("unsupported database driver: %s", driver)
 	}
@@ -198,7 +200,7 @@ func checkIsView(db *sql.DB, table string, driver string) (bool, error) {
 			SELECT COUNT(*) 
 			FROM information_schema.views 
 			WHERE table_name = $1 
-			AND table_schema = 'public'
+			AND table_schema = current_schema()
 		`, table).Scan(&count)
 		if err != nil {
 			return false, fmt.Errorf("failed to check if view exists: %v", err)
@@ -229,7 +231,7 @@ func getTableDeps(db *sql.DB, table string, driver string) ([]string, error) {
 				ON tc.constraint_name = ccu.constraint_name
 			WHERE tc.table_name = $1
 			AND tc.constraint_type = 'FOREIGN KEY'
-			AND tc.table_schema = 'public'
+			AND tc.table_schema = current_schema()
 		`
 	default:
 		return nil, fmt.Errorf("unsupported database driver: %s", driver)
@@ -311,7 +313,7 @@ func GetTables(conn *Connection) ([]string, error) {
 		query = `
 			SELECT table_name
 			FROM information_schema.tables
-			WHERE table_schema = 'public'
+			WHERE table_schema = current_schema()
 			AND table_type = 'BASE TABLE'
 			ORDER BY table_name`
 	default:
@@ -346,6 +348,11 @@ func SortTablesByDependencies(tables []string, deps map[string][]string) []strin
 	return sortTablesByDependencies(tables, deps)
 }
 
+// BuildUpdateList builds the SET clause for upsert queries
+func BuildUpdateList(columns []string, driver string) string {
+	return buildUpdateList(columns, driver)
+}
+
 // GetTableSchema returns the schema information for a table
 func GetTableSchema(conn *Connection, tableName string) (*SchemaInfo, error) {
 	return GetSchema(conn, tableName)
@@ -368,7 +375,7 @@ func DropDatabase(conn *Connection) error {
 
 	// Create a temporary connection without database specified to allow dropping
 	tempConn, err := InitDB(conn.Config.Driver, conn.Config.Host, conn.Config.Port,
-		conn.Config.User, conn.Config.Password, "") // Empty database name
+		conn.Config.User, conn.Config.Password, "", "") // Empty database name
 	if err != nil {
 		return fmt.Errorf("failed to create temporary connection for dropping database: %v", err)
 	}
@@ -385,21 +392,50 @@ func DropDatabase(conn *Connection) error {
 	return nil
 }
 
+// DatabaseOptions are the defaults of a database created by CreateDatabase.
+// Charset and Collation apply to MySQL, Encoding to PostgreSQL; empty values
+// keep the server defaults.
+type DatabaseOptions struct {
+	Charset   string
+	Collation string
+	Encoding  string
+}
+
+// createDatabaseSQL returns the CREATE DATABASE statement of a database
+func createDatabaseSQL(driver, dbName string, opts DatabaseOptions) string {
+	query := fmt.Sprintf("CREATE DATABASE %s", EscapeIdentifier(driver, dbName))
+	switch driver {
+	case DriverMySQL:
+		if opts.Charset != "" {
+			query += " CHARACTER SET " + opts.Charset
+		}
+		if opts.Collation != "" {
+			query += " COLLATE " + opts.Collation
+		}
+	case DriverPostgres:
+		// template1 may have another encoding, template0 accepts any
+		if opts.Encoding != "" {
+			query += fmt.Sprintf(" ENCODING '%s' TEMPLATE template0", strings.ReplaceAll(opts.Encoding, "'", "''"))
+		}
+	}
+	return query
+}
+
 // CreateDatabase creates a new database
-func CreateDatabase(conn *Connection) error {
+func CreateDatabase(conn *Connection, opts DatabaseOptions) error {
 	// Get current database name
 	dbName := conn.Config.Database
 
 	// Create a temporary connection without database specified to allow creation
 	tempConn, err := InitDB(conn.Config.Driver, conn.Config.Host, conn.Config.Port,
-		conn.Config.User, conn.Config.Password, "") // Empty database name
+		conn.Config.User, conn.Config.Password, "", "") // Empty database name
 	if err != nil {
 		return fmt.Errorf("failed to create temporary connection for creating database: %v", err)
 	}
 	defer tempConn.Close()
 
 	// Create database
-	query := fmt.Sprintf("CREATE DATABASE `%s`", dbName)
+	query := createDatabaseSQL(conn.Config.Driver, dbName, opts)
 	_, err = tempConn.Exec(query)
 	if err != nil {
 		return fmt.Errorf("failed to create database %s: %v", dbName, err)
@@ -445,10 +481,92 @@ func ExecuteSchema(conn *Connection, schemaSQL string) error {
 	return nil
 }
 
+// IsolationLevelSQL returns the SET TRANSACTION statement for the given isolation level,
+// or an empty string when no level is requested. MySQL supports all four levels;
+// PostgreSQL is limited to read-committed and serializable.
+func IsolationLevelSQL(driver, level string) (string, error) {
+	if level == "" {
+		return "", nil
+	}
+
+	var supported []string
+	switch driver {
+	case DriverMySQL:
+		supported = []string{IsolationReadUncommitted, IsolationReadCommitted, IsolationRepeatableRead, IsolationSerializable}
+	case DriverPostgres:
+		supported = []string{IsolationReadCommitted, IsolationSerializable}
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
+	}
+
+	for _, s := range supported {
+		if s == level {
+			keyword := strings.ToUpper(strings.ReplaceAll(level, "-", " "))
+			return "SET TRANSACTION ISOLATION LEVEL " + keyword, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s is not supported by %s (supported: %s)",
+		ErrInvalidIsolation, level, driver, strings.Join(supported, ", "))
+}
+
+// dataStatementSeparator separates the statements executed by ExecuteData
+const dataStatementSeparator = "\n--SYNCDB_QUERY_SEPARATOR--\n"
+
+// insertStatementRegex matches the start of an INSERT statement at the
+// beginning of a line
+var insertStatementRegex = regexp.MustCompile(`(?im)^[ \t]*INSERT[ \t]`)
+
+// SplitTxBatches splits a data chunk into batches of at most txSize INSERT
+// statements, to be executed by ExecuteData in a transaction each. Statements
+// start at lines beginning with INSERT; any text before the first one stays
+// with it. The chunk is returned unchanged when txSize is 0 or the chunk has
+// no more than txSize statements.
+func SplitTxBatches(dataSQL string, txSize int) []string {
+	if txSize <= 0 {
+		return []string{dataSQL}
+	}
+	starts := insertStatementRegex.FindAllStringIndex(dataSQL, -1)
+	if len(starts) <= txSize {
+		return []string{dataSQL}
+	}
+
+	statements := make([]string, len(starts))
+	for i := range starts {
+		from, to := starts[i][0], len(dataSQL)
+		if i == 0 {
+			from = 0
+		}
+		if i+1 < len(starts) {
+			to = starts[i+1][0]
+		}
+		statements[i] = strings.TrimSpace(dataSQL[from:to])
+	}
+
+	var batches []string
+	for i := 0; i < len(statements); i += txSize {
+		end := min(i+txSize, len(statements))
+		batches = append(batches, strings.Join(statements[i:end], dataStatementSeparator))
+	}
+	return batches
+}
+
 // ExecuteData executes data import SQL statements
 func ExecuteData(conn *Connection, dataSQL string) error {
-	separator := "\n--SYNCDB_QUERY_SEPARATOR--\n"
-	statements := strings.Split(dataSQL, separator)
+	return ExecuteDataWithBlobs(conn, dataSQL, "")
+}
+
+// ExecuteDataWithBlobs executes data import SQL statements like ExecuteData,
+// binding each LOAD_BLOB_FILE placeholder to the content of its sidecar file
+// in blobDir (see BlobPlaceholder). An empty blobDir leaves them unresolved.
+func ExecuteDataWithBlobs(conn *Connection, dataSQL, blobDir string) error {
+	statements := strings.Split(dataSQL, dataStatementSeparator)
+	blobs := blobBinder{driver: conn.Config.Driver, dir: blobDir}
+
+	// Inside BeginDataTx the statements join the open transaction, which is
+	// too large to be retried after a deadlock
+	if conn.dataTx != nil {
+		return execDataStatements(conn.dataTx.tx, statements, blobs)
+	}
 
 	// Configure MySQL settings for import
 	if conn.Config.Driver == DriverMySQL {
@@ -471,10 +589,43 @@ func ExecuteData(conn *Connection, dataSQL string) error {
 		}()
 	}
 
-	// Start a transaction for data import
-	tx, err := conn.DB.Begin()
+	isolationSQL, err := IsolationLevelSQL(conn.Config.Driver, conn.Config.TxIsolation)
 	if err != nil {
-		return fmt.Errorf("failed to start data import transaction: %v", err)
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = executeDataTx(conn, statements, isolationSQL, blobs)
+		if err == nil || !IsDeadlock(err) {
+			return err
+		}
+		if attempt >= conn.Config.DeadlockRetryCount {
+			if attempt == 0 {
+				return err
+			}
+			return fmt.Errorf("deadlock persisted after %d retries: %w", attempt, err)
+		}
+		delay := jitter(conn.Config.DeadlockRetryDelay)
+		fmt.Printf("  Deadlock detected, retrying transaction (%d/%d) in %s...\n",
+			attempt+1, conn.Config.DeadlockRetryCount, delay)
+		retrySleep(delay)
+	}
+}
+
+// executeDataTx executes the data statements in a single transaction, which is
+// rolled back if any statement fails.
+func executeDataTx(conn *Connection, statements []string, isolationSQL string, blobs blobBinder) (err error) {
+	// Pin a single connection so the isolation level applies to our transaction
+	ctx := context.Background()
+	sqlConn, err := conn.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %v", err)
+	}
+	defer sqlConn.Close()
+
+	tx, err := beginDataTx(ctx, sqlConn, conn.Config.Driver, isolationSQL)
+	if err != nil {
+		return err
 	}
 	defer func() {
 		if err != nil {
@@ -482,23 +633,63 @@ func ExecuteData(conn *Connection, dataSQL string) error {
 		}
 	}()
 
+	if err = execDataStatements(tx, statements, blobs); err != nil {
+		return err
+	}
+
+	// Commit the transaction
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit data import: %w", err)
+	}
+
+	return nil
+}
+
+// beginDataTx starts a data import transaction on sqlConn with the isolation
+// level set by isolationSQL, if any.
+func beginDataTx(ctx context.Context, sqlConn *sql.Conn, driver, isolationSQL string) (*sql.Tx, error) {
+	// MySQL does not allow changing the isolation level once a transaction has
+	// started, so the statement is issued just before BEGIN and applies to the next transaction.
+	if isolationSQL != "" && driver == DriverMySQL {
+		if _, err := sqlConn.ExecContext(ctx, isolationSQL); err != nil {
+			return nil, fmt.Errorf("failed to set transaction isolation level: %v", err)
+		}
+	}
+
+	// Start a transaction for data import
+	tx, err := sqlConn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start data import transaction: %v", err)
+	}
+
+	// PostgreSQL requires SET TRANSACTION to be the first statement inside the transaction
+	if isolationSQL != "" && driver == DriverPostgres {
+		if _, err = tx.Exec(isolationSQL); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to set transaction isolation level: %v", err)
+		}
+	}
+	return tx, nil
+}
+
+// execDataStatements executes the data statements in tx, stopping at the first failure.
+func execDataStatements(tx *sql.Tx, statements []string, blobs blobBinder) error {
 	for _, stmt := range statements {
 		stmt = strings.TrimSpace(stmt)
 		if stmt == "" {
 			continue
 		}
 
-		// Execute the data statement
-		_, err = tx.Exec(stmt)
+		// BLOB sidecar files are sent as parameters rather than SQL text
+		query, args, err := blobs.bind(stmt)
 		if err != nil {
-			return fmt.Errorf("failed to execute data statement: %v\nStatement: %s", err, stmt)
+			return err
 		}
-	}
 
-	// Commit the transaction
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit data import: %v", err)
+		// Execute the data statement
+		if _, err := tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("failed to execute data statement: %w\nStatement: %s", err, stmt)
+		}
 	}
-
 	return nil
 }
@@ -0,0 +1,723 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoangnguyenba/syncdb/pkg/parquet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsolationLevelSQL(t *testing.T) {
+	testCases := []struct {
+		driver   string
+		level    string
+		expected string
+		wantErr  bool
+	}{
+		{DriverMySQL, "", "", false},
+		{DriverMySQL, IsolationReadUncommitted, "SET TRANSACTION ISOLATION LEVEL READ UNCOMMITTED", false},
+		{DriverMySQL, IsolationReadCommitted, "SET TRANSACTION ISOLATION LEVEL READ COMMITTED", false},
+		{DriverMySQL, IsolationRepeatableRead, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ", false},
+		{DriverMySQL, IsolationSerializable, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE", false},
+		{DriverPostgres, "", "", false},
+		{DriverPostgres, IsolationReadUncommitted, "", true},
+		{DriverPostgres, IsolationReadCommitted, "SET TRANSACTION ISOLATION LEVEL READ COMMITTED", false},
+		{DriverPostgres, IsolationRepeatableRead, "", true},
+		{DriverPostgres, IsolationSerializable, "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE", false},
+		{DriverMySQL, "snapshot", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.driver+"/"+tc.level, func(t *testing.T) {
+			stmt, err := IsolationLevelSQL(tc.driver, tc.level)
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidIsolation))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, stmt)
+		})
+	}
+
+	_, err := IsolationLevelSQL("sqlite", IsolationSerializable)
+	assert.True(t, errors.Is(err, ErrUnsupportedDriver))
+}
+
+func TestTableCondition(t *testing.T) {
+	conditions := map[string]string{
+		"orders":              "created_at > '2024-01-01'",
+		AllTablesConditionKey: "deleted_at IS NULL",
+	}
+	assert.Equal(t, "created_at > '2024-01-01'", TableCondition(conditions, "orders"))
+	assert.Equal(t, "deleted_at IS NULL", TableCondition(conditions, "users"))
+	assert.Equal(t, "", TableCondition(map[string]string{"orders": "id > 1"}, "users"))
+	assert.Equal(t, "", TableCondition(nil, "users"))
+}
+
+func TestBuildExportQuery(t *testing.T) {
+	columns := []string{"id", "name"}
+	assert.Equal(t, "SELECT `id`, `name` FROM `users`",
+		buildExportQuery(DriverMySQL, "users", columns, nil, "", nil, 0, 0, 0, 0))
+	assert.Equal(t, "SELECT `id`, `name` FROM `users` WHERE id > 10 LIMIT 5",
+		buildExportQuery(DriverMySQL, "users", columns, nil, "id > 10", nil, 5, 0, 0, 0))
+	assert.Equal(t, `SELECT "id", "name" FROM "users" WHERE name LIKE 'a%'`,
+		buildExportQuery(DriverPostgres, "users", columns, nil, "name LIKE 'a%'", nil, 0, 0, 0, 0))
+
+	// --offset pages through the rows sorted by primary key
+	byID := []SortColumn{{Column: "id"}}
+	assert.Equal(t, "SELECT `id`, `name` FROM `users` ORDER BY `id` ASC LIMIT 100 OFFSET 200",
+		buildExportQuery(DriverMySQL, "users", columns, nil, "", byID, 100, 200, 0, 0))
+	assert.Equal(t, "SELECT `id`, `name` FROM `users` ORDER BY `id` ASC LIMIT 18446744073709551615 OFFSET 200",
+		buildExportQuery(DriverMySQL, "users", columns, nil, "", byID, 0, 200, 0, 0))
+	assert.Equal(t, `SELECT "id", "name" FROM "users" ORDER BY "id" ASC OFFSET 200`,
+		buildExportQuery(DriverPostgres, "users", columns, nil, "", byID, 0, 200, 0, 0))
+	assert.Equal(t, `SELECT "id", "name" FROM "users" ORDER BY "id" ASC LIMIT 100 OFFSET 200`,
+		buildExportQuery(DriverPostgres, "users", columns, nil, "", byID, 100, 200, 0, 0))
+}
+
+func TestBuildExportQueryOrderBy(t *testing.T) {
+	columns := []string{"id", "name"}
+	single, err := ParseOrderBy("id")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id`, `name` FROM `users` ORDER BY `id` ASC",
+		buildExportQuery(DriverMySQL, "users", columns, nil, "", single, 0, 0, 0, 0))
+
+	multi, err := ParseOrderBy("name desc, id ASC")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT `id`, `name` FROM `users` WHERE id > 10 ORDER BY `name` DESC, `id` ASC LIMIT 5",
+		buildExportQuery(DriverMySQL, "users", columns, nil, "id > 10", multi, 5, 0, 0, 0))
+	assert.Equal(t, `SELECT "id", "name" FROM "users" ORDER BY "name" DESC, "id" ASC`,
+		buildExportQuery(DriverPostgres, "users", columns, nil, "", multi, 0, 0, 0, 0))
+}
+
+func TestBuildExportQuerySpatial(t *testing.T) {
+	columns := []string{"id", "location"}
+	mysqlTypes := map[string]string{"id": "int", "location": "point"}
+	assert.Equal(t, "SELECT `id`, CONCAT(ST_SRID(`location`), ':', HEX(ST_AsWKB(`location`))) AS `location` FROM `places`",
+		buildExportQuery(DriverMySQL, "places", columns, mysqlTypes, "", nil, 0, 0, 0, 0))
+	pgTypes := map[string]string{"id": "int4", "location": "geography"}
+	assert.Equal(t, `SELECT "id", "location" FROM "places"`,
+		buildExportQuery(DriverPostgres, "places", columns, pgTypes, "", nil, 0, 0, 0, 0))
+
+	assert.True(t, IsSpatialType("MULTIPOLYGON"))
+	assert.True(t, IsSpatialType("geometry"))
+	assert.False(t, IsSpatialType("varchar"))
+}
+
+func TestParseOrderBy(t *testing.T) {
+	columns, err := ParseOrderBy(" created_at DESC , `id` ")
+	require.NoError(t, err)
+	assert.Equal(t, []SortColumn{{Column: "created_at", Descending: true}, {Column: "id"}}, columns)
+
+	for _, spec := range []string{"", " , ", "id SIDEWAYS", "id ASC NULLS", "id; DROP TABLE users"} {
+		_, err := ParseOrderBy(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestBuildExportQuerySampling(t *testing.T) {
+	columns := []string{"id"}
+	testCases := []struct {
+		name      string
+		driver    string
+		condition string
+		rate      float64
+		seed      int64
+		expected  string
+	}{
+		{"MySQL 10 percent", DriverMySQL, "", 0.1, 0, "SELECT `id` FROM `users` WHERE RAND() < 0.1"},
+		{"MySQL with seed", DriverMySQL, "", 0.25, 42, "SELECT `id` FROM `users` WHERE RAND(42) < 0.25"},
+		{"MySQL with condition", DriverMySQL, "id > 10", 0.5, 0, "SELECT `id` FROM `users` WHERE (id > 10) AND RAND() < 0.5"},
+		{"MySQL full rate is not sampled", DriverMySQL, "", 1, 0, "SELECT `id` FROM `users`"},
+		{"PostgreSQL 10 percent", DriverPostgres, "", 0.1, 0, `SELECT "id" FROM "users" TABLESAMPLE SYSTEM (10)`},
+		{"PostgreSQL small rate", DriverPostgres, "", 0.005, 0, `SELECT "id" FROM "users" TABLESAMPLE SYSTEM (0.5)`},
+		{"PostgreSQL with seed and condition", DriverPostgres, "id > 10", 0.25, 7,
+			`SELECT "id" FROM "users" TABLESAMPLE SYSTEM (25) REPEATABLE (7) WHERE id > 10`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, buildExportQuery(tc.driver, "users", columns, nil, tc.condition, nil, 0, 0, tc.rate, tc.seed))
+		})
+	}
+}
+
+func TestBuildDSNSchema(t *testing.T) {
+	config := ConnectionConfig{
+		Driver:   DriverPostgres,
+		Host:     "localhost",
+		Port:     5432,
+		User:     "user",
+		Password: "pass",
+		Database: "app",
+	}
+
+	dsn, err := buildDSN(config)
+	require.NoError(t, err)
+	assert.NotContains(t, dsn, "search_path")
+
+	config.Schema = SchemaPostgres
+	dsn, err = buildDSN(config)
+	require.NoError(t, err)
+	assert.NotContains(t, dsn, "search_path")
+
+	config.Schema = "tenant1"
+	dsn, err = buildDSN(config)
+	require.NoError(t, err)
+	assert.Contains(t, dsn, " search_path=tenant1")
+}
+
+func TestBuildDSNSSLMode(t *testing.T) {
+	config := ConnectionConfig{Driver: DriverPostgres, Host: "localhost", Port: 5432, Database: "app"}
+	dsn, err := buildDSN(config)
+	require.NoError(t, err)
+	assert.Contains(t, dsn, " sslmode=disable ")
+
+	config.SSLMode = "require"
+	dsn, err = buildDSN(config)
+	require.NoError(t, err)
+	assert.Contains(t, dsn, " sslmode=require ")
+}
+
+func TestValidateSchemaName(t *testing.T) {
+	assert.NoError(t, ValidateSchemaName("public"))
+	assert.NoError(t, ValidateSchemaName("tenant_1"))
+	assert.Error(t, ValidateSchemaName(""))
+	assert.Error(t, ValidateSchemaName("1tenant"))
+	assert.Error(t, ValidateSchemaName("tenant; DROP TABLE users"))
+}
+
+func TestBuildExistingKeysQuery(t *testing.T) {
+	keys := [][]string{{"1"}, {"2"}}
+	assert.Equal(t, "SELECT `id` FROM `users` WHERE `id` IN (1, 2)",
+		buildExistingKeysQuery(DriverMySQL, "users", []string{"id"}, keys))
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE "id" IN (1, 2)`,
+		buildExistingKeysQuery(DriverPostgres, "users", []string{"id"}, keys))
+
+	compositeKeys := [][]string{{"1", "'a'"}, {"2", "'b'"}}
+	assert.Equal(t, "SELECT `order_id`, `sku` FROM `order_items` WHERE (`order_id`, `sku`) IN ((1, 'a'), (2, 'b'))",
+		buildExistingKeysQuery(DriverMySQL, "order_items", []string{"order_id", "sku"}, compositeKeys))
+	assert.Equal(t, `SELECT "order_id", "sku" FROM "order_items" WHERE ("order_id", "sku") IN ((1, 'a'), (2, 'b'))`,
+		buildExistingKeysQuery(DriverPostgres, "order_items", []string{"order_id", "sku"}, compositeKeys))
+}
+
+func TestLiteralKey(t *testing.T) {
+	assert.Equal(t, "42", LiteralKey([]string{"42"}))
+	assert.Equal(t, "it's", LiteralKey([]string{"'it''s'"}))
+	assert.Equal(t, "a\\b\nc", LiteralKey([]string{`'a\\b\nc'`}))
+	assert.Equal(t, "1\x00x", LiteralKey([]string{"1", "'x'"}))
+}
+
+func TestStripIndexesFromCreateTable(t *testing.T) {
+	createTable := "CREATE TABLE `users` (\n" +
+		"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+		"  `email` varchar(255) NOT NULL,\n" +
+		"  `name` varchar(100) DEFAULT NULL,\n" +
+		"  `org_id` int DEFAULT NULL,\n" +
+		"  `bio` text,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `email` (`email`),\n" +
+		"  KEY `idx_name_org` (`name`(10),`org_id`) USING BTREE,\n" +
+		"  FULLTEXT KEY `ft_bio` (`bio`),\n" +
+		"  CONSTRAINT `fk_org` FOREIGN KEY (`org_id`) REFERENCES `orgs` (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"
+
+	tableSQL, indexSQL, err := StripIndexesFromCreateTable(createTable)
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE `users` (\n"+
+		"  `id` int NOT NULL AUTO_INCREMENT,\n"+
+		"  `email` varchar(255) NOT NULL,\n"+
+		"  `name` varchar(100) DEFAULT NULL,\n"+
+		"  `org_id` int DEFAULT NULL,\n"+
+		"  `bio` text,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  CONSTRAINT `fk_org` FOREIGN KEY (`org_id`) REFERENCES `orgs` (`id`)\n"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", tableSQL)
+	assert.Equal(t, "CREATE UNIQUE INDEX `email` ON `users` (`email`);\n"+
+		"CREATE INDEX `idx_name_org` ON `users` (`name`(10),`org_id`) USING BTREE;\n"+
+		"CREATE FULLTEXT INDEX `ft_bio` ON `users` (`bio`);", indexSQL)
+
+	// A key leading with the AUTO_INCREMENT column must stay in the table
+	autoIncrementKey := "CREATE TABLE `events` (\n" +
+		"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+		"  `day` date NOT NULL,\n" +
+		"  PRIMARY KEY (`day`,`id`),\n" +
+		"  KEY `id` (`id`)\n" +
+		") ENGINE=InnoDB"
+	tableSQL, indexSQL, err = StripIndexesFromCreateTable(autoIncrementKey)
+	require.NoError(t, err)
+	assert.Equal(t, autoIncrementKey, tableSQL)
+	assert.Empty(t, indexSQL)
+
+	postgres := `CREATE TABLE "users" ("id" integer NOT NULL, "email" text)`
+	tableSQL, indexSQL, err = StripIndexesFromCreateTable(postgres)
+	require.NoError(t, err)
+	assert.Equal(t, postgres, tableSQL)
+	assert.Empty(t, indexSQL)
+
+	_, _, err = StripIndexesFromCreateTable("CREATE TABLE `t` (\n  `a` int,\n  KEY `broken` (`a`\n) ENGINE=InnoDB")
+	assert.Error(t, err)
+}
+
+func TestSplitTxBatches(t *testing.T) {
+	var chunk strings.Builder
+	for i := 1; i <= 10000; i++ {
+		fmt.Fprintf(&chunk, "INSERT INTO `users` (`id`) VALUES (%d);\n", i)
+	}
+
+	batches := SplitTxBatches(chunk.String(), 1000)
+	require.Len(t, batches, 10)
+	for i, batch := range batches {
+		statements := strings.Split(batch, dataStatementSeparator)
+		require.Len(t, statements, 1000)
+		assert.Equal(t, fmt.Sprintf("INSERT INTO `users` (`id`) VALUES (%d);", i*1000+1), statements[0])
+		assert.Equal(t, fmt.Sprintf("INSERT INTO `users` (`id`) VALUES (%d);", i*1000+1000), statements[999])
+	}
+
+	// Small chunks and a zero size are left alone
+	assert.Equal(t, []string{chunk.String()}, SplitTxBatches(chunk.String(), 0))
+	assert.Equal(t, []string{chunk.String()}, SplitTxBatches(chunk.String(), 10000))
+
+	// Text before the first INSERT stays with it, the last batch may be smaller
+	batches = SplitTxBatches("-- users\nINSERT INTO t VALUES (1);\ninsert into t VALUES (2);\nINSERT INTO t VALUES (3);", 2)
+	assert.Equal(t, []string{
+		"-- users\nINSERT INTO t VALUES (1);" + dataStatementSeparator + "insert into t VALUES (2);",
+		"INSERT INTO t VALUES (3);",
+	}, batches)
+}
+
+func TestExecuteDataTxBatches(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverPostgres, TxSize: 2}}
+
+	chunk := "INSERT INTO t VALUES (1);\nINSERT INTO t VALUES (2);\nINSERT INTO t VALUES (3);"
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO t VALUES (1);").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO t VALUES (2);").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO t VALUES (3);").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	for _, batch := range SplitTxBatches(chunk, conn.Config.TxSize) {
+		require.NoError(t, ExecuteData(conn, batch))
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExecuteDataWithBlobs(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_files_data_1.bin"), []byte{0x00, 0xff}, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1_files_data_2.bin"), []byte("second"), 0644))
+
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverPostgres}}
+
+	// Placeholders become parameters, the one inside a string literal stays text
+	chunk := "INSERT INTO files VALUES (1, " + BlobPlaceholder("1_files_data_1.bin") + ", 'LOAD_BLOB_FILE(''x.bin'')'),\n" +
+		"(2, " + BlobPlaceholder("1_files_data_2.bin") + ", NULL);"
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO files VALUES (1, $1, 'LOAD_BLOB_FILE(''x.bin'')'),\n(2, $2, NULL);").
+		WithArgs([]byte{0x00, 0xff}, []byte("second")).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	require.NoError(t, ExecuteDataWithBlobs(conn, chunk, dir))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	mysql := blobBinder{driver: DriverMySQL, dir: dir}
+	query, args, err := mysql.bind("INSERT INTO files VALUES (" + BlobPlaceholder("1_files_data_2.bin") + ");")
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO files VALUES (?);", query)
+	assert.Equal(t, []interface{}{[]byte("second")}, args)
+
+	_, _, err = mysql.bind("INSERT INTO files VALUES (" + BlobPlaceholder("missing.bin") + ");")
+	assert.ErrorContains(t, err, "missing.bin")
+
+	// Without a directory the statement is executed as it is
+	query, args, err = blobBinder{driver: DriverMySQL}.bind("INSERT INTO files VALUES (" + BlobPlaceholder("missing.bin") + ");")
+	require.NoError(t, err)
+	assert.Contains(t, query, "LOAD_BLOB_FILE('missing.bin')")
+	assert.Nil(t, args)
+}
+
+func TestDataTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverMySQL}}
+
+	// Both chunks run in one transaction, with foreign key checks disabled on its connection
+	mock.ExpectExec("SET FOREIGN_KEY_CHECKS = 0").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO t VALUES (1);").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO t VALUES (2);").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SET FOREIGN_KEY_CHECKS = 1").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, BeginDataTx(conn))
+	assert.Error(t, BeginDataTx(conn), "only one transaction can be open")
+	require.NoError(t, ExecuteData(conn, "INSERT INTO t VALUES (1);"))
+	require.NoError(t, ExecuteData(conn, "INSERT INTO t VALUES (2);"))
+	require.NoError(t, CommitDataTx(conn))
+	assert.Error(t, CommitDataTx(conn))
+	assert.NoError(t, RollbackDataTx(conn), "nothing to roll back")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTableColumnStats(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverMySQL}}
+
+	mock.ExpectQuery("SELECT COLUMN_NAME, DATA_TYPE").WithArgs("orders").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"}).
+			AddRow("id", "int").
+			AddRow("total", "decimal").
+			AddRow("note", "varchar").
+			AddRow("created_at", "datetime"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) - COUNT(`id`), MIN(`id`), MAX(`id`), AVG(`id`), " +
+		"COUNT(*) - COUNT(`total`), MIN(`total`), MAX(`total`), AVG(`total`), " +
+		"COUNT(*) - COUNT(`note`), MAX(CHAR_LENGTH(`note`)), COUNT(DISTINCT `note`), " +
+		"COUNT(*) - COUNT(`created_at`) FROM `orders`")).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"}).
+			AddRow(0, []byte("1"), []byte("250"), []byte("125.5000"),
+				2, []byte("0.50"), []byte("99999999999999999.99"), []byte("42.1250"),
+				5, 120, 37,
+				1))
+
+	stats, err := GetTableColumnStats(conn, "orders")
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	avgID, avgTotal := 125.5, 42.125
+	maxLength, distinct := int64(120), int64(37)
+	assert.Equal(t, map[string]ColumnStats{
+		"id":         {NullCount: 0, Min: "1", Max: "250", Avg: &avgID},
+		"total":      {NullCount: 2, Min: "0.50", Max: "99999999999999999.99", Avg: &avgTotal},
+		"note":       {NullCount: 5, MaxLength: &maxLength, DistinctCount: &distinct},
+		"created_at": {NullCount: 1},
+	}, stats)
+
+	// An empty table has NULL aggregates
+	mock.ExpectQuery("SELECT COLUMN_NAME, DATA_TYPE").WithArgs("empty").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "DATA_TYPE"}).AddRow("amount", "double").AddRow("name", "text"))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM `empty`")).
+		WillReturnRows(sqlmock.NewRows([]string{"a", "b", "c", "d", "e", "f", "g"}).
+			AddRow(0, nil, nil, nil, 0, nil, 0))
+
+	stats, err = GetTableColumnStats(conn, "empty")
+	require.NoError(t, err)
+	zero := int64(0)
+	assert.Equal(t, ColumnStats{}, stats["amount"])
+	assert.Equal(t, ColumnStats{MaxLength: &zero, DistinctCount: &zero}, stats["name"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateDatabaseSQL(t *testing.T) {
+	assert.Equal(t, "CREATE DATABASE `shop`", createDatabaseSQL(DriverMySQL, "shop", DatabaseOptions{}))
+	assert.Equal(t, "CREATE DATABASE `shop` CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci",
+		createDatabaseSQL(DriverMySQL, "shop", DatabaseOptions{Charset: "utf8mb4", Collation: "utf8mb4_unicode_ci", Encoding: "UTF8"}))
+	assert.Equal(t, `CREATE DATABASE "shop"`, createDatabaseSQL(DriverPostgres, "shop", DatabaseOptions{Charset: "utf8mb4"}))
+	assert.Equal(t, `CREATE DATABASE "shop" ENCODING 'UTF8' TEMPLATE template0`,
+		createDatabaseSQL(DriverPostgres, "shop", DatabaseOptions{Encoding: "UTF8"}))
+}
+
+func TestSchemaJSONRoundTrip(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverMySQL}}
+
+	mock.ExpectQuery("SELECT @@SESSION.sql_mode").WillReturnRows(sqlmock.NewRows([]string{"mode"}).AddRow("STRICT_TRANS_TABLES"))
+	mock.ExpectQuery("FROM information_schema.views").WithArgs("orders").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("FROM INFORMATION_SCHEMA.COLUMNS").WithArgs("orders").WillReturnRows(
+		sqlmock.NewRows([]string{"name", "type", "nullable", "default", "extra"}).
+			AddRow("id", "bigint unsigned", false, nil, "auto_increment").
+			AddRow("user_id", "int", false, nil, "").
+			AddRow("status", "varchar(20)", false, "it's new", "").
+			AddRow("note", "text", true, nil, "").
+			AddRow("updated_at", "timestamp", true, "CURRENT_TIMESTAMP", "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"))
+	mock.ExpectQuery("CONSTRAINT_NAME = 'PRIMARY'").WithArgs("orders").WillReturnRows(sqlmock.NewRows([]string{"column"}).AddRow("id"))
+	mock.ExpectQuery("REFERENCED_TABLE_NAME IS NOT NULL").WithArgs("orders").WillReturnRows(
+		sqlmock.NewRows([]string{"name", "column", "ref_table", "ref_column"}).AddRow("fk_orders_user", "user_id", "users", "id"))
+	mock.ExpectQuery("FROM INFORMATION_SCHEMA.STATISTICS").WithArgs("orders").WillReturnRows(
+		sqlmock.NewRows([]string{"name", "column", "unique"}).
+			AddRow("fk_orders_user", "user_id", false).
+			AddRow("idx_lower_status", nil, false).
+			AddRow("uq_status_note", "status", true).
+			AddRow("uq_status_note", "note", true))
+
+	data, err := SchemaToJSON(conn, []string{"orders"})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	schema, err := ParseSchemaJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, DriverMySQL, schema.Driver)
+	assert.Equal(t, "STRICT_TRANS_TABLES", schema.SQLMode)
+	require.Len(t, schema.Tables, 1)
+	orders := schema.Tables[0]
+	assert.Equal(t, []string{"id"}, orders.PrimaryKey)
+	assert.Equal(t, []ForeignKeyDescription{{Name: "fk_orders_user", Columns: []string{"user_id"}, ReferencedTable: "users", ReferencedColumns: []string{"id"}}}, orders.ForeignKeys)
+	assert.Equal(t, []IndexDescription{
+		{Name: "fk_orders_user", Columns: []string{"user_id"}},
+		{Name: "uq_status_note", Columns: []string{"status", "note"}, Unique: true},
+	}, orders.Indexes, "the expression index is left out")
+
+	// Serializing the parsed schema again gives the same JSON
+	again, err := json.MarshalIndent(schema, "", "  ")
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+
+	assert.Equal(t, "CREATE TABLE `orders` (\n"+
+		"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n"+
+		"  `user_id` int NOT NULL,\n"+
+		"  `status` varchar(20) NOT NULL DEFAULT 'it''s new',\n"+
+		"  `note` text,\n"+
+		"  `updated_at` timestamp DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  KEY `fk_orders_user` (`user_id`),\n"+
+		"  UNIQUE KEY `uq_status_note` (`status`, `note`),\n"+
+		"  CONSTRAINT `fk_orders_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`)\n"+
+		");", JSONToCreateTable(schema.Driver, orders, true))
+	assert.NotContains(t, JSONToCreateTable(schema.Driver, orders, false), "KEY `uq_status_note`")
+}
+
+func TestJSONToCreateTablePostgres(t *testing.T) {
+	now := "now()"
+	table := TableDescription{
+		Name: "Events",
+		Columns: []ColumnDescription{
+			{Name: "id", Type: "integer", AutoIncrement: true},
+			{Name: "created_at", Type: "timestamp without time zone", Default: &now},
+			{Name: "kind", Type: "character varying(20)"},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes: []IndexDescription{
+			{Name: "events_kind_key", Columns: []string{"kind"}, Unique: true},
+			{Name: "events_created_idx", Columns: []string{"created_at"}},
+		},
+	}
+	assert.Equal(t, "CREATE TABLE \"Events\" (\n"+
+		"  id integer GENERATED BY DEFAULT AS IDENTITY NOT NULL,\n"+
+		"  created_at timestamp without time zone NOT NULL DEFAULT now(),\n"+
+		"  kind character varying(20) NOT NULL,\n"+
+		"  PRIMARY KEY (id),\n"+
+		"  CONSTRAINT events_kind_key UNIQUE (kind)\n"+
+		");", JSONToCreateTable(DriverPostgres, table, true))
+
+	view := TableDescription{Name: "active_events", View: "CREATE VIEW active_events AS SELECT 1;"}
+	assert.Equal(t, view.View, JSONToCreateTable(DriverPostgres, view, true))
+}
+
+func TestGetViews(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverMySQL}}
+
+	mock.ExpectQuery(`FROM information_schema.views\s+WHERE table_schema = DATABASE\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("active_users").AddRow("order_totals"))
+	views, err := GetViews(conn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active_users", "order_totals"}, views)
+
+	conn.Config.Driver = DriverPostgres
+	mock.ExpectQuery(`FROM information_schema.views\s+WHERE table_schema = current_schema\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}))
+	views, err = GetViews(conn)
+	require.NoError(t, err)
+	assert.Empty(t, views)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRequoteIdentifiers(t *testing.T) {
+	backtick := "-- Bob's table\nINSERT INTO `users` (`id`, `say \"hi\"`) VALUES (1, 'it''s `x`'), (2, 'a\\'b \"c\"');"
+	ansi := "-- Bob's table\nINSERT INTO \"users\" (\"id\", \"say \"\"hi\"\"\") VALUES (1, 'it''s `x`'), (2, 'a\\'b \"c\"');"
+	assert.Equal(t, ansi, ANSIQuoteIdentifiers(backtick))
+	assert.Equal(t, backtick, BacktickQuoteIdentifiers(ansi))
+	assert.Equal(t, "SELECT 5-1 FROM \"t\"", ANSIQuoteIdentifiers("SELECT 5-1 FROM `t`"))
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	testCases := []struct {
+		style      string
+		identifier string
+		mysql      string
+		postgres   string
+	}{
+		{QuoteStyleAuto, "order items", "`order items`", `"order items"`},
+		{QuoteStyleAuto, "select", "`select`", `"select"`},
+		{"", "user-id", "`user-id`", `"user-id"`},
+		{QuoteStyleBacktick, "order items", "`order items`", "`order items`"},
+		{QuoteStyleBacktick, "group", "`group`", "`group`"},
+		{QuoteStyleDoubleQuote, "price$", `"price$"`, `"price$"`},
+		{QuoteStyleDoubleQuote, "select", `"select"`, `"select"`},
+		{QuoteStyleNone, "order items", "order items", "order items"},
+		{QuoteStyleNone, "user-id", "user-id", "user-id"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.style+"/"+tc.identifier, func(t *testing.T) {
+			assert.Equal(t, tc.mysql, QuoteIdentifier(DriverMySQL, tc.style, tc.identifier))
+			assert.Equal(t, tc.postgres, QuoteIdentifier(DriverPostgres, tc.style, tc.identifier))
+		})
+	}
+	assert.Equal(t, "`users`", EscapeIdentifier(DriverMySQL, "users"))
+
+	assert.NoError(t, ValidateQuoteStyle(QuoteStyleNone))
+	assert.Error(t, ValidateQuoteStyle("single"))
+}
+
+func TestRequoteIdentifiersStyle(t *testing.T) {
+	mysql := "INSERT INTO `order items` (`select`, `a$b`) VALUES ('`x`', 'say \"hi\"');"
+	assert.Equal(t, mysql, RequoteIdentifiersStyle(DriverMySQL, QuoteStyleAuto, mysql))
+	assert.Equal(t, mysql, RequoteIdentifiersStyle(DriverMySQL, QuoteStyleBacktick, mysql))
+	assert.Equal(t, "INSERT INTO \"order items\" (\"select\", \"a$b\") VALUES ('`x`', 'say \"hi\"');",
+		RequoteIdentifiersStyle(DriverMySQL, QuoteStyleDoubleQuote, mysql))
+	assert.Equal(t, "INSERT INTO order items (select, a$b) VALUES ('`x`', 'say \"hi\"');",
+		RequoteIdentifiersStyle(DriverMySQL, QuoteStyleNone, mysql))
+
+	postgres := `INSERT INTO "user" ("id", "it's") VALUES (1, 'it''s "quoted"');`
+	assert.Equal(t, "INSERT INTO `user` (`id`, `it's`) VALUES (1, 'it''s \"quoted\"');",
+		RequoteIdentifiersStyle(DriverPostgres, QuoteStyleBacktick, postgres))
+	assert.Equal(t, `INSERT INTO user (id, it's) VALUES (1, 'it''s "quoted"');`,
+		RequoteIdentifiersStyle(DriverPostgres, QuoteStyleNone, postgres))
+}
+
+func TestGetPrimaryKeyColumnsCached(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverMySQL}}
+
+	// Composite keys come back in key order; the second call is served from the cache
+	mock.ExpectQuery(`FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE`).WithArgs("order_items").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME"}).AddRow("order_id").AddRow("line_no"))
+	columns, err := GetPrimaryKeyColumns(conn, "order_items")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order_id", "line_no"}, columns)
+
+	columns[0] = "changed"
+	columns, err = GetPrimaryKeyColumns(conn, "order_items")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"order_id", "line_no"}, columns)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetTableIndexes(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer mockDB.Close()
+	conn := &Connection{DB: mockDB, Config: ConnectionConfig{Driver: DriverPostgres}}
+
+	// Expression and partial indexes are returned as PostgreSQL reports them
+	mock.ExpectQuery(`FROM pg_indexes\s+WHERE schemaname = current_schema\(\)`).WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"indexdef"}).
+			AddRow("CREATE INDEX users_active ON public.users USING btree (id) WHERE active").
+			AddRow("CREATE UNIQUE INDEX users_email_lower ON public.users USING btree (lower((email)::text))"))
+	indexes, err := GetTableIndexes(conn, "users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE INDEX users_active ON public.users USING btree (id) WHERE active;",
+		"CREATE UNIQUE INDEX users_email_lower ON public.users USING btree (lower((email)::text));",
+	}, indexes)
+
+	// MySQL indexes are rebuilt from SHOW INDEX, whose columns vary by version
+	conn.Config.Driver = DriverMySQL
+	columns := []string{"Table", "Non_unique", "Key_name", "Seq_in_index", "Column_name", "Collation", "Sub_part", "Index_type", "Expression"}
+	mock.ExpectQuery("SHOW INDEX FROM `users`").WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("users", "0", "PRIMARY", "1", "id", "A", nil, "BTREE", nil).
+		AddRow("users", "1", "name_created", "1", "name", "A", "10", "BTREE", nil).
+		AddRow("users", "1", "name_created", "2", "created_at", "D", nil, "BTREE", nil).
+		AddRow("users", "0", "email_lower", "1", nil, "A", nil, "BTREE", "lower(`email`)").
+		AddRow("users", "1", "bio", "1", "bio", nil, nil, "FULLTEXT", nil))
+	indexes, err = GetTableIndexes(conn, "users")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE FULLTEXT INDEX `bio` ON `users` (`bio`);",
+		"CREATE UNIQUE INDEX `email_lower` ON `users` ((lower(`email`)));",
+		"CREATE INDEX `name_created` ON `users` (`name`(10), `created_at` DESC);",
+	}, indexes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDefaultTypeMapper(t *testing.T) {
+	var mapper TypeMapper = DefaultTypeMapper{}
+	tests := map[string]parquet.Type{
+		"int":         parquet.Int64Type,
+		"bigint":      parquet.Int64Type,
+		"int4":        parquet.Int64Type,
+		"tinyint":     parquet.Int64Type,
+		"bool":        parquet.BooleanType,
+		"double":      parquet.DoubleType,
+		"float8":      parquet.DoubleType,
+		"date":        parquet.DateType,
+		"datetime":    parquet.TimestampType,
+		"timestamptz": parquet.TimestampType,
+		"varchar":     parquet.StringType,
+		"decimal":     parquet.StringType,
+		"jsonb":       parquet.StringType,
+		"blob":        parquet.BytesType,
+		"bytea":       parquet.BytesType,
+	}
+	for columnType, expected := range tests {
+		assert.Equal(t, expected, mapper.ParquetType(columnType), columnType)
+	}
+}
+
+func TestTablePatternMatch(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		table   string
+		match   bool
+	}{
+		// Glob patterns
+		{"*", "users", true},
+		{"user*", "users", true},
+		{"*_log", "audit_log", true},
+		{"*order*", "my_orders", true},
+		{"a*_log", "audit_log", true},
+		{"user?", "users", true},
+		{"user?", "user", false},
+		{"users", "users", true},
+		{"users", "users_archive", false},
+		// SQL LIKE patterns
+		{"%orders%", "my_orders", true},
+		{"%orders%", "orders_archive", true},
+		{"%orders%", "order_items", false},
+		{"_user", "auser", true},
+		{"_user", "buser", true},
+		{"_user", "users", false},
+		{"user%", "user", true},
+		{"my\\_orders", "my_orders", true},
+		{"my\\_orders", "myxorders", false},
+		{"my_orders", "myxorders", true},
+		{"100\\%", "100%", true},
+		// Mixed patterns are globs, '%' and '_' are literal
+		{"audit_*", "audit_log", true},
+		{"audit_*", "auditxlog", false},
+		{"%tmp*", "%tmp_data", true},
+		{"%tmp*", "my_tmp_data", false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.match, TablePatternMatch(tc.table, tc.pattern), "%s ~ %s", tc.table, tc.pattern)
+	}
+
+	assert.True(t, MixedTablePattern("%tmp*"))
+	assert.False(t, MixedTablePattern("audit_*"))
+	assert.False(t, MixedTablePattern("%orders%"))
+}
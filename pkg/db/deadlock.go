@@ -0,0 +1,39 @@
+package db
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// Error codes reported when a transaction is rolled back as a deadlock victim
+const (
+	mysqlErrLockDeadlock = 1213    // ER_LOCK_DEADLOCK
+	pqErrDeadlock        = "40P01" // deadlock_detected
+)
+
+// IsDeadlock reports whether err was caused by the database aborting the
+// transaction to resolve a deadlock.
+func IsDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrLockDeadlock
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == pqErrDeadlock
+	}
+	return false
+}
+
+// jitter returns d randomized to between 50% and 150% of its value, so
+// transactions that deadlocked each other do not retry at the same time.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)+1))
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsDeadlock(t *testing.T) {
+	assert.True(t, IsDeadlock(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, IsDeadlock(fmt.Errorf("wrapped: %w", &pq.Error{Code: "40P01"})))
+	assert.False(t, IsDeadlock(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, IsDeadlock(errors.New("deadlock")))
+}
+
+func TestExecuteDataDeadlockRetry(t *testing.T) {
+	var sleeps []time.Duration
+	retrySleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	defer func() { retrySleep = time.Sleep }()
+
+	deadlock := &pq.Error{Code: "40P01", Message: "deadlock detected"}
+	stmt := `INSERT INTO "users" ("id") VALUES (1);`
+	newConn := func(t *testing.T, failures int) (*Connection, sqlmock.Sqlmock) {
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		t.Cleanup(func() { mockDB.Close() })
+		for i := 0; i < failures; i++ {
+			mock.ExpectBegin()
+			mock.ExpectExec(stmt).WillReturnError(deadlock)
+			mock.ExpectRollback()
+		}
+		return &Connection{DB: mockDB, Config: ConnectionConfig{
+			Driver:             DriverPostgres,
+			DeadlockRetryCount: 3,
+			DeadlockRetryDelay: 100 * time.Millisecond,
+		}}, mock
+	}
+
+	t.Run("Succeeds after retries", func(t *testing.T) {
+		sleeps = nil
+		conn, mock := newConn(t, 2)
+		mock.ExpectBegin()
+		mock.ExpectExec(stmt).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, ExecuteData(conn, stmt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		require.Len(t, sleeps, 2)
+		for _, d := range sleeps {
+			assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+			assert.LessOrEqual(t, d, 150*time.Millisecond)
+		}
+	})
+
+	t.Run("Gives up after the retry count", func(t *testing.T) {
+		sleeps = nil
+		conn, mock := newConn(t, 4)
+
+		err := ExecuteData(conn, stmt)
+		assert.ErrorContains(t, err, "deadlock persisted after 3 retries")
+		assert.True(t, IsDeadlock(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Len(t, sleeps, 3)
+	})
+
+	t.Run("Other errors are not retried", func(t *testing.T) {
+		sleeps = nil
+		conn, mock := newConn(t, 0)
+		mock.ExpectBegin()
+		mock.ExpectExec(stmt).WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		assert.Error(t, ExecuteData(conn, stmt))
+		assert.NoError(t, mock.ExpectationsWereMet())
+		assert.Empty(t, sleeps)
+	})
+}
@@ -0,0 +1,189 @@
+// Package hashcatalog stores a fingerprint of each row of a table, keyed by
+// primary key, so an incremental export can leave out the rows that did not
+// change since the previous export.
+package hashcatalog
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// magic identifies a hash catalog file, followed by the format version
+const (
+	magic   = "SDBH"
+	version = 1
+)
+
+// Catalog maps the primary key of each row to the CRC32 of its values.
+type Catalog struct {
+	hashes map[string]uint32
+}
+
+// New returns an empty catalog.
+func New() *Catalog {
+	return &Catalog{hashes: make(map[string]uint32)}
+}
+
+// Load reads a catalog written by Save. A missing file is reported with an
+// error matching os.ErrNotExist.
+func Load(path string) (*Catalog, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	c, err := read(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hash catalog %s: %w", path, err)
+	}
+	return c, nil
+}
+
+func read(r *bufio.Reader) (*Catalog, error) {
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("not a hash catalog")
+	}
+	if header[len(magic)] != version {
+		return nil, fmt.Errorf("unsupported version %d", header[len(magic)])
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c := New()
+	for i := uint64(0); i < count; i++ {
+		keyLen, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		entry := make([]byte, keyLen+4)
+		if _, err := io.ReadFull(r, entry); err != nil {
+			return nil, err
+		}
+		c.hashes[string(entry[:keyLen])] = binary.LittleEndian.Uint32(entry[keyLen:])
+	}
+	return c, nil
+}
+
+// Save writes the catalog to path, entries sorted by key so equal catalogs
+// give identical files.
+func (c *Catalog) Save(path string) error {
+	keys := make([]string, 0, len(c.hashes))
+	for key := range c.hashes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf []byte
+	buf = append(buf, magic...)
+	buf = append(buf, version)
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, key := range keys {
+		buf = binary.AppendUvarint(buf, uint64(len(key)))
+		buf = append(buf, key...)
+		buf = binary.LittleEndian.AppendUint32(buf, c.hashes[key])
+	}
+	if err := os.WriteFile(path, buf, 0644); err != nil {
+		return fmt.Errorf("failed to write hash catalog %s: %w", path, err)
+	}
+	return nil
+}
+
+// Set records the hash of the row with the given key.
+func (c *Catalog) Set(key string, hash uint32) {
+	c.hashes[key] = hash
+}
+
+// Len returns the number of rows in the catalog.
+func (c *Catalog) Len() int {
+	return len(c.hashes)
+}
+
+// Compare reports whether the row with the given key is new or has a
+// different hash than recorded in the catalog.
+func (c *Catalog) Compare(key string, hash uint32) bool {
+	previous, ok := c.hashes[key]
+	return !ok || previous != hash
+}
+
+// Key returns the catalog key of a row from its primary key values.
+func Key(pkColumns []string, row map[string]interface{}) string {
+	var b strings.Builder
+	for _, col := range pkColumns {
+		writeValue(&b, row[col])
+	}
+	return b.String()
+}
+
+// RowHash returns the CRC32 of a row's values. Columns are taken in name
+// order and values are serialized in a form that does not depend on the
+// driver, so equivalent rows of MySQL and PostgreSQL have the same hash.
+func RowHash(row map[string]interface{}) uint32 {
+	columns := make([]string, 0, len(row))
+	for col := range row {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	var b strings.Builder
+	for _, col := range columns {
+		writeValue(&b, col)
+		writeValue(&b, row[col])
+	}
+	return crc32.ChecksumIEEE([]byte(b.String()))
+}
+
+// writeValue appends a value as a type tag and its length-prefixed text, so
+// that no two different value lists serialize alike. Booleans are written as
+// the numbers MySQL stores them as, and times in UTC.
+func writeValue(b *strings.Builder, value interface{}) {
+	var tag byte
+	var text string
+	switch v := value.(type) {
+	case nil:
+		tag = 'N'
+	case string:
+		tag, text = 'S', v
+	case []byte:
+		tag, text = 'S', string(v)
+	case bool:
+		tag, text = 'I', "0"
+		if v {
+			text = "1"
+		}
+	case float64:
+		tag, text = 'I', strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		tag, text = 'I', strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case int64:
+		tag, text = 'I', strconv.FormatInt(v, 10)
+	case int:
+		tag, text = 'I', strconv.Itoa(v)
+	case json.Number:
+		tag, text = 'I', v.String()
+	case time.Time:
+		tag, text = 'S', v.UTC().Format(time.RFC3339Nano)
+	default:
+		tag, text = 'S', fmt.Sprint(v)
+	}
+	b.WriteByte(tag)
+	b.WriteString(strconv.Itoa(len(text)))
+	b.WriteByte(':')
+	b.WriteString(text)
+}
@@ -0,0 +1,70 @@
+package hashcatalog
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "0_row_hashes_users.bin")
+	c := New()
+	c.Set(Key([]string{"id"}, map[string]interface{}{"id": float64(1)}), 0xdeadbeef)
+	c.Set(Key([]string{"id"}, map[string]interface{}{"id": float64(2)}), 42)
+	require.NoError(t, c.Save(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, c, loaded)
+
+	// Saving is deterministic
+	first, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, loaded.Save(path))
+	second, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.bin"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0644))
+	_, err = Load(path)
+	assert.ErrorContains(t, err, "not a hash catalog")
+}
+
+func TestCompare(t *testing.T) {
+	c := New()
+	c.Set("a", 1)
+	assert.False(t, c.Compare("a", 1))
+	assert.True(t, c.Compare("a", 2))
+	assert.True(t, c.Compare("b", 1))
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestRowHashIsDriverIndependent(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// A row as read from MySQL: tinyint(1) flag, decimal text, integer id
+	mysqlRow := map[string]interface{}{"id": int64(7), "active": int64(1), "price": []byte("9.99"), "note": nil, "created_at": created}
+	// The same row from PostgreSQL: boolean flag, numeric text, time in another zone
+	pgRow := map[string]interface{}{"created_at": created.In(time.FixedZone("CET", 3600)), "note": nil, "price": "9.99", "active": true, "id": json.Number("7")}
+	// After the JSON round trip of the export, numbers are float64
+	decodedRow := map[string]interface{}{"id": float64(7), "active": float64(1), "price": "9.99", "note": nil, "created_at": created}
+
+	hash := RowHash(mysqlRow)
+	assert.Equal(t, hash, RowHash(pgRow))
+	assert.Equal(t, hash, RowHash(decodedRow))
+
+	// NULL, an empty string and a value moved to another column all differ
+	assert.NotEqual(t, hash, RowHash(map[string]interface{}{"id": float64(7), "active": float64(1), "price": "9.99", "note": "", "created_at": created}))
+	assert.NotEqual(t, RowHash(map[string]interface{}{"a": "x", "b": ""}), RowHash(map[string]interface{}{"a": "", "b": "x"}))
+
+	assert.Equal(t, Key([]string{"id"}, mysqlRow), Key([]string{"id"}, decodedRow))
+	assert.NotEqual(t, Key([]string{"a", "b"}, map[string]interface{}{"a": "1", "b": "23"}),
+		Key([]string{"a", "b"}, map[string]interface{}{"a": "12", "b": "3"}))
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortColumn is one column of an export ORDER BY clause.
+type SortColumn struct {
+	Column     string
+	Descending bool
+}
+
+// ParseOrderBy parses a sort specification such as "created_at DESC, id" into
+// its columns. The direction is ASC or DESC (case-insensitive) and defaults to
+// ASC. Column names are escaped when the clause is built, so they must be plain
+// names rather than expressions.
+func ParseOrderBy(spec string) ([]SortColumn, error) {
+	var columns []SortColumn
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("invalid order by column %q: expected column [ASC|DESC]", strings.TrimSpace(part))
+		}
+		column := SortColumn{Column: strings.Trim(fields[0], "`\"")}
+		if len(fields) == 2 {
+			switch strings.ToUpper(fields[1]) {
+			case "ASC":
+			case "DESC":
+				column.Descending = true
+			default:
+				return nil, fmt.Errorf("invalid sort direction %q for column %s: must be ASC or DESC", fields[1], column.Column)
+			}
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("order by specification %q has no columns", spec)
+	}
+	return columns, nil
+}
+
+// orderByClause builds the column list of an ORDER BY clause with escaped
+// column names and an explicit direction, e.g. "`id` ASC, `name` DESC".
+func orderByClause(driver string, columns []SortColumn) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		direction := "ASC"
+		if col.Descending {
+			direction = "DESC"
+		}
+		parts[i] = EscapeIdentifier(driver, col.Column) + " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
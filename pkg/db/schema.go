@@ -2,7 +2,10 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -12,6 +15,14 @@ type SchemaInfo struct {
 	IsView     bool
 	Definition string
 	Columns    []string
+	// ColumnTypes maps column names to their type (see DataOperation.ColumnTypes)
+	ColumnTypes map[string]string
+	// ColumnDefaults maps column names to their default expression as reported
+	// by information_schema. Columns without a default are not included.
+	ColumnDefaults map[string]string
+	// PrimaryKey lists the primary key columns in key order, empty for views
+	// and tables without a primary key
+	PrimaryKey []string
 }
 
 // GetSchema retrieves the schema information for a table or view
@@ -21,24 +32,79 @@ func GetSchema(conn *Connection, tableName string) (*SchemaInfo, error) {
 		return nil, fmt.Errorf("failed to check if table is view: %w", err)
 	}
 
-	columns, err := getSchemaColumnNames(conn.DB, tableName, conn.Config.Driver)
+	columns, columnTypes, err := getSchemaColumnNames(conn.DB, tableName, conn.Config.Driver)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get table columns: %w", err)
 	}
 
+	defaults, err := getColumnDefaults(conn.DB, tableName, conn.Config.Driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get column defaults: %w", err)
+	}
+
 	definition, err := getDefinition(conn, tableName, isView)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get definition: %w", err)
 	}
 
+	var primaryKey []string
+	if !isView {
+		if primaryKey, err = GetPrimaryKeyColumns(conn, tableName); err != nil {
+			return nil, err
+		}
+	}
+
 	return &SchemaInfo{
-		Name:       tableName,
-		IsView:     isView,
-		Definition: definition,
-		Columns:    columns,
+		Name:           tableName,
+		IsView:         isView,
+		Definition:     definition,
+		Columns:        columns,
+		ColumnTypes:    columnTypes,
+		ColumnDefaults: defaults,
+		PrimaryKey:     primaryKey,
 	}, nil
 }
 
+// getColumnDefaults returns the default expression of each column that has one
+func getColumnDefaults(db *sql.DB, tableName string, driver string) (map[string]string, error) {
+	var query string
+	switch driver {
+	case DriverMySQL:
+		query = `
+			SELECT COLUMN_NAME, COLUMN_DEFAULT
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE()
+			AND TABLE_NAME = ?
+			AND COLUMN_DEFAULT IS NOT NULL`
+	case DriverPostgres:
+		query = `
+			SELECT column_name, column_default
+			FROM information_schema.columns
+			WHERE table_name = $1
+			AND table_schema = current_schema()
+			AND column_default IS NOT NULL`
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
+	}
+
+	rows, err := db.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	defaults := make(map[string]string)
+	for rows.Next() {
+		var col, def string
+		if err := rows.Scan(&col, &def); err != nil {
+			return nil, err
+		}
+		defaults[col] = def
+	}
+
+	return defaults, rows.Err()
+}
+
 // getDefinition retrieves the CREATE TABLE/VIEW statement
 func getDefinition(conn *Connection, tableName string, isView bool) (string, error) {
 	if isView {
@@ -54,9 +120,9 @@ func getViewDefinition(conn *Connection, tableName string) (string, error) {
 
 	switch conn.Config.Driver {
 	case DriverMySQL:
-		query = "SELECT VIEW_DEFINITION FROM information_schema.views WHERE table_name = ?"
+		query = "SELECT VIEW_DEFINITION FROM information_schema.views WHERE table_name = ? AND table_schema = DATABASE()"
 	case DriverPostgres:
-		query = "SELECT view_definition FROM information_schema.views WHERE table_name = $1 AND table_schema = 'public'"
+		query = "SELECT view_definition FROM information_schema.views WHERE table_name = $1 AND table_schema = current_schema()"
 	default:
 		return "", fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
 	}
@@ -66,6 +132,8 @@ func getViewDefinition(conn *Connection, tableName string) (string, error) {
 		return "", fmt.Errorf("failed to get view definition: %w", err)
 	}
 
+	// PostgreSQL ends the definition with its own semicolon
+	viewDef = strings.TrimSuffix(strings.TrimSpace(viewDef), ";")
 	return fmt.Sprintf("CREATE VIEW %s AS %s;", tableName, viewDef), nil
 }
 
@@ -95,6 +163,7 @@ func getTableDefinition(conn *Connection, tableName string) (string, error) {
 				) || ');'
 			FROM information_schema.columns
 			WHERE table_name = $1
+			AND table_schema = current_schema()
 			GROUP BY table_name
 		`)
 	default:
@@ -122,7 +191,704 @@ func getTableDefinition(conn *Connection, tableName string) (string, error) {
 	return schema, nil
 }
 
-// getSchemaColumnNames returns a list of column names for a table
-func getSchemaColumnNames(db *sql.DB, tableName string, driver string) ([]string, error) {
-	return getNonVirtualColumns(db, tableName, driver)
+// getSchemaColumnNames returns the column names of a table and their types
+func getSchemaColumnNames(db *sql.DB, tableName string, driver string) ([]string, map[string]string, error) {
+	return getNonVirtualColumns(db, tableName, driver, false)
+}
+
+// GetPrimaryKeyColumns returns the primary key columns of a table ordered by their position in the key.
+// Results are cached on the connection, so each table is only queried once.
+func GetPrimaryKeyColumns(conn *Connection, tableName string) ([]string, error) {
+	if cached, ok := conn.primaryKeys.Load(tableName); ok {
+		return append([]string(nil), cached.([]string)...), nil
+	}
+
+	var query string
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		query = `
+			SELECT COLUMN_NAME
+			FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = DATABASE()
+			AND TABLE_NAME = ?
+			AND CONSTRAINT_NAME = 'PRIMARY'
+			ORDER BY ORDINAL_POSITION`
+	case DriverPostgres:
+		query = `
+			SELECT kcu.column_name
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+				ON tc.constraint_name = kcu.constraint_name
+				AND tc.table_schema = kcu.table_schema
+			WHERE tc.constraint_type = 'PRIMARY KEY'
+			AND tc.table_name = $1
+			AND tc.table_schema = current_schema()
+			ORDER BY kcu.ordinal_position`
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	rows, err := conn.DB.Query(query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query primary key columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var col string
+		if err := rows.Scan(&col); err != nil {
+			return nil, fmt.Errorf("failed to scan primary key column: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read primary key columns: %w", err)
+	}
+
+	conn.primaryKeys.Store(tableName, append([]string(nil), columns...))
+	return columns, nil
+}
+
+// GetTableIndexes returns the secondary indexes of a table as CREATE INDEX
+// statements, ordered by index name. PostgreSQL statements come from
+// pg_indexes and keep expressions, WHERE clauses of partial indexes and the
+// index method; the index of the primary key is left out. MySQL statements are
+// rebuilt from SHOW INDEX, with prefix lengths, descending and functional key
+// parts. SHOW CREATE TABLE already holds the MySQL indexes, so they are only
+// needed where the CREATE TABLE statement is written without them.
+func GetTableIndexes(conn *Connection, tableName string) ([]string, error) {
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		return getMySQLIndexes(conn, tableName)
+	case DriverPostgres:
+		return getPostgresIndexes(conn, tableName)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+}
+
+// getPostgresIndexes returns the index definitions of a PostgreSQL table
+func getPostgresIndexes(conn *Connection, tableName string) ([]string, error) {
+	query := `
+		SELECT indexdef
+		FROM pg_indexes
+		WHERE schemaname = current_schema()
+		AND tablename = $1
+		AND indexname NOT IN (
+			SELECT conname FROM pg_constraint
+			WHERE conrelid = to_regclass(quote_ident($1)) AND contype = 'p'
+		)
+		ORDER BY indexname`
+	rows, err := conn.DB.Query(query, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []string
+	for rows.Next() {
+		var definition string
+		if err := rows.Scan(&definition); err != nil {
+			return nil, fmt.Errorf("failed to scan index definition: %w", err)
+		}
+		indexes = append(indexes, definition+";")
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+	return indexes, nil
+}
+
+// getMySQLIndexes rebuilds the CREATE INDEX statements of a MySQL table from
+// SHOW INDEX, whose columns differ between server versions.
+func getMySQLIndexes(conn *Connection, tableName string) ([]string, error) {
+	rows, err := conn.DB.Query(fmt.Sprintf("SHOW INDEX FROM %s", EscapeIdentifier(DriverMySQL, tableName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index columns: %w", err)
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	field := func(name string) sql.NullString {
+		for i, column := range columns {
+			if strings.EqualFold(column, name) {
+				return values[i]
+			}
+		}
+		return sql.NullString{}
+	}
+
+	type index struct {
+		kind  string
+		parts []string
+	}
+	var names []string
+	indexes := make(map[string]*index)
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		name := field("Key_name").String
+		if name == "PRIMARY" {
+			continue
+		}
+		idx, ok := indexes[name]
+		if !ok {
+			idx = &index{}
+			switch indexType := field("Index_type").String; {
+			case indexType == "FULLTEXT" || indexType == "SPATIAL":
+				idx.kind = indexType + " "
+			case field("Non_unique").String == "0":
+				idx.kind = "UNIQUE "
+			}
+			names = append(names, name)
+			indexes[name] = idx
+		}
+
+		var part string
+		if column := field("Column_name"); column.Valid {
+			part = EscapeIdentifier(DriverMySQL, column.String)
+			if subPart := field("Sub_part"); subPart.Valid {
+				part += "(" + subPart.String + ")"
+			}
+		} else {
+			part = "(" + field("Expression").String + ")"
+		}
+		if field("Collation").String == "D" {
+			part += " DESC"
+		}
+		idx.parts = append(idx.parts, part)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	sort.Strings(names)
+	statements := make([]string, len(names))
+	for i, name := range names {
+		statements[i] = fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", indexes[name].kind,
+			EscapeIdentifier(DriverMySQL, name), EscapeIdentifier(DriverMySQL, tableName), strings.Join(indexes[name].parts, ", "))
+	}
+	return statements, nil
+}
+
+// createTableNameRegex matches the table name of a CREATE TABLE statement
+var createTableNameRegex = regexp.MustCompile("^CREATE TABLE (`(?:[^`]|``)+`|\"(?:[^\"]|\"\")+\"|\\S+) \\(")
+
+// indexDefinitionRegex matches a secondary index line of SHOW CREATE TABLE,
+// e.g. "UNIQUE KEY `email` (`email`)", capturing the kind and the index name.
+// The key parts and index options follow the name.
+var indexDefinitionRegex = regexp.MustCompile("^(?:(UNIQUE|FULLTEXT|SPATIAL) )?(?:KEY|INDEX) (`(?:[^`]|``)+`) \\(")
+
+// autoIncrementColumnRegex matches a column definition with AUTO_INCREMENT
+var autoIncrementColumnRegex = regexp.MustCompile("^(`(?:[^`]|``)+`) .*\\bAUTO_INCREMENT\\b")
+
+// StripIndexesFromCreateTable removes the secondary indexes from a MySQL
+// CREATE TABLE statement as returned by SHOW CREATE TABLE, one definition per
+// line, and returns them as separate CREATE INDEX statements, one per line.
+// Building indexes once the data is loaded is faster than maintaining them
+// during the inserts. The primary key, foreign keys and indexes leading with
+// an AUTO_INCREMENT column (which MySQL requires to be indexed) are kept.
+// Statements in another layout, such as the PostgreSQL definitions, which
+// have no indexes, are returned unchanged.
+func StripIndexesFromCreateTable(sql string) (tableSQL, indexSQL string, err error) {
+	lines := strings.Split(sql, "\n")
+	match := createTableNameRegex.FindStringSubmatch(lines[0])
+	if match == nil || len(lines) < 3 || !strings.HasPrefix(lines[len(lines)-1], ")") {
+		return sql, "", nil
+	}
+	tableName := match[1]
+	body := lines[1 : len(lines)-1]
+
+	autoIncrement := make(map[string]bool)
+	for _, line := range body {
+		if m := autoIncrementColumnRegex.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			autoIncrement[m[1]] = true
+		}
+	}
+
+	var kept, indexes []string
+	for _, line := range body {
+		definition := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		m := indexDefinitionRegex.FindStringSubmatchIndex(definition)
+		if m == nil {
+			kept = append(kept, strings.TrimSuffix(line, ","))
+			continue
+		}
+		kind, name := "", definition[m[4]:m[5]]
+		if m[2] >= 0 {
+			kind = definition[m[2]:m[3]] + " "
+		}
+		partsStart := m[1] - 1
+		partsEnd, err := matchingParen(definition, partsStart)
+		if err != nil {
+			return "", "", fmt.Errorf("index %s of table %s: %w", name, tableName, err)
+		}
+		keyParts := definition[partsStart : partsEnd+1]
+		if autoIncrement[firstKeyPart(keyParts)] {
+			kept = append(kept, strings.TrimSuffix(line, ","))
+			continue
+		}
+		indexes = append(indexes, fmt.Sprintf("CREATE %sINDEX %s ON %s %s%s;", kind, name, tableName, keyParts, definition[partsEnd+1:]))
+	}
+	if len(indexes) == 0 {
+		return sql, "", nil
+	}
+
+	tableSQL = lines[0] + "\n" + strings.Join(kept, ",\n") + "\n" + lines[len(lines)-1]
+	return tableSQL, strings.Join(indexes, "\n"), nil
+}
+
+// matchingParen returns the index of the parenthesis closing the one at
+// s[open], skipping quoted identifiers and strings.
+func matchingParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '`', '\'', '"':
+			quote := s[i]
+			for i++; i < len(s) && s[i] != quote; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses in %q", s)
+}
+
+// firstKeyPart returns the column of the first key part in "(`a`(10),`b`)",
+// or "" if it is an expression.
+func firstKeyPart(keyParts string) string {
+	inner := keyParts[1:]
+	if !strings.HasPrefix(inner, "`") {
+		return ""
+	}
+	end := strings.Index(inner[1:], "`")
+	if end < 0 {
+		return ""
+	}
+	return inner[:end+2]
+}
+
+// SchemaDescription is the structured schema written to 0_schema.json for
+// --format json, readable by code generators and ETL tools.
+type SchemaDescription struct {
+	Driver  string             `json:"driver"`
+	SQLMode string             `json:"sql_mode,omitempty"` // MySQL session sql_mode at export time
+	Tables  []TableDescription `json:"tables"`
+}
+
+// TableDescription describes a table, or a view by its definition.
+type TableDescription struct {
+	Name        string                  `json:"name"`
+	View        string                  `json:"view,omitempty"` // CREATE VIEW statement of a view
+	Columns     []ColumnDescription     `json:"columns,omitempty"`
+	PrimaryKey  []string                `json:"primary_key,omitempty"`
+	ForeignKeys []ForeignKeyDescription `json:"foreign_keys,omitempty"`
+	Indexes     []IndexDescription      `json:"indexes,omitempty"`
+}
+
+// ColumnDescription describes a column. Type is the full type in the dialect of
+// the schema's driver, e.g. varchar(255) or character varying(255).
+type ColumnDescription struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	Nullable      bool    `json:"nullable"`
+	Default       *string `json:"default,omitempty"`   // SQL expression, quoted for literals
+	OnUpdate      string  `json:"on_update,omitempty"` // MySQL ON UPDATE expression
+	AutoIncrement bool    `json:"auto_increment,omitempty"`
+}
+
+// ForeignKeyDescription describes a foreign key constraint.
+type ForeignKeyDescription struct {
+	Name              string   `json:"name"`
+	Columns           []string `json:"columns"`
+	ReferencedTable   string   `json:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns"`
+}
+
+// IndexDescription describes a secondary index. Indexes on expressions are not
+// described.
+type IndexDescription struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique,omitempty"`
+}
+
+// SchemaToJSON describes the given tables and views as indented JSON (see
+// SchemaDescription).
+func SchemaToJSON(conn *Connection, tables []string) ([]byte, error) {
+	schema := SchemaDescription{Driver: conn.Config.Driver, Tables: []TableDescription{}}
+	if conn.Config.Driver == DriverMySQL {
+		if err := conn.DB.QueryRow("SELECT @@SESSION.sql_mode").Scan(&schema.SQLMode); err != nil {
+			return nil, fmt.Errorf("failed to get SQL mode: %w", err)
+		}
+	}
+	for _, table := range tables {
+		description, err := DescribeTable(conn, table)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %w", table, err)
+		}
+		schema.Tables = append(schema.Tables, *description)
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema to JSON: %w", err)
+	}
+	return data, nil
+}
+
+// ParseSchemaJSON reads a schema written by SchemaToJSON.
+func ParseSchemaJSON(data []byte) (*SchemaDescription, error) {
+	var schema SchemaDescription
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema JSON: %w", err)
+	}
+	return &schema, nil
+}
+
+// DescribeTable reads the columns, primary key, foreign keys and secondary
+// indexes of a table. Views are described by their definition only.
+func DescribeTable(conn *Connection, tableName string) (*TableDescription, error) {
+	isView, err := checkTableIsView(conn.DB, tableName, conn.Config.Driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if table is view: %w", err)
+	}
+	if isView {
+		definition, err := getViewDefinition(conn, tableName)
+		if err != nil {
+			return nil, err
+		}
+		return &TableDescription{Name: tableName, View: definition}, nil
+	}
+
+	description := &TableDescription{Name: tableName}
+	if description.Columns, err = describeColumns(conn, tableName); err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	if description.PrimaryKey, err = GetPrimaryKeyColumns(conn, tableName); err != nil {
+		return nil, err
+	}
+	if description.ForeignKeys, err = describeForeignKeys(conn, tableName); err != nil {
+		return nil, fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+	if description.Indexes, err = describeIndexes(conn, tableName); err != nil {
+		return nil, fmt.Errorf("failed to get indexes: %w", err)
+	}
+	return description, nil
+}
+
+// mysqlCurrentTimestampRegex matches MySQL default expressions that may be
+// written without parentheses
+var mysqlCurrentTimestampRegex = regexp.MustCompile(`(?i)^(CURRENT_TIMESTAMP|NOW|LOCALTIME|LOCALTIMESTAMP)(\(\d*\))?$`)
+
+// describeColumns returns the columns of a table in order.
+func describeColumns(conn *Connection, tableName string) ([]ColumnDescription, error) {
+	var query string
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		query = `
+			SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES', COLUMN_DEFAULT, EXTRA
+			FROM INFORMATION_SCHEMA.COLUMNS
+			WHERE TABLE_SCHEMA = DATABASE()
+			AND TABLE_NAME = ?
+			ORDER BY ORDINAL_POSITION`
+	case DriverPostgres:
+		// format_type keeps the length and precision of the type, and identity
+		// columns (PostgreSQL 10+) are reported instead of their sequence default
+		query = `
+			SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+				pg_get_expr(d.adbin, d.adrelid), CASE WHEN a.attidentity <> '' THEN 'identity' ELSE '' END
+			FROM pg_attribute a
+			LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE a.attrelid = to_regclass(quote_ident($1))
+			AND a.attnum > 0
+			AND NOT a.attisdropped
+			ORDER BY a.attnum`
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	rows, err := conn.DB.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []ColumnDescription
+	for rows.Next() {
+		var column ColumnDescription
+		var def sql.NullString
+		var extra string
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &def, &extra); err != nil {
+			return nil, err
+		}
+		if conn.Config.Driver == DriverMySQL {
+			describeMySQLColumn(&column, def, extra)
+		} else {
+			describePostgresColumn(&column, def, extra)
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// describeMySQLColumn fills in the default, ON UPDATE and AUTO_INCREMENT
+// settings of a MySQL column from COLUMN_DEFAULT and EXTRA. COLUMN_DEFAULT holds
+// literals unquoted, and expressions only when EXTRA says DEFAULT_GENERATED.
+func describeMySQLColumn(column *ColumnDescription, def sql.NullString, extra string) {
+	upperExtra := strings.ToUpper(extra)
+	column.AutoIncrement = strings.Contains(upperExtra, "AUTO_INCREMENT")
+	if i := strings.Index(upperExtra, "ON UPDATE "); i >= 0 {
+		column.OnUpdate = strings.TrimSpace(extra[i+len("ON UPDATE "):])
+	}
+	if !def.Valid {
+		return
+	}
+	value := def.String
+	switch {
+	case strings.Contains(upperExtra, "DEFAULT_GENERATED"):
+		if !mysqlCurrentTimestampRegex.MatchString(value) {
+			value = "(" + value + ")"
+		}
+	case strings.HasPrefix(value, "b'"):
+		// Bit values are reported as b'...' literals
+	default:
+		value = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	}
+	column.Default = &value
+}
+
+// describePostgresColumn fills in the default of a PostgreSQL column. Serial
+// columns, whose default takes the next value of a sequence, are described as
+// auto-increment columns like identity columns.
+func describePostgresColumn(column *ColumnDescription, def sql.NullString, extra string) {
+	column.AutoIncrement = extra == "identity"
+	if !def.Valid {
+		return
+	}
+	if strings.HasPrefix(def.String, "nextval(") {
+		column.AutoIncrement = true
+		return
+	}
+	value := def.String
+	column.Default = &value
+}
+
+// describeForeignKeys returns the foreign keys of a table ordered by name.
+func describeForeignKeys(conn *Connection, tableName string) ([]ForeignKeyDescription, error) {
+	var query string
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		query = `
+			SELECT CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+			FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+			WHERE TABLE_SCHEMA = DATABASE()
+			AND TABLE_NAME = ?
+			AND REFERENCED_TABLE_NAME IS NOT NULL
+			ORDER BY CONSTRAINT_NAME, ORDINAL_POSITION`
+	case DriverPostgres:
+		query = `
+			SELECT kcu.constraint_name, kcu.column_name, ref.table_name, ref.column_name
+			FROM information_schema.referential_constraints rc
+			JOIN information_schema.key_column_usage kcu
+				ON kcu.constraint_name = rc.constraint_name
+				AND kcu.constraint_schema = rc.constraint_schema
+			JOIN information_schema.key_column_usage ref
+				ON ref.constraint_name = rc.unique_constraint_name
+				AND ref.constraint_schema = rc.unique_constraint_schema
+				AND ref.ordinal_position = kcu.position_in_unique_constraint
+			WHERE kcu.table_name = $1
+			AND kcu.table_schema = current_schema()
+			ORDER BY kcu.constraint_name, kcu.ordinal_position`
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	rows, err := conn.DB.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foreignKeys []ForeignKeyDescription
+	for rows.Next() {
+		var name, column, refTable, refColumn string
+		if err := rows.Scan(&name, &column, &refTable, &refColumn); err != nil {
+			return nil, err
+		}
+		// Rows of a constraint are consecutive, one per column
+		if n := len(foreignKeys); n > 0 && foreignKeys[n-1].Name == name {
+			foreignKeys[n-1].Columns = append(foreignKeys[n-1].Columns, column)
+			foreignKeys[n-1].ReferencedColumns = append(foreignKeys[n-1].ReferencedColumns, refColumn)
+			continue
+		}
+		foreignKeys = append(foreignKeys, ForeignKeyDescription{
+			Name:              name,
+			Columns:           []string{column},
+			ReferencedTable:   refTable,
+			ReferencedColumns: []string{refColumn},
+		})
+	}
+	return foreignKeys, rows.Err()
+}
+
+// describeIndexes returns the secondary indexes of a table ordered by name.
+func describeIndexes(conn *Connection, tableName string) ([]IndexDescription, error) {
+	var query string
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		// COLUMN_NAME is NULL for the key parts of functional indexes
+		query = `
+			SELECT INDEX_NAME, COLUMN_NAME, NON_UNIQUE = 0
+			FROM INFORMATION_SCHEMA.STATISTICS
+			WHERE TABLE_SCHEMA = DATABASE()
+			AND TABLE_NAME = ?
+			AND INDEX_NAME <> 'PRIMARY'
+			ORDER BY INDEX_NAME, SEQ_IN_INDEX`
+	case DriverPostgres:
+		// Indexes on expressions and partial indexes are left out
+		query = `
+			SELECT i.relname, a.attname, ix.indisunique
+			FROM pg_index ix
+			JOIN pg_class i ON i.oid = ix.indexrelid
+			JOIN pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = ANY(ix.indkey)
+			WHERE ix.indrelid = to_regclass(quote_ident($1))
+			AND NOT ix.indisprimary
+			AND ix.indexprs IS NULL
+			AND ix.indpred IS NULL
+			ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum)`
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	rows, err := conn.DB.Query(query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []IndexDescription
+	expression := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		var column sql.NullString
+		var unique bool
+		if err := rows.Scan(&name, &column, &unique); err != nil {
+			return nil, err
+		}
+		if !column.Valid {
+			expression[name] = true
+			continue
+		}
+		if n := len(indexes); n > 0 && indexes[n-1].Name == name {
+			indexes[n-1].Columns = append(indexes[n-1].Columns, column.String)
+			continue
+		}
+		indexes = append(indexes, IndexDescription{Name: name, Columns: []string{column.String}, Unique: unique})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	described := indexes[:0]
+	for _, index := range indexes {
+		if !expression[index.Name] {
+			described = append(described, index)
+		}
+	}
+	return described, nil
+}
+
+// simpleIdentifierRegex matches PostgreSQL identifiers that need no quoting
+var simpleIdentifierRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// schemaIdentifier quotes an identifier for JSONToCreateTable. PostgreSQL
+// identifiers are only quoted when needed, like the definitions of
+// GetTableSchema, so the statements are recognized by the schema import.
+func schemaIdentifier(driver, name string) string {
+	if driver == DriverPostgres && simpleIdentifierRegex.MatchString(name) {
+		return name
+	}
+	return EscapeIdentifier(driver, name)
+}
+
+// schemaIdentifiers quotes and joins a list of column names.
+func schemaIdentifiers(driver string, names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = schemaIdentifier(driver, name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// JSONToCreateTable rebuilds the CREATE TABLE statement of a table described in
+// a schema JSON file, in the dialect of driver, with one definition per line.
+// Secondary indexes are included with withIndexes: as KEY definitions on
+// MySQL, and as UNIQUE constraints for the unique indexes on PostgreSQL, whose
+// CREATE TABLE has no other index syntax. Views return their definition.
+func JSONToCreateTable(driver string, table TableDescription, withIndexes bool) string {
+	if table.View != "" {
+		return table.View
+	}
+
+	var definitions []string
+	for _, column := range table.Columns {
+		definition := schemaIdentifier(driver, column.Name) + " " + column.Type
+		if column.AutoIncrement && driver == DriverPostgres {
+			definition += " GENERATED BY DEFAULT AS IDENTITY"
+		}
+		if !column.Nullable {
+			definition += " NOT NULL"
+		}
+		if column.Default != nil {
+			definition += " DEFAULT " + *column.Default
+		}
+		if column.OnUpdate != "" && driver == DriverMySQL {
+			definition += " ON UPDATE " + column.OnUpdate
+		}
+		if column.AutoIncrement && driver == DriverMySQL {
+			definition += " AUTO_INCREMENT"
+		}
+		definitions = append(definitions, definition)
+	}
+	if len(table.PrimaryKey) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+schemaIdentifiers(driver, table.PrimaryKey)+")")
+	}
+	if withIndexes {
+		for _, index := range table.Indexes {
+			switch {
+			case driver == DriverMySQL && index.Unique:
+				definitions = append(definitions, fmt.Sprintf("UNIQUE KEY %s (%s)", schemaIdentifier(driver, index.Name), schemaIdentifiers(driver, index.Columns)))
+			case driver == DriverMySQL:
+				definitions = append(definitions, fmt.Sprintf("KEY %s (%s)", schemaIdentifier(driver, index.Name), schemaIdentifiers(driver, index.Columns)))
+			case index.Unique:
+				definitions = append(definitions, fmt.Sprintf("CONSTRAINT %s UNIQUE (%s)", schemaIdentifier(driver, index.Name), schemaIdentifiers(driver, index.Columns)))
+			}
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		definitions = append(definitions, fmt.Sprintf("CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s)",
+			schemaIdentifier(driver, fk.Name), schemaIdentifiers(driver, fk.Columns),
+			schemaIdentifier(driver, fk.ReferencedTable), schemaIdentifiers(driver, fk.ReferencedColumns)))
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n  %s\n);", schemaIdentifier(driver, table.Name), strings.Join(definitions, ",\n  "))
 }
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsSpatialType reports whether a column type as returned by
+// getNonVirtualColumns (MySQL DATA_TYPE or PostgreSQL udt_name) holds
+// geometry values.
+func IsSpatialType(columnType string) bool {
+	switch strings.ToLower(columnType) {
+	case "geometry", "point", "linestring", "polygon", "multipoint", "multilinestring",
+		"multipolygon", "geometrycollection", "geomcollection", "geography":
+		return true
+	}
+	return false
+}
+
+// spatialSelectExpr returns the select list entry for a geometry column.
+// MySQL stores geometries in an internal format, so they are exported as
+// "{srid}:{hex WKB}" to keep the SRID along with the exact coordinates.
+// PostGIS already returns geometry and geography values as hex EWKB, which
+// includes the SRID, so they are selected as they are.
+func spatialSelectExpr(driver, column string) string {
+	escaped := EscapeIdentifier(driver, column)
+	if driver != DriverMySQL {
+		return escaped
+	}
+	return fmt.Sprintf("CONCAT(ST_SRID(%s), ':', HEX(ST_AsWKB(%s))) AS %s", escaped, escaped, escaped)
+}
@@ -2,7 +2,9 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 // TableInfo contains information about a database table
@@ -23,7 +25,7 @@ func ListTables(conn *Connection) ([]string, error) {
 		query = `
 			SELECT table_name 
 			FROM information_schema.tables 
-			WHERE table_schema = 'public'
+			WHERE table_schema = current_schema()
 		`
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
@@ -51,6 +53,48 @@ func ListTables(conn *Connection) ([]string, error) {
 	return tables, nil
 }
 
+// GetViews returns the views of the current database or schema, sorted by name
+func GetViews(conn *Connection) ([]string, error) {
+	var query string
+	switch conn.Config.Driver {
+	case DriverMySQL:
+		query = `
+			SELECT table_name
+			FROM information_schema.views
+			WHERE table_schema = DATABASE()
+			ORDER BY table_name`
+	case DriverPostgres:
+		query = `
+			SELECT table_name
+			FROM information_schema.views
+			WHERE table_schema = current_schema()
+			ORDER BY table_name`
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	rows, err := conn.DB.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []string
+	for rows.Next() {
+		var view string
+		if err := rows.Scan(&view); err != nil {
+			return nil, fmt.Errorf("failed to scan view name: %w", err)
+		}
+		views = append(views, view)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating views: %w", err)
+	}
+
+	return views, nil
+}
+
 // GetTableInfo retrieves information about a table
 func GetTableInfo(conn *Connection, tableName string) (*TableInfo, error) {
 	isView, err := checkTableIsView(conn.DB, tableName, conn.Config.Driver)
@@ -76,6 +120,24 @@ func GetTableInfo(conn *Connection, tableName string) (*TableInfo, error) {
 	}, nil
 }
 
+// GetTableEngine returns the storage engine of a MySQL table (e.g. InnoDB, MyISAM)
+func GetTableEngine(conn *Connection, tableName string) (string, error) {
+	if conn.Config.Driver != DriverMySQL {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedDriver, conn.Config.Driver)
+	}
+
+	query := `
+		SELECT ENGINE
+		FROM INFORMATION_SCHEMA.TABLES
+		WHERE TABLE_SCHEMA = DATABASE()
+		AND TABLE_NAME = ?`
+	var engine sql.NullString
+	if err := conn.DB.QueryRow(query, tableName).Scan(&engine); err != nil {
+		return "", fmt.Errorf("failed to get engine of table %s: %w", tableName, err)
+	}
+	return engine.String, nil
+}
+
 // checkTableIsView checks if a table is actually a view
 func checkTableIsView(db *sql.DB, tableName string, driver string) (bool, error) {
 	var query string
@@ -91,7 +153,7 @@ func checkTableIsView(db *sql.DB, tableName string, driver string) (bool, error)
 			SELECT COUNT(*)
 			FROM information_schema.views
 			WHERE table_name = $1
-			AND table_schema = 'public'`
+			AND table_schema = current_schema()`
 	default:
 		return false, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
 	}
@@ -134,7 +196,8 @@ func getTableDependencies(db *sql.DB, tableName string, driver string) ([]string
             JOIN information_schema.constraint_column_usage ccu
                 ON tc.constraint_name = ccu.constraint_name
             WHERE tc.constraint_type = 'FOREIGN KEY'
-            AND tc.table_name = $1`
+            AND tc.table_name = $1
+            AND tc.table_schema = current_schema()`
 	default:
 		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDriver, driver)
 	}
@@ -235,3 +298,104 @@ func TruncateTable(conn *Connection, tableName string) error {
 	}
 	return nil
 }
+
+// ColumnStats holds aggregate statistics of a table column. Min, Max and Avg
+// are set for numeric columns, MaxLength and DistinctCount for string columns.
+type ColumnStats struct {
+	NullCount     int64       `json:"null_count"`
+	Min           json.Number `json:"min,omitempty"`
+	Max           json.Number `json:"max,omitempty"`
+	Avg           *float64    `json:"avg,omitempty"`
+	MaxLength     *int64      `json:"max_length,omitempty"`
+	DistinctCount *int64      `json:"distinct_count,omitempty"`
+}
+
+// Column kinds of GetTableColumnStats
+const (
+	statsKindOther = iota
+	statsKindNumeric
+	statsKindString
+)
+
+// columnStatsKind classifies a MySQL DATA_TYPE or PostgreSQL udt_name
+func columnStatsKind(columnType string) int {
+	switch strings.ToLower(columnType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "decimal", "numeric",
+		"float", "double", "real", "int2", "int4", "int8", "float4", "float8":
+		return statsKindNumeric
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "bpchar":
+		return statsKindString
+	}
+	return statsKindOther
+}
+
+// GetTableColumnStats computes the statistics of every non-generated column
+// of a table with a single aggregate query: the NULL count of every column,
+// MIN, MAX and AVG of numeric columns, and the maximum length and number of
+// distinct values of string columns. The distinct count is exact, so this
+// scans the whole table.
+func GetTableColumnStats(conn *Connection, tableName string) (map[string]ColumnStats, error) {
+	columns, columnTypes, err := getNonVirtualColumns(conn.DB, tableName, conn.Config.Driver, conn.Config.IncludeGenerated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return map[string]ColumnStats{}, nil
+	}
+
+	var selects []string
+	for _, col := range columns {
+		c := EscapeIdentifier(conn.Config.Driver, col)
+		selects = append(selects, fmt.Sprintf("COUNT(*) - COUNT(%s)", c))
+		switch columnStatsKind(columnTypes[col]) {
+		case statsKindNumeric:
+			selects = append(selects, fmt.Sprintf("MIN(%s)", c), fmt.Sprintf("MAX(%s)", c), fmt.Sprintf("AVG(%s)", c))
+		case statsKindString:
+			selects = append(selects, fmt.Sprintf("MAX(CHAR_LENGTH(%s))", c), fmt.Sprintf("COUNT(DISTINCT %s)", c))
+		}
+	}
+	query := fmt.Sprintf("SELECT %s FROM %s", strings.Join(selects, ", "), EscapeIdentifier(conn.Config.Driver, tableName))
+
+	type columnResult struct {
+		nulls              int64
+		minValue, maxValue sql.NullString
+		avg                sql.NullFloat64
+		maxLength          sql.NullInt64
+		distinct           int64
+	}
+	results := make([]columnResult, len(columns))
+	var dest []interface{}
+	for i, col := range columns {
+		r := &results[i]
+		dest = append(dest, &r.nulls)
+		switch columnStatsKind(columnTypes[col]) {
+		case statsKindNumeric:
+			dest = append(dest, &r.minValue, &r.maxValue, &r.avg)
+		case statsKindString:
+			dest = append(dest, &r.maxLength, &r.distinct)
+		}
+	}
+	if err := conn.DB.QueryRow(query).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to compute column statistics of table %s: %w", tableName, err)
+	}
+
+	stats := make(map[string]ColumnStats, len(columns))
+	for i, col := range columns {
+		r := results[i]
+		s := ColumnStats{NullCount: r.nulls}
+		switch columnStatsKind(columnTypes[col]) {
+		case statsKindNumeric:
+			s.Min = json.Number(r.minValue.String)
+			s.Max = json.Number(r.maxValue.String)
+			if r.avg.Valid {
+				avg := r.avg.Float64
+				s.Avg = &avg
+			}
+		case statsKindString:
+			maxLength, distinct := r.maxLength.Int64, r.distinct
+			s.MaxLength, s.DistinctCount = &maxLength, &distinct
+		}
+		stats[col] = s
+	}
+	return stats, nil
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"strings"
+
+	"github.com/hoangnguyenba/syncdb/pkg/parquet"
+)
+
+// TypeMapper maps column types (see DataOperation.ColumnTypes) to the column
+// types of the file formats data is exported to.
+type TypeMapper interface {
+	// ParquetType returns the type of a column in a Parquet data file
+	ParquetType(columnType string) parquet.Type
+}
+
+// DefaultTypeMapper is the TypeMapper for MySQL and PostgreSQL columns.
+// Integers are stored as INT64, floating point numbers as DOUBLE, dates and
+// timestamps as DATE and TIMESTAMP (microseconds) and binary columns as
+// BYTE_ARRAY. Every other type, including DECIMAL and JSON, is stored as a
+// UTF-8 string so that no precision is lost.
+type DefaultTypeMapper struct{}
+
+// ParquetType implements TypeMapper.
+func (DefaultTypeMapper) ParquetType(columnType string) parquet.Type {
+	switch strings.ToLower(columnType) {
+	case "bool", "boolean":
+		return parquet.BooleanType
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "year",
+		"int2", "int4", "int8":
+		return parquet.Int64Type
+	case "float", "double", "real", "float4", "float8":
+		return parquet.DoubleType
+	case "date":
+		return parquet.DateType
+	case "datetime", "timestamp", "timestamptz":
+		return parquet.TimestampType
+	}
+	if IsBinaryType(columnType) {
+		return parquet.BytesType
+	}
+	return parquet.StringType
+}
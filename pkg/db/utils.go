@@ -3,6 +3,7 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -12,12 +13,46 @@ const (
 	SchemaPostgres = "public"
 )
 
+// schemaNameRegex matches unquoted PostgreSQL identifiers accepted for --pg-schema
+var schemaNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+
 // Common database placeholders
 const (
 	PlaceholderMySQL    = "?"
 	PlaceholderPostgres = "$%d"
 )
 
+// searchPathOption returns the PostgreSQL DSN option that selects schema as the
+// search_path. Unqualified table names in queries and data files then resolve
+// to that schema, and current_schema() returns it in catalog queries.
+func searchPathOption(schema string) string {
+	if schema == "" || schema == SchemaPostgres {
+		return ""
+	}
+	return fmt.Sprintf(" search_path=%s", schema)
+}
+
+// ValidateSchemaName checks that a PostgreSQL schema name is a plain identifier.
+func ValidateSchemaName(schema string) error {
+	if !schemaNameRegex.MatchString(schema) {
+		return fmt.Errorf("invalid PostgreSQL schema name %q", schema)
+	}
+	return nil
+}
+
+// EnsureSchema creates the connection's PostgreSQL schema if it does not exist.
+// It is a no-op for MySQL and for the public schema.
+func EnsureSchema(conn *Connection) error {
+	schema := conn.Config.Schema
+	if conn.Config.Driver != DriverPostgres || schema == "" || schema == SchemaPostgres {
+		return nil
+	}
+	if _, err := conn.DB.Exec(fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", EscapeIdentifier(DriverPostgres, schema))); err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+	return nil
+}
+
 // GetDriverConfig returns the configuration for a specific database driver
 func GetDriverConfig(driver string) (schema, placeholder string, err error) {
 	switch driver {
@@ -30,18 +65,163 @@ func GetDriverConfig(driver string) (schema, placeholder string, err error) {
 	}
 }
 
+// Quoting styles of identifiers in exported SQL
+const (
+	QuoteStyleAuto        = "auto"         // The driver's own quotes
+	QuoteStyleBacktick    = "backtick"     // `name`
+	QuoteStyleDoubleQuote = "double-quote" // "name"
+	QuoteStyleNone        = "none"         // No quoting
+)
+
+// ValidateQuoteStyle returns an error unless style is one of the QuoteStyle
+// values. An empty style means QuoteStyleAuto.
+func ValidateQuoteStyle(style string) error {
+	switch style {
+	case "", QuoteStyleAuto, QuoteStyleBacktick, QuoteStyleDoubleQuote, QuoteStyleNone:
+		return nil
+	}
+	return fmt.Errorf("invalid identifier quoting %q (valid values: %s, %s, %s, %s)", style,
+		QuoteStyleAuto, QuoteStyleBacktick, QuoteStyleDoubleQuote, QuoteStyleNone)
+}
+
 // EscapeIdentifier escapes a database identifier based on the driver
 func EscapeIdentifier(driver, identifier string) string {
-	switch driver {
-	case DriverMySQL:
+	return QuoteIdentifier(driver, QuoteStyleAuto, identifier)
+}
+
+// QuoteIdentifier quotes an identifier with a quoting style. QuoteStyleAuto
+// uses backticks for MySQL and double quotes for PostgreSQL; QuoteStyleNone
+// leaves the identifier as is, so it must not be a reserved word or contain
+// special characters.
+func QuoteIdentifier(driver, style, identifier string) string {
+	switch quoteChar(driver, style) {
+	case '`':
 		return fmt.Sprintf("`%s`", identifier)
-	case DriverPostgres:
+	case '"':
 		return fmt.Sprintf(`"%s"`, identifier)
 	default:
 		return identifier
 	}
 }
 
+// quoteChar returns the quote character of a quoting style, or 0 for none.
+func quoteChar(driver, style string) byte {
+	switch style {
+	case QuoteStyleBacktick:
+		return '`'
+	case QuoteStyleDoubleQuote:
+		return '"'
+	case QuoteStyleNone:
+		return 0
+	}
+	switch driver {
+	case DriverMySQL:
+		return '`'
+	case DriverPostgres:
+		return '"'
+	default:
+		return 0
+	}
+}
+
+// RequoteIdentifiersStyle rewrites the identifiers of SQL written with the
+// driver's own quotes in another quoting style. String literals are left
+// untouched.
+func RequoteIdentifiersStyle(driver, style, sql string) string {
+	from, to := quoteChar(driver, QuoteStyleAuto), quoteChar(driver, style)
+	if from == 0 || from == to {
+		return sql
+	}
+	return requoteIdentifiers(sql, from, to)
+}
+
+// ANSIQuoteIdentifiers rewrites the backtick-quoted identifiers of MySQL SQL
+// as double-quoted identifiers, the syntax MySQL accepts with the ANSI_QUOTES
+// SQL mode. String literals are left untouched.
+func ANSIQuoteIdentifiers(sql string) string {
+	return requoteIdentifiers(sql, '`', '"')
+}
+
+// BacktickQuoteIdentifiers rewrites the double-quoted identifiers of MySQL SQL
+// written for the ANSI_QUOTES SQL mode as backtick-quoted identifiers, so it
+// runs without that mode. String literals are left untouched.
+func BacktickQuoteIdentifiers(sql string) string {
+	return requoteIdentifiers(sql, '"', '`')
+}
+
+// requoteIdentifiers replaces the from quotes around identifiers with to
+// quotes, skipping -- comments and single-quoted string literals with MySQL
+// escapes. A quote character inside an identifier is escaped by doubling it.
+// With to set to 0 the quotes are removed.
+func requoteIdentifiers(sql string, from, to byte) string {
+	var out strings.Builder
+	out.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch c {
+		case '\'':
+			// Copy the string literal, including '' and backslash escapes
+			end := i + 1
+			for end < len(sql) {
+				if sql[end] == '\\' && end+1 < len(sql) {
+					end += 2
+					continue
+				}
+				if sql[end] == '\'' {
+					if end+1 < len(sql) && sql[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end < len(sql) {
+				end++ // Closing quote
+			}
+			out.WriteString(sql[i:end])
+			i = end - 1
+		case '-':
+			// Copy -- comments to the end of the line, they may hold quotes
+			if i+1 >= len(sql) || sql[i+1] != '-' {
+				out.WriteByte(c)
+				continue
+			}
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			out.WriteString(sql[i : i+end])
+			i += end - 1
+		case from:
+			var name strings.Builder
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == from {
+					if j+1 < len(sql) && sql[j+1] == from {
+						name.WriteByte(from)
+						j++
+						continue
+					}
+					break
+				}
+				name.WriteByte(sql[j])
+			}
+			if to == 0 {
+				out.WriteString(name.String())
+			} else {
+				out.WriteByte(to)
+				out.WriteString(strings.ReplaceAll(name.String(), string(to), string([]byte{to, to})))
+				out.WriteByte(to)
+			}
+			i = j
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.String()
+}
+
 // BuildPlaceholders creates a string of placeholders for SQL queries
 func BuildPlaceholders(driver string, count int) string {
 	switch driver {
@@ -137,24 +317,66 @@ func SanitizeSQL(input string) string {
 	return result
 }
 
-// TablePatternMatch returns true if the tableName matches the pattern with '*' wildcard support.
-// Only supports '*' as prefix or suffix (not in the middle).
+// TablePatternMatch returns true if the tableName matches the pattern. Glob
+// patterns use '*' for any sequence of characters and '?' for a single
+// character; patterns without either of them are SQL LIKE patterns, using '%'
+// and '_' instead, with '\' escaping a literal '%' or '_'. A pattern with both
+// styles of wildcards is a glob pattern, in which '%' and '_' are literal.
 func TablePatternMatch(tableName, pattern string) bool {
-	if pattern == "*" {
-		return true
-	}
-	if strings.HasPrefix(pattern, "*") && strings.HasSuffix(pattern, "*") {
-		// *foo* matches substring
-		needle := pattern[1 : len(pattern)-1]
-		return strings.Contains(tableName, needle)
-	} else if strings.HasPrefix(pattern, "*") {
-		// *foo matches suffix
-		needle := pattern[1:]
-		return strings.HasSuffix(tableName, needle)
-	} else if strings.HasSuffix(pattern, "*") {
-		// foo* matches prefix
-		needle := pattern[:len(pattern)-1]
-		return strings.HasPrefix(tableName, needle)
-	}
-	return tableName == pattern
+	if strings.ContainsAny(pattern, "*?") {
+		return wildcardMatch(tableName, pattern, '*', '?', false)
+	}
+	return wildcardMatch(tableName, pattern, '%', '_', true)
+}
+
+// MixedTablePattern reports whether a table pattern has both glob ('*', '?')
+// and SQL LIKE ('%') wildcards, in which case '%' is matched literally. An
+// underscore is not taken as a sign of mixing, as glob patterns such as
+// "audit_*" commonly hold one as part of the table name.
+func MixedTablePattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?") && strings.Contains(pattern, "%")
+}
+
+// wildcardMatch matches name against a pattern where many matches any
+// sequence of characters and one a single character. With escape, a
+// backslash makes the next character of the pattern literal.
+func wildcardMatch(name, pattern string, many, one rune, escape bool) bool {
+	n, p := []rune(name), []rune(pattern)
+	// Position in the pattern after the last 'many' wildcard and the name
+	// position it is retried from, for backtracking
+	star, retry := -1, 0
+	i, j := 0, 0
+	for i < len(n) {
+		if j < len(p) {
+			switch {
+			case p[j] == many:
+				star, retry = j+1, i
+				j++
+				continue
+			case p[j] == one:
+				i++
+				j++
+				continue
+			case escape && p[j] == '\\' && j+1 < len(p):
+				if p[j+1] == n[i] {
+					i++
+					j += 2
+					continue
+				}
+			case p[j] == n[i]:
+				i++
+				j++
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		retry++
+		i, j = retry, star
+	}
+	for j < len(p) && p[j] == many {
+		j++
+	}
+	return j == len(p)
 }
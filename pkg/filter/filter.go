@@ -0,0 +1,100 @@
+// Package filter reads filter files, which select the tables of an export and
+// the rows exported from them.
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FilterSpec is the content of a filter file. Table names may be patterns,
+// as accepted by --tables and --exclude-table.
+type FilterSpec struct {
+	Include       []string          `json:"include,omitempty" yaml:"include,omitempty"`               // Tables exported (--tables)
+	Exclude       []string          `json:"exclude,omitempty" yaml:"exclude,omitempty"`               // Tables left out (--exclude-table)
+	ExcludeSchema []string          `json:"exclude_schema,omitempty" yaml:"exclude_schema,omitempty"` // Tables exported without schema (--exclude-table-schema)
+	ExcludeData   []string          `json:"exclude_data,omitempty" yaml:"exclude_data,omitempty"`     // Tables exported without data (--exclude-table-data)
+	Conditions    map[string]string `json:"conditions,omitempty" yaml:"conditions,omitempty"`         // Per-table WHERE conditions
+}
+
+// ParseFilterFile reads and validates the filter file at path.
+func ParseFilterFile(path string) (*FilterSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filter file: %v", err)
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter file %s: %v", path, err)
+	}
+	return spec, nil
+}
+
+// Parse decodes and validates a filter spec. Unknown keys are rejected, so
+// that a misspelled rule is not silently ignored.
+func Parse(data []byte) (*FilterSpec, error) {
+	spec := &FilterSpec{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// Validate checks that the table names and conditions of the spec are not
+// empty and that the table patterns are well formed.
+func (s *FilterSpec) Validate() error {
+	lists := []struct {
+		key    string
+		tables []string
+	}{
+		{"include", s.Include},
+		{"exclude", s.Exclude},
+		{"exclude_schema", s.ExcludeSchema},
+		{"exclude_data", s.ExcludeData},
+	}
+	for _, list := range lists {
+		for _, table := range list.tables {
+			if err := validateTable(table); err != nil {
+				return fmt.Errorf("%s: %v", list.key, err)
+			}
+		}
+	}
+	for table, condition := range s.Conditions {
+		if err := validateTable(table); err != nil {
+			return fmt.Errorf("conditions: %v", err)
+		}
+		if strings.TrimSpace(condition) == "" {
+			return fmt.Errorf("conditions: empty condition for table %q", table)
+		}
+	}
+	return nil
+}
+
+// IsEmpty reports whether the spec has no rules.
+func (s *FilterSpec) IsEmpty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0 && len(s.ExcludeSchema) == 0 &&
+		len(s.ExcludeData) == 0 && len(s.Conditions) == 0
+}
+
+// validateTable checks a table name or pattern.
+func validateTable(table string) error {
+	if strings.TrimSpace(table) == "" {
+		return fmt.Errorf("empty table name")
+	}
+	if _, err := path.Match(table, ""); err != nil {
+		return fmt.Errorf("invalid table pattern %q: %v", table, err)
+	}
+	return nil
+}
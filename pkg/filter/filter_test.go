@@ -0,0 +1,77 @@
+package filter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter.yaml")
+	content := `include: [orders, users]
+exclude: [audit_log, temp_*]
+exclude_schema: [big_table]
+exclude_data: [config_table]
+conditions:
+  orders: "status = 'active'"
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	spec, err := ParseFilterFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, &FilterSpec{
+		Include:       []string{"orders", "users"},
+		Exclude:       []string{"audit_log", "temp_*"},
+		ExcludeSchema: []string{"big_table"},
+		ExcludeData:   []string{"config_table"},
+		Conditions:    map[string]string{"orders": "status = 'active'"},
+	}, spec)
+	assert.False(t, spec.IsEmpty())
+
+	// Recorded in the export metadata with the keys of the file
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"include":["orders","users"],"exclude":["audit_log","temp_*"],"exclude_schema":["big_table"],
+		"exclude_data":["config_table"],"conditions":{"orders":"status = 'active'"}}`, string(data))
+
+	_, err = ParseFilterFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read filter file")
+}
+
+func TestParse(t *testing.T) {
+	t.Run("Empty file", func(t *testing.T) {
+		spec, err := Parse(nil)
+		require.NoError(t, err)
+		assert.True(t, spec.IsEmpty())
+	})
+
+	t.Run("Some rules", func(t *testing.T) {
+		spec, err := Parse([]byte("exclude:\n  - logs_%\n"))
+		require.NoError(t, err)
+		assert.Equal(t, &FilterSpec{Exclude: []string{"logs_%"}}, spec)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"Unknown key", "excludes: [logs]\n", "field excludes not found"},
+		{"Not a list", "include: orders_and_users\n", "cannot unmarshal"},
+		{"Invalid YAML", "include: [orders\n", "did not find expected"},
+		{"Empty table name", "exclude_data: ['']\n", "exclude_data: empty table name"},
+		{"Invalid pattern", "exclude: ['temp_[']\n", `exclude: invalid table pattern "temp_["`},
+		{"Empty condition", "conditions:\n  orders: ' '\n", `empty condition for table "orders"`},
+		{"Invalid condition table", "conditions:\n  '': id > 1\n", "conditions: empty table name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+// Package lock provides an exclusive, advisory lock on a file, used to keep
+// concurrent syncdb runs from writing to the same directory.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrLocked is returned when the lock is held by another process or FileLock.
+var ErrLocked = errors.New("lock is held by another process")
+
+// retryInterval is how often TryLockFor retries while waiting for the lock.
+const retryInterval = 100 * time.Millisecond
+
+// FileLock is an exclusive lock on a file. The file is created if needed and
+// kept after Unlock, since removing it would race with other processes that
+// opened it in the meantime.
+type FileLock struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+}
+
+// New returns a FileLock for path. The lock is not acquired.
+func New(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// Path returns the path of the lock file.
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// Lock acquires the lock, blocking until it is available.
+func (l *FileLock) Lock() error {
+	return l.acquire(true)
+}
+
+// TryLock acquires the lock without blocking. It returns false if the lock is
+// held elsewhere.
+func (l *FileLock) TryLock() (bool, error) {
+	err := l.acquire(false)
+	if errors.Is(err, ErrLocked) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// TryLockFor retries TryLock until the lock is acquired or timeout elapses.
+// A zero timeout tries exactly once.
+func (l *FileLock) TryLockFor(timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		locked, err := l.TryLock()
+		if locked || err != nil {
+			return locked, err
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false, nil
+		}
+		time.Sleep(min(retryInterval, remaining))
+	}
+}
+
+// Unlock releases the lock. Unlocking a FileLock that is not locked is a no-op.
+func (l *FileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to release lock %s: %w", l.path, err)
+	}
+	return nil
+}
+
+func (l *FileLock) acquire(blocking bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return fmt.Errorf("lock %s is already held by this FileLock", l.path)
+	}
+
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file %s: %w", l.path, err)
+	}
+	if err := lockFile(file, blocking); err != nil {
+		file.Close()
+		if errors.Is(err, ErrLocked) {
+			return err
+		}
+		return fmt.Errorf("failed to lock %s: %w", l.path, err)
+	}
+
+	// Record the owner to help diagnose stale or contended locks
+	if err := file.Truncate(0); err == nil {
+		fmt.Fprintf(file, "%d\n", os.Getpid())
+	}
+	l.file = file
+	return nil
+}
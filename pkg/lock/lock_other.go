@@ -0,0 +1,16 @@
+//go:build !unix && !windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(file *os.File, blocking bool) error {
+	return errors.ErrUnsupported
+}
+
+func unlockFile(file *os.File) error {
+	return errors.ErrUnsupported
+}
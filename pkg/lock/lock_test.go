@@ -0,0 +1,104 @@
+package lock
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".syncdb.lock")
+
+	t.Run("Second lock fails while the first is held", func(t *testing.T) {
+		first, second := New(path), New(path)
+		locked, err := first.TryLock()
+		require.NoError(t, err)
+		require.True(t, locked)
+
+		locked, err = second.TryLock()
+		require.NoError(t, err)
+		assert.False(t, locked)
+
+		start := time.Now()
+		locked, err = second.TryLockFor(250 * time.Millisecond)
+		require.NoError(t, err)
+		assert.False(t, locked)
+		assert.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond)
+
+		require.NoError(t, first.Unlock())
+		locked, err = second.TryLock()
+		require.NoError(t, err)
+		assert.True(t, locked)
+		require.NoError(t, second.Unlock())
+	})
+
+	t.Run("Lock blocks until the holder unlocks", func(t *testing.T) {
+		first, second := New(path), New(path)
+		require.NoError(t, first.Lock())
+
+		acquired := make(chan struct{})
+		go func() {
+			defer close(acquired)
+			assert.NoError(t, second.Lock())
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("second lock acquired while the first was held")
+		case <-time.After(100 * time.Millisecond):
+		}
+		require.NoError(t, first.Unlock())
+		select {
+		case <-acquired:
+		case <-time.After(5 * time.Second):
+			t.Fatal("second lock was not acquired after unlock")
+		}
+		require.NoError(t, second.Unlock())
+	})
+
+	t.Run("Only one of many concurrent TryLock calls succeeds", func(t *testing.T) {
+		const workers = 10
+		locks := make([]*FileLock, workers)
+		results := make([]bool, workers)
+		var wg sync.WaitGroup
+		for i := range locks {
+			locks[i] = New(path)
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				locked, err := locks[i].TryLock()
+				assert.NoError(t, err)
+				results[i] = locked
+			}(i)
+		}
+		wg.Wait()
+
+		held := 0
+		for i, locked := range results {
+			if locked {
+				held++
+				require.NoError(t, locks[i].Unlock())
+			}
+		}
+		assert.Equal(t, 1, held)
+	})
+
+	t.Run("TryLockFor waits for the lock to be released", func(t *testing.T) {
+		first, second := New(path), New(path)
+		require.NoError(t, first.Lock())
+		time.AfterFunc(150*time.Millisecond, func() { first.Unlock() })
+
+		locked, err := second.TryLockFor(5 * time.Second)
+		require.NoError(t, err)
+		assert.True(t, locked)
+		require.NoError(t, second.Unlock())
+	})
+
+	t.Run("Unlock without lock is a no-op", func(t *testing.T) {
+		assert.NoError(t, New(path).Unlock())
+	})
+}
@@ -0,0 +1,78 @@
+// Package notify sends notifications about finished exports and imports.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Events reported to webhooks
+const (
+	EventExportComplete = "export_complete"
+	EventExportFailed   = "export_failed"
+	EventImportComplete = "import_complete"
+	EventImportFailed   = "import_failed"
+)
+
+// DefaultWebhookTimeout is the default timeout of a webhook request.
+const DefaultWebhookTimeout = 10 * time.Second
+
+// Payload is the JSON body sent to webhooks.
+type Payload struct {
+	Event           string  `json:"event"`
+	Database        string  `json:"database"`
+	TablesCount     int     `json:"tables_count"`
+	Records         int64   `json:"records"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// NotifyWebhook sends payload as JSON to url. Any response status outside the
+// 2xx range is returned as an error.
+func NotifyWebhook(url, method string, headers map[string]string, payload interface{}, timeout time.Duration) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %v", err)
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) // Drain the body so the connection can be reused
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// ParseHeaders parses headers given as "Name: value" into a map.
+func ParseHeaders(headers []string) (map[string]string, error) {
+	parsed := make(map[string]string, len(headers))
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid webhook header %q: expected \"Name: value\"", header)
+		}
+		parsed[name] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	var received Payload
+	var authHeader, contentType, method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		authHeader = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	payload := Payload{Event: EventExportComplete, Database: "shop", TablesCount: 3, Records: 42, DurationSeconds: 1.5}
+	err := NotifyWebhook(server.URL, http.MethodPut, map[string]string{"Authorization": "Bearer token"}, payload, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "Bearer token", authHeader)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, payload, received)
+}
+
+func TestNotifyWebhookErrors(t *testing.T) {
+	t.Run("Error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		err := NotifyWebhook(server.URL, http.MethodPost, nil, Payload{}, time.Second)
+		assert.ErrorContains(t, err, "500")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		done := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-done
+		}))
+		defer server.Close()
+		defer close(done)
+
+		err := NotifyWebhook(server.URL, http.MethodPost, nil, Payload{}, 50*time.Millisecond)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := ParseHeaders([]string{"Authorization: Bearer a:b", "X-Team:data "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer a:b", "X-Team": "data"}, headers)
+
+	_, err = ParseHeaders([]string{"no-colon"})
+	assert.Error(t, err)
+}
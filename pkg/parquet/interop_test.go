@@ -0,0 +1,149 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests check the package against Apache Arrow's Parquet implementation
+// through pyarrow. They are skipped when python3 or pyarrow is not installed.
+
+// pyarrowReadScript prints the rows of a Parquet file as JSON, with binary
+// values hex encoded, dates as days and timestamps as microseconds since the
+// epoch, followed by the Arrow type of each column.
+const pyarrowReadScript = `
+import json, sys
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+table = pq.read_table(sys.argv[1])
+types = [str(field.type) for field in table.schema]
+columns = []
+for column in table.columns:
+    if pa.types.is_timestamp(column.type):
+        column = column.cast(pa.int64())
+    elif pa.types.is_date32(column.type):
+        column = column.cast(pa.int32())
+    columns.append([v.hex() if isinstance(v, bytes) else v for v in column.to_pylist()])
+rows = [list(row) for row in zip(*columns)]
+print(json.dumps(types, separators=(",", ":")))
+print(json.dumps(rows, separators=(",", ":"), ensure_ascii=False))
+`
+
+// pyarrowWriteScript writes a PLAIN encoded, uncompressed Parquet file, the
+// subset of the format that Read supports.
+const pyarrowWriteScript = `
+import datetime, sys
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+table = pa.table({
+    "id": pa.array([1, None, 9007199254740993], pa.int64()),
+    "name": pa.array(["Alice", None, "Ω"], pa.string()),
+    "active": pa.array([True, False, None], pa.bool_()),
+    "score": pa.array([9.5, None, -1.25], pa.float64()),
+    "data": pa.array([b"\x00\xff", None, b""], pa.binary()),
+    "birthday": pa.array([datetime.date(1990, 5, 1), None, datetime.date(1960, 1, 1)], pa.date32()),
+})
+pq.write_table(table, sys.argv[1], compression="NONE", use_dictionary=False,
+               data_page_version="1.0", write_statistics=False)
+`
+
+// requirePyArrow skips the test unless python3 can import pyarrow.
+func requirePyArrow(t *testing.T) {
+	t.Helper()
+	if err := exec.Command("python3", "-c", "import pyarrow.parquet").Run(); err != nil {
+		t.Skip("python3 with pyarrow is not available")
+	}
+}
+
+func runPython(t *testing.T, script string, args ...string) []byte {
+	t.Helper()
+	cmd := exec.Command("python3", append([]string{"-c", script}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	require.NoError(t, err, stderr.String())
+	return out
+}
+
+func TestPyArrowReadsWriterOutput(t *testing.T) {
+	requirePyArrow(t)
+
+	columns := []Column{
+		{Name: "id", Type: Int64Type},
+		{Name: "name", Type: StringType},
+		{Name: "active", Type: BooleanType},
+		{Name: "score", Type: DoubleType},
+		{Name: "data", Type: BytesType},
+		{Name: "birthday", Type: DateType},
+		{Name: "created_at", Type: TimestampType},
+	}
+	created := time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC)
+	input := [][]interface{}{
+		{int64(1), "Alice", true, 9.5, []byte{0x00, 0xff}, time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), created},
+		{nil, nil, false, nil, nil, nil, nil},
+		{int64(9007199254740993), "Ω", nil, -1.25, []byte{}, time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), created.Add(time.Hour)},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns)
+	require.NoError(t, err)
+	w.RowGroupSize = 2
+	for _, row := range input {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+	path := filepath.Join(t.TempDir(), "data.parquet")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+
+	lines := bytes.Split(bytes.TrimSpace(runPython(t, pyarrowReadScript, path)), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var types []string
+	require.NoError(t, json.Unmarshal(lines[0], &types))
+	require.Len(t, types, len(columns))
+	assert.Equal(t, []string{"int64", "string", "bool", "double", "binary", "date32[day]"}, types[:6])
+	assert.Contains(t, types[6], "timestamp[us")
+
+	expected, err := json.Marshal([][]interface{}{
+		{int64(1), "Alice", true, 9.5, "00ff", 7425, created.UnixMicro()},
+		{nil, nil, false, nil, nil, nil, nil},
+		{int64(9007199254740993), "Ω", nil, -1.25, "", -3653, created.Add(time.Hour).UnixMicro()},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(lines[1]))
+}
+
+func TestReadPyArrowOutput(t *testing.T) {
+	requirePyArrow(t)
+
+	path := filepath.Join(t.TempDir(), "data.parquet")
+	runPython(t, pyarrowWriteScript, path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	columns, rows, err := Read(data)
+	require.NoError(t, err)
+	assert.Equal(t, []Column{
+		{Name: "id", Type: Int64Type},
+		{Name: "name", Type: StringType},
+		{Name: "active", Type: BooleanType},
+		{Name: "score", Type: DoubleType},
+		{Name: "data", Type: BytesType},
+		{Name: "birthday", Type: DateType},
+	}, columns)
+	assert.Equal(t, [][]interface{}{
+		{int64(1), []byte("Alice"), true, 9.5, []byte{0x00, 0xff}, int32(7425)},
+		{nil, nil, false, nil, nil, nil},
+		{int64(9007199254740993), []byte("Ω"), nil, -1.25, []byte{}, int32(-3653)},
+	}, rows)
+}
@@ -0,0 +1,376 @@
+// Package parquet reads and writes Apache Parquet files with a flat schema of
+// optional columns. Data pages are PLAIN encoded and uncompressed, which every
+// Parquet reader supports; files using other encodings or compression codecs
+// cannot be read.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// magic starts and ends every Parquet file
+const magic = "PAR1"
+
+// PhysicalType is the Parquet type values are stored as
+type PhysicalType int32
+
+// Physical types, numbered as in the Parquet format
+const (
+	Boolean   PhysicalType = 0
+	Int32     PhysicalType = 1
+	Int64     PhysicalType = 2
+	Float     PhysicalType = 4
+	Double    PhysicalType = 5
+	ByteArray PhysicalType = 6
+)
+
+// LogicalType tells how the values of a physical type are interpreted
+type LogicalType int
+
+// Logical types supported by syncdb
+const (
+	LogicalNone            LogicalType = iota
+	LogicalString                      // UTF-8 text stored as ByteArray
+	LogicalDate                        // Days since 1970-01-01 stored as Int32
+	LogicalTimestampMillis             // UTC milliseconds since the epoch stored as Int64
+	LogicalTimestampMicros             // UTC microseconds since the epoch stored as Int64
+)
+
+// Type is the physical and logical type of a column
+type Type struct {
+	Physical PhysicalType
+	Logical  LogicalType
+}
+
+// Column types written by syncdb
+var (
+	BooleanType   = Type{Physical: Boolean}
+	Int64Type     = Type{Physical: Int64}
+	DoubleType    = Type{Physical: Double}
+	StringType    = Type{Physical: ByteArray, Logical: LogicalString}
+	BytesType     = Type{Physical: ByteArray}
+	DateType      = Type{Physical: Int32, Logical: LogicalDate}
+	TimestampType = Type{Physical: Int64, Logical: LogicalTimestampMicros}
+)
+
+// Column is a column of a Parquet file. All columns are optional, so their
+// values may be nil.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Converted types and encodings, numbered as in the Parquet format
+const (
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	repetitionRequired = 0
+	repetitionOptional = 1
+
+	pageTypeData = 0
+)
+
+// DefaultRowGroupSize is the number of rows per row group of a Writer
+const DefaultRowGroupSize = 10000
+
+// Writer writes rows to a Parquet file. Rows are buffered and written as a
+// row group every RowGroupSize rows; Close writes the last row group and the
+// file footer.
+type Writer struct {
+	RowGroupSize int
+
+	w         io.Writer
+	columns   []Column
+	pos       int64
+	rows      [][]interface{}
+	numRows   int64
+	rowGroups [][]byte // Encoded RowGroup metadata
+	closed    bool
+}
+
+// NewWriter starts a Parquet file with the given columns on w.
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("parquet: no columns")
+	}
+	pw := &Writer{RowGroupSize: DefaultRowGroupSize, w: w, columns: columns}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *Writer) write(data []byte) error {
+	n, err := pw.w.Write(data)
+	pw.pos += int64(n)
+	return err
+}
+
+// Write adds a row, holding one value per column: nil, or a bool, int32,
+// int64, float64, string or []byte matching the column's physical type. Date
+// and timestamp columns also take a time.Time.
+func (pw *Writer) Write(row []interface{}) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("parquet: row has %d values, expected %d", len(row), len(pw.columns))
+	}
+	pw.rows = append(pw.rows, row)
+	if len(pw.rows) >= pw.RowGroupSize {
+		return pw.flush()
+	}
+	return nil
+}
+
+// flush writes the buffered rows as a row group.
+func (pw *Writer) flush() error {
+	if len(pw.rows) == 0 {
+		return nil
+	}
+	var meta compactWriter
+	meta.structBegin()
+	meta.listField(1, compactStruct, len(pw.columns))
+	var totalSize int64
+	for c, col := range pw.columns {
+		page, err := encodePage(col, pw.rows, c)
+		if err != nil {
+			return err
+		}
+		var header compactWriter
+		header.structBegin()
+		header.i32Field(1, pageTypeData)
+		header.i32Field(2, int32(len(page)))
+		header.i32Field(3, int32(len(page)))
+		header.structField(5)
+		header.i32Field(1, int32(len(pw.rows)))
+		header.i32Field(2, encodingPlain)
+		header.i32Field(3, encodingRLE)
+		header.i32Field(4, encodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		offset := pw.pos
+		if err := pw.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(page); err != nil {
+			return err
+		}
+		size := int64(header.buf.Len() + len(page))
+		totalSize += size
+
+		// ColumnChunk with its ColumnMetaData
+		meta.structBegin()
+		meta.i64Field(2, offset)
+		meta.structField(3)
+		meta.i32Field(1, int32(col.Type.Physical))
+		meta.listField(2, compactI32, 2)
+		meta.i32Value(encodingPlain)
+		meta.i32Value(encodingRLE)
+		meta.listField(3, compactBinary, 1)
+		meta.stringValue(col.Name)
+		meta.i32Field(4, 0) // Uncompressed
+		meta.i64Field(5, int64(len(pw.rows)))
+		meta.i64Field(6, size)
+		meta.i64Field(7, size)
+		meta.i64Field(9, offset)
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.i64Field(2, totalSize)
+	meta.i64Field(3, int64(len(pw.rows)))
+	meta.structEnd()
+
+	pw.rowGroups = append(pw.rowGroups, meta.buf.Bytes())
+	pw.numRows += int64(len(pw.rows))
+	pw.rows = pw.rows[:0]
+	return nil
+}
+
+// Close writes the remaining rows and the file footer. It does not close the
+// underlying writer.
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return nil
+	}
+	pw.closed = true
+	if err := pw.flush(); err != nil {
+		return err
+	}
+
+	var meta compactWriter
+	meta.structBegin()
+	meta.i32Field(1, 1)
+	meta.listField(2, compactStruct, len(pw.columns)+1)
+	meta.structBegin()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(pw.columns)))
+	meta.structEnd()
+	for _, col := range pw.columns {
+		writeSchemaElement(&meta, col)
+	}
+	meta.i64Field(3, pw.numRows)
+	meta.listField(4, compactStruct, len(pw.rowGroups))
+	for _, rowGroup := range pw.rowGroups {
+		meta.buf.Write(rowGroup)
+	}
+	meta.stringField(6, "syncdb")
+	meta.structEnd()
+
+	footer := meta.buf.Bytes()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	return pw.write(append(footer, magic...))
+}
+
+// writeSchemaElement writes the SchemaElement of a column, with both the
+// converted type and the logical type of the column so that older and newer
+// readers understand it.
+func writeSchemaElement(meta *compactWriter, col Column) {
+	meta.structBegin()
+	meta.i32Field(1, int32(col.Type.Physical))
+	meta.i32Field(3, repetitionOptional)
+	meta.stringField(4, col.Name)
+	switch col.Type.Logical {
+	case LogicalString:
+		meta.i32Field(6, convertedUTF8)
+		meta.structField(10)
+		meta.structField(1) // STRING
+		meta.structEnd()
+		meta.structEnd()
+	case LogicalDate:
+		meta.i32Field(6, convertedDate)
+		meta.structField(10)
+		meta.structField(6) // DATE
+		meta.structEnd()
+		meta.structEnd()
+	case LogicalTimestampMillis, LogicalTimestampMicros:
+		converted, unit := int32(convertedTimestampMicros), int16(2)
+		if col.Type.Logical == LogicalTimestampMillis {
+			converted, unit = convertedTimestampMillis, 1
+		}
+		meta.i32Field(6, converted)
+		meta.structField(10)
+		meta.structField(8) // TIMESTAMP
+		meta.boolField(1, true)
+		meta.structField(2)
+		meta.structField(unit)
+		meta.structEnd()
+		meta.structEnd()
+		meta.structEnd()
+		meta.structEnd()
+	}
+	meta.structEnd()
+}
+
+// encodePage encodes the values of column c of rows as a data page: the
+// RLE encoded definition levels followed by the PLAIN encoded non-null values.
+func encodePage(col Column, rows [][]interface{}, c int) ([]byte, error) {
+	var levels, values bytes.Buffer
+	var bits []bool
+	var run byte
+	runLength := 0
+	flushRun := func() {
+		if runLength > 0 {
+			levels.Write(binary.AppendUvarint(nil, uint64(runLength)<<1))
+			levels.WriteByte(run)
+		}
+	}
+	for _, row := range rows {
+		var level byte
+		if row[c] != nil {
+			level = 1
+			if col.Type.Physical == Boolean {
+				v, ok := row[c].(bool)
+				if !ok {
+					return nil, fmt.Errorf("parquet: column %s: expected bool, got %T", col.Name, row[c])
+				}
+				bits = append(bits, v)
+			} else if err := encodePlain(&values, col, row[c]); err != nil {
+				return nil, err
+			}
+		}
+		if level != run || runLength == 0 {
+			flushRun()
+			run, runLength = level, 0
+		}
+		runLength++
+	}
+	flushRun()
+
+	if col.Type.Physical == Boolean {
+		packed := make([]byte, (len(bits)+7)/8)
+		for i, bit := range bits {
+			if bit {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		values.Write(packed)
+	}
+
+	page := binary.LittleEndian.AppendUint32(nil, uint32(levels.Len()))
+	page = append(page, levels.Bytes()...)
+	return append(page, values.Bytes()...), nil
+}
+
+// encodePlain appends the PLAIN encoding of a non-null value.
+func encodePlain(buf *bytes.Buffer, col Column, value interface{}) error {
+	if t, ok := value.(time.Time); ok {
+		switch col.Type.Logical {
+		case LogicalDate:
+			value = int32(daysSinceEpoch(t))
+		case LogicalTimestampMillis:
+			value = t.UnixMilli()
+		case LogicalTimestampMicros:
+			value = t.UnixMicro()
+		}
+	}
+	switch col.Type.Physical {
+	case Int32:
+		if v, ok := value.(int32); ok {
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(v)))
+			return nil
+		}
+	case Int64:
+		if v, ok := value.(int64); ok {
+			buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+			return nil
+		}
+	case Float:
+		if v, ok := value.(float32); ok {
+			buf.Write(binary.LittleEndian.AppendUint32(nil, math.Float32bits(v)))
+			return nil
+		}
+	case Double:
+		if v, ok := value.(float64); ok {
+			buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+			return nil
+		}
+	case ByteArray:
+		switch v := value.(type) {
+		case string:
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			buf.WriteString(v)
+			return nil
+		case []byte:
+			buf.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			buf.Write(v)
+			return nil
+		}
+	}
+	return fmt.Errorf("parquet: column %s: unexpected %T value", col.Name, value)
+}
+
+// daysSinceEpoch returns the number of days from 1970-01-01 to the date of t.
+func daysSinceEpoch(t time.Time) int64 {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int64(math.Floor(float64(date.Unix()) / 86400))
+}
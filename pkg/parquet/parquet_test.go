@@ -0,0 +1,66 @@
+package parquet
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: Int64Type},
+		{Name: "name", Type: StringType},
+		{Name: "active", Type: BooleanType},
+		{Name: "score", Type: DoubleType},
+		{Name: "data", Type: BytesType},
+		{Name: "birthday", Type: DateType},
+		{Name: "created_at", Type: TimestampType},
+	}
+	created := time.Date(2024, 1, 15, 10, 30, 0, 123456000, time.UTC)
+	input := [][]interface{}{
+		{int64(1), "Alice", true, 9.5, []byte{0x00, 0xff}, time.Date(1990, 5, 1, 0, 0, 0, 0, time.UTC), created},
+		{int64(2), nil, false, nil, nil, nil, nil},
+		{nil, "Bob", nil, -1.25, []byte{}, time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC), created.Add(time.Hour)},
+		{int64(-4), "Ω", true, 0.0, nil, nil, created},
+		{int64(5), "", false, nil, []byte("x"), nil, nil},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, columns)
+	require.NoError(t, err)
+	w.RowGroupSize = 2
+	for _, row := range input {
+		require.NoError(t, w.Write(row))
+	}
+	require.NoError(t, w.Close())
+
+	readColumns, rows, err := Read(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, columns, readColumns)
+	require.Len(t, rows, len(input))
+
+	assert.Equal(t, []interface{}{int64(1), []byte("Alice"), true, 9.5, []byte{0x00, 0xff}, int32(7425), created.UnixMicro()}, rows[0])
+	assert.Equal(t, []interface{}{int64(2), nil, false, nil, nil, nil, nil}, rows[1])
+	assert.Equal(t, []interface{}{nil, []byte("Bob"), nil, -1.25, []byte{}, int32(-3653), created.Add(time.Hour).UnixMicro()}, rows[2])
+	assert.Equal(t, []byte("Ω"), rows[3][1])
+	assert.Equal(t, []byte(""), rows[4][1])
+}
+
+func TestWriteErrors(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+
+	w, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "id", Type: Int64Type}})
+	require.NoError(t, err)
+	assert.ErrorContains(t, w.Write([]interface{}{int64(1), int64(2)}), "2 values")
+	require.NoError(t, w.Write([]interface{}{"one"}))
+	assert.ErrorContains(t, w.Close(), "unexpected string value")
+}
+
+func TestReadInvalid(t *testing.T) {
+	_, _, err := Read([]byte("not a parquet file"))
+	assert.ErrorContains(t, err, "not a Parquet file")
+}
@@ -0,0 +1,272 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Read decodes a Parquet file held in data. It returns the columns and the
+// rows, whose values are nil or a bool, int32, int64, float32, float64 or
+// []byte according to the physical type of their column.
+func Read(data []byte) ([]Column, [][]interface{}, error) {
+	if len(data) < 12 || string(data[:4]) != magic || string(data[len(data)-4:]) != magic {
+		return nil, nil, fmt.Errorf("parquet: not a Parquet file")
+	}
+	footerSize := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footerSize > len(data)-12 {
+		return nil, nil, fmt.Errorf("parquet: invalid footer size")
+	}
+	r := &compactReader{data: data[len(data)-8-footerSize : len(data)-8]}
+	meta, err := r.readStruct()
+	if err != nil {
+		return nil, nil, fmt.Errorf("parquet: failed to read file metadata: %v", err)
+	}
+
+	columns, optional, err := readSchema(meta.list(2))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rows [][]interface{}
+	for _, rg := range meta.list(4) {
+		rowGroup, _ := rg.(thriftStruct)
+		numRows, _ := rowGroup.int(3)
+		chunks := rowGroup.list(1)
+		if len(chunks) != len(columns) {
+			return nil, nil, fmt.Errorf("parquet: row group has %d columns, expected %d", len(chunks), len(columns))
+		}
+		groupRows := make([][]interface{}, numRows)
+		for i := range groupRows {
+			groupRows[i] = make([]interface{}, len(columns))
+		}
+		for c, chunk := range chunks {
+			chunkMeta, _ := chunk.(thriftStruct)
+			values, err := readColumnChunk(data, columns[c], optional[c], chunkMeta.child(3), int(numRows))
+			if err != nil {
+				return nil, nil, err
+			}
+			for i, v := range values {
+				groupRows[i][c] = v
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	return columns, rows, nil
+}
+
+// readSchema returns the columns of a flat schema and whether each of them is
+// optional.
+func readSchema(elements []interface{}) ([]Column, []bool, error) {
+	if len(elements) == 0 {
+		return nil, nil, fmt.Errorf("parquet: file has no schema")
+	}
+	var columns []Column
+	var optional []bool
+	for _, e := range elements[1:] {
+		element, _ := e.(thriftStruct)
+		if children, ok := element.int(5); ok && children > 0 {
+			return nil, nil, fmt.Errorf("parquet: nested column %s is not supported", element.str(4))
+		}
+		repetition, _ := element.int(3)
+		if repetition != repetitionRequired && repetition != repetitionOptional {
+			return nil, nil, fmt.Errorf("parquet: repeated column %s is not supported", element.str(4))
+		}
+		physical, ok := element.int(1)
+		if !ok {
+			return nil, nil, fmt.Errorf("parquet: column %s has no type", element.str(4))
+		}
+		col := Column{Name: element.str(4), Type: Type{Physical: PhysicalType(physical)}}
+		if converted, ok := element.int(6); ok {
+			switch converted {
+			case convertedUTF8:
+				col.Type.Logical = LogicalString
+			case convertedDate:
+				col.Type.Logical = LogicalDate
+			case convertedTimestampMillis:
+				col.Type.Logical = LogicalTimestampMillis
+			case convertedTimestampMicros:
+				col.Type.Logical = LogicalTimestampMicros
+			}
+		}
+		columns = append(columns, col)
+		optional = append(optional, repetition == repetitionOptional)
+	}
+	return columns, optional, nil
+}
+
+// readColumnChunk decodes the values of a column chunk, nil for null values.
+func readColumnChunk(data []byte, col Column, optional bool, meta thriftStruct, numRows int) ([]interface{}, error) {
+	if codec, _ := meta.int(4); codec != 0 {
+		return nil, fmt.Errorf("parquet: column %s is compressed, only uncompressed files are supported", col.Name)
+	}
+	if _, ok := meta.int(11); ok {
+		return nil, fmt.Errorf("parquet: column %s is dictionary encoded, only PLAIN encoded files are supported", col.Name)
+	}
+	offset, _ := meta.int(9)
+	values := make([]interface{}, 0, numRows)
+	for len(values) < numRows {
+		if offset < 0 || offset >= int64(len(data)) {
+			return nil, fmt.Errorf("parquet: column %s: invalid page offset", col.Name)
+		}
+		r := &compactReader{data: data[offset:]}
+		header, err := r.readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("parquet: column %s: failed to read page header: %v", col.Name, err)
+		}
+		pageSize, _ := header.int(3)
+		start := offset + int64(r.pos)
+		if pageSize < 0 || start+pageSize > int64(len(data)) {
+			return nil, fmt.Errorf("parquet: column %s: invalid page size", col.Name)
+		}
+		offset = start + pageSize
+		if pageType, _ := header.int(1); pageType != pageTypeData {
+			return nil, fmt.Errorf("parquet: column %s: page type %d is not supported", col.Name, pageType)
+		}
+		pageHeader := header.child(5)
+		if encoding, _ := pageHeader.int(2); encoding != encodingPlain {
+			return nil, fmt.Errorf("parquet: column %s: encoding %d is not supported", col.Name, encoding)
+		}
+		numValues, _ := pageHeader.int(1)
+		pageValues, err := decodePage(data[start:offset], col, int(numValues), optional)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, pageValues...)
+	}
+	if len(values) > numRows {
+		return nil, fmt.Errorf("parquet: column %s has %d values, expected %d", col.Name, len(values), numRows)
+	}
+	return values, nil
+}
+
+// decodePage decodes a data page of numValues values: the definition levels
+// of an optional column followed by the PLAIN encoded non-null values.
+func decodePage(page []byte, col Column, numValues int, optional bool) ([]interface{}, error) {
+	defined := make([]bool, numValues)
+	for i := range defined {
+		defined[i] = true
+	}
+	if optional {
+		if len(page) < 4 {
+			return nil, fmt.Errorf("parquet: column %s: truncated page", col.Name)
+		}
+		size := int(binary.LittleEndian.Uint32(page))
+		if size > len(page)-4 {
+			return nil, fmt.Errorf("parquet: column %s: truncated definition levels", col.Name)
+		}
+		if err := decodeLevels(page[4:4+size], defined); err != nil {
+			return nil, fmt.Errorf("parquet: column %s: %v", col.Name, err)
+		}
+		page = page[4+size:]
+	}
+
+	values := make([]interface{}, numValues)
+	r := bytes.NewReader(page)
+	bit := 0
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		var err error
+		if col.Type.Physical == Boolean {
+			if bit/8 >= len(page) {
+				return nil, fmt.Errorf("parquet: column %s: truncated values", col.Name)
+			}
+			values[i] = page[bit/8]&(1<<(bit%8)) != 0
+			bit++
+			continue
+		}
+		if values[i], err = decodePlain(r, col); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// decodeLevels decodes definition levels of bit width 1, encoded with the
+// RLE/bit-packing hybrid, into defined.
+func decodeLevels(data []byte, defined []bool) error {
+	i := 0
+	for i < len(defined) {
+		header, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("truncated definition levels")
+		}
+		data = data[n:]
+		if header&1 == 0 {
+			// RLE run of one value
+			if len(data) < 1 {
+				return fmt.Errorf("truncated definition levels")
+			}
+			for count := int(header >> 1); count > 0 && i < len(defined); count-- {
+				defined[i] = data[0] == 1
+				i++
+			}
+			data = data[1:]
+			continue
+		}
+		// Bit-packed groups of 8 values
+		size := int(header>>1) * 8
+		if size/8 > len(data) {
+			return fmt.Errorf("truncated definition levels")
+		}
+		for j := 0; j < size && i < len(defined); j++ {
+			defined[i] = data[j/8]&(1<<(j%8)) != 0
+			i++
+		}
+		data = data[size/8:]
+	}
+	return nil
+}
+
+// decodePlain reads one PLAIN encoded value.
+func decodePlain(r *bytes.Reader, col Column) (interface{}, error) {
+	var buf [8]byte
+	read := func(n int) ([]byte, error) {
+		if _, err := r.Read(buf[:n]); err != nil {
+			return nil, fmt.Errorf("parquet: column %s: truncated values", col.Name)
+		}
+		return buf[:n], nil
+	}
+	switch col.Type.Physical {
+	case Int32:
+		b, err := read(4)
+		if err != nil {
+			return nil, err
+		}
+		return int32(binary.LittleEndian.Uint32(b)), nil
+	case Int64:
+		b, err := read(8)
+		if err != nil {
+			return nil, err
+		}
+		return int64(binary.LittleEndian.Uint64(b)), nil
+	case Float:
+		b, err := read(4)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(b)), nil
+	case Double:
+		b, err := read(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), nil
+	case ByteArray:
+		b, err := read(4)
+		if err != nil {
+			return nil, err
+		}
+		size := int(binary.LittleEndian.Uint32(b))
+		if size > r.Len() {
+			return nil, fmt.Errorf("parquet: column %s: truncated values", col.Name)
+		}
+		value := make([]byte, size)
+		r.Read(value)
+		return value, nil
+	}
+	return nil, fmt.Errorf("parquet: column %s: physical type %d is not supported", col.Name, col.Type.Physical)
+}
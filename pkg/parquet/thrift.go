@@ -0,0 +1,277 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Type codes of the Thrift compact protocol, used by the Parquet file and
+// page metadata
+const (
+	compactBoolTrue  = 1
+	compactBoolFalse = 2
+	compactByte      = 3
+	compactI16       = 4
+	compactI32       = 5
+	compactI64       = 6
+	compactDouble    = 7
+	compactBinary    = 8
+	compactList      = 9
+	compactSet       = 10
+	compactMap       = 11
+	compactStruct    = 12
+)
+
+// compactWriter encodes Thrift structs with the compact protocol. Fields must
+// be written in increasing id order within each struct.
+type compactWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16 // Last field id of each open struct
+}
+
+func (w *compactWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	w.buf.Write(b[:n])
+}
+
+func (w *compactWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *compactWriter) fieldHeader(id int16, typ byte) {
+	last := &w.lastIDs[len(w.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	*last = id
+}
+
+func (w *compactWriter) boolField(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, compactBoolTrue)
+	} else {
+		w.fieldHeader(id, compactBoolFalse)
+	}
+}
+
+func (w *compactWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, compactI32)
+	w.zigzag(int64(v))
+}
+
+func (w *compactWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, compactI64)
+	w.zigzag(v)
+}
+
+func (w *compactWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, compactBinary)
+	w.stringValue(v)
+}
+
+func (w *compactWriter) stringValue(v string) {
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// listField starts a list field of size elements of type elemType, which are
+// written with i32Value, stringValue or structBegin/structEnd.
+func (w *compactWriter) listField(id int16, elemType byte, size int) {
+	w.fieldHeader(id, compactList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		w.buf.WriteByte(0xf0 | elemType)
+		w.varint(uint64(size))
+	}
+}
+
+func (w *compactWriter) i32Value(v int32) {
+	w.zigzag(int64(v))
+}
+
+// structField starts a struct field, ended with structEnd.
+func (w *compactWriter) structField(id int16) {
+	w.fieldHeader(id, compactStruct)
+	w.structBegin()
+}
+
+// structBegin starts a struct, ended with structEnd. Top-level structs and
+// list elements start with structBegin.
+func (w *compactWriter) structBegin() {
+	w.lastIDs = append(w.lastIDs, 0)
+}
+
+func (w *compactWriter) structEnd() {
+	w.buf.WriteByte(0)
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+// thriftStruct is a decoded Thrift struct: field ids to values. Integers are
+// decoded as int64, binary fields as []byte, lists as []interface{} and
+// nested structs as thriftStruct.
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) int(id int16) (int64, bool) {
+	v, ok := s[id].(int64)
+	return v, ok
+}
+
+func (s thriftStruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+func (s thriftStruct) child(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// compactReader decodes Thrift structs encoded with the compact protocol.
+type compactReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, fmt.Errorf("unexpected end of metadata")
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *compactReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint in metadata")
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *compactReader) zigzag() (int64, error) {
+	v, err := r.varint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *compactReader) readStruct() (thriftStruct, error) {
+	s := make(thriftStruct)
+	var lastID int16
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return s, nil
+		}
+		typ := header & 0x0f
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			longID, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(longID)
+		}
+		lastID = id
+
+		switch typ {
+		case compactBoolTrue:
+			s[id] = true
+		case compactBoolFalse:
+			s[id] = false
+		default:
+			if s[id], err = r.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func (r *compactReader) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case compactBoolTrue, compactBoolFalse:
+		// Bool list elements are a byte each
+		b, err := r.byte()
+		return b == compactBoolTrue, err
+	case compactByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case compactI16, compactI32, compactI64:
+		return r.zigzag()
+	case compactDouble:
+		if r.pos+8 > len(r.data) {
+			return nil, fmt.Errorf("unexpected end of metadata")
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case compactBinary:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("unexpected end of metadata")
+		}
+		v := r.data[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+	case compactList, compactSet:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > uint64(len(r.data)-r.pos) {
+			return nil, fmt.Errorf("invalid list size in metadata")
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			if list[i], err = r.readValue(header & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case compactMap:
+		size, err := r.varint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := r.readValue(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := r.readValue(types & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil // Maps are not used by syncdb
+	case compactStruct:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("unknown Thrift type %d in metadata", typ)
+}
@@ -7,26 +7,71 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // ProfileConfig holds the configuration parameters stored within a profile.
 type ProfileConfig struct {
-	Host               string   `yaml:"host,omitempty"`
-	Port               int      `yaml:"port,omitempty"`
-	Username           string   `yaml:"username,omitempty"`
-	Password           string   `yaml:"password,omitempty"` // Stored in plain text
-	Database           string   `yaml:"database"`           // Required field
-	Driver             string   `yaml:"driver,omitempty"`
-	Tables             []string `yaml:"tables,omitempty"`
-	IncludeSchema      *bool    `yaml:"include_schema,omitempty"` // Pointer to distinguish between false and not set
-	IncludeData        *bool    `yaml:"include_data,omitempty"`   // Pointer to distinguish between false and not set
-	Condition          string   `yaml:"condition,omitempty"`
-	ExcludeTable       []string `yaml:"exclude_table,omitempty"`
-	ExcludeTableSchema []string `yaml:"exclude_table_schema,omitempty"`
-	ExcludeTableData   []string `yaml:"exclude_table_data,omitempty"`
+	Host               string              `yaml:"host,omitempty"`
+	Port               int                 `yaml:"port,omitempty"`
+	Username           string              `yaml:"username,omitempty"`
+	Password           string              `yaml:"password,omitempty"` // Stored in plain text
+	Database           string              `yaml:"database"`           // Required unless DSN is set
+	Driver             string              `yaml:"driver,omitempty"`
+	DSN                string              `yaml:"dsn,omitempty"`       // Connection URL, overrides the fields above
+	PgSchema           string              `yaml:"pg_schema,omitempty"` // PostgreSQL schema, defaults to public
+	ConnectRetryCount  int                 `yaml:"connect_retry_count,omitempty"`
+	ConnectRetryDelay  string              `yaml:"connect_retry_delay,omitempty"` // e.g. "5s"
+	DeadlockRetryCount int                 `yaml:"deadlock_retry_count,omitempty"`
+	DeadlockRetryDelay string              `yaml:"deadlock_retry_delay,omitempty"` // e.g. "100ms"
+	Workers            int                 `yaml:"workers,omitempty"`              // Tables exported or imported in parallel
+	Tables             []string            `yaml:"tables,omitempty"`
+	IncludeSchema      *bool               `yaml:"include_schema,omitempty"` // Pointer to distinguish between false and not set
+	IncludeData        *bool               `yaml:"include_data,omitempty"`   // Pointer to distinguish between false and not set
+	Condition          string              `yaml:"condition,omitempty"`      // Fallback for tables not in Conditions
+	Conditions         map[string]string   `yaml:"conditions,omitempty"`     // Per-table WHERE conditions
+	ExcludeTable       []string            `yaml:"exclude_table,omitempty"`
+	ExcludeTableSchema []string            `yaml:"exclude_table_schema,omitempty"`
+	ExcludeTableData   []string            `yaml:"exclude_table_data,omitempty"`
+	FilterFile         string              `yaml:"filter_file,omitempty"`     // YAML filter spec applied to exports
+	ExcludeColumns     map[string][]string `yaml:"exclude_columns,omitempty"` // Per-table columns left out of data exports
+	OrderBy            map[string]string   `yaml:"order_by,omitempty"`        // Per-table sort specification, e.g. "col1 ASC, col2 DESC"
+	InsertMode         string              `yaml:"insert_mode,omitempty"`     // insert, insert-ignore, replace or upsert
+	InsertStrategy     string              `yaml:"insert_strategy,omitempty"` // Alias of insert_mode, used when insert_mode is not set
+	TableOrder         string              `yaml:"table_order,omitempty"`     // dependency, alpha, reverse-alpha or manual
+	SchemaConflict     string              `yaml:"schema_conflict,omitempty"` // Imports: error, skip or replace
+	NullToken          string              `yaml:"null_token,omitempty"`      // Token written for NULL values
+	QueryTimeout       string              `yaml:"query_timeout,omitempty"`   // Export query timeout, e.g. "30s"
+	CompressFormat     string              `yaml:"compress_format,omitempty"` // zip, tar.gz or tar.zst
+	CompressLevel      string              `yaml:"compress_level,omitempty"`
+	PreExportSQL       string              `yaml:"pre_export_sql,omitempty"`
+	PostExportSQL      string              `yaml:"post_export_sql,omitempty"`
+	PreImportSQL       string              `yaml:"pre_import_sql,omitempty"`
+	PostImportSQL      string              `yaml:"post_import_sql,omitempty"`
+	TempDir            string              `yaml:"temp_dir,omitempty"` // Import extraction directory
+	Charset            string              `yaml:"charset,omitempty"`  // MySQL default character set of imported tables
+	Collation          string              `yaml:"collation,omitempty"`
+	Encoding           string              `yaml:"encoding,omitempty"` // PostgreSQL encoding of a database recreated with --drop
+	Storage            string              `yaml:"storage,omitempty"`  // local, s3, gdrive or gcs
+	S3Bucket           string              `yaml:"s3_bucket,omitempty"`
+	S3Region           string              `yaml:"s3_region,omitempty"`
+	S3StorageClass     string              `yaml:"s3_storage_class,omitempty"` // e.g. STANDARD_IA or GLACIER
+	S3Tags             string              `yaml:"s3_tags,omitempty"`          // e.g. Environment=prod,CostCenter=db-backups
+	S3KMSKeyID         string              `yaml:"s3_kms_key_id,omitempty"`    // KMS key ID, ARN or alias
+	GdriveCredentials  string              `yaml:"gdrive_credentials,omitempty"` // Path to the service account credentials file
+	GdriveFolder       string              `yaml:"gdrive_folder,omitempty"`
+	SkipExisting       *bool               `yaml:"skip_existing,omitempty"`
+	ANSIQuotes         *bool               `yaml:"ansi_quotes,omitempty"` // Double-quoted MySQL identifiers in exported SQL
+	QuoteIdentifiers   string              `yaml:"quote_identifiers,omitempty"` // Identifier quoting in exported SQL
+	SchemaVersion      string              `yaml:"schema_version,omitempty"` // Tagged on exports, expected by imports
+	WebhookURL         string              `yaml:"webhook_url,omitempty"`
+	WebhookHeaders     []string            `yaml:"webhook_headers,omitempty"` // "Name: value" pairs
 }
 
 // GetSyncDBDir determines the base directory for syncdb application data.
@@ -97,23 +142,68 @@ func GetProfileDir(syncDBPath string) (string, error) {
 	return profileDir, nil
 }
 
+// profileNamePattern matches valid profile names, see ValidateProfileName.
+var profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,63}$`)
+
+// ValidateProfileName returns an error unless name is a valid profile name: a
+// letter or digit followed by up to 63 letters, digits, underscores or
+// hyphens. Profile names are used as file names, so they must not be able to
+// point outside the profile directory.
+func ValidateProfileName(name string) error {
+	if name == "" {
+		return errors.New("profile name cannot be empty")
+	}
+	if strings.HasPrefix(name, "..") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid profile name %q: must not start with '..' or contain path separators", name)
+	}
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: must start with a letter or digit, followed by up to 63 letters, digits, '_' or '-'", name)
+	}
+	return nil
+}
+
 // GetProfilePath constructs the full path to a specific profile file.
 func GetProfilePath(profileName string) (string, error) {
-	if profileName == "" {
-		return "", errors.New("profile name cannot be empty")
+	if err := ValidateProfileName(profileName); err != nil {
+		return "", err
 	}
-	profileDir, err := GetProfileDir("") // Note: GetProfileDir now requires syncDBPath, this will need to be updated where called.
+	profileDir, err := GetProfileDir(os.Getenv("SYNCDB_PATH"))
 	if err != nil {
 		return "", err // Error already formatted by GetProfileDir
 	}
-	// Basic validation for profile name (prevent path traversal, etc.)
-	// For now, just ensure it's not empty and join it. More robust validation could be added.
+	profileDir, err = filepath.Abs(profileDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve profile directory: %w", err)
+	}
 	fileName := fmt.Sprintf("%s.yaml", profileName)
-	return filepath.Join(profileDir, fileName), nil
+	filePath, err := filepath.Abs(filepath.Join(profileDir, fileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve profile path: %w", err)
+	}
+	// The name is validated already; this guards against anything it misses
+	if !strings.HasPrefix(filePath, profileDir+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid profile name %q: resolves outside the profile directory", profileName)
+	}
+	return filePath, nil
 }
 
-// LoadProfile reads and unmarshals a profile configuration file.
+// LoadProfile reads and unmarshals a profile configuration file, then applies
+// the SYNCDB_PROFILE_<NAME>_<FIELD> environment overrides.
 func LoadProfile(profileName string) (*ProfileConfig, error) {
+	return loadProfile(profileName, os.Environ())
+}
+
+// LoadProfileFile reads a profile as stored in its file, without environment
+// overrides, for commands that write the profile back.
+func LoadProfileFile(profileName string) (*ProfileConfig, error) {
+	return loadProfile(profileName, nil)
+}
+
+// loadProfile reads a profile and applies the overrides found in environ.
+func loadProfile(profileName string, environ []string) (*ProfileConfig, error) {
+	if err := ValidateProfileName(profileName); err != nil {
+		return nil, err
+	}
 	filePath, err := GetProfilePath(profileName)
 	if err != nil {// This will need to be updated as GetProfilePath now calls GetProfileDir
 		return nil, err
@@ -132,8 +222,12 @@ func LoadProfile(profileName string) (*ProfileConfig, error) {
 		return nil, fmt.Errorf("failed to parse profile file %s: %w", filePath, err)
 	}
 
-	// Basic validation after loading
-	if config.Database == "" {
+	if err := applyEnvOverrides(profileName, &config, environ); err != nil {
+		return nil, err
+	}
+
+	// Basic validation after loading; the database may come from the DSN
+	if config.Database == "" && config.DSN == "" {
 		return nil, fmt.Errorf("profile '%s' is invalid: missing required 'database' field", profileName)
 	}
 
@@ -142,10 +236,13 @@ func LoadProfile(profileName string) (*ProfileConfig, error) {
 
 // SaveProfile marshals and saves a profile configuration to a file.
 func SaveProfile(profileName string, config *ProfileConfig) error {
+	if err := ValidateProfileName(profileName); err != nil {
+		return err
+	}
 	if config == nil {
 		return errors.New("cannot save a nil profile config")
 	}
-	if config.Database == "" {
+	if config.Database == "" && config.DSN == "" {
 		return errors.New("cannot save profile: missing required 'database' field")
 	}
 
@@ -171,4 +268,98 @@ func SaveProfile(profileName string, config *ProfileConfig) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// EnvOverridePrefix returns the prefix of the environment variables that
+// override fields of a profile, e.g. SYNCDB_PROFILE_PROD_ for "prod". The
+// profile name is upper-cased and characters other than letters and digits
+// become underscores.
+func EnvOverridePrefix(profileName string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, profileName)
+	return "SYNCDB_PROFILE_" + strings.ToUpper(name) + "_"
+}
+
+// applyEnvOverrides sets the fields of config from the environment variables
+// in environ (as returned by os.Environ) named after the profile prefix and
+// the upper-cased YAML key of the field, e.g. SYNCDB_PROFILE_PROD_PG_SCHEMA.
+// Lists are comma-separated, maps are YAML flow mappings such as
+// {orders: "id > 10"}. Unknown field names are ignored, since they may belong
+// to another profile whose name starts with this one.
+func applyEnvOverrides(profileName string, config *ProfileConfig, environ []string) error {
+	prefix := EnvOverridePrefix(profileName)
+	fields := envOverrideFields(config)
+	for _, env := range environ {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		field, ok := fields[strings.TrimPrefix(key, prefix)]
+		if !ok {
+			continue
+		}
+		if err := setEnvOverrideField(field, value); err != nil {
+			return fmt.Errorf("invalid value of %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// envOverrideFields maps the upper-cased YAML key of each ProfileConfig field
+// to the settable field of config.
+func envOverrideFields(config *ProfileConfig) map[string]reflect.Value {
+	v := reflect.ValueOf(config).Elem()
+	fields := make(map[string]reflect.Value, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[strings.ToUpper(name)] = v.Field(i)
+	}
+	return fields
+}
+
+// setEnvOverrideField parses value according to the type of field and sets it.
+func setEnvOverrideField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Ptr:
+		if field.Type().Elem().Kind() != reflect.Bool {
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(&b))
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		m := reflect.New(field.Type())
+		if err := yaml.Unmarshal([]byte(value), m.Interface()); err != nil {
+			return err
+		}
+		field.Set(m.Elem())
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package profile
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -46,7 +47,7 @@ func TestGetProfileDir(t *testing.T) {
 		// Determine expected default path (this is OS-dependent)
 		// For simplicity, we'll just check it doesn't return an error and is absolute.
 		// A more robust test would mock os.UserConfigDir()
-		dir, err := GetProfileDir()
+		dir, err := GetProfileDir(os.Getenv("SYNCDB_PATH"))
 		assert.NoError(t, err)
 		assert.True(t, filepath.IsAbs(dir), "Expected absolute path")
 		assert.Contains(t, dir, "syncdb", "Expected path to contain 'syncdb'")
@@ -61,7 +62,7 @@ func TestGetProfileDir(t *testing.T) {
 		defer os.Setenv("SYNCDB_PATH", originalPath) // Restore original value
 
 		expectedDir := filepath.Join(testPath, "profiles")
-		dir, err := GetProfileDir()
+		dir, err := GetProfileDir(os.Getenv("SYNCDB_PATH"))
 		assert.NoError(t, err)
 		assert.Equal(t, expectedDir, dir)
 	})
@@ -84,6 +85,51 @@ func TestGetProfilePath(t *testing.T) {
 	})
 }
 
+func TestValidateProfileName(t *testing.T) {
+	for _, name := range []string{"prod", "my-test-profile", "dev_2", "0", "A" + strings.Repeat("b", 63)} {
+		assert.NoError(t, ValidateProfileName(name), name)
+	}
+
+	malicious := []string{
+		"",
+		"../secret",
+		"..",
+		"..prod",
+		"foo/bar",
+		`foo\bar`,
+		"/etc/passwd",
+		"prod\x00.yaml",
+		"-prod",
+		"_prod",
+		".hidden",
+		"prod.yaml",
+		"my profile",
+		"A" + strings.Repeat("b", 64),
+	}
+	for _, name := range malicious {
+		assert.Error(t, ValidateProfileName(name), "%q", name)
+	}
+}
+
+func TestProfileNameTraversal(t *testing.T) {
+	baseTmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+	require.NoError(t, os.WriteFile(filepath.Join(baseTmpDir, "secret.yaml"), []byte("database: secret\n"), 0644))
+
+	_, err := GetProfilePath("../secret")
+	assert.ErrorContains(t, err, "invalid profile name")
+	_, err = LoadProfile("../secret")
+	assert.ErrorContains(t, err, "invalid profile name")
+	err = SaveProfile("foo/bar", &ProfileConfig{Database: "db"})
+	assert.ErrorContains(t, err, "invalid profile name")
+	assert.NoDirExists(t, filepath.Join(baseTmpDir, "profiles", "foo"))
+
+	path, err := GetProfilePath("prod")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(baseTmpDir, "profiles", "prod.yaml"), path)
+}
+
 func TestLoadProfile(t *testing.T) {
 	baseTmpDir, cleanup := setupTestDir(t)
 	defer cleanup()
@@ -102,7 +148,7 @@ host: localhost
 port: 3306
 driver: mysql
 tables: [users, orders]
-profile-include-schema: true
+include_schema: true
 `
 		createDummyProfile(t, profileDir, profileName, content)
 
@@ -123,8 +169,7 @@ profile-include-schema: true
 		profileName := "non-existent-profile"
 		_, err := LoadProfile(profileName)
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "not found")
-		assert.True(t, os.IsNotExist(err), "Expected a 'not exist' error type")
+		assert.Contains(t, err.Error(), "profile 'non-existent-profile' not found")
 	})
 
 	t.Run("Profile exists but is invalid YAML", func(t *testing.T) {
@@ -134,7 +179,7 @@ profile-include-schema: true
 
 		_, err := LoadProfile(profileName)
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to unmarshal")
+		assert.Contains(t, err.Error(), "failed to parse profile file")
 	})
 
 	t.Run("Profile exists but has incorrect types", func(t *testing.T) {
@@ -143,7 +188,7 @@ profile-include-schema: true
 database: testdb
 port: "not-a-number" # Port should be int
 tables: "not-a-slice" # Tables should be slice
-profile-include-schema: "not-a-bool" # IncludeSchema should be bool
+include_schema: "not-a-bool" # IncludeSchema should be bool
 `
 		createDummyProfile(t, profileDir, profileName, content)
 
@@ -151,10 +196,37 @@ profile-include-schema: "not-a-bool" # IncludeSchema should be bool
 		// Depending on the YAML library, this might error during unmarshal or result in zero values.
 		// We expect an error here.
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "failed to unmarshal")
+		assert.Contains(t, err.Error(), "failed to parse profile file")
 	})
 }
 
+func TestLoadProfileConditions(t *testing.T) {
+	baseTmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+	profileDir := filepath.Join(baseTmpDir, "profiles")
+
+	originalPath := os.Getenv("SYNCDB_PATH")
+	os.Setenv("SYNCDB_PATH", baseTmpDir)
+	defer os.Setenv("SYNCDB_PATH", originalPath)
+
+	content := `
+database: testdb
+condition: deleted_at IS NULL
+conditions:
+  orders: created_at > '2024-01-01'
+  audit_log: id > 1000
+`
+	createDummyProfile(t, profileDir, "with-conditions", content)
+
+	cfg, err := LoadProfile("with-conditions")
+	require.NoError(t, err)
+	assert.Equal(t, "deleted_at IS NULL", cfg.Condition)
+	assert.Equal(t, map[string]string{
+		"orders":    "created_at > '2024-01-01'",
+		"audit_log": "id > 1000",
+	}, cfg.Conditions)
+}
+
 func TestSaveProfile(t *testing.T) {
 	baseTmpDir, cleanup := setupTestDir(t)
 	defer cleanup()
@@ -195,9 +267,9 @@ func TestSaveProfile(t *testing.T) {
 		assert.Contains(t, content, "driver: postgres")
 		assert.Contains(t, content, "tables:")
 		assert.Contains(t, content, "- products")
-		assert.Contains(t, content, "includeschema: true")
-		assert.NotContains(t, content, "includedata:") // Should not be present if nil
-		assert.NotContains(t, content, "password:")    // Should not be present if empty
+		assert.Contains(t, content, "include_schema: true")
+		assert.NotContains(t, content, "include_data:") // Should not be present if nil
+		assert.NotContains(t, content, "password:")     // Should not be present if empty
 	})
 
 	t.Run("Overwrite existing profile", func(t *testing.T) {
@@ -267,3 +339,116 @@ func TestSaveProfile(t *testing.T) {
 		assert.NoError(t, err, "Profile file should exist after saving")
 	})
 }
+
+func TestApplyEnvOverrides(t *testing.T) {
+	includeData := false
+	testCases := []struct {
+		field    string
+		value    string
+		expected ProfileConfig
+	}{
+		{"HOST", "staging-db.internal", ProfileConfig{Host: "staging-db.internal"}},
+		{"PORT", "3307", ProfileConfig{Port: 3307}},
+		{"USERNAME", "ci", ProfileConfig{Username: "ci"}},
+		{"PASSWORD", "p@ss=word", ProfileConfig{Password: "p@ss=word"}},
+		{"DATABASE", "staging", ProfileConfig{Database: "staging"}},
+		{"DRIVER", "postgres", ProfileConfig{Driver: "postgres"}},
+		{"DSN", "mysql://u:p@h/db", ProfileConfig{DSN: "mysql://u:p@h/db"}},
+		{"PG_SCHEMA", "tenant1", ProfileConfig{PgSchema: "tenant1"}},
+		{"CONNECT_RETRY_COUNT", "3", ProfileConfig{ConnectRetryCount: 3}},
+		{"CONNECT_RETRY_DELAY", "5s", ProfileConfig{ConnectRetryDelay: "5s"}},
+		{"DEADLOCK_RETRY_COUNT", "4", ProfileConfig{DeadlockRetryCount: 4}},
+		{"DEADLOCK_RETRY_DELAY", "100ms", ProfileConfig{DeadlockRetryDelay: "100ms"}},
+		{"WORKERS", "8", ProfileConfig{Workers: 8}},
+		{"TABLES", "users, orders", ProfileConfig{Tables: []string{"users", "orders"}}},
+		{"INCLUDE_SCHEMA", "true", ProfileConfig{IncludeSchema: boolPtr(true)}},
+		{"INCLUDE_DATA", "false", ProfileConfig{IncludeData: &includeData}},
+		{"CONDITION", "deleted_at IS NULL", ProfileConfig{Condition: "deleted_at IS NULL"}},
+		{"CONDITIONS", `{orders: "id > 10"}`, ProfileConfig{Conditions: map[string]string{"orders": "id > 10"}}},
+		{"EXCLUDE_TABLE", "logs", ProfileConfig{ExcludeTable: []string{"logs"}}},
+		{"EXCLUDE_TABLE_SCHEMA", "a,b", ProfileConfig{ExcludeTableSchema: []string{"a", "b"}}},
+		{"EXCLUDE_TABLE_DATA", "sessions", ProfileConfig{ExcludeTableData: []string{"sessions"}}},
+		{"FILTER_FILE", "/etc/syncdb/filter.yaml", ProfileConfig{FilterFile: "/etc/syncdb/filter.yaml"}},
+		{"EXCLUDE_COLUMNS", "{users: [password, token]}", ProfileConfig{ExcludeColumns: map[string][]string{"users": {"password", "token"}}}},
+		{"ORDER_BY", "{users: id DESC}", ProfileConfig{OrderBy: map[string]string{"users": "id DESC"}}},
+		{"INSERT_MODE", "upsert", ProfileConfig{InsertMode: "upsert"}},
+		{"INSERT_STRATEGY", "replace", ProfileConfig{InsertStrategy: "replace"}},
+		{"TABLE_ORDER", "alpha", ProfileConfig{TableOrder: "alpha"}},
+		{"SCHEMA_CONFLICT", "skip", ProfileConfig{SchemaConflict: "skip"}},
+		{"NULL_TOKEN", "\\N", ProfileConfig{NullToken: "\\N"}},
+		{"QUERY_TIMEOUT", "30s", ProfileConfig{QueryTimeout: "30s"}},
+		{"COMPRESS_FORMAT", "tar.zst", ProfileConfig{CompressFormat: "tar.zst"}},
+		{"COMPRESS_LEVEL", "best", ProfileConfig{CompressLevel: "best"}},
+		{"PRE_EXPORT_SQL", "SELECT 1", ProfileConfig{PreExportSQL: "SELECT 1"}},
+		{"POST_EXPORT_SQL", "SELECT 2", ProfileConfig{PostExportSQL: "SELECT 2"}},
+		{"PRE_IMPORT_SQL", "SELECT 3", ProfileConfig{PreImportSQL: "SELECT 3"}},
+		{"POST_IMPORT_SQL", "SELECT 4", ProfileConfig{PostImportSQL: "SELECT 4"}},
+		{"TEMP_DIR", "/tmp/syncdb", ProfileConfig{TempDir: "/tmp/syncdb"}},
+		{"CHARSET", "utf8mb4", ProfileConfig{Charset: "utf8mb4"}},
+		{"COLLATION", "utf8mb4_unicode_ci", ProfileConfig{Collation: "utf8mb4_unicode_ci"}},
+		{"ENCODING", "UTF8", ProfileConfig{Encoding: "UTF8"}},
+		{"STORAGE", "s3", ProfileConfig{Storage: "s3"}},
+		{"S3_BUCKET", "backups", ProfileConfig{S3Bucket: "backups"}},
+		{"S3_REGION", "eu-west-1", ProfileConfig{S3Region: "eu-west-1"}},
+		{"S3_STORAGE_CLASS", "GLACIER", ProfileConfig{S3StorageClass: "GLACIER"}},
+		{"S3_TAGS", "Environment=prod", ProfileConfig{S3Tags: "Environment=prod"}},
+		{"S3_KMS_KEY_ID", "alias/backups", ProfileConfig{S3KMSKeyID: "alias/backups"}},
+		{"GDRIVE_CREDENTIALS", "/etc/syncdb/creds.json", ProfileConfig{GdriveCredentials: "/etc/syncdb/creds.json"}},
+		{"GDRIVE_FOLDER", "folder-id", ProfileConfig{GdriveFolder: "folder-id"}},
+		{"SKIP_EXISTING", "1", ProfileConfig{SkipExisting: boolPtr(true)}},
+		{"ANSI_QUOTES", "true", ProfileConfig{ANSIQuotes: boolPtr(true)}},
+		{"QUOTE_IDENTIFIERS", "none", ProfileConfig{QuoteIdentifiers: "none"}},
+		{"SCHEMA_VERSION", "1.4.0", ProfileConfig{SchemaVersion: "1.4.0"}},
+		{"WEBHOOK_URL", "https://example.com/hook", ProfileConfig{WebhookURL: "https://example.com/hook"}},
+		{"WEBHOOK_HEADERS", "X-Token: abc", ProfileConfig{WebhookHeaders: []string{"X-Token: abc"}}},
+	}
+
+	// Every field must be covered, so new fields get a test case
+	assert.Len(t, testCases, len(envOverrideFields(&ProfileConfig{})))
+
+	for _, tc := range testCases {
+		t.Run(tc.field, func(t *testing.T) {
+			var cfg ProfileConfig
+			environ := []string{"SYNCDB_PROFILE_PROD_" + tc.field + "=" + tc.value}
+			require.NoError(t, applyEnvOverrides("prod", &cfg, environ))
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+
+	t.Run("other profiles and unknown fields are ignored", func(t *testing.T) {
+		cfg := ProfileConfig{Host: "prod-db"}
+		environ := []string{"SYNCDB_PROFILE_DEV_HOST=dev-db", "SYNCDB_PROFILE_PROD_EU_HOST=eu-db", "SYNCDB_PROFILE_PROD_COLOR=red"}
+		require.NoError(t, applyEnvOverrides("prod", &cfg, environ))
+		assert.Equal(t, ProfileConfig{Host: "prod-db"}, cfg)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		for _, env := range []string{"SYNCDB_PROFILE_PROD_PORT=abc", "SYNCDB_PROFILE_PROD_SKIP_EXISTING=maybe", "SYNCDB_PROFILE_PROD_CONDITIONS=[a"} {
+			err := applyEnvOverrides("prod", &ProfileConfig{}, []string{env})
+			assert.Error(t, err, env)
+		}
+	})
+
+	assert.Equal(t, "SYNCDB_PROFILE_MY_PROD_", EnvOverridePrefix("my-prod"))
+}
+
+func TestLoadProfileEnvOverrides(t *testing.T) {
+	baseTmpDir, cleanup := setupTestDir(t)
+	defer cleanup()
+	t.Setenv("SYNCDB_PATH", baseTmpDir)
+	createDummyProfile(t, filepath.Join(baseTmpDir, "profiles"), "prod", "host: prod-db\ndatabase: app\n")
+
+	t.Setenv("SYNCDB_PROFILE_PROD_HOST", "staging-db.internal")
+	cfg, err := LoadProfile("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "staging-db.internal", cfg.Host)
+	assert.Equal(t, "app", cfg.Database)
+
+	stored, err := LoadProfileFile("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "prod-db", stored.Host)
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
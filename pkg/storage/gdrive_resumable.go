@@ -0,0 +1,298 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultGDriveChunkSize is the size of each chunk of a resumable Google Drive upload.
+	DefaultGDriveChunkSize int64 = 5 * 1024 * 1024
+	// GDriveChunkAlign is the multiple of which resumable upload chunks must be,
+	// except for the last chunk.
+	GDriveChunkAlign int64 = 256 * 1024
+	// gdriveResumableThreshold is the file size above which uploads use a resumable session.
+	gdriveResumableThreshold = 5 * 1024 * 1024
+	// gdriveResumableUploadURL is the Drive API endpoint that creates resumable upload sessions.
+	gdriveResumableUploadURL = "https://www.googleapis.com/upload/drive/v3/files?uploadType=resumable"
+	// maxGDriveChunkRetries is the number of times a chunk is retried after a transient error.
+	maxGDriveChunkRetries = 5
+	// defaultGDriveRetryDelay is the delay before the first retry of a chunk, doubled on each further retry.
+	defaultGDriveRetryDelay = time.Second
+	// GDriveSessionsFileName is the file in GDriveUploadOptions.SessionDir that
+	// keeps the sessions of unfinished resumable uploads between runs.
+	GDriveSessionsFileName = ".syncdb_gdrive_sessions.json"
+)
+
+// GDriveUploadOptions controls how large files are uploaded to Google Drive.
+type GDriveUploadOptions struct {
+	ChunkSize  int64  // Size of each chunk of a resumable upload, a multiple of GDriveChunkAlign
+	SessionDir string // Directory keeping unfinished resumable sessions between runs, if set
+}
+
+// gdriveSession is an unfinished resumable upload. The size and SHA-256 of
+// the data identify it, so that only the same content resumes the session.
+type gdriveSession struct {
+	URI    string `json:"uri"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// loadGDriveSessions reads the unfinished sessions saved in dir, by filename.
+// There are none when dir is empty or holds no sessions file.
+func loadGDriveSessions(dir string) (map[string]gdriveSession, error) {
+	sessions := make(map[string]gdriveSession)
+	if dir == "" {
+		return sessions, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, GDriveSessionsFileName))
+	if os.IsNotExist(err) {
+		return sessions, nil
+	}
+	if err != nil {
+		return sessions, err
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		return make(map[string]gdriveSession), fmt.Errorf("invalid %s: %w", GDriveSessionsFileName, err)
+	}
+	return sessions, nil
+}
+
+// setSession records the session of filename, or forgets it when session
+// is nil, and saves the sessions to the session directory.
+func (g *gdriveStorage) setSession(filename string, session *gdriveSession) {
+	if session != nil {
+		g.sessions[filename] = *session
+	} else {
+		delete(g.sessions, filename)
+	}
+	if g.opts.SessionDir == "" {
+		return
+	}
+	path := filepath.Join(g.opts.SessionDir, GDriveSessionsFileName)
+	var err error
+	if len(g.sessions) == 0 {
+		if err = os.Remove(path); os.IsNotExist(err) {
+			err = nil
+		}
+	} else {
+		var data []byte
+		if data, err = json.MarshalIndent(g.sessions, "", "  "); err == nil {
+			err = os.WriteFile(path, data, 0600)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to save resumable upload sessions to %s: %v\n", path, err)
+	}
+}
+
+// ValidateGDriveChunkSize checks that size is a positive multiple of 256 KB,
+// as the Drive API requires for resumable upload chunks.
+func ValidateGDriveChunkSize(size int64) error {
+	if size <= 0 || size%GDriveChunkAlign != 0 {
+		return fmt.Errorf("gdrive-chunk-size must be a multiple of 256KB, got %d bytes", size)
+	}
+	return nil
+}
+
+// gdriveTransientError is a failed chunk request that is worth retrying: a
+// network error or a 5xx response.
+type gdriveTransientError struct {
+	err error
+}
+
+func (e *gdriveTransientError) Error() string {
+	return e.err.Error()
+}
+
+// uploadResumable uploads data through a resumable upload session. The
+// session is kept until the upload completes, in the session directory if
+// there is one, so that uploading the same file again after a failure, in
+// this run or a later one, resumes from the last chunk the Drive API
+// acknowledged instead of starting over. It returns the ID of the new file.
+func (g *gdriveStorage) uploadResumable(data []byte, filename string) (string, error) {
+	total := int64(len(data))
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	var sessionURI string
+	if session, ok := g.sessions[filename]; ok && session.Size == total && session.SHA256 == checksum {
+		sessionURI = session.URI
+	}
+	var offset int64
+	if sessionURI != "" {
+		// Ask the session how much of the previous attempt it received
+		received, fileID, err := g.querySession(sessionURI, total)
+		switch {
+		case err != nil:
+			fmt.Printf("Could not resume the previous upload of %s, starting over: %v\n", filename, err)
+			sessionURI = ""
+		case fileID != "":
+			g.setSession(filename, nil)
+			return fileID, nil
+		default:
+			offset = received
+			fmt.Printf("Resuming upload of %s at %d of %d bytes\n", filename, offset, total)
+		}
+	}
+	if sessionURI == "" {
+		var err error
+		if sessionURI, err = g.createSession(filename, total); err != nil {
+			return "", err
+		}
+		g.setSession(filename, &gdriveSession{URI: sessionURI, Size: total, SHA256: checksum})
+	}
+
+	chunkSize := g.opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultGDriveChunkSize
+	}
+	for {
+		end := min(offset+chunkSize, total)
+		next, fileID, err := g.putChunk(sessionURI, data[offset:end], offset, total)
+		for retry := 0; err != nil && retry < maxGDriveChunkRetries; retry++ {
+			var transient *gdriveTransientError
+			if !errors.As(err, &transient) {
+				break
+			}
+			delay := g.retryDelay << retry
+			fmt.Printf("Chunk at offset %d of %s failed (%v), retrying in %s...\n", offset, filename, err, delay)
+			time.Sleep(delay)
+			// The failed request may have been partly received, so ask the
+			// session where to continue before sending the chunk again
+			received, doneID, queryErr := g.querySession(sessionURI, total)
+			if queryErr != nil {
+				err = queryErr
+				continue
+			}
+			if doneID != "" {
+				next, fileID, err = total, doneID, nil
+				break
+			}
+			offset = received
+			end = min(offset+chunkSize, total)
+			next, fileID, err = g.putChunk(sessionURI, data[offset:end], offset, total)
+		}
+		if err != nil {
+			return "", fmt.Errorf("upload of %s failed at %d of %d bytes: %w", filename, offset, total, err)
+		}
+		if fileID != "" {
+			g.setSession(filename, nil)
+			return fileID, nil
+		}
+		offset = next
+	}
+}
+
+// createSession starts a resumable upload of a file of size bytes into the
+// storage's folder and returns the session URI.
+func (g *gdriveStorage) createSession(filename string, size int64) (string, error) {
+	metadata, err := json.Marshal(map[string]any{
+		"name":    filename,
+		"parents": []string{g.folderId},
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, g.uploadURL, bytes.NewReader(metadata))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", "application/octet-stream")
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create resumable upload session for %s: %w", filename, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to create resumable upload session for %s: %s", filename, responseError(resp))
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("failed to create resumable upload session for %s: no session URI in response", filename)
+	}
+	return location, nil
+}
+
+// putChunk sends chunk, starting at offset of a file of total bytes, to the
+// session. It returns the offset to continue from, or the file ID once the
+// upload is complete.
+func (g *gdriveStorage) putChunk(sessionURI string, chunk []byte, offset, total int64) (int64, string, error) {
+	req, err := http.NewRequest(http.MethodPut, sessionURI, bytes.NewReader(chunk))
+	if err != nil {
+		return 0, "", err
+	}
+	req.ContentLength = int64(len(chunk))
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+int64(len(chunk))-1, total))
+	return g.sessionRequest(req)
+}
+
+// querySession asks the session how many bytes of a file of total bytes it
+// has received. It returns the file ID instead if the upload is complete.
+func (g *gdriveStorage) querySession(sessionURI string, total int64) (int64, string, error) {
+	req, err := http.NewRequest(http.MethodPut, sessionURI, nil)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", total))
+	return g.sessionRequest(req)
+}
+
+// sessionRequest sends a request to a resumable upload session and
+// interprets the response: 308 Resume Incomplete with the received range, or
+// 200/201 with the uploaded file.
+func (g *gdriveStorage) sessionRequest(req *http.Request) (int64, string, error) {
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return 0, "", &gdriveTransientError{err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPermanentRedirect:
+		// Range is bytes=0-N; without it nothing has been received yet
+		received := resp.Header.Get("Range")
+		if received == "" {
+			return 0, "", nil
+		}
+		_, last, ok := strings.Cut(strings.TrimPrefix(received, "bytes="), "-")
+		end, err := strconv.ParseInt(last, 10, 64)
+		if !ok || err != nil {
+			return 0, "", fmt.Errorf("invalid Range header %q in resumable upload response", received)
+		}
+		return end + 1, "", nil
+	case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated:
+		var file struct {
+			ID string `json:"id"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&file); err != nil {
+			return 0, "", fmt.Errorf("failed to decode uploaded file: %w", err)
+		}
+		return 0, file.ID, nil
+	case resp.StatusCode >= 500:
+		return 0, "", &gdriveTransientError{fmt.Errorf("%s", responseError(resp))}
+	}
+	return 0, "", fmt.Errorf("%s", responseError(resp))
+}
+
+// responseError describes an unexpected response by its status and body.
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return resp.Status
+	}
+	return fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriveUploads implements the resumable upload endpoints of the Drive API.
+type fakeDriveUploads struct {
+	mu             sync.Mutex
+	sessions       int
+	metadata       string
+	received       []byte
+	chunkOffsets   []int // Offset of each chunk PUT, including failed ones
+	statusQueries  int
+	failFromChunk  int // Chunk PUTs from this one (1-based) on fail with 503, 0 for none
+	failChunkCount int // Number of chunk PUTs that fail, 0 for all from failFromChunk
+	failed         int
+}
+
+func (f *fakeDriveUploads) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	body, _ := io.ReadAll(r.Body)
+
+	if r.Method == http.MethodPost {
+		f.sessions++
+		f.metadata = string(body)
+		w.Header().Set("Location", "http://"+r.Host+"/session")
+		return
+	}
+
+	var total int
+	contentRange := r.Header.Get("Content-Range")
+	if _, err := fmt.Sscanf(contentRange, "bytes */%d", &total); err == nil {
+		f.statusQueries++
+		f.writeStatus(w, total)
+		return
+	}
+	var start, end int
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		http.Error(w, "bad Content-Range "+contentRange, http.StatusBadRequest)
+		return
+	}
+	f.chunkOffsets = append(f.chunkOffsets, start)
+	if f.failFromChunk > 0 && len(f.chunkOffsets) >= f.failFromChunk && (f.failChunkCount == 0 || f.failed < f.failChunkCount) {
+		f.failed++
+		http.Error(w, "backend error", http.StatusServiceUnavailable)
+		return
+	}
+	if start != len(f.received) || end-start+1 != len(body) {
+		http.Error(w, "unexpected chunk "+contentRange, http.StatusBadRequest)
+		return
+	}
+	f.received = append(f.received, body...)
+	f.writeStatus(w, total)
+}
+
+func (f *fakeDriveUploads) writeStatus(w http.ResponseWriter, total int) {
+	if len(f.received) == total {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"id": "file-1"}`)
+		return
+	}
+	if len(f.received) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(f.received)-1))
+	}
+	w.WriteHeader(http.StatusPermanentRedirect)
+}
+
+func newFakeDriveStorage(t *testing.T, fake *fakeDriveUploads) *gdriveStorage {
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return &gdriveStorage{
+		folderId:   "folder-1",
+		httpClient: server.Client(),
+		uploadURL:  server.URL + "/upload?uploadType=resumable",
+		opts:       GDriveUploadOptions{ChunkSize: 1024 * 1024},
+		sessions:   make(map[string]gdriveSession),
+	}
+}
+
+func TestGDriveResumableUpload(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 6*1024*1024/16+100)
+
+	// The third chunk fails once and is sent again
+	fake := &fakeDriveUploads{failFromChunk: 3, failChunkCount: 1}
+	store := newFakeDriveStorage(t, fake)
+	require.NoError(t, store.Upload(data, "export.zip"))
+	assert.Equal(t, data, fake.received)
+	assert.Equal(t, 1, fake.sessions)
+	assert.JSONEq(t, `{"name": "export.zip", "parents": ["folder-1"]}`, fake.metadata)
+	assert.Equal(t, []int{0, 1 << 20, 2 << 20, 2 << 20, 3 << 20, 4 << 20, 5 << 20, 6 << 20}, fake.chunkOffsets)
+	assert.Equal(t, 1, fake.statusQueries)
+	assert.Empty(t, store.sessions)
+}
+
+func TestGDriveResumableUploadResume(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab}, 6*1024*1024+1)
+
+	// Every chunk from the third on fails until the retries run out
+	fake := &fakeDriveUploads{failFromChunk: 3}
+	store := newFakeDriveStorage(t, fake)
+	err := store.Upload(data, "export.zip")
+	assert.ErrorContains(t, err, "failed at 2097152 of 6291457 bytes")
+	assert.Len(t, fake.received, 2<<20)
+	assert.NotEmpty(t, store.sessions["export.zip"])
+
+	// Uploading again continues the same session after the acknowledged chunks
+	fake.failFromChunk = 0
+	fake.chunkOffsets = nil
+	require.NoError(t, store.Upload(data, "export.zip"))
+	assert.Equal(t, data, fake.received)
+	assert.Equal(t, 1, fake.sessions)
+	assert.Equal(t, []int{2 << 20, 3 << 20, 4 << 20, 5 << 20, 6 << 20}, fake.chunkOffsets)
+	assert.Empty(t, store.sessions)
+}
+
+func TestGDriveResumableUploadResumeNextRun(t *testing.T) {
+	data := bytes.Repeat([]byte{0xcd}, 6*1024*1024+1)
+	dir := t.TempDir()
+	sessionsFile := filepath.Join(dir, GDriveSessionsFileName)
+
+	fake := &fakeDriveUploads{failFromChunk: 3}
+	store := newFakeDriveStorage(t, fake)
+	store.opts.SessionDir = dir
+	assert.Error(t, store.Upload(data, "export.zip"))
+	assert.FileExists(t, sessionsFile)
+
+	// A new storage, as in the next run, loads the session and continues it
+	fake.failFromChunk = 0
+	fake.chunkOffsets = nil
+	next := newFakeDriveStorage(t, fake)
+	next.opts.SessionDir = dir
+	sessions, err := loadGDriveSessions(dir)
+	require.NoError(t, err)
+	require.Contains(t, sessions, "export.zip")
+	assert.Equal(t, int64(len(data)), sessions["export.zip"].Size)
+	next.sessions = sessions
+	require.NoError(t, next.Upload(data, "export.zip"))
+	assert.Equal(t, data, fake.received)
+	assert.Equal(t, 1, fake.sessions)
+	assert.Equal(t, []int{2 << 20, 3 << 20, 4 << 20, 5 << 20, 6 << 20}, fake.chunkOffsets)
+	assert.NoFileExists(t, sessionsFile)
+}
+
+func TestGDriveResumableUploadChangedContent(t *testing.T) {
+	data := bytes.Repeat([]byte{0xcd}, 6*1024*1024+1)
+	fake := &fakeDriveUploads{failFromChunk: 3}
+	store := newFakeDriveStorage(t, fake)
+	store.opts.SessionDir = t.TempDir()
+	assert.Error(t, store.Upload(data, "export.zip"))
+
+	// A file of the same name and size but other content starts a new session
+	fake.failFromChunk = 0
+	fake.chunkOffsets = nil
+	fake.received = nil
+	changed := bytes.Repeat([]byte{0xef}, len(data))
+	require.NoError(t, store.Upload(changed, "export.zip"))
+	assert.Equal(t, changed, fake.received)
+	assert.Equal(t, 2, fake.sessions)
+	assert.Equal(t, 0, fake.chunkOffsets[0])
+}
+
+func TestLoadGDriveSessions(t *testing.T) {
+	sessions, err := loadGDriveSessions("")
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	dir := t.TempDir()
+	sessions, err = loadGDriveSessions(dir)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GDriveSessionsFileName), []byte("{"), 0600))
+	sessions, err = loadGDriveSessions(dir)
+	assert.ErrorContains(t, err, "invalid "+GDriveSessionsFileName)
+	assert.Empty(t, sessions)
+}
+
+func TestValidateGDriveChunkSize(t *testing.T) {
+	assert.NoError(t, ValidateGDriveChunkSize(DefaultGDriveChunkSize))
+	assert.NoError(t, ValidateGDriveChunkSize(256*1024))
+	for _, size := range []int64{0, -256 * 1024, 100 * 1024, 1000 * 1000} {
+		err := ValidateGDriveChunkSize(size)
+		if assert.Error(t, err) {
+			assert.True(t, strings.HasPrefix(err.Error(), "gdrive-chunk-size must be a multiple of 256KB"))
+		}
+	}
+}
@@ -2,18 +2,81 @@ package storage
 
 import (
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
+// exportTimestampLayout is the timestamp format of default export names.
+const exportTimestampLayout = "20060102_150405"
+
+// MetadataFileNames are the names of an export's metadata file, JSON or YAML,
+// in order of preference when an export has both.
+var MetadataFileNames = []string{"0_metadata.json", "0_metadata.yaml"}
+
+// IsMetadataFile reports whether name is the name of an export's metadata file.
+func IsMetadataFile(name string) bool {
+	for _, metadataName := range MetadataFileNames {
+		if name == metadataName {
+			return true
+		}
+	}
+	return false
+}
+
 // IsExportPath checks if the given path contains the metadata file indicating it's a complete export path.
 func IsExportPath(path string) bool {
 	if path == "" {
 		return false
 	}
 
-	metadataPath := filepath.Join(path, "0_metadata.json")
-	if _, err := os.Stat(metadataPath); err == nil {
-		return true
+	for _, name := range MetadataFileNames {
+		if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+			return true
+		}
 	}
 	return false
 }
+
+// isExportArchiveName reports whether name is an export archive of database
+// with the default name, {database}_{yyyymmdd_hhmmss}.zip.
+func isExportArchiveName(name, database string) bool {
+	stamp, ok := strings.CutPrefix(name, database+"_")
+	if !ok {
+		return false
+	}
+	stamp, ok = strings.CutSuffix(stamp, ".zip")
+	if !ok {
+		return false
+	}
+	_, err := time.Parse(exportTimestampLayout, stamp)
+	return err == nil
+}
+
+// latestExportDir returns the most recent export directory of database among
+// object keys, using the timestamp in directory names of the form
+// {database}_{yyyymmdd_hhmmss}. Only directories containing a metadata file
+// are considered.
+func latestExportDir(keys []string, database string) (string, bool) {
+	var latestDir string
+	var latestTime time.Time
+	for _, key := range keys {
+		if !IsMetadataFile(path.Base(key)) {
+			continue
+		}
+		dir := path.Dir(key)
+		name, ok := strings.CutPrefix(path.Base(dir), database+"_")
+		if !ok {
+			continue
+		}
+		ts, err := time.Parse(exportTimestampLayout, name)
+		if err != nil {
+			continue
+		}
+		if latestDir == "" || ts.After(latestTime) {
+			latestDir, latestTime = dir, ts
+		}
+	}
+	return latestDir, latestDir != ""
+}
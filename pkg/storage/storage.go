@@ -5,14 +5,22 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
+	gcs "cloud.google.com/go/storage"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 type Storage interface {
@@ -22,6 +30,36 @@ type Storage interface {
 	GetLatestZipFile() (string, error)
 }
 
+// StreamUploader is implemented by storages that can upload from a reader
+// without holding the whole file in memory.
+type StreamUploader interface {
+	UploadStream(r io.Reader, size int64, filename string) error
+}
+
+// Deleter is implemented by storages that can delete objects in bulk.
+type Deleter interface {
+	DeleteObjects(filenames []string) error
+}
+
+// ExportBrowser is implemented by storages that can find the most recent
+// export directory of a database, named {database}_{yyyymmdd_hhmmss}.
+type ExportBrowser interface {
+	GetLatestExportPath(prefix, database string) (string, error)
+}
+
+// ExportFile is an export archive found in storage.
+type ExportFile struct {
+	Name        string
+	Size        int64
+	CreatedTime time.Time
+}
+
+// ExportFileLister is implemented by storages that can list the export
+// archives of a database, named {database}_{yyyymmdd_hhmmss}.zip.
+type ExportFileLister interface {
+	ListExportsByDatabase(database string) ([]ExportFile, error)
+}
+
 type localStorage struct {
 	path string
 }
@@ -75,7 +113,112 @@ func NewLocalStorage(path string) Storage {
 	return &localStorage{path: path}
 }
 
+const (
+	// DefaultS3MultipartThreshold is the object size above which uploads switch to multipart.
+	DefaultS3MultipartThreshold int64 = 100 * 1024 * 1024
+	// DefaultS3PartSize is the size of each part in a multipart upload.
+	DefaultS3PartSize int64 = 32 * 1024 * 1024
+	// MinS3PartSize is the smallest part size S3 accepts (except for the last part).
+	MinS3PartSize int64 = 5 * 1024 * 1024
+)
+
+// S3UploadOptions controls when and how uploads are split into multipart
+// uploads, and the storage class, tags and encryption of the uploaded objects.
+type S3UploadOptions struct {
+	MultipartThreshold int64
+	PartSize           int64
+	StorageClass       string      // Empty means the bucket default, STANDARD unless configured otherwise
+	Tags               []types.Tag // Object tags, e.g. for lifecycle rules and cost allocation
+	KMSKeyID           string      // AWS KMS key used for server-side encryption, empty for the bucket default
+}
+
+// maxS3Tags is the number of tags S3 allows on an object
+const maxS3Tags = 10
+
+// ParseS3Tags parses a comma-separated list of Key=Value object tags, e.g.
+// "Environment=prod,CostCenter=db-backups". Values may be empty.
+func ParseS3Tags(spec string) ([]types.Tag, error) {
+	var tags []types.Tag
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid S3 tag %q: expected Key=Value", pair)
+		}
+		if len(key) > 128 || len(value) > 256 {
+			return nil, fmt.Errorf("invalid S3 tag %q: keys are limited to 128 and values to 256 characters", pair)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate S3 tag %q", key)
+		}
+		seen[key] = true
+		tags = append(tags, types.Tag{Key: aws.String(key), Value: aws.String(strings.TrimSpace(value))})
+	}
+	if len(tags) > maxS3Tags {
+		return nil, fmt.Errorf("too many S3 tags: %d (S3 allows at most %d per object)", len(tags), maxS3Tags)
+	}
+	return tags, nil
+}
+
+// s3Tagging returns tags in the URL-encoded form of the x-amz-tagging header,
+// Key=Value&Key2=Value2, or nil without tags.
+func s3Tagging(tags []types.Tag) *string {
+	if len(tags) == 0 {
+		return nil
+	}
+	pairs := make([]string, len(tags))
+	for i, tag := range tags {
+		pairs[i] = url.QueryEscape(aws.ToString(tag.Key)) + "=" + url.QueryEscape(aws.ToString(tag.Value))
+	}
+	return aws.String(strings.Join(pairs, "&"))
+}
+
+// ValidateS3StorageClass returns an error if class is not empty and not one of
+// the storage classes known to the S3 API.
+func ValidateS3StorageClass(class string) error {
+	if class == "" {
+		return nil
+	}
+	valid := types.StorageClassStandard.Values()
+	for _, v := range valid {
+		if string(v) == class {
+			return nil
+		}
+	}
+	names := make([]string, len(valid))
+	for i, v := range valid {
+		names[i] = string(v)
+	}
+	return fmt.Errorf("invalid S3 storage class %q (valid values: %s)", class, strings.Join(names, ", "))
+}
+
+// s3API is the subset of the S3 client used by s3Storage, allowing it to be mocked in tests.
+type s3API interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error)
+	UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error)
+	CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error)
+	DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error)
+	PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error)
+}
+
 func NewS3Storage(bucket, region string) Storage {
+	return NewS3StorageWithOptions(bucket, region, S3UploadOptions{
+		MultipartThreshold: DefaultS3MultipartThreshold,
+		PartSize:           DefaultS3PartSize,
+	})
+}
+
+// NewS3StorageWithOptions creates an S3 storage using the given multipart upload settings.
+func NewS3StorageWithOptions(bucket, region string, opts S3UploadOptions) Storage {
 	cfg, err := config.LoadDefaultConfig(context.Background(),
 		config.WithRegion(region),
 	)
@@ -93,24 +236,136 @@ func NewS3Storage(bucket, region string) Storage {
 	return &s3Storage{
 		client: s3Client,
 		bucket: bucket,
+		opts:   opts,
 	}
 }
 
 type s3Storage struct {
-	client *s3.Client
+	client s3API
 	bucket string
+	opts   S3UploadOptions
 }
 
-func (s *s3Storage) Upload(data []byte, filename string) error {
+// putObjectInput returns the PutObject request of an object, with the
+// storage class, tags and encryption of the options.
+func (s *s3Storage) putObjectInput(filename string, body io.Reader) *s3.PutObjectInput {
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(filename),
-		Body:   bytes.NewReader(data),
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(filename),
+		Body:         body,
+		StorageClass: types.StorageClass(s.opts.StorageClass),
+		Tagging:      s3Tagging(s.opts.Tags),
+	}
+	if s.opts.KMSKeyID != "" {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(s.opts.KMSKeyID)
 	}
-	_, err := s.client.PutObject(context.Background(), input)
+	return input
+}
+
+func (s *s3Storage) Upload(data []byte, filename string) error {
+	_, err := s.client.PutObject(context.Background(), s.putObjectInput(filename, bytes.NewReader(data)))
 	return err
 }
 
+// UploadStream uploads size bytes from r, using a multipart upload when size
+// exceeds the configured threshold so the file never has to fit in memory.
+func (s *s3Storage) UploadStream(r io.Reader, size int64, filename string) error {
+	if size <= s.opts.MultipartThreshold {
+		input := s.putObjectInput(filename, r)
+		input.ContentLength = aws.Int64(size)
+		_, err := s.client.PutObject(context.Background(), input)
+		return err
+	}
+	return s.uploadMultipart(r, filename)
+}
+
+func (s *s3Storage) uploadMultipart(r io.Reader, filename string) error {
+	ctx := context.Background()
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:       aws.String(s.bucket),
+		Key:          aws.String(filename),
+		StorageClass: types.StorageClass(s.opts.StorageClass),
+	}
+	if s.opts.KMSKeyID != "" {
+		input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+		input.SSEKMSKeyId = aws.String(s.opts.KMSKeyID)
+	}
+	created, err := s.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	uploadID := created.UploadId
+
+	abort := func(cause error) error {
+		_, abortErr := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(filename),
+			UploadId: uploadID,
+		})
+		if abortErr != nil {
+			return fmt.Errorf("%w (additionally, aborting the multipart upload failed: %v)", cause, abortErr)
+		}
+		return cause
+	}
+
+	var completed []types.CompletedPart
+	buf := make([]byte, s.opts.PartSize)
+	for partNumber := int32(1); ; partNumber++ {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil && readErr != io.ErrUnexpectedEOF {
+			return abort(fmt.Errorf("failed to read part %d: %w", partNumber, readErr))
+		}
+
+		part, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:        aws.String(s.bucket),
+			Key:           aws.String(filename),
+			UploadId:      uploadID,
+			PartNumber:    aws.Int32(partNumber),
+			Body:          bytes.NewReader(buf[:n]),
+			ContentLength: aws.Int64(int64(n)),
+		})
+		if err != nil {
+			return abort(fmt.Errorf("failed to upload part %d: %w", partNumber, err))
+		}
+		completed = append(completed, types.CompletedPart{
+			ETag:       part.ETag,
+			PartNumber: aws.Int32(partNumber),
+		})
+
+		if readErr == io.ErrUnexpectedEOF {
+			break // Last, short part
+		}
+	}
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(filename),
+		UploadId:        uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to complete multipart upload: %w", err))
+	}
+
+	// Tags are set once the object exists
+	if len(s.opts.Tags) > 0 {
+		_, err = s.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+			Bucket:  aws.String(s.bucket),
+			Key:     aws.String(filename),
+			Tagging: &types.Tagging{TagSet: s.opts.Tags},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to tag %s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
 func (s *s3Storage) Download(filename string) ([]byte, error) {
 	input := &s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
@@ -159,6 +414,35 @@ func (s *s3Storage) ListObjects(prefix string) ([]string, error) {
 	return files, nil
 }
 
+// DeleteObjects deletes the given keys, batching requests at the S3 limit of 1000 keys.
+func (s *s3Storage) DeleteObjects(filenames []string) error {
+	const batchSize = 1000
+	for start := 0; start < len(filenames); start += batchSize {
+		end := start + batchSize
+		if end > len(filenames) {
+			end = len(filenames)
+		}
+
+		var objects []types.ObjectIdentifier
+		for _, name := range filenames[start:end] {
+			objects = append(objects, types.ObjectIdentifier{Key: aws.String(name)})
+		}
+
+		output, err := s.client.DeleteObjects(context.Background(), &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return err
+		}
+		if len(output.Errors) > 0 {
+			first := output.Errors[0]
+			return fmt.Errorf("failed to delete %d objects (first: %s: %s)", len(output.Errors), aws.ToString(first.Key), aws.ToString(first.Message))
+		}
+	}
+	return nil
+}
+
 func (s *s3Storage) GetLatestZipFile() (string, error) {
 	// List all zip files in the bucket
 	files, err := s.ListObjects("")
@@ -182,15 +466,45 @@ func (s *s3Storage) GetLatestZipFile() (string, error) {
 	return latestZip, nil
 }
 
+// GetLatestExportPath returns the key prefix of the most recent export
+// directory of database under prefix, e.g. "backups/mydb_20240101_120000".
+// Only directories containing a metadata file are considered.
+func (s *s3Storage) GetLatestExportPath(prefix, database string) (string, error) {
+	keys, err := s.ListObjects(prefix)
+	if err != nil {
+		return "", err
+	}
+	latest, ok := latestExportDir(keys, database)
+	if !ok {
+		return "", fmt.Errorf("no exports of %s found in s3://%s/%s", database, s.bucket, prefix)
+	}
+	return latest, nil
+}
+
 type gdriveStorage struct {
 	service    *drive.Service
 	folderId   string
 	fileFields string
+
+	httpClient *http.Client // Authorized client for resumable uploads
+	uploadURL  string
+	opts       GDriveUploadOptions
+	retryDelay time.Duration
+	sessions   map[string]gdriveSession // Unfinished resumable uploads, by filename
 }
 
 func NewGoogleDriveStorage(credentialsFile string, folderId string) (Storage, error) {
+	return NewGoogleDriveStorageWithOptions(credentialsFile, folderId, GDriveUploadOptions{ChunkSize: DefaultGDriveChunkSize})
+}
+
+// NewGoogleDriveStorageWithOptions creates a Google Drive storage using the given upload settings.
+func NewGoogleDriveStorageWithOptions(credentialsFile string, folderId string, opts GDriveUploadOptions) (Storage, error) {
 	ctx := context.Background()
-	srv, err := drive.NewService(ctx, option.WithCredentialsFile(credentialsFile))
+	client, _, err := htransport.NewClient(ctx, option.WithCredentialsFile(credentialsFile), option.WithScopes(drive.DriveScope))
+	var srv *drive.Service
+	if err == nil {
+		srv, err = drive.NewService(ctx, option.WithHTTPClient(client))
+	}
 	if err != nil {
 		fmt.Printf("Error creating Google Drive service: %v\n", err)
 		fmt.Println("Please ensure:")
@@ -199,11 +513,20 @@ func NewGoogleDriveStorage(credentialsFile string, folderId string) (Storage, er
 		fmt.Println("  3. The Google Drive API is enabled in your project")
 		return nil, err
 	}
+	sessions, err := loadGDriveSessions(opts.SessionDir)
+	if err != nil {
+		fmt.Printf("Warning: ignoring saved resumable upload sessions: %v\n", err)
+	}
 
 	return &gdriveStorage{
 		service:    srv,
 		folderId:   folderId,
 		fileFields: "id, name, createdTime",
+		httpClient: client,
+		uploadURL:  gdriveResumableUploadURL,
+		opts:       opts,
+		retryDelay: defaultGDriveRetryDelay,
+		sessions:   sessions,
 	}, nil
 }
 
@@ -214,6 +537,16 @@ func (g *gdriveStorage) Upload(data []byte, filename string) error {
 	}
 
 	fmt.Printf("Starting upload of %s to Google Drive folder %s...\n", filename, g.folderId)
+	if len(data) > gdriveResumableThreshold {
+		// Large files are sent in chunks that can be retried and resumed
+		fileID, err := g.uploadResumable(data, filename)
+		if err != nil {
+			fmt.Printf("Failed to upload %s to Google Drive: %v\n", filename, err)
+			return err
+		}
+		fmt.Printf("Successfully uploaded %s to Google Drive (File ID: %s)\n", filename, fileID)
+		return nil
+	}
 	reader := bytes.NewReader(data)
 	file, err := g.service.Files.Create(f).Media(reader).Do()
 	if err != nil {
@@ -302,3 +635,169 @@ func (g *gdriveStorage) GetLatestZipFile() (string, error) {
 
 	return fileList.Files[0].Name, nil
 }
+
+// GetLatestExportPath returns the name of the most recent export directory of
+// database in the folder. Directory exports are uploaded as files named
+// {export}/{file}, so prefix is not used.
+func (g *gdriveStorage) GetLatestExportPath(prefix, database string) (string, error) {
+	names, err := g.ListObjects(database + "_")
+	if err != nil {
+		return "", err
+	}
+	latest, ok := latestExportDir(names, database)
+	if !ok {
+		return "", fmt.Errorf("no exports of %s found in Google Drive folder %s", database, g.folderId)
+	}
+	return latest, nil
+}
+
+// ListExportsByDatabase returns the export archives of database in the folder,
+// named {database}_{yyyymmdd_hhmmss}.zip, the most recent first.
+func (g *gdriveStorage) ListExportsByDatabase(database string) ([]ExportFile, error) {
+	query := fmt.Sprintf("name contains '%s_' and '%s' in parents and trashed = false",
+		database, g.folderId)
+
+	var exports []ExportFile
+	pageToken := ""
+	for {
+		fileList, err := g.service.Files.List().
+			Q(query).
+			Fields("nextPageToken, files(name, size, createdTime)").
+			PageToken(pageToken).
+			Do()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range fileList.Files {
+			if !isExportArchiveName(file.Name, database) {
+				continue
+			}
+			createdTime, _ := time.Parse(time.RFC3339, file.CreatedTime)
+			exports = append(exports, ExportFile{Name: file.Name, Size: file.Size, CreatedTime: createdTime})
+		}
+
+		pageToken = fileList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	// The names differ only in their timestamp, so they sort by time
+	sort.Slice(exports, func(i, j int) bool { return exports[i].Name > exports[j].Name })
+	return exports, nil
+}
+
+type gcsStorage struct {
+	client    *gcs.Client
+	bucket    *gcs.BucketHandle
+	name      string
+	projectID string
+}
+
+// NewGCSStorage creates a Google Cloud Storage backed Storage. When
+// credentialsFile is empty, Application Default Credentials are used.
+func NewGCSStorage(bucket, projectID, credentialsFile string) (Storage, error) {
+	ctx := context.Background()
+
+	var opts []option.ClientOption
+	if credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsFile))
+	}
+
+	client, err := gcs.NewClient(ctx, opts...)
+	if err != nil {
+		fmt.Printf("Error creating Google Cloud Storage client: %v\n", err)
+		fmt.Println("Please ensure either:")
+		fmt.Println("  1. A valid service account credentials file is provided via --gcs-credentials")
+		fmt.Println("  2. Application Default Credentials are configured (gcloud auth application-default login)")
+		return nil, err
+	}
+
+	// Retry every operation, including uploads, using the client's built-in backoff.
+	handle := client.Bucket(bucket).Retryer(gcs.WithPolicy(gcs.RetryAlways))
+
+	return &gcsStorage{
+		client:    client,
+		bucket:    handle,
+		name:      bucket,
+		projectID: projectID,
+	}, nil
+}
+
+func (g *gcsStorage) Upload(data []byte, filename string) error {
+	w := g.bucket.Object(filename).NewWriter(context.Background())
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (g *gcsStorage) Download(filename string) ([]byte, error) {
+	r, err := g.bucket.Object(filename).NewReader(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func (g *gcsStorage) ListObjects(prefix string) ([]string, error) {
+	var files []string
+
+	// Ensure prefix ends with / if not empty
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	it := g.bucket.Objects(context.Background(), &gcs.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, attrs.Name)
+	}
+
+	return files, nil
+}
+
+// GetLatestZipFile returns the most recently created .zip object in the
+// bucket. Objects created at the same time are ordered by name.
+func (g *gcsStorage) GetLatestZipFile() (string, error) {
+	query := &gcs.Query{}
+	if err := query.SetAttrSelection([]string{"Name", "Created"}); err != nil {
+		return "", err
+	}
+
+	var latestZip string
+	var latestCreated time.Time
+	it := g.bucket.Objects(context.Background(), query)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasSuffix(attrs.Name, ".zip") {
+			continue
+		}
+		if latestZip == "" || attrs.Created.After(latestCreated) ||
+			(attrs.Created.Equal(latestCreated) && attrs.Name > latestZip) {
+			latestZip, latestCreated = attrs.Name, attrs.Created
+		}
+	}
+
+	if latestZip == "" {
+		return "", fmt.Errorf("no zip files found in GCS bucket %s", g.name)
+	}
+
+	return latestZip, nil
+}
@@ -0,0 +1,416 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/option"
+)
+
+// mockS3 records multipart API calls made by s3Storage.
+type mockS3 struct {
+	putObjects  int
+	partSizes   []int
+	completed   int
+	aborted     int
+	failOnPart  int32
+	uploadedLen int
+
+	deleteBatches []int
+
+	keys []string // Keys returned by ListObjectsV2, filtered by prefix
+
+	storageClasses []types.StorageClass // Storage class of each PutObject and CreateMultipartUpload call
+
+	putInputs       []*s3.PutObjectInput
+	multipartInputs []*s3.CreateMultipartUploadInput
+	taggings        []*s3.PutObjectTaggingInput
+}
+
+func (m *mockS3) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.putObjects++
+	m.uploadedLen += len(data)
+	m.storageClasses = append(m.storageClasses, params.StorageClass)
+	m.putInputs = append(m.putInputs, params)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (m *mockS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockS3) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	output := &s3.ListObjectsV2Output{}
+	for _, key := range m.keys {
+		if strings.HasPrefix(key, aws.ToString(params.Prefix)) {
+			output.Contents = append(output.Contents, types.Object{Key: aws.String(key)})
+		}
+	}
+	return output, nil
+}
+
+func (m *mockS3) CreateMultipartUpload(ctx context.Context, params *s3.CreateMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CreateMultipartUploadOutput, error) {
+	m.storageClasses = append(m.storageClasses, params.StorageClass)
+	m.multipartInputs = append(m.multipartInputs, params)
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (m *mockS3) UploadPart(ctx context.Context, params *s3.UploadPartInput, optFns ...func(*s3.Options)) (*s3.UploadPartOutput, error) {
+	if m.failOnPart != 0 && *params.PartNumber == m.failOnPart {
+		return nil, errors.New("network error")
+	}
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.partSizes = append(m.partSizes, len(data))
+	m.uploadedLen += len(data)
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", *params.PartNumber))}, nil
+}
+
+func (m *mockS3) CompleteMultipartUpload(ctx context.Context, params *s3.CompleteMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.CompleteMultipartUploadOutput, error) {
+	m.completed++
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3) AbortMultipartUpload(ctx context.Context, params *s3.AbortMultipartUploadInput, optFns ...func(*s3.Options)) (*s3.AbortMultipartUploadOutput, error) {
+	m.aborted++
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func (m *mockS3) DeleteObjects(ctx context.Context, params *s3.DeleteObjectsInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectsOutput, error) {
+	m.deleteBatches = append(m.deleteBatches, len(params.Delete.Objects))
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func (m *mockS3) PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error) {
+	m.taggings = append(m.taggings, params)
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+func TestS3DeleteObjects(t *testing.T) {
+	mock := &mockS3{}
+	s := &s3Storage{client: mock, bucket: "bucket"}
+
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("backups/mydb_20240101_120000/%d.sql", i)
+	}
+	require.NoError(t, s.DeleteObjects(keys))
+	assert.Equal(t, []int{1000, 1000, 500}, mock.deleteBatches)
+}
+
+func TestS3UploadStream(t *testing.T) {
+	opts := S3UploadOptions{MultipartThreshold: 10, PartSize: 4}
+
+	t.Run("Small file uses PutObject", func(t *testing.T) {
+		mock := &mockS3{}
+		s := &s3Storage{client: mock, bucket: "bucket", opts: opts}
+
+		data := []byte("0123456789")
+		require.NoError(t, s.UploadStream(bytes.NewReader(data), int64(len(data)), "export.zip"))
+		assert.Equal(t, 1, mock.putObjects)
+		assert.Empty(t, mock.partSizes)
+	})
+
+	t.Run("Large file is split into parts", func(t *testing.T) {
+		mock := &mockS3{}
+		s := &s3Storage{client: mock, bucket: "bucket", opts: opts}
+
+		data := bytes.Repeat([]byte("x"), 18)
+		require.NoError(t, s.UploadStream(bytes.NewReader(data), int64(len(data)), "export.zip"))
+		assert.Equal(t, 0, mock.putObjects)
+		assert.Equal(t, []int{4, 4, 4, 4, 2}, mock.partSizes)
+		assert.Equal(t, len(data), mock.uploadedLen)
+		assert.Equal(t, 1, mock.completed)
+		assert.Equal(t, 0, mock.aborted)
+	})
+
+	t.Run("Failed part aborts the upload", func(t *testing.T) {
+		mock := &mockS3{failOnPart: 2}
+		s := &s3Storage{client: mock, bucket: "bucket", opts: opts}
+
+		data := bytes.Repeat([]byte("x"), 16)
+		err := s.UploadStream(bytes.NewReader(data), int64(len(data)), "export.zip")
+		assert.Error(t, err)
+		assert.Equal(t, 1, mock.aborted)
+		assert.Equal(t, 0, mock.completed)
+	})
+}
+
+func TestS3StorageClass(t *testing.T) {
+	mock := &mockS3{}
+	s := &s3Storage{client: mock, bucket: "bucket", opts: S3UploadOptions{MultipartThreshold: 10, PartSize: 5, StorageClass: "GLACIER"}}
+
+	require.NoError(t, s.Upload([]byte("metadata"), "0_metadata.json"))
+	require.NoError(t, s.UploadStream(bytes.NewReader([]byte("small")), 5, "small.zip"))
+	large := bytes.Repeat([]byte("x"), 12)
+	require.NoError(t, s.UploadStream(bytes.NewReader(large), int64(len(large)), "large.zip"))
+	assert.Equal(t, []types.StorageClass{types.StorageClassGlacier, types.StorageClassGlacier, types.StorageClassGlacier}, mock.storageClasses)
+
+	assert.NoError(t, ValidateS3StorageClass(""))
+	assert.NoError(t, ValidateS3StorageClass("DEEP_ARCHIVE"))
+	assert.ErrorContains(t, ValidateS3StorageClass("glacier"), "INTELLIGENT_TIERING")
+}
+
+func TestS3TagsAndKMS(t *testing.T) {
+	tags, err := ParseS3Tags("Environment=prod, CostCenter=db backups,Retention=30days")
+	require.NoError(t, err)
+	require.Len(t, tags, 3)
+
+	mock := &mockS3{}
+	s := &s3Storage{client: mock, bucket: "bucket", opts: S3UploadOptions{MultipartThreshold: 10, PartSize: 5, Tags: tags, KMSKeyID: "alias/backups"}}
+	require.NoError(t, s.Upload([]byte("metadata"), "0_metadata.json"))
+	require.NoError(t, s.UploadStream(bytes.NewReader([]byte("small")), 5, "small.zip"))
+	large := bytes.Repeat([]byte("x"), 12)
+	require.NoError(t, s.UploadStream(bytes.NewReader(large), int64(len(large)), "large.zip"))
+
+	require.Len(t, mock.putInputs, 2)
+	for _, input := range mock.putInputs {
+		assert.Equal(t, "Environment=prod&CostCenter=db+backups&Retention=30days", aws.ToString(input.Tagging))
+		assert.Equal(t, types.ServerSideEncryptionAwsKms, input.ServerSideEncryption)
+		assert.Equal(t, "alias/backups", aws.ToString(input.SSEKMSKeyId))
+	}
+	// Multipart uploads are encrypted when created and tagged once completed
+	require.Len(t, mock.multipartInputs, 1)
+	assert.Equal(t, types.ServerSideEncryptionAwsKms, mock.multipartInputs[0].ServerSideEncryption)
+	assert.Equal(t, "alias/backups", aws.ToString(mock.multipartInputs[0].SSEKMSKeyId))
+	require.Len(t, mock.taggings, 1)
+	assert.Equal(t, "large.zip", aws.ToString(mock.taggings[0].Key))
+	assert.Equal(t, tags, mock.taggings[0].Tagging.TagSet)
+
+	// Without options, nothing is set
+	mock = &mockS3{}
+	s = &s3Storage{client: mock, bucket: "bucket", opts: S3UploadOptions{MultipartThreshold: 10, PartSize: 5}}
+	require.NoError(t, s.Upload([]byte("metadata"), "0_metadata.json"))
+	require.NoError(t, s.UploadStream(bytes.NewReader(large), int64(len(large)), "large.zip"))
+	assert.Nil(t, mock.putInputs[0].Tagging)
+	assert.Empty(t, mock.putInputs[0].ServerSideEncryption)
+	assert.Empty(t, mock.multipartInputs[0].ServerSideEncryption)
+	assert.Empty(t, mock.taggings)
+
+	_, err = ParseS3Tags("Environment")
+	assert.ErrorContains(t, err, "expected Key=Value")
+	_, err = ParseS3Tags("a=1,a=2")
+	assert.ErrorContains(t, err, "duplicate S3 tag")
+	_, err = ParseS3Tags("a=1,b=2,c=3,d=4,e=5,f=6,g=7,h=8,i=9,j=10,k=11")
+	assert.ErrorContains(t, err, "too many S3 tags")
+	tags, err = ParseS3Tags("")
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestS3GetLatestExportPath(t *testing.T) {
+	client := &mockS3{keys: []string{
+		"backups/mydb_20240101_120000/0_metadata.json",
+		"backups/mydb_20240101_120000/1_users.sql",
+		"backups/mydb_20240301_080000/0_metadata.json",
+		"backups/mydb_20240301_080000/1_users.sql",
+		// Newer, but an archive rather than a directory
+		"backups/mydb_20240401_000000.zip",
+		// Newer, but incomplete without metadata
+		"backups/mydb_20240501_000000/1_users.sql",
+		"backups/otherdb_20240601_000000/0_metadata.json",
+		"backups/mydb_custom/0_metadata.json",
+		"archive/mydb_20241231_235959/0_metadata.json",
+	}}
+	store := &s3Storage{client: client, bucket: "bucket"}
+
+	latest, err := store.GetLatestExportPath("backups", "mydb")
+	require.NoError(t, err)
+	assert.Equal(t, "backups/mydb_20240301_080000", latest)
+
+	_, err = store.GetLatestExportPath("backups", "missing")
+	assert.EqualError(t, err, "no exports of missing found in s3://bucket/backups")
+}
+
+func TestGDriveListExportsByDatabase(t *testing.T) {
+	// Two pages of files.list results
+	pages := map[string]string{
+		"": `{"nextPageToken": "page-2", "files": [
+			{"name": "mydb_20240101_120000.zip", "size": "1024", "createdTime": "2024-01-01T12:00:05Z"},
+			{"name": "mydb_custom.zip", "size": "10"},
+			{"name": "mydb_20240301_080000.tar.gz", "size": "10"}]}`,
+		"page-2": `{"files": [
+			{"name": "mydb_20240301_080000.zip", "size": "2048", "createdTime": "2024-03-01T08:00:09Z"},
+			{"name": "mydb_2_20240401_000000.zip", "size": "10"}]}`,
+	}
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query().Get("q"))
+		page, ok := pages[r.URL.Query().Get("pageToken")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+	srv, err := drive.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	store := &gdriveStorage{service: srv, folderId: "folder-1"}
+
+	exports, err := store.ListExportsByDatabase("mydb")
+	require.NoError(t, err)
+	assert.Equal(t, []ExportFile{
+		{Name: "mydb_20240301_080000.zip", Size: 2048, CreatedTime: time.Date(2024, 3, 1, 8, 0, 9, 0, time.UTC)},
+		{Name: "mydb_20240101_120000.zip", Size: 1024, CreatedTime: time.Date(2024, 1, 1, 12, 0, 5, 0, time.UTC)},
+	}, exports)
+	assert.Equal(t, []string{
+		"name contains 'mydb_' and 'folder-1' in parents and trashed = false",
+		"name contains 'mydb_' and 'folder-1' in parents and trashed = false",
+	}, queries)
+}
+
+// fakeGCS implements the parts of the Cloud Storage JSON and XML APIs used by
+// gcsStorage: multipart uploads, object listing and downloads. Each upload is
+// created one minute after the previous one.
+type fakeGCS struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+	created map[string]time.Time
+	clock   time.Time
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/"+f.bucket+"/o":
+		_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || r.URL.Query().Get("uploadType") != "multipart" {
+			http.Error(w, "expected a multipart upload", http.StatusBadRequest)
+			return
+		}
+		parts := multipart.NewReader(r.Body, params["boundary"])
+		var object struct {
+			Name string `json:"name"`
+		}
+		metadata, err := parts.NextPart()
+		if err == nil {
+			err = json.NewDecoder(metadata).Decode(&object)
+		}
+		media, err2 := parts.NextPart()
+		if err != nil || err2 != nil {
+			http.Error(w, "invalid multipart upload", http.StatusBadRequest)
+			return
+		}
+		data, _ := io.ReadAll(media)
+		f.clock = f.clock.Add(time.Minute)
+		f.objects[object.Name] = data
+		f.created[object.Name] = f.clock
+		json.NewEncoder(w).Encode(f.attrs(object.Name))
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/") && strings.HasSuffix(r.URL.Path, "/o"):
+		// Other buckets are empty
+		prefix := r.URL.Query().Get("prefix")
+		var names []string
+		for name := range f.objects {
+			if r.URL.Path == "/storage/v1/b/"+f.bucket+"/o" && strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		items := make([]map[string]string, len(names))
+		for i, name := range names {
+			items[i] = f.attrs(name)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"kind": "storage#objects", "items": items})
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/"+f.bucket+"/"):
+		data, ok := f.objects[strings.TrimPrefix(r.URL.Path, "/"+f.bucket+"/")]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Write(data)
+	default:
+		// Not retried by the client, unlike 5xx responses
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+	}
+}
+
+func (f *fakeGCS) attrs(name string) map[string]string {
+	created := f.created[name].Format(time.RFC3339)
+	return map[string]string{
+		"kind":        "storage#object",
+		"bucket":      f.bucket,
+		"name":        name,
+		"size":        strconv.Itoa(len(f.objects[name])),
+		"timeCreated": created,
+		"updated":     created,
+	}
+}
+
+func TestGCSStorage(t *testing.T) {
+	fake := &fakeGCS{
+		bucket:  "backups",
+		objects: make(map[string][]byte),
+		created: make(map[string]time.Time),
+		clock:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	t.Setenv("STORAGE_EMULATOR_HOST", server.Listener.Addr().String())
+
+	store, err := NewGCSStorage("backups", "project", "")
+	require.NoError(t, err)
+
+	// Names do not sort by time: the latest upload is the first name
+	require.NoError(t, store.Upload([]byte("old"), "nightly.zip"))
+	require.NoError(t, store.Upload([]byte("schema"), "mydb_20240101_000000/0_schema.sql"))
+	require.NoError(t, store.Upload([]byte("new"), "manual.zip"))
+	assert.Equal(t, []byte("old"), fake.objects["nightly.zip"])
+
+	files, err := store.ListObjects("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"manual.zip", "mydb_20240101_000000/0_schema.sql", "nightly.zip"}, files)
+	files, err = store.ListObjects("mydb_20240101_000000")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mydb_20240101_000000/0_schema.sql"}, files)
+
+	latest, err := store.GetLatestZipFile()
+	require.NoError(t, err)
+	assert.Equal(t, "manual.zip", latest)
+
+	data, err := store.Download(latest)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new"), data)
+
+	// Uploading again makes the older name the latest
+	require.NoError(t, store.Upload([]byte("newer"), "nightly.zip"))
+	latest, err = store.GetLatestZipFile()
+	require.NoError(t, err)
+	assert.Equal(t, "nightly.zip", latest)
+
+	empty, err := NewGCSStorage("empty", "project", "")
+	require.NoError(t, err)
+	_, err = empty.GetLatestZipFile()
+	assert.ErrorContains(t, err, "no zip files found in GCS bucket empty")
+}